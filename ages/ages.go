@@ -0,0 +1,218 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package ages implements a collection of age ranges
+// (in million years)
+// of taxa and specimens,
+// used as tip dates for total-evidence dating.
+package ages
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/js-arias/phydata/names"
+)
+
+// An Ages is a collection of age ranges
+// of taxa and specimens.
+type Ages struct {
+	taxa map[string]*taxon
+}
+
+// New creates a new empty collection of ages.
+func New() *Ages {
+	return &Ages{
+		taxa: make(map[string]*taxon),
+	}
+}
+
+// Add adds an age range
+// (minimum and maximum age in million years)
+// for a taxon specimen.
+// If the specimen is empty,
+// the age range will be assigned to the taxon.
+// If the record is already defined,
+// the range will be replaced.
+func (a *Ages) Add(name, spec string, min, max float64) error {
+	name = names.Taxon(name)
+	if name == "" {
+		return nil
+	}
+	if min < 0 {
+		return fmt.Errorf("invalid minimum age %.6f", min)
+	}
+	if max < min {
+		return fmt.Errorf("maximum age %.6f is lesser than minimum age %.6f", max, min)
+	}
+
+	tx, ok := a.taxa[name]
+	if !ok {
+		tx = &taxon{
+			name:  name,
+			specs: make(map[string]*age),
+		}
+		a.taxa[name] = tx
+	}
+
+	spec = names.Specimen(spec)
+	r := &age{
+		min: min,
+		max: max,
+	}
+	if spec == "" {
+		if tx.age != nil {
+			r.ref = tx.age.ref
+			r.comment = tx.age.comment
+		}
+		tx.age = r
+		return nil
+	}
+	if prev, ok := tx.specs[spec]; ok {
+		r.ref = prev.ref
+		r.comment = prev.comment
+	}
+	tx.specs[spec] = r
+	return nil
+}
+
+// Range returns the age range of a record.
+// If the specimen is empty,
+// it returns the age range assigned to the taxon.
+func (a *Ages) Range(name, spec string) (min, max float64, ok bool) {
+	r := a.record(name, spec)
+	if r == nil {
+		return 0, 0, false
+	}
+	return r.min, r.max, true
+}
+
+// TaxonRange returns the age range of a taxon.
+// If the taxon does not have an assigned age,
+// it returns the range that includes the ages
+// of all the specimens of the taxon.
+func (a *Ages) TaxonRange(name string) (min, max float64, ok bool) {
+	tx, ok := a.taxa[names.Taxon(name)]
+	if !ok {
+		return 0, 0, false
+	}
+	if tx.age != nil {
+		return tx.age.min, tx.age.max, true
+	}
+	if len(tx.specs) == 0 {
+		return 0, 0, false
+	}
+
+	first := true
+	for _, r := range tx.specs {
+		if first {
+			min, max = r.min, r.max
+			first = false
+			continue
+		}
+		if r.min < min {
+			min = r.min
+		}
+		if r.max > max {
+			max = r.max
+		}
+	}
+	return min, max, true
+}
+
+// Taxa returns the taxa with age records.
+func (a *Ages) Taxa() []string {
+	taxa := make([]string, 0, len(a.taxa))
+	for _, tx := range a.taxa {
+		taxa = append(taxa, tx.name)
+	}
+	slices.Sort(taxa)
+	return taxa
+}
+
+// TaxSpec returns the specimens of a taxon
+// with age records.
+func (a *Ages) TaxSpec(name string) []string {
+	tx, ok := a.taxa[names.Taxon(name)]
+	if !ok {
+		return nil
+	}
+
+	specs := make([]string, 0, len(tx.specs))
+	for sp := range tx.specs {
+		specs = append(specs, sp)
+	}
+	slices.Sort(specs)
+	return specs
+}
+
+// Field is used to define additional information fields
+// of an age record.
+type Field string
+
+// Additional age record fields.
+const (
+	Reference Field = "reference"
+	Comments  Field = "comments"
+)
+
+// Set sets the value of an additional information
+// for an age record.
+func (a *Ages) Set(name, spec, val string, field Field) {
+	r := a.record(name, spec)
+	if r == nil {
+		return
+	}
+
+	val = strings.Join(strings.Fields(val), " ")
+	switch field {
+	case Reference:
+		r.ref = val
+	case Comments:
+		r.comment = val
+	}
+}
+
+// Val returns the value of additional fields
+// for an age record.
+func (a *Ages) Val(name, spec string, field Field) string {
+	r := a.record(name, spec)
+	if r == nil {
+		return ""
+	}
+
+	switch field {
+	case Reference:
+		return r.ref
+	case Comments:
+		return r.comment
+	}
+	return ""
+}
+
+func (a *Ages) record(name, spec string) *age {
+	tx, ok := a.taxa[names.Taxon(name)]
+	if !ok {
+		return nil
+	}
+	spec = names.Specimen(spec)
+	if spec == "" {
+		return tx.age
+	}
+	return tx.specs[spec]
+}
+
+type taxon struct {
+	name  string
+	age   *age
+	specs map[string]*age
+}
+
+type age struct {
+	min     float64
+	max     float64
+	ref     string
+	comment string
+}
@@ -0,0 +1,102 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package ages_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/phydata/ages"
+)
+
+func TestAges(t *testing.T) {
+	a := newAges()
+
+	taxa := []string{"Ascaphus truei", "Prosalirus bitis", "Vieraella herbsti"}
+	if tx := a.Taxa(); !reflect.DeepEqual(tx, taxa) {
+		t.Errorf("taxa: got %v, want %v", tx, taxa)
+	}
+
+	specs := []string{"mcz_9023", "mnz_9734"}
+	if sp := a.TaxSpec("prosalirus bitis"); !reflect.DeepEqual(sp, specs) {
+		t.Errorf("specimens: got %v, want %v", sp, specs)
+	}
+
+	tests := map[string]struct {
+		taxon string
+		spec  string
+		min   float64
+		max   float64
+		ok    bool
+	}{
+		"taxon":    {"Vieraella herbsti", "", 188, 201, true},
+		"specimen": {"Prosalirus bitis", "MCZ 9023", 183, 190, true},
+		"no taxon": {"Prosalirus bitis", "", 0, 0, false},
+		"unknown":  {"Bufo bufo", "", 0, 0, false},
+	}
+	for name, test := range tests {
+		min, max, ok := a.Range(test.taxon, test.spec)
+		if min != test.min || max != test.max || ok != test.ok {
+			t.Errorf("%s: range: got %.1f-%.1f (%v), want %.1f-%.1f (%v)", name, min, max, ok, test.min, test.max, test.ok)
+		}
+	}
+
+	if min, max, ok := a.TaxonRange("Prosalirus bitis"); min != 180 || max != 190 || !ok {
+		t.Errorf("taxon range: got %.1f-%.1f (%v), want 180.0-190.0 (true)", min, max, ok)
+	}
+	if ref := a.Val("Vieraella herbsti", "", ages.Reference); ref != "baez1999" {
+		t.Errorf("reference: got %q, want %q", ref, "baez1999")
+	}
+
+	if err := a.Add("Bufo bufo", "", 10, 5); err == nil {
+		t.Errorf("invalid range: expecting error")
+	}
+}
+
+func TestTSV(t *testing.T) {
+	a := newAges()
+	var w bytes.Buffer
+	if err := a.TSV(&w); err != nil {
+		t.Fatalf("unable to write TSV data: %v", err)
+	}
+	t.Logf("output:\n%s\n", w.String())
+
+	got := ages.New()
+	if err := got.ReadTSV(&w); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+
+	if tx := got.Taxa(); !reflect.DeepEqual(tx, a.Taxa()) {
+		t.Errorf("taxa: got %v, want %v", tx, a.Taxa())
+	}
+	for _, tx := range a.Taxa() {
+		specs := append([]string{""}, a.TaxSpec(tx)...)
+		for _, sp := range specs {
+			gMin, gMax, gOk := got.Range(tx, sp)
+			wMin, wMax, wOk := a.Range(tx, sp)
+			if gMin != wMin || gMax != wMax || gOk != wOk {
+				t.Errorf("%s %s: range: got %.1f-%.1f (%v), want %.1f-%.1f (%v)", tx, sp, gMin, gMax, gOk, wMin, wMax, wOk)
+			}
+			for _, f := range []ages.Field{ages.Reference, ages.Comments} {
+				if v := got.Val(tx, sp, f); v != a.Val(tx, sp, f) {
+					t.Errorf("%s %s: field %q: got %q, want %q", tx, sp, f, v, a.Val(tx, sp, f))
+				}
+			}
+		}
+	}
+}
+
+func newAges() *ages.Ages {
+	a := ages.New()
+	a.Add("Ascaphus truei", "", 0, 0)
+	a.Add("Vieraella herbsti", "", 188, 201)
+	a.Add("Prosalirus bitis", "MCZ 9023", 183, 190)
+	a.Add("Prosalirus bitis", "MNZ 9734", 180, 185.5)
+
+	a.Set("Vieraella herbsti", "", "baez1999", ages.Reference)
+	a.Set("Prosalirus bitis", "mcz_9023", "Kayenta Formation", ages.Comments)
+	return a
+}
@@ -0,0 +1,155 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package ages
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var headerFields = []string{
+	"taxon",
+	"min",
+	"max",
+}
+
+var valFields = []Field{
+	Reference,
+	Comments,
+}
+
+// ReadTSV reads a collection of ages
+// from a TSV file.
+//
+// The TSV file must contains the following fields:
+//
+//   - taxon, the taxonomic name
+//   - min, the minimum age, in million years
+//   - max, the maximum age, in million years
+//
+// Additional fields are:
+//
+//   - specimen, the ID of the specimen,
+//     if empty,
+//     the age is assigned to the taxon
+//   - reference, a bibliographic reference for the dating
+//   - comments, simple comments about the age
+//
+// Here is an example file:
+//
+//	# ages
+//	taxon	specimen	min	max	reference	comments
+//	Ascaphus truei		0	0		living taxon
+//	Vieraella herbsti	mlp_64-iv-10	188	201	baez1999	Roca Blanca Formation
+func (a *Ages) ReadTSV(r io.Reader) error {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return fmt.Errorf("while reading header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(h)
+		fields[h] = i
+	}
+	for _, h := range headerFields {
+		if _, ok := fields[h]; !ok {
+			return fmt.Errorf("expecting field %q", h)
+		}
+	}
+
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		f := "taxon"
+		tax := row[fields[f]]
+		if tax == "" {
+			continue
+		}
+
+		var spec string
+		f = "specimen"
+		if i, ok := fields[f]; ok {
+			spec = row[i]
+		}
+
+		f = "min"
+		min, err := strconv.ParseFloat(strings.TrimSpace(row[fields[f]]), 64)
+		if err != nil {
+			return fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		f = "max"
+		max, err := strconv.ParseFloat(strings.TrimSpace(row[fields[f]]), 64)
+		if err != nil {
+			return fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		if err := a.Add(tax, spec, min, max); err != nil {
+			return fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		for _, ff := range valFields {
+			f = string(ff)
+			i, ok := fields[f]
+			if !ok {
+				continue
+			}
+			a.Set(tax, spec, row[i], ff)
+		}
+	}
+	return nil
+}
+
+// TSV writes a collection of ages as a TSV file.
+func (a *Ages) TSV(w io.Writer) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	header := []string{"taxon", "specimen", "min", "max", "reference", "comments"}
+	if err := tab.Write(header); err != nil {
+		return fmt.Errorf("unable to write header: %v", err)
+	}
+
+	for _, tax := range a.Taxa() {
+		specs := append([]string{""}, a.TaxSpec(tax)...)
+		for _, spec := range specs {
+			r := a.record(tax, spec)
+			if r == nil {
+				continue
+			}
+			row := []string{
+				tax,
+				spec,
+				strconv.FormatFloat(r.min, 'f', -1, 64),
+				strconv.FormatFloat(r.max, 'f', -1, 64),
+				r.ref,
+				r.comment,
+			}
+			if err := tab.Write(row); err != nil {
+				return fmt.Errorf("while writing data: %v", err)
+			}
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
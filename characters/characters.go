@@ -0,0 +1,339 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package characters implements a catalog
+// of character metadata,
+// such as the anatomical region of each character.
+package characters
+
+import (
+	"slices"
+	"strings"
+)
+
+// A Catalog is a collection of character records.
+type Catalog struct {
+	chars map[string]*character
+}
+
+// New creates a new empty catalog.
+func New() *Catalog {
+	return &Catalog{
+		chars: make(map[string]*character),
+	}
+}
+
+// Add adds a character to the catalog.
+// If the character is already in the catalog,
+// it will do nothing.
+func (c *Catalog) Add(name string) {
+	name = charName(name)
+	if name == "" {
+		return
+	}
+	if _, ok := c.chars[name]; ok {
+		return
+	}
+	c.chars[name] = &character{
+		name: name,
+	}
+}
+
+// Delete removes a character from the catalog.
+func (c *Catalog) Delete(name string) {
+	delete(c.chars, charName(name))
+}
+
+// Chars returns the characters defined in the catalog.
+func (c *Catalog) Chars() []string {
+	ls := make([]string, 0, len(c.chars))
+	for _, ch := range c.chars {
+		ls = append(ls, ch.name)
+	}
+	slices.Sort(ls)
+	return ls
+}
+
+// Regions returns the anatomical regions
+// defined in the catalog.
+func (c *Catalog) Regions() []string {
+	rs := make(map[string]bool)
+	for _, ch := range c.chars {
+		if ch.region == "" {
+			continue
+		}
+		rs[ch.region] = true
+	}
+	ls := make([]string, 0, len(rs))
+	for r := range rs {
+		ls = append(ls, r)
+	}
+	slices.Sort(ls)
+	return ls
+}
+
+// Order sorts a list of characters
+// grouped by its anatomical region.
+// Regions are sorted alphabetically,
+// and characters without a region,
+// or not in the catalog,
+// are placed at the end of the list.
+// Inside each region,
+// characters keep its relative order.
+func (c *Catalog) Order(chars []string) []string {
+	ls := slices.Clone(chars)
+	slices.SortStableFunc(ls, func(a, b string) int {
+		ra := c.Val(a, Region)
+		rb := c.Val(b, Region)
+		if ra == rb {
+			return 0
+		}
+		if ra == "" {
+			return 1
+		}
+		if rb == "" {
+			return -1
+		}
+		return strings.Compare(ra, rb)
+	})
+	return ls
+}
+
+// Field is used to define additional information fields
+// of a character.
+type Field string
+
+// Additional character fields.
+const (
+	// Region is the anatomical region
+	// (or system)
+	// of the character.
+	Region Field = "region"
+
+	// Ontology is the ID
+	// (e.g., 'UBERON:0001137'),
+	// or the IRI,
+	// of a term of an anatomy ontology.
+	Ontology Field = "ontology"
+
+	// DependsOn is the name of the character
+	// that controls the applicability of the character
+	// (e.g., 'tail color' depends on 'tail').
+	DependsOn Field = "depends-on"
+
+	// ApplicableIf are the states
+	// of the controlling character
+	// in which the character is applicable,
+	// separated by semicolons.
+	ApplicableIf Field = "applicable-if"
+
+	Comments Field = "comments"
+)
+
+// Set sets the value of an additional information
+// for a character.
+func (c *Catalog) Set(name, val string, field Field) {
+	ch, ok := c.chars[charName(name)]
+	if !ok {
+		return
+	}
+
+	val = strings.Join(strings.Fields(val), " ")
+	switch field {
+	case Region:
+		ch.region = strings.ToLower(val)
+	case Ontology:
+		ch.ontology = val
+	case DependsOn:
+		ch.dependsOn = charName(val)
+	case ApplicableIf:
+		ch.applicable = stateList(val)
+	case Comments:
+		ch.comment = val
+	}
+}
+
+// Val returns the value of additional fields
+// for a character.
+func (c *Catalog) Val(name string, field Field) string {
+	ch, ok := c.chars[charName(name)]
+	if !ok {
+		return ""
+	}
+
+	switch field {
+	case Region:
+		return ch.region
+	case Ontology:
+		return ch.ontology
+	case DependsOn:
+		return ch.dependsOn
+	case ApplicableIf:
+		return strings.Join(ch.applicable, "; ")
+	case Comments:
+		return ch.comment
+	}
+	return ""
+}
+
+// Dependency returns the character
+// that controls the applicability of a character,
+// and the states of the controlling character
+// in which the character is applicable.
+// If the character does not depend on another character,
+// it returns an empty string.
+func (c *Catalog) Dependency(char string) (parent string, states []string) {
+	ch, ok := c.chars[charName(char)]
+	if !ok || ch.dependsOn == "" {
+		return "", nil
+	}
+	return ch.dependsOn, slices.Clone(ch.applicable)
+}
+
+// SetState sets the ontology term
+// (e.g., a PATO quality)
+// of a character state.
+// If the character is not in the catalog,
+// it will be added.
+// If the term is empty,
+// the annotation of the state will be removed.
+func (c *Catalog) SetState(char, state, term string) {
+	state = charName(state)
+	if state == "" {
+		return
+	}
+	c.Add(char)
+	ch, ok := c.chars[charName(char)]
+	if !ok {
+		return
+	}
+
+	term = strings.Join(strings.Fields(term), " ")
+	if term == "" {
+		delete(ch.states, state)
+		return
+	}
+	if ch.states == nil {
+		ch.states = make(map[string]string)
+	}
+	ch.states[state] = term
+}
+
+// AddState adds a state to a character,
+// without an ontology term.
+// If the character is not in the catalog,
+// it will be added.
+// If the state is already defined,
+// it will do nothing.
+func (c *Catalog) AddState(char, state string) {
+	state = charName(state)
+	if state == "" {
+		return
+	}
+	c.Add(char)
+	ch, ok := c.chars[charName(char)]
+	if !ok {
+		return
+	}
+	if _, ok := ch.states[state]; ok {
+		return
+	}
+	if ch.states == nil {
+		ch.states = make(map[string]string)
+	}
+	ch.states[state] = ""
+}
+
+// States returns the defined states
+// of a character.
+func (c *Catalog) States(char string) []string {
+	ch, ok := c.chars[charName(char)]
+	if !ok {
+		return nil
+	}
+	ls := make([]string, 0, len(ch.states))
+	for s := range ch.states {
+		ls = append(ls, s)
+	}
+	slices.Sort(ls)
+	return ls
+}
+
+// StateVal returns the ontology term
+// of a character state.
+func (c *Catalog) StateVal(char, state string) string {
+	ch, ok := c.chars[charName(char)]
+	if !ok {
+		return ""
+	}
+	return ch.states[charName(state)]
+}
+
+// CharTerm returns the IRI
+// of the ontology term of a character.
+func (c *Catalog) CharTerm(char string) string {
+	return IRI(c.Val(char, Ontology))
+}
+
+// StateTerm returns the IRI
+// of the ontology term of a character state.
+func (c *Catalog) StateTerm(char, state string) string {
+	return IRI(c.StateVal(char, state))
+}
+
+// OBO is the prefix of the IRIs
+// of the OBO Foundry ontologies.
+const OBO = "http://purl.obolibrary.org/obo/"
+
+// IRI returns the IRI of an ontology term.
+// If the term is a compact ID
+// (e.g., 'PATO:0000467'),
+// it returns the IRI of the term
+// in the OBO Foundry
+// (e.g., 'http://purl.obolibrary.org/obo/PATO_0000467').
+func IRI(term string) string {
+	term = strings.TrimSpace(term)
+	if term == "" || strings.Contains(term, "://") {
+		return term
+	}
+	prefix, id, ok := strings.Cut(term, ":")
+	if !ok || prefix == "" || id == "" {
+		return term
+	}
+	return OBO + strings.ToUpper(prefix) + "_" + id
+}
+
+type character struct {
+	name     string
+	region   string
+	ontology string
+	comment  string
+	states   map[string]string
+
+	// character dependency
+	dependsOn  string
+	applicable []string
+}
+
+// StateList returns a sorted list of states
+// from a list of state names
+// separated by semicolons.
+func stateList(val string) []string {
+	var ls []string
+	for _, s := range strings.Split(val, ";") {
+		s = charName(s)
+		if s == "" || slices.Contains(ls, s) {
+			continue
+		}
+		ls = append(ls, s)
+	}
+	slices.Sort(ls)
+	return ls
+}
+
+// CharName returns a character name
+// in its canonical form.
+func charName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,194 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package characters_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/phydata/characters"
+)
+
+var catalogText = `# character metadata
+character	state	region	ontology	comments
+pectoral girdle		Appendicular skeleton	UBERON:0007831	
+ribs, fusion		axial skeleton	UBERON:0002228	
+ribs, fusion	Fused		PATO:0000642	
+Vertebral  ossification		axial skeleton		
+tail muscle			http://purl.obolibrary.org/obo/UBERON_0001630	only present in Ascaphus
+tail muscle	absent		PATO:0000462	
+`
+
+func TestReadTSV(t *testing.T) {
+	c := characters.New()
+	if err := c.ReadTSV(strings.NewReader(catalogText)); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+	cmpCatalog(t, c, newCatalog())
+}
+
+func TestWriteTSV(t *testing.T) {
+	c := newCatalog()
+	var w bytes.Buffer
+	if err := c.TSV(&w); err != nil {
+		t.Fatalf("unable to write TSV data: %v", err)
+	}
+	t.Logf("output:\n%s\n", w.String())
+
+	got := characters.New()
+	if err := got.ReadTSV(&w); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+	cmpCatalog(t, got, c)
+}
+
+func TestOrder(t *testing.T) {
+	c := newCatalog()
+
+	regions := []string{"appendicular skeleton", "axial skeleton"}
+	if r := c.Regions(); !reflect.DeepEqual(r, regions) {
+		t.Errorf("regions: got %v, want %v", r, regions)
+	}
+
+	chars := []string{"pectoral girdle", "ribs, fusion", "scapula, relation to clavical", "tail muscle", "vertebral ossification"}
+	want := []string{"pectoral girdle", "ribs, fusion", "vertebral ossification", "scapula, relation to clavical", "tail muscle"}
+	if got := c.Order(chars); !reflect.DeepEqual(got, want) {
+		t.Errorf("order: got %v, want %v", got, want)
+	}
+}
+
+func newCatalog() *characters.Catalog {
+	c := characters.New()
+	c.Add("pectoral girdle")
+	c.Set("pectoral girdle", "appendicular skeleton", characters.Region)
+	c.Set("pectoral girdle", "UBERON:0007831", characters.Ontology)
+	c.Add("ribs, fusion")
+	c.Set("ribs, fusion", "axial skeleton", characters.Region)
+	c.Set("ribs, fusion", "UBERON:0002228", characters.Ontology)
+	c.Add("vertebral ossification")
+	c.Set("vertebral ossification", "axial skeleton", characters.Region)
+	c.Add("tail muscle")
+	c.Set("tail muscle", "only present in Ascaphus", characters.Comments)
+	c.Set("tail muscle", "http://purl.obolibrary.org/obo/UBERON_0001630", characters.Ontology)
+	c.SetState("ribs, fusion", "fused", "PATO:0000642")
+	c.SetState("tail muscle", "absent", "PATO:0000462")
+	return c
+}
+
+func cmpCatalog(t testing.TB, got, want *characters.Catalog) {
+	t.Helper()
+
+	chars := want.Chars()
+	if c := got.Chars(); !reflect.DeepEqual(c, chars) {
+		t.Errorf("characters: got %v, want %v", c, chars)
+	}
+
+	fields := []characters.Field{characters.Region, characters.Ontology, characters.Comments}
+	for _, c := range chars {
+		for _, f := range fields {
+			if v, w := got.Val(c, f), want.Val(c, f); v != w {
+				t.Errorf("character %q [%q]: got %q, want %q", c, f, v, w)
+			}
+		}
+
+		states := want.States(c)
+		if s := got.States(c); !reflect.DeepEqual(s, states) {
+			t.Errorf("character %q states: got %v, want %v", c, s, states)
+		}
+		for _, s := range states {
+			if v, w := got.StateVal(c, s), want.StateVal(c, s); v != w {
+				t.Errorf("character %q state %q: got %q, want %q", c, s, v, w)
+			}
+		}
+	}
+}
+
+func TestTerms(t *testing.T) {
+	c := newCatalog()
+
+	tests := map[string]struct {
+		got  string
+		want string
+	}{
+		"character id":  {c.CharTerm("ribs, fusion"), "http://purl.obolibrary.org/obo/UBERON_0002228"},
+		"character iri": {c.CharTerm("tail muscle"), "http://purl.obolibrary.org/obo/UBERON_0001630"},
+		"state":         {c.StateTerm("ribs, fusion", "fused"), "http://purl.obolibrary.org/obo/PATO_0000642"},
+		"undefined":     {c.StateTerm("ribs, fusion", "free"), ""},
+		"no character":  {c.CharTerm("scapula"), ""},
+	}
+	for name, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s: got %q, want %q", name, test.got, test.want)
+		}
+	}
+}
+
+func TestDependency(t *testing.T) {
+	blob := `character	depends-on	applicable-if
+tail color	Tail	Present
+tail shape	tail	present; Vestigial
+tail		
+`
+	c := characters.New()
+	if err := c.ReadTSV(strings.NewReader(blob)); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+
+	tests := map[string]struct {
+		parent string
+		states []string
+	}{
+		"tail color": {"tail", []string{"present"}},
+		"tail shape": {"tail", []string{"present", "vestigial"}},
+		"tail":       {"", nil},
+	}
+	for name, test := range tests {
+		parent, states := c.Dependency(name)
+		if parent != test.parent {
+			t.Errorf("%s: parent: got %q, want %q", name, parent, test.parent)
+		}
+		if !reflect.DeepEqual(states, test.states) {
+			t.Errorf("%s: states: got %v, want %v", name, states, test.states)
+		}
+	}
+
+	var w bytes.Buffer
+	if err := c.TSV(&w); err != nil {
+		t.Fatalf("unable to write TSV data: %v", err)
+	}
+	got := characters.New()
+	if err := got.ReadTSV(&w); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+	if v := got.Val("tail shape", characters.ApplicableIf); v != "present; vestigial" {
+		t.Errorf("TSV: got %q, want %q", v, "present; vestigial")
+	}
+}
+
+func TestAddState(t *testing.T) {
+	c := characters.New()
+	c.AddState("Tail muscle", "Present")
+	c.SetState("tail muscle", "absent", "PATO:0000462")
+	c.AddState("tail muscle", "absent")
+
+	if got, want := c.States("tail muscle"), []string{"absent", "present"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("states: got %v, want %v", got, want)
+	}
+	if got := c.StateVal("tail muscle", "absent"); got != "PATO:0000462" {
+		t.Errorf("state term: got %q, want %q", got, "PATO:0000462")
+	}
+
+	var w bytes.Buffer
+	if err := c.TSV(&w); err != nil {
+		t.Fatalf("unable to write TSV data: %v", err)
+	}
+	got := characters.New()
+	if err := got.ReadTSV(&w); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+	cmpCatalog(t, got, c)
+}
@@ -0,0 +1,90 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package templates provides templates
+// of common suites of characters
+// (e.g., osteological characters of a group),
+// with the names and states of the characters,
+// so different projects can use
+// a consistent naming of the characters.
+//
+// Each template is a character metadata file
+// (see characters.Catalog.ReadTSV),
+// and the first line of the file
+// is a comment with the description of the template.
+package templates
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/js-arias/phydata/characters"
+)
+
+//go:embed *.tab
+var files embed.FS
+
+// Ext is the extension of the template files.
+const ext = ".tab"
+
+// Names returns the names of the available templates.
+func Names() []string {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil
+	}
+
+	var ls []string
+	for _, e := range entries {
+		if path.Ext(e.Name()) != ext {
+			continue
+		}
+		ls = append(ls, strings.TrimSuffix(e.Name(), ext))
+	}
+	slices.Sort(ls)
+	return ls
+}
+
+// Description returns the description of a template.
+func Description(name string) string {
+	f, err := files.Open(fileName(name))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	r := bufio.NewScanner(f)
+	if !r.Scan() {
+		return ""
+	}
+	ln := r.Text()
+	if !strings.HasPrefix(ln, "#") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(ln, "#"))
+}
+
+// Read adds the characters of a template
+// to a catalog.
+func Read(name string, c *characters.Catalog) error {
+	f, err := files.Open(fileName(name))
+	if err != nil {
+		return fmt.Errorf("template %q not found", name)
+	}
+	defer f.Close()
+
+	if err := c.ReadTSV(f); err != nil {
+		return fmt.Errorf("template %q: %v", name, err)
+	}
+	return nil
+}
+
+func fileName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name)) + ext
+}
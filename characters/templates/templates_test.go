@@ -0,0 +1,59 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package templates_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/js-arias/phydata/characters"
+	"github.com/js-arias/phydata/characters/templates"
+)
+
+func TestTemplates(t *testing.T) {
+	names := templates.Names()
+	if len(names) == 0 {
+		t.Fatalf("no templates found")
+	}
+
+	for _, name := range names {
+		if templates.Description(name) == "" {
+			t.Errorf("template %q: without description", name)
+		}
+
+		c := characters.New()
+		if err := templates.Read(name, c); err != nil {
+			t.Errorf("template %q: unable to read: %v", name, err)
+			continue
+		}
+		chars := c.Chars()
+		if len(chars) == 0 {
+			t.Errorf("template %q: without characters", name)
+		}
+		for _, ch := range chars {
+			states := c.States(ch)
+			if len(states) < 2 {
+				t.Errorf("template %q: character %q: got %d states, want at least 2", name, ch, len(states))
+			}
+			parent, app := c.Dependency(ch)
+			if parent == "" {
+				continue
+			}
+			if !slices.Contains(chars, parent) {
+				t.Errorf("template %q: character %q: undefined controlling character %q", name, ch, parent)
+				continue
+			}
+			for _, s := range app {
+				if !slices.Contains(c.States(parent), s) {
+					t.Errorf("template %q: character %q: undefined state %q of %q", name, ch, s, parent)
+				}
+			}
+		}
+	}
+
+	if err := templates.Read("unknown", characters.New()); err == nil {
+		t.Errorf("unknown template: expecting error")
+	}
+}
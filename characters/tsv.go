@@ -0,0 +1,171 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package characters
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var headerFields = []string{
+	"character",
+}
+
+var valFields = []Field{
+	Region,
+	Ontology,
+	DependsOn,
+	ApplicableIf,
+	Comments,
+}
+
+// ReadTSV reads a catalog of characters
+// from a TSV file.
+//
+// The TSV file must contains the following fields:
+//
+//   - character, the name of the character
+//
+// Additional fields are:
+//
+//   - state, a state of the character
+//   - region, the anatomical region (or system) of the character
+//   - ontology, the ID (or IRI) of a term of an anatomy ontology
+//   - depends-on, the character that controls
+//     the applicability of the character
+//   - applicable-if, the states of the controlling character
+//     (separated by semicolons)
+//     in which the character is applicable
+//   - comments, simple comments about the character
+//
+// If the state field is defined,
+// the row is a state of the character,
+// and the ontology field
+// is the ID (or IRI) of a term
+// of a phenotype quality ontology
+// (e.g., PATO).
+//
+// Here is an example file:
+//
+//	# character metadata
+//	character	state	region	ontology	comments
+//	pectoral girdle		appendicular skeleton	UBERON:0007831
+//	ribs, fusion		axial skeleton	UBERON:0002228
+//	ribs, fusion	fused		PATO:0000642
+//	tail muscle		musculature		only present in Ascaphus
+//	tail muscle	absent		PATO:0000462
+func (c *Catalog) ReadTSV(r io.Reader) error {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return fmt.Errorf("while reading header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(h)
+		fields[h] = i
+	}
+	for _, h := range headerFields {
+		if _, ok := fields[h]; !ok {
+			return fmt.Errorf("expecting field %q", h)
+		}
+	}
+
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		f := "character"
+		name := row[fields[f]]
+		if name == "" {
+			continue
+		}
+		c.Add(name)
+
+		if i, ok := fields["state"]; ok && row[i] != "" {
+			if j, ok := fields[string(Ontology)]; ok && row[j] != "" {
+				c.SetState(name, row[i], row[j])
+				continue
+			}
+			c.AddState(name, row[i])
+			continue
+		}
+
+		for _, ff := range valFields {
+			f = string(ff)
+			i, ok := fields[f]
+			if !ok {
+				continue
+			}
+			v := row[i]
+			if v == "" {
+				continue
+			}
+			c.Set(name, v, ff)
+		}
+	}
+	return nil
+}
+
+// TSV writes a catalog of characters as a TSV file.
+func (c *Catalog) TSV(w io.Writer) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	header := []string{"character", "state", "region", "ontology", "depends-on", "applicable-if", "comments"}
+	if err := tab.Write(header); err != nil {
+		return fmt.Errorf("unable to write header: %v", err)
+	}
+
+	for _, name := range c.Chars() {
+		ch := c.chars[name]
+		row := []string{
+			ch.name,
+			"",
+			ch.region,
+			ch.ontology,
+			ch.dependsOn,
+			strings.Join(ch.applicable, "; "),
+			ch.comment,
+		}
+		if err := tab.Write(row); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+
+		for _, st := range c.States(name) {
+			row := []string{
+				ch.name,
+				st,
+				"",
+				ch.states[st],
+				"",
+				"",
+				"",
+			}
+			if err := tab.Write(row); err != nil {
+				return fmt.Errorf("while writing data: %v", err)
+			}
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package add implements a command to add age ranges
+// to a PhyData project.
+package add
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/ages"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `add [-f|--file <ages-file>]
+	<project-file> <ages-data-file>`,
+	Short: "add age ranges to a project",
+	Long: `
+Command add reads a file with age ranges of taxa or specimens, and add the
+ages to a PhyData project.
+
+The first argument of the command is the name of the project file. If no
+project file exists, a new project will be created.
+
+The second argument is the name of the file that contains the ages. It must be
+a TSV file with the fields 'taxon', 'min', and 'max' (the minimum and maximum
+age, in million years), and optionally, the fields 'specimen', 'reference'
+(the reference of the dating), and 'comments'. If the specimen field is empty,
+the age will be assigned to the taxon. If a record is already in the project,
+it will be replaced by the values in the file.
+
+By default, the ages will be stored in the ages file currently defined for the
+project. If the project does not have an ages file, a new one will be created
+with the name 'ages.tab'. A different file name can be defined using the flag
+--file or -f. If this flag is given and there is an ages file already defined,
+then a new file will be created and used as the ages file for the project
+(previously defined records will be preserved).
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var agesFile string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&agesFile, "file", "", "")
+	c.Flags().StringVar(&agesFile, "f", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if len(args) < 2 {
+		return c.UsageError("expecting ages file")
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := openProject(pFile)
+	if err != nil {
+		return err
+	}
+
+	a := ages.New()
+	if af := p.Path(project.Ages); af != "" {
+		if err := readAgesFile(af, a); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+
+	in := args[1]
+	na := ages.New()
+	if err := readAgesFile(in, na); err != nil {
+		return err
+	}
+
+	fields := []ages.Field{ages.Reference, ages.Comments}
+	for _, tax := range na.Taxa() {
+		specs := append([]string{""}, na.TaxSpec(tax)...)
+		for _, spec := range specs {
+			min, max, ok := na.Range(tax, spec)
+			if !ok {
+				continue
+			}
+			if err := a.Add(tax, spec, min, max); err != nil {
+				return fmt.Errorf("when adding %q %q: %v", tax, spec, err)
+			}
+			for _, f := range fields {
+				a.Set(tax, spec, na.Val(tax, spec, f), f)
+			}
+		}
+	}
+
+	if agesFile == "" {
+		agesFile = p.Path(project.Ages)
+		if agesFile == "" {
+			agesFile = "ages.tab"
+		}
+	}
+	if p.ReadOnly(project.Ages, agesFile) {
+		return fmt.Errorf("on project %q: file %q is read-only", pFile, p.Source(project.Ages, agesFile))
+	}
+	if err := writeAges(agesFile, a); err != nil {
+		return err
+	}
+
+	p.Add(project.Ages, agesFile)
+	if err := p.Write(pFile); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func openProject(name string) (*project.Project, error) {
+	p, err := project.Read(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return project.New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable ot open project %q: %v", name, err)
+	}
+	return p, nil
+}
+
+func readAgesFile(name string, a *ages.Ages) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := a.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeAges(name string, a *ages.Ages) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: tip ages\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Ages))
+	if err := a.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
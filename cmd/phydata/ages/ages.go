@@ -0,0 +1,25 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package ages is a metapackage for commands
+// that dealt with the ages of taxa and specimens.
+package ages
+
+import (
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/ages/add"
+	"github.com/js-arias/phydata/cmd/phydata/ages/export"
+	"github.com/js-arias/phydata/cmd/phydata/ages/list"
+)
+
+func init() {
+	Command.Add(add.Command)
+	Command.Add(export.Command)
+	Command.Add(list.Command)
+}
+
+var Command = &command.Command{
+	Usage: "ages <command> [<argument>...]",
+	Short: "commands for tip ages",
+}
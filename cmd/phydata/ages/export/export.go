@@ -0,0 +1,232 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package export implements a command to export the age ranges
+// of a PhyData project
+// as tip dates for dating programs.
+package export
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/ages"
+	"github.com/js-arias/phydata/cmd/phydata/config"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `export [-f|--format <format>] [-o|--output <file>]
+	[--taxa <file>]
+	<project-file>`,
+	Short: "export tip ages",
+	Long: `
+Command export reads a PhyData project and writes the age ranges of the taxa
+in a format that can be used by dating programs.
+
+The argument of the command is the name of the project file.
+
+By default, the ages are written as BEAST tip dates. Use the flag -f or
+--format to define a format. Valid formats are:
+
+	beast    a tab-delimited file with the taxon name and its age,
+	         that can be imported as tip dates in BEAUti
+	         (the dates must be set as "before the present").
+	         As BEAST requires a single value,
+	         the midpoint of the age range is used.
+	mrbayes  a MrBayes block with the calibration of each taxon,
+	         using a uniform distribution for the age range,
+	         or a fixed value if the minimum and maximum ages are equal.
+	         Taxa with an age of 0 (living taxa) are omitted.
+
+A different default format can be defined with the key 'ages-format' of the
+configuration file (see 'phydata help config').
+
+The ages of a taxon are the ages assigned to the taxon, or the range that
+includes the ages of all its specimens.
+
+By default, the output is printed in the standard output. Use the flag
+--output, or -o, to define an output file.
+
+By default, all taxa with ages will be exported. If the flag --taxa is defined
+with a file, only the taxa in that file will be exported (e.g., the taxa file
+used to build a matrix). In the file each line will be read as a taxon name.
+Blank lines and lines starting with '#' will be ignored.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var format string
+var output string
+var taxaFile string
+
+func setFlags(c *command.Command) {
+	defFormat := config.Get(config.AgesFormat, "beast")
+	c.Flags().StringVar(&format, "format", defFormat, "")
+	c.Flags().StringVar(&format, "f", defFormat, "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+	c.Flags().StringVar(&taxaFile, "taxa", "", "")
+}
+
+func run(c *command.Command, args []string) (err error) {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	af := p.Path(project.Ages)
+	if af == "" {
+		return fmt.Errorf("undefined ages file")
+	}
+	a := ages.New()
+	if err := readAgesFile(af, a); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	taxa := a.Taxa()
+	if taxaFile != "" {
+		filter, err := readFilter(taxaFile)
+		if err != nil {
+			return err
+		}
+		var ls []string
+		for _, tx := range taxa {
+			if !filter[strings.ToLower(tx)] {
+				continue
+			}
+			ls = append(ls, tx)
+		}
+		taxa = ls
+	}
+
+	var write func(io.Writer, *ages.Ages, []string) error
+	switch strings.ToLower(format) {
+	case "beast":
+		write = writeBeast
+	case "mrbayes":
+		write = writeMrBayes
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	out := c.Stdout()
+	if output != "" {
+		var f *os.File
+		f, err = os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			e := f.Close()
+			if e != nil && err == nil {
+				err = e
+			}
+		}()
+		out = f
+	}
+
+	bw := bufio.NewWriter(out)
+	if err := write(bw, a, taxa); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func writeBeast(w io.Writer, a *ages.Ages, taxa []string) error {
+	for _, tx := range taxa {
+		min, max, ok := a.TaxonRange(tx)
+		if !ok {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", taxonName(tx), formatAge((min+max)/2)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeMrBayes(w io.Writer, a *ages.Ages, taxa []string) error {
+	fmt.Fprintf(w, "begin mrbayes;\n")
+	for _, tx := range taxa {
+		min, max, ok := a.TaxonRange(tx)
+		if !ok || max == 0 {
+			continue
+		}
+		if min == max {
+			fmt.Fprintf(w, "\tcalibrate %s = fixed(%s);\n", taxonName(tx), formatAge(min))
+			continue
+		}
+		fmt.Fprintf(w, "\tcalibrate %s = uniform(%s, %s);\n", taxonName(tx), formatAge(min), formatAge(max))
+	}
+	fmt.Fprintf(w, "\tprset nodeagepr = calibrated;\n")
+	_, err := fmt.Fprintf(w, "end;\n")
+	return err
+}
+
+func taxonName(tx string) string {
+	return strings.Join(strings.Fields(tx), "_")
+}
+
+func formatAge(a float64) string {
+	return strconv.FormatFloat(a, 'f', -1, 64)
+}
+
+func readAgesFile(name string, a *ages.Ages) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := a.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func readFilter(name string) (map[string]bool, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	filter := make(map[string]bool)
+	for i := 1; ; i++ {
+		ln, err := r.ReadString('\n')
+		if errors.Is(err, io.EOF) && ln == "" {
+			break
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("on file %q: line %d: %v", name, i, err)
+		}
+
+		n := strings.Join(strings.Fields(ln), " ")
+		if n == "" {
+			continue
+		}
+		if n[0] == '#' {
+			continue
+		}
+		filter[strings.ToLower(n)] = true
+	}
+
+	return filter, nil
+}
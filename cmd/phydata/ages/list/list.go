@@ -0,0 +1,90 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package list implements a command to print the age ranges
+// stored in a PhyData project.
+package list
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/ages"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: "list [--specimens] <project-file>",
+	Short: "print age ranges",
+	Long: `
+Command list reads a PhyData project and prints the age ranges (in million
+years) of the taxa stored in the project.
+
+The argument of the command is the name of the project file.
+
+By default, a single range is printed for each taxon. If the taxon does not
+have an assigned age, the range that includes the ages of all its specimens
+will be printed. If the flag --specimens is defined, the age of each specimen
+will be printed.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var specFlag bool
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&specFlag, "specimens", false, "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	af := p.Path(project.Ages)
+	if af == "" {
+		return fmt.Errorf("undefined ages file")
+	}
+	a := ages.New()
+	if err := readAgesFile(af, a); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	for _, tx := range a.Taxa() {
+		if !specFlag {
+			min, max, _ := a.TaxonRange(tx)
+			fmt.Fprintf(c.Stdout(), "%s\t%.3f\t%.3f\n", tx, min, max)
+			continue
+		}
+		specs := append([]string{""}, a.TaxSpec(tx)...)
+		for _, sp := range specs {
+			min, max, ok := a.Range(tx, sp)
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(c.Stdout(), "%s\t%s\t%.3f\t%.3f\n", tx, sp, min, max)
+		}
+	}
+	return nil
+}
+
+func readAgesFile(name string, a *ages.Ages) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := a.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
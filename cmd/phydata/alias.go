@@ -0,0 +1,96 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AliasFile returns the path of the file
+// with the user defined aliases.
+func aliasFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "phydata", "aliases")
+}
+
+// ReadAliases reads the user defined aliases
+// from a file.
+func readAliases(name string) (map[string][]string, error) {
+	if name == "" {
+		return nil, nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	aliases := make(map[string][]string)
+	for i := 1; ; i++ {
+		ln, err := r.ReadString('\n')
+		if errors.Is(err, io.EOF) && ln == "" {
+			break
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("on file %q: line %d: %v", name, i, err)
+		}
+
+		f := strings.Fields(ln)
+		if len(f) == 0 || strings.HasPrefix(f[0], "#") {
+			continue
+		}
+		if len(f) < 2 {
+			return nil, fmt.Errorf("on file %q: line %d: alias %q: expecting expansion", name, i, f[0])
+		}
+		a := strings.ToLower(f[0])
+		if _, dup := aliases[a]; dup {
+			return nil, fmt.Errorf("on file %q: line %d: alias %q already defined", name, i, f[0])
+		}
+		aliases[a] = f[1:]
+	}
+	return aliases, nil
+}
+
+// ExpandAlias replaces the first word of a command line
+// (after the global flags)
+// with the expansion of an alias.
+// PhyData commands are never replaced.
+func expandAlias(args []string, aliases map[string][]string) []string {
+	if len(aliases) == 0 {
+		return args
+	}
+	for i, a := range args {
+		if strings.HasPrefix(a, "-") {
+			continue
+		}
+		exp, ok := aliases[strings.ToLower(a)]
+		if !ok {
+			return args
+		}
+		if info, err := commandInfo(nil); err == nil {
+			for _, c := range info.children {
+				if c == strings.ToLower(a) {
+					return args
+				}
+			}
+		}
+		ls := make([]string, 0, len(args)+len(exp))
+		ls = append(ls, args[:i]...)
+		ls = append(ls, exp...)
+		ls = append(ls, args[i+1:]...)
+		return ls
+	}
+	return args
+}
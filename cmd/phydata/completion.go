@@ -0,0 +1,448 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var completionCommand = &command.Command{
+	Usage: "completion [--complete] [--cword <number>] <shell>",
+	Short: "print a shell completion script",
+	Long: `
+Command completion prints a script for the completion of the PhyData commands
+in a shell. Valid shells are:
+
+	bash
+	zsh
+	fish
+
+For example, to enable the completion in bash, add the following line to the
+'.bashrc' file:
+
+	source <(phydata completion bash)
+
+In zsh, add the following line to the '.zshrc' file (after 'compinit'):
+
+	source <(phydata completion zsh)
+
+In fish, write the script into the completions directory:
+
+	phydata completion fish > ~/.config/fish/completions/phydata.fish
+
+The script completes the commands, the flags of each command, and the user
+defined aliases (see below). When a command expects a gene, a taxon, a
+character, a specimen, a dataset, or a data type, the values are taken from
+the project given to the command (e.g., in 'phydata dna trim <project>
+<gene>', the genes are taken from the DNA file of the project). Otherwise,
+file names are completed.
+
+If the flag --complete is defined, instead of the script, the command prints
+the completions for the arguments given after the flag (i.e., the words of
+the command line after 'phydata'). By default, the last argument is the word
+being completed, use the flag --cword to define the index (starting at 0)
+of the word being completed. These flags are used by the completion scripts.
+
+User defined aliases of commands can be stored in the file 'phydata/aliases'
+in the user configuration directory (e.g., '~/.config/phydata/aliases' in
+Linux). Each line of the file is an alias, the first word is the name of the
+alias, and the rest of the line is the expansion of the alias. Blank lines
+and lines starting with '#' are ignored. For example:
+
+	# export matrices in NEXUS format
+	nexus	matrix -f nexus
+	genes	dna genes
+
+Then, 'phydata nexus project.tab obs' is equivalent to 'phydata matrix -f
+nexus project.tab obs'. Only the first word of a command can be an alias,
+and an alias never replaces a PhyData command. The expansion is split by
+white space, so quoted values are not supported.
+	`,
+	SetFlags: setCompletionFlags,
+	Run:      runCompletion,
+}
+
+var completeFlag bool
+var cword int
+
+func setCompletionFlags(c *command.Command) {
+	c.Flags().BoolVar(&completeFlag, "complete", false, "")
+	c.Flags().IntVar(&cword, "cword", -1, "")
+}
+
+func runCompletion(c *command.Command, args []string) error {
+	if completeFlag {
+		if cword < 0 || cword > len(args) {
+			cword = len(args) - 1
+		}
+		if cword < 0 || cword == len(args) {
+			args = append(args, "")
+			cword = len(args) - 1
+		}
+		for _, s := range complete(args[:cword+1], args[cword+1:]) {
+			fmt.Fprintf(c.Stdout(), "%s\n", s)
+		}
+		return nil
+	}
+
+	if len(args) < 1 {
+		return c.UsageError("expecting shell name")
+	}
+	script, ok := scripts[strings.ToLower(args[0])]
+	if !ok {
+		return c.UsageError(fmt.Sprintf("unknown shell %q", args[0]))
+	}
+	fmt.Fprintf(c.Stdout(), "%s", script)
+	return nil
+}
+
+var scripts = map[string]string{
+	"bash": `# bash completion for phydata
+_phydata() {
+	local IFS=$'\n'
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	COMPREPLY=($(phydata completion --complete --cword $((COMP_CWORD-1)) -- "${COMP_WORDS[@]:1}" 2>/dev/null))
+	if [ ${#COMPREPLY[@]} -eq 0 ]; then
+		COMPREPLY=($(compgen -f -- "$cur"))
+	fi
+}
+complete -o filenames -F _phydata phydata
+`,
+	"zsh": `#compdef phydata
+# zsh completion for phydata
+_phydata() {
+	local -a cands
+	cands=("${(@f)$(phydata completion --complete --cword $((CURRENT-2)) -- "${(@)words[2,-1]}" 2>/dev/null)}")
+	if [[ -n "${cands[1]}" ]]; then
+		compadd -a cands
+	else
+		_files
+	fi
+}
+compdef _phydata phydata
+`,
+	"fish": `# fish completion for phydata
+function __phydata_complete
+	set -l args (commandline -opc)[2..-1] (commandline -ct)
+	set -l cands (phydata completion --complete -- $args 2>/dev/null)
+	if test (count $cands) -gt 0
+		printf '%s\n' $cands
+	else
+		__fish_complete_path (commandline -ct)
+	end
+end
+complete -c phydata -f -a '(__phydata_complete)'
+`,
+}
+
+// A cmdInfo is the information of a command
+// taken from its help message.
+type cmdInfo struct {
+	children []string
+
+	// flags stores the flags of the command,
+	// and the name of its value
+	// (empty for boolean flags).
+	flags map[string]string
+
+	// args are the names of the positional arguments.
+	args []string
+
+	// repeat is true if the last argument
+	// can be repeated.
+	repeat bool
+}
+
+// CommandInfo returns the information of a command
+// from the help of the command.
+func commandInfo(path []string) (cmdInfo, error) {
+	var buf bytes.Buffer
+	app.SetStdout(&buf)
+	err := app.Execute(append([]string{"help"}, path...))
+	app.SetStdout(nil)
+	if err != nil {
+		return cmdInfo{}, err
+	}
+
+	info := cmdInfo{
+		flags: make(map[string]string),
+	}
+	lines := strings.Split(buf.String(), "\n")
+	for i := 0; i < len(lines); i++ {
+		switch strings.TrimSpace(lines[i]) {
+		case "Usage:":
+			var usage []string
+			for i += 2; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				usage = append(usage, lines[i])
+			}
+			info.parseUsage(strings.Join(usage, " "), len(path)+1)
+		case "The commands are:":
+			for i += 2; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				f := strings.Fields(lines[i])
+				info.children = append(info.children, f[0])
+			}
+		}
+	}
+	return info, nil
+}
+
+var usageToken = regexp.MustCompile(`-{1,2}[\w-]+|<[^>]+>(\.\.\.)?|\||\S`)
+
+// ParseUsage reads the flags and arguments
+// from a usage line,
+// skipping the names of the command
+// and its parents.
+func (info *cmdInfo) parseUsage(usage string, skip int) {
+	f := strings.Fields(usage)
+	if len(f) < skip {
+		return
+	}
+	usage = strings.Join(f[skip:], " ")
+
+	var flags []string
+	for _, t := range usageToken.FindAllString(usage, -1) {
+		switch {
+		case t == "|":
+			continue
+		case strings.HasPrefix(t, "-"):
+			flags = append(flags, strings.TrimLeft(t, "-"))
+			continue
+		case strings.HasPrefix(t, "<"):
+			name := strings.Trim(strings.TrimSuffix(t, "..."), "<>")
+			if len(flags) > 0 {
+				for _, fl := range flags {
+					info.flags[fl] = name
+				}
+				flags = nil
+				continue
+			}
+			info.args = append(info.args, name)
+			info.repeat = strings.HasSuffix(t, "...")
+			continue
+		}
+		for _, fl := range flags {
+			info.flags[fl] = ""
+		}
+		flags = nil
+	}
+	for _, fl := range flags {
+		info.flags[fl] = ""
+	}
+}
+
+// Complete returns the completions
+// of the last word of a command line.
+// The words after the completed word
+// are used to search the project file.
+func complete(words, after []string) []string {
+	cur := words[len(words)-1]
+	words = words[:len(words)-1]
+
+	aliases, _ := readAliases(aliasFile())
+	words = expandAlias(words, aliases)
+
+	var path []string
+	info, err := commandInfo(path)
+	if err != nil {
+		return nil
+	}
+
+	var pending string
+	var pos []string
+	helpMode := false
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if len(w) > 1 && strings.HasPrefix(w, "-") {
+			name := strings.TrimLeft(w, "-")
+			if strings.Contains(name, "=") {
+				continue
+			}
+			if v := info.flags[name]; v != "" {
+				if i == len(words)-1 {
+					pending = v
+				}
+				i++
+			}
+			continue
+		}
+		if len(pos) == 0 && len(info.children) > 0 {
+			if w == "help" && len(path) == 0 {
+				helpMode = true
+				continue
+			}
+			if slices.Contains(info.children, w) {
+				path = append(path, w)
+				info, err = commandInfo(path)
+				if err != nil {
+					return nil
+				}
+				continue
+			}
+		}
+		pos = append(pos, w)
+	}
+
+	// the project might be defined
+	// after the completed word
+	for _, w := range after {
+		if !strings.HasPrefix(w, "-") {
+			pos = append(pos, w)
+		}
+	}
+	var cands []string
+	switch {
+	case pending != "":
+		cands = values(pending, pos)
+	case strings.HasPrefix(cur, "-") && !helpMode:
+		for fl := range info.flags {
+			if len(fl) == 1 {
+				cands = append(cands, "-"+fl)
+				continue
+			}
+			cands = append(cands, "--"+fl)
+		}
+	case len(info.children) > 0 && len(pos) == len(after):
+		cands = append(cands, info.children...)
+		if len(path) > 0 && len(info.args) > 0 {
+			// a runnable command with children
+			matches, _ := filepath.Glob(cur + "*")
+			cands = append(cands, matches...)
+		}
+		if len(path) == 0 {
+			if !helpMode {
+				cands = append(cands, "help")
+			}
+			for a := range aliases {
+				if !slices.Contains(info.children, a) {
+					cands = append(cands, a)
+				}
+			}
+		}
+	case helpMode:
+	case len(info.args) > 0:
+		i := len(pos) - len(after)
+		if i >= len(info.args) {
+			if !info.repeat {
+				break
+			}
+			i = len(info.args) - 1
+		}
+		cands = values(info.args[i], pos)
+	}
+
+	var ls []string
+	for _, c := range cands {
+		if strings.HasPrefix(c, cur) {
+			ls = append(ls, c)
+		}
+	}
+	slices.Sort(ls)
+	return slices.Compact(ls)
+}
+
+// Values returns the possible values
+// of an argument,
+// using the data of the project
+// (the first positional argument that is a project file).
+// If the values are file names,
+// it returns nil.
+func values(name string, pos []string) []string {
+	name = strings.ToLower(name)
+	if strings.Contains(name, "data-type") {
+		return []string{"dna", "obs"}
+	}
+	var p *project.Project
+	for _, a := range pos {
+		if v, err := project.Read(a); err == nil {
+			p = v
+			break
+		}
+	}
+	if p == nil {
+		return nil
+	}
+
+	switch {
+	case strings.Contains(name, "dataset"):
+		var ls []string
+		for _, s := range p.Sets() {
+			ls = append(ls, string(s))
+		}
+		return ls
+	case strings.Contains(name, "gene"):
+		coll, err := readDNA(p)
+		if err != nil {
+			return nil
+		}
+		return coll.Genes()
+	case strings.Contains(name, "character"):
+		m, err := readObs(p)
+		if err != nil {
+			return nil
+		}
+		return m.Chars()
+	case strings.Contains(name, "taxon"):
+		var ls []string
+		if m, err := readObs(p); err == nil {
+			ls = append(ls, m.Taxa()...)
+		}
+		if coll, err := readDNA(p); err == nil {
+			ls = append(ls, coll.Taxa()...)
+		}
+		return ls
+	case strings.Contains(name, "specimen"):
+		var ls []string
+		if m, err := readObs(p); err == nil {
+			ls = append(ls, m.Specimens()...)
+		}
+		if coll, err := readDNA(p); err == nil {
+			ls = append(ls, coll.Specimens()...)
+		}
+		return ls
+	}
+	return nil
+}
+
+func readObs(p *project.Project) (*matrix.Matrix, error) {
+	mf := p.Path(project.Observations)
+	if mf == "" {
+		return nil, errors.New("undefined observations file")
+	}
+	f, err := os.Open(mf)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := matrix.New()
+	if err := m.ReadTSV(f); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func readDNA(p *project.Project) (*dna.Collection, error) {
+	if p.Path(project.DNA) == "" {
+		return nil, errors.New("undefined DNA file")
+	}
+	coll := dna.New()
+	if _, err := dnafiles.Read(p, coll); err != nil {
+		return nil, err
+	}
+	return coll, nil
+}
@@ -0,0 +1,261 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package config implements the configuration files
+// of PhyData,
+// used to define the default values
+// of some flags of the commands.
+//
+// The configuration is read from the user configuration file,
+// and then from the project configuration file
+// (the file 'phydata.conf' in the working directory),
+// so project values replace user values.
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/names"
+)
+
+// Valid configuration keys.
+const (
+	// Author is the default author of the imported observations,
+	// and the exported archives.
+	Author = "author"
+
+	// EntrezEmail is the email sent
+	// in the requests to the NCBI E-utilities.
+	EntrezEmail = "entrez-email"
+
+	// EntrezKey is the API key
+	// of the NCBI E-utilities.
+	EntrezKey = "entrez-api-key"
+
+	// MatrixFormat is the default format
+	// of the 'matrix' command.
+	MatrixFormat = "matrix-format"
+
+	// GeoFormat is the default format
+	// of the 'geo export' command.
+	GeoFormat = "geo-format"
+
+	// AgesFormat is the default format
+	// of the 'ages export' command.
+	AgesFormat = "ages-format"
+
+	// Names is the normalization policy
+	// of the imported taxon names.
+	Names = "names"
+)
+
+var keys = []string{
+	Author,
+	EntrezEmail,
+	EntrezKey,
+	MatrixFormat,
+	GeoFormat,
+	AgesFormat,
+	Names,
+}
+
+// ProjectFile is the name of the project configuration file.
+const ProjectFile = "phydata.conf"
+
+// values are the configuration values.
+var values = make(map[string]string)
+
+// source stores the file
+// that defines each configuration value.
+var source = make(map[string]string)
+
+// UserFile returns the path of the user configuration file.
+func UserFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "phydata", "config")
+}
+
+// Load reads the user configuration file,
+// and the project configuration file.
+// Files that do not exist are ignored.
+func Load() error {
+	for _, name := range []string{UserFile(), ProjectFile} {
+		if name == "" {
+			continue
+		}
+		if err := Read(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read reads a configuration file.
+// Values defined in the file
+// replace the values already defined.
+//
+// Each line of the file is a value,
+// the first word is the key,
+// and the rest of the line is the value.
+// Blank lines and lines starting with '#' are ignored.
+func Read(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for i := 1; ; i++ {
+		ln, err := r.ReadString('\n')
+		if errors.Is(err, io.EOF) && ln == "" {
+			break
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("on file %q: line %d: %v", name, i, err)
+		}
+
+		fs := strings.Fields(ln)
+		if len(fs) == 0 || strings.HasPrefix(fs[0], "#") {
+			continue
+		}
+		key := strings.ToLower(fs[0])
+		if !slices.Contains(keys, key) {
+			return fmt.Errorf("on file %q: line %d: unknown key %q", name, i, fs[0])
+		}
+		v := strings.Join(fs[1:], " ")
+		if key == Names {
+			if _, err := parseNames(v); err != nil {
+				return fmt.Errorf("on file %q: line %d: %v", name, i, err)
+			}
+		}
+		values[key] = v
+		source[key] = name
+	}
+	return nil
+}
+
+// Get returns the value of a key.
+// If the key is undefined,
+// it returns the default value.
+func Get(key, def string) string {
+	v, ok := values[key]
+	if !ok || v == "" {
+		return def
+	}
+	return v
+}
+
+// NameOptions returns the normalization options
+// of the imported taxon names.
+func NameOptions() names.Options {
+	opt, _ := parseNames(values[Names])
+	return opt
+}
+
+// ParseNames parses a list of name normalization rules.
+func parseNames(v string) (names.Options, error) {
+	var opt names.Options
+	for _, r := range strings.Split(v, ",") {
+		switch strings.ToLower(strings.TrimSpace(r)) {
+		case "", "default":
+		case "strip-authors":
+			opt.StripAuthors = true
+		case "strip-diacritics":
+			opt.StripDiacritics = true
+		case "species":
+			opt.Species = true
+		default:
+			return opt, fmt.Errorf("unknown name rule %q", strings.TrimSpace(r))
+		}
+	}
+	return opt, nil
+}
+
+var Command = &command.Command{
+	Usage: "config",
+	Short: "print the configuration values",
+	Long: `
+Command config prints the configuration values used by PhyData, and the
+files that define them.
+
+The configuration values are used as the default values of some flags of the
+commands, so they are not repeated on every call. The values are read from
+the user configuration file 'phydata/config' in the user configuration
+directory (e.g., '~/.config/phydata/config' in Linux), and then from the
+project configuration file 'phydata.conf' in the working directory. The
+values of the project file replace the values of the user file. Flags given
+in the command line always replace the configuration values.
+
+Each line of a configuration file is a value: the first word is the key, and
+the rest of the line is the value. Blank lines and lines starting with '#'
+are ignored. Valid keys are:
+
+	author          the default author of the imported observations
+	                (see 'phydata obs add'), and of the exported
+	                archives (see 'phydata export archive').
+	entrez-email    the email sent in the requests to the NCBI
+	                E-utilities (see 'phydata taxa ids').
+	entrez-api-key  the API key of the NCBI E-utilities. With a key,
+	                up to ten requests per second are accepted.
+	matrix-format   the default format of 'phydata matrix'.
+	geo-format      the default format of 'phydata geo export'.
+	ages-format     the default format of 'phydata ages export'.
+	names           a comma-separated list of the normalization rules
+	                of the taxon names imported with 'phydata obs add',
+	                or 'phydata dna add'.
+
+Valid normalization rules of taxon names are:
+
+	strip-authors     remove the author string of a name
+	                  (e.g., 'Felis catus Linnaeus, 1758' is
+	                  'Felis catus').
+	strip-diacritics  replace letters with diacritics with its base
+	                  letter.
+	species           collapse infraspecific names to the species.
+
+For example:
+
+	# user configuration
+	author          J. Salvador Arias
+	entrez-email    jsalarias@example.com
+	matrix-format   nexus
+	names           strip-authors,strip-diacritics
+
+The NCBI credentials can also be defined with the environment variables
+NCBI_EMAIL and NCBI_API_KEY, that replace the values of the configuration
+files.
+
+The output is a TSV table with the key, the value, and the file that defines
+the value.
+	`,
+	Run: run,
+}
+
+func run(c *command.Command, args []string) error {
+	fmt.Fprintf(c.Stdout(), "key\tvalue\tfile\n")
+	for _, k := range keys {
+		v, ok := values[k]
+		if !ok {
+			continue
+		}
+		if k == EntrezKey {
+			// do not print the credentials
+			v = strings.Repeat("*", len(v))
+		}
+		fmt.Fprintf(c.Stdout(), "%s\t%s\t%s\n", k, v, source[k])
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package accessions implements a command to list
+// the GenBank accessions of a PhyData project.
+package accessions
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: "accessions [--gene <name>] <project-file>",
+	Short: "list the GenBank accessions of a project",
+	Long: `
+Command accessions reads the DNA sequences of a PhyData project and prints the
+GenBank accessions of each taxon.
+
+The argument of the command is the name of the project file.
+
+By default, the accessions of all genes are printed. Use the flag --gene to
+print only the accessions of a given gene.
+
+The output is a TSV table with the taxon, the specimen, the gene, and the
+accession of each sequence.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var geneFlag string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&geneFlag, "gene", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	if p.Path(project.DNA) == "" {
+		return fmt.Errorf("undefined DNA file")
+	}
+	coll := dna.New()
+	if _, err := dnafiles.Read(p, coll); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	gene := strings.ToLower(strings.TrimSpace(geneFlag))
+
+	tab := csv.NewWriter(c.Stdout())
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	if err := tab.Write([]string{"taxon", "specimen", "gene", "accession"}); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+	for _, tx := range coll.Taxa() {
+		for _, sp := range coll.TaxSpec(tx) {
+			for _, g := range coll.SpecGene(sp) {
+				if gene != "" && g != gene {
+					continue
+				}
+				for _, acc := range coll.GeneAccession(sp, g) {
+					if err := tab.Write([]string{tx, sp, g, acc}); err != nil {
+						return fmt.Errorf("while writing data: %v", err)
+					}
+				}
+			}
+		}
+	}
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
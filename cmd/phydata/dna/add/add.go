@@ -12,17 +12,25 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/config"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/cmd/phydata/progress"
 	"github.com/js-arias/phydata/matrix/dna"
 	"github.com/js-arias/phydata/project"
 )
 
 var Command = &command.Command{
 	Usage: `add [-f|--file <dna-file>]
-	[--filter <file>]
+	[--filter <file>] [--aliases <file>]
+	[--replace] [--accessions <policy>]
+	[--strict] [--normalize-u] [--dry-run]
+	[--delimiter <delimiter>] [--headers <file>]
 	<project-file> <dna-data-file>`,
 	Short: "add DNA sequences to a project",
 	Long: `
@@ -39,12 +47,82 @@ the flag --filter, only the sequences for the taxa defined in the file will be
 used. In this filter file, each taxon name must be given per line. Empty lines
 or lines starting with '#' will be ignored.
 
-By default, the DNA data will be stored in the DNA file currently defined for
-the project. If the project does not have a DNA file, a ew one will be created
-with the name 'dna.tab'. A different DNA file name can be defined using the
-flag --file or -f. If this flag is given and there is a DNA file already
-defined, then a new file will be created and used as the DNA file for the
-project (previously defined DNA sequences will be preserved).
+The gene names of the imported sequences are replaced by its canonical name,
+using a table of common gene synonyms (e.g., 'coi' and 'coxi' are replaced
+by 'cox1'; 'rrnl' and '16s rrna' by '16s'). Additional synonyms can be
+defined with the flag --aliases, with a tab-delimited file in which each line
+is a gene, the first field is the canonical name, and the following fields
+are its synonyms. Empty lines or lines starting with '#' will be ignored. Use
+the command 'dna genes --merge' to merge the synonymous genes already in the
+project.
+
+The taxon names of the imported sequences are normalized using the rules
+defined with the key 'names' of the configuration file (e.g., to remove the
+author strings of the names, see 'phydata help config').
+
+By default, sequences already in the project (i.e., with the same specimen,
+gene, and accession) are not modified, and the new sequence is skipped. Use
+the flag --replace to replace the sequences already in the project. A
+sequence is also replaced if it has the same accession but a different
+accession version (e.g., 'MN148748.2' replaces 'MN148748.1'). The metadata
+of the replaced sequence is kept, unless the new sequence has a different
+value. The replaced sequences will be reported.
+
+The flag --accessions defines what to do if a GenBank accession is already
+assigned to a different specimen or gene in the project. Valid values are:
+
+	allow   the accession is added (the default)
+	reject  the import stops with an error
+	merge   the sequence is assigned to the specimen that already has
+	        the accession (the gene can be different, e.g., for
+	        mitochondrial genomes)
+
+Use the command 'dna validate' to report the accessions assigned to different
+specimens or genes in a project.
+
+By default, rows of the DNA file with an empty taxon, specimen, gene,
+accession, or sequence are ignored. Use the flag --strict to stop the import
+with an error (reporting the row number) if there is any of such rows.
+
+By default, the sequences are stored as given in the DNA file, so RNA
+sequences (with uracil) keep its 'u' nucleotides. Use the flag --normalize-u
+to replace 'u' with 't' in the imported sequences, so all the sequences of
+the project use the same nucleotide alphabet. The DNA file can declare the
+kind of molecule of a sequence with the field 'molecule' ('dna', 'rna', or
+'rrna', for ribosomal RNA), and the secondary structure of an RNA sequence,
+in dot-bracket notation, with the field 'structure'.
+
+By default, the field delimiter of the DNA file (tab, comma, or semicolon) is
+detected from the header of the file. Use the flag --delimiter to define the
+delimiter explicitly. Valid values are 'tab', 'comma', and 'semicolon'.
+
+The header of the DNA file can use common synonyms of the field names (e.g.,
+'species' for taxon, 'voucher' for specimen, 'locus' for gene, or 'sequence'
+for bases). Additional synonyms can be defined with the flag --headers, with a
+tab-delimited file in which each line is a field, the first value is the name
+of the field, and the following values are its synonyms. Empty lines or lines
+starting with '#' will be ignored.
+
+A project can store its DNA sequences in several files (e.g., a file for each
+gene, or for each sequencing batch). The files are merged when read, so the
+sequences of all the files are checked when adding the new sequences, and
+each sequence already in the project is kept in its own file. By default, the
+new sequences will be stored in the first DNA file of the project that is not
+read-only (see 'phydata project readonly'). A replaced sequence of a
+read-only file is also stored in that file, so the read-only file is never
+modified. If the project does not have a DNA file, a new one will be created
+with the name 'dna.tab'. A different DNA file can be defined using the flag
+--file or -f. If the file is not one of the DNA files of the project, it will
+be added to the DNA files of the project (if the file already exists, its
+sequences are read as part of the project). The other DNA files of the
+project are preserved.
+
+After the import, the command prints a summary with the number of new
+sequences, the number of replaced sequences, the number of skipped sequences
+(sequences already in the project, when --replace is not used), the number of
+conflicts (sequences of a specimen already assigned to a different taxon, the
+sequence is assigned to the previous taxon), and the number of new taxa. Use
+the flag --dry-run to print the summary without saving any change.
 	`,
 	SetFlags: setFlags,
 	Run:      run,
@@ -52,11 +130,27 @@ project (previously defined DNA sequences will be preserved).
 
 var dnaFile string
 var filterFile string
+var aliasFile string
+var replace bool
+var accPolicy string
+var strict bool
+var delimiter string
+var headerFile string
+var dryRun bool
+var normalizeU bool
 
 func setFlags(c *command.Command) {
 	c.Flags().StringVar(&dnaFile, "file", "", "")
 	c.Flags().StringVar(&dnaFile, "f", "", "")
 	c.Flags().StringVar(&filterFile, "filter", "", "")
+	c.Flags().StringVar(&aliasFile, "aliases", "", "")
+	c.Flags().BoolVar(&replace, "replace", false, "")
+	c.Flags().StringVar(&accPolicy, "accessions", "allow", "")
+	c.Flags().BoolVar(&strict, "strict", false, "")
+	c.Flags().StringVar(&delimiter, "delimiter", "", "")
+	c.Flags().StringVar(&headerFile, "headers", "", "")
+	c.Flags().BoolVar(&normalizeU, "normalize-u", false, "")
+	c.Flags().BoolVar(&dryRun, "dry-run", false, "")
 }
 
 func run(c *command.Command, args []string) error {
@@ -68,23 +162,82 @@ func run(c *command.Command, args []string) error {
 	}
 
 	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	p, err := openProject(pFile)
 	if err != nil {
 		return err
 	}
 
 	coll := dna.New()
-	if df := p.Path(project.DNA); df != "" {
-		if err := readDNAFile(df, coll); err != nil {
-			return fmt.Errorf("on project %q: %v", pFile, err)
+	files, err := dnafiles.Read(p, coll)
+	if err != nil {
+		return fmt.Errorf("on project %q: %v", pFile, err)
+	}
+	if dnaFile == "" {
+		dnaFile = p.Path(project.DNA)
+		if dnaFile == "" {
+			dnaFile = "dna.tab"
 		}
 	}
+	if err := files.SetTarget(coll, dnaFile); err != nil {
+		return fmt.Errorf("on project %q: %v", pFile, err)
+	}
+
+	aliases := dna.DefaultAliases()
+	if aliasFile != "" {
+		a, err := readAliases(aliasFile)
+		if err != nil {
+			return err
+		}
+		aliases.Merge(a)
+	}
 
 	in := args[1]
 	nd := dna.New()
-	if err := readDNAFile(in, nd); err != nil {
+	nd.SetAliases(aliases)
+	nd.SetNormalizeU(normalizeU)
+	headers := dna.DefaultHeaders()
+	if headerFile != "" {
+		h, err := readHeaders(headerFile)
+		if err != nil {
+			return err
+		}
+		headers.Merge(h)
+	}
+	nd.SetHeaders(headers)
+	comma, err := parseDelimiter(delimiter)
+	if err != nil {
+		return c.UsageError(err.Error())
+	}
+	read := func(r io.Reader) error {
+		return nd.ReadDelimited(r, comma, strict)
+	}
+	if err := readDNAFile(in, read); err != nil {
 		return err
 	}
+	progress.Printf("read %q: %d sequences", in, nd.NumSequences())
+	opt := config.NameOptions()
+	for _, tax := range nd.Taxa() {
+		nd.RenameTaxon(tax, opt.Taxon(tax))
+	}
+	var policy dna.Policy
+	switch strings.ToLower(accPolicy) {
+	case "", "allow":
+		policy = dna.Allow
+	case "reject":
+		policy = dna.Reject
+	case "merge":
+		policy = dna.Merge
+	default:
+		return c.UsageError(fmt.Sprintf("invalid accession policy %q", accPolicy))
+	}
+	coll.SetPolicy(policy)
+
 	var filter map[string]bool
 	if filterFile != "" {
 		filter, err = readFilter(filterFile)
@@ -93,6 +246,18 @@ func run(c *command.Command, args []string) error {
 		}
 	}
 
+	prevTaxa := make(map[string]bool)
+	specTaxon := make(map[string]string)
+	for _, tax := range coll.Taxa() {
+		prevTaxa[tax] = true
+		for _, spec := range coll.TaxSpec(tax) {
+			specTaxon[spec] = tax
+		}
+	}
+
+	var added, replaced, skipped, conflicts int
+	newTaxa := make(map[string]bool)
+	count := progress.NewCounter("adding sequences", nd.NumSequences())
 	for _, tax := range nd.Taxa() {
 		if filter != nil {
 			if !filter[strings.ToLower(tax)] {
@@ -102,37 +267,69 @@ func run(c *command.Command, args []string) error {
 		for _, spec := range nd.TaxSpec(tax) {
 			for _, gene := range nd.SpecGene(spec) {
 				for _, acc := range nd.GeneAccession(spec, gene) {
+					count.Add(1)
 					seq := nd.Sequence(spec, gene, acc)
-					if err := coll.Add(tax, spec, gene, acc, seq); err != nil {
+
+					// specimen in the project
+					sp := spec
+					if policy == dna.Merge {
+						if ls := coll.AccessionSpec(acc); len(ls) > 0 && !slices.Contains(ls, spec) {
+							sp = ls[0]
+						}
+					}
+
+					old := prevAccession(coll, sp, gene, acc)
+					if old != "" && !replace {
+						skipped++
+						continue
+					}
+					if t, ok := specTaxon[sp]; ok && t != tax {
+						conflicts++
+					} else if !prevTaxa[tax] {
+						newTaxa[tax] = true
+					}
+
+					vals := make(map[dna.Field]string, len(fields))
+					if old != "" {
+						for _, f := range fields {
+							vals[f] = coll.Val(sp, gene, old, f)
+						}
+						if old != acc {
+							coll.Delete(sp, gene, old)
+						}
+						fmt.Fprintf(c.Stdout(), "replaced %s: %s (%s, %s)\n", old, acc, gene, sp)
+						replaced++
+					} else {
+						added++
+					}
+					if err := coll.Add(tax, sp, gene, acc, seq); err != nil {
 						return fmt.Errorf("when adding %q (%s, %s): %v", acc, gene, tax, err)
 					}
 
-					alg := nd.Val(spec, gene, acc, dna.Aligned)
-					coll.Set(spec, gene, acc, alg, dna.Aligned)
-					prt := nd.Val(spec, gene, acc, dna.Protein)
-					coll.Set(spec, gene, acc, prt, dna.Protein)
-					org := nd.Val(spec, gene, acc, dna.Organelle)
-					coll.Set(spec, gene, acc, org, dna.Organelle)
-					ref := nd.Val(spec, gene, acc, dna.Reference)
-					coll.Set(spec, gene, acc, ref, dna.Reference)
-					com := nd.Val(spec, gene, acc, dna.Comments)
-					coll.Set(spec, gene, acc, com, dna.Comments)
+					for _, f := range fields {
+						v := nd.Val(spec, gene, acc, f)
+						if v == "" {
+							v = vals[f]
+						}
+						coll.Set(sp, gene, acc, v, f)
+					}
 				}
 			}
 		}
 	}
 
-	if dnaFile == "" {
-		dnaFile = p.Path(project.DNA)
-		if dnaFile == "" {
-			dnaFile = "dna.tab"
-		}
+	count.Done()
+	fmt.Fprintf(c.Stdout(), "%d new sequences, %d replaced sequences, %d skipped sequences, %d conflicts, %d new taxa\n", added, replaced, skipped, conflicts, len(newTaxa))
+	if dryRun {
+		fmt.Fprintf(c.Stdout(), "dry run: no changes saved\n")
+		return nil
 	}
-	if err := writeDNA(dnaFile, coll); err != nil {
+
+	if err := files.Write(coll); err != nil {
 		return err
 	}
 
-	p.Add(project.DNA, dnaFile)
+	p.Append(project.DNA, dnaFile)
 	if err := p.Write(pFile); err != nil {
 		return err
 	}
@@ -140,6 +337,54 @@ func run(c *command.Command, args []string) error {
 	return nil
 }
 
+// Fields are the additional fields of a sequence.
+var fields = []dna.Field{
+	dna.Aligned,
+	dna.Protein,
+	dna.Organelle,
+	dna.Reference,
+	dna.Comments,
+	dna.Start,
+	dna.End,
+	dna.Strand,
+	dna.Molecule,
+	dna.Structure,
+	dna.Quality,
+	dna.Trace,
+	dna.Run,
+	dna.Primers,
+}
+
+// PrevAccession returns the accession of a sequence
+// already in the collection
+// with the same specimen, gene, and accession
+// (ignoring the accession version).
+func prevAccession(coll *dna.Collection, spec, gene, acc string) string {
+	base := accessionBase(acc)
+	for _, a := range coll.GeneAccession(spec, gene) {
+		if a == acc {
+			return a
+		}
+		if strings.EqualFold(accessionBase(a), base) {
+			return a
+		}
+	}
+	return ""
+}
+
+// AccessionBase returns an accession
+// without its version.
+func accessionBase(acc string) string {
+	i := strings.LastIndex(acc, ".")
+	if i < 0 {
+		return acc
+	}
+	if _, err := strconv.Atoi(acc[i+1:]); err != nil {
+		return acc
+	}
+	return acc[:i]
+}
+
 func openProject(name string) (*project.Project, error) {
 	p, err := project.Read(name)
 	if errors.Is(err, os.ErrNotExist) {
@@ -151,37 +396,61 @@ func openProject(name string) (*project.Project, error) {
 	return p, nil
 }
 
-func readDNAFile(name string, c *dna.Collection) error {
+// ParseDelimiter returns the field delimiter
+// defined by a flag value.
+func parseDelimiter(val string) (rune, error) {
+	switch strings.ToLower(strings.TrimSpace(val)) {
+	case "":
+		return 0, nil
+	case "tab", `\t`:
+		return '\t', nil
+	case "comma", ",":
+		return ',', nil
+	case "semicolon", ";":
+		return ';', nil
+	}
+	return 0, fmt.Errorf("invalid delimiter %q", val)
+}
+
+func readHeaders(name string) (dna.Headers, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h, err := dna.ReadHeaders(f)
+	if err != nil {
+		return nil, fmt.Errorf("on file %q: %v", name, err)
+	}
+	return h, nil
+}
+
+func readDNAFile(name string, read func(io.Reader) error) error {
 	f, err := os.Open(name)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	if err := c.ReadTSV(f); err != nil {
+	if err := read(progress.Reader(f, name)); err != nil {
 		return fmt.Errorf("while reading file %q: %v", name, err)
 	}
 	return nil
 }
 
-func writeDNA(name string, c *dna.Collection) (err error) {
-	f, err := os.Create(name)
+func readAliases(name string) (dna.Aliases, error) {
+	f, err := os.Open(name)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer func() {
-		e := f.Close()
-		if e != nil && err == nil {
-			err = e
-		}
-	}()
+	defer f.Close()
 
-	fmt.Fprintf(f, "# phydata: DNA sequences\n")
-	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
-	if err := c.TSV(f); err != nil {
-		return fmt.Errorf("while writing to %q: %v", name, err)
+	a, err := dna.ReadAliases(f)
+	if err != nil {
+		return nil, fmt.Errorf("on file %q: %v", name, err)
 	}
-	return nil
+	return a, nil
 }
 
 func readFilter(name string) (map[string]bool, error) {
@@ -0,0 +1,226 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package dedupe implements a command to detect
+// duplicated DNA sequences in a PhyData project.
+package dedupe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `dedupe [--identity <value>] [--min-overlap <number>] [--across]
+	[--remove] [--keep <policy>]
+	<project-file> [<gene>...]`,
+	Short: "detect duplicated DNA sequences",
+	Long: `
+Command dedupe reads the DNA sequences of a PhyData project and reports the
+sequences of the same gene that are identical, or nearly identical, within a
+taxon.
+
+The first argument of the command is the name of the project file.
+
+The second and following arguments are optional, and are the names of the
+genes to be checked. If no gene is given, all genes will be checked.
+
+Two sequences are considered duplicates if the fraction of identical bases is
+equal or larger than 0.99. Use the flag --identity to define a different
+threshold. Aligned sequences are compared position by position; unaligned
+sequences are considered duplicates only if one sequence is contained in the
+other (ignoring gaps).
+
+Partial sequences can share only a few positions, so two sequences are
+compared only if they share at least 50 bases (ignoring gaps and missing
+data). Use the flag --min-overlap to define a different number of bases.
+Aligned sequences must also share at least half of the bases of the shorter
+sequence. Pairs that are not comparable are never reported, nor removed.
+
+By default, only sequences of the same taxon are compared. If the flag
+--across is defined, sequences of different taxa will be compared too. This is
+a common signal of contamination or mislabeled sequences. Duplicates across
+taxa are only reported.
+
+The output is a tab-delimited table with the gene, the taxon, specimen and
+accession of both sequences, and their identity.
+
+If the flag --remove is defined, one of the sequences of each duplicated pair
+within a taxon will be removed. By default, the sequence with more bases will
+be kept. Use the flag --keep to define a different policy. Valid policies are:
+
+	longest  keep the sequence with more bases (default)
+	first    keep the sequence with the first accession, in alphabetical
+	         order
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var identity float64
+var minOverlap int
+var across bool
+var remove bool
+var keep string
+
+func setFlags(c *command.Command) {
+	c.Flags().Float64Var(&identity, "identity", 0.99, "")
+	c.Flags().IntVar(&minOverlap, "min-overlap", 50, "")
+	c.Flags().BoolVar(&across, "across", false, "")
+	c.Flags().BoolVar(&remove, "remove", false, "")
+	c.Flags().StringVar(&keep, "keep", "longest", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	keep = strings.ToLower(keep)
+	if keep != "longest" && keep != "first" {
+		return fmt.Errorf("unknown policy %q", keep)
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+
+	if p.Path(project.DNA) == "" {
+		return fmt.Errorf("undefined DNA file")
+	}
+	coll := dna.New()
+	files, err := dnafiles.Read(p, coll)
+	if err != nil {
+		return fmt.Errorf("on project %q: %v", pFile, err)
+	}
+
+	genes := coll.Genes()
+	if len(args) > 1 {
+		genes = args[1:]
+	}
+
+	fmt.Fprintf(c.Stdout(), "gene\ttaxon\tspecimen\taccession\ttaxon\tspecimen\taccession\tidentity\n")
+	var removed int
+	for _, g := range genes {
+		g = strings.ToLower(strings.TrimSpace(g))
+		seqs := geneSequences(coll, g)
+		deleted := make(map[int]bool)
+		for i, a := range seqs {
+			for j := i + 1; j < len(seqs); j++ {
+				b := seqs[j]
+				same := a.taxon == b.taxon
+				if !same && !across {
+					continue
+				}
+				id, ok := seqIdentity(a, b)
+				if !ok || id < identity {
+					continue
+				}
+				fmt.Fprintf(c.Stdout(), "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%.4f\n", g, a.taxon, a.spec, a.acc, b.taxon, b.spec, b.acc, id)
+
+				if !remove || !same {
+					continue
+				}
+				if deleted[i] || deleted[j] {
+					continue
+				}
+				del := j
+				if keep == "longest" && countBases(b.seq) > countBases(a.seq) {
+					del = i
+				}
+				deleted[del] = true
+			}
+		}
+
+		for i := range deleted {
+			s := seqs[i]
+			coll.Delete(s.spec, g, s.acc)
+			removed++
+		}
+	}
+
+	if removed == 0 {
+		return nil
+	}
+	if err := files.Write(coll); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.Stderr(), "%d sequences removed\n", removed)
+	return nil
+}
+
+type sequence struct {
+	taxon   string
+	spec    string
+	acc     string
+	seq     string
+	aligned bool
+}
+
+func geneSequences(coll *dna.Collection, gene string) []sequence {
+	var seqs []sequence
+	for _, tax := range coll.Taxa() {
+		for _, spec := range coll.TaxSpec(tax) {
+			for _, acc := range coll.GeneAccession(spec, gene) {
+				seqs = append(seqs, sequence{
+					taxon:   tax,
+					spec:    spec,
+					acc:     acc,
+					seq:     coll.Sequence(spec, gene, acc),
+					aligned: coll.Val(spec, gene, acc, dna.Aligned) == "true",
+				})
+			}
+		}
+	}
+	return seqs
+}
+
+// SeqIdentity returns the identity of two sequences,
+// and false if the sequences share less than
+// the minimum overlap.
+func seqIdentity(a, b sequence) (float64, bool) {
+	if a.aligned && b.aligned && len(a.seq) == len(b.seq) {
+		if dna.Overlap(a.seq, b.seq) < minOverlap {
+			return 0, false
+		}
+		id := dna.Identity(a.seq, b.seq)
+		return id, id > 0
+	}
+
+	if min(countBases(a.seq), countBases(b.seq)) < minOverlap {
+		return 0, false
+	}
+	sa := dna.Ungap(a.seq)
+	sb := dna.Ungap(b.seq)
+	if strings.Contains(sa, sb) || strings.Contains(sb, sa) {
+		return 1, true
+	}
+	return 0, true
+}
+
+func countBases(seq string) int {
+	var n int
+	for _, b := range seq {
+		switch b {
+		case '-', '?', 'n':
+			continue
+		}
+		n++
+	}
+	return n
+}
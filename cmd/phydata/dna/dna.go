@@ -8,13 +8,39 @@ package dna
 
 import (
 	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dna/accessions"
 	"github.com/js-arias/phydata/cmd/phydata/dna/add"
+	"github.com/js-arias/phydata/cmd/phydata/dna/dedupe"
+	"github.com/js-arias/phydata/cmd/phydata/dna/genes"
+	"github.com/js-arias/phydata/cmd/phydata/dna/mask"
+	"github.com/js-arias/phydata/cmd/phydata/dna/primers"
+	"github.com/js-arias/phydata/cmd/phydata/dna/screen"
+	"github.com/js-arias/phydata/cmd/phydata/dna/set"
+	"github.com/js-arias/phydata/cmd/phydata/dna/specimens"
+	"github.com/js-arias/phydata/cmd/phydata/dna/store"
+	"github.com/js-arias/phydata/cmd/phydata/dna/summary"
 	"github.com/js-arias/phydata/cmd/phydata/dna/taxa"
+	"github.com/js-arias/phydata/cmd/phydata/dna/translate"
+	"github.com/js-arias/phydata/cmd/phydata/dna/trim"
+	"github.com/js-arias/phydata/cmd/phydata/dna/validate"
 )
 
 func init() {
+	Command.Add(accessions.Command)
 	Command.Add(add.Command)
+	Command.Add(dedupe.Command)
+	Command.Add(genes.Command)
+	Command.Add(mask.Command)
+	Command.Add(primers.Command)
+	Command.Add(screen.Command)
+	Command.Add(set.Command)
+	Command.Add(specimens.Command)
+	Command.Add(store.Command)
+	Command.Add(summary.Command)
 	Command.Add(taxa.Command)
+	Command.Add(translate.Command)
+	Command.Add(trim.Command)
+	Command.Add(validate.Command)
 }
 
 var Command = &command.Command{
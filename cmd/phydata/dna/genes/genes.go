@@ -0,0 +1,192 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package genes implements a command to list
+// the genes of a PhyData project.
+package genes
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: "genes [--json] [--merge] [--aliases <file>] <project-file>",
+	Short: "list the genes of a project",
+	Long: `
+Command genes reads the DNA sequences of a PhyData project and prints the
+genes in the project.
+
+The argument of the command is the name of the project file.
+
+The output is a TSV table with the name of the gene, the number of taxa with
+sequences of the gene, the number of sequences, and the maximum length of the
+sequences of the gene. Use the flag --json to print the output as a JSON
+array, with an object for each gene.
+
+If the flag --merge is defined, the synonymous genes of the project will be
+merged, using a table of common gene synonyms (e.g., 'coi' and 'coxi' are
+merged into 'cox1'; 'rrnl' and '16s rrna' into '16s'). Additional synonyms
+can be defined with the flag --aliases, with a tab-delimited file in which
+each line is a gene, the first field is the canonical name, and the following
+fields are its synonyms. Empty lines or lines starting with '#' will be
+ignored. The merged genes will be reported.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var merge bool
+var aliasFile string
+var jsonFlag bool
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&jsonFlag, "json", false, "")
+	c.Flags().BoolVar(&merge, "merge", false, "")
+	c.Flags().StringVar(&aliasFile, "aliases", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if merge && jsonFlag {
+		return c.UsageError("flag --merge is incompatible with flag --json")
+	}
+
+	unlock, err := lock.Project(args[0])
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	if p.Path(project.DNA) == "" {
+		return fmt.Errorf("undefined DNA file")
+	}
+	coll := dna.New()
+	files, err := dnafiles.Read(p, coll)
+	if err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	if jsonFlag {
+		return writeJSON(c.Stdout(), coll)
+	}
+	if !merge {
+		return writeGenes(c.Stdout(), coll)
+	}
+
+	aliases := dna.DefaultAliases()
+	if aliasFile != "" {
+		a, err := readAliases(aliasFile)
+		if err != nil {
+			return err
+		}
+		aliases.Merge(a)
+	}
+
+	var merged int
+	for _, g := range coll.Genes() {
+		n := aliases.Gene(g)
+		if n == g {
+			continue
+		}
+		coll.RenameGene(g, n)
+		fmt.Fprintf(c.Stdout(), "merged %s: %s\n", n, g)
+		merged++
+	}
+	if merged == 0 {
+		return nil
+	}
+
+	if err := files.Write(coll); err != nil {
+		return err
+	}
+	return nil
+}
+
+func writeGenes(w io.Writer, coll *dna.Collection) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	if err := tab.Write([]string{"gene", "taxa", "sequences", "length"}); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+	for _, g := range coll.Genes() {
+		st := coll.LenStats(g)
+		row := []string{
+			g,
+			strconv.Itoa(st.Taxa),
+			strconv.Itoa(st.Sequences),
+			strconv.Itoa(coll.MaxLen(g)),
+		}
+		if err := tab.Write(row); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+	}
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+// A geneData is the number of taxa and sequences,
+// and the maximum length of the sequences,
+// of a gene.
+type geneData struct {
+	Gene      string `json:"gene"`
+	Taxa      int    `json:"taxa"`
+	Sequences int    `json:"sequences"`
+	Length    int    `json:"length"`
+}
+
+func writeJSON(w io.Writer, coll *dna.Collection) error {
+	data := []geneData{}
+	for _, g := range coll.Genes() {
+		st := coll.LenStats(g)
+		data = append(data, geneData{
+			Gene:      g,
+			Taxa:      st.Taxa,
+			Sequences: st.Sequences,
+			Length:    coll.MaxLen(g),
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+func readAliases(name string) (dna.Aliases, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	a, err := dna.ReadAliases(f)
+	if err != nil {
+		return nil, fmt.Errorf("on file %q: %v", name, err)
+	}
+	return a, nil
+}
@@ -0,0 +1,254 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package mask implements a command to define
+// exclusion masks of aligned genes
+// in a PhyData project.
+package mask
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `mask [--add <range>] [--delete <range>] [--comment <text>]
+	[-f|--file <file>] <project-file> [<gene>]`,
+	Short: "define exclusion masks of aligned genes",
+	Long: `
+Command mask reads the exclusion masks of a PhyData project, and adds or
+removes excluded regions of an aligned gene. An excluded region is a range of
+aligned columns (for example, an ambiguously aligned region) that should not
+be used in the analysis.
+
+The first argument of the command is the name of the project file.
+
+The second argument is the name of the gene. If no flag is defined, the
+command prints the excluded regions of the gene as a TSV table with the gene,
+the first and last excluded columns, and the comments on the region. If no
+gene is given, the regions of all genes will be printed.
+
+Use the flag --add to add one or more excluded regions. Regions are defined
+by the first and last excluded columns (1-based) separated by a dash, and
+multiple regions are separated by commas (e.g., '210-245,501-530'). A single
+column can be given as a single number. Use the flag --comment to add a
+comment to the added regions.
+
+Use the flag --delete to remove one or more excluded regions, using the same
+format of the flag --add. Use the value 'all' to remove all the regions of
+the gene.
+
+By default, the masks will be stored in the exclusions file defined for the
+project. If the project does not have an exclusions file, a new one will be
+created with the name 'exclusions.tab'. A different file name can be defined
+with the flag --file or -f. If this flag is used, and there is an exclusions
+file already defined, then a new file will be created, and used as the
+exclusions file for the project (previously defined masks will be kept).
+
+The masks are used by the command 'phydata matrix', either as exclusion sets,
+or to physically remove the excluded columns.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var addFlag string
+var delFlag string
+var comment string
+var maskFile string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&addFlag, "add", "", "")
+	c.Flags().StringVar(&delFlag, "delete", "", "")
+	c.Flags().StringVar(&comment, "comment", "", "")
+	c.Flags().StringVar(&maskFile, "file", "", "")
+	c.Flags().StringVar(&maskFile, "f", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+
+	ex := dna.NewExclusions()
+	if ef := p.Path(project.Exclusions); ef != "" {
+		if err := readExclusions(ef, ex); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+
+	var gene string
+	if len(args) > 1 {
+		gene = strings.ToLower(strings.Join(strings.Fields(strings.Join(args[1:], " ")), " "))
+	}
+
+	if addFlag == "" && delFlag == "" {
+		return writeRegions(c.Stdout(), ex, gene)
+	}
+	if gene == "" {
+		return c.UsageError("expecting gene name")
+	}
+
+	if delFlag != "" {
+		if strings.ToLower(strings.TrimSpace(delFlag)) == "all" {
+			ex.Delete(gene, 0, 0)
+		} else {
+			regs, err := parseRegions(delFlag)
+			if err != nil {
+				return c.UsageError(fmt.Sprintf("flag --delete: %v", err))
+			}
+			for _, r := range regs {
+				ex.Delete(gene, r[0], r[1])
+			}
+		}
+	}
+	if addFlag != "" {
+		regs, err := parseRegions(addFlag)
+		if err != nil {
+			return c.UsageError(fmt.Sprintf("flag --add: %v", err))
+		}
+		for _, r := range regs {
+			if err := ex.Add(gene, r[0], r[1], comment); err != nil {
+				return err
+			}
+		}
+	}
+
+	if maskFile == "" {
+		maskFile = p.Path(project.Exclusions)
+		if maskFile == "" {
+			maskFile = "exclusions.tab"
+		}
+	}
+	if p.ReadOnly(project.Exclusions, maskFile) {
+		return fmt.Errorf("on project %q: file %q is read-only", pFile, p.Source(project.Exclusions, maskFile))
+	}
+	if err := writeExclusions(maskFile, ex); err != nil {
+		return err
+	}
+
+	p.Add(project.Exclusions, maskFile)
+	if err := p.Write(pFile); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseRegions parses a list of regions
+// separated by commas.
+func parseRegions(val string) ([][2]int, error) {
+	var regs [][2]int
+	for _, v := range strings.Split(val, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(v, "-")
+		if !ok {
+			to = from
+		}
+		f, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("invalid region %q: %v", v, err)
+		}
+		t, err := strconv.Atoi(strings.TrimSpace(to))
+		if err != nil {
+			return nil, fmt.Errorf("invalid region %q: %v", v, err)
+		}
+		regs = append(regs, [2]int{f, t})
+	}
+	if len(regs) == 0 {
+		return nil, fmt.Errorf("undefined regions")
+	}
+	return regs, nil
+}
+
+func writeRegions(w io.Writer, ex *dna.Exclusions, gene string) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	if err := tab.Write([]string{"gene", "from", "to", "comments"}); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+
+	genes := ex.Genes()
+	if gene != "" {
+		genes = []string{gene}
+	}
+	for _, g := range genes {
+		for _, r := range ex.Regions(g) {
+			row := []string{
+				g,
+				strconv.Itoa(r.From),
+				strconv.Itoa(r.To),
+				r.Comment,
+			}
+			if err := tab.Write(row); err != nil {
+				return fmt.Errorf("while writing data: %v", err)
+			}
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+func readExclusions(name string, ex *dna.Exclusions) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := ex.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeExclusions(name string, ex *dna.Exclusions) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: exclusion masks\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Exclusions))
+	if err := ex.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
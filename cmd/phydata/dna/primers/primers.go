@@ -0,0 +1,248 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package primers implements a command to manage
+// the primers used to sequence the genes
+// of a PhyData project.
+package primers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `primers [--add <file>] [--registry]
+	[-f|--file <file>] <project-file> [<gene>]`,
+	Short: "manage the primers used to sequence the genes",
+	Long: `
+Command primers reads the primer registry of a PhyData project, and prints
+the primers used to sequence each specimen.
+
+The first argument of the command is the name of the project file.
+
+The second argument is optional, and is the name of a gene. If given, only
+the primers of that gene will be printed.
+
+By default, the command prints a TSV table with the specimen, the gene, the
+GenBank accession, and the primer used for each sequence, as well as the
+direction, the sequence, and the reference of the primer as defined in the
+registry. The primers of a sequence are defined in the 'primers' field of the
+DNA file (see 'phydata dna set'). Primers not found in the registry are
+printed without direction, sequence, or reference. This table is useful to
+write the methods section of a paper, or to find the primers associated with
+failed amplifications.
+
+Use the flag --registry to print the primers in the registry, instead of the
+primers used for each specimen.
+
+Use the flag --add to add the primers in a file to the registry. The file is
+a TSV file with the following fields:
+
+	name       the name of the primer
+	gene       the gene amplified by the primer
+	sequence   the sequence of the primer (optional)
+	direction  either "forward" or "reverse" (optional)
+	reference  an ID of a bibliographic reference (optional)
+
+If a primer is already in the registry, it will be replaced.
+
+By default, the primers will be stored in the primers file defined for the
+project. If the project does not have a primers file, a new one will be
+created with the name 'primers.tab'. A different file name can be defined with
+the flag --file or -f. If this flag is used, and there is a primers file
+already defined, then a new file will be created, and used as the primers file
+for the project (previously defined primers will be kept).
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var addFlag string
+var registryFlag bool
+var primerFile string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&addFlag, "add", "", "")
+	c.Flags().BoolVar(&registryFlag, "registry", false, "")
+	c.Flags().StringVar(&primerFile, "file", "", "")
+	c.Flags().StringVar(&primerFile, "f", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+
+	ps := dna.NewPrimerSet()
+	if pf := p.Path(project.Primers); pf != "" {
+		if err := readFile(pf, ps.ReadTSV); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+
+	var gene string
+	if len(args) > 1 {
+		gene = strings.ToLower(strings.Join(strings.Fields(strings.Join(args[1:], " ")), " "))
+	}
+
+	if addFlag != "" {
+		if err := readFile(addFlag, ps.ReadTSV); err != nil {
+			return err
+		}
+		if primerFile == "" {
+			primerFile = p.Path(project.Primers)
+			if primerFile == "" {
+				primerFile = "primers.tab"
+			}
+		}
+		if p.ReadOnly(project.Primers, primerFile) {
+			return fmt.Errorf("on project %q: file %q is read-only", pFile, p.Source(project.Primers, primerFile))
+		}
+		if err := writePrimers(primerFile, ps); err != nil {
+			return err
+		}
+
+		p.Add(project.Primers, primerFile)
+		if err := p.Write(pFile); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if registryFlag {
+		return writeRegistry(c.Stdout(), ps, gene)
+	}
+
+	if p.Path(project.DNA) == "" {
+		return fmt.Errorf("on project %q: undefined DNA file", pFile)
+	}
+	coll := dna.New()
+	if _, err := dnafiles.Read(p, coll); err != nil {
+		return fmt.Errorf("on project %q: %v", pFile, err)
+	}
+	return writeUsed(c.Stdout(), coll, ps, gene)
+}
+
+func writeUsed(w io.Writer, coll *dna.Collection, ps *dna.PrimerSet, gene string) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	header := []string{
+		"specimen",
+		"gene",
+		"genbank",
+		"primer",
+		"direction",
+		"sequence",
+		"reference",
+	}
+	if err := tab.Write(header); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+
+	for _, spec := range coll.Specimens() {
+		for _, g := range coll.SpecGene(spec) {
+			if gene != "" && g != gene {
+				continue
+			}
+			for _, acc := range coll.GeneAccession(spec, g) {
+				for _, n := range coll.SeqPrimers(spec, g, acc) {
+					pr, ok := ps.Primer(n)
+					if !ok {
+						pr = dna.Primer{Name: n}
+					}
+					row := []string{
+						spec,
+						g,
+						acc,
+						pr.Name,
+						pr.Direction,
+						pr.Sequence,
+						pr.Reference,
+					}
+					if err := tab.Write(row); err != nil {
+						return fmt.Errorf("while writing data: %v", err)
+					}
+				}
+			}
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+func writeRegistry(w io.Writer, ps *dna.PrimerSet, gene string) error {
+	if gene == "" {
+		return ps.TSV(w)
+	}
+
+	reg := dna.NewPrimerSet()
+	for _, pr := range ps.Gene(gene) {
+		if err := reg.Add(pr); err != nil {
+			return err
+		}
+	}
+	return reg.TSV(w)
+}
+
+func readFile(name string, read func(io.Reader) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := read(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func writePrimers(name string, ps *dna.PrimerSet) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: primers\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Primers))
+	if err := ps.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package screen implements a command to screen
+// DNA sequences for possible contamination
+// or mislabeled sequences.
+package screen
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/names"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `screen [-k <value>] [--neighbors <number>]
+	[--groups <file>]
+	<project-file> [<gene>...]`,
+	Short: "screen DNA sequences for contamination",
+	Long: `
+Command screen reads the DNA sequences of a PhyData project, compares each
+sequence against the other sequences of the same gene, and reports the
+sequences whose nearest neighbors are from a different taxonomic group. This
+is a common sign of mislabeled or contaminated sequences.
+
+The first argument of the command is the name of the project file.
+
+The second and following arguments are optional, and are the names of the
+genes to be screened. If no gene is given, all genes will be screened.
+
+Sequences are compared using a k-mer distance: the fraction of words of
+length k of the shorter sequence that are not found in the other sequence. By
+default, k is 8. Use the flag -k to define a different word length.
+
+By default, the three nearest sequences are used as neighbors. Use the flag
+--neighbors to define a different number of neighbors. A sequence is reported
+if none of its neighbors belong to the same group of the sequence, and there
+are other sequences of the same group for that gene.
+
+By default, the group of a taxon is its genus (the first word of the taxon
+name). Use the flag --groups to define a file with the expected group of each
+taxon. The file must be a tab-delimited file with the columns "taxon" and
+"group". Taxa not defined in the file are assigned to its genus.
+
+The output is a tab-delimited table with the gene, the taxon, specimen, and
+accession of the sequence, its group, and the taxa and distances of the
+nearest neighbors.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var kmer int
+var neighbors int
+var groupsFile string
+
+func setFlags(c *command.Command) {
+	c.Flags().IntVar(&kmer, "k", 8, "")
+	c.Flags().IntVar(&neighbors, "neighbors", 3, "")
+	c.Flags().StringVar(&groupsFile, "groups", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if kmer < 1 {
+		return fmt.Errorf("invalid k-mer length %d", kmer)
+	}
+	if neighbors < 1 {
+		return fmt.Errorf("invalid number of neighbors %d", neighbors)
+	}
+
+	pFile := args[0]
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+
+	if p.Path(project.DNA) == "" {
+		return fmt.Errorf("undefined DNA file")
+	}
+	coll := dna.New()
+	if _, err := dnafiles.Read(p, coll); err != nil {
+		return fmt.Errorf("on project %q: %v", pFile, err)
+	}
+
+	groups := make(map[string]string)
+	if groupsFile != "" {
+		groups, err = readGroups(groupsFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	genes := coll.Genes()
+	if len(args) > 1 {
+		genes = args[1:]
+	}
+
+	fmt.Fprintf(c.Stdout(), "gene\ttaxon\tspecimen\taccession\tgroup\tneighbors\n")
+	for _, g := range genes {
+		g = strings.ToLower(strings.TrimSpace(g))
+		seqs := geneSequences(coll, g, groups)
+		for i, s := range seqs {
+			nb := nearest(seqs, i)
+			if len(nb) == 0 {
+				continue
+			}
+			if !hasGroup(seqs, i) {
+				continue
+			}
+
+			same := false
+			for _, n := range nb {
+				if seqs[n.index].group == s.group {
+					same = true
+					break
+				}
+			}
+			if same {
+				continue
+			}
+
+			ls := make([]string, 0, len(nb))
+			for _, n := range nb {
+				ls = append(ls, fmt.Sprintf("%s (%.3f)", seqs[n.index].taxon, n.dist))
+			}
+			fmt.Fprintf(c.Stdout(), "%s\t%s\t%s\t%s\t%s\t%s\n", g, s.taxon, s.spec, s.acc, s.group, strings.Join(ls, ", "))
+		}
+	}
+	return nil
+}
+
+type sequence struct {
+	taxon   string
+	group   string
+	spec    string
+	acc     string
+	profile dna.Profile
+}
+
+func geneSequences(coll *dna.Collection, gene string, groups map[string]string) []sequence {
+	var seqs []sequence
+	for _, tax := range coll.Taxa() {
+		grp, ok := groups[tax]
+		if !ok {
+			grp = genus(tax)
+		}
+		for _, spec := range coll.TaxSpec(tax) {
+			for _, acc := range coll.GeneAccession(spec, gene) {
+				seqs = append(seqs, sequence{
+					taxon:   tax,
+					group:   grp,
+					spec:    spec,
+					acc:     acc,
+					profile: dna.KmerProfile(coll.Sequence(spec, gene, acc), kmer),
+				})
+			}
+		}
+	}
+	return seqs
+}
+
+type neighbor struct {
+	index int
+	dist  float64
+}
+
+func nearest(seqs []sequence, i int) []neighbor {
+	nb := make([]neighbor, 0, len(seqs))
+	for j, s := range seqs {
+		if j == i {
+			continue
+		}
+		// ignore sequences of the same specimen
+		if s.spec == seqs[i].spec {
+			continue
+		}
+		nb = append(nb, neighbor{
+			index: j,
+			dist:  seqs[i].profile.Distance(s.profile),
+		})
+	}
+	slices.SortStableFunc(nb, func(a, b neighbor) int {
+		if a.dist < b.dist {
+			return -1
+		}
+		if a.dist > b.dist {
+			return 1
+		}
+		return 0
+	})
+	if len(nb) > neighbors {
+		nb = nb[:neighbors]
+	}
+	return nb
+}
+
+// HasGroup returns true if there is another sequence
+// of the same group of the indicated sequence.
+func hasGroup(seqs []sequence, i int) bool {
+	for j, s := range seqs {
+		if j == i {
+			continue
+		}
+		if s.spec == seqs[i].spec {
+			continue
+		}
+		if s.group == seqs[i].group {
+			return true
+		}
+	}
+	return false
+}
+
+func genus(name string) string {
+	name, _, _ = strings.Cut(name, " ")
+	return strings.ToLower(name)
+}
+
+func readGroups(name string) (map[string]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tab := csv.NewReader(f)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return nil, fmt.Errorf("on file %q: while reading header: %v", name, err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(h)
+		fields[h] = i
+	}
+	for _, h := range []string{"taxon", "group"} {
+		if _, ok := fields[h]; !ok {
+			return nil, fmt.Errorf("on file %q: expecting field %q", name, h)
+		}
+	}
+
+	groups := make(map[string]string)
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("on file %q: on row %d: %v", name, ln, err)
+		}
+
+		f := "taxon"
+		tax := names.Taxon(row[fields[f]])
+		if tax == "" {
+			continue
+		}
+
+		f = "group"
+		grp := strings.Join(strings.Fields(row[fields[f]]), " ")
+		if grp == "" {
+			continue
+		}
+		groups[tax] = strings.ToLower(grp)
+	}
+	return groups, nil
+}
@@ -0,0 +1,163 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package set implements a command to set the fields
+// of a DNA sequence
+// in a PhyData project.
+package set
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `set [--accession <accession>]
+	<project-file> <specimen> <gene> <field>=<value>...`,
+	Short: "set the fields of a DNA sequence",
+	Long: `
+Command set reads a PhyData project, and sets the values of one or more
+fields of a DNA sequence.
+
+The first argument of the command is the name of the project file.
+
+The second and third arguments are the specimen and the gene of the sequence.
+If the specimen has more than one sequence of the gene, use the flag
+--accession to define the GenBank accession of the sequence.
+
+The following arguments are the fields that will be set, in the form
+<field>=<value> (e.g., 'quality=38.2'). An empty value removes the value of
+the field. Valid fields are:
+
+	aligned    if "true" the sequence is aligned
+	protein    if "true" the molecule product is a protein
+	organelle  the celular organelle that contains the sequence
+	molecule   the kind of molecule ('dna', 'rna', or 'rrna')
+	structure  the secondary structure of an RNA sequence
+	start      the first position of the sequence in the reference gene
+	end        the last position of the sequence in the reference gene
+	strand     the strand of the sequence ('+' or '-')
+	reference  an ID of a bibliographic reference
+	comments   simple additional comments about the sequence
+	quality    a summary of the sequencing quality (e.g., the mean phred
+	           score)
+	trace      a link to the trace (chromatogram) file
+	run        the sequencing run
+	primers    the primers used to sequence the gene, separated by commas
+
+The sequencing fields (quality, trace, run, and primers) are intended for
+lab-generated sequences, so they carry their quality control metadata
+alongside the sequences downloaded from GenBank.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var accession string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&accession, "accession", "", "")
+}
+
+// Fields are the fields that can be set.
+var fields = []dna.Field{
+	dna.Aligned,
+	dna.Protein,
+	dna.Organelle,
+	dna.Molecule,
+	dna.Structure,
+	dna.Start,
+	dna.End,
+	dna.Strand,
+	dna.Reference,
+	dna.Comments,
+	dna.Quality,
+	dna.Trace,
+	dna.Run,
+	dna.Primers,
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if len(args) < 2 {
+		return c.UsageError("expecting specimen")
+	}
+	if len(args) < 3 {
+		return c.UsageError("expecting gene")
+	}
+	if len(args) < 4 {
+		return c.UsageError("expecting field values")
+	}
+
+	vals := make(map[dna.Field]string, len(args)-3)
+	for _, a := range args[3:] {
+		k, v, ok := strings.Cut(a, "=")
+		if !ok {
+			return c.UsageError(fmt.Sprintf("invalid field value %q", a))
+		}
+		f := dna.Field(strings.ToLower(strings.TrimSpace(k)))
+		if !slices.Contains(fields, f) {
+			return c.UsageError(fmt.Sprintf("unknown field %q", k))
+		}
+		vals[f] = v
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+	if p.Path(project.DNA) == "" {
+		return fmt.Errorf("on project %q: undefined DNA file", pFile)
+	}
+	coll := dna.New()
+	files, err := dnafiles.Read(p, coll)
+	if err != nil {
+		return fmt.Errorf("on project %q: %v", pFile, err)
+	}
+
+	spec, gene := args[1], args[2]
+	acc := accession
+	if acc == "" {
+		ls := coll.GeneAccession(spec, gene)
+		if len(ls) == 0 {
+			return fmt.Errorf("on project %q: specimen %q: gene %q: sequence not found", pFile, spec, gene)
+		}
+		if len(ls) > 1 {
+			return fmt.Errorf("on project %q: specimen %q: gene %q: multiple sequences %v, use --accession", pFile, spec, gene, ls)
+		}
+		acc = ls[0]
+	}
+	if !slices.Contains(coll.GeneAccession(spec, gene), acc) {
+		return fmt.Errorf("on project %q: specimen %q: gene %q: accession %q not found", pFile, spec, gene, acc)
+	}
+
+	for _, f := range fields {
+		v, ok := vals[f]
+		if !ok {
+			continue
+		}
+		coll.Set(spec, gene, acc, v, f)
+	}
+
+	if err := files.Write(coll); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package specimens implements a command to print the specimens
+// with DNA sequences in a PhyData project.
+package specimens
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: "specimens [--taxon <name>] <project-file>",
+	Short: "print specimens",
+	Long: `
+Command specimens reads a PhyData project and print the list of specimens
+with DNA sequences in the project.
+
+The argument of the command is the name of the project-file.
+
+By default, the specimens of all taxa are printed. Use the flag --taxon to
+print only the specimens of a given taxon.
+
+The output is a TSV table with the specimen, its taxon, the number of genes
+sequenced, and the number of sequences of the specimen.
+
+If the project has several DNA files, the specimens of all the files are
+printed. Only the index of each DNA file is read: either the index of a store (see
+'phydata dna store'), or an index sidecar file (the DNA file name with the
+'.idx' extension). The sidecar is written the first time the command is used,
+and it is rebuilt if the DNA file was modified.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var taxonFlag string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&taxonFlag, "taxon", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	if p.Path(project.DNA) == "" {
+		return fmt.Errorf("undefined DNA file")
+	}
+	specs := make(map[string]*specInfo)
+	for _, df := range p.Paths(project.DNA) {
+		if err := readSpecimens(df, specs); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+	}
+
+	taxon := strings.Join(strings.Fields(taxonFlag), " ")
+	ls := make([]string, 0, len(specs))
+	for sp, si := range specs {
+		if taxon != "" && !strings.EqualFold(si.taxon, taxon) {
+			continue
+		}
+		ls = append(ls, sp)
+	}
+	slices.SortFunc(ls, func(a, b string) int {
+		if c := cmp.Compare(specs[a].taxon, specs[b].taxon); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+
+	tab := csv.NewWriter(c.Stdout())
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	if err := tab.Write([]string{"specimen", "taxon", "genes", "sequences"}); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+	for _, sp := range ls {
+		si := specs[sp]
+		row := []string{
+			sp,
+			si.taxon,
+			strconv.Itoa(len(si.genes)),
+			strconv.Itoa(si.seqs),
+		}
+		if err := tab.Write(row); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+	}
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+// A specInfo is the summary
+// of the sequences of a specimen.
+type specInfo struct {
+	taxon string
+	genes map[string]bool
+	seqs  int
+}
+
+// ReadSpecimens adds the specimens of a DNA file,
+// using the index of the file.
+func readSpecimens(name string, specs map[string]*specInfo) error {
+	coll, err := dna.OpenIndexed(name)
+	if err != nil {
+		return err
+	}
+	defer coll.Close()
+
+	for _, tx := range coll.Taxa() {
+		for _, sp := range coll.TaxSpec(tx) {
+			si, ok := specs[sp]
+			if !ok {
+				si = &specInfo{
+					taxon: tx,
+					genes: make(map[string]bool),
+				}
+				specs[sp] = si
+			}
+			for _, g := range coll.SpecGene(sp) {
+				si.genes[g] = true
+				si.seqs += len(coll.GeneAccession(sp, g))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package store implements a command to convert
+// the DNA sequences of a PhyData project
+// into an indexed store file.
+package store
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/cmd/phydata/progress"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: "store [--tsv] <project-file> <file>",
+	Short: "convert DNA sequences into an indexed store",
+	Long: `
+Command store reads the DNA sequences of a PhyData project, and writes them
+into an indexed store file, that will be used as the DNA file of the project.
+
+The first argument of the command is the name of the project file.
+
+The second argument is the name of the store file. It can be the same as the
+current DNA file of the project. If the project has several DNA files, the
+sequences of all the files are merged into the store file, that will be the
+only DNA file of the project. Read-only files (see 'phydata project
+readonly') are kept as they are, and only the sequences that are not in a
+read-only file (or that were modified) are written into the store file.
+
+A store file is a regular DNA file (so it can be read by any command, or
+edited with a spreadsheet), with an index of the position of each sequence
+at the end of the file. With the index, some commands (e.g., 'phydata dna
+taxa', or 'phydata dna specimens') do not have to read the sequences, which
+is much faster in projects with hundreds of thousands of sequences. Commands
+that modify the sequences of the project keep the store format, and update
+the index.
+
+Commands that only read the index can also use a plain TSV file: they write
+an index sidecar file (the DNA file name with the '.idx' extension) that is
+rebuilt whenever the DNA file is modified. A store file has no sidecar.
+
+If the flag --tsv is defined, the sequences will be written as a plain TSV
+file (i.e., without the index), for example, to export the sequences into
+another program.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var tsvFlag bool
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&tsvFlag, "tsv", false, "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if len(args) < 2 {
+		return c.UsageError("expecting output file")
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+
+	if p.Path(project.DNA) == "" {
+		return fmt.Errorf("undefined DNA file")
+	}
+	coll := dna.New()
+	files, err := dnafiles.Read(p, coll)
+	if err != nil {
+		return fmt.Errorf("on project %q: %v", pFile, err)
+	}
+	progress.Printf("read %q: %d sequences", pFile, coll.NumSequences())
+
+	out := args[1]
+	if p.ReadOnly(project.DNA, out) {
+		return fmt.Errorf("on project %q: file %q is read-only", pFile, p.Source(project.DNA, out))
+	}
+	coll = files.Writable(coll)
+	if err := writeDNA(out, coll, !tsvFlag); err != nil {
+		return err
+	}
+	progress.Printf("wrote %q", out)
+
+	p.Add(project.DNA, out)
+	if err := p.Write(pFile); err != nil {
+		return err
+	}
+	return nil
+}
+
+func writeDNA(name string, c *dna.Collection, store bool) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	if store {
+		if err := c.WriteStore(f, project.VersionComment(project.DNA)); err != nil {
+			return fmt.Errorf("while writing to %q: %v", name, err)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(f, "# phydata: DNA sequences\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.DNA))
+	if err := c.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package summary implements a command to print a summary
+// of the DNA sequences of a PhyData project,
+// grouped by reference,
+// organelle,
+// and protein flag.
+package summary
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: "summary [--by <field>[,<field>...]] [--json] <project-file>",
+	Short: "print a summary of the DNA sequences",
+	Long: `
+Command summary reads the DNA sequences of a PhyData project, and prints the
+number of sequences grouped by the reference, the organelle, and the protein
+flag of the sequences, so it can be used to see, for example, how much of the
+dataset is mitochondrial versus nuclear.
+
+The argument of the command is the name of the project file.
+
+By default, the sequences are grouped by the three fields. Use the flag --by
+to define the fields used to group the sequences, as a comma separated list.
+Valid fields are 'reference', 'organelle', and 'protein'. For example, use
+'--by organelle' to count the sequences of each organelle. An empty
+organelle is usually a nuclear sequence.
+
+The output is a TSV table with the values of the grouping fields, the number
+of sequences, the number of specimens, the number of taxa, and the number of
+genes of each group. Groups are sorted by the values of the fields. Use the
+flag --json to print the output as a JSON array, with an object for each
+group.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var byFlag string
+var jsonFlag bool
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&byFlag, "by", "reference,organelle,protein", "")
+	c.Flags().BoolVar(&jsonFlag, "json", false, "")
+}
+
+// ValidFields are the fields
+// that can be used to group the sequences.
+var validFields = []dna.Field{
+	dna.Reference,
+	dna.Organelle,
+	dna.Protein,
+}
+
+// A group is the number of sequences,
+// specimens,
+// taxa,
+// and genes,
+// of a group of sequences.
+type group struct {
+	Fields    map[string]string `json:"fields"`
+	Sequences int               `json:"sequences"`
+	Specimens int               `json:"specimens"`
+	Taxa      int               `json:"taxa"`
+	Genes     int               `json:"genes"`
+
+	values []string
+	specs  map[string]bool
+	taxa   map[string]bool
+	genes  map[string]bool
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	var fields []dna.Field
+	for _, f := range strings.Split(byFlag, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" {
+			continue
+		}
+		if !slices.Contains(validFields, dna.Field(f)) {
+			return c.UsageError(fmt.Sprintf("unknown field %q", f))
+		}
+		if slices.Contains(fields, dna.Field(f)) {
+			continue
+		}
+		fields = append(fields, dna.Field(f))
+	}
+	if len(fields) == 0 {
+		return c.UsageError("expecting a field for flag --by")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	if p.Path(project.DNA) == "" {
+		return fmt.Errorf("undefined DNA file")
+	}
+	coll := dna.New()
+	if _, err := dnafiles.Read(p, coll); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	groups := summarize(coll, fields)
+	if jsonFlag {
+		return writeJSON(c.Stdout(), groups)
+	}
+	return writeTSV(c.Stdout(), fields, groups)
+}
+
+// Summarize returns the groups of sequences
+// of a collection,
+// sorted by the values of the fields.
+func summarize(coll *dna.Collection, fields []dna.Field) []*group {
+	taxon := make(map[string]string)
+	for _, tx := range coll.Taxa() {
+		for _, sp := range coll.TaxSpec(tx) {
+			taxon[sp] = tx
+		}
+	}
+
+	groups := make(map[string]*group)
+	for _, sp := range coll.Specimens() {
+		for _, g := range coll.SpecGene(sp) {
+			for _, acc := range coll.GeneAccession(sp, g) {
+				values := make([]string, 0, len(fields))
+				for _, f := range fields {
+					values = append(values, coll.Val(sp, g, acc, f))
+				}
+				key := strings.Join(values, "\x00")
+				gr, ok := groups[key]
+				if !ok {
+					gr = &group{
+						Fields: make(map[string]string, len(fields)),
+						values: values,
+						specs:  make(map[string]bool),
+						taxa:   make(map[string]bool),
+						genes:  make(map[string]bool),
+					}
+					for i, f := range fields {
+						gr.Fields[string(f)] = values[i]
+					}
+					groups[key] = gr
+				}
+				gr.Sequences++
+				gr.specs[sp] = true
+				gr.taxa[taxon[sp]] = true
+				gr.genes[g] = true
+			}
+		}
+	}
+
+	ls := make([]*group, 0, len(groups))
+	for _, gr := range groups {
+		gr.Specimens = len(gr.specs)
+		gr.Taxa = len(gr.taxa)
+		gr.Genes = len(gr.genes)
+		ls = append(ls, gr)
+	}
+	slices.SortFunc(ls, func(a, b *group) int {
+		return slices.Compare(a.values, b.values)
+	})
+	return ls
+}
+
+func writeTSV(w io.Writer, fields []dna.Field, groups []*group) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	header := make([]string, 0, len(fields)+4)
+	for _, f := range fields {
+		header = append(header, string(f))
+	}
+	header = append(header, "sequences", "specimens", "taxa", "genes")
+	if err := tab.Write(header); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+
+	for _, gr := range groups {
+		row := append(slices.Clone(gr.values),
+			strconv.Itoa(gr.Sequences),
+			strconv.Itoa(gr.Specimens),
+			strconv.Itoa(gr.Taxa),
+			strconv.Itoa(gr.Genes),
+		)
+		if err := tab.Write(row); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+	}
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, groups []*group) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(groups); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
@@ -7,8 +7,9 @@
 package taxa
 
 import (
+	"encoding/json"
 	"fmt"
-	"os"
+	"slices"
 
 	"github.com/js-arias/command"
 	"github.com/js-arias/phydata/matrix/dna"
@@ -16,15 +17,31 @@ import (
 )
 
 var Command = &command.Command{
-	Usage: "taxa <project-file>",
+	Usage: "taxa [--json] <project-file>",
 	Short: "print taxa",
 	Long: `
 Command taxa reads a PhyData project and print the list of taxa with
 DNA sequences in the project.
 
 The argument of the command is the name of the project-file.
+
+If the project has several DNA files, the taxa of all the files are printed.
+Only the index of each DNA file is read: either the index of a store (see
+'phydata dna store'), or an index sidecar file (the DNA file name with the
+'.idx' extension). The sidecar is written the first time the command is used,
+and it is rebuilt if the DNA file was modified.
+
+By default, each taxon is printed in a line. Use the flag --json to print the
+taxa as a JSON array.
 	`,
-	Run: run,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var jsonFlag bool
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&jsonFlag, "json", false, "")
 }
 
 func run(c *command.Command, args []string) error {
@@ -37,31 +54,43 @@ func run(c *command.Command, args []string) error {
 		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
 	}
 
-	df := p.Path(project.DNA)
-	if df == "" {
+	if p.Path(project.DNA) == "" {
 		return fmt.Errorf("undefined DNA file")
 	}
-	coll := dna.New()
-	if err := readDNAFile(df, coll); err != nil {
-		return fmt.Errorf("on project %q: %v", args[0], err)
+	var taxa []string
+	for _, df := range p.Paths(project.DNA) {
+		ls, err := readTaxa(df)
+		if err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+		taxa = append(taxa, ls...)
 	}
+	slices.Sort(taxa)
+	taxa = slices.Compact(taxa)
 
-	for _, tx := range coll.Taxa() {
+	if jsonFlag {
+		enc := json.NewEncoder(c.Stdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(taxa); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+		return nil
+	}
+
+	for _, tx := range taxa {
 		fmt.Fprintf(c.Stdout(), "%s\n", tx)
 	}
 
 	return nil
 }
 
-func readDNAFile(name string, c *dna.Collection) error {
-	f, err := os.Open(name)
+// ReadTaxa returns the taxa of a DNA file,
+// using the index of the file.
+func readTaxa(name string) ([]string, error) {
+	s, err := dna.OpenIndexed(name)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer f.Close()
-
-	if err := c.ReadTSV(f); err != nil {
-		return fmt.Errorf("while reading file %q: %v", name, err)
-	}
-	return nil
+	defer s.Close()
+	return s.Taxa(), nil
 }
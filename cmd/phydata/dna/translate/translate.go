@@ -0,0 +1,202 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package translate implements a command to translate
+// protein-coding DNA sequences into amino acid sequences.
+package translate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/matrix/protein"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `translate [--frame <number>] [--code <number>] [--strict]
+	[-f|--file <protein-file>]
+	<project-file> <gene>...`,
+	Short: "translate protein-coding DNA sequences",
+	Long: `
+Command translate reads the DNA sequences of one or more genes in a PhyData
+project, translates them into amino acid sequences, and stores the amino acid
+sequences in the proteins file of the project.
+
+The first argument of the command is the name of the project file.
+
+The second and following arguments are the names of the genes to be
+translated.
+
+By default, the sequences are translated from the first base. Use the flag
+--frame to define a different reading frame (1, 2, or 3). Sequences in the
+minus strand (as defined by the 'strand' field of the DNA file) are reverse
+complemented before the translation.
+
+By default, the standard genetic code is used. Use the flag --code to define a
+different genetic code, using the NCBI translation table numbers. Valid values
+are:
+
+	1	standard code
+	2	vertebrate mitochondrial code
+	3	yeast mitochondrial code
+	4	mold, protozoan, and coelenterate mitochondrial code
+	5	invertebrate mitochondrial code
+
+Sequences with internal stop codons are reported as errors in the standard
+output, and are not stored. If the flag --strict is defined, the command fails
+if any sequence has an internal stop codon, and no sequence is stored.
+
+By default, the amino acid sequences will be stored in the proteins file
+currently defined for the project. If the project does not have a proteins
+file, a new one will be created with the name 'proteins.tab'. A different file
+name can be defined using the flag --file or -f.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var frame int
+var code int
+var protFile string
+var strict bool
+
+func setFlags(c *command.Command) {
+	c.Flags().IntVar(&frame, "frame", 1, "")
+	c.Flags().IntVar(&code, "code", 1, "")
+	c.Flags().BoolVar(&strict, "strict", false, "")
+	c.Flags().StringVar(&protFile, "file", "", "")
+	c.Flags().StringVar(&protFile, "f", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if len(args) < 2 {
+		return c.UsageError("expecting gene names")
+	}
+
+	gc := dna.GeneticCode(code)
+	if !gc.Valid() {
+		return fmt.Errorf("invalid genetic code %d", code)
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+
+	if p.Path(project.DNA) == "" {
+		return fmt.Errorf("undefined DNA file")
+	}
+	coll := dna.New()
+	if _, err := dnafiles.Read(p, coll); err != nil {
+		return fmt.Errorf("on project %q: %v", pFile, err)
+	}
+
+	prot := protein.New()
+	if pf := p.Path(project.Proteins); pf != "" {
+		if err := readProteinFile(pf, prot); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+
+	var stops int
+	for _, gene := range args[1:] {
+		gene = strings.ToLower(strings.TrimSpace(gene))
+		for _, tax := range coll.Taxa() {
+			for _, spec := range coll.TaxSpec(tax) {
+				for _, acc := range coll.GeneAccession(spec, gene) {
+					aa, err := coll.Translate(spec, gene, acc, frame, gc)
+					if errors.Is(err, dna.ErrStopCodon) {
+						fmt.Fprintf(c.Stdout(), "%s\t%s\t%s\t%s\t%v\n", gene, tax, spec, acc, err)
+						stops++
+						continue
+					}
+					if err != nil {
+						return fmt.Errorf("gene %q: sequence %q: %v", gene, acc, err)
+					}
+					if err := prot.Add(tax, spec, gene, acc, aa); err != nil {
+						return fmt.Errorf("gene %q: sequence %q: %v", gene, acc, err)
+					}
+					prot.Set(spec, gene, acc, coll.Val(spec, gene, acc, dna.Reference), protein.Reference)
+					com := fmt.Sprintf("translated from DNA, frame %d, genetic code %d", frame, code)
+					prot.Set(spec, gene, acc, com, protein.Comments)
+				}
+			}
+		}
+	}
+
+	if strict && stops > 0 {
+		return fmt.Errorf("%d sequences with internal stop codons", stops)
+	}
+
+	if protFile == "" {
+		protFile = p.Path(project.Proteins)
+		if protFile == "" {
+			protFile = "proteins.tab"
+		}
+	}
+	if p.ReadOnly(project.Proteins, protFile) {
+		return fmt.Errorf("on project %q: file %q is read-only", pFile, p.Source(project.Proteins, protFile))
+	}
+	if err := writeProteins(protFile, prot); err != nil {
+		return err
+	}
+
+	p.Add(project.Proteins, protFile)
+	if err := p.Write(pFile); err != nil {
+		return err
+	}
+	return nil
+}
+
+func readProteinFile(name string, c *protein.Collection) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := c.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeProteins(name string, c *protein.Collection) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: amino acid sequences\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Proteins))
+	if err := c.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
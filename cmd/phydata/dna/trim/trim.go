@@ -0,0 +1,200 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package trim implements a command to trim
+// aligned DNA sequences in a PhyData project.
+package trim
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `trim [-m|--method <method>] [--variant <name>]
+	[--min-frac <value>] [--max-gaps <value>] [--min-identity <value>]
+	[--min-block <number>]
+	<project-file> <gene>...`,
+	Short: "trim aligned DNA sequences",
+	Long: `
+Command trim reads the aligned sequences of one or more genes in a PhyData
+project, removes the columns selected by a trimming method, and stores the
+trimmed alignment as a new variant of the gene.
+
+The first argument of the command is the name of the project file.
+
+The second and following arguments are the names of the genes to be trimmed.
+All the sequences of the genes must be aligned.
+
+By default, only the columns in which all sequences have a gap are removed.
+Use the flag --method, or -m, to define a different trimming method. Valid
+methods are:
+
+	gaps       remove the columns with only gaps (default)
+	ends       remove the leading and trailing columns in which the
+	           fraction of sequences with bases is less than the value
+	           defined by the flag --min-frac (default 0.5).
+	conserved  keep only conserved columns, in a way similar to Gblocks.
+	           A column is removed if the fraction of gaps is larger than
+	           the value of the flag --max-gaps (default 0.5), or if the
+	           frequency of the most common base is lesser than the value
+	           of the flag --min-identity (default 0.5). Blocks of kept
+	           columns shorter than the value of the flag --min-block
+	           (default 5) are also removed.
+
+In all methods, missing data ('?', or 'n') is counted as a gap.
+
+The trimmed sequences are stored using a gene name of the form
+"<gene>:<variant>". By default the variant is "trim". Use the flag --variant
+to define a different variant name. The comments of the trimmed sequences will
+indicate the source gene and the trimming method.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var method string
+var variant string
+var minFrac float64
+var maxGaps float64
+var minIdentity float64
+var minBlock int
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&method, "method", "gaps", "")
+	c.Flags().StringVar(&method, "m", "gaps", "")
+	c.Flags().StringVar(&variant, "variant", "trim", "")
+	c.Flags().Float64Var(&minFrac, "min-frac", 0.5, "")
+	c.Flags().Float64Var(&maxGaps, "max-gaps", 0.5, "")
+	c.Flags().Float64Var(&minIdentity, "min-identity", 0.5, "")
+	c.Flags().IntVar(&minBlock, "min-block", 5, "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if len(args) < 2 {
+		return c.UsageError("expecting gene names")
+	}
+
+	variant = strings.ToLower(strings.Join(strings.Fields(variant), "-"))
+	if variant == "" {
+		return c.UsageError("undefined variant name")
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+
+	if p.Path(project.DNA) == "" {
+		return fmt.Errorf("undefined DNA file")
+	}
+	coll := dna.New()
+	files, err := dnafiles.Read(p, coll)
+	if err != nil {
+		return fmt.Errorf("on project %q: %v", pFile, err)
+	}
+
+	for _, gene := range args[1:] {
+		gene = strings.ToLower(strings.TrimSpace(gene))
+		if err := trimGene(coll, gene); err != nil {
+			return err
+		}
+	}
+
+	if err := files.Write(coll); err != nil {
+		return err
+	}
+	return nil
+}
+
+type sequence struct {
+	taxon string
+	spec  string
+	acc   string
+	seq   string
+}
+
+func trimGene(coll *dna.Collection, gene string) error {
+	var seqs []sequence
+	for _, tax := range coll.Taxa() {
+		for _, spec := range coll.TaxSpec(tax) {
+			for _, acc := range coll.GeneAccession(spec, gene) {
+				if coll.Val(spec, gene, acc, dna.Aligned) != "true" {
+					return fmt.Errorf("gene %q: sequence %q: sequence not aligned", gene, acc)
+				}
+				seqs = append(seqs, sequence{
+					taxon: tax,
+					spec:  spec,
+					acc:   acc,
+					seq:   coll.Sequence(spec, gene, acc),
+				})
+			}
+		}
+	}
+	if len(seqs) == 0 {
+		return fmt.Errorf("gene %q: without sequences", gene)
+	}
+
+	ls := make([]string, 0, len(seqs))
+	for _, s := range seqs {
+		if len(s.seq) != len(seqs[0].seq) {
+			return fmt.Errorf("gene %q: sequence %q: aligned sequences with different lengths", gene, s.acc)
+		}
+		ls = append(ls, s.seq)
+	}
+
+	var mask dna.Mask
+	var desc string
+	switch strings.ToLower(method) {
+	case "gaps":
+		mask = dna.GapColumns(ls)
+		desc = "gap-only columns"
+	case "ends":
+		mask = dna.RaggedEnds(ls, minFrac)
+		desc = fmt.Sprintf("ragged ends, min-frac %.2f", minFrac)
+	case "conserved":
+		mask = dna.Conserved(ls, maxGaps, minIdentity, minBlock)
+		desc = fmt.Sprintf("conserved, max-gaps %.2f, min-identity %.2f, min-block %d", maxGaps, minIdentity, minBlock)
+	default:
+		return fmt.Errorf("unknown trimming method %q", method)
+	}
+	if mask.Len() == 0 {
+		return fmt.Errorf("gene %q: all columns removed", gene)
+	}
+
+	nGene := gene + ":" + variant
+	prov := fmt.Sprintf("trimmed from %s (%s) on %s", gene, desc, time.Now().Format(time.DateOnly))
+	for _, s := range seqs {
+		if err := coll.Add(s.taxon, s.spec, nGene, s.acc, mask.Apply(s.seq)); err != nil {
+			return fmt.Errorf("gene %q: sequence %q: %v", gene, s.acc, err)
+		}
+		for _, f := range []dna.Field{dna.Aligned, dna.Protein, dna.Organelle, dna.Molecule, dna.Reference} {
+			coll.Set(s.spec, nGene, s.acc, coll.Val(s.spec, gene, s.acc, f), f)
+		}
+		com := coll.Val(s.spec, gene, s.acc, dna.Comments)
+		if com != "" {
+			com += "; "
+		}
+		coll.Set(s.spec, nGene, s.acc, com+prov, dna.Comments)
+	}
+
+	return nil
+}
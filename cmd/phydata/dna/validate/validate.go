@@ -0,0 +1,83 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package validate implements a command to check
+// the DNA sequences of a PhyData project.
+package validate
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: "validate <project-file>",
+	Short: "check the DNA sequences of a project",
+	Long: `
+Command validate reads the DNA sequences of a PhyData project and reports the
+GenBank accessions assigned to more than one specimen, or more than one gene.
+
+The argument of the command is the name of the project file.
+
+An accession assigned to different specimens is usually an error (for
+example, a wrong specimen column in an imported file). An accession assigned
+to different genes might be valid, if the accession is a large sequence
+(e.g., a mitochondrial genome), or an error.
+
+The output is a TSV table with the accession, the kind of the problem
+('specimen' or 'gene'), and the list of specimens or genes, separated by
+commas.
+	`,
+	Run: run,
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	if p.Path(project.DNA) == "" {
+		return fmt.Errorf("undefined DNA file")
+	}
+	coll := dna.New()
+	if _, err := dnafiles.Read(p, coll); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	tab := csv.NewWriter(c.Stdout())
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	if err := tab.Write([]string{"accession", "problem", "values"}); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+
+	for _, acc := range coll.GenBank() {
+		if specs := coll.AccessionSpec(acc); len(specs) > 1 {
+			if err := tab.Write([]string{acc, "specimen", strings.Join(specs, ", ")}); err != nil {
+				return fmt.Errorf("while writing data: %v", err)
+			}
+		}
+		if genes := coll.AccessionGene(acc); len(genes) > 1 {
+			if err := tab.Write([]string{acc, "gene", strings.Join(genes, ", ")}); err != nil {
+				return fmt.Errorf("while writing data: %v", err)
+			}
+		}
+	}
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package dnafiles implements the reading and writing
+// of the DNA files of a PhyData project.
+//
+// A project can store its DNA sequences in several files
+// (e.g., a file for each gene,
+// or for each sequencing batch).
+// The files are merged into a single collection when read,
+// and each sequence is written back
+// into the file from which it was read.
+//
+// Read-only files are never written.
+// A modified sequence of a read-only file
+// is written into the working file
+// (the target file)
+// which, as it is read after the read-only files,
+// overrides the sequence of the read-only file.
+package dnafiles
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/js-arias/phydata/cmd/phydata/progress"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+// Files are the DNA files of a project.
+type Files struct {
+	names []string
+
+	// file that receives the new sequences
+	// (-1 if all the files are read-only)
+	target int
+
+	// original sequences of the read-only files
+	// (nil for writable files)
+	orig []*dna.Collection
+
+	// file of each sequence,
+	// by specimen, gene, and accession,
+	// and by specimen and accession
+	// (e.g., if the gene was renamed)
+	seqs map[[3]string]int
+	accs map[[2]string]int
+}
+
+// Read reads all the DNA files of a project
+// into a collection.
+// The read-only files are read first,
+// so the sequences of the writable files
+// override them.
+// New sequences will be written
+// into the working file of the project
+// (see project.Path).
+func Read(p *project.Project, c *dna.Collection) (*Files, error) {
+	fs := &Files{
+		target: -1,
+		seqs:   make(map[[3]string]int),
+		accs:   make(map[[2]string]int),
+	}
+	paths := p.Paths(project.DNA)
+	for _, name := range paths {
+		if !p.ReadOnly(project.DNA, name) {
+			continue
+		}
+		if err := fs.read(c, name, true); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range paths {
+		if p.ReadOnly(project.DNA, name) {
+			continue
+		}
+		if err := fs.read(c, name, false); err != nil {
+			return nil, err
+		}
+		if fs.target < 0 {
+			fs.target = len(fs.names) - 1
+		}
+	}
+	return fs, nil
+}
+
+// Read reads a DNA file into a collection
+// and adds it to the files.
+func (fs *Files) read(c *dna.Collection, name string, readOnly bool) error {
+	if err := readDNA(name, c); err != nil {
+		return err
+	}
+
+	// if there are read-only files,
+	// the sequences of the file
+	// are read in its own collection
+	src := c
+	if readOnly || slices.ContainsFunc(fs.orig, func(o *dna.Collection) bool { return o != nil }) {
+		src = dna.New()
+		if err := readDNA(name, src); err != nil {
+			return err
+		}
+	}
+	var orig *dna.Collection
+	if readOnly {
+		orig = src
+	}
+
+	i := len(fs.names)
+	fs.names = append(fs.names, name)
+	fs.orig = append(fs.orig, orig)
+	for _, sp := range src.Specimens() {
+		for _, g := range src.SpecGene(sp) {
+			for _, acc := range src.GeneAccession(sp, g) {
+				// a writable file overrides
+				// the sequences of a read-only file
+				if j, ok := fs.seqs[[3]string{sp, g, acc}]; ok && (readOnly || fs.orig[j] == nil) {
+					continue
+				}
+				fs.seqs[[3]string{sp, g, acc}] = i
+				if j, ok := fs.accs[[2]string{sp, acc}]; !ok || (!readOnly && fs.orig[j] != nil) {
+					fs.accs[[2]string{sp, acc}] = i
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func readDNA(name string, c *dna.Collection) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := c.ReadTSV(progress.Reader(f, name)); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+// Names returns the names of the files.
+func (fs *Files) Names() []string {
+	return slices.Clone(fs.names)
+}
+
+// SetTarget sets the file that will receive
+// the new sequences.
+// If the file is not one of the files of the project,
+// it is added to the files
+// (and if the file already exists,
+// its sequences are read into the collection).
+// The target can not be a read-only file.
+func (fs *Files) SetTarget(c *dna.Collection, name string) error {
+	if i := slices.Index(fs.names, name); i >= 0 {
+		if fs.orig[i] != nil {
+			return fmt.Errorf("file %q is read-only", name)
+		}
+		fs.target = i
+		return nil
+	}
+
+	if _, err := os.Stat(name); err == nil {
+		if err := fs.read(c, name, false); err != nil {
+			return err
+		}
+	} else if errors.Is(err, os.ErrNotExist) {
+		fs.names = append(fs.names, name)
+		fs.orig = append(fs.orig, nil)
+	} else {
+		return err
+	}
+	fs.target = len(fs.names) - 1
+	return nil
+}
+
+// File returns the index of the file
+// of a sequence.
+// A sequence not read from any file,
+// or a modified sequence of a read-only file,
+// is assigned to the target file.
+func (fs *Files) file(c *dna.Collection, e dna.Entry) int {
+	i, ok := fs.seqs[[3]string{e.Specimen, e.Gene, e.GenBank}]
+	if !ok {
+		i, ok = fs.accs[[2]string{e.Specimen, e.GenBank}]
+	}
+	if !ok {
+		return fs.target
+	}
+	if fs.orig[i] != nil && !c.Equal(fs.orig[i], e.Specimen, e.Gene, e.GenBank) {
+		return fs.target
+	}
+	return i
+}
+
+// Write writes the sequences of a collection
+// into the files,
+// each sequence in the file from which it was read,
+// and the new sequences in the target file.
+// Store files keep the store format.
+//
+// Read-only files are not written,
+// and it is an error to remove a sequence,
+// or to change the taxon of a specimen,
+// of a read-only file.
+func (fs *Files) Write(c *dna.Collection) error {
+	if len(fs.names) == 0 {
+		return errors.New("undefined DNA file")
+	}
+	if err := fs.checkReadOnly(c); err != nil {
+		return err
+	}
+
+	if fs.target < 0 {
+		nc := c.Filter(func(e dna.Entry) bool {
+			return fs.file(c, e) < 0
+		})
+		if nc.NumSequences() > 0 {
+			return errors.New("all the DNA files are read-only: undefined working file")
+		}
+	}
+
+	for i, name := range fs.names {
+		if fs.orig[i] != nil {
+			continue
+		}
+		nc := c.Filter(func(e dna.Entry) bool {
+			return fs.file(c, e) == i
+		})
+		if err := writeDNA(name, nc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Writable returns the sequences of a collection
+// that are written into the writable files
+// (i.e., all the sequences,
+// except the unmodified sequences of the read-only files).
+func (fs *Files) Writable(c *dna.Collection) *dna.Collection {
+	return c.Filter(func(e dna.Entry) bool {
+		i := fs.file(c, e)
+		return i < 0 || fs.orig[i] == nil
+	})
+}
+
+// CheckReadOnly returns an error
+// if a sequence of a read-only file
+// is not in the collection,
+// or if it is assigned to a different taxon.
+func (fs *Files) checkReadOnly(c *dna.Collection) error {
+	for i, orig := range fs.orig {
+		if orig == nil {
+			continue
+		}
+		for _, sp := range orig.Specimens() {
+			for _, g := range orig.SpecGene(sp) {
+				for _, acc := range orig.GeneAccession(sp, g) {
+					if c.Sequence(sp, g, acc) == "" {
+						return fmt.Errorf("sequence %s %s %s: can not be removed from read-only file %q", sp, g, acc, fs.names[i])
+					}
+				}
+			}
+		}
+		for _, tax := range orig.Taxa() {
+			for _, sp := range orig.TaxSpec(tax) {
+				if !slices.Contains(c.TaxSpec(tax), sp) {
+					return fmt.Errorf("specimen %q: taxon %q can not be changed in read-only file %q", sp, tax, fs.names[i])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func writeDNA(name string, c *dna.Collection) (err error) {
+	// keep the format of a store file
+	store := dna.IsStore(name)
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	if store {
+		if err := c.WriteStore(f, project.VersionComment(project.DNA)); err != nil {
+			return fmt.Errorf("while writing to %q: %v", name, err)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(f, "# phydata: DNA sequences\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.DNA))
+	if err := c.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	progress.Printf("wrote %q: %d sequences", name, c.NumSequences())
+	return nil
+}
@@ -0,0 +1,455 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package archive implements a command to export
+// a PhyData project
+// as a self-contained zip archive.
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/config"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `archive [--title <title>] [--version <version>]
+	[--author <names>] [--license <license>]
+	[--description <text>]
+	[-o|--output <file>] <project-file>`,
+	Short: "export a project as a zip archive",
+	Long: `
+Command archive reads a PhyData project, and writes a self-contained zip
+archive with the project data, ready to be uploaded to a data repository
+(e.g., Zenodo or Dryad).
+
+The argument of the command is the name of the project file.
+
+The archive contains:
+
+	project.tab     the project file
+	datasets/       the dataset files of the project
+	matrices/       the exported matrices: the observations as a NEXUS
+	                file, and the DNA sequences as a FASTA file for each
+	                gene
+	metadata.json   the metadata of the archive, with the SHA-256 checksum
+	                of each file
+	CITATION.cff    a citation file (see <https://citation-file-format.github.io>)
+
+All the files are stored inside a directory named after the title and the
+version of the archive.
+
+The flag --title defines the title of the archive. By default, the name of
+the project file (without extension) is used. The flag --version defines the
+version of the archive, by default "1.0.0".
+
+The flag --author defines the authors of the data, as a list separated by
+semicolons. Each author can be given as 'family-names, given-names'
+(e.g., 'Arias, J. Salvador; Smith, John'). By default, the value of the key
+'author' of the configuration file is used (see 'phydata help config'). The
+flag --license defines the license of the data, by default 'CC-BY-4.0'. The
+flag --description defines a short description of the data.
+
+By default, the archive will be written in a file named after the title and
+the version of the archive (e.g., 'frogs-1.0.0.zip'). Use the flag --output,
+or -o, to define a different file name.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var title string
+var version string
+var authors string
+var license string
+var description string
+var output string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&title, "title", "", "")
+	c.Flags().StringVar(&version, "version", "1.0.0", "")
+	c.Flags().StringVar(&authors, "author", config.Get(config.Author, ""), "")
+	c.Flags().StringVar(&license, "license", "CC-BY-4.0", "")
+	c.Flags().StringVar(&description, "description", "", "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+}
+
+func run(c *command.Command, args []string) (err error) {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return c.UsageError("undefined version")
+	}
+
+	pFile := args[0]
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+	if len(p.Sets()) == 0 {
+		return fmt.Errorf("on project %q: no datasets", pFile)
+	}
+
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(pFile), filepath.Ext(pFile))
+	}
+	title = strings.Join(strings.Fields(title), " ")
+	root := fileName(title) + "-" + fileName(version)
+	if output == "" {
+		output = root + ".zip"
+	}
+
+	md := metadata{
+		Title:       title,
+		Version:     version,
+		Created:     time.Now().Format(time.RFC3339),
+		License:     license,
+		Description: description,
+		Authors:     parseAuthors(authors),
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	a := &archive{
+		zw:   zip.NewWriter(f),
+		root: root,
+		md:   &md,
+	}
+
+	// datasets
+	np := project.New()
+	used := make(map[string]bool)
+	for _, set := range p.Sets() {
+		src := p.Path(set)
+		name := filepath.Base(src)
+		if used[name] {
+			name = string(set) + "-" + name
+		}
+		used[name] = true
+
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+		file := path.Join("datasets", name)
+		if err := a.add(file, data); err != nil {
+			return err
+		}
+		np.Add(set, file)
+		md.Datasets = append(md.Datasets, fileInfo{
+			Dataset: string(set),
+			File:    file,
+		})
+	}
+	if err := a.addProject(np); err != nil {
+		return err
+	}
+
+	// matrices
+	if mf := p.Path(project.Observations); mf != "" {
+		m := matrix.New()
+		if err := readObsFile(mf, m); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+		if err := a.addObs(m); err != nil {
+			return err
+		}
+	}
+	if p.Path(project.DNA) != "" {
+		coll := dna.New()
+		if _, err := dnafiles.Read(p, coll); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+		if err := a.addDNA(coll); err != nil {
+			return err
+		}
+	}
+
+	if err := a.add("CITATION.cff", citation(md)); err != nil {
+		return err
+	}
+	if err := a.addMetadata(); err != nil {
+		return err
+	}
+
+	if err := a.zw.Close(); err != nil {
+		return fmt.Errorf("while writing to %q: %v", output, err)
+	}
+	return nil
+}
+
+// Metadata is the description of an archive.
+type metadata struct {
+	Title       string     `json:"title"`
+	Version     string     `json:"version"`
+	Created     string     `json:"created"`
+	License     string     `json:"license,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Authors     []author   `json:"authors,omitempty"`
+	Datasets    []fileInfo `json:"datasets"`
+	Matrices    []fileInfo `json:"matrices,omitempty"`
+	Files       []fileInfo `json:"files"`
+}
+
+type author struct {
+	Family string `json:"family-names,omitempty"`
+	Given  string `json:"given-names,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+type fileInfo struct {
+	File    string `json:"file"`
+	Dataset string `json:"dataset,omitempty"`
+	Format  string `json:"format,omitempty"`
+	Gene    string `json:"gene,omitempty"`
+	Taxa    int    `json:"taxa,omitempty"`
+	Chars   int    `json:"characters,omitempty"`
+	Seqs    int    `json:"sequences,omitempty"`
+	Length  int    `json:"length,omitempty"`
+	Size    int    `json:"size,omitempty"`
+	SHA256  string `json:"sha256,omitempty"`
+}
+
+func parseAuthors(s string) []author {
+	var ls []author
+	for _, a := range strings.Split(s, ";") {
+		a = strings.Join(strings.Fields(a), " ")
+		if a == "" {
+			continue
+		}
+		family, given, ok := strings.Cut(a, ",")
+		if !ok {
+			ls = append(ls, author{Name: a})
+			continue
+		}
+		ls = append(ls, author{
+			Family: strings.TrimSpace(family),
+			Given:  strings.TrimSpace(given),
+		})
+	}
+	return ls
+}
+
+// An archive is a zip file
+// with the files of a project.
+type archive struct {
+	zw   *zip.Writer
+	root string
+	md   *metadata
+}
+
+// Add adds a file to the archive.
+func (a *archive) add(name string, data []byte) error {
+	w, err := a.zw.CreateHeader(&zip.FileHeader{
+		Name:     path.Join(a.root, name),
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("while adding %q: %v", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("while adding %q: %v", name, err)
+	}
+
+	sum := sha256.Sum256(data)
+	a.md.Files = append(a.md.Files, fileInfo{
+		File:   name,
+		Size:   len(data),
+		SHA256: hex.EncodeToString(sum[:]),
+	})
+	return nil
+}
+
+func (a *archive) addProject(p *project.Project) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# phydata project files\n")
+	fmt.Fprintf(&buf, "# data save on: %s\n", a.md.Created)
+	tab := csv.NewWriter(&buf)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	if err := tab.Write([]string{"dataset", "path"}); err != nil {
+		return fmt.Errorf("while writing project: %v", err)
+	}
+	for _, s := range p.Sets() {
+		if err := tab.Write([]string{string(s), p.Path(s)}); err != nil {
+			return fmt.Errorf("while writing project: %v", err)
+		}
+	}
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing project: %v", err)
+	}
+	return a.add("project.tab", buf.Bytes())
+}
+
+func (a *archive) addObs(m *matrix.Matrix) error {
+	var buf bytes.Buffer
+	if err := m.Nexus(&buf); err != nil {
+		return fmt.Errorf("while writing observations: %v", err)
+	}
+	file := "matrices/observations.nex"
+	if err := a.add(file, buf.Bytes()); err != nil {
+		return err
+	}
+	a.md.Matrices = append(a.md.Matrices, fileInfo{
+		File:   file,
+		Format: "nexus",
+		Taxa:   len(m.Taxa()),
+		Chars:  len(m.Chars()),
+	})
+	return nil
+}
+
+func (a *archive) addDNA(coll *dna.Collection) error {
+	for _, gene := range coll.Genes() {
+		ln := coll.MaxLen(gene)
+		var buf bytes.Buffer
+		taxa := make(map[string]bool)
+		seqs := 0
+		for _, tx := range coll.Taxa() {
+			for _, spec := range coll.TaxSpec(tx) {
+				for _, acc := range coll.GeneAccession(spec, gene) {
+					seq := coll.Placed(spec, gene, acc)
+					if seq == "" {
+						continue
+					}
+					if len(seq) < ln {
+						seq += strings.Repeat("?", ln-len(seq))
+					}
+					fmt.Fprintf(&buf, ">%s %s %s\n%s\n", spec, acc, tx, seq)
+					taxa[tx] = true
+					seqs++
+				}
+			}
+		}
+		if seqs == 0 {
+			continue
+		}
+
+		file := "matrices/" + fileName(gene) + ".fasta"
+		if err := a.add(file, buf.Bytes()); err != nil {
+			return err
+		}
+		a.md.Matrices = append(a.md.Matrices, fileInfo{
+			File:   file,
+			Format: "fasta",
+			Gene:   gene,
+			Taxa:   len(taxa),
+			Seqs:   seqs,
+			Length: ln,
+		})
+	}
+	return nil
+}
+
+func (a *archive) addMetadata() error {
+	// checksums of the datasets and matrices
+	sums := make(map[string]fileInfo, len(a.md.Files))
+	for _, f := range a.md.Files {
+		sums[f.File] = f
+	}
+	for i, f := range a.md.Datasets {
+		a.md.Datasets[i].Size = sums[f.File].Size
+		a.md.Datasets[i].SHA256 = sums[f.File].SHA256
+	}
+	for i, f := range a.md.Matrices {
+		a.md.Matrices[i].Size = sums[f.File].Size
+		a.md.Matrices[i].SHA256 = sums[f.File].SHA256
+	}
+
+	data, err := json.MarshalIndent(a.md, "", "  ")
+	if err != nil {
+		return fmt.Errorf("while writing metadata: %v", err)
+	}
+	data = append(data, '\n')
+	return a.add("metadata.json", data)
+}
+
+// Citation returns a citation file
+// in the Citation File Format.
+func citation(md metadata) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "cff-version: 1.2.0\n")
+	fmt.Fprintf(&buf, "message: \"If you use this dataset, please cite it as below.\"\n")
+	fmt.Fprintf(&buf, "type: dataset\n")
+	fmt.Fprintf(&buf, "title: %s\n", strconv.Quote(md.Title))
+	fmt.Fprintf(&buf, "version: %s\n", strconv.Quote(md.Version))
+	fmt.Fprintf(&buf, "date-released: %s\n", md.Created[:len("2006-01-02")])
+	if md.License != "" {
+		fmt.Fprintf(&buf, "license: %s\n", strconv.Quote(md.License))
+	}
+	if md.Description != "" {
+		fmt.Fprintf(&buf, "abstract: %s\n", strconv.Quote(md.Description))
+	}
+	if len(md.Authors) == 0 {
+		return buf.Bytes()
+	}
+	fmt.Fprintf(&buf, "authors:\n")
+	for _, a := range md.Authors {
+		if a.Name != "" {
+			fmt.Fprintf(&buf, "  - name: %s\n", strconv.Quote(a.Name))
+			continue
+		}
+		fmt.Fprintf(&buf, "  - family-names: %s\n", strconv.Quote(a.Family))
+		if a.Given != "" {
+			fmt.Fprintf(&buf, "    given-names: %s\n", strconv.Quote(a.Given))
+		}
+	}
+	return buf.Bytes()
+}
+
+// FileName returns a valid file name
+// from a string.
+func fileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
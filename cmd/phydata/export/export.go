@@ -0,0 +1,28 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package export is a metapackage for commands
+// that export a PhyData project
+// for publication.
+package export
+
+import (
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/export/archive"
+	"github.com/js-arias/phydata/cmd/phydata/export/json"
+	"github.com/js-arias/phydata/cmd/phydata/export/parquet"
+	"github.com/js-arias/phydata/cmd/phydata/export/phyloxml"
+)
+
+func init() {
+	Command.Add(archive.Command)
+	Command.Add(json.Command)
+	Command.Add(parquet.Command)
+	Command.Add(phyloxml.Command)
+}
+
+var Command = &command.Command{
+	Usage: "export <command> [<argument>...]",
+	Short: "commands to export projects",
+}
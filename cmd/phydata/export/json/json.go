@@ -0,0 +1,181 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package json implements a command to export
+// a PhyData project
+// as a single JSON document.
+package json
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: "json [-o|--output <file>] <project-file>",
+	Short: "export a project as a JSON document",
+	Long: `
+Command json reads a PhyData project, and writes all the datasets of the
+project as a single JSON document, that can be used by web applications, or
+processed with tools such as jq or Python, without reading the TSV files.
+
+The argument of the command is the name of the project file.
+
+The document is an object with the following fields:
+
+	project   the name of the project
+	created   the date of the export
+	datasets  an object with a field for each dataset of the project
+
+Each dataset is an object with the following fields:
+
+	file    the name of the original dataset file
+	fields  the fields (columns) of the dataset, in its original order
+	rows    the records of the dataset, each one an object with the value
+	        of each field (empty values are omitted)
+
+The document can be imported back as a project with the command
+'phydata project import'.
+
+By default, the document will be printed in the standard output. Use the flag
+--output, or -o, to define an output file.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var output string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+}
+
+func run(c *command.Command, args []string) (err error) {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	pFile := args[0]
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+	if len(p.Sets()) == 0 {
+		return fmt.Errorf("on project %q: no datasets", pFile)
+	}
+
+	doc := document{
+		Project:  strings.TrimSuffix(filepath.Base(pFile), filepath.Ext(pFile)),
+		Created:  time.Now().Format(time.RFC3339),
+		Datasets: make(map[string]dataset, len(p.Sets())),
+	}
+	for _, set := range p.Sets() {
+		ds, err := readDataset(p.Path(set))
+		if err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+		doc.Datasets[string(set)] = ds
+	}
+
+	out := c.Stdout()
+	if output != "" {
+		var f *os.File
+		f, err = os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			e := f.Close()
+			if e != nil && err == nil {
+				err = e
+			}
+		}()
+		out = f
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("while writing JSON: %v", err)
+	}
+	return nil
+}
+
+// A document is a JSON representation
+// of a project.
+type document struct {
+	Project  string             `json:"project"`
+	Created  string             `json:"created"`
+	Datasets map[string]dataset `json:"datasets"`
+}
+
+// A dataset is the JSON representation
+// of a TSV dataset file.
+type dataset struct {
+	File   string              `json:"file"`
+	Fields []string            `json:"fields"`
+	Rows   []map[string]string `json:"rows"`
+}
+
+func readDataset(name string) (dataset, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return dataset{}, err
+	}
+	defer f.Close()
+
+	tab := csv.NewReader(f)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+	tab.FieldsPerRecord = -1
+
+	head, err := tab.Read()
+	if err != nil {
+		return dataset{}, fmt.Errorf("while reading file %q: header: %v", name, err)
+	}
+	fields := make([]string, len(head))
+	for i, h := range head {
+		fields[i] = strings.ToLower(strings.TrimSpace(h))
+	}
+
+	ds := dataset{
+		File:   filepath.Base(name),
+		Fields: fields,
+		Rows:   []map[string]string{},
+	}
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return dataset{}, fmt.Errorf("while reading file %q: on row %d: %v", name, ln, err)
+		}
+
+		r := make(map[string]string, len(fields))
+		for i, v := range row {
+			if i >= len(fields) || fields[i] == "" || v == "" {
+				continue
+			}
+			r[fields[i]] = v
+		}
+		if len(r) == 0 {
+			continue
+		}
+		ds.Rows = append(ds.Rows, r)
+	}
+	return ds, nil
+}
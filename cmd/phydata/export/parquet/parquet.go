@@ -0,0 +1,233 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package parquet implements a command to export
+// the observations and DNA sequences
+// of a PhyData project
+// as Parquet files.
+package parquet
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: "parquet [-o|--output <dir>] <project-file>",
+	Short: "export observations and sequences as Parquet files",
+	Long: `
+Command parquet reads a PhyData project, and writes the observations and the
+DNA sequences of the project as Parquet files (see
+<https://parquet.apache.org>), a columnar format that can be read directly by
+tools such as pandas, polars, or duckdb.
+
+The argument of the command is the name of the project file.
+
+The observations are written in the file 'observations.parquet', with the
+columns taxon, specimen, character, state, reference, image, comments,
+reviewer, added_by, timestamp (as a timestamp), uncertain (as a boolean), and
+source. The DNA sequences are written in the file 'dna.parquet', with the
+columns taxon, specimen, gene, accession, protein and aligned (as booleans),
+organelle, start and end (as integers), strand, reference, comments, length
+(the number of bases), and sequence. Empty values are written as nulls.
+
+By default, the files are written in the current directory. Use the flag
+--output, or -o, to define a different directory.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var output string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&output, "output", ".", "")
+	c.Flags().StringVar(&output, "o", ".", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	pFile := args[0]
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+
+	var m *matrix.Matrix
+	if mf := p.Path(project.Observations); mf != "" {
+		m = matrix.New()
+		if err := readData(mf, m.ReadTSV); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+	var coll *dna.Collection
+	if p.Path(project.DNA) != "" {
+		coll = dna.New()
+		if _, err := dnafiles.Read(p, coll); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+	if m == nil && coll == nil {
+		return fmt.Errorf("on project %q: no observations or DNA sequences", pFile)
+	}
+
+	if err := os.MkdirAll(output, 0o755); err != nil {
+		return err
+	}
+	if m != nil {
+		name := filepath.Join(output, "observations.parquet")
+		if err := writeFile(name, obsTable(m)); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.Stdout(), "%s\n", name)
+	}
+	if coll != nil {
+		name := filepath.Join(output, "dna.parquet")
+		if err := writeFile(name, dnaTable(coll)); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.Stdout(), "%s\n", name)
+	}
+	return nil
+}
+
+func obsTable(m *matrix.Matrix) []*column {
+	taxon := stringCol("taxon", false)
+	spec := stringCol("specimen", false)
+	char := stringCol("character", false)
+	state := stringCol("state", false)
+	ref := stringCol("reference", true)
+	img := stringCol("image", true)
+	comment := stringCol("comments", true)
+	reviewer := stringCol("reviewer", true)
+	addedBy := stringCol("added_by", true)
+	stamp := timestampCol("timestamp")
+	uncertain := boolCol("uncertain")
+	source := stringCol("source", true)
+
+	chars := m.Chars()
+	for _, tx := range m.Taxa() {
+		for _, sp := range m.TaxSpec(tx) {
+			for _, ch := range chars {
+				for _, s := range m.Obs(sp, ch) {
+					taxon.addString(tx)
+					spec.addString(sp)
+					char.addString(ch)
+					state.addString(s)
+					ref.addString(m.Val(sp, ch, s, matrix.Reference))
+					img.addString(m.Val(sp, ch, s, matrix.ImageLink))
+					comment.addString(m.Val(sp, ch, s, matrix.Comments))
+					reviewer.addString(m.Val(sp, ch, s, matrix.Reviewer))
+					addedBy.addString(m.Val(sp, ch, s, matrix.AddedBy))
+					t, err := time.Parse(time.RFC3339, m.Val(sp, ch, s, matrix.Timestamp))
+					stamp.addInt(t.UnixMilli(), err == nil)
+					uncertain.addBool(m.Val(sp, ch, s, matrix.Uncertain) == "true")
+					source.addString(m.Val(sp, ch, s, matrix.Source))
+				}
+			}
+		}
+	}
+
+	return []*column{taxon, spec, char, state, ref, img, comment, reviewer, addedBy, stamp, uncertain, source}
+}
+
+func dnaTable(coll *dna.Collection) []*column {
+	taxon := stringCol("taxon", false)
+	spec := stringCol("specimen", false)
+	gene := stringCol("gene", false)
+	accession := stringCol("accession", true)
+	protein := boolCol("protein")
+	organelle := stringCol("organelle", true)
+	aligned := boolCol("aligned")
+	start := int64Col("start", true)
+	end := int64Col("end", true)
+	strand := stringCol("strand", true)
+	ref := stringCol("reference", true)
+	comment := stringCol("comments", true)
+	length := int64Col("length", false)
+	sequence := stringCol("sequence", false)
+
+	genes := coll.Genes()
+	for _, tx := range coll.Taxa() {
+		for _, sp := range coll.TaxSpec(tx) {
+			for _, g := range genes {
+				for _, acc := range coll.GeneAccession(sp, g) {
+					seq := coll.Sequence(sp, g, acc)
+					taxon.addString(tx)
+					spec.addString(sp)
+					gene.addString(g)
+					if strings.HasPrefix(acc, "no-gb:") {
+						accession.addString("")
+					} else {
+						accession.addString(acc)
+					}
+					protein.addBool(coll.Val(sp, g, acc, dna.Protein) == "true")
+					organelle.addString(coll.Val(sp, g, acc, dna.Organelle))
+					aligned.addBool(coll.Val(sp, g, acc, dna.Aligned) == "true")
+					s, err := strconv.ParseInt(coll.Val(sp, g, acc, dna.Start), 10, 64)
+					start.addInt(s, err == nil)
+					e, err := strconv.ParseInt(coll.Val(sp, g, acc, dna.End), 10, 64)
+					end.addInt(e, err == nil)
+					strand.addString(coll.Val(sp, g, acc, dna.Strand))
+					ref.addString(coll.Val(sp, g, acc, dna.Reference))
+					comment.addString(coll.Val(sp, g, acc, dna.Comments))
+					length.addInt(int64(len(seq)), true)
+					sequence.addString(seq)
+				}
+			}
+		}
+	}
+
+	return []*column{taxon, spec, gene, accession, protein, organelle, aligned, start, end, strand, ref, comment, length, sequence}
+}
+
+func writeFile(name string, cols []*column) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	bw := bufio.NewWriter(f)
+	if err := writeParquet(bw, cols); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
+
+func readData(name string, read func(io.Reader) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := read(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
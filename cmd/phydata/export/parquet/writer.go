@@ -0,0 +1,394 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// This file implements a minimal Parquet writer
+// (see <https://parquet.apache.org/docs/file-format>).
+// Each table is written as a single row group,
+// and each column as a single uncompressed data page
+// with plain encoding.
+
+// Physical types of Parquet.
+const (
+	typeBoolean   = 0
+	typeInt64     = 2
+	typeByteArray = 6
+)
+
+// Converted types of Parquet.
+const (
+	noConverted     = -1
+	convUTF8        = 0
+	convTimestampMS = 9
+)
+
+// A column is a column of a table.
+type column struct {
+	name      string
+	typ       int32
+	converted int32
+	optional  bool
+
+	strs  []string
+	ints  []int64
+	bools []bool
+
+	// defined values of an optional column
+	defined []bool
+}
+
+func stringCol(name string, optional bool) *column {
+	return &column{
+		name:      name,
+		typ:       typeByteArray,
+		converted: convUTF8,
+		optional:  optional,
+	}
+}
+
+func int64Col(name string, optional bool) *column {
+	return &column{
+		name:      name,
+		typ:       typeInt64,
+		converted: noConverted,
+		optional:  optional,
+	}
+}
+
+func timestampCol(name string) *column {
+	return &column{
+		name:      name,
+		typ:       typeInt64,
+		converted: convTimestampMS,
+		optional:  true,
+	}
+}
+
+func boolCol(name string) *column {
+	return &column{
+		name:      name,
+		typ:       typeBoolean,
+		converted: noConverted,
+	}
+}
+
+// AddString adds a string value.
+// In an optional column,
+// an empty string is a null value.
+func (c *column) addString(v string) {
+	if c.optional {
+		c.defined = append(c.defined, v != "")
+		if v == "" {
+			return
+		}
+	}
+	c.strs = append(c.strs, v)
+}
+
+// AddInt adds an integer value,
+// if ok is false,
+// the value is null.
+func (c *column) addInt(v int64, ok bool) {
+	if c.optional {
+		c.defined = append(c.defined, ok)
+		if !ok {
+			return
+		}
+	}
+	c.ints = append(c.ints, v)
+}
+
+func (c *column) addBool(v bool) {
+	c.bools = append(c.bools, v)
+}
+
+// Len returns the number of rows in the column.
+func (c *column) len() int {
+	if c.optional {
+		return len(c.defined)
+	}
+	switch c.typ {
+	case typeBoolean:
+		return len(c.bools)
+	case typeInt64:
+		return len(c.ints)
+	}
+	return len(c.strs)
+}
+
+// Values returns the plain encoding
+// of the defined values of the column.
+func (c *column) values() []byte {
+	var b bytes.Buffer
+	switch c.typ {
+	case typeBoolean:
+		packed := make([]byte, (len(c.bools)+7)/8)
+		for i, v := range c.bools {
+			if v {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		b.Write(packed)
+	case typeInt64:
+		for _, v := range c.ints {
+			binary.Write(&b, binary.LittleEndian, v)
+		}
+	default:
+		for _, v := range c.strs {
+			binary.Write(&b, binary.LittleEndian, uint32(len(v)))
+			b.WriteString(v)
+		}
+	}
+	return b.Bytes()
+}
+
+// Levels returns the definition levels
+// of an optional column,
+// encoded with the RLE hybrid encoding
+// (with a bit width of 1),
+// and prefixed with its length.
+func (c *column) levels() []byte {
+	var rle bytes.Buffer
+	for i := 0; i < len(c.defined); {
+		j := i
+		for j < len(c.defined) && c.defined[j] == c.defined[i] {
+			j++
+		}
+		rle.Write(binary.AppendUvarint(nil, uint64(j-i)<<1))
+		if c.defined[i] {
+			rle.WriteByte(1)
+		} else {
+			rle.WriteByte(0)
+		}
+		i = j
+	}
+
+	b := binary.LittleEndian.AppendUint32(nil, uint32(rle.Len()))
+	return append(b, rle.Bytes()...)
+}
+
+// WriteParquet writes a table as a Parquet file.
+// All columns must have the same number of rows.
+func writeParquet(w io.Writer, cols []*column) error {
+	if len(cols) == 0 {
+		return fmt.Errorf("table without columns")
+	}
+	rows := cols[0].len()
+	for _, c := range cols {
+		if c.len() != rows {
+			return fmt.Errorf("column %q: got %d rows, want %d", c.name, c.len(), rows)
+		}
+	}
+
+	var file bytes.Buffer
+	file.WriteString("PAR1")
+
+	chunks := make([]chunk, 0, len(cols))
+	for _, c := range cols {
+		var page []byte
+		if c.optional {
+			page = append(page, c.levels()...)
+		}
+		page = append(page, c.values()...)
+
+		ph := &thrift{}
+		ph.i32(1, 0) // DATA_PAGE
+		ph.i32(2, int32(len(page)))
+		ph.i32(3, int32(len(page)))
+		ph.beginStruct(5)
+		ph.i32(1, int32(rows))
+		ph.i32(2, 0) // PLAIN
+		ph.i32(3, 3) // RLE
+		ph.i32(4, 3) // RLE
+		ph.endStruct()
+		ph.stop()
+
+		offset := int64(file.Len())
+		file.Write(ph.buf.Bytes())
+		file.Write(page)
+		chunks = append(chunks, chunk{
+			col:    c,
+			offset: offset,
+			size:   int64(file.Len()) - offset,
+		})
+	}
+
+	md := fileMetadata(cols, chunks, rows)
+	file.Write(md)
+	file.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(md))))
+	file.WriteString("PAR1")
+
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+// A chunk is the position of a column
+// in the file.
+type chunk struct {
+	col    *column
+	offset int64
+	size   int64
+}
+
+func fileMetadata(cols []*column, chunks []chunk, rows int) []byte {
+	t := &thrift{}
+	t.i32(1, 1) // version
+
+	// schema
+	t.list(2, thriftStruct, len(cols)+1)
+	t.beginElem()
+	t.str(4, "schema")
+	t.i32(5, int32(len(cols)))
+	t.endElem()
+	for _, c := range cols {
+		t.beginElem()
+		t.i32(1, c.typ)
+		if c.optional {
+			t.i32(3, 1) // OPTIONAL
+		} else {
+			t.i32(3, 0) // REQUIRED
+		}
+		t.str(4, c.name)
+		if c.converted != noConverted {
+			t.i32(6, c.converted)
+		}
+		t.endElem()
+	}
+
+	t.i64(3, int64(rows))
+
+	// row groups
+	var total int64
+	for _, ch := range chunks {
+		total += ch.size
+	}
+	t.list(4, thriftStruct, 1)
+	t.beginElem()
+	t.list(1, thriftStruct, len(chunks))
+	for _, ch := range chunks {
+		t.beginElem()
+		t.i64(2, ch.offset)
+		t.beginStruct(3)
+		t.i32(1, ch.col.typ)
+		t.list(2, thriftI32, 2)
+		t.zigzag(0) // PLAIN
+		t.zigzag(3) // RLE
+		t.list(3, thriftBinary, 1)
+		t.binary(ch.col.name)
+		t.i32(4, 0) // UNCOMPRESSED
+		t.i64(5, int64(rows))
+		t.i64(6, ch.size)
+		t.i64(7, ch.size)
+		t.i64(9, ch.offset)
+		t.endStruct()
+		t.endElem()
+	}
+	t.i64(2, total)
+	t.i64(3, int64(rows))
+	t.endElem()
+
+	t.str(6, "phydata")
+	t.stop()
+	return t.buf.Bytes()
+}
+
+// Types of the thrift compact protocol.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// Thrift is an encoder
+// for the thrift compact protocol,
+// used by the Parquet metadata.
+type thrift struct {
+	buf  bytes.Buffer
+	last int16
+	prev []int16
+}
+
+func (t *thrift) field(id int16, typ byte) {
+	if d := id - t.last; d > 0 && d <= 15 {
+		t.buf.WriteByte(byte(d)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.zigzag(int64(id))
+	}
+	t.last = id
+}
+
+func (t *thrift) varint(v uint64) {
+	t.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func (t *thrift) zigzag(v int64) {
+	t.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (t *thrift) binary(s string) {
+	t.varint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+func (t *thrift) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.zigzag(int64(v))
+}
+
+func (t *thrift) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.zigzag(v)
+}
+
+func (t *thrift) str(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.binary(s)
+}
+
+func (t *thrift) list(id int16, typ byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | typ)
+		return
+	}
+	t.buf.WriteByte(0xf0 | typ)
+	t.varint(uint64(n))
+}
+
+func (t *thrift) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.beginElem()
+}
+
+func (t *thrift) endStruct() {
+	t.endElem()
+}
+
+// BeginElem starts a struct
+// that is an element of a list.
+func (t *thrift) beginElem() {
+	t.prev = append(t.prev, t.last)
+	t.last = 0
+}
+
+func (t *thrift) endElem() {
+	t.stop()
+	t.last = t.prev[len(t.prev)-1]
+	t.prev = t.prev[:len(t.prev)-1]
+}
+
+func (t *thrift) stop() {
+	t.buf.WriteByte(0)
+}
@@ -0,0 +1,232 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestWriteParquet(t *testing.T) {
+	name := stringCol("name", false)
+	name.addString("a")
+	name.addString("bc")
+	num := int64Col("n", true)
+	num.addInt(7, true)
+	num.addInt(0, false)
+	ok := boolCol("ok")
+	ok.addBool(true)
+	ok.addBool(false)
+	cols := []*column{name, num, ok}
+
+	var buf bytes.Buffer
+	if err := writeParquet(&buf, cols); err != nil {
+		t.Fatalf("write parquet: %v", err)
+	}
+	file := buf.Bytes()
+
+	if string(file[:4]) != "PAR1" || string(file[len(file)-4:]) != "PAR1" {
+		t.Fatalf("invalid magic number")
+	}
+	size := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := file[len(file)-8-size : len(file)-8]
+
+	md, n, err := decodeStruct(footer)
+	if err != nil {
+		t.Fatalf("footer: %v", err)
+	}
+	if n != len(footer) {
+		t.Errorf("footer: read %d bytes, want %d", n, len(footer))
+	}
+	if v := md[1]; v != int64(1) {
+		t.Errorf("version: got %v, want 1", v)
+	}
+	if v := md[3]; v != int64(2) {
+		t.Errorf("rows: got %v, want 2", v)
+	}
+
+	schema := md[2].([]any)
+	if len(schema) != len(cols)+1 {
+		t.Fatalf("schema: got %d elements, want %d", len(schema), len(cols)+1)
+	}
+	if v := schema[0].(map[int16]any)[5]; v != int64(len(cols)) {
+		t.Errorf("schema: got %v children, want %d", v, len(cols))
+	}
+	for i, c := range cols {
+		el := schema[i+1].(map[int16]any)
+		if v := el[4]; v != c.name {
+			t.Errorf("schema %d: got name %v, want %q", i, v, c.name)
+		}
+		if v := el[1]; v != int64(c.typ) {
+			t.Errorf("schema %q: got type %v, want %d", c.name, v, c.typ)
+		}
+	}
+
+	groups := md[4].([]any)
+	if len(groups) != 1 {
+		t.Fatalf("row groups: got %d, want 1", len(groups))
+	}
+	chunks := groups[0].(map[int16]any)[1].([]any)
+	if len(chunks) != len(cols) {
+		t.Fatalf("column chunks: got %d, want %d", len(chunks), len(cols))
+	}
+
+	pages := [][]byte{
+		{1, 0, 0, 0, 'a', 2, 0, 0, 0, 'b', 'c'},
+		{4, 0, 0, 0, 2, 1, 2, 0, 7, 0, 0, 0, 0, 0, 0, 0},
+		{0x01},
+	}
+	for i, c := range cols {
+		meta := chunks[i].(map[int16]any)[3].(map[int16]any)
+		if v, want := meta[2], []any{int64(0), int64(3)}; !reflect.DeepEqual(v, want) {
+			t.Errorf("column %q: got encodings %v, want %v", c.name, v, want)
+		}
+		if v, want := meta[3], []any{c.name}; !reflect.DeepEqual(v, want) {
+			t.Errorf("column %q: got path %v, want %v", c.name, v, want)
+		}
+		if v := meta[5]; v != int64(2) {
+			t.Errorf("column %q: got %v values, want 2", c.name, v)
+		}
+
+		offset := int(meta[9].(int64))
+		ph, n, err := decodeStruct(file[offset:])
+		if err != nil {
+			t.Fatalf("column %q: page header: %v", c.name, err)
+		}
+		if v := ph[1]; v != int64(0) {
+			t.Errorf("column %q: got page type %v, want 0", c.name, v)
+		}
+		if v := ph[2]; v != int64(len(pages[i])) {
+			t.Errorf("column %q: got page size %v, want %d", c.name, v, len(pages[i]))
+		}
+		dp := ph[5].(map[int16]any)
+		if v := dp[1]; v != int64(2) {
+			t.Errorf("column %q: got %v page values, want 2", c.name, v)
+		}
+		if v := dp[2]; v != int64(0) {
+			t.Errorf("column %q: got encoding %v, want 0", c.name, v)
+		}
+
+		end := offset + n + len(pages[i])
+		if v := meta[6]; v != int64(end-offset) {
+			t.Errorf("column %q: got chunk size %v, want %d", c.name, v, end-offset)
+		}
+		if page := file[offset+n : end]; !bytes.Equal(page, pages[i]) {
+			t.Errorf("column %q: got page %v, want %v", c.name, page, pages[i])
+		}
+	}
+}
+
+// DecodeStruct decodes a struct
+// encoded with the thrift compact protocol,
+// and returns the fields of the struct
+// and the number of read bytes.
+func decodeStruct(b []byte) (map[int16]any, int, error) {
+	d := &decoder{b: b}
+	s, err := d.readStruct()
+	return s, d.pos, err
+}
+
+type decoder struct {
+	b   []byte
+	pos int
+}
+
+func (d *decoder) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(d.b[d.pos:])
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid varint at %d", d.pos)
+	}
+	d.pos += n
+	return v, nil
+}
+
+func (d *decoder) zigzag() (int64, error) {
+	v, err := d.uvarint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+func (d *decoder) readByte() (byte, error) {
+	if d.pos >= len(d.b) {
+		return 0, fmt.Errorf("unexpected end of data")
+	}
+	v := d.b[d.pos]
+	d.pos++
+	return v, nil
+}
+
+func (d *decoder) readStruct() (map[int16]any, error) {
+	s := make(map[int16]any)
+	var last int16
+	for {
+		h, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if h == 0 {
+			return s, nil
+		}
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			v, err := d.zigzag()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		v, err := d.value(h & 0x0f)
+		if err != nil {
+			return nil, fmt.Errorf("field %d: %v", id, err)
+		}
+		s[id] = v
+		last = id
+	}
+}
+
+func (d *decoder) value(typ byte) (any, error) {
+	switch typ {
+	case thriftI32, thriftI64:
+		return d.zigzag()
+	case thriftBinary:
+		n, err := d.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if d.pos+int(n) > len(d.b) {
+			return nil, fmt.Errorf("unexpected end of data")
+		}
+		v := string(d.b[d.pos : d.pos+int(n)])
+		d.pos += int(n)
+		return v, nil
+	case thriftList:
+		h, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		n := int(h >> 4)
+		if n == 15 {
+			v, err := d.uvarint()
+			if err != nil {
+				return nil, err
+			}
+			n = int(v)
+		}
+		ls := make([]any, 0, n)
+		for i := 0; i < n; i++ {
+			v, err := d.value(h & 0x0f)
+			if err != nil {
+				return nil, err
+			}
+			ls = append(ls, v)
+		}
+		return ls, nil
+	case thriftStruct:
+		return d.readStruct()
+	}
+	return nil, fmt.Errorf("unknown type %d", typ)
+}
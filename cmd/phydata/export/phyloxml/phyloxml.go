@@ -0,0 +1,325 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package phyloxml implements a command to export
+// the taxa of a PhyData project
+// as a PhyloXML file.
+package phyloxml
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+	"github.com/js-arias/phydata/taxonomy"
+)
+
+var Command = &command.Command{
+	Usage: `phyloxml [--gene <name>] [--no-sequences]
+	[-o|--output <file>] <project-file>`,
+	Short: "export taxa as a PhyloXML file",
+	Long: `
+Command phyloxml reads a PhyData project, and writes the taxa of the project,
+with its sequences and external identifiers, as a PhyloXML file (see
+<http://www.phyloxml.org>), that can be read by programs such as Archaeopteryx
+or ETE.
+
+The argument of the command is the name of the project file.
+
+As PhyloXML is a format for trees, the taxa are written as the terminals of
+an unresolved tree. Each terminal includes the taxon name, the external
+identifiers of the taxon (from the taxonomy file of the project), and the
+sequence of each gene (the sequence with more nucleotides, if the taxon has
+multiple sequences for a gene), with its GenBank accession. The NCBI taxon
+ID is used as the identifier of the taxon, and the other identifiers (OTT and
+GBIF) are written as URIs.
+
+Use the flag --gene to export only the sequences of a given gene. Use the
+flag --no-sequences to export only the taxa and its identifiers.
+
+By default, the file will be printed in the standard output. Use the flag
+--output, or -o, to define an output file.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var geneFlag string
+var noSeqs bool
+var output string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&geneFlag, "gene", "", "")
+	c.Flags().BoolVar(&noSeqs, "no-sequences", false, "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+}
+
+func run(c *command.Command, args []string) (err error) {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	pFile := args[0]
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+
+	var coll *dna.Collection
+	if p.Path(project.DNA) != "" && !noSeqs {
+		coll = dna.New()
+		if _, err := dnafiles.Read(p, coll); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+	var tax *taxonomy.Taxonomy
+	if tf := p.Path(project.Taxonomy); tf != "" {
+		tax = taxonomy.New()
+		if err := readData(tf, tax.ReadTSV); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+
+	taxa := make(map[string]bool)
+	if coll != nil {
+		for _, tx := range coll.Taxa() {
+			taxa[tx] = true
+		}
+	}
+	if tax != nil {
+		for _, tx := range tax.Taxa() {
+			taxa[tx] = true
+		}
+	}
+	if len(taxa) == 0 {
+		return fmt.Errorf("on project %q: no taxa", pFile)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(pFile), filepath.Ext(pFile))
+	doc := newDoc(name, taxa, coll, tax)
+
+	out := c.Stdout()
+	if output != "" {
+		var f *os.File
+		f, err = os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			e := f.Close()
+			if e != nil && err == nil {
+				err = e
+			}
+		}()
+		out = f
+	}
+
+	bw := bufio.NewWriter(out)
+	fmt.Fprintf(bw, "%s", xml.Header)
+	enc := xml.NewEncoder(bw)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("while writing PhyloXML: %v", err)
+	}
+	fmt.Fprintf(bw, "\n")
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("while writing PhyloXML: %v", err)
+	}
+	return nil
+}
+
+type phyloXML struct {
+	XMLName   xml.Name  `xml:"phyloxml"`
+	Xmlns     string    `xml:"xmlns,attr"`
+	Phylogeny phylogeny `xml:"phylogeny"`
+}
+
+type phylogeny struct {
+	Rooted bool   `xml:"rooted,attr"`
+	Name   string `xml:"name"`
+	Clade  clade  `xml:"clade"`
+}
+
+type clade struct {
+	Name     string     `xml:"name,omitempty"`
+	Taxonomy *taxon     `xml:"taxonomy,omitempty"`
+	Seqs     []sequence `xml:"sequence"`
+	Clades   []clade    `xml:"clade"`
+}
+
+type taxon struct {
+	ID   *taxonID `xml:"id,omitempty"`
+	Name string   `xml:"scientific_name"`
+	URIs []uri    `xml:"uri"`
+}
+
+type taxonID struct {
+	Provider string `xml:"provider,attr"`
+	ID       string `xml:",chardata"`
+}
+
+type uri struct {
+	Desc string `xml:"desc,attr,omitempty"`
+	URI  string `xml:",chardata"`
+}
+
+type sequence struct {
+	Type      string     `xml:"type,attr"`
+	Accession *accession `xml:"accession,omitempty"`
+	Name      string     `xml:"name"`
+	MolSeq    molSeq     `xml:"mol_seq"`
+}
+
+type accession struct {
+	Source string `xml:"source,attr"`
+	ID     string `xml:",chardata"`
+}
+
+type molSeq struct {
+	Aligned bool   `xml:"is_aligned,attr"`
+	Seq     string `xml:",chardata"`
+}
+
+func newDoc(name string, taxa map[string]bool, coll *dna.Collection, tax *taxonomy.Taxonomy) phyloXML {
+	ls := make([]string, 0, len(taxa))
+	for tx := range taxa {
+		ls = append(ls, tx)
+	}
+	slices.Sort(ls)
+
+	var genes []string
+	if coll != nil {
+		genes = coll.Genes()
+		if geneFlag != "" {
+			genes = []string{strings.ToLower(strings.TrimSpace(geneFlag))}
+		}
+	}
+
+	root := clade{}
+	for _, tx := range ls {
+		cl := clade{
+			Name:     tx,
+			Taxonomy: taxonData(tx, tax),
+		}
+		for _, g := range genes {
+			if s, ok := taxonSequence(coll, tx, g); ok {
+				cl.Seqs = append(cl.Seqs, s)
+			}
+		}
+		root.Clades = append(root.Clades, cl)
+	}
+
+	return phyloXML{
+		Xmlns: "http://www.phyloxml.org",
+		Phylogeny: phylogeny{
+			Name:  name,
+			Clade: root,
+		},
+	}
+}
+
+// TaxonData returns the taxonomy element
+// of a taxon.
+func taxonData(name string, tax *taxonomy.Taxonomy) *taxon {
+	t := &taxon{
+		Name: name,
+	}
+	if tax == nil {
+		return t
+	}
+
+	if id := tax.Val(name, taxonomy.NCBI); id != "" {
+		t.ID = &taxonID{
+			Provider: "ncbi",
+			ID:       id,
+		}
+		t.URIs = append(t.URIs, uri{
+			Desc: "ncbi",
+			URI:  "https://www.ncbi.nlm.nih.gov/Taxonomy/Browser/wwwtax.cgi?id=" + id,
+		})
+	}
+	if id := tax.Val(name, taxonomy.OTT); id != "" {
+		t.URIs = append(t.URIs, uri{
+			Desc: "ott",
+			URI:  "https://tree.opentreeoflife.org/taxonomy/browse?id=" + id,
+		})
+	}
+	if id := tax.Val(name, taxonomy.GBIF); id != "" {
+		t.URIs = append(t.URIs, uri{
+			Desc: "gbif",
+			URI:  "https://www.gbif.org/species/" + id,
+		})
+	}
+	return t
+}
+
+// TaxonSequence returns the sequence of a gene
+// for a taxon.
+// If the taxon has multiple sequences,
+// the one with more nucleotides will be used.
+func taxonSequence(coll *dna.Collection, tx, gene string) (sequence, bool) {
+	var seq, spec, acc string
+	for _, sp := range coll.TaxSpec(tx) {
+		for _, a := range coll.GeneAccession(sp, gene) {
+			s := coll.Sequence(sp, gene, a)
+			if countNucleotides(s) > countNucleotides(seq) {
+				seq = s
+				spec, acc = sp, a
+			}
+		}
+	}
+	if acc == "" {
+		return sequence{}, false
+	}
+
+	s := sequence{
+		Type: "dna",
+		Name: gene,
+		MolSeq: molSeq{
+			Aligned: coll.Val(spec, gene, acc, dna.Aligned) == "true",
+			Seq:     strings.ToUpper(seq),
+		},
+	}
+	if !strings.HasPrefix(acc, "no-gb:") {
+		s.Accession = &accession{
+			Source: "genbank",
+			ID:     acc,
+		}
+	}
+	return s, true
+}
+
+func countNucleotides(seq string) int {
+	var n int
+	for _, r := range seq {
+		switch r {
+		case 'a', 'c', 'g', 't', 'u':
+			n++
+		}
+	}
+	return n
+}
+
+func readData(name string, read func(io.Reader) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := read(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
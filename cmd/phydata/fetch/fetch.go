@@ -0,0 +1,247 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package fetch implements a client
+// for the web services used by PhyData,
+// that paces the requests,
+// and retries failed requests with an exponential backoff,
+// so large searches do not overload the servers.
+//
+// The behavior of the client,
+// and the credentials of the services,
+// can be defined with environment variables:
+//
+//	PHYDATA_WAIT     the minimum time between requests
+//	                 (e.g., "1s", or "500ms").
+//	                 It is ignored if it is smaller than
+//	                 the time required by the service.
+//	PHYDATA_RETRIES  the maximum number of retries
+//	                 of a failed request.
+//	NCBI_API_KEY     the API key of the NCBI E-utilities.
+//	NCBI_EMAIL       the email sent to the NCBI E-utilities.
+package fetch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/js-arias/phydata/cmd/phydata/config"
+	"github.com/js-arias/phydata/cmd/phydata/progress"
+)
+
+// Environment variables.
+const (
+	WaitEnv      = "PHYDATA_WAIT"
+	RetriesEnv   = "PHYDATA_RETRIES"
+	NCBIKeyEnv   = "NCBI_API_KEY"
+	NCBIEmailEnv = "NCBI_EMAIL"
+)
+
+// DefaultRetries is the default number of retries
+// of a failed request.
+const defaultRetries = 5
+
+// Backoff limits.
+const (
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// UserAgent is the user agent of the requests.
+const userAgent = "phydata (https://github.com/js-arias/phydata)"
+
+// A Client is an HTTP client
+// that paces the requests to a service,
+// and retries the failed requests.
+type Client struct {
+	client  *http.Client
+	wait    time.Duration
+	retries int
+	last    time.Time
+}
+
+// New returns a new client
+// with a minimum time between requests.
+func New(wait time.Duration) *Client {
+	if v := os.Getenv(WaitEnv); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > wait {
+			wait = d
+		}
+	}
+	retries := defaultRetries
+	if v := os.Getenv(RetriesEnv); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			retries = n
+		}
+	}
+	return &Client{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		wait:    wait,
+		retries: retries,
+	}
+}
+
+// JSON sends a request
+// and decodes the JSON response into v.
+// If body is not nil,
+// it is sent as a JSON content.
+//
+// Requests are separated at least by the wait time
+// of the client.
+// If the request fails because of a network error,
+// or the server answers with a 429 (too many requests)
+// or a 5xx status,
+// the request is retried after an exponential backoff
+// (or the time requested by the server).
+func (c *Client) JSON(method, u string, body []byte, v any) error {
+	data, err := c.request(method, u, body, "application/json")
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("request %q: %v", u, err)
+	}
+	return nil
+}
+
+// Get sends a GET request
+// and returns the body of the response
+// (e.g., to download a file).
+// Failed requests are retried
+// as in JSON.
+func (c *Client) Get(u string) ([]byte, error) {
+	return c.request(http.MethodGet, u, nil, "*/*")
+}
+
+// Request sends a request,
+// retrying the failed requests,
+// and returns the body of the response.
+func (c *Client) request(method, u string, body []byte, accept string) ([]byte, error) {
+	backoff := minBackoff
+	for try := 0; ; try++ {
+		c.pace()
+		data, retry, err := c.do(method, u, body, accept)
+		if err == nil {
+			return data, nil
+		}
+		if retry < 0 || try >= c.retries {
+			return nil, err
+		}
+
+		if retry == 0 {
+			retry = backoff
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+		progress.Printf("%v: retrying in %v", err, retry)
+		time.Sleep(retry)
+	}
+}
+
+// Pace waits until the wait time
+// since the last request is elapsed.
+func (c *Client) pace() {
+	if !c.last.IsZero() {
+		if d := c.wait - time.Since(c.last); d > 0 {
+			time.Sleep(d)
+		}
+	}
+	c.last = time.Now()
+}
+
+// Do sends a request
+// and returns the body of the response.
+// If the request fails,
+// it returns the time to wait before a retry
+// (0 for the default backoff),
+// or a negative value if the request must not be retried.
+func (c *Client) do(method, u string, body []byte, accept string) ([]byte, time.Duration, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, u, r)
+	if err != nil {
+		return nil, -1, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("request %q: %s", u, resp.Status)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return nil, retryAfter(resp.Header.Get("Retry-After")), err
+		}
+		return nil, -1, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("request %q: %v", u, err)
+	}
+	return data, 0, nil
+}
+
+// RetryAfter returns the time to wait
+// defined in a Retry-After header.
+// It returns 0 if the header is empty or invalid.
+func retryAfter(v string) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		d := time.Duration(s) * time.Second
+		if d > maxBackoff {
+			d = maxBackoff
+		}
+		return d
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d <= 0 {
+			return 0
+		}
+		if d > maxBackoff {
+			d = maxBackoff
+		}
+		return d
+	}
+	return 0
+}
+
+// Entrez returns the API key and the email
+// used in the requests to the NCBI E-utilities.
+// The values of the environment variables
+// replace the values of the configuration file.
+func Entrez() (key, email string) {
+	key = os.Getenv(NCBIKeyEnv)
+	if key == "" {
+		key = config.Get(config.EntrezKey, "")
+	}
+	email = os.Getenv(NCBIEmailEnv)
+	if email == "" {
+		email = config.Get(config.EntrezEmail, "")
+	}
+	return key, email
+}
@@ -0,0 +1,272 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package export implements a command to export
+// the geographic locations of the specimens
+// of a PhyData project.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/config"
+	"github.com/js-arias/phydata/project"
+	"github.com/js-arias/phydata/specimen"
+)
+
+var Command = &command.Command{
+	Usage: `export [-f|--format <format>] [-o|--output <file>]
+	[--taxa <file>]
+	<project-file>`,
+	Short: "export specimen locations",
+	Long: `
+Command export reads a PhyData project and writes the geographic locations of
+the specimens in a format that can be used by biogeographic software or a
+GIS.
+
+The argument of the command is the name of the project file.
+
+Only the specimens with valid geographic coordinates (latitude and longitude
+in decimal degrees) are exported. By default the output is a CSV file with
+the fields taxon, specimen, voucher, latitude, longitude, and locality. Use
+the flag -f or --format to define a format. Valid formats are:
+
+	csv      comma-delimited file (default)
+	geojson  a GeoJSON feature collection of points
+
+A different default format can be defined with the key 'geo-format' of the
+configuration file (see 'phydata help config').
+
+By default, the output is printed in the standard output. Use the flag
+--output, or -o, to define an output file.
+
+By default, the specimens of all taxa are exported. If the flag --taxa is
+defined with a file, only the specimens of the taxa in that file will be
+exported (e.g., the taxa file used to build a matrix). In the file each line
+will be read as a taxon name, if the line has more than one column (separated
+by tabs), only the first column will be used. Blank lines and lines starting
+with '#' will be ignored.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var format string
+var output string
+var taxaFile string
+
+func setFlags(c *command.Command) {
+	defFormat := config.Get(config.GeoFormat, "csv")
+	c.Flags().StringVar(&format, "format", defFormat, "")
+	c.Flags().StringVar(&format, "f", defFormat, "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+	c.Flags().StringVar(&taxaFile, "taxa", "", "")
+}
+
+func run(c *command.Command, args []string) (err error) {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	sf := p.Path(project.Specimens)
+	if sf == "" {
+		return fmt.Errorf("undefined specimens file")
+	}
+	reg := specimen.New()
+	if err := readSpecFile(sf, reg); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	var filter map[string]bool
+	if taxaFile != "" {
+		filter, err = readFilter(taxaFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	var write func(io.Writer, *specimen.Registry, []string) error
+	switch strings.ToLower(format) {
+	case "csv":
+		write = writeCSV
+	case "geojson":
+		write = writeGeoJSON
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	var specs []string
+	for _, tx := range reg.Taxa() {
+		if filter != nil && !filter[strings.ToLower(tx)] {
+			continue
+		}
+		for _, sp := range reg.TaxSpec(tx) {
+			if _, _, ok := reg.Geo(sp); !ok {
+				continue
+			}
+			specs = append(specs, sp)
+		}
+	}
+
+	out := c.Stdout()
+	if output != "" {
+		var f *os.File
+		f, err = os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			e := f.Close()
+			if e != nil && err == nil {
+				err = e
+			}
+		}()
+		out = f
+	}
+
+	bw := bufio.NewWriter(out)
+	if err := write(bw, reg, specs); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func writeCSV(w io.Writer, reg *specimen.Registry, specs []string) error {
+	tab := csv.NewWriter(w)
+	if err := tab.Write([]string{"taxon", "specimen", "voucher", "latitude", "longitude", "locality"}); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+	for _, sp := range specs {
+		row := []string{
+			reg.Taxon(sp),
+			sp,
+			reg.Val(sp, specimen.Voucher),
+			reg.Val(sp, specimen.Latitude),
+			reg.Val(sp, specimen.Longitude),
+			reg.Val(sp, specimen.Locality),
+		}
+		if err := tab.Write(row); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	Type       string            `json:"type"`
+	Geometry   geometry          `json:"geometry"`
+	Properties map[string]string `json:"properties"`
+}
+
+type geometry struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+func writeGeoJSON(w io.Writer, reg *specimen.Registry, specs []string) error {
+	fc := featureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]feature, 0, len(specs)),
+	}
+	for _, sp := range specs {
+		lat, lon, _ := reg.Geo(sp)
+		props := map[string]string{
+			"taxon":    reg.Taxon(sp),
+			"specimen": sp,
+		}
+		if v := reg.Val(sp, specimen.Voucher); v != "" {
+			props["voucher"] = v
+		}
+		if l := reg.Val(sp, specimen.Locality); l != "" {
+			props["locality"] = l
+		}
+		fc.Features = append(fc.Features, feature{
+			Type: "Feature",
+			Geometry: geometry{
+				Type:        "Point",
+				Coordinates: [2]float64{lon, lat},
+			},
+			Properties: props,
+		})
+	}
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	if err := e.Encode(fc); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+func readSpecFile(name string, r *specimen.Registry) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := r.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func readFilter(name string) (map[string]bool, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	filter := make(map[string]bool)
+	for i := 1; ; i++ {
+		ln, err := r.ReadString('\n')
+		if errors.Is(err, io.EOF) && ln == "" {
+			break
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("on file %q: line %d: %v", name, i, err)
+		}
+
+		ln, _, _ = strings.Cut(ln, "\t")
+		n := strings.Join(strings.Fields(ln), " ")
+		if n == "" {
+			continue
+		}
+		if n[0] == '#' {
+			continue
+		}
+		filter[strings.ToLower(n)] = true
+	}
+
+	return filter, nil
+}
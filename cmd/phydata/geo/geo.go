@@ -0,0 +1,22 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package geo is a metapackage for commands
+// that dealt with the geographic locations
+// of the specimens.
+package geo
+
+import (
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/geo/export"
+)
+
+func init() {
+	Command.Add(export.Command)
+}
+
+var Command = &command.Command{
+	Usage: "geo <command> [<argument>...]",
+	Short: "commands for specimen locations",
+}
@@ -0,0 +1,267 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package grep implements a command to search a pattern
+// in all the datasets of a PhyData project.
+package grep
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/matrix/protein"
+	"github.com/js-arias/phydata/project"
+	"github.com/js-arias/phydata/specimen"
+)
+
+var Command = &command.Command{
+	Usage: "grep [-i] <project-file> <pattern>",
+	Short: "search a pattern in a project",
+	Long: `
+Command grep reads a PhyData project, and search a pattern in the taxon
+names, specimen IDs, characters, states, comments, references, vouchers, and
+accessions of all the datasets of the project.
+
+The first argument of the command is the name of the project file.
+
+The second argument is the pattern to search. The pattern is a regular
+expression, using the syntax of the Go regular expressions
+(see <https://pkg.go.dev/regexp/syntax>). By default the search is case
+sensitive. Use the flag -i to make a case-insensitive search.
+
+The output is a TSV table with the dataset, the taxon, the specimen, the
+record (a character and state for observations, or a gene and accession for
+sequences), the field, and the value of each hit. Hits in taxon names are
+reported once for each dataset, and hits in specimen IDs are reported once
+for each specimen.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var ignoreCase bool
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&ignoreCase, "i", false, "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if len(args) < 2 {
+		return c.UsageError("expecting pattern")
+	}
+
+	expr := args[1]
+	if ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return c.UsageError(fmt.Sprintf("invalid pattern %q: %v", args[1], err))
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	tab := csv.NewWriter(c.Stdout())
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	if err := tab.Write([]string{"dataset", "taxon", "specimen", "record", "field", "value"}); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+
+	g := &grep{
+		re:  re,
+		tab: tab,
+	}
+	if mf := p.Path(project.Observations); mf != "" {
+		m := matrix.New()
+		if err := readObsFile(mf, m); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+		g.obs(m)
+	}
+	if p.Path(project.DNA) != "" {
+		coll := dna.New()
+		if _, err := dnafiles.Read(p, coll); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+		g.dna(coll)
+	}
+	if pf := p.Path(project.Proteins); pf != "" {
+		coll := protein.New()
+		if err := readProteinFile(pf, coll); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+		g.proteins(coll)
+	}
+	if sf := p.Path(project.Specimens); sf != "" {
+		reg := specimen.New()
+		if err := readSpecFile(sf, reg); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+		g.specimens(reg)
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+// A grep stores the search pattern
+// and the output table.
+type grep struct {
+	re  *regexp.Regexp
+	tab *csv.Writer
+}
+
+// Match writes a hit
+// if the value matches the pattern.
+func (g *grep) match(set project.Dataset, tax, spec, rec, field, val string) {
+	if val == "" || !g.re.MatchString(val) {
+		return
+	}
+	g.tab.Write([]string{string(set), tax, spec, rec, field, val})
+}
+
+func (g *grep) obs(m *matrix.Matrix) {
+	set := project.Observations
+	fields := []matrix.Field{matrix.Reference, matrix.ImageLink, matrix.Comments, matrix.Reviewer, matrix.AddedBy, matrix.Timestamp, matrix.Uncertain, matrix.Source}
+	chars := m.Chars()
+	for _, tax := range m.Taxa() {
+		g.match(set, tax, "", "", "taxon", tax)
+		for _, spec := range m.TaxSpec(tax) {
+			g.match(set, tax, spec, "", "specimen", spec)
+			for _, char := range chars {
+				obs := m.Obs(spec, char)
+				if len(obs) == 0 {
+					continue
+				}
+				g.match(set, tax, spec, char, "character", char)
+				for _, st := range obs {
+					rec := char + ": " + st
+					g.match(set, tax, spec, rec, "state", st)
+					for _, f := range fields {
+						g.match(set, tax, spec, rec, string(f), m.Val(spec, char, st, f))
+					}
+				}
+			}
+		}
+	}
+}
+
+func (g *grep) dna(coll *dna.Collection) {
+	var set project.Dataset = project.DNA
+	fields := []dna.Field{dna.Reference, dna.Comments}
+	for _, tax := range coll.Taxa() {
+		g.match(set, tax, "", "", "taxon", tax)
+		for _, spec := range coll.TaxSpec(tax) {
+			g.match(set, tax, spec, "", "specimen", spec)
+			for _, gene := range coll.SpecGene(spec) {
+				for _, acc := range coll.GeneAccession(spec, gene) {
+					rec := gene + ": " + acc
+					g.match(set, tax, spec, rec, "gene", gene)
+					g.match(set, tax, spec, rec, "genbank", acc)
+					for _, f := range fields {
+						g.match(set, tax, spec, rec, string(f), coll.Val(spec, gene, acc, f))
+					}
+				}
+			}
+		}
+	}
+}
+
+func (g *grep) proteins(coll *protein.Collection) {
+	set := project.Proteins
+	fields := []protein.Field{protein.Reference, protein.Comments}
+	for _, tax := range coll.Taxa() {
+		g.match(set, tax, "", "", "taxon", tax)
+		for _, spec := range coll.TaxSpec(tax) {
+			g.match(set, tax, spec, "", "specimen", spec)
+			for _, gene := range coll.SpecGene(spec) {
+				for _, acc := range coll.GeneAccession(spec, gene) {
+					rec := gene + ": " + acc
+					g.match(set, tax, spec, rec, "gene", gene)
+					g.match(set, tax, spec, rec, "accession", acc)
+					for _, f := range fields {
+						g.match(set, tax, spec, rec, string(f), coll.Val(spec, gene, acc, f))
+					}
+				}
+			}
+		}
+	}
+}
+
+func (g *grep) specimens(reg *specimen.Registry) {
+	set := project.Specimens
+	fields := []specimen.Field{
+		specimen.Voucher,
+		specimen.Latitude,
+		specimen.Longitude,
+		specimen.Locality,
+		specimen.Collector,
+		specimen.Date,
+		specimen.Comments,
+	}
+	for _, tax := range reg.Taxa() {
+		g.match(set, tax, "", "", "taxon", tax)
+		for _, spec := range reg.TaxSpec(tax) {
+			g.match(set, tax, spec, "", "specimen", spec)
+			for _, f := range fields {
+				g.match(set, tax, spec, "", string(f), reg.Val(spec, f))
+			}
+		}
+	}
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func readProteinFile(name string, c *protein.Collection) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := c.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func readSpecFile(name string, r *specimen.Registry) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := r.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package lock implements the locking of the project files
+// by the commands that modify a project,
+// so two PhyData processes
+// do not write the same project at the same time.
+//
+// The dataset files are not locked,
+// so a dataset file shared by different projects
+// can be written by commands run on different projects
+// at the same time.
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/js-arias/phydata/cmd/phydata/progress"
+	"github.com/js-arias/phydata/project"
+)
+
+// Break is set by the global flag --break-lock.
+// If true,
+// a previous lock of the project is removed.
+var Break bool
+
+// Timeout is the maximum time
+// to wait for a locked project.
+const timeout = 10 * time.Second
+
+// poll is the time between attempts
+// to acquire a lock.
+const poll = 100 * time.Millisecond
+
+// Project locks a project file,
+// and returns a function to release the lock.
+// If the project is locked by other process,
+// it waits up to ten seconds
+// for the release of the lock.
+// Only the project file is locked
+// (see project.Lock).
+func Project(name string) (unlock func(), err error) {
+	if Break {
+		if err := project.BreakLock(name); err != nil {
+			return nil, fmt.Errorf("while breaking lock of %q: %v", name, err)
+		}
+	}
+
+	start := time.Now()
+	waiting := false
+	for {
+		l, err := project.LockFile(name)
+		if err == nil {
+			return func() { l.Release() }, nil
+		}
+		if !errors.Is(err, project.ErrLocked) {
+			return nil, err
+		}
+		if time.Since(start) > timeout {
+			return nil, fmt.Errorf("%v (use --break-lock if the lock is stale)", err)
+		}
+		if !waiting {
+			progress.Printf("waiting: %v", err)
+			waiting = true
+		}
+		time.Sleep(poll)
+	}
+}
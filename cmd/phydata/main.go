@@ -7,23 +7,89 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"os"
+
 	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/ages"
+	"github.com/js-arias/phydata/cmd/phydata/config"
 	"github.com/js-arias/phydata/cmd/phydata/dna"
+	"github.com/js-arias/phydata/cmd/phydata/export"
+	"github.com/js-arias/phydata/cmd/phydata/geo"
+	"github.com/js-arias/phydata/cmd/phydata/grep"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
 	"github.com/js-arias/phydata/cmd/phydata/matrix"
 	"github.com/js-arias/phydata/cmd/phydata/obs"
+	"github.com/js-arias/phydata/cmd/phydata/progress"
+	"github.com/js-arias/phydata/cmd/phydata/project"
+	"github.com/js-arias/phydata/cmd/phydata/report"
+	"github.com/js-arias/phydata/cmd/phydata/spec"
+	"github.com/js-arias/phydata/cmd/phydata/taxa"
 )
 
 var app = &command.Command{
-	Usage: "phydata <command> [<argument>...]",
+	Usage: "phydata [-v|--verbose] [--break-lock] <command> [<argument>...]",
 	Short: "a tool for phylogenetic data management",
+	Long: `
+PhyData is a tool for the management of character data (morphological
+observations, and DNA sequences) for phylogenetic analysis.
+
+If the flag --verbose, or -v, is defined before the command, long-running
+commands (e.g., 'phydata dna add', or 'phydata matrix') will print progress
+messages in the standard error, with the number of records read, the files
+written, and the elapsed time.
+
+Commands that modify a project lock the project file, by creating a file with
+the name of the project file and the suffix '.lock' (e.g., 'project.tab.lock'),
+so two PhyData processes (e.g., two users of a shared drive) do not modify the
+same project at the same time. If the project is locked, the command waits a
+few seconds for the release of the lock, and then fails. The lock is removed
+when the command ends. If a lock was not removed (e.g., because the process
+was killed), use the flag --break-lock, before the command, to remove the
+stale lock. Only the project file is locked, not the dataset files, so if a
+dataset file is shared by different projects, the commands that modify those
+projects can still write the file at the same time.
+
+The default values of some flags (e.g., the author of the imported
+observations, or the format of the exported matrices) can be defined in a
+configuration file (see 'phydata help config').
+	`,
+	SetFlags: setFlags,
+}
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&progress.Verbose, "verbose", false, "")
+	c.Flags().BoolVar(&progress.Verbose, "v", false, "")
+	c.Flags().BoolVar(&lock.Break, "break-lock", false, "")
 }
 
 func init() {
+	app.Add(completionCommand)
+	app.Add(config.Command)
+	app.Add(ages.Command)
 	app.Add(dna.Command)
+	app.Add(export.Command)
+	app.Add(geo.Command)
+	app.Add(grep.Command)
 	app.Add(matrix.Command)
 	app.Add(obs.Command)
+	app.Add(project.Command)
+	app.Add(report.Command)
+	app.Add(spec.Command)
+	app.Add(taxa.Command)
 }
 
 func main() {
+	if err := config.Load(); err != nil {
+		fmt.Fprintf(os.Stderr, "phydata: %v.\n", err)
+		os.Exit(1)
+	}
+	aliases, err := readAliases(aliasFile())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "phydata: %v.\n", err)
+		os.Exit(1)
+	}
+	os.Args = append(os.Args[:1], expandAlias(os.Args[1:], aliases)...)
 	app.Main()
 }
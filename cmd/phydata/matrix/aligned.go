@@ -0,0 +1,73 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+// CheckAligned checks that the sequences
+// used for the matrix are aligned,
+// and that the aligned sequences of each gene
+// have the same length.
+// Sequences with a start position
+// are placed in the coordinates of the reference gene,
+// so their length is not checked.
+// If allowUnaligned is defined,
+// the problems are written in w
+// instead of returning an error.
+func checkAligned(w io.Writer, coll *dna.Collection, taxa []string) error {
+	for _, gene := range coll.Genes() {
+		var ln int
+		var first string
+		for _, tx := range taxa {
+			spec, acc := taxonAccession(coll, tx, gene)
+			if acc == "" {
+				continue
+			}
+
+			var err error
+			if coll.Val(spec, gene, acc, dna.Aligned) != "true" {
+				err = fmt.Errorf("gene %q: taxon %q: sequence %q: sequence not aligned", gene, tx, acc)
+			} else if coll.Val(spec, gene, acc, dna.Start) == "" {
+				l := len(coll.Sequence(spec, gene, acc))
+				if ln == 0 {
+					ln = l
+					first = acc
+				} else if l != ln {
+					err = fmt.Errorf("gene %q: taxon %q: sequence %q: aligned length %d, sequence %q length %d", gene, tx, acc, l, first, ln)
+				}
+			}
+			if err == nil {
+				continue
+			}
+			if !allowUnaligned {
+				return err
+			}
+			fmt.Fprintf(w, "warning: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// VariantSelection returns the selected version of each gene
+// from a comma-separated list of gene names
+// (e.g., "cytb:manual,coi").
+func variantSelection(ls string) map[string]string {
+	sel := make(map[string]string)
+	for _, v := range strings.Split(ls, ",") {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" {
+			continue
+		}
+		g, _ := dna.SplitVariant(v)
+		sel[g] = v
+	}
+	return sel
+}
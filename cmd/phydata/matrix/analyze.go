@@ -0,0 +1,243 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/coverage"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var analyzeCommand = &command.Command{
+	Usage: `analyze [--taxa <file>] [--species] [--variant <gene-list>]
+	[--top <number>]
+	<project> <data-type>...`,
+	Short: "analyze the taxon coverage of a data matrix",
+	Long: `
+Command analyze reads a PhyData project, and analyzes the taxon coverage of
+the partitions (the morphology, and each gene) of the data matrix that will be
+built with the data stored in the project (see 'phydata matrix'), so the
+matrix can be improved before the phylogenetic analysis.
+
+The first argument is the name of the project file.
+
+The second and following arguments, are the types of data that will be
+included in the data matrix. Valid values are:
+
+	obs	used for morphological characters
+	dna	used for DNA sequences
+
+The command prints:
+
+	- The number of taxa and partitions of the matrix, the occupancy (the
+	  fraction of taxon-partition cells with data), and the quartet
+	  decisiveness (the fraction of quartets of taxa for which at least one
+	  partition has data for the four taxa, Sanderson et al. 2010, BMC Evol.
+	  Biol. 10: 155).
+	- For each partition, the number of taxa with data, and its occupancy.
+	- A table with the number of taxa shared between each pair of
+	  partitions.
+	- The suggested changes to the taxon sample that would most improve the
+	  decisiveness of the matrix: the taxa that, if added to a partition
+	  (i.e., by sequencing a gene, or coding the morphology of the taxon),
+	  will increase the decisiveness, and the taxa whose removal from the
+	  matrix will increase the decisiveness. By default, the ten best
+	  suggestions are printed. Use the flag --top to define a different
+	  number.
+
+If the number of quartets of taxa is too large, the decisiveness is estimated
+from a random sample of quartets.
+
+By default, all taxa in the project will be used. If the flag --taxa is
+defined with a file, only the taxa in that file will be used (see 'phydata
+help matrix' for the format of the file). If the flag --species is defined,
+infraspecific taxa will be merged into its species. If a gene has more than
+one alignment version, use the flag --variant with a comma-separated list of
+the versions that will be used.
+	`,
+	SetFlags: setAnalyzeFlags,
+	Run:      runAnalyze,
+}
+
+func init() {
+	Command.Add(analyzeCommand)
+}
+
+var topFlag int
+
+func setAnalyzeFlags(c *command.Command) {
+	c.Flags().StringVar(&txLsFile, "taxa", "", "")
+	c.Flags().BoolVar(&speciesFlag, "species", false, "")
+	c.Flags().StringVar(&variants, "variant", "", "")
+	c.Flags().IntVar(&topFlag, "top", 10, "")
+}
+
+// AnalyzeErr is the error of the analyze command.
+// As the analyze command is executed by the matrix command,
+// the error is returned by the matrix command,
+// so the error message is not prefixed twice.
+var analyzeErr error
+
+func runAnalyze(c *command.Command, args []string) error {
+	err := analyze(c, args)
+	if errors.Is(err, c.UsageError("")) {
+		return err
+	}
+	analyzeErr = err
+	return nil
+}
+
+func analyze(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if len(args) < 2 {
+		return c.UsageError("expecting data type definitions")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	var m *matrix.Matrix
+	var coll *dna.Collection
+	for _, a := range args[1:] {
+		switch strings.ToLower(a) {
+		case "obs":
+			mf := p.Path(project.Observations)
+			if mf == "" {
+				return fmt.Errorf("undefined observations file")
+			}
+			m = matrix.New()
+			if err := readObsFile(mf, m); err != nil {
+				return fmt.Errorf("on project %q: %v", args[0], err)
+			}
+		case "dna":
+			if p.Path(project.DNA) == "" {
+				return fmt.Errorf("undefined DNA file")
+			}
+			coll = dna.New()
+			if _, err := dnafiles.Read(p, coll); err != nil {
+				return fmt.Errorf("on project %q: %v", args[0], err)
+			}
+			coll, err = coll.SelectVariants(variantSelection(variants))
+			if err != nil {
+				return fmt.Errorf("on project %q: %v", args[0], err)
+			}
+		}
+	}
+	if m == nil && coll == nil {
+		return fmt.Errorf("data types %v not defined in the project", args[1:])
+	}
+
+	if speciesFlag {
+		collapseSpecies(m, coll, nil)
+	}
+
+	var txLs []string
+	if txLsFile != "" {
+		txLs, _, err = readTaxa(txLsFile)
+		if err != nil {
+			return err
+		}
+		var chLs []string
+		if m != nil {
+			chLs = m.Chars()
+		}
+		m, coll = filterData(m, coll, txLs, chLs)
+	}
+	if len(txLs) == 0 {
+		txLs = getTaxaList(m, coll)
+	}
+
+	cov := buildCoverage(m, coll, txLs)
+	printCoverage(c.Stdout(), cov)
+	return nil
+}
+
+// A suggestion is a change in the taxon sample
+// that increases the decisiveness of the matrix.
+type suggestion struct {
+	action string // either "add" or "remove"
+	taxon  string
+	part   string
+	gain   float64
+}
+
+// PrintCoverage prints the statistics of a coverage table,
+// the number of taxa shared between partitions,
+// and the changes to the taxon sample
+// that would most improve the decisiveness of the matrix.
+func printCoverage(w io.Writer, cov *coverage.Coverage) {
+	taxa := cov.Taxa()
+	parts := cov.Partitions()
+	dec := cov.Decisiveness(1)
+	fmt.Fprintf(w, "matrix: %d taxa, %d partitions, occupancy %.3f, decisiveness %.3f\n", len(taxa), len(parts), cov.Total(), dec)
+
+	fmt.Fprintf(w, "\npartition\ttaxa\toccupancy\n")
+	for _, pt := range parts {
+		var n int
+		for _, tx := range taxa {
+			if cov.Has(pt, tx) {
+				n++
+			}
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.3f\n", pt, n, cov.Occupancy(pt))
+	}
+
+	fmt.Fprintf(w, "\nshared taxa\t%s\n", strings.Join(parts, "\t"))
+	for _, a := range parts {
+		row := make([]string, 0, len(parts)+1)
+		row = append(row, a)
+		for _, b := range parts {
+			row = append(row, fmt.Sprintf("%d", cov.Shared(a, b)))
+		}
+		fmt.Fprintf(w, "%s\n", strings.Join(row, "\t"))
+	}
+
+	var sg []suggestion
+	for _, tx := range taxa {
+		for _, pt := range parts {
+			if cov.Has(pt, tx) {
+				continue
+			}
+			if g := cov.Gain(pt, tx, 1); g > 0 {
+				sg = append(sg, suggestion{action: "add", taxon: tx, part: pt, gain: g})
+			}
+		}
+		nc := cov.Clone()
+		nc.DeleteTaxon(tx)
+		if g := nc.Decisiveness(1) - dec; g > 0 {
+			sg = append(sg, suggestion{action: "remove", taxon: tx, gain: g})
+		}
+	}
+	slices.SortStableFunc(sg, func(a, b suggestion) int {
+		if a.gain > b.gain {
+			return -1
+		}
+		if a.gain < b.gain {
+			return 1
+		}
+		return 0
+	})
+	if topFlag > 0 && len(sg) > topFlag {
+		sg = sg[:topFlag]
+	}
+
+	fmt.Fprintf(w, "\naction\ttaxon\tpartition\tdecisiveness\tgain\n")
+	for _, s := range sg {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%.3f\t%.3f\n", s.action, s.taxon, s.part, dec+s.gain, s.gain)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+// A block is a partition of the matrix
+// (morphology, a gene, or the indels).
+// The row of each taxon is built
+// when the block is written,
+// so only the rows of a single block
+// are kept in memory.
+// Taxa with an empty row are not written.
+type block struct {
+	head string
+	taxa []string
+	row  func(tx string) string
+}
+
+// GeneRow returns a function that builds
+// the row of a taxon for a gene,
+// padded with missing data
+// to the length of the gene.
+// If pad is false,
+// taxa without a sequence of the gene
+// have an empty row.
+func geneRow(coll *dna.Collection, gene string, pad bool) func(tx string) string {
+	ns := coll.MaxLen(gene)
+	return func(tx string) string {
+		seq := taxonSequence(coll, tx, gene)
+		if len(seq) == 0 && !pad {
+			return ""
+		}
+		if len(seq) < ns {
+			seq += strings.Repeat("?", ns-len(seq))
+		}
+		return seq
+	}
+}
+
+// MapRow returns a function that returns
+// the row of a taxon
+// from a map of rows.
+func mapRow(rows map[string]string) func(tx string) string {
+	return func(tx string) string {
+		return rows[tx]
+	}
+}
+
+// WriteBlocks writes the partitions of a matrix
+// as blocks,
+// one block at a time.
+// If width is greater than zero,
+// each partition is split in interleaved blocks
+// with at most width characters.
+func writeBlocks(w io.Writer, blocks []block, names map[string]string, width int) {
+	for _, b := range blocks {
+		if width <= 0 {
+			fmt.Fprintf(w, "%s\n", b.head)
+			for _, tx := range b.taxa {
+				r := b.row(tx)
+				if r == "" {
+					continue
+				}
+				fmt.Fprintf(w, "%s\t%s\n", names[tx], r)
+			}
+			fmt.Fprintf(w, "\n")
+			continue
+		}
+
+		chunks := make(map[string][]string, len(b.taxa))
+		var nb int
+		for _, tx := range b.taxa {
+			c := splitCells(b.row(tx), width)
+			chunks[tx] = c
+			if len(c) > nb {
+				nb = len(c)
+			}
+		}
+		for i := 0; i < nb; i++ {
+			fmt.Fprintf(w, "%s\n", b.head)
+			for _, tx := range b.taxa {
+				c := chunks[tx]
+				if i >= len(c) {
+					continue
+				}
+				fmt.Fprintf(w, "%s\t%s\n", names[tx], c[i])
+			}
+			fmt.Fprintf(w, "\n")
+		}
+	}
+}
+
+// WriteWrapped writes the partitions of a matrix
+// as a single sequential block,
+// with the row of each taxon
+// wrapped at width characters.
+func writeWrapped(w io.Writer, blocks []block, taxa []string, names map[string]string, width int) {
+	for _, tx := range taxa {
+		var row strings.Builder
+		for _, b := range blocks {
+			row.WriteString(b.row(tx))
+		}
+		for i, c := range splitCells(row.String(), width) {
+			if i == 0 {
+				fmt.Fprintf(w, "%s\t%s\n", names[tx], c)
+				continue
+			}
+			fmt.Fprintf(w, "\t%s\n", c)
+		}
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+// SplitCells splits a row in chunks
+// of at most n characters.
+// Polymorphic and uncertain cells
+// (enclosed by brackets or parenthesis)
+// are counted as a single character,
+// and never split.
+func splitCells(row string, n int) []string {
+	var chunks []string
+	start, cells, depth := 0, 0, 0
+	for i, r := range row {
+		if depth == 0 && cells == n {
+			chunks = append(chunks, row[start:i])
+			start = i
+			cells = 0
+		}
+		switch r {
+		case '[', '{', '(':
+			depth++
+		case ']', '}', ')':
+			depth--
+		}
+		if depth == 0 {
+			cells++
+		}
+	}
+	if start < len(row) {
+		chunks = append(chunks, row[start:])
+	}
+	return chunks
+}
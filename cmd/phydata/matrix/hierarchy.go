@@ -0,0 +1,127 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/js-arias/phydata/characters"
+	"github.com/js-arias/phydata/matrix"
+)
+
+// A dependency is a character of the exported matrix
+// that is only applicable
+// when its controlling character
+// has one of the given states.
+type dependency struct {
+	char   int   // position of the dependent character (0-based)
+	parent int   // position of the controlling character (0-based)
+	states []int // state codes of the controlling character
+}
+
+// CharDependencies returns the dependencies
+// between the exported characters,
+// as defined in the characters catalog.
+// Dependencies with a controlling character
+// that is not exported are ignored.
+func charDependencies(m *matrix.Matrix, cat *characters.Catalog, chars []string) []dependency {
+	if m == nil || cat == nil {
+		return nil
+	}
+
+	var deps []dependency
+	for i, c := range chars {
+		parent, states := cat.Dependency(c)
+		if parent == "" {
+			continue
+		}
+		p := slices.Index(chars, parent)
+		if p < 0 {
+			continue
+		}
+		d := dependency{
+			char:   i,
+			parent: p,
+		}
+		for j, s := range m.States(parent) {
+			if slices.Contains(states, s) {
+				d.states = append(d.states, j)
+			}
+		}
+		deps = append(deps, d)
+	}
+	return deps
+}
+
+// FillInapplicable returns a copy of the matrix
+// in which the unknown observations
+// of the dependent characters
+// are set as inapplicable
+// in the specimens in which the controlling character
+// makes them inapplicable.
+func fillInapplicable(m *matrix.Matrix, cat *characters.Catalog) *matrix.Matrix {
+	m = m.Clone()
+	for {
+		var n int
+		for _, c := range cat.Chars() {
+			parent, states := cat.Dependency(c)
+			if parent == "" {
+				continue
+			}
+			n += m.SetInapplicable(c, parent, states)
+		}
+		if n == 0 {
+			break
+		}
+	}
+	return m
+}
+
+// DependencyText returns a description
+// of the dependencies,
+// with the characters numbered from the first value,
+// and the states coded with the code function.
+func dependencyText(deps []dependency, first int, code func(int) string) []string {
+	var ls []string
+	for _, d := range deps {
+		st := make([]string, 0, len(d.states))
+		for _, s := range d.states {
+			st = append(st, code(s))
+		}
+		ls = append(ls, fmt.Sprintf("character %d is applicable if character %d is %s", d.char+first, d.parent+first, strings.Join(st, " or ")))
+	}
+	return ls
+}
+
+// PrintNexusDependencies writes a NEXUS sets block
+// with the controlling and the dependent characters,
+// and the description of each dependency
+// as comments.
+func printNexusDependencies(w io.Writer, deps []dependency, sym []rune) {
+	if len(deps) == 0 {
+		return
+	}
+
+	var controlling, dependent []int
+	for _, d := range deps {
+		if !slices.Contains(controlling, d.parent+1) {
+			controlling = append(controlling, d.parent+1)
+		}
+		dependent = append(dependent, d.char+1)
+	}
+	slices.Sort(controlling)
+	slices.Sort(dependent)
+
+	fmt.Fprintf(w, "Begin sets;\n")
+	for _, t := range dependencyText(deps, 1, func(s int) string { return string(sym[s]) }) {
+		fmt.Fprintf(w, "\t[%s]\n", t)
+	}
+	fmt.Fprintf(w, "\tcharset controlling = %s;\n", charRanges(controlling))
+	fmt.Fprintf(w, "\tcharset dependent = %s;\n", charRanges(dependent))
+	fmt.Fprintf(w, "End;\n\n")
+}
@@ -0,0 +1,169 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+// A manifest describes the content
+// of an exported matrix.
+type manifest struct {
+	Created    string            `json:"created"`
+	Project    string            `json:"project"`
+	Arguments  []string          `json:"arguments"`
+	Flags      map[string]string `json:"flags"`
+	Datasets   []datasetSum      `json:"datasets"`
+	Taxa       []manifestTaxon   `json:"taxa"`
+	Characters []string          `json:"characters,omitempty"`
+	Genes      []manifestGene    `json:"genes,omitempty"`
+	Accessions []manifestAcc     `json:"accessions,omitempty"`
+	NumTaxa    int               `json:"num_taxa"`
+	NumChars   int               `json:"num_chars"`
+}
+
+// A datasetSum is the SHA-256 checksum
+// of a dataset file.
+type datasetSum struct {
+	Dataset string `json:"dataset"`
+	Path    string `json:"path"`
+	SHA256  string `json:"sha256"`
+}
+
+type manifestTaxon struct {
+	Taxon string `json:"taxon"`
+	Name  string `json:"name"`
+}
+
+type manifestGene struct {
+	Gene   string `json:"gene"`
+	Length int    `json:"length"`
+	Taxa   int    `json:"taxa"`
+}
+
+// A manifestAcc is the sequence
+// used for a taxon
+// in a gene.
+type manifestAcc struct {
+	Taxon     string `json:"taxon"`
+	Gene      string `json:"gene"`
+	Specimen  string `json:"specimen"`
+	Accession string `json:"accession"`
+}
+
+// WriteManifest writes a JSON file
+// with the description of an exported matrix.
+func writeManifest(name string, c *command.Command, pFile string, p *project.Project, args []string, m *matrix.Matrix, coll *dna.Collection, taxa, chLs []string, names map[string]string) (err error) {
+	mf := manifest{
+		Created:   time.Now().Format(time.RFC3339),
+		Project:   pFile,
+		Arguments: args,
+		Flags:     make(map[string]string),
+	}
+	c.Flags().Visit(func(f *flag.Flag) {
+		mf.Flags[f.Name] = f.Value.String()
+	})
+
+	for _, set := range p.Sets() {
+		path := p.Path(set)
+		sum, err := checksum(path)
+		if err != nil {
+			return fmt.Errorf("on dataset %q: %v", set, err)
+		}
+		mf.Datasets = append(mf.Datasets, datasetSum{
+			Dataset: string(set),
+			Path:    path,
+			SHA256:  sum,
+		})
+	}
+
+	for _, tx := range taxa {
+		mf.Taxa = append(mf.Taxa, manifestTaxon{
+			Taxon: tx,
+			Name:  names[tx],
+		})
+	}
+	mf.NumTaxa = len(taxa)
+
+	if m != nil {
+		mf.Characters = m.Chars()
+		if len(chLs) > 0 {
+			mf.Characters = chLs
+		}
+	}
+	mf.NumChars = getNumChars(chLs, m, coll)
+
+	if coll != nil {
+		for _, g := range coll.Genes() {
+			var n int
+			for _, tx := range taxa {
+				spec, acc := taxonAccession(coll, tx, g)
+				if acc == "" {
+					continue
+				}
+				n++
+				mf.Accessions = append(mf.Accessions, manifestAcc{
+					Taxon:     tx,
+					Gene:      g,
+					Specimen:  spec,
+					Accession: acc,
+				})
+			}
+			mf.Genes = append(mf.Genes, manifestGene{
+				Gene:   g,
+				Length: coll.MaxLen(g),
+				Taxa:   n,
+			})
+		}
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	b, err := json.MarshalIndent(mf, "", "  ")
+	if err != nil {
+		return fmt.Errorf("while writing manifest %q: %v", name, err)
+	}
+	if _, err := fmt.Fprintf(f, "%s\n", b); err != nil {
+		return fmt.Errorf("while writing manifest %q: %v", name, err)
+	}
+	return nil
+}
+
+// Checksum returns the SHA-256 checksum
+// of a file.
+func checksum(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
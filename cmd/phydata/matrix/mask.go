@@ -0,0 +1,104 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+// ApplyMask returns a new collection
+// without the excluded columns
+// of each gene.
+func applyMask(coll *dna.Collection, ex *dna.Exclusions) *dna.Collection {
+	nc := dna.New()
+	for _, gene := range coll.Genes() {
+		mask := ex.Mask(gene, coll.MaxLen(gene))
+		for _, tx := range coll.Taxa() {
+			for _, spec := range coll.TaxSpec(tx) {
+				for _, acc := range coll.GeneAccession(spec, gene) {
+					seq := mask.Apply(coll.Placed(spec, gene, acc))
+					nc.Add(tx, spec, gene, acc, seq)
+					nc.Set(spec, gene, acc, "true", dna.Aligned)
+				}
+			}
+		}
+	}
+	return nc
+}
+
+// ExcludedChars returns the positions
+// (1-based)
+// of the excluded columns of the genes
+// in the exported matrix.
+func excludedChars(ex *dna.Exclusions, m *matrix.Matrix, coll *dna.Collection, chLs []string) []int {
+	if ex == nil || coll == nil {
+		return nil
+	}
+
+	var pos []int
+	offset := getNumChars(chLs, m, nil)
+	for _, gene := range coll.Genes() {
+		ln := coll.MaxLen(gene)
+		for i, in := range ex.Mask(gene, ln) {
+			if !in {
+				pos = append(pos, offset+i+1)
+			}
+		}
+		offset += ln
+	}
+	return pos
+}
+
+// TNTRanges returns a list of sorted positions
+// as TNT ranges
+// (0-based, e.g., '0.2 4').
+func tntRanges(pos []int) string {
+	var ls []string
+	for i := 0; i < len(pos); {
+		j := i
+		for j+1 < len(pos) && pos[j+1] == pos[j]+1 {
+			j++
+		}
+		if j == i {
+			ls = append(ls, strconv.Itoa(pos[i]-1))
+		} else {
+			ls = append(ls, fmt.Sprintf("%d.%d", pos[i]-1, pos[j]-1))
+		}
+		i = j + 1
+	}
+	return strings.Join(ls, " ")
+}
+
+func readExclusions(name string, ex *dna.Exclusions) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := ex.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+// PrintNexusExSet writes a NEXUS assumptions block
+// with an exclusion set
+// that is applied by default.
+func printNexusExSet(w io.Writer, pos []int) {
+	if len(pos) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Begin assumptions;\n")
+	fmt.Fprintf(w, "\texset * masked = %s;\n", charRanges(pos))
+	fmt.Fprintf(w, "End;\n\n")
+}
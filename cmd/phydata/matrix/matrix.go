@@ -13,28 +13,51 @@ import (
 	"io"
 	"os"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
-	"unicode"
-	"unicode/utf8"
+	"time"
 
 	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/characters"
+	"github.com/js-arias/phydata/cmd/phydata/config"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/cmd/phydata/progress"
 	"github.com/js-arias/phydata/matrix"
 	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/names"
 	"github.com/js-arias/phydata/project"
+	"github.com/js-arias/phydata/specimen"
 )
 
 var Command = &command.Command{
 	Usage: `matrix
 	[-f|--format <format>]
 	[-o|--output <file>]
-	[--taxa <file>] [--chars <file>]
+	[--taxa <file>] [--chars <file>] [--group-order]
+	[--numbering <file>]
+	[--outgroup <taxon>] [--species]
+	[--gapcode] [--strict] [--no-inferred] [--apply-mask]
+	[--inapplicable <mode>]
+	[--allow-unaligned] [--variant <gene-list>]
+	[--min-occupancy <value>] [--min-taxon-occupancy <value>]
+	[--split-genes <dir>] [--split-format <format>]
+	[--tnt-header <file>] [--tnt-footer <file>] [--tnt-missing <policy>]
+	[--symbols <string>]
+	[--wrap <number>] [--interleave <number>]
+	[--name-template <template>]
+	[--manifest <file>]
+	[--jackknife-taxa <value>] [--bootstrap-chars]
+	[--replicates <number>] [--seed <number>]
 	<project> <data-type>...`,
 	Short: "build a phylogenetic data matrix",
 	Long: `
 Command matrix reads a PhyData project and builds a phylogenetic data matrix
 with the data stored in the project.
 
-The first argument is the name of the project file.
+The first argument is the name of the project file. If the first argument is
+'analyze', the command analyzes the taxon coverage of the partitions of the
+matrix instead of building the matrix (see 'phydata help matrix analyze').
 
 The second and following arguments, are the types of data that will be
 included in the data matrix. Valid values are:
@@ -50,18 +73,218 @@ to define a format. Valid formats are:
 
 	tnt   used for tnt output (default)
 	nexus used for nexus output
+	mega  used for MEGA output (only DNA sequences)
+
+A different default format can be defined with the key 'matrix-format' of
+the configuration file (see 'phydata help config').
+
+By default, the TNT output starts with the commands 'mxram 250' and
+'taxname +255', and ends with the commands 'cc - .' and 'proc /'. Use the
+flag --tnt-header to define a file with the commands that will be written
+before the matrix, and the flag --tnt-footer to define a file with the
+commands that will be written after the matrix (e.g., to run an analysis).
+The files are read as Go templates, in which {{.Taxa}} is replaced by the
+number of taxa, and {{.Chars}} by the number of characters in the matrix.
+
+In TNT output, each gene is written in its own block, and sequences shorter
+than the longest sequence of the gene are padded with '?'. Use the flag
+--tnt-missing to define how the taxa without a sequence for a gene are
+written. Valid values are:
+
+	omit  the taxon is not written in the block (default), TNT will read it
+	      as missing data
+	pad   the taxon is written with a sequence of '?', so all blocks
+	      have all the taxa
+
+In NEXUS output, the morphological states are written with the symbols
+'0123456789ABCDEFGHIJKLMNOPQRSTUV', using only as many symbols as the
+maximum number of states of the characters. Use the flag --symbols to define
+a different set of symbols (e.g., '0123456789abcdefghijklmnopqrstuvwxyz').
+If the matrix includes DNA sequences, most programs only accept digits as
+symbols of the morphological states, so the symbols are limited to the
+first ten symbols. If a character has more states than the available
+symbols, the command will fail.
+
+By default, the row of each taxon is written in a single line (in NEXUS and
+TNT, each partition of the matrix, i.e., the morphology, and each gene, are
+written in its own block). Some programs fail to read very long lines (e.g.,
+with large DNA alignments). Use the flag --interleave to define the maximum
+number of characters of each block, so each partition will be split in
+interleaved blocks of that length. Use the flag --wrap to define the maximum
+number of characters in each line of a row. In NEXUS, the flag --wrap writes
+a sequential (non-interleaved) matrix, with the full row of each taxon
+wrapped in several lines. As TNT reads each line of a block as a full row,
+in TNT the flag --wrap is equivalent to the flag --interleave. Polymorphic
+and uncertain observations are never split. The flags --wrap and
+--interleave are incompatible.
 
 By default, all taxa in the project will be used to build the matrix. If the
 flag --taxa is defined with a file, the taxa in that file will be used as the
 terminals of the matrix, using the order given in the file. In the file each
 line will be read as a taxon name. Blank lines and lines starting with '#'
-will be ignored.
+will be ignored. If no file is given, the taxa will be sorted alphabetically.
+A line of the file can have two columns separated by a tab: the first column
+is the taxon name in the project, and the second column is the name that will
+be used in the output matrix (e.g., to export the matrix with the current
+taxonomy, when the project uses outdated names).
+
+Use the flag --outgroup to define a taxon that will be used as the first
+taxon of the matrix (the default outgroup in most phylogenetic programs).
+
+If the flag --species is defined, infraspecific taxa (e.g., 'Panthera tigris
+altaica', or 'Poa annua var. reptans') will be merged into its species, and
+the species will be used as the terminal. The names stored in the project
+are not modified. Names with an open nomenclature qualifier (e.g., 'Bufo cf.
+marinus') are kept as different terminals.
+
+By default, the terminals of the matrix are named with the taxon name. Use the
+flag --name-template to define how the terminal names are composed. In the
+template, the following fields will be replaced:
+
+	{taxon}     the taxon name
+	{specimen}  the specimen IDs of the taxon
+	{voucher}   the vouchers of the specimens of the taxon
+
+For example, '{taxon}_{voucher}' will produce names such as
+'Panthera_tigris_FMNH_2485'. The vouchers are taken from the specimen records
+of the project. If a taxon has multiple specimens, the specimen IDs (or the
+vouchers) will be joined with a '-'. Spaces in the resulting name will be
+replaced by underscores.
 
 By default, when making a matrix with observations, all characters will be
 used to build the matrix. If the flag --chars is defined with a file, the
 characters in the file will be used in the given order. In the file each line
 will be interpreted as a character. Blank lines and lines starting with '#'
 will be ignored.
+
+If the project has a character metadata file (see 'phydata obs meta'), and
+the flag --group-order is defined, the characters will be grouped by its
+anatomical region (regions sorted alphabetically, and characters without a
+region at the end), keeping the relative order of the characters inside each
+region. In NEXUS output, if the project has a character metadata file, a
+block of sets will be added with a charset for each anatomical region.
+
+In NEXUS output, if a gene has a secondary structure (defined with the
+'structure' field of its aligned sequences, in dot-bracket notation, and in
+the coordinates of the alignment), a block of sets will be added with a
+charset for the stems (paired positions) and a charset for the loops
+(unpaired positions) of the gene, named with the gene and the suffix '_stems'
+or '_loops' (e.g., '16s_stems'), so the gene can be partitioned for
+doublet-model analyses. All the aligned sequences of a gene with a structure
+must have the same structure.
+
+If the flag --numbering is defined with a character numbering file (see
+'phydata obs numbering'), the characters will be exported using the order of
+the numbering file, and characters not in the file will be added at the end,
+sorted alphabetically. This flag is incompatible with the flags --chars and
+--group-order.
+
+Observations can be flagged as uncertain (with the 'uncertain' field of the
+observations file). If all the observations of a taxon for a character are
+uncertain, the character is coded as an uncertainty among all the states of
+the character (e.g., '[01]' in TNT, or '{01}' in NEXUS, instead of a certain
+'1'). If the taxon has other observations for the character, the uncertain
+observations are ignored. If the flag --strict is defined, uncertain
+observations are always ignored, and if a taxon only has uncertain
+observations for a character, the character will be coded as missing data.
+
+The source of the coding of an observation can be defined with the 'source'
+field of the observations file, as 'observed' (first-hand observations),
+'literature' (codings taken from the literature), or 'inferred' (codings
+inferred from other specimens, or from the ontogeny). If the flag
+--no-inferred is defined, inferred codings will be ignored.
+
+By default, inapplicable observations are written as '-' tokens. Use the flag
+--inapplicable with the value 'aware' to export the matrix for methods that
+take into account the dependencies between characters (e.g., the algorithm
+of Brazeau et al. (2019), as implemented in MorphyLib and TreeSearch). This
+mode uses the character dependencies of the characters file of the project
+(the fields 'depends-on' and 'applicable-if', see 'phydata obs meta'). Unknown
+observations of a dependent character will be written as inapplicable if the
+state of the controlling character makes the character inapplicable. In
+NEXUS output, '-' is declared as the gap (inapplicable) token, and a block of
+sets will be added with a charset of the controlling characters ('controlling')
+and of the dependent characters ('dependent'), and a comment with each
+dependency. In TNT output, the dependencies will be written in the title of
+the matrix (using TNT numbering, starting at 0). Valid values for the flag
+are 'dash' (the default) and 'aware'.
+
+By default, gaps in DNA sequences are left to the analysis program (in TNT
+they are treated as missing data). If the flag --gapcode is defined, the gaps
+of the aligned sequences will be coded as presence/absence characters using
+the simple indel coding of Simmons & Ochoterena (2000). The indel characters
+are appended at the end of the matrix.
+
+The DNA sequences used in the matrix must be aligned (the 'aligned' field of
+the DNA file), and the aligned sequences of each gene must have the same
+length (sequences with a start position are placed in the coordinates of the
+reference gene, so its length is not checked). Otherwise the command will
+fail. If the flag --allow-unaligned is defined, the problems will be printed
+as warnings in the standard error, and the matrix will be written. This check
+is not done when the genes are split in different files (flag
+--split-genes).
+
+A gene can be stored with different alignment versions, using the gene name,
+a colon, and the name of the alignment variant (e.g., 'cytb:mafft-linsi', or
+'cytb:manual'), so the raw sequences (stored with the gene name, e.g.,
+'cytb') and its alignments are kept side by side. If a gene has more than one
+version, use the flag --variant with a comma-separated list of the versions
+that will be used in the matrix (e.g., '--variant cytb:manual,coi'). If a
+gene has more than one version, and no version is selected, the command will
+fail.
+
+If the project has a file with exclusion masks of the aligned genes (see
+'phydata dna mask'), the excluded columns will be deactivated in the output
+matrix: in TNT with the 'cc ]' command, and in NEXUS with an exclusion set
+(exset) applied by default. If the flag --apply-mask is defined, the excluded
+columns will be removed from the matrix. Masks are always applied when
+writing resampled pseudoreplicates, when the genes are split in different
+files, or in MEGA output.
+
+By default, all genes and taxa are included in the matrix. Use the flag
+--min-occupancy with a value between 0 and 1 to exclude the genes in which
+the fraction of taxa with sequences is lesser than the given value. Use the
+flag --min-taxon-occupancy to exclude the taxa in which the fraction of
+partitions (morphology and each gene) with data is lesser than the given
+value. Genes are excluded before taxa. If any of these flags is defined, a
+summary of the excluded genes and taxa, and the statistics of the resulting
+matrix (occupancy and quartet decisiveness) will be printed in the standard
+error.
+
+If the flag --split-genes is defined with a directory, instead of a single
+concatenated matrix, each gene will be written in a different file in the
+indicated directory, as required by gene-tree workflows. Only the taxa with
+sequences for a gene are included in the gene file. The directory will also
+contain the file 'genes.tab' with the gene, file name, number of taxa, and
+length of each gene. By default, the gene files are written in FASTA format.
+Use the flag --split-format to define a different format. Valid formats are:
+
+	fasta   FASTA format (default)
+	phylip  relaxed PHYLIP format
+	nexus   NEXUS format
+	mega    MEGA format
+
+If the flag --manifest is defined with a file name, a JSON file will be
+written with a description of the exported matrix: the date of the export,
+the arguments and flags of the command, the SHA-256 checksum of each dataset
+file of the project, the taxa (and its terminal names), the characters, the
+genes (with its length and number of taxa), the accession used for each taxon
+in each gene, and the number of taxa and characters of the matrix. This file
+can be used to document the matrix in the methods section of a paper.
+
+For sensitivity analyses, the command can write resampled pseudoreplicates of
+the matrix. Use the flag --jackknife-taxa with a probability (between 0 and
+1) to delete each taxon (except the outgroup) with that probability in each
+pseudoreplicate. Use the flag --bootstrap-chars to sample the characters with
+replacement. Characters are sampled within each partition (i.e., morphology,
+and each gene). By default 100 pseudoreplicates are written, use the flag
+--replicates to define a different number. Resampling requires an output file
+(flag --output, or -o), and each pseudoreplicate will be written in a file
+with the output name and the number of the pseudoreplicate (e.g., with
+'-o data.tnt', the files will be 'data-001.tnt', 'data-002.tnt', etc.). Use
+the flag --seed to define the seed of the random number generator, so the
+pseudoreplicates can be reproduced. By default, the seed is taken from the
+current time. The seed will be printed in the standard error.
 	`,
 	SetFlags: setFlags,
 	Run:      run,
@@ -71,23 +294,95 @@ var output string
 var format string
 var txLsFile string
 var charFile string
+var gapCode bool
+var minOccupancy float64
+var minTaxOccupancy float64
+var splitDir string
+var splitFormat string
+var tntHeader string
+var tntFooter string
+var tntMissing string
+var symbols string
+var wrap int
+var interleave int
+var nameTemplate string
+var outgroup string
+var speciesFlag bool
+var strict bool
+var noInferred bool
+var groupOrder bool
+var numberFile string
+var manifestFile string
+var jackTaxa float64
+var bootChars bool
+var replicates int
+var seed int64
+var applyMaskFlag bool
+var allowUnaligned bool
+var variants string
+var inapplicable string
 
 func setFlags(c *command.Command) {
 	c.Flags().StringVar(&output, "output", "", "")
 	c.Flags().StringVar(&output, "o", "", "")
 	c.Flags().StringVar(&txLsFile, "taxa", "", "")
 	c.Flags().StringVar(&charFile, "chars", "", "")
-	c.Flags().StringVar(&format, "format", "tnt", "")
-	c.Flags().StringVar(&format, "f", "tnt", "")
+	c.Flags().StringVar(&outgroup, "outgroup", "", "")
+	c.Flags().BoolVar(&speciesFlag, "species", false, "")
+	c.Flags().BoolVar(&gapCode, "gapcode", false, "")
+	c.Flags().BoolVar(&strict, "strict", false, "")
+	c.Flags().BoolVar(&noInferred, "no-inferred", false, "")
+	c.Flags().BoolVar(&applyMaskFlag, "apply-mask", false, "")
+	c.Flags().BoolVar(&allowUnaligned, "allow-unaligned", false, "")
+	c.Flags().StringVar(&variants, "variant", "", "")
+	c.Flags().BoolVar(&groupOrder, "group-order", false, "")
+	c.Flags().StringVar(&numberFile, "numbering", "", "")
+	c.Flags().StringVar(&manifestFile, "manifest", "", "")
+	c.Flags().Float64Var(&jackTaxa, "jackknife-taxa", 0, "")
+	c.Flags().BoolVar(&bootChars, "bootstrap-chars", false, "")
+	c.Flags().IntVar(&replicates, "replicates", 100, "")
+	c.Flags().Int64Var(&seed, "seed", time.Now().UnixNano(), "")
+	c.Flags().Float64Var(&minOccupancy, "min-occupancy", 0, "")
+	c.Flags().Float64Var(&minTaxOccupancy, "min-taxon-occupancy", 0, "")
+	c.Flags().StringVar(&splitDir, "split-genes", "", "")
+	c.Flags().StringVar(&splitFormat, "split-format", "fasta", "")
+	c.Flags().StringVar(&tntHeader, "tnt-header", "", "")
+	c.Flags().StringVar(&tntFooter, "tnt-footer", "", "")
+	c.Flags().StringVar(&tntMissing, "tnt-missing", "omit", "")
+	c.Flags().StringVar(&inapplicable, "inapplicable", "dash", "")
+	c.Flags().StringVar(&symbols, "symbols", matrix.DefaultSymbols, "")
+	c.Flags().IntVar(&wrap, "wrap", 0, "")
+	c.Flags().IntVar(&interleave, "interleave", 0, "")
+	c.Flags().StringVar(&nameTemplate, "name-template", "{taxon}", "")
+	defFormat := config.Get(config.MatrixFormat, "tnt")
+	c.Flags().StringVar(&format, "format", defFormat, "")
+	c.Flags().StringVar(&format, "f", defFormat, "")
 }
 
 func run(c *command.Command, args []string) (err error) {
+	if len(args) > 0 && strings.ToLower(args[0]) == "analyze" {
+		if err := analyzeCommand.Execute(args[1:]); err != nil {
+			return err
+		}
+		return analyzeErr
+	}
 	if len(args) < 1 {
 		return c.UsageError("expecting project file")
 	}
 	if len(args) < 2 {
 		return c.UsageError("expecting data type definitions")
 	}
+	if wrap > 0 && interleave > 0 {
+		return c.UsageError("flag --wrap is incompatible with flag --interleave")
+	}
+	tntMissing = strings.ToLower(tntMissing)
+	if tntMissing != "omit" && tntMissing != "pad" {
+		return c.UsageError(fmt.Sprintf("flag --tnt-missing: unknown policy %q", tntMissing))
+	}
+	inapplicable = strings.ToLower(inapplicable)
+	if inapplicable != "dash" && inapplicable != "aware" {
+		return c.UsageError(fmt.Sprintf("flag --inapplicable: unknown mode %q", inapplicable))
+	}
 
 	p, err := project.Read(args[0])
 	if err != nil {
@@ -108,14 +403,21 @@ func run(c *command.Command, args []string) (err error) {
 			if err := readObsFile(mf, m); err != nil {
 				return fmt.Errorf("on project %q: %v", args[0], err)
 			}
+			m.SetTaxPolicy(taxPolicy())
+			if err := m.SetSymbols(symbols); err != nil {
+				return c.UsageError(fmt.Sprintf("flag --symbols: %v", err))
+			}
 			withData = true
 		case "dna":
-			df := p.Path(project.DNA)
-			if df == "" {
+			if p.Path(project.DNA) == "" {
 				return fmt.Errorf("undefined DNA file")
 			}
 			coll = dna.New()
-			if err := readDNAFile(df, coll); err != nil {
+			if _, err := dnafiles.Read(p, coll); err != nil {
+				return fmt.Errorf("on project %q: %v", args[0], err)
+			}
+			coll, err = coll.SelectVariants(variantSelection(variants))
+			if err != nil {
 				return fmt.Errorf("on project %q: %v", args[0], err)
 			}
 			withData = true
@@ -125,6 +427,130 @@ func run(c *command.Command, args []string) (err error) {
 		return fmt.Errorf("data types %v not defined in the project", args[1:])
 	}
 
+	var ex *dna.Exclusions
+	if ef := p.Path(project.Exclusions); ef != "" && coll != nil {
+		ex = dna.NewExclusions()
+		if err := readExclusions(ef, ex); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+		if applyMaskFlag || splitDir != "" || jackTaxa != 0 || bootChars || strings.ToLower(format) == "mega" {
+			coll = applyMask(coll, ex)
+			ex = nil
+		}
+	}
+
+	if err := checkNameTemplate(nameTemplate); err != nil {
+		return c.UsageError(err.Error())
+	}
+	var reg *specimen.Registry
+	if sf := p.Path(project.Specimens); sf != "" {
+		reg = specimen.New()
+		if err := readSpecFile(sf, reg); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+	}
+	if speciesFlag {
+		collapseSpecies(m, coll, reg)
+	}
+
+	var txLs []string
+	var rename map[string]string
+	if txLsFile != "" {
+		txLs, rename, err = readTaxa(txLsFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	var chLs []string
+	if charFile != "" {
+		chLs, err = readFileList(charFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	if numberFile != "" && m != nil {
+		if charFile != "" || groupOrder {
+			return c.UsageError("flag --numbering is incompatible with flags --chars and --group-order")
+		}
+		prev, err := readNumbering(numberFile)
+		if err != nil {
+			return err
+		}
+		chLs = matrix.Renumber(prev, m.Chars())
+	}
+
+	var cat *characters.Catalog
+	if cf := p.Path(project.Characters); cf != "" && m != nil {
+		cat = characters.New()
+		if err := readCharFile(cf, cat); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+	}
+	if inapplicable == "aware" && m != nil {
+		if cat == nil {
+			return fmt.Errorf("undefined characters file")
+		}
+		m = fillInapplicable(m, cat)
+	}
+	if groupOrder && m != nil {
+		if cat == nil {
+			return fmt.Errorf("undefined characters file")
+		}
+		if len(chLs) == 0 {
+			chLs = m.Chars()
+		}
+		chLs = cat.Order(chLs)
+	}
+
+	if len(txLs) > 0 || len(chLs) > 0 {
+		if len(txLs) == 0 {
+			txLs = getTaxaList(m, coll)
+		}
+		if len(chLs) == 0 && m != nil {
+			chLs = m.Chars()
+		}
+		m, coll = filterData(m, coll, txLs, chLs)
+	}
+
+	if minOccupancy > 0 || minTaxOccupancy > 0 {
+		txLs = filterOccupancy(c.Stderr(), m, coll, txLs)
+	}
+
+	ls := txLs
+	if len(ls) == 0 {
+		ls = getTaxaList(m, coll)
+	}
+	if outgroup != "" {
+		outgroup = canon(outgroup)
+		if !slices.Contains(ls, outgroup) {
+			return fmt.Errorf("outgroup %q not in the matrix", outgroup)
+		}
+		txLs = outgroupFirst(txLs)
+	}
+	names := terminalNames(ls, rename, m, coll, reg)
+
+	if coll != nil && splitDir == "" {
+		if err := checkAligned(c.Stderr(), coll, ls); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+	}
+
+	if manifestFile != "" {
+		if err := writeManifest(manifestFile, c, args[0], p, args, m, coll, outgroupFirst(ls), chLs, names); err != nil {
+			return err
+		}
+	}
+
+	if splitDir != "" {
+		return splitGenes(splitDir, coll, txLs, names)
+	}
+
+	if jackTaxa != 0 || bootChars {
+		return writeReplicates(c.Stderr(), m, coll, outgroupFirst(ls), chLs, names)
+	}
+
 	out := c.Stdout()
 	if output != "" {
 		var f *os.File
@@ -141,20 +567,63 @@ func run(c *command.Command, args []string) (err error) {
 		out = f
 	}
 
+	exPos := excludedChars(ex, m, coll, chLs)
+	progress.Printf("writing matrix: %d taxa, %d characters", len(ls), getNumChars(chLs, m, coll))
+	if err := writeMatrix(out, m, coll, txLs, chLs, names, cat, exPos); err != nil {
+		return err
+	}
+	if output != "" {
+		progress.Printf("wrote %q", output)
+	}
+	return nil
+}
+
+// FilterData restricts the observations
+// and the DNA sequences
+// to the taxa and characters in the lists.
+// An empty list keeps all the taxa
+// (or characters).
+func filterData(m *matrix.Matrix, coll *dna.Collection, txLs, chLs []string) (*matrix.Matrix, *dna.Collection) {
+	taxa := make(map[string]bool, len(txLs))
+	for _, tx := range txLs {
+		taxa[tx] = true
+	}
+	chars := make(map[string]bool, len(chLs))
+	for _, c := range chLs {
+		chars[c] = true
+	}
+
+	if m != nil {
+		m = m.Filter(func(o matrix.Observation) bool {
+			if len(taxa) > 0 && !taxa[o.Taxon] {
+				return false
+			}
+			if len(chars) > 0 && !chars[o.Character] {
+				return false
+			}
+			return true
+		})
+	}
+	if coll != nil && len(taxa) > 0 {
+		coll = coll.Filter(func(e dna.Entry) bool {
+			return taxa[e.Taxon]
+		})
+	}
+	return m, coll
+}
+
+// WriteMatrix writes a matrix
+// in the output format.
+func writeMatrix(w io.Writer, m *matrix.Matrix, coll *dna.Collection, txLs, chLs []string, names map[string]string, cat *characters.Catalog, exPos []int) error {
 	switch strings.ToLower(format) {
 	case "tnt":
-		if err := printTNTMatrix(out, m, coll); err != nil {
-			return err
-		}
+		return printTNTMatrix(w, m, coll, txLs, chLs, names, cat, exPos)
 	case "nexus":
-		if err := printNexusMatrix(out, m, coll); err != nil {
-			return err
-		}
-	default:
-		return fmt.Errorf("unknown format %q", format)
+		return printNexusMatrix(w, m, coll, txLs, chLs, names, cat, exPos)
+	case "mega":
+		return printMegaMatrix(w, m, coll, txLs, names)
 	}
-
-	return nil
+	return fmt.Errorf("unknown format %q", format)
 }
 
 func readObsFile(name string, m *matrix.Matrix) error {
@@ -164,13 +633,27 @@ func readObsFile(name string, m *matrix.Matrix) error {
 	}
 	defer f.Close()
 
-	if err := m.ReadTSV(f); err != nil {
+	if err := m.ReadTSV(progress.Reader(f, name)); err != nil {
 		return fmt.Errorf("while reading file %q: %v", name, err)
 	}
 	return nil
 }
 
-func readDNAFile(name string, c *dna.Collection) error {
+func readNumbering(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	chars, err := matrix.ReadNumbering(f)
+	if err != nil {
+		return nil, fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return chars, nil
+}
+
+func readCharFile(name string, c *characters.Catalog) error {
 	f, err := os.Open(name)
 	if err != nil {
 		return err
@@ -183,6 +666,19 @@ func readDNAFile(name string, c *dna.Collection) error {
 	return nil
 }
 
+func readSpecFile(name string, r *specimen.Registry) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := r.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
 type taxaer interface {
 	Taxa() []string
 }
@@ -216,10 +712,39 @@ func getTaxaList(d ...taxaer) []string {
 	for n := range tn {
 		ls = append(ls, n)
 	}
+	slices.Sort(ls)
 
 	return ls
 }
 
+// TaxaOrder returns the taxa of a matrix block
+// in the order in which they will be written.
+// If a list of taxa is given,
+// that list will be used,
+// otherwise the taxa of the block will be used,
+// with the outgroup as the first taxon.
+func taxaOrder(taxa, txLs []string) []string {
+	if len(txLs) > 0 {
+		return txLs
+	}
+	return outgroupFirst(taxa)
+}
+
+// OutgroupFirst returns a list of taxa
+// with the outgroup as the first taxon.
+func outgroupFirst(ls []string) []string {
+	i := slices.Index(ls, outgroup)
+	if i <= 0 {
+		return ls
+	}
+
+	o := make([]string, 0, len(ls))
+	o = append(o, outgroup)
+	o = append(o, ls[:i]...)
+	o = append(o, ls[i+1:]...)
+	return o
+}
+
 func validTaxNames(ls []string) map[string]string {
 	m := make(map[string]string, len(ls))
 	for _, n := range ls {
@@ -255,25 +780,7 @@ func getNumChars(chLs []string, m *matrix.Matrix, coll *dna.Collection) int {
 	return nc
 }
 
-func printTNTMatrix(w io.Writer, m *matrix.Matrix, coll *dna.Collection) error {
-	var txLs []string
-	if txLsFile != "" {
-		var err error
-		txLs, err = readTaxa(txLsFile)
-		if err != nil {
-			return err
-		}
-	}
-
-	var chLs []string
-	if charFile != "" {
-		var err error
-		chLs, err = readFileList(charFile)
-		if err != nil {
-			return err
-		}
-	}
-
+func printTNTMatrix(w io.Writer, m *matrix.Matrix, coll *dna.Collection, txLs, chLs []string, names map[string]string, cat *characters.Catalog, exPos []int) error {
 	bw := bufio.NewWriter(w)
 
 	nt := getNumTaxa(m, coll)
@@ -282,10 +789,38 @@ func printTNTMatrix(w io.Writer, m *matrix.Matrix, coll *dna.Collection) error {
 	}
 	nc := getNumChars(chLs, m, coll)
 
-	fmt.Fprintf(bw, "mxram 250 ;\ntaxname +255 ;\nxread %d %d\n\n", nc, nt)
-	if m != nil {
-		fmt.Fprintf(bw, "&[num]\n")
+	var gaps map[string]string
+	if gapCode && coll != nil {
+		ls := taxaOrder(coll.Taxa(), txLs)
+		var ng int
+		gaps, ng = indelCoding(coll, ls)
+		nc += ng
+	}
+
+	tmpl := tntTemplate{
+		Taxa:  nt,
+		Chars: nc,
+	}
+	if err := tmpl.write(bw, tntHeader, "mxram 250 ;\ntaxname +255 ;\n"); err != nil {
+		return err
+	}
+	var deps []dependency
+	if inapplicable == "aware" && m != nil {
+		chars := m.Chars()
+		if len(chLs) > 0 {
+			chars = chLs
+		}
+		deps = charDependencies(m, cat, chars)
+	}
+	if len(deps) > 0 {
+		txt := dependencyText(deps, 0, strconv.Itoa)
+		fmt.Fprintf(bw, "xread\n'inapplicable-aware: %s'\n%d %d\n\n", strings.Join(txt, "; "), nc, nt)
+	} else {
+		fmt.Fprintf(bw, "xread %d %d\n\n", nc, nt)
+	}
 
+	var blocks []block
+	if m != nil {
 		states := make(map[string]map[int]string)
 		chars := m.Chars()
 		if len(chLs) > 0 {
@@ -303,97 +838,99 @@ func printTNTMatrix(w io.Writer, m *matrix.Matrix, coll *dna.Collection) error {
 			states[c] = stID
 		}
 
-		ls := m.Taxa()
-		if len(txLs) > 0 {
-			ls = txLs
+		ls := taxaOrder(m.Taxa(), txLs)
+		row := &strings.Builder{}
+		b := block{
+			head: "&[num]",
+			taxa: ls,
 		}
-
-		for _, tx := range ls {
-			ntx := strings.Join(strings.Fields(tx), "_")
-			fmt.Fprintf(bw, "%s\t", ntx)
-			txSp := m.TaxSpec(tx)
+		b.row = func(tx string) string {
+			row.Reset()
 			for _, c := range chars {
-				na := false
-				st := make(map[string]bool, len(states[c]))
-				for _, sp := range txSp {
-					obs := m.Obs(sp, c)
-					if len(obs) == 0 {
-						continue
-					}
-					if obs[0] == matrix.NotApplicable {
-						na = true
-						continue
-					}
-					if obs[0] == matrix.Unknown {
-						continue
-					}
-					for _, o := range obs {
-						st[o] = true
-					}
+				st, unc := m.TaxObs(tx, c)
+				if st[0] == matrix.NotApplicable {
+					fmt.Fprintf(row, "-")
+					continue
+				}
+				if st[0] == matrix.Unknown {
+					fmt.Fprintf(row, "?")
+					continue
 				}
-				if len(st) == 0 {
-					if na {
-						fmt.Fprintf(bw, "-")
+				obSt := states[c]
+				if unc {
+					if len(obSt) == 1 {
+						fmt.Fprintf(row, "0")
 						continue
 					}
-					fmt.Fprintf(bw, "?")
+					fmt.Fprintf(row, "[")
+					for i := 0; i < len(obSt); i++ {
+						fmt.Fprintf(row, "%d", i)
+					}
+					fmt.Fprintf(row, "]")
 					continue
 				}
-				obSt := states[c]
 				if len(st) > 1 {
-					fmt.Fprintf(bw, "[")
+					fmt.Fprintf(row, "[")
 					for i := 0; i < len(obSt); i++ {
 						v := obSt[i]
-						if !st[v] {
+						if !slices.Contains(st, v) {
 							continue
 						}
-						fmt.Fprintf(bw, "%d", i)
+						fmt.Fprintf(row, "%d", i)
 					}
-					fmt.Fprintf(bw, "]")
+					fmt.Fprintf(row, "]")
 					continue
 				}
 				for i := 0; i < len(obSt); i++ {
 					v := obSt[i]
-					if st[v] {
-						fmt.Fprintf(bw, "%d", i)
+					if v == st[0] {
+						fmt.Fprintf(row, "%d", i)
 						break
 					}
 				}
 			}
-			fmt.Fprintf(bw, "\n")
+			return row.String()
 		}
-		fmt.Fprintf(bw, "\n")
+		blocks = append(blocks, b)
 	}
 
 	if coll != nil {
 		for _, gene := range coll.Genes() {
-			fmt.Fprintf(bw, "&[dna nogaps]\n")
-
-			ls := coll.Taxa()
-			if len(txLs) > 0 {
-				ls = txLs
-			}
-			for _, tx := range ls {
-				var seq string
-				for _, spec := range coll.TaxSpec(tx) {
-					for _, acc := range coll.GeneAccession(spec, gene) {
-						s := coll.Sequence(spec, gene, acc)
-						if countNucleotides(s) > countNucleotides(seq) {
-							seq = s
-						}
-					}
-				}
-				if len(seq) == 0 {
-					continue
-				}
-				ntx := strings.Join(strings.Fields(tx), "_")
-				fmt.Fprintf(bw, "%s\t%s\n", ntx, seq)
+			ls := taxaOrder(coll.Taxa(), txLs)
+			if tntMissing == "pad" {
+				ls = taxaOrder(getTaxaList(m, coll), txLs)
 			}
-			fmt.Fprintf(bw, "\n")
+			blocks = append(blocks, block{
+				head: "&[dna nogaps]",
+				taxa: ls,
+				row:  geneRow(coll, gene, tntMissing == "pad"),
+			})
 		}
 	}
 
-	fmt.Fprintf(bw, ";\n\ncc - . ;\n\nproc /; \n")
+	if len(gaps) > 0 {
+		blocks = append(blocks, block{
+			head: "&[num]",
+			taxa: taxaOrder(coll.Taxa(), txLs),
+			row:  mapRow(gaps),
+		})
+	}
+
+	// TNT reads each line of a block as a full row,
+	// so wrapped rows are written as interleaved blocks.
+	width := interleave
+	if wrap > 0 {
+		width = wrap
+	}
+	writeBlocks(bw, blocks, names, width)
+
+	fmt.Fprintf(bw, ";\n\n")
+	if len(exPos) > 0 {
+		fmt.Fprintf(bw, "cc ] %s ;\n\n", tntRanges(exPos))
+	}
+	if err := tmpl.write(bw, tntFooter, "cc - . ;\n\nproc /; \n"); err != nil {
+		return err
+	}
 	if err := bw.Flush(); err != nil {
 		return err
 	}
@@ -401,25 +938,7 @@ func printTNTMatrix(w io.Writer, m *matrix.Matrix, coll *dna.Collection) error {
 	return nil
 }
 
-func printNexusMatrix(w io.Writer, m *matrix.Matrix, coll *dna.Collection) error {
-	var txLs []string
-	if txLsFile != "" {
-		var err error
-		txLs, err = readTaxa(txLsFile)
-		if err != nil {
-			return err
-		}
-	}
-
-	var chLs []string
-	if charFile != "" {
-		var err error
-		chLs, err = readFileList(charFile)
-		if err != nil {
-			return err
-		}
-	}
-
+func printNexusMatrix(w io.Writer, m *matrix.Matrix, coll *dna.Collection, txLs, chLs []string, names map[string]string, cat *characters.Catalog, exPos []int) error {
 	bw := bufio.NewWriter(w)
 
 	fmt.Fprintf(bw, "#NEXUS\n\n")
@@ -433,26 +952,70 @@ func printNexusMatrix(w io.Writer, m *matrix.Matrix, coll *dna.Collection) error
 	nMorf := getNumChars(chLs, m, nil)
 	nDNA := getNumChars(nil, nil, coll)
 
+	txLs = taxaOrder(getTaxaList(m, coll), txLs)
+
+	var gaps map[string]string
+	var nGaps int
+	if gapCode && coll != nil {
+		gaps, nGaps = indelCoding(coll, txLs)
+		nc += nGaps
+	}
+
+	var sym []rune
+	if m != nil {
+		chars := m.Chars()
+		if len(chLs) > 0 {
+			chars = chLs
+		}
+		if nDNA > 0 {
+			s := []rune(strings.Join(strings.Fields(symbols), ""))
+			if len(s) > 10 {
+				m.SetSymbols(string(s[:10]))
+			}
+		}
+		var err error
+		sym, err = m.NexusSymbols(chars)
+		if err != nil {
+			return err
+		}
+	}
+
+	stSets, err := structureSets(m, coll, chLs)
+	if err != nil {
+		return err
+	}
+
+	il := " interleave=yes"
+	if wrap > 0 {
+		il = ""
+	}
 	fmt.Fprintf(bw, "Begin data;\n")
 	fmt.Fprintf(bw, "\tDimensions ntax=%d nchar=%d;\n", nt, nc)
-	if nMorf > 0 && nDNA > 0 {
-		fmt.Fprintf(bw, "\tFormat datatype=mixed(standard:1-%d,DNA:%d-%d) interleave=yes gap=- missing=?;\n\n", nMorf, nMorf+1, nc)
+	if nGaps > 0 {
+		if nMorf > 0 {
+			fmt.Fprintf(bw, "\tFormat datatype=mixed(standard:1-%d,DNA:%d-%d,standard:%d-%d)%s gap=- missing=?;\n\n", nMorf, nMorf+1, nMorf+nDNA, nMorf+nDNA+1, nc, il)
+		} else {
+			fmt.Fprintf(bw, "\tFormat datatype=mixed(DNA:1-%d,standard:%d-%d)%s gap=- missing=?;\n\n", nDNA, nDNA+1, nc, il)
+		}
+	} else if nMorf > 0 && nDNA > 0 {
+		fmt.Fprintf(bw, "\tFormat datatype=mixed(standard:1-%d,DNA:%d-%d)%s gap=- missing=?;\n\n", nMorf, nMorf+1, nc, il)
 	} else if nMorf > 0 {
-		fmt.Fprintf(bw, "\tFormat datatype=standard missing=?;\n\n")
+		if interleave <= 0 {
+			il = ""
+		}
+		gap := ""
+		if inapplicable == "aware" {
+			gap = " gap=-"
+		}
+		fmt.Fprintf(bw, "\tFormat datatype=standard%s%s missing=? symbols=\"%s\";\n\n", il, gap, string(sym))
 	} else {
-		fmt.Fprintf(bw, "\tFormat datatype=DNA interleave=yes gap=- missing=?;\n\n")
+		fmt.Fprintf(bw, "\tFormat datatype=DNA%s gap=- missing=?;\n\n", il)
 	}
 
-	if len(txLs) == 0 {
-		txLs = getTaxaList(m, coll)
-	}
-	names := validTaxNames(txLs)
-
 	fmt.Fprintf(bw, "\tMatrix\n\n")
 
+	var blocks []block
 	if m != nil {
-		fmt.Fprintf(bw, "[Morphology]\n")
-
 		states := make(map[string]map[int]string)
 		chars := m.Chars()
 		if len(chLs) > 0 {
@@ -462,101 +1025,103 @@ func printNexusMatrix(w io.Writer, m *matrix.Matrix, coll *dna.Collection) error
 			st := m.States(c)
 			stID := make(map[int]string, len(st))
 			for i, s := range st {
-				if i > 9 {
-					break
-				}
 				stID[i] = s
 			}
 			states[c] = stID
 		}
 
-		for _, tx := range txLs {
-			ntx := names[tx]
-			fmt.Fprintf(bw, "%s\t", ntx)
-			txSp := m.TaxSpec(tx)
+		row := &strings.Builder{}
+		b := block{
+			head: "[Morphology]",
+			taxa: txLs,
+		}
+		b.row = func(tx string) string {
+			row.Reset()
 			for _, c := range chars {
-				na := false
-				st := make(map[string]bool, len(states[c]))
-				for _, sp := range txSp {
-					obs := m.Obs(sp, c)
-					if len(obs) == 0 {
-						continue
-					}
-					if obs[0] == matrix.NotApplicable {
-						na = true
-						continue
-					}
-					if obs[0] == matrix.Unknown {
-						continue
-					}
-					for _, o := range obs {
-						st[o] = true
-					}
+				st, unc := m.TaxObs(tx, c)
+				if st[0] == matrix.NotApplicable {
+					fmt.Fprintf(row, "-")
+					continue
 				}
-				if len(st) == 0 {
-					if na {
-						fmt.Fprintf(bw, "-")
+				if st[0] == matrix.Unknown {
+					fmt.Fprintf(row, "?")
+					continue
+				}
+				obSt := states[c]
+				if unc {
+					if len(obSt) == 1 {
+						fmt.Fprintf(row, "%c", sym[0])
 						continue
 					}
-					fmt.Fprintf(bw, "?")
+					fmt.Fprintf(row, "{")
+					for i := 0; i < len(obSt); i++ {
+						fmt.Fprintf(row, "%c", sym[i])
+					}
+					fmt.Fprintf(row, "}")
 					continue
 				}
-				obSt := states[c]
 				if len(st) > 1 {
-					fmt.Fprintf(bw, "{")
+					fmt.Fprintf(row, "(")
 					for i := 0; i < len(obSt); i++ {
 						v := obSt[i]
-						if !st[v] {
+						if !slices.Contains(st, v) {
 							continue
 						}
-						fmt.Fprintf(bw, "%d", i)
+						fmt.Fprintf(row, "%c", sym[i])
 					}
-					fmt.Fprintf(bw, "}")
+					fmt.Fprintf(row, ")")
 					continue
 				}
 				for i := 0; i < len(obSt); i++ {
 					v := obSt[i]
-					if st[v] {
-						fmt.Fprintf(bw, "%d", i)
+					if v == st[0] {
+						fmt.Fprintf(row, "%c", sym[i])
 						break
 					}
 				}
 			}
-			fmt.Fprintf(bw, "\n")
+			return row.String()
 		}
-		fmt.Fprintf(bw, "\n")
+		blocks = append(blocks, b)
 	}
 	if coll != nil {
 		for _, gene := range coll.Genes() {
-			fmt.Fprintf(bw, "[%s]\n", gene)
-			ns := coll.MaxLen(gene)
-
-			for _, tx := range txLs {
-				var seq string
-				for _, spec := range coll.TaxSpec(tx) {
-					for _, acc := range coll.GeneAccession(spec, gene) {
-						s := coll.Sequence(spec, gene, acc)
-						if countNucleotides(s) > countNucleotides(seq) {
-							seq = s
-						}
-					}
-				}
-				ntx := names[tx]
-				if len(seq) == 0 {
-					fmt.Fprintf(bw, "%s\t", ntx)
-					for i := 0; i < ns; i++ {
-						fmt.Fprintf(bw, "?")
-					}
-					fmt.Fprintf(bw, "\n")
-					continue
-				}
-				fmt.Fprintf(bw, "%s\t%s\n", ntx, seq)
-			}
-			fmt.Fprintf(bw, "\n")
+			blocks = append(blocks, block{
+				head: fmt.Sprintf("[%s]", gene),
+				taxa: txLs,
+				row:  geneRow(coll, gene, true),
+			})
+		}
+	}
+	if len(gaps) > 0 {
+		blocks = append(blocks, block{
+			head: "[Indels]",
+			taxa: txLs,
+			row:  mapRow(gaps),
+		})
+	}
+
+	if wrap > 0 {
+		writeWrapped(bw, blocks, txLs, names, wrap)
+	} else {
+		writeBlocks(bw, blocks, names, interleave)
+	}
+
+	fmt.Fprintf(bw, "\t;\nEnd;\n\n")
+
+	if cat != nil && m != nil {
+		chars := m.Chars()
+		if len(chLs) > 0 {
+			chars = chLs
+		}
+		printNexusCharSets(bw, cat, chars)
+		if inapplicable == "aware" {
+			printNexusDependencies(bw, charDependencies(m, cat, chars), sym)
 		}
 	}
+	printNexusStructSets(bw, stSets)
+	printNexusExSet(bw, exPos)
 
-	fmt.Fprintf(bw, "\t;\n\n")
 	if err := bw.Flush(); err != nil {
 		return err
 	}
@@ -564,6 +1129,160 @@ func printNexusMatrix(w io.Writer, m *matrix.Matrix, coll *dna.Collection) error
 	return nil
 }
 
+// PrintMegaMatrix writes the DNA sequences
+// of the matrix in MEGA format,
+// with a gene command for each gene.
+func printMegaMatrix(w io.Writer, m *matrix.Matrix, coll *dna.Collection, txLs []string, names map[string]string) error {
+	if m != nil {
+		return fmt.Errorf("format %q only supports DNA sequences", format)
+	}
+	bw := bufio.NewWriter(w)
+
+	txLs = taxaOrder(coll.Taxa(), txLs)
+
+	fmt.Fprintf(bw, "#MEGA\n")
+	fmt.Fprintf(bw, "!Title phydata matrix;\n")
+	fmt.Fprintf(bw, "!Format DataType=Nucleotide indel=- missing=?;\n\n")
+	for _, gene := range coll.Genes() {
+		fmt.Fprintf(bw, "!Gene=%s;\n", fileName(gene))
+		ns := coll.MaxLen(gene)
+		for _, tx := range txLs {
+			seq := taxonSequence(coll, tx, gene)
+			if len(seq) < ns {
+				seq += strings.Repeat("?", ns-len(seq))
+			}
+			fmt.Fprintf(bw, "#%s\n%s\n", names[tx], seq)
+		}
+		fmt.Fprintf(bw, "\n")
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// PrintNexusCharSets writes a NEXUS sets block
+// with a charset for each anatomical region.
+func printNexusCharSets(w io.Writer, cat *characters.Catalog, chars []string) {
+	sets := make(map[string][]int)
+	for i, c := range chars {
+		r := cat.Val(c, characters.Region)
+		if r == "" {
+			continue
+		}
+		sets[r] = append(sets[r], i+1)
+	}
+	if len(sets) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "Begin sets;\n")
+	for _, r := range cat.Regions() {
+		pos, ok := sets[r]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "\tcharset %s = %s;\n", strings.Join(strings.Fields(r), "_"), charRanges(pos))
+	}
+	fmt.Fprintf(w, "End;\n\n")
+}
+
+// CharRanges returns a list of sorted positions
+// as NEXUS ranges
+// (e.g., '1-3 5').
+func charRanges(pos []int) string {
+	var ls []string
+	for i := 0; i < len(pos); {
+		j := i
+		for j+1 < len(pos) && pos[j+1] == pos[j]+1 {
+			j++
+		}
+		if j == i {
+			ls = append(ls, strconv.Itoa(pos[i]))
+		} else {
+			ls = append(ls, fmt.Sprintf("%d-%d", pos[i], pos[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(ls, " ")
+}
+
+// TaxPolicy returns the policy
+// used to aggregate the observations of a taxon,
+// as defined by the flags --strict and --no-inferred.
+func taxPolicy() matrix.TaxPolicy {
+	p := matrix.Union
+	if strict {
+		p |= matrix.IgnoreUncertain
+	}
+	if noInferred {
+		p |= matrix.IgnoreInferred
+	}
+	return p
+}
+
+// TaxonSequence returns the sequence of a gene
+// for a taxon,
+// placed in the coordinates of the gene.
+// If the taxon has multiple sequences,
+// the one with more nucleotides will be used.
+func taxonSequence(coll *dna.Collection, tx, gene string) string {
+	spec, acc := taxonAccession(coll, tx, gene)
+	if acc == "" {
+		return ""
+	}
+	return coll.Placed(spec, gene, acc)
+}
+
+// TaxonAccession returns the specimen and the accession
+// of the sequence used for a taxon in a gene,
+// i.e., the sequence with more nucleotides.
+func taxonAccession(coll *dna.Collection, tx, gene string) (spec, acc string) {
+	var seq string
+	for _, sp := range coll.TaxSpec(tx) {
+		for _, a := range coll.GeneAccession(sp, gene) {
+			s := coll.Placed(sp, gene, a)
+			if countNucleotides(s) > countNucleotides(seq) {
+				seq = s
+				spec, acc = sp, a
+			}
+		}
+	}
+	return spec, acc
+}
+
+// IndelCoding returns the simple indel coding
+// of all genes for a list of taxa,
+// and the number of indel characters.
+func indelCoding(coll *dna.Collection, taxa []string) (map[string]string, int) {
+	codes := make(map[string]string, len(taxa))
+	var num int
+	for _, gene := range coll.Genes() {
+		seqs := make(map[string]string, len(taxa))
+		for _, tx := range taxa {
+			seq := taxonSequence(coll, tx, gene)
+			if len(seq) == 0 {
+				continue
+			}
+			seqs[tx] = seq
+		}
+		indels, gc := dna.IndelCoding(seqs)
+		if len(indels) == 0 {
+			continue
+		}
+		for _, tx := range taxa {
+			c, ok := gc[tx]
+			if !ok {
+				c = strings.Repeat("?", len(indels))
+			}
+			codes[tx] += c
+		}
+		num += len(indels)
+	}
+	return codes, num
+}
+
 func countNucleotides(seq string) float64 {
 	num := 0.0
 	for _, p := range seq {
@@ -579,18 +1298,55 @@ func countNucleotides(seq string) float64 {
 	return num
 }
 
-func readTaxa(name string) ([]string, error) {
-	ls, err := readFileList(name)
+// ReadTaxa reads a list of taxa from a file.
+// If a line has two columns,
+// the second column is the name of the taxon
+// in the output.
+func readTaxa(name string) ([]string, map[string]string, error) {
+	f, err := os.Open(name)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	defer f.Close()
 
-	for i, n := range ls {
-		n = canon(n)
-		ls[i] = n
+	r := bufio.NewReader(f)
+	var ls []string
+	rename := make(map[string]string)
+	used := make(map[string]string)
+	for i := 1; ; i++ {
+		ln, err := r.ReadString('\n')
+		if errors.Is(err, io.EOF) && ln == "" {
+			break
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, nil, fmt.Errorf("on file %q: line %d: %v", name, i, err)
+		}
+
+		cols := strings.Split(ln, "\t")
+		n := canon(cols[0])
+		if n == "" {
+			continue
+		}
+		if n[0] == '#' {
+			continue
+		}
+		ls = append(ls, n)
+
+		if len(cols) < 2 {
+			continue
+		}
+		out := strings.Join(strings.Fields(cols[1]), " ")
+		if out == "" {
+			continue
+		}
+		if o, ok := used[strings.ToLower(out)]; ok {
+			return nil, nil, fmt.Errorf("on file %q: line %d: taxa %q and %q with the same output name %q", name, i, o, n, out)
+		}
+		used[strings.ToLower(out)] = n
+		rename[n] = out
 	}
 
-	return ls, nil
+	return ls, rename, nil
 }
 
 func readFileList(name string) ([]string, error) {
@@ -625,14 +1381,8 @@ func readFileList(name string) ([]string, error) {
 }
 
 // Canon returns a taxon name
-// in its canonical form.
+// in its canonical form,
+// using underscores as spaces.
 func canon(name string) string {
-	name = strings.ReplaceAll(name, "_", " ")
-	name = strings.Join(strings.Fields(name), " ")
-	if name == "" {
-		return ""
-	}
-	name = strings.ToLower(name)
-	r, n := utf8.DecodeRuneInString(name)
-	return string(unicode.ToUpper(r)) + name[n:]
+	return names.Taxon(strings.ReplaceAll(name, "_", " "))
 }
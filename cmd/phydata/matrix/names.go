@@ -0,0 +1,138 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/names"
+	"github.com/js-arias/phydata/specimen"
+)
+
+// nameFields are the fields
+// that can be used in a name template.
+var nameFields = []string{
+	"{taxon}",
+	"{specimen}",
+	"{voucher}",
+}
+
+// CheckNameTemplate returns an error
+// if a name template has an unknown field.
+func checkNameTemplate(tmpl string) error {
+	s := tmpl
+	for _, f := range nameFields {
+		s = strings.ReplaceAll(s, f, "")
+	}
+	if strings.ContainsAny(s, "{}") {
+		return fmt.Errorf("invalid name template %q", tmpl)
+	}
+	if !strings.Contains(tmpl, "{taxon}") && !strings.Contains(tmpl, "{specimen}") && !strings.Contains(tmpl, "{voucher}") {
+		return fmt.Errorf("name template %q without fields", tmpl)
+	}
+	return nil
+}
+
+// TerminalNames returns the names of the terminals
+// of a list of taxa,
+// using the name template.
+// If a taxon is in the rename map,
+// the new name will be used as the taxon name.
+//
+// As a terminal is a taxon,
+// if the taxon has multiple specimens,
+// the specimens
+// (or its vouchers)
+// will be joined with a '-'.
+func terminalNames(txLs []string, rename map[string]string, m *matrix.Matrix, coll *dna.Collection, reg *specimen.Registry) map[string]string {
+	ls := make([]string, 0, len(txLs))
+	for _, tx := range txLs {
+		name := tx
+		if n, ok := rename[tx]; ok {
+			name = n
+		}
+		if nameTemplate == "" || nameTemplate == "{taxon}" {
+			ls = append(ls, name)
+			continue
+		}
+
+		specs := taxonSpecimens(tx, m, coll, reg)
+
+		var vouchers []string
+		if reg != nil {
+			for _, sp := range specs {
+				v := reg.Val(sp, specimen.Voucher)
+				if v == "" {
+					continue
+				}
+				vouchers = append(vouchers, v)
+			}
+		}
+
+		r := strings.NewReplacer(
+			"{taxon}", name,
+			"{specimen}", strings.Join(specs, "-"),
+			"{voucher}", strings.Join(vouchers, "-"),
+		)
+		n := strings.Trim(r.Replace(nameTemplate), " _-")
+		if n == "" {
+			n = name
+		}
+		ls = append(ls, n)
+	}
+
+	v := validTaxNames(ls)
+	names := make(map[string]string, len(txLs))
+	for i, tx := range txLs {
+		names[tx] = v[ls[i]]
+	}
+	return names
+}
+
+// TaxonSpecimens returns the specimens of a taxon
+// with data in the matrix.
+// If there are no specimens with data,
+// it returns the specimens of the taxon
+// in the specimen registry.
+func taxonSpecimens(tx string, m *matrix.Matrix, coll *dna.Collection, reg *specimen.Registry) []string {
+	var specs []string
+	if m != nil {
+		specs = append(specs, m.TaxSpec(tx)...)
+	}
+	if coll != nil {
+		specs = append(specs, coll.TaxSpec(tx)...)
+	}
+	if len(specs) == 0 && reg != nil {
+		specs = append(specs, reg.TaxSpec(tx)...)
+	}
+	slices.Sort(specs)
+	return slices.Compact(specs)
+}
+
+// CollapseSpecies merges the infraspecific taxa
+// into its species.
+// As the data is not saved,
+// the original names are kept in the project.
+func collapseSpecies(m *matrix.Matrix, coll *dna.Collection, reg *specimen.Registry) {
+	if m != nil {
+		for _, tx := range m.Taxa() {
+			m.RenameTaxon(tx, names.Species(tx))
+		}
+	}
+	if coll != nil {
+		for _, tx := range coll.Taxa() {
+			coll.RenameTaxon(tx, names.Species(tx))
+		}
+	}
+	if reg != nil {
+		for _, tx := range reg.Taxa() {
+			reg.RenameTaxon(tx, names.Species(tx))
+		}
+	}
+}
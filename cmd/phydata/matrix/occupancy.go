@@ -0,0 +1,109 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/js-arias/phydata/coverage"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+// morphology is the name of the partition
+// of the observations.
+const morphology = "morphology"
+
+// FilterOccupancy removes the genes and taxa
+// below the occupancy thresholds,
+// prints a summary of the excluded data,
+// and returns the list of taxa that will be used
+// in the matrix.
+func filterOccupancy(w io.Writer, m *matrix.Matrix, coll *dna.Collection, txLs []string) []string {
+	if len(txLs) == 0 {
+		txLs = getTaxaList(m, coll)
+	}
+	cov := buildCoverage(m, coll, txLs)
+
+	if minOccupancy > 0 && coll != nil {
+		for _, g := range coll.Genes() {
+			occ := cov.Occupancy(g)
+			if occ >= minOccupancy {
+				continue
+			}
+			fmt.Fprintf(w, "excluded gene %q: occupancy %.3f\n", g, occ)
+			cov.DeletePartition(g)
+			for _, spec := range coll.Specimens() {
+				for _, acc := range coll.GeneAccession(spec, g) {
+					coll.Delete(spec, g, acc)
+				}
+			}
+		}
+	}
+
+	if minTaxOccupancy > 0 {
+		ls := make([]string, 0, len(txLs))
+		for _, tx := range txLs {
+			occ := cov.TaxonOccupancy(tx)
+			if occ < minTaxOccupancy {
+				fmt.Fprintf(w, "excluded taxon %q: occupancy %.3f\n", tx, occ)
+				cov.DeleteTaxon(tx)
+				continue
+			}
+			ls = append(ls, tx)
+		}
+		txLs = ls
+	}
+
+	fmt.Fprintf(w, "matrix: %d taxa, %d partitions, occupancy %.3f, decisiveness %.3f\n", len(cov.Taxa()), len(cov.Partitions()), cov.Total(), cov.Decisiveness(1))
+	return txLs
+}
+
+// BuildCoverage returns the coverage of the partitions
+// (morphology and each gene)
+// for a list of taxa.
+func buildCoverage(m *matrix.Matrix, coll *dna.Collection, txLs []string) *coverage.Coverage {
+	cov := coverage.New()
+	for _, tx := range txLs {
+		cov.AddTaxon(tx)
+	}
+
+	if m != nil {
+		cov.AddPartition(morphology)
+		chars := m.Chars()
+		for _, tx := range txLs {
+			if hasObs(m, tx, chars) {
+				cov.Add(morphology, tx)
+			}
+		}
+	}
+
+	if coll != nil {
+		for _, g := range coll.Genes() {
+			cov.AddPartition(g)
+			for _, tx := range txLs {
+				if taxonSequence(coll, tx, g) != "" {
+					cov.Add(g, tx)
+				}
+			}
+		}
+	}
+	return cov
+}
+
+// HasObs returns true if a taxon has at least one observation
+// for a set of characters.
+func hasObs(m *matrix.Matrix, tx string, chars []string) bool {
+	for _, sp := range m.TaxSpec(tx) {
+		for _, c := range chars {
+			obs := m.Obs(sp, c)
+			if len(obs) > 0 && obs[0] != matrix.Unknown {
+				return true
+			}
+		}
+	}
+	return false
+}
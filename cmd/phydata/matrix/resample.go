@@ -0,0 +1,148 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/phydata/characters"
+	"github.com/js-arias/phydata/cmd/phydata/progress"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+// WriteReplicates writes a set of resampled pseudoreplicates
+// of a matrix,
+// each one in a different file.
+func writeReplicates(w io.Writer, m *matrix.Matrix, coll *dna.Collection, taxa, chLs []string, names map[string]string) error {
+	if output == "" {
+		return fmt.Errorf("resampling requires an output file")
+	}
+	if jackTaxa < 0 || jackTaxa >= 1 {
+		return fmt.Errorf("invalid jackknife probability %.3f", jackTaxa)
+	}
+	if replicates < 1 {
+		return fmt.Errorf("invalid number of replicates %d", replicates)
+	}
+
+	rnd := rand.New(rand.NewSource(seed))
+	fmt.Fprintf(w, "resampling: %d replicates, seed %d\n", replicates, seed)
+
+	if m != nil && len(chLs) == 0 {
+		chLs = m.Chars()
+	}
+
+	ext := filepath.Ext(output)
+	base := strings.TrimSuffix(output, ext)
+	digits := len(strconv.Itoa(replicates))
+	for i := 1; i <= replicates; i++ {
+		txR := taxa
+		if jackTaxa > 0 {
+			txR = jackknifeTaxa(rnd, taxa)
+		}
+		chR := chLs
+		collR := coll
+		if bootChars {
+			if m != nil {
+				chR = bootstrapChars(rnd, chLs)
+			}
+			if coll != nil {
+				collR = bootstrapDNA(rnd, coll)
+			}
+		}
+
+		name := fmt.Sprintf("%s-%0*d%s", base, digits, i, ext)
+		if err := writeReplicate(name, m, collR, txR, chR, names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeReplicate(name string, m *matrix.Matrix, coll *dna.Collection, txLs, chLs []string, names map[string]string) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	// character sets are not valid
+	// in a resampled matrix
+	var cat *characters.Catalog
+	if err := writeMatrix(f, m, coll, txLs, chLs, names, cat, nil); err != nil {
+		return err
+	}
+	progress.Printf("wrote %q", name)
+	return nil
+}
+
+// JackknifeTaxa returns a list of taxa
+// in which each taxon is deleted
+// with the jackknife probability.
+// The outgroup is never deleted.
+func jackknifeTaxa(rnd *rand.Rand, taxa []string) []string {
+	ls := make([]string, 0, len(taxa))
+	for _, tx := range taxa {
+		if tx != outgroup && rnd.Float64() < jackTaxa {
+			continue
+		}
+		ls = append(ls, tx)
+	}
+	return ls
+}
+
+// BootstrapChars returns a list of characters
+// sampled with replacement.
+func bootstrapChars(rnd *rand.Rand, chars []string) []string {
+	ls := make([]string, len(chars))
+	for i := range ls {
+		ls[i] = chars[rnd.Intn(len(chars))]
+	}
+	return ls
+}
+
+// BootstrapDNA returns a new collection
+// in which the positions of each gene
+// are sampled with replacement.
+func bootstrapDNA(rnd *rand.Rand, coll *dna.Collection) *dna.Collection {
+	nc := dna.New()
+	for _, gene := range coll.Genes() {
+		ln := coll.MaxLen(gene)
+		pos := make([]int, ln)
+		for i := range pos {
+			pos[i] = rnd.Intn(ln)
+		}
+
+		for _, tx := range coll.Taxa() {
+			for _, spec := range coll.TaxSpec(tx) {
+				for _, acc := range coll.GeneAccession(spec, gene) {
+					seq := coll.Placed(spec, gene, acc)
+					var b strings.Builder
+					for _, p := range pos {
+						if p >= len(seq) {
+							b.WriteByte('?')
+							continue
+						}
+						b.WriteByte(seq[p])
+					}
+					nc.Add(tx, spec, gene, acc, b.String())
+					nc.Set(spec, gene, acc, "true", dna.Aligned)
+				}
+			}
+		}
+	}
+	return nc
+}
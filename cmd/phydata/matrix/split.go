@@ -0,0 +1,194 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/js-arias/phydata/cmd/phydata/progress"
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+// SplitGenes writes each gene in a different file
+// in the indicated directory,
+// and a coordination file with the list of genes
+// and files.
+func splitGenes(dir string, coll *dna.Collection, txLs []string, names map[string]string) error {
+	if coll == nil {
+		return fmt.Errorf("split genes requires DNA data")
+	}
+	txLs = taxaOrder(coll.Taxa(), txLs)
+
+	var ext string
+	var write func(w io.Writer, gene string, taxa []string, seqs map[string]string, ln int) error
+	switch strings.ToLower(splitFormat) {
+	case "fasta":
+		ext = ".fasta"
+		write = writeFasta
+	case "phylip":
+		ext = ".phy"
+		write = writePhylip
+	case "nexus":
+		ext = ".nex"
+		write = writeNexusGene
+	case "mega":
+		ext = ".meg"
+		write = writeMegaGene
+	default:
+		return fmt.Errorf("unknown split format %q", splitFormat)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	files := make([][]string, 0, len(coll.Genes()))
+	for _, gene := range coll.Genes() {
+		ln := coll.MaxLen(gene)
+		seqs := make(map[string]string, len(txLs))
+		var taxa []string
+		for _, tx := range txLs {
+			seq := taxonSequence(coll, tx, gene)
+			if seq == "" {
+				continue
+			}
+			if len(seq) < ln {
+				seq += strings.Repeat("?", ln-len(seq))
+			}
+			seqs[names[tx]] = seq
+			taxa = append(taxa, names[tx])
+		}
+		if len(taxa) == 0 {
+			continue
+		}
+
+		name := fileName(gene) + ext
+		if err := writeGeneFile(filepath.Join(dir, name), gene, taxa, seqs, ln, write); err != nil {
+			return err
+		}
+		files = append(files, []string{gene, name, strconv.Itoa(len(taxa)), strconv.Itoa(ln)})
+	}
+
+	return writeCoordination(filepath.Join(dir, "genes.tab"), files)
+}
+
+func writeGeneFile(name, gene string, taxa []string, seqs map[string]string, ln int, write func(io.Writer, string, []string, map[string]string, int) error) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	bw := bufio.NewWriter(f)
+	if err := write(bw, gene, taxa, seqs, ln); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	progress.Printf("wrote %q: %d taxa", name, len(taxa))
+	return nil
+}
+
+func writeFasta(w io.Writer, gene string, taxa []string, seqs map[string]string, ln int) error {
+	for _, tx := range taxa {
+		if _, err := fmt.Fprintf(w, ">%s\n%s\n", tx, seqs[tx]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writePhylip(w io.Writer, gene string, taxa []string, seqs map[string]string, ln int) error {
+	if _, err := fmt.Fprintf(w, "%d %d\n", len(taxa), ln); err != nil {
+		return err
+	}
+	for _, tx := range taxa {
+		if _, err := fmt.Fprintf(w, "%s  %s\n", tx, seqs[tx]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeNexusGene(w io.Writer, gene string, taxa []string, seqs map[string]string, ln int) error {
+	fmt.Fprintf(w, "#NEXUS\n\n")
+	fmt.Fprintf(w, "[%s]\n", gene)
+	fmt.Fprintf(w, "Begin data;\n")
+	fmt.Fprintf(w, "\tDimensions ntax=%d nchar=%d;\n", len(taxa), ln)
+	fmt.Fprintf(w, "\tFormat datatype=DNA gap=- missing=?;\n\n")
+	fmt.Fprintf(w, "\tMatrix\n")
+	for _, tx := range taxa {
+		fmt.Fprintf(w, "%s\t%s\n", tx, seqs[tx])
+	}
+	_, err := fmt.Fprintf(w, "\t;\nEnd;\n")
+	return err
+}
+
+func writeMegaGene(w io.Writer, gene string, taxa []string, seqs map[string]string, ln int) error {
+	fmt.Fprintf(w, "#MEGA\n")
+	fmt.Fprintf(w, "!Title %s;\n", gene)
+	fmt.Fprintf(w, "!Format DataType=Nucleotide indel=- missing=?;\n\n")
+	for _, tx := range taxa {
+		fmt.Fprintf(w, "#%s\n%s\n", tx, seqs[tx])
+	}
+	return nil
+}
+
+func writeCoordination(name string, files [][]string) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	tab := csv.NewWriter(f)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	if err := tab.Write([]string{"gene", "file", "taxa", "length"}); err != nil {
+		return fmt.Errorf("on file %q: while writing header: %v", name, err)
+	}
+	for _, row := range files {
+		if err := tab.Write(row); err != nil {
+			return fmt.Errorf("on file %q: %v", name, err)
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("on file %q: while writing data: %v", name, err)
+	}
+	return nil
+}
+
+// FileName returns a valid file name
+// from a gene name.
+func fileName(gene string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, gene)
+}
@@ -0,0 +1,97 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+// A charSet is a named set of characters
+// (1-based positions)
+// of the exported matrix.
+type charSet struct {
+	name string
+	pos  []int
+}
+
+// StructureSets returns the stems
+// (paired positions)
+// and the loops
+// (unpaired positions)
+// of the genes with a secondary structure,
+// in the positions of the exported matrix.
+func structureSets(m *matrix.Matrix, coll *dna.Collection, chLs []string) ([]charSet, error) {
+	if coll == nil {
+		return nil, nil
+	}
+
+	var sets []charSet
+	offset := getNumChars(chLs, m, nil)
+	for _, gene := range coll.Genes() {
+		ln := coll.MaxLen(gene)
+		st, err := coll.Structure(gene)
+		if err != nil {
+			return nil, err
+		}
+		if st == "" {
+			offset += ln
+			continue
+		}
+		pairs, err := dna.Pairs(st)
+		if err != nil {
+			return nil, fmt.Errorf("gene %q: %v", gene, err)
+		}
+
+		var stems, loops []int
+		for i, p := range pairs {
+			if p < 0 {
+				loops = append(loops, offset+i+1)
+				continue
+			}
+			stems = append(stems, offset+i+1)
+		}
+		name := setName(gene)
+		if len(stems) > 0 {
+			sets = append(sets, charSet{name: name + "_stems", pos: stems})
+		}
+		if len(loops) > 0 {
+			sets = append(sets, charSet{name: name + "_loops", pos: loops})
+		}
+		offset += ln
+	}
+	return sets, nil
+}
+
+// PrintNexusStructSets writes a NEXUS sets block
+// with the stems and loops
+// of the genes with a secondary structure.
+func printNexusStructSets(w io.Writer, sets []charSet) {
+	if len(sets) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "Begin sets;\n")
+	for _, s := range sets {
+		fmt.Fprintf(w, "\tcharset %s = %s;\n", s.name, charRanges(s.pos))
+	}
+	fmt.Fprintf(w, "End;\n\n")
+}
+
+// SetName returns a valid NEXUS name
+// from a gene name.
+func setName(gene string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, gene)
+}
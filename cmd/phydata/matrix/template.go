@@ -0,0 +1,47 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// TntTemplate contains the values
+// that can be used in a TNT header or footer template.
+type tntTemplate struct {
+	Taxa  int
+	Chars int
+}
+
+// Write executes a template file
+// and writes the result.
+// If no file is given,
+// it writes the default text.
+func (t tntTemplate) write(w io.Writer, name, def string) error {
+	if name == "" {
+		_, err := io.WriteString(w, def)
+		return err
+	}
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New(name).Parse(string(b))
+	if err != nil {
+		return fmt.Errorf("on template %q: %v", name, err)
+	}
+	if err := tmpl.Execute(w, t); err != nil {
+		return fmt.Errorf("on template %q: %v", name, err)
+	}
+	if !strings.HasSuffix(string(b), "\n") {
+		fmt.Fprintf(w, "\n")
+	}
+	return nil
+}
@@ -9,17 +9,26 @@ package add
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/config"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
 	"github.com/js-arias/phydata/matrix"
 	"github.com/js-arias/phydata/project"
 )
 
 var Command = &command.Command{
 	Usage: `add [-f|--file <obs-file>]
-	[--nexus <ref-id>] <project-file> <obs-file>`,
+	[--nexus <ref-id>] [--wide <ref-id>] [--xlsx <ref-id>] [--batch]
+	[--sheet <name>] [--legend <file>] [--map <file>]
+	[--author <name>] [--source <source>] [--strict] [--dry-run]
+	[--delimiter <delimiter>] [--headers <file>]
+	<project-file> <obs-file>`,
 	Short: "add characters observations to a PhyData project",
 	Long: `
 Command add, read a character observation file, and add the observations to a
@@ -35,7 +44,85 @@ By default, the input is expected to be in the form of a tab-delimited
 observations file. To import a nexus matrix, use the flag --nexus with an ID
 for the reference of the data matrix that will be used as a prefix for
 specimen identifiers.
+
+To import several NEXUS matrices at once, use the flag --batch. With this
+flag, the second argument is a directory, and all the NEXUS files of the
+directory (files with the extension '.nex', '.nexus', or '.nxs') will be
+imported. The reference ID of each matrix is derived from its file name (e.g.,
+'kluge1969.nex' will use the ID 'kluge1969'). The command prints a summary for
+each imported file.
+
+By default, rows of a tab-delimited observations file with an empty taxon,
+specimen, character, or state are ignored. Use the flag --strict to stop the
+import with an error (reporting the row number) if there is any of such rows.
+Rows with a specimen already assigned to a different taxon are skipped, and
+reported as warnings in the standard error (with --strict, the import stops
+with an error). If the observations file of the project has such rows, the
+command fails, as the rows would be lost when the file is written (use
+'phydata obs validate' to find them).
+
+By default, the field delimiter of an observations file (tab, comma, or
+semicolon) is detected from the header of the file. Use the flag --delimiter
+to define the delimiter explicitly. Valid values are 'tab', 'comma', and
+'semicolon'.
+
+The header of the observations file can use common synonyms of the field names
+(e.g., 'species' for taxon, 'voucher' for specimen, or 'trait' for character).
+Additional synonyms can be defined with the flag --headers, with a
+tab-delimited file in which each line is a field, the first value is the name
+of the field, and the following values are its synonyms. Empty lines or lines
+starting with '#' will be ignored.
+
+To import a wide-format matrix, use the flag --wide (for tab, comma, or
+semicolon delimited text files), or the flag --xlsx (for Excel files), with
+an ID for the reference of the data matrix that will be used as a prefix for
+specimen identifiers. The matrix must be a grid with the taxa in rows and the
+characters in columns: the first row contains the character names, and the
+first column the taxon names. If the first cell of the second row is empty,
+or it is 'states' or 'legend', the second row is read as a legend with the
+state names of each character, separated by semicolons, and ordered by its
+state code (e.g., 'absent; present'), or with explicit codes (e.g.,
+'0=absent; 1=present'). Cells can be state codes, state names, or
+polymorphisms separated by '/' (e.g., '0/1'). A question mark '?' is an
+unknown state, a dash '-' is an inapplicable character, and empty cells are
+ignored. For Excel files, by default the first sheet of the file is read,
+use the flag --sheet to define a different sheet.
+
+The state names of a wide-format matrix can also be defined with a legend
+file, using the flag --legend. The legend file is a tab-delimited file with
+the fields 'character', 'code', and 'state'. The legend row of the matrix, if
+present, takes precedence over the legend file.
+
+When merging matrices from different publications, the same character can be
+coded with different names. Use the flag --map to define a character map, a
+tab-delimited file that translates the characters and states of the imported
+matrix into the canonical characters and states of the project, so homologous
+characters are merged instead of duplicated. The file must have the fields
+'character' and 'to-character', and optionally the fields 'state' and
+'to-state'. A row without a state maps the whole character, and a row with a
+state maps only that state (if 'to-character' is empty, the state is mapped
+to a state of the same character). Characters and states not in the map are
+kept unchanged. The map is only applied to the imported observations.
 	
+The new observations are stamped with the time in which they were added (in
+the 'timestamp' field). Use the flag --author to record the name of the person
+that adds the observations (in the 'added-by' field), so in projects with
+multiple contributors it is possible to known who coded each observation. If
+the input file already has values for these fields, they will be kept. The
+default author can be defined with the key 'author' of the configuration file
+(see 'phydata help config').
+
+The names of the new taxa are normalized using the rules defined with the key
+'names' of the configuration file (e.g., to remove the author strings of the
+names).
+
+Use the flag --source to define the source of the coding of the new
+observations (in the 'source' field). Valid values are:
+
+	observed    first-hand observations
+	literature  codings taken from the literature
+	inferred    codings inferred from other specimens, or from the ontogeny
+
 By default, the observations will be stored in the observations file currently
 defined for the project. If the project does not have an observations file, a
 new one will be created with the name 'observations.tab'. A different
@@ -43,6 +130,13 @@ observations file name can be defined using the flag --file or -f. If this
 file is used and there is an observations file already defined, then a new
 file will be created and used as the observations file for the project
 (previously defined observations will be preserved).
+
+After the import, the command prints a summary with the number of new
+observations, the number of replaced cells (cells in which a previous
+observation was removed, for example, when an inapplicable character was
+coded), the number of conflicts (cells already coded in which a new state was
+added, so the cell becomes a polymorphism), and the number of new taxa. Use
+the flag --dry-run to print the summary without saving any change.
 	`,
 	SetFlags: setFlags,
 	Run:      run,
@@ -50,11 +144,35 @@ file will be created and used as the observations file for the project
 
 var obsFile string
 var nexusRef string
+var batch bool
+var wideRef string
+var xlsxRef string
+var sheet string
+var legendFile string
+var mapFile string
+var author string
+var source string
+var strict bool
+var dryRun bool
+var delimiter string
+var headerFile string
 
 func setFlags(c *command.Command) {
 	c.Flags().StringVar(&obsFile, "file", "", "")
 	c.Flags().StringVar(&obsFile, "f", "", "")
 	c.Flags().StringVar(&nexusRef, "nexus", "", "")
+	c.Flags().BoolVar(&batch, "batch", false, "")
+	c.Flags().StringVar(&wideRef, "wide", "", "")
+	c.Flags().StringVar(&xlsxRef, "xlsx", "", "")
+	c.Flags().StringVar(&sheet, "sheet", "", "")
+	c.Flags().StringVar(&legendFile, "legend", "", "")
+	c.Flags().StringVar(&mapFile, "map", "", "")
+	c.Flags().StringVar(&author, "author", config.Get(config.Author, ""), "")
+	c.Flags().StringVar(&source, "source", "", "")
+	c.Flags().BoolVar(&strict, "strict", false, "")
+	c.Flags().BoolVar(&dryRun, "dry-run", false, "")
+	c.Flags().StringVar(&delimiter, "delimiter", "", "")
+	c.Flags().StringVar(&headerFile, "headers", "", "")
 }
 
 func run(c *command.Command, args []string) error {
@@ -66,6 +184,12 @@ func run(c *command.Command, args []string) error {
 	}
 
 	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	p, err := openProject(pFile)
 	if err != nil {
 		return err
@@ -73,20 +197,129 @@ func run(c *command.Command, args []string) error {
 
 	m := matrix.New()
 	if mf := p.Path(project.Observations); mf != "" {
-		if err := readObsFile(mf, m); err != nil {
+		if err := readObsFile(mf, m.ReadTSV); err != nil {
 			return fmt.Errorf("on project %q: %v", pFile, err)
 		}
+		if w := m.Warnings(); len(w) > 0 {
+			// skipped rows would be lost when writing the file
+			return fmt.Errorf("on project %q: file %q: %v (see 'phydata obs validate')", pFile, mf, w[0])
+		}
 	}
 
 	in := args[1]
-	if nexusRef != "" {
-		if err := readNexusFile(in, m, nexusRef); err != nil {
+	formats := 0
+	for _, ref := range []string{nexusRef, wideRef, xlsxRef} {
+		if ref != "" {
+			formats++
+		}
+	}
+	if formats > 1 {
+		return c.UsageError("flags --nexus, --wide, and --xlsx are incompatible")
+	}
+	if batch && formats > 0 {
+		return c.UsageError("flag --batch is incompatible with --nexus, --wide, and --xlsx")
+	}
+
+	source = strings.ToLower(strings.TrimSpace(source))
+	switch source {
+	case "", matrix.Observed, matrix.Literature, matrix.Inferred:
+	default:
+		return c.UsageError(fmt.Sprintf("invalid source %q", source))
+	}
+
+	var legend matrix.Legend
+	if legendFile != "" {
+		legend, err = readLegend(legendFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	var cm *matrix.CharMap
+	if mapFile != "" {
+		cm, err = readCharMap(mapFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	prev := observations(m)
+	prevTaxa := make(map[string]bool)
+	for _, tx := range m.Taxa() {
+		prevTaxa[tx] = true
+	}
+
+	// with a character map,
+	// the data is read into a new matrix
+	// and then merged into the project matrix.
+	nm := m
+	if cm != nil && !batch {
+		nm = matrix.New()
+	}
+	if batch {
+		if err := readBatch(c.Stdout(), in, m, cm); err != nil {
+			return err
+		}
+	} else if nexusRef != "" {
+		if err := readNexusFile(in, nm, nexusRef); err != nil {
 			return err
 		}
+	} else if wideRef != "" {
+		if err := readWideFile(in, nm, wideRef, legend); err != nil {
+			return err
+		}
+	} else if xlsxRef != "" {
+		grid, err := readXLSX(in, sheet)
+		if err != nil {
+			return fmt.Errorf("while reading file %q: %v", in, err)
+		}
+		if err := nm.AddGrid(grid, xlsxRef, legend); err != nil {
+			return fmt.Errorf("while reading file %q: %v", in, err)
+		}
 	} else {
-		if err := readObsFile(in, m); err != nil {
+		comma, err := parseDelimiter(delimiter)
+		if err != nil {
+			return c.UsageError(err.Error())
+		}
+		headers := matrix.DefaultHeaders()
+		if headerFile != "" {
+			h, err := readHeaders(headerFile)
+			if err != nil {
+				return err
+			}
+			headers.Merge(h)
+		}
+		nm.SetHeaders(headers)
+		read := func(r io.Reader) error {
+			return nm.ReadDelimited(r, comma, strict)
+		}
+		if err := readObsFile(in, read); err != nil {
 			return err
 		}
+		printWarnings(c.Stderr(), in, nm)
+	}
+	if nm != m {
+		if err := m.Merge(nm, cm); err != nil {
+			return fmt.Errorf("while merging file %q: %v", in, err)
+		}
+	}
+
+	// normalize the names of the new taxa
+	opt := config.NameOptions()
+	for _, tx := range m.Taxa() {
+		if prevTaxa[tx] {
+			continue
+		}
+		m.RenameTaxon(tx, opt.Taxon(tx))
+	}
+
+	stamp(m, prev, author, source, time.Now().Format(time.RFC3339))
+
+	s := summarize(m, prev, prevTaxa)
+	fmt.Fprintf(c.Stdout(), "%d new observations, %d replaced cells, %d conflicts, %d new taxa\n", s.added, s.replaced, s.conflicts, s.taxa)
+	if dryRun {
+		fmt.Fprintf(c.Stdout(), "dry run: no changes saved\n")
+		return nil
 	}
 
 	if obsFile == "" {
@@ -95,6 +328,9 @@ func run(c *command.Command, args []string) error {
 			obsFile = "observations.tab"
 		}
 	}
+	if p.ReadOnly(project.Observations, obsFile) {
+		return fmt.Errorf("on project %q: file %q is read-only", pFile, p.Source(project.Observations, obsFile))
+	}
 	if err := writeObs(obsFile, m); err != nil {
 		return err
 	}
@@ -107,6 +343,109 @@ func run(c *command.Command, args []string) error {
 	return nil
 }
 
+// Observations returns the observations
+// already defined in a matrix,
+// indexed by specimen and character.
+func observations(m *matrix.Matrix) map[[2]string][]string {
+	obs := make(map[[2]string][]string)
+	chars := m.Chars()
+	for _, sp := range m.Specimens() {
+		for _, c := range chars {
+			st := m.Obs(sp, c)
+			if st[0] == matrix.Unknown {
+				continue
+			}
+			obs[[2]string{sp, c}] = st
+		}
+	}
+	return obs
+}
+
+// A summary is the summary of an import.
+type summary struct {
+	added     int // new observations
+	replaced  int // cells with removed observations
+	conflicts int // coded cells with new states
+	taxa      int // new taxa
+}
+
+// Summarize compares the observations of a matrix
+// with the previous observations.
+func summarize(m *matrix.Matrix, prev map[[2]string][]string, prevTaxa map[string]bool) summary {
+	var s summary
+	curr := observations(m)
+	for k, st := range curr {
+		old, ok := prev[k]
+		if !ok {
+			s.added += len(st)
+			continue
+		}
+		if slices.Equal(old, st) {
+			continue
+		}
+		removed := false
+		for _, o := range old {
+			if !slices.Contains(st, o) {
+				removed = true
+			}
+		}
+		for _, o := range st {
+			if !slices.Contains(old, o) {
+				s.added++
+			}
+		}
+		if removed {
+			s.replaced++
+			continue
+		}
+		s.conflicts++
+	}
+	for k := range prev {
+		if _, ok := curr[k]; !ok {
+			s.replaced++
+		}
+	}
+
+	for _, tx := range m.Taxa() {
+		if !prevTaxa[tx] {
+			s.taxa++
+		}
+	}
+	return s
+}
+
+// Stamp sets the author, the source, and the timestamp
+// of the new observations of a matrix.
+func stamp(m *matrix.Matrix, prev map[[2]string][]string, author, source, now string) {
+	chars := m.Chars()
+	for _, sp := range m.Specimens() {
+		for _, c := range chars {
+			for _, s := range m.Obs(sp, c) {
+				if s == matrix.Unknown || slices.Contains(prev[[2]string{sp, c}], s) {
+					continue
+				}
+				if author != "" && m.Val(sp, c, s, matrix.AddedBy) == "" {
+					m.Set(sp, c, s, author, matrix.AddedBy)
+				}
+				if source != "" && m.Val(sp, c, s, matrix.Source) == "" {
+					m.Set(sp, c, s, source, matrix.Source)
+				}
+				if m.Val(sp, c, s, matrix.Timestamp) == "" {
+					m.Set(sp, c, s, now, matrix.Timestamp)
+				}
+			}
+		}
+	}
+}
+
+// PrintWarnings prints the rows of an observations file
+// that were skipped while reading.
+func printWarnings(w io.Writer, name string, m *matrix.Matrix) {
+	for _, err := range m.Warnings() {
+		fmt.Fprintf(w, "warning: file %q: %v: row skipped\n", name, err)
+	}
+}
+
 func openProject(name string) (*project.Project, error) {
 	p, err := project.Read(name)
 	if errors.Is(err, os.ErrNotExist) {
@@ -118,14 +457,44 @@ func openProject(name string) (*project.Project, error) {
 	return p, nil
 }
 
-func readObsFile(name string, m *matrix.Matrix) error {
+// ParseDelimiter returns the field delimiter
+// defined by a flag value.
+func parseDelimiter(val string) (rune, error) {
+	switch strings.ToLower(strings.TrimSpace(val)) {
+	case "":
+		return 0, nil
+	case "tab", `\t`:
+		return '\t', nil
+	case "comma", ",":
+		return ',', nil
+	case "semicolon", ";":
+		return ';', nil
+	}
+	return 0, fmt.Errorf("invalid delimiter %q", val)
+}
+
+func readHeaders(name string) (matrix.Headers, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h, err := matrix.ReadHeaders(f)
+	if err != nil {
+		return nil, fmt.Errorf("on file %q: %v", name, err)
+	}
+	return h, nil
+}
+
+func readObsFile(name string, read func(io.Reader) error) error {
 	f, err := os.Open(name)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	if err := m.ReadTSV(f); err != nil {
+	if err := read(f); err != nil {
 		return fmt.Errorf("while reading file %q: %v", name, err)
 	}
 	return nil
@@ -144,6 +513,47 @@ func readNexusFile(name string, m *matrix.Matrix, ref string) error {
 	return nil
 }
 
+func readWideFile(name string, m *matrix.Matrix, ref string, legend matrix.Legend) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadWide(f, ref, legend); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func readLegend(name string) (matrix.Legend, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	legend, err := matrix.ReadLegend(f)
+	if err != nil {
+		return nil, fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return legend, nil
+}
+
+func readCharMap(name string) (*matrix.CharMap, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cm, err := matrix.ReadCharMap(f)
+	if err != nil {
+		return nil, fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return cm, nil
+}
+
 func writeObs(name string, m *matrix.Matrix) (err error) {
 	f, err := os.Create(name)
 	if err != nil {
@@ -158,6 +568,7 @@ func writeObs(name string, m *matrix.Matrix) (err error) {
 
 	fmt.Fprintf(f, "# phydata: character observations\n")
 	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Observations))
 	if err := m.TSV(f); err != nil {
 		return fmt.Errorf("while writing to %q: %v", name, err)
 	}
@@ -0,0 +1,106 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package add
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/js-arias/phydata/matrix"
+)
+
+// NexusExt are the file extensions
+// of NEXUS files.
+var nexusExt = []string{
+	".nex",
+	".nexus",
+	".nxs",
+}
+
+// NexusFiles returns the NEXUS files of a directory,
+// sorted by name.
+func nexusFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if !slices.Contains(nexusExt, ext) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, e.Name()))
+	}
+	return files, nil
+}
+
+// BatchRef returns the reference ID
+// of a file name
+// (i.e., the file name without the directory
+// and the extension).
+func batchRef(name string) string {
+	base := filepath.Base(name)
+	return strings.TrimSpace(strings.TrimSuffix(base, filepath.Ext(base)))
+}
+
+// ReadBatch reads all the NEXUS files of a directory
+// into a matrix,
+// using the file name as the reference ID of each file.
+// If a character map is defined,
+// it is applied to the observations of each file.
+// A summary of each file is written in w.
+func readBatch(w io.Writer, dir string, m *matrix.Matrix, cm *matrix.CharMap) error {
+	files, err := nexusFiles(dir)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("directory %q: no NEXUS files found", dir)
+	}
+
+	refs := make(map[string]string, len(files))
+	for _, f := range files {
+		ref := batchRef(f)
+		if ref == "" {
+			return fmt.Errorf("file %q: empty reference ID", f)
+		}
+		if prev, ok := refs[strings.ToLower(ref)]; ok {
+			return fmt.Errorf("file %q: reference ID %q already used by file %q", f, ref, prev)
+		}
+		refs[strings.ToLower(ref)] = f
+	}
+
+	for _, f := range files {
+		prev := observations(m)
+		prevTaxa := make(map[string]bool)
+		for _, tx := range m.Taxa() {
+			prevTaxa[tx] = true
+		}
+		nm := m
+		if cm != nil {
+			nm = matrix.New()
+		}
+		if err := readNexusFile(f, nm, batchRef(f)); err != nil {
+			return err
+		}
+		if nm != m {
+			if err := m.Merge(nm, cm); err != nil {
+				return fmt.Errorf("while merging file %q: %v", f, err)
+			}
+		}
+		s := summarize(m, prev, prevTaxa)
+		fmt.Fprintf(w, "%s: %d new observations, %d replaced cells, %d conflicts, %d new taxa\n", filepath.Base(f), s.added, s.replaced, s.conflicts, s.taxa)
+	}
+	return nil
+}
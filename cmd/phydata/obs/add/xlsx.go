@@ -0,0 +1,187 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package add
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ReadXLSX reads a sheet of an Excel (xlsx) file
+// as a grid of cells.
+// If sheet is empty,
+// the first sheet of the file will be read.
+func readXLSX(name, sheet string) ([][]string, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var wb struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeXML(files, "xl/workbook.xml", &wb); err != nil {
+		return nil, err
+	}
+	if len(wb.Sheets) == 0 {
+		return nil, fmt.Errorf("workbook without sheets")
+	}
+	id := wb.Sheets[0].ID
+	if sheet != "" {
+		id = ""
+		for _, s := range wb.Sheets {
+			if strings.EqualFold(s.Name, sheet) {
+				id = s.ID
+				break
+			}
+		}
+		if id == "" {
+			return nil, fmt.Errorf("sheet %q not found", sheet)
+		}
+	}
+
+	var rels struct {
+		Rels []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeXML(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	var target string
+	for _, r := range rels.Rels {
+		if r.ID == id {
+			target = r.Target
+			break
+		}
+	}
+	if target == "" {
+		return nil, fmt.Errorf("undefined sheet file")
+	}
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = path.Join("xl", target)
+	}
+
+	var shared []string
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst struct {
+			SI []struct {
+				T string `xml:"t"`
+				R []struct {
+					T string `xml:"t"`
+				} `xml:"r"`
+			} `xml:"si"`
+		}
+		if err := decodeXML(files, "xl/sharedStrings.xml", &sst); err != nil {
+			return nil, err
+		}
+		for _, si := range sst.SI {
+			s := si.T
+			for _, r := range si.R {
+				s += r.T
+			}
+			shared = append(shared, s)
+		}
+	}
+
+	var ws struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				R      string `xml:"r,attr"`
+				T      string `xml:"t,attr"`
+				V      string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodeXML(files, target, &ws); err != nil {
+		return nil, err
+	}
+
+	var grid [][]string
+	for i, row := range ws.Rows {
+		r := row.R - 1
+		if row.R == 0 {
+			r = i
+		}
+		for len(grid) <= r {
+			grid = append(grid, nil)
+		}
+
+		var cells []string
+		for j, c := range row.Cells {
+			col := j
+			if c.R != "" {
+				col = column(c.R)
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+
+			v := c.V
+			switch c.T {
+			case "s":
+				k, err := strconv.Atoi(v)
+				if err != nil || k < 0 || k >= len(shared) {
+					return nil, fmt.Errorf("sheet %q: cell %q: invalid shared string %q", target, c.R, v)
+				}
+				v = shared[k]
+			case "inlineStr":
+				v = c.Inline
+			}
+			cells[col] = v
+		}
+		grid[r] = cells
+	}
+	return grid, nil
+}
+
+// Column returns the column index
+// of a cell reference
+// (e.g., "B3" is 1).
+func column(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1
+}
+
+func decodeXML(files map[string]*zip.File, name string, v any) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("file %q not found", name)
+	}
+	r, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("file %q: %v", name, err)
+	}
+	defer r.Close()
+
+	if err := xml.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("file %q: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package agreement implements a command to compare
+// the codings of the references
+// of a PhyData project.
+package agreement
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `agreement [--cells] [--char <character>]
+	<project-file>`,
+	Short: "compare the codings of different references",
+	Long: `
+Command agreement reads a PhyData project, and for the taxa coded by multiple
+references (for example, the matrices of different papers), compares the
+states assigned by each reference.
+
+The argument of the command is the name of the project file.
+
+The reference of an observation is taken from its 'reference' field, or, if
+the field is empty, from the prefix of the specimen identifier (as in the
+identifiers of imported matrices, e.g., 'kluge1969:ascaphus truei').
+Observations without a reference, and unknown observations, are ignored.
+Inapplicable observations are compared as any other state.
+
+For each character and taxon coded by two or more references, the codings are
+compared. If all references assigned the same states, the codings agree. If
+the references share some states, but not all (for example, a reference
+coded a polymorphism), the codings partially agree. If a pair of references
+do not share any state, the codings are in conflict.
+
+By default, the output is a TSV table with the character, the number of
+compared taxa, the number of taxa in agreement, in partial agreement, and in
+conflict, and the proportion of taxa in agreement.
+
+Use the flag --cells to print the cells in which the references contradict
+each other, instead of the statistics. The output is a TSV table with the
+taxon, the character, the status of the cell ('conflict' or 'partial'), the
+reference, and the states assigned by the reference (separated by '/'), with
+a row for each reference.
+
+Use the flag --char to restrict the comparison to a single character.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var cellsFlag bool
+var charFlag string
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&cellsFlag, "cells", false, "")
+	c.Flags().StringVar(&charFlag, "char", "", "")
+}
+
+// Status of a compared cell.
+const (
+	agree    = "agree"
+	partial  = "partial"
+	conflict = "conflict"
+)
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	mf := p.Path(project.Observations)
+	if mf == "" {
+		return fmt.Errorf("undefined observations file")
+	}
+	m := matrix.New()
+	if err := readObsFile(mf, m); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	chars := m.Chars()
+	if charFlag != "" {
+		ch := strings.ToLower(strings.Join(strings.Fields(charFlag), " "))
+		if !slices.Contains(chars, ch) {
+			return fmt.Errorf("on project %q: character %q not found", args[0], charFlag)
+		}
+		chars = []string{ch}
+	}
+
+	tab := csv.NewWriter(c.Stdout())
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	header := []string{"character", "taxa", "agree", "partial", "conflict", "agreement"}
+	if cellsFlag {
+		header = []string{"taxon", "character", "status", "reference", "states"}
+	}
+	if err := tab.Write(header); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+
+	for _, ch := range chars {
+		var taxa, nAgree, nPartial, nConflict int
+		for _, tx := range m.Taxa() {
+			refs := refCodes(m, tx, ch)
+			if len(refs) < 2 {
+				continue
+			}
+			taxa++
+			st := compare(refs)
+			switch st {
+			case agree:
+				nAgree++
+				continue
+			case partial:
+				nPartial++
+			case conflict:
+				nConflict++
+			}
+			if !cellsFlag {
+				continue
+			}
+
+			for _, r := range sortedRefs(refs) {
+				row := []string{
+					tx,
+					ch,
+					st,
+					r,
+					strings.Join(refs[r], "/"),
+				}
+				if err := tab.Write(row); err != nil {
+					return fmt.Errorf("while writing data: %v", err)
+				}
+			}
+		}
+		if cellsFlag || taxa == 0 {
+			continue
+		}
+
+		row := []string{
+			ch,
+			strconv.Itoa(taxa),
+			strconv.Itoa(nAgree),
+			strconv.Itoa(nPartial),
+			strconv.Itoa(nConflict),
+			strconv.FormatFloat(float64(nAgree)/float64(taxa), 'f', 3, 64),
+		}
+		if err := tab.Write(row); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+// RefCodes returns the states assigned
+// by each reference
+// to a character of a taxon.
+func refCodes(m *matrix.Matrix, taxon, char string) map[string][]string {
+	refs := make(map[string][]string)
+	for _, sp := range m.TaxSpec(taxon) {
+		for _, o := range m.Obs(sp, char) {
+			if o == matrix.Unknown {
+				continue
+			}
+			r := m.Val(sp, char, o, matrix.Reference)
+			if r == "" {
+				pre, _, ok := strings.Cut(sp, ":")
+				if !ok {
+					continue
+				}
+				r = pre
+			}
+			r = strings.ToLower(r)
+			if slices.Contains(refs[r], o) {
+				continue
+			}
+			refs[r] = append(refs[r], o)
+		}
+	}
+	for r := range refs {
+		slices.Sort(refs[r])
+	}
+	return refs
+}
+
+// Compare returns the status of the codings
+// of a cell.
+func compare(refs map[string][]string) string {
+	ls := sortedRefs(refs)
+	st := agree
+	for i, a := range ls {
+		for _, b := range ls[i+1:] {
+			sa, sb := refs[a], refs[b]
+			if slices.Equal(sa, sb) {
+				continue
+			}
+			shared := false
+			for _, s := range sa {
+				if slices.Contains(sb, s) {
+					shared = true
+					break
+				}
+			}
+			if !shared {
+				return conflict
+			}
+			st = partial
+		}
+	}
+	return st
+}
+
+func sortedRefs(refs map[string][]string) []string {
+	ls := make([]string, 0, len(refs))
+	for r := range refs {
+		ls = append(ls, r)
+	}
+	slices.Sort(ls)
+	return ls
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
@@ -7,6 +7,7 @@
 package chars
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 
@@ -16,15 +17,25 @@ import (
 )
 
 var Command = &command.Command{
-	Usage: "chars <project-file>",
+	Usage: "chars [--json] <project-file>",
 	Short: "print characters",
 	Long: `
 Command chars reads a PhyData project and print the character names sued for
 the observations stored in a PhyData project.
 
 The argument of the command is the name of the project file.
+
+By default, each character is printed in a line. Use the flag --json to print
+the characters as a JSON array.
 	`,
-	Run: run,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var jsonFlag bool
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&jsonFlag, "json", false, "")
 }
 
 func run(c *command.Command, args []string) error {
@@ -46,6 +57,15 @@ func run(c *command.Command, args []string) error {
 		return fmt.Errorf("on project %q: %v", args[0], err)
 	}
 
+	if jsonFlag {
+		enc := json.NewEncoder(c.Stdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(m.Chars()); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+		return nil
+	}
+
 	for _, ch := range m.Chars() {
 		fmt.Fprintf(c.Stdout(), "%s\n", ch)
 	}
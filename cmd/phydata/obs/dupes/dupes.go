@@ -0,0 +1,300 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package dupes implements a command to detect
+// possible duplicated characters
+// in a PhyData project.
+package dupes
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `dupes [--similarity <value>] [--min-taxa <number>]
+	<project-file>`,
+	Short: "detect possible duplicated characters",
+	Long: `
+Command dupes reads a PhyData project and search for characters that are
+possibly duplicated, for example, the same character imported from matrices
+of different papers, using a different name.
+
+The argument of the command is the name of the project file.
+
+Two characters are flagged as possible duplicates if their names are similar,
+or if they have identical coding patterns in the taxa in which both characters
+are coded. The name similarity is measured as one minus the edit distance
+between the names, divided by the length of the longest name (so identical
+names have a similarity of 1). By default, names with a similarity greater or
+equal to 0.8 are flagged. Use the flag --similarity to define a different
+threshold. Coding patterns are identical if there is a one-to-one
+correspondence between the states of both characters in the shared taxa
+(i.e., the characters are the same, except for the state names). Unknown and
+inapplicable observations are ignored. By default, at least 4 shared taxa
+are required to compare the patterns of two characters. Use the flag
+--min-taxa to define a different value.
+
+Flagged characters are clustered, so if character A is a duplicate of B, and
+B of C, then the three characters are in the same cluster.
+
+The output is a TSV table with the cluster ID, the names of both characters,
+the name similarity, the number of shared taxa, and the kind of match
+('name', 'pattern', or both).
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var similarity float64
+var minTaxa int
+
+func setFlags(c *command.Command) {
+	c.Flags().Float64Var(&similarity, "similarity", 0.8, "")
+	c.Flags().IntVar(&minTaxa, "min-taxa", 4, "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	mf := p.Path(project.Observations)
+	if mf == "" {
+		return fmt.Errorf("undefined observations file")
+	}
+	m := matrix.New()
+	if err := readObsFile(mf, m); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	chars := m.Chars()
+	codes := make([]map[string]string, len(chars))
+	for i, ch := range chars {
+		codes[i] = taxonCodes(m, ch)
+	}
+
+	var pairs []pair
+	for i := range chars {
+		for j := i + 1; j < len(chars); j++ {
+			pr := pair{
+				a:   i,
+				b:   j,
+				sim: nameSimilarity(chars[i], chars[j]),
+			}
+			same, shared := samePattern(codes[i], codes[j])
+			pr.taxa = shared
+			if pr.sim >= similarity {
+				pr.match = append(pr.match, "name")
+			}
+			if same && shared >= minTaxa {
+				pr.match = append(pr.match, "pattern")
+			}
+			if len(pr.match) == 0 {
+				continue
+			}
+			pairs = append(pairs, pr)
+		}
+	}
+
+	clusters := clusterPairs(len(chars), pairs)
+
+	tab := csv.NewWriter(c.Stdout())
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	if err := tab.Write([]string{"cluster", "character", "duplicate", "similarity", "taxa", "match"}); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+	for _, pr := range pairs {
+		row := []string{
+			strconv.Itoa(clusters[pr.a]),
+			chars[pr.a],
+			chars[pr.b],
+			strconv.FormatFloat(pr.sim, 'f', 3, 64),
+			strconv.Itoa(pr.taxa),
+			strings.Join(pr.match, ","),
+		}
+		if err := tab.Write(row); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+	}
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+// A pair is a pair of possible duplicated characters.
+type pair struct {
+	a, b  int
+	sim   float64
+	taxa  int
+	match []string
+}
+
+// TaxonCodes returns the states
+// of each taxon for a character.
+// Polymorphic observations are joined
+// in a single code.
+func taxonCodes(m *matrix.Matrix, char string) map[string]string {
+	codes := make(map[string]string)
+	for _, tx := range m.Taxa() {
+		st := make(map[string]bool)
+		for _, sp := range m.TaxSpec(tx) {
+			for _, o := range m.Obs(sp, char) {
+				if o == matrix.Unknown || o == matrix.NotApplicable {
+					continue
+				}
+				st[o] = true
+			}
+		}
+		if len(st) == 0 {
+			continue
+		}
+		ls := make([]string, 0, len(st))
+		for s := range st {
+			ls = append(ls, s)
+		}
+		slices.Sort(ls)
+		codes[tx] = strings.Join(ls, "/")
+	}
+	return codes
+}
+
+// SamePattern returns true
+// if there is a one-to-one correspondence
+// between the codes of two characters
+// in the shared taxa,
+// and the number of shared taxa.
+func samePattern(a, b map[string]string) (bool, int) {
+	ab := make(map[string]string)
+	ba := make(map[string]string)
+	shared := 0
+	same := true
+	for tx, ca := range a {
+		cb, ok := b[tx]
+		if !ok {
+			continue
+		}
+		shared++
+		if v, ok := ab[ca]; ok && v != cb {
+			same = false
+		}
+		if v, ok := ba[cb]; ok && v != ca {
+			same = false
+		}
+		ab[ca] = cb
+		ba[cb] = ca
+	}
+	if shared == 0 {
+		return false, 0
+	}
+	return same, shared
+}
+
+// NameSimilarity returns the similarity
+// between two character names,
+// as one minus the edit distance
+// divided by the length of the longest name.
+func nameSimilarity(a, b string) float64 {
+	ra := []rune(a)
+	rb := []rune(b)
+	max := len(ra)
+	if len(rb) > max {
+		max = len(rb)
+	}
+	if max == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(max)
+}
+
+// EditDistance returns the Levenshtein distance
+// between two strings.
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// ClusterPairs returns the cluster ID
+// of each character
+// using the flagged pairs.
+func clusterPairs(n int, pairs []pair) []int {
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(x int) int {
+		if parent[x] != x {
+			parent[x] = find(parent[x])
+		}
+		return parent[x]
+	}
+	for _, pr := range pairs {
+		ra, rb := find(pr.a), find(pr.b)
+		if ra == rb {
+			continue
+		}
+		if rb < ra {
+			ra, rb = rb, ra
+		}
+		parent[rb] = ra
+	}
+
+	ids := make(map[int]int)
+	clusters := make([]int, n)
+	for i := range clusters {
+		r := find(i)
+		id, ok := ids[r]
+		if !ok {
+			id = len(ids) + 1
+			ids[r] = id
+		}
+		clusters[i] = id
+	}
+	return clusters
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
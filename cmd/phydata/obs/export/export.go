@@ -0,0 +1,162 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package export implements a command to export
+// the character observations of a PhyData project.
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/characters"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `export [--wide] [--nexml] [-o|--output <file>]
+	<project-file>`,
+	Short: "export character observations",
+	Long: `
+Command export reads a PhyData project and writes the character observations
+stored in the project.
+
+The argument of the command is the name of the project file.
+
+By default, the observations are written as a TSV file with one row per
+observation, the same format used to store the observations in the project.
+
+If the flag --wide is defined, the observations are written as a wide-format
+TSV file, with the taxa in rows and the characters in columns, that can be
+opened in a spreadsheet, for example, to proofread the codings. The first row
+contains the character names, and the first column the taxon names. Cells
+contain the state names, with polymorphic observations separated by '/'. A
+question mark '?' is used for unknown states, and a dash '-' for inapplicable
+characters. The observations of all the specimens of a taxon are merged. The
+output can be imported again with the flag --wide of the command 'obs add'.
+
+If the flag --nexml is defined, the observations are written as a NeXML file
+(see <http://nexml.org>), with the observations of all the specimens of a
+taxon merged. If the project has a character metadata file (see
+'phydata obs meta'), the characters and states with ontology terms (e.g.,
+UBERON for characters, and PATO for states) will be annotated with the IRIs of
+the terms, in a form compatible with Phenoscape, so the matrix can be
+semantically compared with other matrices.
+
+By default, the output is printed in the standard output. Use the flag
+--output, or -o, to define an output file.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var wide bool
+var nexml bool
+var output string
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&wide, "wide", false, "")
+	c.Flags().BoolVar(&nexml, "nexml", false, "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+}
+
+func run(c *command.Command, args []string) (err error) {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	if wide && nexml {
+		return c.UsageError("flags --wide and --nexml are incompatible")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	mf := p.Path(project.Observations)
+	if mf == "" {
+		return fmt.Errorf("undefined observations file")
+	}
+	m := matrix.New()
+	if err := readObsFile(mf, m); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	var cat *characters.Catalog
+	if cf := p.Path(project.Characters); cf != "" && nexml {
+		cat = characters.New()
+		if err := readCharFile(cf, cat); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+	}
+
+	out := c.Stdout()
+	if output != "" {
+		var f *os.File
+		f, err = os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			e := f.Close()
+			if e != nil && err == nil {
+				err = e
+			}
+		}()
+		out = f
+	}
+
+	bw := bufio.NewWriter(out)
+	write := m.TSV
+	if wide {
+		write = m.Wide
+	}
+	if nexml {
+		write = func(w io.Writer) error {
+			if cat == nil {
+				return m.NeXML(w, nil)
+			}
+			return m.NeXML(w, cat)
+		}
+	}
+	if err := write(bw); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func readCharFile(name string, c *characters.Catalog) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := c.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package get implements a command to print the observations
+// of a taxon in a PhyData project.
+package get
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: "get [--tsv] [--json] <project-file> <taxon> [<character>]",
+	Short: "print the observations of a taxon",
+	Long: `
+Command get reads a PhyData project and prints the observations of a taxon,
+with the specimen, the reference, the comments, and the image of each
+observation, so it can be used to know how a taxon is coded for a character,
+and who says so.
+
+The first argument of the command is the name of the project file.
+
+The second argument is the name of the taxon.
+
+The optional third argument is the name of a character. If it is given, only
+the observations of that character are printed. Otherwise, the observations
+of all the characters are printed.
+
+The observations are sorted by character, and then by specimen. Unknown
+observations are not printed.
+
+By default, the output is formatted as a table for reading in the terminal.
+Use the flag --tsv to print the output as a TSV table, or the flag --json to
+print the output as a JSON array, with an object for each observation.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var tsvFlag bool
+var jsonFlag bool
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&tsvFlag, "tsv", false, "")
+	c.Flags().BoolVar(&jsonFlag, "json", false, "")
+}
+
+// An obsData is an observation
+// of a taxon.
+type obsData struct {
+	Specimen  string `json:"specimen"`
+	Character string `json:"character"`
+	State     string `json:"state"`
+	Reference string `json:"reference,omitempty"`
+	Comments  string `json:"comments,omitempty"`
+	Image     string `json:"image,omitempty"`
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if len(args) < 2 {
+		return c.UsageError("expecting taxon name")
+	}
+	if tsvFlag && jsonFlag {
+		return c.UsageError("flag --tsv is incompatible with flag --json")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	mf := p.Path(project.Observations)
+	if mf == "" {
+		return fmt.Errorf("undefined observations file")
+	}
+	m := matrix.New()
+	if err := readObsFile(mf, m); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	tax := args[1]
+	specs := m.TaxSpec(tax)
+	if len(specs) == 0 {
+		return fmt.Errorf("on project %q: taxon %q not found", args[0], tax)
+	}
+
+	chars := m.Chars()
+	if len(args) > 2 {
+		char := strings.ToLower(strings.Join(strings.Fields(args[2]), " "))
+		if !slices.Contains(chars, char) {
+			return fmt.Errorf("on project %q: character %q not found", args[0], args[2])
+		}
+		chars = []string{char}
+	}
+
+	data := []obsData{}
+	for _, char := range chars {
+		for _, sp := range specs {
+			for _, st := range m.Obs(sp, char) {
+				if st == matrix.Unknown {
+					continue
+				}
+				data = append(data, obsData{
+					Specimen:  sp,
+					Character: char,
+					State:     st,
+					Reference: m.Val(sp, char, st, matrix.Reference),
+					Comments:  m.Val(sp, char, st, matrix.Comments),
+					Image:     m.Val(sp, char, st, matrix.ImageLink),
+				})
+			}
+		}
+	}
+
+	if jsonFlag {
+		enc := json.NewEncoder(c.Stdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(data); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+		return nil
+	}
+
+	rows := [][]string{{"specimen", "character", "state", "reference", "comments", "image"}}
+	for _, d := range data {
+		rows = append(rows, []string{
+			d.Specimen,
+			d.Character,
+			d.State,
+			d.Reference,
+			d.Comments,
+			d.Image,
+		})
+	}
+
+	if tsvFlag {
+		tab := csv.NewWriter(c.Stdout())
+		tab.Comma = '\t'
+		tab.UseCRLF = true
+		if err := tab.WriteAll(rows); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.Stdout(), 0, 0, 2, ' ', 0)
+	for _, r := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", r[0], r[1], r[2], r[3], r[4], r[5])
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
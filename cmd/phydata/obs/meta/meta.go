@@ -0,0 +1,166 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package meta implements a command to add character metadata
+// to a PhyData project.
+package meta
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/characters"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `meta [-f|--file <metadata-file>]
+	<project-file> <metadata-file>`,
+	Short: "add character metadata to a PhyData project",
+	Long: `
+Command meta reads a character metadata file, and add the metadata to a
+PhyData project.
+
+The first argument of the command is the name of the project file. If no
+project file exists, a new project will be created.
+
+The second argument of the command is the name of the file that contains the
+character metadata. It is a tab-delimited file with the following fields:
+
+	character      the name of the character
+	state          a state of the character
+	region         the anatomical region (or system) of the character
+	ontology       the ID (or IRI) of a term of an ontology
+	depends-on     the character that controls the applicability of the
+	               character
+	applicable-if  the states of the controlling character (separated by
+	               semicolons) in which the character is applicable
+	comments       simple comments about the character
+
+Only the field 'character' is required. If the field 'state' is defined, the
+row is an annotation of the state, and only the field 'ontology' is used. For
+characters, the ontology term is usually an anatomical entity (e.g.,
+'UBERON:0002228'), and for states, a phenotype quality (e.g.,
+'PATO:0000642'). Ontology IDs are expanded to OBO Foundry IRIs when exported
+(see 'phydata obs export --nexml'). Empty values in the file do not
+replace the values already defined in the project. The anatomical region of
+the characters is used by the flag --group-order of the command 'matrix' to
+group the characters by region. The dependencies between characters (for
+example, 'tail color' is only applicable if 'tail' is 'present') are used by
+the command 'phydata obs validate'.
+
+By default, the metadata will be stored in the characters file currently
+defined for the project. If the project does not have a characters file, a
+new one will be created with the name 'characters.tab'. A different file name
+can be defined using the flag --file or -f.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var charFile string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&charFile, "file", "", "")
+	c.Flags().StringVar(&charFile, "f", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if len(args) < 2 {
+		return c.UsageError("expecting metadata file")
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := openProject(pFile)
+	if err != nil {
+		return err
+	}
+
+	cat := characters.New()
+	if cf := p.Path(project.Characters); cf != "" {
+		if err := readCharFile(cf, cat); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+	if err := readCharFile(args[1], cat); err != nil {
+		return err
+	}
+
+	if charFile == "" {
+		charFile = p.Path(project.Characters)
+		if charFile == "" {
+			charFile = "characters.tab"
+		}
+	}
+	if p.ReadOnly(project.Characters, charFile) {
+		return fmt.Errorf("on project %q: file %q is read-only", pFile, p.Source(project.Characters, charFile))
+	}
+	if err := writeChars(charFile, cat); err != nil {
+		return err
+	}
+
+	p.Add(project.Characters, charFile)
+	if err := p.Write(pFile); err != nil {
+		return err
+	}
+	return nil
+}
+
+func openProject(name string) (*project.Project, error) {
+	p, err := project.Read(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return project.New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable ot open project %q: %v", name, err)
+	}
+	return p, nil
+}
+
+func readCharFile(name string, c *characters.Catalog) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := c.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeChars(name string, c *characters.Catalog) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: character metadata\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Characters))
+	if err := c.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package numbering implements a command to export
+// a numbering of the characters
+// of a PhyData project.
+package numbering
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `numbering [--from <numbering-file>] [-o|--output <file>]
+	<project-file>`,
+	Short: "export a character numbering",
+	Long: `
+Command numbering reads a PhyData project and writes a character numbering,
+a TSV file with the number and the name of each character, in the order in
+which the characters will be exported in a matrix.
+
+The argument of the command is the name of the project file.
+
+By default, the characters are numbered alphabetically. If the flag --from is
+defined with a previously saved numbering file, the characters in that file
+keep their relative order, and the new characters are added at the end,
+sorted alphabetically, so the character numbers (e.g., in a manuscript) stay
+stable as characters are added. Characters of the previous numbering that are
+not in the project are removed (and the numbers of the following characters
+are shifted). If a character is renamed, edit the name in the previous
+numbering file to keep its number.
+
+The numbering file can be used with the flag --numbering of the command
+'matrix' to export the matrix with the same character numbers.
+
+By default, the output is printed in the standard output. Use the flag
+--output, or -o, to define an output file.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var fromFile string
+var output string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&fromFile, "from", "", "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+}
+
+func run(c *command.Command, args []string) (err error) {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	mf := p.Path(project.Observations)
+	if mf == "" {
+		return fmt.Errorf("undefined observations file")
+	}
+	m := matrix.New()
+	if err := readObsFile(mf, m); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	chars := m.Chars()
+	if fromFile != "" {
+		prev, err := readNumbering(fromFile)
+		if err != nil {
+			return err
+		}
+		chars = matrix.Renumber(prev, chars)
+	}
+
+	out := c.Stdout()
+	if output != "" {
+		var f *os.File
+		f, err = os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			e := f.Close()
+			if e != nil && err == nil {
+				err = e
+			}
+		}()
+		out = f
+	}
+
+	fmt.Fprintf(out, "# phydata: character numbering\n")
+	fmt.Fprintf(out, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	if err := matrix.WriteNumbering(out, chars); err != nil {
+		return err
+	}
+	return nil
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func readNumbering(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	chars, err := matrix.ReadNumbering(f)
+	if err != nil {
+		return nil, fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return chars, nil
+}
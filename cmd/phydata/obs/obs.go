@@ -9,14 +9,38 @@ package obs
 import (
 	"github.com/js-arias/command"
 	"github.com/js-arias/phydata/cmd/phydata/obs/add"
+	"github.com/js-arias/phydata/cmd/phydata/obs/agreement"
 	"github.com/js-arias/phydata/cmd/phydata/obs/chars"
+	"github.com/js-arias/phydata/cmd/phydata/obs/dupes"
+	"github.com/js-arias/phydata/cmd/phydata/obs/export"
+	"github.com/js-arias/phydata/cmd/phydata/obs/get"
+	"github.com/js-arias/phydata/cmd/phydata/obs/meta"
+	"github.com/js-arias/phydata/cmd/phydata/obs/numbering"
+	"github.com/js-arias/phydata/cmd/phydata/obs/recode"
+	"github.com/js-arias/phydata/cmd/phydata/obs/refs"
+	"github.com/js-arias/phydata/cmd/phydata/obs/review"
+	"github.com/js-arias/phydata/cmd/phydata/obs/specimens"
 	"github.com/js-arias/phydata/cmd/phydata/obs/taxa"
+	"github.com/js-arias/phydata/cmd/phydata/obs/template"
+	"github.com/js-arias/phydata/cmd/phydata/obs/validate"
 )
 
 func init() {
 	Command.Add(add.Command)
+	Command.Add(agreement.Command)
 	Command.Add(chars.Command)
+	Command.Add(dupes.Command)
+	Command.Add(export.Command)
+	Command.Add(get.Command)
+	Command.Add(meta.Command)
+	Command.Add(numbering.Command)
+	Command.Add(recode.Command)
+	Command.Add(refs.Command)
+	Command.Add(review.Command)
+	Command.Add(specimens.Command)
 	Command.Add(taxa.Command)
+	Command.Add(template.Command)
+	Command.Add(validate.Command)
 }
 
 var Command = &command.Command{
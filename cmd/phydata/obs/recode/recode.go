@@ -0,0 +1,217 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package recode implements a command to recode
+// presence/absence characters
+// into multistate characters
+// (and back)
+// in a PhyData project.
+package recode
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `recode [--binary] [--keep] [--dry-run]
+	<project-file> <recoding-file>`,
+	Short: "recode presence/absence and multistate characters",
+	Long: `
+Command recode reads a recoding file, and converts a set of binary
+(presence/absence) characters of a PhyData project into a single multistate
+character, or a multistate character into a set of binary characters. As
+different sources code the same anatomy under different conventions, this
+command can be used to keep a consistent coding in the project.
+
+The first argument of the command is the name of the project file.
+
+The second argument is the name of the recoding file. It is a tab-delimited
+file with the following fields:
+
+	character  the name of the multistate character
+	state      a state of the multistate character
+	binary     the name of the binary character equivalent to the state
+	present    the state of the binary character that indicates presence
+	           (optional, by default 'present')
+	absent     the state of the binary character that indicates absence
+	           (optional, by default 'absent')
+
+A row without a binary character defines the state of the multistate
+character in which all the binary characters are absent. For example:
+
+	character	state	binary
+	dentition	incisors	incisors
+	dentition	canines	canines
+	dentition	toothless
+
+By default, the binary characters are recoded as a multistate character. If
+more than one binary character is present in a specimen, the multistate
+character will be polymorphic. If all the binary characters are absent, the
+multistate character uses the state defined for the absence of all binary
+characters (if there is no such state, the specimen is ignored). If all the
+binary characters are inapplicable, the multistate character will be
+inapplicable. Specimens with unknown binary characters, and without present
+binary characters, are ignored.
+
+If the flag --binary is defined, the multistate character is recoded as
+binary characters. A binary character is present if its state is observed in
+the multistate character, and absent otherwise. If the multistate character
+is inapplicable, the binary characters will be inapplicable.
+
+The recoded observations keep the additional fields (e.g., reference,
+comments, or source) of the observations used to recode them.
+
+The recoded characters must not have observations in the project (otherwise
+the new states would be mixed with the previous ones), so if they have, the
+command fails.
+
+By default, the recoded characters are removed from the project. Use the flag
+--keep to keep them.
+
+The command prints the number of recoded specimens of each character. Use the
+flag --dry-run to print the summary without saving any change.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var binaryFlag bool
+var keep bool
+var dryRun bool
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&binaryFlag, "binary", false, "")
+	c.Flags().BoolVar(&keep, "keep", false, "")
+	c.Flags().BoolVar(&dryRun, "dry-run", false, "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if len(args) < 2 {
+		return c.UsageError("expecting recoding file")
+	}
+
+	unlock, err := lock.Project(args[0])
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	mf := p.Path(project.Observations)
+	if mf == "" {
+		return fmt.Errorf("undefined observations file")
+	}
+	m := matrix.New()
+	if err := readObsFile(mf, m); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	ls, err := readRecoding(args[1])
+	if err != nil {
+		return err
+	}
+
+	var remove []string
+	for _, rc := range ls {
+		if binaryFlag {
+			n, err := m.ToBinary(rc)
+			if err != nil {
+				return fmt.Errorf("on project %q: %v", args[0], err)
+			}
+			fmt.Fprintf(c.Stdout(), "%s: %d specimens recoded as binary characters\n", rc.Char, n)
+			remove = append(remove, rc.Char)
+			continue
+		}
+		n, err := m.ToMultistate(rc)
+		if err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+		fmt.Fprintf(c.Stdout(), "%s: %d specimens recoded as a multistate character\n", rc.Char, n)
+		remove = append(remove, rc.Binaries()...)
+	}
+	if dryRun {
+		fmt.Fprintf(c.Stdout(), "dry run: no changes saved\n")
+		return nil
+	}
+
+	if !keep {
+		m = m.Filter(func(o matrix.Observation) bool {
+			return !slices.Contains(remove, o.Character)
+		})
+	}
+	if p.ReadOnly(project.Observations, mf) {
+		return fmt.Errorf("on project %q: file %q is read-only", args[0], p.Source(project.Observations, mf))
+	}
+	if err := writeObs(mf, m); err != nil {
+		return err
+	}
+	return nil
+}
+
+func readRecoding(name string) ([]*matrix.Recoding, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ls, err := matrix.ReadRecoding(f)
+	if err != nil {
+		return nil, fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return ls, nil
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	if w := m.Warnings(); len(w) > 0 {
+		// skipped rows would be lost when writing the file
+		return fmt.Errorf("while reading file %q: %v (see 'phydata obs validate')", name, w[0])
+	}
+	return nil
+}
+
+func writeObs(name string, m *matrix.Matrix) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: character observations\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Observations))
+	if err := m.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package recode_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/js-arias/phydata/cmd/phydata/obs/recode"
+)
+
+func TestRecodeTaxonMismatch(t *testing.T) {
+	dir := t.TempDir()
+	obs := "taxon\tspecimen\tcharacter\tstate\n" +
+		"Homo sapiens\tsp:1\tincisors\tpresent\n" +
+		"Mus musculus\tsp:1\tcanines\tabsent\n"
+	writeFile(t, filepath.Join(dir, "obs.tab"), obs)
+	writeFile(t, filepath.Join(dir, "project.tab"), "dataset\tpath\nobservations\t"+filepath.Join(dir, "obs.tab")+"\n")
+	writeFile(t, filepath.Join(dir, "recode.tab"), "character\tstate\tbinary\n"+
+		"dentition\tincisors\tincisors\n"+
+		"dentition\tcanines\tcanines\n")
+
+	recode.Command.SetStdout(io.Discard)
+	recode.Command.SetStderr(io.Discard)
+	err := recode.Command.Execute([]string{filepath.Join(dir, "project.tab"), filepath.Join(dir, "recode.tab")})
+	if err == nil {
+		t.Fatalf("recode: expecting error on specimen with a different taxon")
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "obs.tab"))
+	if err != nil {
+		t.Fatalf("unable to read observations: %v", err)
+	}
+	if string(got) != obs {
+		t.Errorf("observations file modified:\n%s", got)
+	}
+}
+
+func writeFile(t testing.TB, name, data string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+}
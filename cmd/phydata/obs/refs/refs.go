@@ -0,0 +1,240 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package refs implements a command to print the references
+// of the observations in a PhyData project.
+package refs
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: "refs [--expected <file>] [--tsv] [--json] <project-file>",
+	Short: "print references of the observations",
+	Long: `
+Command refs reads a PhyData project and print the list of references of the
+observations stored in the project, so it can be used to audit the sources
+of the data of the matrix.
+
+The argument of the command is the name of the project-file.
+
+For each reference, it prints the number of observations, the number of taxa,
+and the number of characters with observations from the reference (unknown
+observations are not counted). References are compared without
+distinguishing between upper and lower case letters. Observations without a
+reference are counted in a row with an empty reference.
+
+The flag --expected defines a file with the references that should be in the
+project (e.g., the papers of a bibliography). In the file each line will be
+read as a reference. Blank lines and lines starting with '#' will be ignored.
+References of the file without observations are printed with zero counts, so
+they are the references that still need to be imported.
+
+By default, the output is formatted as a table for reading in the terminal.
+Use the flag --tsv to print the output as a TSV table, or the flag --json to
+print the output as a JSON array, with an object for each reference.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var expected string
+var tsvFlag bool
+var jsonFlag bool
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&expected, "expected", "", "")
+	c.Flags().BoolVar(&tsvFlag, "tsv", false, "")
+	c.Flags().BoolVar(&jsonFlag, "json", false, "")
+}
+
+// A refData is the number of observations,
+// taxa,
+// and characters
+// of a reference.
+type refData struct {
+	Reference    string `json:"reference"`
+	Observations int    `json:"observations"`
+	Taxa         int    `json:"taxa"`
+	Characters   int    `json:"characters"`
+
+	taxa  map[string]bool
+	chars map[string]bool
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if tsvFlag && jsonFlag {
+		return c.UsageError("flag --tsv is incompatible with flag --json")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	mf := p.Path(project.Observations)
+	if mf == "" {
+		return fmt.Errorf("undefined observations file")
+	}
+	m := matrix.New()
+	if err := readObsFile(mf, m); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	refs := make(map[string]*refData)
+	getRef := func(ref string) *refData {
+		key := strings.ToLower(ref)
+		r, ok := refs[key]
+		if !ok {
+			r = &refData{
+				Reference: ref,
+				taxa:      make(map[string]bool),
+				chars:     make(map[string]bool),
+			}
+			refs[key] = r
+		}
+		return r
+	}
+
+	if expected != "" {
+		ls, err := readRefs(expected)
+		if err != nil {
+			return err
+		}
+		for _, ref := range ls {
+			getRef(ref)
+		}
+	}
+
+	chars := m.Chars()
+	for _, tx := range m.Taxa() {
+		for _, sp := range m.TaxSpec(tx) {
+			for _, char := range chars {
+				for _, st := range m.Obs(sp, char) {
+					if st == matrix.Unknown {
+						continue
+					}
+					r := getRef(m.Val(sp, char, st, matrix.Reference))
+					r.Observations++
+					r.taxa[tx] = true
+					r.chars[char] = true
+				}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(refs))
+	for k := range refs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	data := make([]refData, 0, len(keys))
+	for _, k := range keys {
+		r := refs[k]
+		r.Taxa = len(r.taxa)
+		r.Characters = len(r.chars)
+		data = append(data, *r)
+	}
+
+	if jsonFlag {
+		enc := json.NewEncoder(c.Stdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(data); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+		return nil
+	}
+
+	rows := [][]string{{"reference", "observations", "taxa", "characters"}}
+	for _, d := range data {
+		rows = append(rows, []string{
+			d.Reference,
+			strconv.Itoa(d.Observations),
+			strconv.Itoa(d.Taxa),
+			strconv.Itoa(d.Characters),
+		})
+	}
+
+	if tsvFlag {
+		tab := csv.NewWriter(c.Stdout())
+		tab.Comma = '\t'
+		tab.UseCRLF = true
+		if err := tab.WriteAll(rows); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.Stdout(), 0, 0, 2, ' ', 0)
+	for _, r := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r[0], r[1], r[2], r[3])
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func readRefs(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var refs []string
+	for i := 1; ; i++ {
+		ln, err := r.ReadString('\n')
+		if errors.Is(err, io.EOF) && ln == "" {
+			break
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("on file %q: line %d: %v", name, i, err)
+		}
+
+		n := strings.Join(strings.Fields(ln), " ")
+		if n == "" {
+			continue
+		}
+		if n[0] == '#' {
+			continue
+		}
+		refs = append(refs, n)
+	}
+
+	return refs, nil
+}
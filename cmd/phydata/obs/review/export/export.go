@@ -0,0 +1,111 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package export implements a command to export
+// the character observations of a PhyData project
+// as a review grid.
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: "export [-o|--output <file>] <project-file>",
+	Short: "export a review grid",
+	Long: `
+Command export reads a PhyData project and writes the character observations
+as a review grid, a TSV file that can be opened in a spreadsheet to proofread
+the codings.
+
+The argument of the command is the name of the project file.
+
+The review grid has a row for each specimen, and a column for each character.
+The first column is the taxon name, and the second column the specimen ID.
+The specimen ID and the character name are the stable ID of each cell, so
+the rows and columns can be reordered, but the specimen IDs and the character
+names should not be edited. Cells contain the state names, with polymorphic
+observations separated by '/'. A question mark '?' is used for unknown
+states, and a dash '-' for inapplicable characters.
+
+After the review, use the command 'obs review import' to update the project
+with the changed cells.
+
+By default, the output is printed in the standard output. Use the flag
+--output, or -o, to define an output file.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var output string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+}
+
+func run(c *command.Command, args []string) (err error) {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	mf := p.Path(project.Observations)
+	if mf == "" {
+		return fmt.Errorf("undefined observations file")
+	}
+	m := matrix.New()
+	if err := readObsFile(mf, m); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	out := c.Stdout()
+	if output != "" {
+		var f *os.File
+		f, err = os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			e := f.Close()
+			if e != nil && err == nil {
+				err = e
+			}
+		}()
+		out = f
+	}
+
+	bw := bufio.NewWriter(out)
+	if err := m.Review(bw); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package reimport implements a command to import
+// a reviewed grid of character observations
+// into a PhyData project.
+package reimport
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `import --reviewer <name>
+	<project-file> <grid-file>`,
+	Short: "import a reviewed grid",
+	Long: `
+Command import reads a review grid, as written by 'obs review export' and
+edited in a spreadsheet, and updates the observations of a PhyData project
+with the cells that were changed.
+
+The first argument of the command is the name of the project file. The second
+argument is the name of the reviewed grid. The grid can be delimited by tabs,
+commas, or semicolons.
+
+The flag --reviewer is required, and defines the name of the reviewer, that
+will be recorded in the 'reviewer' field of the updated observations.
+
+Only the cells with a content different from the observations stored in the
+project are updated, so the cells that were not changed by the reviewer will
+be kept as they are in the project, even if the project was modified after
+the grid was exported. The reference, image, and comments of the states that
+were already present in a cell are kept. Empty cells are ignored. Use a
+question mark '?' to remove the observations of a cell.
+
+The number of updated cells is printed in the standard output.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var reviewer string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&reviewer, "reviewer", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if len(args) < 2 {
+		return c.UsageError("expecting grid file")
+	}
+	reviewer = strings.Join(strings.Fields(reviewer), " ")
+	if reviewer == "" {
+		return c.UsageError("flag --reviewer must be defined")
+	}
+
+	unlock, err := lock.Project(args[0])
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	mf := p.Path(project.Observations)
+	if mf == "" {
+		return fmt.Errorf("undefined observations file")
+	}
+	m := matrix.New()
+	if err := readObsFile(mf, m); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	n, err := readGrid(args[1], m)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.Stdout(), "%d cells updated\n", n)
+	if n == 0 {
+		return nil
+	}
+
+	if p.ReadOnly(project.Observations, mf) {
+		return fmt.Errorf("on project %q: file %q is read-only", args[0], p.Source(project.Observations, mf))
+	}
+	if err := writeObs(mf, m); err != nil {
+		return err
+	}
+	return nil
+}
+
+func readGrid(name string, m *matrix.Matrix) (int, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	n, err := m.ReadReview(f, reviewer)
+	if err != nil {
+		return 0, fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return n, nil
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	if w := m.Warnings(); len(w) > 0 {
+		// skipped rows would be lost when writing the file
+		return fmt.Errorf("while reading file %q: %v (see 'phydata obs validate')", name, w[0])
+	}
+	return nil
+}
+
+func writeObs(name string, m *matrix.Matrix) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: character observations\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Observations))
+	if err := m.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package review is a metapackage for commands
+// used to review character observations
+// in a spreadsheet.
+package review
+
+import (
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/obs/review/export"
+	"github.com/js-arias/phydata/cmd/phydata/obs/review/reimport"
+)
+
+func init() {
+	Command.Add(export.Command)
+	Command.Add(reimport.Command)
+}
+
+var Command = &command.Command{
+	Usage: "review <command> [<argument>...]",
+	Short: "commands to review observations in a spreadsheet",
+}
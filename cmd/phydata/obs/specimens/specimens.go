@@ -0,0 +1,112 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package specimens implements a command to print the specimens
+// with observations in a PhyData project.
+package specimens
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: "specimens [--taxon <name>] <project-file>",
+	Short: "print specimens",
+	Long: `
+Command specimens reads a PhyData project and print the list of specimens
+with observations stored in the project.
+
+The argument of the command is the name of the project-file.
+
+By default, the specimens of all taxa are printed. Use the flag --taxon to
+print only the specimens of a given taxon.
+
+The output is a TSV table with the specimen, its taxon, and the number of
+characters coded in the specimen (unknown and inapplicable observations are
+not counted).
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var taxonFlag string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&taxonFlag, "taxon", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	mf := p.Path(project.Observations)
+	if mf == "" {
+		return fmt.Errorf("undefined observations file")
+	}
+	m := matrix.New()
+	if err := readObsFile(mf, m); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	taxon := strings.Join(strings.Fields(taxonFlag), " ")
+
+	tab := csv.NewWriter(c.Stdout())
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	if err := tab.Write([]string{"specimen", "taxon", "characters"}); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+
+	chars := m.Chars()
+	for _, tx := range m.Taxa() {
+		if taxon != "" && !strings.EqualFold(tx, taxon) {
+			continue
+		}
+		for _, sp := range m.TaxSpec(tx) {
+			coded := 0
+			for _, ch := range chars {
+				obs := m.Obs(sp, ch)
+				if obs[0] == matrix.Unknown || obs[0] == matrix.NotApplicable {
+					continue
+				}
+				coded++
+			}
+			if err := tab.Write([]string{sp, tx, strconv.Itoa(coded)}); err != nil {
+				return fmt.Errorf("while writing data: %v", err)
+			}
+		}
+	}
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
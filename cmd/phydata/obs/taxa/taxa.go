@@ -7,8 +7,12 @@
 package taxa
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"text/tabwriter"
 
 	"github.com/js-arias/command"
 	"github.com/js-arias/phydata/matrix"
@@ -16,21 +20,52 @@ import (
 )
 
 var Command = &command.Command{
-	Usage: "taxa <project-file>",
+	Usage: "taxa [--tsv] [--json] <project-file>",
 	Short: "print taxa",
 	Long: `
 Command taxa reads a PhyData project and print the list of taxa with
 observations stored in the project.
 
 The argument of the command is the name of the project-file.
+
+For each taxon, it prints the number of specimens, the number of characters
+coded in any of the specimens of the taxon (unknown and inapplicable
+observations are not counted), and the completeness, i.e., the percentage of
+the characters of the project coded for the taxon.
+
+By default, the output is formatted as a table for reading in the terminal.
+Use the flag --tsv to print the output as a TSV table, or the flag --json to
+print the output as a JSON array, with an object for each taxon.
 	`,
-	Run: run,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var tsvFlag bool
+var jsonFlag bool
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&tsvFlag, "tsv", false, "")
+	c.Flags().BoolVar(&jsonFlag, "json", false, "")
+}
+
+// A taxonData is the number of specimens
+// and coded characters
+// of a taxon.
+type taxonData struct {
+	Taxon        string  `json:"taxon"`
+	Specimens    int     `json:"specimens"`
+	Characters   int     `json:"characters"`
+	Completeness float64 `json:"completeness"`
 }
 
 func run(c *command.Command, args []string) error {
 	if len(args) < 1 {
 		return c.UsageError("expecting project file")
 	}
+	if tsvFlag && jsonFlag {
+		return c.UsageError("flag --tsv is incompatible with flag --json")
+	}
 
 	p, err := project.Read(args[0])
 	if err != nil {
@@ -46,10 +81,61 @@ func run(c *command.Command, args []string) error {
 		return fmt.Errorf("on project %q: %v", args[0], err)
 	}
 
+	nc := m.NumChars()
+	var data []taxonData
 	for _, tx := range m.Taxa() {
-		fmt.Fprintf(c.Stdout(), "%s\n", tx)
+		coded := m.Coded(tx)
+		var comp float64
+		if nc > 0 {
+			comp = float64(coded) * 100 / float64(nc)
+		}
+		data = append(data, taxonData{
+			Taxon:        tx,
+			Specimens:    len(m.TaxSpec(tx)),
+			Characters:   coded,
+			Completeness: comp,
+		})
 	}
 
+	if jsonFlag {
+		if data == nil {
+			data = []taxonData{}
+		}
+		enc := json.NewEncoder(c.Stdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(data); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+		return nil
+	}
+
+	rows := [][]string{{"taxon", "specimens", "characters", "completeness"}}
+	for _, d := range data {
+		rows = append(rows, []string{
+			d.Taxon,
+			strconv.Itoa(d.Specimens),
+			strconv.Itoa(d.Characters),
+			strconv.FormatFloat(d.Completeness, 'f', 1, 64),
+		})
+	}
+
+	if tsvFlag {
+		tab := csv.NewWriter(c.Stdout())
+		tab.Comma = '\t'
+		tab.UseCRLF = true
+		if err := tab.WriteAll(rows); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.Stdout(), 0, 0, 2, ' ', 0)
+	for _, r := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r[0], r[1], r[2], r[3])
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
 	return nil
 }
 
@@ -0,0 +1,168 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package apply implements a command to add
+// the characters of a template
+// to a PhyData project.
+package apply
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/characters"
+	"github.com/js-arias/phydata/characters/templates"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `apply [-f|--file <metadata-file>]
+	<project-file> <template>...`,
+	Short: "add the characters of a template to a project",
+	Long: `
+Command apply reads one or more character templates, and adds its characters
+to the character metadata of a PhyData project, so the project starts with a
+consistent naming of the characters and its states.
+
+The first argument of the command is the name of the project file. If no
+project file exists, a new project will be created.
+
+The following arguments are the templates that will be added. A template can
+be the name of a template provided by PhyData (use 'phydata obs template
+list' to see the available templates), or the name of a character metadata
+file (see 'phydata obs meta'). If there is a file with the given name, the
+file will be used.
+
+The templates define the characters, its states, and its metadata (e.g., the
+anatomical region, or the dependencies between characters). Values already
+defined in the project are not replaced by empty values of the templates.
+
+By default, the metadata will be stored in the characters file currently
+defined for the project. If the project does not have a characters file, a
+new one will be created with the name 'characters.tab'. A different file name
+can be defined using the flag --file or -f.
+
+The command prints the number of new characters added to the project.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var charFile string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&charFile, "file", "", "")
+	c.Flags().StringVar(&charFile, "f", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if len(args) < 2 {
+		return c.UsageError("expecting template")
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := openProject(pFile)
+	if err != nil {
+		return err
+	}
+
+	cat := characters.New()
+	if cf := p.Path(project.Characters); cf != "" {
+		if err := readCharFile(cf, cat); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+	prev := len(cat.Chars())
+
+	for _, t := range args[1:] {
+		if _, err := os.Stat(t); err == nil {
+			if err := readCharFile(t, cat); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := templates.Read(t, cat); err != nil {
+			return err
+		}
+	}
+
+	if charFile == "" {
+		charFile = p.Path(project.Characters)
+		if charFile == "" {
+			charFile = "characters.tab"
+		}
+	}
+	if p.ReadOnly(project.Characters, charFile) {
+		return fmt.Errorf("on project %q: file %q is read-only", pFile, p.Source(project.Characters, charFile))
+	}
+	if err := writeChars(charFile, cat); err != nil {
+		return err
+	}
+
+	p.Add(project.Characters, charFile)
+	if err := p.Write(pFile); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Stdout(), "%d new characters\n", len(cat.Chars())-prev)
+	return nil
+}
+
+func openProject(name string) (*project.Project, error) {
+	p, err := project.Read(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return project.New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable ot open project %q: %v", name, err)
+	}
+	return p, nil
+}
+
+func readCharFile(name string, c *characters.Catalog) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := c.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeChars(name string, c *characters.Catalog) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: character metadata\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Characters))
+	if err := c.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
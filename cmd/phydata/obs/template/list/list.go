@@ -0,0 +1,79 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package list implements a command to list
+// the available character templates.
+package list
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/characters"
+	"github.com/js-arias/phydata/characters/templates"
+)
+
+var Command = &command.Command{
+	Usage: "list [<template>]",
+	Short: "list the available character templates",
+	Long: `
+Command list prints the character templates available in PhyData.
+
+If no argument is given, the output is a TSV table with the name of each
+template, the number of characters, and the description of the template.
+
+If the name of a template is given, the output is a TSV table with the
+characters and states of the template.
+	`,
+	Run: run,
+}
+
+func run(c *command.Command, args []string) error {
+	tab := csv.NewWriter(c.Stdout())
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	if len(args) > 0 {
+		cat := characters.New()
+		if err := templates.Read(args[0], cat); err != nil {
+			return err
+		}
+		if err := tab.Write([]string{"character", "state"}); err != nil {
+			return fmt.Errorf("while writing header: %v", err)
+		}
+		for _, ch := range cat.Chars() {
+			for _, s := range cat.States(ch) {
+				if err := tab.Write([]string{ch, s}); err != nil {
+					return fmt.Errorf("while writing data: %v", err)
+				}
+			}
+		}
+	} else {
+		if err := tab.Write([]string{"template", "characters", "description"}); err != nil {
+			return fmt.Errorf("while writing header: %v", err)
+		}
+		for _, name := range templates.Names() {
+			cat := characters.New()
+			if err := templates.Read(name, cat); err != nil {
+				return err
+			}
+			row := []string{
+				name,
+				strconv.Itoa(len(cat.Chars())),
+				templates.Description(name),
+			}
+			if err := tab.Write(row); err != nil {
+				return fmt.Errorf("while writing data: %v", err)
+			}
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
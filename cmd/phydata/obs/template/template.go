@@ -0,0 +1,24 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package template is a metapackage for commands
+// used to seed the characters of a project
+// from templates of common character suites.
+package template
+
+import (
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/obs/template/apply"
+	"github.com/js-arias/phydata/cmd/phydata/obs/template/list"
+)
+
+func init() {
+	Command.Add(apply.Command)
+	Command.Add(list.Command)
+}
+
+var Command = &command.Command{
+	Usage: "template <command> [<argument>...]",
+	Short: "commands for character templates",
+}
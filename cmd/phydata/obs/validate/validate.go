@@ -0,0 +1,313 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package validate implements a command to check
+// the coding of the observations
+// in a PhyData project.
+package validate
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/characters"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: "validate [--set-na] [--symbols <string>] <project-file>",
+	Short: "check the coding of observations",
+	Long: `
+Command validate reads the observations of a PhyData project and reports
+possible problems in the coding of the characters.
+
+The argument of the command is the name of the project file.
+
+The following problems are reported:
+
+	single-state	a character with a single observed state.
+	singleton	a state observed in only one specimen.
+	states		a character with more states than the symbols that can
+			be used to export it.
+	punctuation	a character whose name differs from another character
+			only in punctuation.
+	taxon		a specimen assigned to different taxa in the
+			observations file.
+	dependency	a character coded with a state in a specimen in which
+			its controlling character makes it inapplicable, or
+			coded as inapplicable when the controlling character
+			makes it applicable.
+
+Rows of the observations file with a specimen assigned to a different taxon
+than in a previous row are skipped, so the other problems are reported
+without those rows.
+
+The output is a TSV table with the kind of the problem, the character, the
+state, the specimen, and a description of the problem. Unknown and
+inapplicable observations are ignored.
+
+The dependencies between characters are defined in the characters file of the
+project (see 'phydata obs meta'), with the fields 'depends-on' (the name of
+the controlling character) and 'applicable-if' (the states of the controlling
+character, separated by semicolons, in which the character is applicable).
+Specimens with an unknown state for the controlling character are not
+checked.
+
+By default, the symbols used to export the states are the ones used by
+'phydata matrix' (the 32 symbols '0123456789ABCDEFGHIJKLMNOPQRSTUV'). Use
+the flag --symbols to define the symbols, as in the --symbols flag of
+'phydata matrix' (e.g., use '0123456789' to check the characters that can
+be exported together with DNA sequences).
+
+Use the flag --set-na to set as inapplicable the unknown observations of the
+dependent characters, in the specimens in which the controlling character
+makes them inapplicable. Coded observations are never changed, so violations
+should be fixed by hand. The modified observations are saved in the
+observations file of the project.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var setNA bool
+var symbols string
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&setNA, "set-na", false, "")
+	c.Flags().StringVar(&symbols, "symbols", matrix.DefaultSymbols, "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	unlock, err := lock.Project(args[0])
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	mf := p.Path(project.Observations)
+	if mf == "" {
+		return fmt.Errorf("undefined observations file")
+	}
+	m := matrix.New()
+	if err := readObsFile(mf, m); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+	if err := m.SetSymbols(symbols); err != nil {
+		return c.UsageError(fmt.Sprintf("flag --symbols: %v", err))
+	}
+	// maximum number of states
+	// that can be exported into a matrix
+	maxStates := len([]rune(m.Symbols()))
+
+	var rows [][]string
+	for _, w := range m.Warnings() {
+		var te *matrix.TaxonError
+		if !errors.As(w, &te) {
+			continue
+		}
+		rows = append(rows, []string{"taxon", "", "", te.Spec, fmt.Sprintf("%v: row skipped", w)})
+	}
+
+	cat := characters.New()
+	if cf := p.Path(project.Characters); cf != "" {
+		if err := readCharFile(cf, cat); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+	}
+	if setNA {
+		// dependent characters can control other characters,
+		// so it is repeated until no cell is modified.
+		var total int
+		for {
+			var n int
+			for _, ch := range cat.Chars() {
+				parent, states := cat.Dependency(ch)
+				if parent == "" {
+					continue
+				}
+				n += m.SetInapplicable(ch, parent, states)
+			}
+			if n == 0 {
+				break
+			}
+			total += n
+		}
+		if total > 0 {
+			if len(rows) > 0 {
+				// skipped rows would be lost when writing the file
+				return fmt.Errorf("on project %q: file %q: specimens assigned to different taxa: observations not saved", args[0], mf)
+			}
+			if p.ReadOnly(project.Observations, mf) {
+				return fmt.Errorf("on project %q: file %q is read-only", args[0], p.Source(project.Observations, mf))
+			}
+			if err := writeObs(mf, m); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(c.Stderr(), "%d observations set as inapplicable\n", total)
+	}
+
+	specs := m.Specimens()
+	for _, ch := range m.Chars() {
+		states := make(map[string][]string)
+		for _, sp := range specs {
+			for _, o := range m.Obs(sp, ch) {
+				if o == matrix.Unknown || o == matrix.NotApplicable {
+					continue
+				}
+				states[o] = append(states[o], sp)
+			}
+		}
+		if len(states) == 1 {
+			for s := range states {
+				rows = append(rows, []string{"single-state", ch, s, "", "only one state observed"})
+			}
+		}
+		if len(states) > maxStates {
+			rows = append(rows, []string{"states", ch, "", "", fmt.Sprintf("%d states, expecting at most %d", len(states), maxStates)})
+		}
+		if len(states) < 2 {
+			continue
+		}
+		ls := make([]string, 0, len(states))
+		for s := range states {
+			ls = append(ls, s)
+		}
+		slices.Sort(ls)
+		for _, s := range ls {
+			if len(states[s]) > 1 {
+				continue
+			}
+			rows = append(rows, []string{"singleton", ch, s, states[s][0], "state observed in a single specimen"})
+		}
+	}
+
+	similar := make(map[string][]string)
+	for _, ch := range m.Chars() {
+		k := noPunct(ch)
+		similar[k] = append(similar[k], ch)
+	}
+	for _, ch := range m.Chars() {
+		for _, o := range similar[noPunct(ch)] {
+			if o == ch {
+				continue
+			}
+			rows = append(rows, []string{"punctuation", ch, "", "", fmt.Sprintf("similar to: %s", o)})
+		}
+	}
+
+	for _, ch := range cat.Chars() {
+		parent, states := cat.Dependency(ch)
+		if parent == "" {
+			continue
+		}
+		for _, v := range m.CheckDependency(ch, parent, states) {
+			ps := strings.Join(v.ParentStates, "/")
+			if v.States[0] == matrix.NotApplicable {
+				rows = append(rows, []string{"dependency", ch, v.States[0], v.Spec, fmt.Sprintf("inapplicable, but '%s' is %s", parent, ps)})
+				continue
+			}
+			rows = append(rows, []string{"dependency", ch, strings.Join(v.States, "/"), v.Spec, fmt.Sprintf("applicable only if '%s' is %s, found %s", parent, strings.Join(states, "/"), ps)})
+		}
+	}
+
+	return writeRows(c.Stdout(), rows)
+}
+
+func writeRows(w io.Writer, rows [][]string) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	if err := tab.Write([]string{"problem", "character", "state", "specimen", "comment"}); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+	for _, row := range rows {
+		if err := tab.Write(row); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+	}
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+// NoPunct returns a character name
+// without punctuation marks.
+func noPunct(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) {
+			return ' '
+		}
+		return r
+	}, name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func readCharFile(name string, c *characters.Catalog) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := c.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeObs(name string, m *matrix.Matrix) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: character observations\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Observations))
+	if err := m.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
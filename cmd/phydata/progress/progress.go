@@ -0,0 +1,119 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package progress prints progress messages
+// of long-running commands
+// in the standard error,
+// when the global flag --verbose is defined.
+//
+// Each message starts with the elapsed time
+// since the start of the program.
+package progress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Verbose is set by the global flag --verbose.
+// If false,
+// no progress message is printed.
+var Verbose bool
+
+// Out is the writer of the progress messages.
+var out io.Writer = os.Stderr
+
+// Start is the start time of the program.
+var start = time.Now()
+
+// Interval is the minimum time
+// between two messages of a reader,
+// or a counter.
+const interval = time.Second
+
+// Printf prints a progress message.
+func Printf(format string, a ...any) {
+	if !Verbose {
+		return
+	}
+	fmt.Fprintf(out, "[%8.2fs] %s\n", time.Since(start).Seconds(), fmt.Sprintf(format, a...))
+}
+
+// Reader returns a reader
+// that prints the number of lines read
+// from a file,
+// at most once a second.
+// If the global flag --verbose is not defined,
+// it returns the original reader.
+func Reader(r io.Reader, name string) io.Reader {
+	if !Verbose {
+		return r
+	}
+	return &reader{
+		r:    r,
+		name: name,
+		last: time.Now(),
+	}
+}
+
+type reader struct {
+	r     io.Reader
+	name  string
+	lines int
+	last  time.Time
+	done  bool
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.lines += bytes.Count(p[:n], []byte{'\n'})
+	if time.Since(r.last) >= interval {
+		r.last = time.Now()
+		Printf("reading %q: %d lines", r.name, r.lines)
+	}
+	if err == io.EOF && !r.done {
+		r.done = true
+		Printf("read %q: %d lines", r.name, r.lines)
+	}
+	return n, err
+}
+
+// A Counter reports the progress of a loop
+// over a known number of items.
+type Counter struct {
+	what  string
+	n     int
+	total int
+	last  time.Time
+}
+
+// NewCounter returns a new counter
+// for a given number of items.
+func NewCounter(what string, total int) *Counter {
+	return &Counter{
+		what:  what,
+		total: total,
+		last:  time.Now(),
+	}
+}
+
+// Add adds items to the counter,
+// and prints the number of processed items,
+// at most once a second.
+func (c *Counter) Add(n int) {
+	c.n += n
+	if time.Since(c.last) < interval {
+		return
+	}
+	c.last = time.Now()
+	Printf("%s: %d of %d", c.what, c.n, c.total)
+}
+
+// Done prints the number of processed items.
+func (c *Counter) Done() {
+	Printf("%s: %d of %d done", c.what, c.n, c.total)
+}
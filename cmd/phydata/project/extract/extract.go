@@ -0,0 +1,485 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package extract implements a command to create a new project
+// with a subset of the taxa of a PhyData project.
+package extract
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/matrix/protein"
+	"github.com/js-arias/phydata/project"
+	"github.com/js-arias/phydata/specimen"
+)
+
+var Command = &command.Command{
+	Usage: `extract --taxa <file> -o|--output <new-project>
+	[--chars <file>] [--genes <file>]
+	<project-file>`,
+	Short: "extract a sub-project",
+	Long: `
+Command extract reads a PhyData project, and creates a new project that only
+contains the data of a list of taxa. It can be used to share a subset of the
+data with collaborators.
+
+The argument of the command is the name of the project file.
+
+The flag --taxa is required, and defines a file with the taxa that will be
+included in the new project. In the file each line will be read as a taxon
+name. Blank lines and lines starting with '#' will be ignored.
+
+The flag --output, or -o, is required, and defines the name of the new
+project file. The datasets of the new project (observations, DNA, proteins,
+and specimens) will be written in the same directory of the new project
+file, using the name of the dataset with the extension '.tab' (e.g.,
+'observations.tab'). If any of these files already exists, the command will
+fail, so the files of the source project are never overwritten.
+
+By default, all characters and genes of the taxa are copied. Use the flag
+--chars to define a file with the characters that will be copied, and the
+flag --genes to define a file with the genes that will be copied. In both
+files, each line will be read as a character (or gene) name. Blank lines and
+lines starting with '#' will be ignored.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var taxaFile string
+var output string
+var charFile string
+var geneFile string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&taxaFile, "taxa", "", "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+	c.Flags().StringVar(&charFile, "chars", "", "")
+	c.Flags().StringVar(&geneFile, "genes", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if taxaFile == "" {
+		return c.UsageError("expecting --taxa flag")
+	}
+	if output == "" {
+		return c.UsageError("expecting --output flag")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	taxa, err := readFilter(taxaFile)
+	if err != nil {
+		return err
+	}
+	var chars map[string]bool
+	if charFile != "" {
+		chars, err = readFilter(charFile)
+		if err != nil {
+			return err
+		}
+	}
+	var genes map[string]bool
+	if geneFile != "" {
+		genes, err = readFilter(geneFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	dir := filepath.Dir(output)
+	np := project.New()
+	var files []string
+	datasetFile := func(set project.Dataset) (string, error) {
+		name := filepath.Join(dir, string(set)+".tab")
+		if _, err := os.Stat(name); err == nil {
+			return "", fmt.Errorf("file %q already exists", name)
+		}
+		np.Add(set, name)
+		files = append(files, name)
+		return name, nil
+	}
+
+	var m *matrix.Matrix
+	if mf := p.Path(project.Observations); mf != "" {
+		m = matrix.New()
+		if err := readObsFile(mf, m); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+	}
+	var coll *dna.Collection
+	if p.Path(project.DNA) != "" {
+		coll = dna.New()
+		if _, err := dnafiles.Read(p, coll); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+	}
+	var prot *protein.Collection
+	if pf := p.Path(project.Proteins); pf != "" {
+		prot = protein.New()
+		if err := readProteinFile(pf, prot); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+	}
+	var reg *specimen.Registry
+	if sf := p.Path(project.Specimens); sf != "" {
+		reg = specimen.New()
+		if err := readSpecFile(sf, reg); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+	}
+
+	// check the files before writing any data
+	if m != nil {
+		if _, err := datasetFile(project.Observations); err != nil {
+			return err
+		}
+	}
+	if coll != nil {
+		if _, err := datasetFile(project.DNA); err != nil {
+			return err
+		}
+	}
+	if prot != nil {
+		if _, err := datasetFile(project.Proteins); err != nil {
+			return err
+		}
+	}
+	if reg != nil {
+		if _, err := datasetFile(project.Specimens); err != nil {
+			return err
+		}
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("on project %q: no datasets to extract", args[0])
+	}
+
+	if m != nil {
+		nm := extractObs(m, taxa, chars)
+		if err := writeObs(np.Path(project.Observations), nm); err != nil {
+			return err
+		}
+	}
+	if coll != nil {
+		nc, err := extractDNA(coll, taxa, genes)
+		if err != nil {
+			return err
+		}
+		if err := writeDNA(np.Path(project.DNA), nc); err != nil {
+			return err
+		}
+	}
+	if prot != nil {
+		nc, err := extractProteins(prot, taxa, genes)
+		if err != nil {
+			return err
+		}
+		if err := writeProteins(np.Path(project.Proteins), nc); err != nil {
+			return err
+		}
+	}
+	if reg != nil {
+		nr := extractSpecimens(reg, taxa)
+		if err := writeSpecimens(np.Path(project.Specimens), nr); err != nil {
+			return err
+		}
+	}
+
+	if err := np.Write(output); err != nil {
+		return err
+	}
+	return nil
+}
+
+func extractObs(m *matrix.Matrix, taxa, chars map[string]bool) *matrix.Matrix {
+	fields := []matrix.Field{matrix.Reference, matrix.ImageLink, matrix.Comments, matrix.Reviewer, matrix.AddedBy, matrix.Timestamp, matrix.Uncertain, matrix.Source}
+	nm := matrix.New()
+	for _, tax := range m.Taxa() {
+		if !taxa[strings.ToLower(tax)] {
+			continue
+		}
+		for _, spec := range m.TaxSpec(tax) {
+			for _, char := range m.Chars() {
+				if chars != nil && !chars[char] {
+					continue
+				}
+				for _, st := range m.Obs(spec, char) {
+					nm.Add(tax, spec, char, st)
+					for _, f := range fields {
+						nm.Set(spec, char, st, m.Val(spec, char, st, f), f)
+					}
+				}
+			}
+		}
+	}
+	return nm
+}
+
+func extractDNA(coll *dna.Collection, taxa, genes map[string]bool) (*dna.Collection, error) {
+	fields := []dna.Field{
+		dna.Aligned,
+		dna.Protein,
+		dna.Organelle,
+		dna.Reference,
+		dna.Comments,
+		dna.Start,
+		dna.End,
+		dna.Strand,
+		dna.Molecule,
+		dna.Structure,
+		dna.Quality,
+		dna.Trace,
+		dna.Run,
+		dna.Primers,
+	}
+	nc := dna.New()
+	for _, tax := range coll.Taxa() {
+		if !taxa[strings.ToLower(tax)] {
+			continue
+		}
+		for _, spec := range coll.TaxSpec(tax) {
+			for _, gene := range coll.SpecGene(spec) {
+				if genes != nil && !genes[gene] {
+					continue
+				}
+				for _, acc := range coll.GeneAccession(spec, gene) {
+					if err := nc.Add(tax, spec, gene, acc, coll.Sequence(spec, gene, acc)); err != nil {
+						return nil, fmt.Errorf("when adding %q (%s, %s): %v", acc, gene, tax, err)
+					}
+					for _, f := range fields {
+						nc.Set(spec, gene, acc, coll.Val(spec, gene, acc, f), f)
+					}
+				}
+			}
+		}
+	}
+	return nc, nil
+}
+
+func extractProteins(coll *protein.Collection, taxa, genes map[string]bool) (*protein.Collection, error) {
+	fields := []protein.Field{protein.Reference, protein.Comments}
+	nc := protein.New()
+	for _, tax := range coll.Taxa() {
+		if !taxa[strings.ToLower(tax)] {
+			continue
+		}
+		for _, spec := range coll.TaxSpec(tax) {
+			for _, gene := range coll.SpecGene(spec) {
+				if genes != nil && !genes[gene] {
+					continue
+				}
+				for _, acc := range coll.GeneAccession(spec, gene) {
+					if err := nc.Add(tax, spec, gene, acc, coll.Sequence(spec, gene, acc)); err != nil {
+						return nil, fmt.Errorf("when adding %q (%s, %s): %v", acc, gene, tax, err)
+					}
+					for _, f := range fields {
+						nc.Set(spec, gene, acc, coll.Val(spec, gene, acc, f), f)
+					}
+				}
+			}
+		}
+	}
+	return nc, nil
+}
+
+func extractSpecimens(reg *specimen.Registry, taxa map[string]bool) *specimen.Registry {
+	fields := []specimen.Field{
+		specimen.Voucher,
+		specimen.Latitude,
+		specimen.Longitude,
+		specimen.Locality,
+		specimen.Collector,
+		specimen.Date,
+		specimen.Comments,
+	}
+	nr := specimen.New()
+	for _, tax := range reg.Taxa() {
+		if !taxa[strings.ToLower(tax)] {
+			continue
+		}
+		for _, spec := range reg.TaxSpec(tax) {
+			nr.Add(tax, spec)
+			for _, f := range fields {
+				nr.Set(spec, reg.Val(spec, f), f)
+			}
+		}
+	}
+	return nr
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	if w := m.Warnings(); len(w) > 0 {
+		// skipped rows would be lost when writing the file
+		return fmt.Errorf("while reading file %q: %v (see 'phydata obs validate')", name, w[0])
+	}
+	return nil
+}
+
+func readProteinFile(name string, c *protein.Collection) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := c.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func readSpecFile(name string, r *specimen.Registry) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := r.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeObs(name string, m *matrix.Matrix) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: character observations\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Observations))
+	if err := m.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeDNA(name string, c *dna.Collection) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: DNA sequences\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.DNA))
+	if err := c.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeProteins(name string, c *protein.Collection) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: amino acid sequences\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Proteins))
+	if err := c.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeSpecimens(name string, r *specimen.Registry) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: specimen records\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Specimens))
+	if err := r.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
+
+func readFilter(name string) (map[string]bool, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	filter := make(map[string]bool)
+	for i := 1; ; i++ {
+		ln, err := r.ReadString('\n')
+		if errors.Is(err, io.EOF) && ln == "" {
+			break
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("on file %q: line %d: %v", name, i, err)
+		}
+
+		n := strings.Join(strings.Fields(ln), " ")
+		if n == "" {
+			continue
+		}
+		if n[0] == '#' {
+			continue
+		}
+		filter[strings.ToLower(n)] = true
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,204 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package importer implements a command to create
+// a PhyData project from a JSON document.
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/ages"
+	"github.com/js-arias/phydata/characters"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/matrix/protein"
+	"github.com/js-arias/phydata/project"
+	"github.com/js-arias/phydata/specimen"
+	"github.com/js-arias/phydata/taxonomy"
+)
+
+var Command = &command.Command{
+	Usage: "import -o|--output <new-project> <json-file>",
+	Short: "import a project from a JSON document",
+	Long: `
+Command import reads a JSON document with the datasets of a PhyData project
+(as written by the command 'phydata export json'), and creates a new project
+with these datasets.
+
+The argument of the command is the name of the JSON file.
+
+The flag --output, or -o, is required, and defines the name of the new
+project file. The datasets of the new project will be written in the same
+directory of the new project file, using the name of the dataset with the
+extension '.tab' (e.g., 'observations.tab'). If any of these files already
+exists, the command will fail.
+
+Before writing any file, the datasets are validated, so the command fails if
+any dataset has invalid data.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var output string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting JSON file")
+	}
+	if output == "" {
+		return c.UsageError("expecting --output flag")
+	}
+
+	doc, err := readDoc(args[0])
+	if err != nil {
+		return err
+	}
+	if len(doc.Datasets) == 0 {
+		return fmt.Errorf("on file %q: no datasets", args[0])
+	}
+
+	sets := make([]string, 0, len(doc.Datasets))
+	for s := range doc.Datasets {
+		sets = append(sets, s)
+	}
+	slices.Sort(sets)
+
+	// check and validate the datasets
+	// before writing any data
+	dir := filepath.Dir(output)
+	np := project.New()
+	data := make(map[string][]byte, len(sets))
+	for _, s := range sets {
+		name := filepath.Join(dir, s+".tab")
+		if _, err := os.Stat(name); err == nil {
+			return fmt.Errorf("file %q already exists", name)
+		}
+		b, err := tsvData(s, doc.Datasets[s])
+		if err != nil {
+			return fmt.Errorf("on file %q: dataset %q: %v", args[0], s, err)
+		}
+		if err := validate(project.Dataset(s), b); err != nil {
+			return fmt.Errorf("on file %q: dataset %q: %v", args[0], s, err)
+		}
+		data[s] = b
+		np.Add(project.Dataset(s), name)
+	}
+
+	for _, s := range sets {
+		if err := os.WriteFile(np.Path(project.Dataset(s)), data[s], 0o644); err != nil {
+			return err
+		}
+	}
+	if err := np.Write(output); err != nil {
+		return err
+	}
+	return nil
+}
+
+// A document is a JSON representation
+// of a project.
+type document struct {
+	Project  string             `json:"project"`
+	Created  string             `json:"created"`
+	Datasets map[string]dataset `json:"datasets"`
+}
+
+// A dataset is the JSON representation
+// of a TSV dataset file.
+type dataset struct {
+	File   string              `json:"file"`
+	Fields []string            `json:"fields"`
+	Rows   []map[string]string `json:"rows"`
+}
+
+func readDoc(name string) (document, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return document{}, err
+	}
+	defer f.Close()
+
+	var doc document
+	if err := json.NewDecoder(f).Decode(&doc); err != nil {
+		return document{}, fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return doc, nil
+}
+
+// TSVData returns a dataset as a TSV file.
+func tsvData(set string, ds dataset) ([]byte, error) {
+	if len(ds.Fields) == 0 {
+		return nil, fmt.Errorf("undefined fields")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# phydata: %s\n", set)
+	fmt.Fprintf(&buf, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	tab := csv.NewWriter(&buf)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	if err := tab.Write(ds.Fields); err != nil {
+		return nil, fmt.Errorf("while writing header: %v", err)
+	}
+	for _, r := range ds.Rows {
+		row := make([]string, len(ds.Fields))
+		for i, f := range ds.Fields {
+			row[i] = r[f]
+		}
+		if err := tab.Write(row); err != nil {
+			return nil, fmt.Errorf("while writing data: %v", err)
+		}
+	}
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return nil, fmt.Errorf("while writing data: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Validate reads a dataset
+// with the reader of its kind of data.
+// Datasets without a reader are not validated.
+func validate(set project.Dataset, data []byte) error {
+	var read func(io.Reader) error
+	switch set {
+	case project.Ages:
+		read = ages.New().ReadTSV
+	case project.Characters:
+		read = characters.New().ReadTSV
+	case project.DNA:
+		read = dna.New().ReadTSV
+	case project.Exclusions:
+		read = dna.NewExclusions().ReadTSV
+	case project.Observations:
+		read = matrix.New().ReadTSV
+	case project.Primers:
+		read = dna.NewPrimerSet().ReadTSV
+	case project.Proteins:
+		read = protein.New().ReadTSV
+	case project.Specimens:
+		read = specimen.New().ReadTSV
+	case project.Taxonomy:
+		read = taxonomy.New().ReadTSV
+	default:
+		return nil
+	}
+	return read(bytes.NewReader(data))
+}
@@ -0,0 +1,232 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package migrate implements a command to upgrade
+// the dataset files of a PhyData project
+// to the current file format.
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/ages"
+	"github.com/js-arias/phydata/characters"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/matrix/protein"
+	"github.com/js-arias/phydata/project"
+	"github.com/js-arias/phydata/specimen"
+	"github.com/js-arias/phydata/taxonomy"
+)
+
+var Command = &command.Command{
+	Usage: "migrate [--dry-run] <project-file>",
+	Short: "upgrade dataset files to the current format",
+	Long: `
+Command migrate reads the dataset files of a PhyData project, and rewrites
+the files written with an older file format, using the current format.
+
+The argument of the command is the name of the project file.
+
+Each dataset file has a comment line in its header with the version of its
+file format (e.g., '# format version: 1'). Files without that line were
+written before the format versions were defined, and are version 0. When the
+columns of a dataset change (for example, when a new observation field is
+added), the format version of the dataset is incremented. Upgrading a file
+reads the file and writes it with all the columns of the current format, so
+commands never have to guess which columns a file should have.
+
+If a file has a format version newer than the one supported, the command
+fails, as the file was written by a newer version of the program.
+
+A DNA store file (see 'phydata dna store') is written as a store file.
+Read-only files (see 'phydata project readonly') are never upgraded.
+
+For each upgraded file, the command prints the dataset, the file, and the
+old and new format versions. Use the flag --dry-run to print the files that
+will be upgraded without modifying them.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var dryRun bool
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&dryRun, "dry-run", false, "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+
+	for _, set := range p.Sets() {
+		cur := project.FormatVersion(set)
+		if cur == 0 {
+			// a dataset without a file format
+			continue
+		}
+		for _, name := range p.Paths(set) {
+			if p.ReadOnly(set, name) {
+				continue
+			}
+			v, err := project.FileVersion(name)
+			if err != nil {
+				return fmt.Errorf("on project %q: %v", pFile, err)
+			}
+			if v > cur {
+				return fmt.Errorf("on project %q: file %q: format version %d, newer than supported version %d", pFile, name, v, cur)
+			}
+			if v == cur {
+				continue
+			}
+			if !dryRun {
+				if err := upgrade(set, name); err != nil {
+					return fmt.Errorf("on project %q: %v", pFile, err)
+				}
+			}
+			fmt.Fprintf(c.Stdout(), "%s\t%s\t%d -> %d\n", set, name, v, cur)
+		}
+	}
+	return nil
+}
+
+// A dataset is a dataset
+// that can be read from, and written to,
+// a TSV file.
+type dataset interface {
+	ReadTSV(r io.Reader) error
+	TSV(w io.Writer) error
+}
+
+// Labels are the descriptions of the datasets
+// used in the header of the files.
+var labels = map[project.Dataset]string{
+	project.Ages:         "tip ages",
+	project.Characters:   "character metadata",
+	project.DNA:          "DNA sequences",
+	project.Exclusions:   "exclusion masks",
+	project.Observations: "character observations",
+	project.Primers:      "primers",
+	project.Proteins:     "amino acid sequences",
+	project.Specimens:    "specimen records",
+	project.Taxonomy:     "taxonomy",
+}
+
+func newDataset(set project.Dataset) dataset {
+	switch set {
+	case project.Ages:
+		return ages.New()
+	case project.Characters:
+		return characters.New()
+	case project.DNA:
+		return dna.New()
+	case project.Exclusions:
+		return dna.NewExclusions()
+	case project.Observations:
+		return matrix.New()
+	case project.Primers:
+		return dna.NewPrimerSet()
+	case project.Proteins:
+		return protein.New()
+	case project.Specimens:
+		return specimen.New()
+	case project.Taxonomy:
+		return taxonomy.New()
+	}
+	return nil
+}
+
+// Upgrade reads a dataset file
+// and writes it with the current file format.
+func upgrade(set project.Dataset, name string) error {
+	ds := newDataset(set)
+	if ds == nil {
+		return fmt.Errorf("file %q: unknown dataset %q", name, set)
+	}
+	if err := readFile(name, ds); err != nil {
+		return err
+	}
+
+	if coll, ok := ds.(*dna.Collection); ok && dna.IsStore(name) {
+		return writeStore(name, coll)
+	}
+	return writeFile(name, set, ds)
+}
+
+func readFile(name string, ds dataset) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := ds.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	if m, ok := ds.(*matrix.Matrix); ok {
+		if w := m.Warnings(); len(w) > 0 {
+			// skipped rows would be lost when writing the file
+			return fmt.Errorf("while reading file %q: %v (see 'phydata obs validate')", name, w[0])
+		}
+	}
+	return nil
+}
+
+func writeFile(name string, set project.Dataset, ds dataset) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: %s\n", labels[set])
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(set))
+	if err := ds.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeStore(name string, c *dna.Collection) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	if err := c.WriteStore(f, project.VersionComment(project.DNA)); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
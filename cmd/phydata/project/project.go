@@ -0,0 +1,35 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package project is a metapackage for commands
+// that dealt with PhyData projects.
+package project
+
+import (
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/project/extract"
+	"github.com/js-arias/phydata/cmd/phydata/project/importer"
+	"github.com/js-arias/phydata/cmd/phydata/project/migrate"
+	"github.com/js-arias/phydata/cmd/phydata/project/readonly"
+	"github.com/js-arias/phydata/cmd/phydata/project/remote"
+	"github.com/js-arias/phydata/cmd/phydata/project/scaffold"
+	"github.com/js-arias/phydata/cmd/phydata/project/treebase"
+	"github.com/js-arias/phydata/cmd/phydata/project/validate"
+)
+
+func init() {
+	Command.Add(extract.Command)
+	Command.Add(importer.Command)
+	Command.Add(migrate.Command)
+	Command.Add(readonly.Command)
+	Command.Add(remote.Command)
+	Command.Add(scaffold.Command)
+	Command.Add(treebase.Command)
+	Command.Add(validate.Command)
+}
+
+var Command = &command.Command{
+	Usage: "project <command> [<argument>...]",
+	Short: "commands for PhyData projects",
+}
@@ -0,0 +1,191 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package readonly implements a command to mark
+// the dataset files of a PhyData project
+// as read-only.
+package readonly
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `readonly [--unset] [-w|--working <file>]
+	<project-file> <dataset> [<file>...]`,
+	Short: "mark dataset files as read-only",
+	Long: `
+Command readonly marks one or more files of a dataset of a PhyData project as
+read-only (e.g., a published reference dataset), so the commands that modify
+the dataset never write into them.
+
+The first argument of the command is the name of the project file.
+
+The second argument is the dataset (e.g., 'observations', or 'dna'). By
+default, all the files of the dataset are marked as read-only. Additional
+arguments can be used to mark only some files of the dataset.
+
+With the flag --unset, the files are no longer read-only.
+
+The flag --working, or -w, defines the working file of the dataset, that
+receives the changes to the dataset. If the file does not exist, it will be
+created: for DNA sequences, it will be an empty file, as the sequences of all
+the DNA files are merged when read, and any new or modified sequence is
+written into the working file, which overrides the sequences of the read-only
+files. For other datasets, the working file will be a copy of the current
+file of the dataset, and it will be used in place of the read-only file, so
+the read-only file is kept as a reference. If the file already exists, it
+will be used as is. Without a working file, any command that modifies a
+read-only dataset will fail.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var unset bool
+var working string
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&unset, "unset", false, "")
+	c.Flags().StringVar(&working, "working", "", "")
+	c.Flags().StringVar(&working, "w", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if len(args) < 2 {
+		return c.UsageError("expecting dataset")
+	}
+	if unset && working != "" {
+		return c.UsageError("flag --working can not be used with --unset")
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+
+	set := project.Dataset(args[1])
+	paths := p.Paths(set)
+	if len(paths) == 0 {
+		return fmt.Errorf("on project %q: undefined dataset %q", pFile, set)
+	}
+
+	files := args[2:]
+	if len(files) == 0 {
+		files = slices.DeleteFunc(paths, func(f string) bool {
+			return f == working
+		})
+	}
+	for _, f := range files {
+		if !slices.Contains(p.Paths(set), f) {
+			return fmt.Errorf("on project %q: file %q is not in dataset %q", pFile, f, set)
+		}
+		if f == working {
+			return fmt.Errorf("on project %q: working file %q can not be read-only", pFile, f)
+		}
+		p.SetReadOnly(set, f, !unset)
+	}
+
+	if working != "" {
+		if err := setWorking(p, set); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+
+	if err := p.Write(pFile); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetWorking sets the working file of a dataset.
+func setWorking(p *project.Project, set project.Dataset) error {
+	if p.ReadOnly(set, working) {
+		return fmt.Errorf("working file %q is read-only", working)
+	}
+
+	if _, err := os.Stat(working); errors.Is(err, os.ErrNotExist) {
+		if set == project.DNA {
+			if err := writeDNA(working); err != nil {
+				return err
+			}
+		} else if err := copyFile(working, p.Path(set)); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if set == project.DNA {
+		p.Append(set, working)
+		return nil
+	}
+	p.Add(set, working)
+	return nil
+}
+
+func copyFile(dst, src string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	if _, err := io.Copy(f, in); err != nil {
+		return fmt.Errorf("while writing to %q: %v", dst, err)
+	}
+	return nil
+}
+
+func writeDNA(name string) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: DNA sequences\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.DNA))
+	if err := dna.New().TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package remote implements a command to add remote datasets
+// to a PhyData project,
+// and to download their local copies.
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/fetch"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/cmd/phydata/progress"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: "remote [--update] <project-file> [<dataset> <url>]",
+	Short: "add remote datasets to a project",
+	Long: `
+Command remote downloads the remote files of a PhyData project, and
+optionally, adds a remote file to a dataset of the project. A remote file is
+a dataset file defined by an 'https://' URL (e.g., a shared canonical
+observation file used by many projects), so it can be used without copying
+it by hand.
+
+The first argument of the command is the name of the project file. If no
+project file exists, a new project will be created.
+
+The optional second and third arguments are a dataset (e.g.,
+'observations', or 'dna') and the URL of a remote file that will be added to
+the dataset. For DNA sequences, the remote file is added to the DNA files of
+the project (the files are merged when read). For other datasets, the remote
+file replaces the current files of the dataset.
+
+The remote files are always read-only (to modify a remote dataset, define a
+working file with 'phydata project readonly --working'). The commands use a
+local copy of each remote file, stored in a cache directory shared by all the
+projects of the user. By default, it is the directory 'phydata' of the user
+cache directory (e.g., '~/.cache/phydata' on Linux), and it can be defined
+with the PHYDATA_CACHE environment variable.
+
+When a remote file is downloaded for the first time, its checksum (the
+SHA-256 hash of its content) is stored in the project file, so the content
+of the file is pinned: the local copy is identified by the checksum, and if
+the content of the remote file changes, the command fails, so the project
+always uses the same data. Use the flag --update to download the files again
+and accept their new content.
+
+For each downloaded file, the command prints the dataset, the URL, and the
+checksum of the file.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var update bool
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&update, "update", false, "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if len(args) == 2 {
+		return c.UsageError("expecting remote file URL")
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := openProject(pFile)
+	if err != nil {
+		return err
+	}
+
+	if len(args) > 2 {
+		set := project.Dataset(args[1])
+		url := args[2]
+		if !project.Remote(url) {
+			return fmt.Errorf("invalid URL %q: expecting an https:// URL", url)
+		}
+		if set == project.DNA {
+			p.Append(set, url)
+		} else {
+			p.Add(set, "")
+			p.Add(set, url)
+		}
+	}
+
+	cl := fetch.New(0)
+	for _, set := range p.Sets() {
+		for _, name := range p.Paths(set) {
+			url := p.Source(set, name)
+			if !project.Remote(url) {
+				continue
+			}
+			sum, err := download(cl, p, set, url)
+			if err != nil {
+				return fmt.Errorf("on project %q: %v", pFile, err)
+			}
+			if sum == "" {
+				continue
+			}
+			fmt.Fprintf(c.Stdout(), "%s\t%s\t%s\n", set, url, sum)
+		}
+	}
+
+	if err := p.Write(pFile); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Download downloads a remote file
+// and stores its local copy,
+// pinning its checksum.
+// It returns the checksum of the downloaded file,
+// or an empty string
+// if the local copy is already stored.
+func download(cl *fetch.Client, p *project.Project, set project.Dataset, url string) (string, error) {
+	want := p.Checksum(set, url)
+	if want != "" && !update {
+		data, err := os.ReadFile(p.Local(set, url))
+		if err == nil && project.Sum(data) == want {
+			return "", nil
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+
+	progress.Printf("downloading %q", url)
+	data, err := cl.Get(url)
+	if err != nil {
+		return "", err
+	}
+	sum := project.Sum(data)
+	if want != "" && sum != want && !update {
+		return "", fmt.Errorf("remote file %q: checksum %s, want %s (use --update to accept the new content)", url, sum, want)
+	}
+	p.SetChecksum(set, url, sum)
+
+	if err := writeLocal(p.Local(set, url), data); err != nil {
+		return "", fmt.Errorf("remote file %q: %v", url, err)
+	}
+	return sum, nil
+}
+
+// WriteLocal writes the local copy of a remote file.
+// The data is written into a temporary file
+// that is then renamed,
+// so an interrupted download
+// never leaves an incomplete copy.
+func writeLocal(name string, data []byte) error {
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, "download-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("while writing to %q: %v", tmp, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func openProject(name string) (*project.Project, error) {
+	p, err := project.Read(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return project.New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable ot open project %q: %v", name, err)
+	}
+	return p, nil
+}
@@ -0,0 +1,192 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package scaffold implements a command to create
+// a new PhyData project
+// with empty dataset files.
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/ages"
+	"github.com/js-arias/phydata/characters"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+	"github.com/js-arias/phydata/specimen"
+	"github.com/js-arias/phydata/taxonomy"
+)
+
+var Command = &command.Command{
+	Usage: "new [--template <name>] <project-file>",
+	Short: "create a new project",
+	Long: `
+Command new creates a new PhyData project, with empty dataset files (i.e.,
+files with only the header of the dataset), so the files can be filled by
+hand, or with a spreadsheet, without learning the layout of the files first.
+
+The argument of the command is the name of the new project file. The
+dataset files will be written in the same directory of the project file
+(the directory will be created, if it does not exist), using the name of the
+dataset with the extension '.tab' (e.g., 'observations.tab'). If the project
+file, or any of the dataset files, already exists, the command will fail, so
+no file is ever overwritten.
+
+The flag --template defines the datasets of the new project. Valid values
+are:
+
+	total-evidence	observations, character metadata, DNA sequences,
+			specimens, taxonomy, and tip ages (the default).
+	morphology	observations, character metadata, specimens, and
+			tip ages.
+	molecular	DNA sequences, specimens, and taxonomy.
+
+There is no separate dataset for references: the records of each dataset
+have a 'reference' field (e.g., the source of an observation, or of a DNA
+sequence).
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var template string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&template, "template", "total-evidence", "")
+}
+
+// Templates are the datasets
+// of each project template.
+var templates = map[string][]project.Dataset{
+	"total-evidence": {
+		project.Observations,
+		project.Characters,
+		project.DNA,
+		project.Specimens,
+		project.Taxonomy,
+		project.Ages,
+	},
+	"morphology": {
+		project.Observations,
+		project.Characters,
+		project.Specimens,
+		project.Ages,
+	},
+	"molecular": {
+		project.DNA,
+		project.Specimens,
+		project.Taxonomy,
+	},
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	sets, ok := templates[template]
+	if !ok {
+		return c.UsageError(fmt.Sprintf("unknown template %q", template))
+	}
+
+	pFile := args[0]
+	dir := filepath.Dir(pFile)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(pFile); err == nil {
+		return fmt.Errorf("file %q already exists", pFile)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	p := project.New()
+	for _, set := range sets {
+		name := filepath.Join(dir, string(set)+".tab")
+		if _, err := os.Stat(name); err == nil {
+			return fmt.Errorf("file %q already exists", name)
+		}
+		p.Add(set, name)
+	}
+
+	for _, set := range sets {
+		if err := writeFile(p.Path(set), set, newDataset(set)); err != nil {
+			return err
+		}
+	}
+	if err := p.Write(pFile); err != nil {
+		return err
+	}
+	return nil
+}
+
+// A dataset is a dataset
+// that can be written to a TSV file.
+type dataset interface {
+	TSV(w io.Writer) error
+}
+
+// Labels are the descriptions of the datasets
+// used in the header of the files.
+var labels = map[project.Dataset]string{
+	project.Ages:         "tip ages",
+	project.Characters:   "character metadata",
+	project.DNA:          "DNA sequences",
+	project.Observations: "character observations",
+	project.Specimens:    "specimen records",
+	project.Taxonomy:     "taxonomy",
+}
+
+func newDataset(set project.Dataset) dataset {
+	switch set {
+	case project.Ages:
+		return ages.New()
+	case project.Characters:
+		return characters.New()
+	case project.DNA:
+		return dna.New()
+	case project.Observations:
+		return matrix.New()
+	case project.Specimens:
+		return specimen.New()
+	case project.Taxonomy:
+		return taxonomy.New()
+	}
+	return nil
+}
+
+func writeFile(name string, set project.Dataset, ds dataset) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: %s\n", labels[set])
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(set))
+	if err := ds.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
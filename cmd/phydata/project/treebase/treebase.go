@@ -0,0 +1,269 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package treebase implements a command to import
+// a TreeBASE study into a PhyData project.
+package treebase
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `treebase [--study <study-id>] [--gene <name>]
+	<project-file> <study-file>`,
+	Short: "import a TreeBASE study",
+	Long: `
+Command treebase reads a study downloaded from TreeBASE
+(see <https://www.treebase.org>), and adds the character observations and
+the DNA sequences of the study into a PhyData project.
+
+The first argument of the command is the name of the project file. If no
+project file exists, a new project will be created.
+
+The second argument of the command is the name of the study file. The file
+can be in NEXUS or NeXML format (the format is detected from the content of
+the file).
+
+The study ID is used as the reference of all the imported data, and as the
+prefix of the specimen identifiers (e.g., 'S1234:Ascaphus truei'). By
+default, the name of the study file, without extension, is used as the study
+ID. Use the flag --study to define a different study ID.
+
+Morphological data is read from the first block of standard characters of the
+study. The observations will be stored in the observations file of the
+project. If the project does not have an observations file, a new one will be
+created with the name 'observations.tab'.
+
+DNA sequences are read from all DNA blocks of the study, and the title (or
+label) of each block is used as the gene name. If a DNA block does not have
+a title, the name defined with the flag --gene will be used. The sequences
+will be stored as aligned sequences in the DNA file of the project. If the
+project does not have a DNA file, a new one will be created with the name
+'dna.tab'.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var studyID string
+var geneName string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&studyID, "study", "", "")
+	c.Flags().StringVar(&geneName, "gene", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if len(args) < 2 {
+		return c.UsageError("expecting study file")
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := openProject(pFile)
+	if err != nil {
+		return err
+	}
+
+	in := args[1]
+	data, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	ref := studyID
+	if ref == "" {
+		ref = strings.TrimSuffix(filepath.Base(in), filepath.Ext(in))
+	}
+	ref = strings.Join(strings.Fields(ref), "_")
+	if ref == "" {
+		return c.UsageError("undefined study ID")
+	}
+	nexml := bytes.HasPrefix(bytes.TrimSpace(data), []byte("<"))
+
+	m := matrix.New()
+	if nexml {
+		err = m.ReadNeXML(bytes.NewReader(data), ref)
+	} else {
+		err = m.ReadNexus(bytes.NewReader(data), ref)
+	}
+	if err != nil {
+		return fmt.Errorf("while reading file %q: %v", in, err)
+	}
+
+	coll := dna.New()
+	if nexml {
+		err = coll.ReadNeXML(bytes.NewReader(data), ref, geneName)
+	} else {
+		err = coll.ReadNexus(bytes.NewReader(data), ref, geneName)
+	}
+	if err != nil {
+		return fmt.Errorf("while reading file %q: %v", in, err)
+	}
+
+	if len(m.Taxa()) == 0 && len(coll.Taxa()) == 0 {
+		return fmt.Errorf("while reading file %q: no data found", in)
+	}
+
+	if len(m.Taxa()) > 0 {
+		if err := addObs(p, m); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+	if len(coll.Taxa()) > 0 {
+		if err := addDNA(p, coll); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+
+	if err := p.Write(pFile); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AddObs adds the observations of a study
+// to the observations of a project.
+func addObs(p *project.Project, study *matrix.Matrix) error {
+	m := matrix.New()
+	obsFile := p.Path(project.Observations)
+	if obsFile != "" {
+		if err := readObsFile(obsFile, m); err != nil {
+			return err
+		}
+	} else {
+		obsFile = "observations.tab"
+	}
+
+	for _, tax := range study.Taxa() {
+		for _, spec := range study.TaxSpec(tax) {
+			for _, char := range study.Chars() {
+				for _, st := range study.Obs(spec, char) {
+					if err := m.Add(tax, spec, char, st); err != nil {
+						return err
+					}
+					if ref := study.Val(spec, char, st, matrix.Reference); ref != "" {
+						m.Set(spec, char, st, ref, matrix.Reference)
+					}
+				}
+			}
+		}
+	}
+
+	if p.ReadOnly(project.Observations, obsFile) {
+		return fmt.Errorf("file %q is read-only", p.Source(project.Observations, obsFile))
+	}
+	if err := writeObs(obsFile, m); err != nil {
+		return err
+	}
+	p.Add(project.Observations, obsFile)
+	return nil
+}
+
+// AddDNA adds the sequences of a study
+// to the DNA sequences of a project.
+func addDNA(p *project.Project, study *dna.Collection) error {
+	coll := dna.New()
+	files, err := dnafiles.Read(p, coll)
+	if err != nil {
+		return err
+	}
+	dnaFile := p.Path(project.DNA)
+	if dnaFile == "" {
+		dnaFile = "dna.tab"
+	}
+	if err := files.SetTarget(coll, dnaFile); err != nil {
+		return err
+	}
+
+	for _, tax := range study.Taxa() {
+		for _, spec := range study.TaxSpec(tax) {
+			for _, gene := range study.SpecGene(spec) {
+				for _, acc := range study.GeneAccession(spec, gene) {
+					if err := coll.Add(tax, spec, gene, "", study.Sequence(spec, gene, acc)); err != nil {
+						return err
+					}
+					coll.Set(spec, gene, acc, study.Val(spec, gene, acc, dna.Aligned), dna.Aligned)
+					coll.Set(spec, gene, acc, study.Val(spec, gene, acc, dna.Reference), dna.Reference)
+				}
+			}
+		}
+	}
+
+	if err := files.Write(coll); err != nil {
+		return err
+	}
+	p.Append(project.DNA, dnaFile)
+	return nil
+}
+
+func openProject(name string) (*project.Project, error) {
+	p, err := project.Read(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return project.New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable ot open project %q: %v", name, err)
+	}
+	return p, nil
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	if w := m.Warnings(); len(w) > 0 {
+		// skipped rows would be lost when writing the file
+		return fmt.Errorf("while reading file %q: %v (see 'phydata obs validate')", name, w[0])
+	}
+	return nil
+}
+
+func writeObs(name string, m *matrix.Matrix) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: character observations\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Observations))
+	if err := m.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
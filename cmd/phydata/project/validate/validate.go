@@ -0,0 +1,558 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package validate implements a command to check
+// all the datasets of a PhyData project.
+package validate
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/ages"
+	"github.com/js-arias/phydata/characters"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/matrix/protein"
+	"github.com/js-arias/phydata/project"
+	"github.com/js-arias/phydata/specimen"
+	"github.com/js-arias/phydata/taxonomy"
+)
+
+var Command = &command.Command{
+	Usage: "validate <project-file>",
+	Short: "check all the datasets of a project",
+	Long: `
+Command validate reads all the datasets of a PhyData project and reports the
+problems found in them, or between them. If any problem is found, the command
+exits with an error, so it can be used as a pre-commit hook of a data
+repository.
+
+The argument of the command is the name of the project file.
+
+The following problems are reported:
+
+	dataset		an unknown dataset keyword in the project file.
+	missing		a dataset file that does not exist (or a remote file
+			without a local copy, see 'phydata project remote').
+	format		a dataset file that can not be read (for example, a
+			missing header field, or a malformed row).
+	version		a dataset file with a format version different from
+			the current version (use 'phydata project migrate' to
+			upgrade older files).
+	taxon		a specimen assigned to different taxa in different
+			datasets.
+	orphan		a specimen without a record in the specimens file of
+			the project.
+	unused		a specimen record that is not used by any other
+			dataset.
+	alignment	an aligned sequence with a length different from the
+			other aligned sequences of the gene, or an excluded
+			region outside the alignment.
+	reference	a reference to an undefined item: a character that
+			depends on an undefined character, an excluded region
+			or a primer of an undefined gene, or a sequence that
+			uses an undefined primer.
+	image		an image of an observation that is not found (only
+			local files are checked).
+
+Specimens are only checked for orphan or unused records if the project has a
+specimens file. If a dataset file can not be read, the dataset is ignored in
+the checks between datasets. The files of a dataset with several files (e.g.,
+several DNA files) are merged. A read-only file of a dataset other than DNA
+is replaced by the working file of the dataset (see 'phydata project
+readonly'), so it is not checked.
+
+The output is a TSV table with the dataset, the kind of the problem, the
+item with the problem, and a description of the problem.
+	`,
+	Run: run,
+}
+
+// A problem is a problem found
+// in a dataset.
+type problem struct {
+	set     project.Dataset
+	kind    string
+	item    string
+	comment string
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	d := &datasets{}
+	var probs []problem
+	for _, set := range p.Sets() {
+		read := d.reader(set)
+		if read == nil {
+			probs = append(probs, problem{set, "dataset", p.Path(set), "unknown dataset"})
+			continue
+		}
+		var failed bool
+		for _, name := range p.Paths(set) {
+			if set != project.DNA && p.ReadOnly(set, name) && name != p.Path(set) {
+				// a read-only file replaced
+				// by a working file
+				continue
+			}
+			if _, err := os.Stat(name); err != nil {
+				if url := p.Source(set, name); project.Remote(url) {
+					probs = append(probs, problem{set, "missing", url, "remote file not downloaded"})
+					failed = true
+					continue
+				}
+				probs = append(probs, problem{set, "missing", name, "file not found"})
+				failed = true
+				continue
+			}
+			if err := readFile(name, read); err != nil {
+				probs = append(probs, problem{set, "format", name, err.Error()})
+				failed = true
+				continue
+			}
+			v, err := project.FileVersion(name)
+			if err != nil {
+				probs = append(probs, problem{set, "version", name, err.Error()})
+				continue
+			}
+			if cur := project.FormatVersion(set); v != cur {
+				probs = append(probs, problem{set, "version", name, fmt.Sprintf("format version %d, want %d", v, cur)})
+			}
+		}
+		if failed {
+			d.clear(set)
+		}
+	}
+
+	probs = append(probs, d.taxonProblems()...)
+	probs = append(probs, d.specimenProblems()...)
+	probs = append(probs, d.alignmentProblems()...)
+	probs = append(probs, d.referenceProblems()...)
+	probs = append(probs, d.imageProblems()...)
+
+	if err := writeProblems(c.Stdout(), probs); err != nil {
+		return err
+	}
+	if len(probs) > 0 {
+		return fmt.Errorf("on project %q: %d problems found", args[0], len(probs))
+	}
+	return nil
+}
+
+// Datasets are the datasets of a project.
+type datasets struct {
+	ages  *ages.Ages
+	chars *characters.Catalog
+	coll  *dna.Collection
+	excl  *dna.Exclusions
+	obs   *matrix.Matrix
+	prim  *dna.PrimerSet
+	prot  *protein.Collection
+	reg   *specimen.Registry
+	taxon *taxonomy.Taxonomy
+}
+
+// Reader returns the function used to read a dataset,
+// or nil if the dataset is unknown.
+func (d *datasets) reader(set project.Dataset) func(io.Reader) error {
+	switch set {
+	case project.Ages:
+		d.ages = ages.New()
+		return d.ages.ReadTSV
+	case project.Characters:
+		d.chars = characters.New()
+		return d.chars.ReadTSV
+	case project.DNA:
+		d.coll = dna.New()
+		return d.coll.ReadTSV
+	case project.Exclusions:
+		d.excl = dna.NewExclusions()
+		return d.excl.ReadTSV
+	case project.Observations:
+		d.obs = matrix.New()
+		return d.obs.ReadTSV
+	case project.Primers:
+		d.prim = dna.NewPrimerSet()
+		return d.prim.ReadTSV
+	case project.Proteins:
+		d.prot = protein.New()
+		return d.prot.ReadTSV
+	case project.Specimens:
+		d.reg = specimen.New()
+		return d.reg.ReadTSV
+	case project.Taxonomy:
+		d.taxon = taxonomy.New()
+		return d.taxon.ReadTSV
+	}
+	return nil
+}
+
+// Clear removes a dataset that can not be read.
+func (d *datasets) clear(set project.Dataset) {
+	switch set {
+	case project.Ages:
+		d.ages = nil
+	case project.Characters:
+		d.chars = nil
+	case project.DNA:
+		d.coll = nil
+	case project.Exclusions:
+		d.excl = nil
+	case project.Observations:
+		d.obs = nil
+	case project.Primers:
+		d.prim = nil
+	case project.Proteins:
+		d.prot = nil
+	case project.Specimens:
+		d.reg = nil
+	case project.Taxonomy:
+		d.taxon = nil
+	}
+}
+
+// A taxaer is a dataset with taxa
+// and specimens.
+type taxaer interface {
+	Taxa() []string
+	TaxSpec(name string) []string
+}
+
+// SpecDatasets returns the datasets with specimens
+// (except the specimens file).
+func (d *datasets) specDatasets() map[project.Dataset]taxaer {
+	sets := make(map[project.Dataset]taxaer)
+	if d.ages != nil {
+		sets[project.Ages] = d.ages
+	}
+	if d.coll != nil {
+		sets[project.DNA] = d.coll
+	}
+	if d.obs != nil {
+		sets[project.Observations] = d.obs
+	}
+	if d.prot != nil {
+		sets[project.Proteins] = d.prot
+	}
+	return sets
+}
+
+// SpecTaxa returns the taxon of each specimen
+// of a dataset.
+func specTaxa(ds taxaer) map[string]string {
+	taxa := make(map[string]string)
+	for _, tx := range ds.Taxa() {
+		for _, sp := range ds.TaxSpec(tx) {
+			if sp == "" {
+				continue
+			}
+			taxa[sp] = tx
+		}
+	}
+	return taxa
+}
+
+// TaxonProblems returns the specimens assigned
+// to different taxa in different datasets.
+// If there is a specimens file,
+// the taxon of the specimen records are used as reference,
+// otherwise,
+// the taxon of the first dataset of the specimen.
+func (d *datasets) taxonProblems() []problem {
+	var ref map[string]string
+	var refSet project.Dataset
+	if d.reg != nil {
+		ref = make(map[string]string)
+		for _, sp := range d.reg.Specimens() {
+			ref[sp] = d.reg.Taxon(sp)
+		}
+		refSet = project.Specimens
+	}
+
+	sets := d.specDatasets()
+	keys := make([]project.Dataset, 0, len(sets))
+	for s := range sets {
+		keys = append(keys, s)
+	}
+	slices.Sort(keys)
+
+	var probs []problem
+	first := make(map[string]project.Dataset)
+	taxa := make(map[string]string)
+	for _, s := range keys {
+		st := specTaxa(sets[s])
+		specs := make([]string, 0, len(st))
+		for sp := range st {
+			specs = append(specs, sp)
+		}
+		slices.Sort(specs)
+		for _, sp := range specs {
+			tx := st[sp]
+			if want, ok := ref[sp]; ok {
+				if want != tx {
+					probs = append(probs, problem{s, "taxon", sp, fmt.Sprintf("taxon %s, want %s (from %s)", tx, want, refSet)})
+				}
+				continue
+			}
+			want, ok := taxa[sp]
+			if !ok {
+				taxa[sp] = tx
+				first[sp] = s
+				continue
+			}
+			if want != tx {
+				probs = append(probs, problem{s, "taxon", sp, fmt.Sprintf("taxon %s, want %s (from %s)", tx, want, first[sp])})
+			}
+		}
+	}
+	return probs
+}
+
+// SpecimenProblems returns the specimens
+// without a specimen record,
+// and the specimen records without data.
+func (d *datasets) specimenProblems() []problem {
+	if d.reg == nil {
+		return nil
+	}
+	records := make(map[string]bool)
+	for _, sp := range d.reg.Specimens() {
+		records[sp] = true
+	}
+
+	sets := d.specDatasets()
+	keys := make([]project.Dataset, 0, len(sets))
+	for s := range sets {
+		keys = append(keys, s)
+	}
+	slices.Sort(keys)
+
+	var probs []problem
+	used := make(map[string]bool)
+	for _, s := range keys {
+		st := specTaxa(sets[s])
+		specs := make([]string, 0, len(st))
+		for sp := range st {
+			specs = append(specs, sp)
+		}
+		slices.Sort(specs)
+		for _, sp := range specs {
+			used[sp] = true
+			if !records[sp] {
+				probs = append(probs, problem{s, "orphan", sp, "specimen without record"})
+			}
+		}
+	}
+	for _, sp := range d.reg.Specimens() {
+		if !used[sp] {
+			probs = append(probs, problem{project.Specimens, "unused", sp, "specimen without data"})
+		}
+	}
+	return probs
+}
+
+// AlignmentProblems returns the aligned sequences
+// with a length different from the other aligned sequences
+// of the gene,
+// and the excluded regions outside the alignment.
+// The expected length of a gene is the most common
+// length of its aligned sequences.
+func (d *datasets) alignmentProblems() []problem {
+	if d.coll == nil {
+		return nil
+	}
+
+	var probs []problem
+	length := make(map[string]int)
+	for _, gene := range d.coll.Genes() {
+		type seq struct {
+			spec, acc string
+			n         int
+		}
+		var seqs []seq
+		count := make(map[int]int)
+		for _, sp := range d.coll.Specimens() {
+			for _, acc := range d.coll.GeneAccession(sp, gene) {
+				if d.coll.Val(sp, gene, acc, dna.Aligned) != "true" {
+					continue
+				}
+				if d.coll.Val(sp, gene, acc, dna.Start) != "" {
+					continue
+				}
+				n := len(d.coll.Sequence(sp, gene, acc))
+				seqs = append(seqs, seq{spec: sp, acc: acc, n: n})
+				count[n]++
+			}
+		}
+		if len(seqs) == 0 {
+			continue
+		}
+		var want int
+		for n, c := range count {
+			if c > count[want] || (c == count[want] && n > want) {
+				want = n
+			}
+		}
+		length[gene] = want
+		for _, s := range seqs {
+			if s.n == want {
+				continue
+			}
+			probs = append(probs, problem{project.DNA, "alignment", fmt.Sprintf("%s:%s:%s", gene, s.spec, s.acc), fmt.Sprintf("aligned length %d, want %d", s.n, want)})
+		}
+	}
+
+	if d.excl == nil {
+		return probs
+	}
+	for _, gene := range d.excl.Genes() {
+		ln, ok := length[gene]
+		if !ok {
+			continue
+		}
+		for _, r := range d.excl.Regions(gene) {
+			if r.To <= ln {
+				continue
+			}
+			probs = append(probs, problem{project.Exclusions, "alignment", fmt.Sprintf("%s:%d-%d", gene, r.From, r.To), fmt.Sprintf("region outside the alignment (length %d)", ln)})
+		}
+	}
+	return probs
+}
+
+// ReferenceProblems returns the references
+// to undefined characters, genes, or primers.
+func (d *datasets) referenceProblems() []problem {
+	var probs []problem
+	if d.chars != nil {
+		for _, ch := range d.chars.Chars() {
+			parent, _ := d.chars.Dependency(ch)
+			if parent == "" || slices.Contains(d.chars.Chars(), parent) {
+				continue
+			}
+			if d.obs != nil && slices.Contains(d.obs.Chars(), parent) {
+				continue
+			}
+			probs = append(probs, problem{project.Characters, "reference", ch, fmt.Sprintf("depends on undefined character: %s", parent)})
+		}
+	}
+
+	if d.coll == nil {
+		return probs
+	}
+	genes := d.coll.Genes()
+	if d.excl != nil {
+		for _, gene := range d.excl.Genes() {
+			if _, ok := slices.BinarySearch(genes, gene); ok {
+				continue
+			}
+			probs = append(probs, problem{project.Exclusions, "reference", gene, "undefined gene"})
+		}
+	}
+
+	if d.prim == nil {
+		return probs
+	}
+	for _, name := range d.prim.Names() {
+		pr, _ := d.prim.Primer(name)
+		if pr.Gene == "" {
+			continue
+		}
+		if _, ok := slices.BinarySearch(genes, pr.Gene); ok {
+			continue
+		}
+		probs = append(probs, problem{project.Primers, "reference", name, fmt.Sprintf("undefined gene: %s", pr.Gene)})
+	}
+	for _, sp := range d.coll.Specimens() {
+		for _, gene := range d.coll.SpecGene(sp) {
+			for _, acc := range d.coll.GeneAccession(sp, gene) {
+				for _, name := range d.coll.SeqPrimers(sp, gene, acc) {
+					if _, ok := d.prim.Primer(name); ok {
+						continue
+					}
+					probs = append(probs, problem{project.DNA, "reference", fmt.Sprintf("%s:%s:%s", gene, sp, acc), fmt.Sprintf("undefined primer: %s", name)})
+				}
+			}
+		}
+	}
+	return probs
+}
+
+// ImageProblems returns the images of the observations
+// that are not found.
+// Only local files are checked.
+func (d *datasets) imageProblems() []problem {
+	if d.obs == nil {
+		return nil
+	}
+
+	var probs []problem
+	found := make(map[string]bool)
+	for _, sp := range d.obs.Specimens() {
+		for _, ch := range d.obs.Chars() {
+			for _, st := range d.obs.Obs(sp, ch) {
+				img := d.obs.Val(sp, ch, st, matrix.ImageLink)
+				if img == "" || strings.Contains(img, "://") {
+					continue
+				}
+				ok, seen := found[img]
+				if !seen {
+					_, err := os.Stat(img)
+					ok = err == nil
+					found[img] = ok
+				}
+				if ok {
+					continue
+				}
+				probs = append(probs, problem{project.Observations, "image", fmt.Sprintf("%s:%s:%s", sp, ch, st), fmt.Sprintf("image not found: %s", img)})
+			}
+		}
+	}
+	return probs
+}
+
+func readFile(name string, read func(io.Reader) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := read(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeProblems(w io.Writer, probs []problem) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	if err := tab.Write([]string{"dataset", "problem", "item", "comment"}); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+	for _, p := range probs {
+		if err := tab.Write([]string{string(p.set), p.kind, p.item, p.comment}); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+	}
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,406 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package heatmap implements a command to draw
+// a heatmap of the data occupancy
+// of the taxa and partitions
+// of a PhyData project.
+package heatmap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/names"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `heatmap [-o|--output <file>]
+	[--taxa <file>] [--variant <gene-list>]
+	<project-file>`,
+	Short: "draw a heatmap of the data occupancy",
+	Long: `
+Command heatmap reads a PhyData project, and draws an SVG image with a heatmap
+of the data occupancy of each taxon (in rows) and partition (in columns) of
+the project: the morphology (if the project has an observations file), and
+each gene (if the project has a DNA file). The image can be used as a data
+coverage figure in a paper.
+
+The argument of the command is the name of the project file.
+
+The color of each cell indicates the fraction of the partition with data for
+the taxon. For the morphology, it is the fraction of characters with an
+observation (i.e., not unknown) for the taxon. For a gene, it is the number of
+nucleotides of the sequence of the taxon (if the taxon has multiple
+sequences, the one with more nucleotides is used), divided by the length of
+the longest sequence of the gene. Cells without data are drawn in white.
+
+By default, the image will be printed in the standard output. To define an
+output file use the flag --output, or -o to define the file name.
+
+By default, all taxa in the project will be drawn, sorted alphabetically. If
+the flag --taxa is defined with a file, only the taxa in that file will be
+drawn, using the order given in the file. In the file each line will be read
+as a taxon name. Blank lines and lines starting with '#' will be ignored.
+
+If a gene has more than one alignment version, use the flag --variant with a
+comma-separated list of the versions that will be used (e.g., '--variant
+cytb:manual,coi').
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var output string
+var txLsFile string
+var variants string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+	c.Flags().StringVar(&txLsFile, "taxa", "", "")
+	c.Flags().StringVar(&variants, "variant", "", "")
+}
+
+// morphology is the name of the partition
+// of the observations.
+const morphology = "morphology"
+
+func run(c *command.Command, args []string) (err error) {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	var m *matrix.Matrix
+	if mf := p.Path(project.Observations); mf != "" {
+		m = matrix.New()
+		if err := readObsFile(mf, m); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+	}
+	var coll *dna.Collection
+	if p.Path(project.DNA) != "" {
+		coll = dna.New()
+		if _, err := dnafiles.Read(p, coll); err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+		coll, err = coll.SelectVariants(variantSelection(variants))
+		if err != nil {
+			return fmt.Errorf("on project %q: %v", args[0], err)
+		}
+	}
+	if m == nil && coll == nil {
+		return fmt.Errorf("on project %q: undefined observations and DNA files", args[0])
+	}
+
+	var txLs []string
+	if txLsFile != "" {
+		txLs, err = readTaxa(txLsFile)
+		if err != nil {
+			return err
+		}
+	} else {
+		txLs = getTaxaList(m, coll)
+	}
+	if len(txLs) == 0 {
+		return fmt.Errorf("on project %q: no taxa", args[0])
+	}
+
+	hm := occupancy(m, coll, txLs)
+
+	out := c.Stdout()
+	if output != "" {
+		var f *os.File
+		f, err = os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			e := f.Close()
+			if e != nil && err == nil {
+				err = e
+			}
+		}()
+		out = f
+	}
+
+	bw := bufio.NewWriter(out)
+	writeSVG(bw, hm)
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("while writing heatmap: %v", err)
+	}
+	return nil
+}
+
+// A heatMap is the occupancy of each taxon
+// in each partition.
+type heatMap struct {
+	taxa  []string
+	parts []string
+	cells [][]float64 // indexed by taxon and partition
+}
+
+// Occupancy returns the heatmap
+// with the occupancy of the taxa
+// in the morphology
+// and each gene.
+func occupancy(m *matrix.Matrix, coll *dna.Collection, txLs []string) heatMap {
+	hm := heatMap{
+		taxa:  txLs,
+		cells: make([][]float64, len(txLs)),
+	}
+
+	if m != nil {
+		hm.parts = append(hm.parts, morphology)
+		chars := m.Chars()
+		for i, tx := range txLs {
+			var n int
+			for _, ch := range chars {
+				if hasObs(m, tx, ch) {
+					n++
+				}
+			}
+			var v float64
+			if len(chars) > 0 {
+				v = float64(n) / float64(len(chars))
+			}
+			hm.cells[i] = append(hm.cells[i], v)
+		}
+	}
+
+	if coll != nil {
+		for _, g := range coll.Genes() {
+			hm.parts = append(hm.parts, g)
+			max := coll.MaxLen(g)
+			for i, tx := range txLs {
+				var v float64
+				if max > 0 {
+					v = taxonNucleotides(coll, tx, g) / float64(max)
+				}
+				hm.cells[i] = append(hm.cells[i], v)
+			}
+		}
+	}
+	return hm
+}
+
+// HasObs returns true if a taxon has an observation
+// for a character.
+func hasObs(m *matrix.Matrix, tx, char string) bool {
+	for _, sp := range m.TaxSpec(tx) {
+		obs := m.Obs(sp, char)
+		if len(obs) > 0 && obs[0] != matrix.Unknown {
+			return true
+		}
+	}
+	return false
+}
+
+// TaxonNucleotides returns the number of nucleotides
+// of the sequence of a gene for a taxon.
+// If the taxon has multiple sequences,
+// the one with more nucleotides will be used.
+func taxonNucleotides(coll *dna.Collection, tx, gene string) float64 {
+	var max float64
+	for _, sp := range coll.TaxSpec(tx) {
+		for _, a := range coll.GeneAccession(sp, gene) {
+			if n := countNucleotides(coll.Placed(sp, gene, a)); n > max {
+				max = n
+			}
+		}
+	}
+	return max
+}
+
+func countNucleotides(seq string) float64 {
+	num := 0.0
+	for _, p := range seq {
+		switch p {
+		case 'a', 'c', 'g', 't', 'u':
+			num += 1
+		case 'm', 'r', 'w', 's', 'y', 'k':
+			num += 0.5
+		case 'v', 'h', 'd', 'b':
+			num += 0.25
+		}
+	}
+	return num
+}
+
+// Sizes of the elements of the image
+// (in pixels).
+const (
+	cellSize  = 14
+	charWidth = 7 // approximate width of a character of the labels
+	fontSize  = 11
+	margin    = 10
+)
+
+// WriteSVG writes a heatmap as an SVG image.
+func writeSVG(w io.Writer, hm heatMap) {
+	left := margin + labelWidth(hm.taxa)
+	top := margin + labelWidth(hm.parts)
+	width := left + len(hm.parts)*cellSize + margin
+	legend := top + len(hm.taxa)*cellSize + 2*margin
+	height := legend + cellSize + fontSize + 2*margin
+	if lw := margin + 6*3*cellSize + margin; width < lw {
+		width = lw
+	}
+
+	fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"sans-serif\" font-size=\"%d\">\n", width, height, width, height, fontSize)
+	fmt.Fprintf(w, "<rect width=\"100%%\" height=\"100%%\" fill=\"white\"/>\n")
+
+	// partitions
+	for j, pt := range hm.parts {
+		x := left + j*cellSize + cellSize/2 + fontSize/3
+		fmt.Fprintf(w, "<text x=\"%d\" y=\"%d\" transform=\"rotate(-90 %d %d)\">%s</text>\n", x, top-4, x, top-4, html.EscapeString(pt))
+	}
+
+	// taxa
+	for i, tx := range hm.taxa {
+		y := top + i*cellSize
+		fmt.Fprintf(w, "<text x=\"%d\" y=\"%d\" text-anchor=\"end\" font-style=\"italic\">%s</text>\n", left-4, y+cellSize-3, html.EscapeString(tx))
+		for j, v := range hm.cells[i] {
+			fmt.Fprintf(w, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\" stroke=\"#bdbdbd\" stroke-width=\"0.5\"><title>%s, %s: %.2f</title></rect>\n", left+j*cellSize, y, cellSize, cellSize, color(v), html.EscapeString(tx), html.EscapeString(hm.parts[j]), v)
+		}
+	}
+
+	// legend
+	for i, v := range []float64{0, 0.2, 0.4, 0.6, 0.8, 1} {
+		x := margin + i*3*cellSize
+		fmt.Fprintf(w, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\" stroke=\"#bdbdbd\" stroke-width=\"0.5\"/>\n", x, legend, cellSize, cellSize, color(v))
+		fmt.Fprintf(w, "<text x=\"%d\" y=\"%d\">%.1f</text>\n", x, legend+cellSize+fontSize+2, v)
+	}
+	fmt.Fprintf(w, "</svg>\n")
+}
+
+// LabelWidth returns the approximate width
+// of the longest label.
+func labelWidth(ls []string) int {
+	var max int
+	for _, s := range ls {
+		if n := len([]rune(s)); n > max {
+			max = n
+		}
+	}
+	return max * charWidth
+}
+
+// Color returns the color of a cell
+// with the given occupancy,
+// from light blue,
+// to dark blue with full occupancy.
+// Cells without data are white.
+func color(v float64) string {
+	if v <= 0 {
+		return "#ffffff"
+	}
+	if v > 1 {
+		v = 1
+	}
+	from := [3]float64{222, 235, 247}
+	to := [3]float64{8, 81, 156}
+	var c [3]int
+	for i := range c {
+		c[i] = int(from[i] + (to[i]-from[i])*v + 0.5)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", c[0], c[1], c[2])
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+// VariantSelection returns the selected version of each gene
+// from a comma-separated list of gene names
+// (e.g., "cytb:manual,coi").
+func variantSelection(ls string) map[string]string {
+	sel := make(map[string]string)
+	for _, v := range strings.Split(ls, ",") {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" {
+			continue
+		}
+		g, _ := dna.SplitVariant(v)
+		sel[g] = v
+	}
+	return sel
+}
+
+func getTaxaList(m *matrix.Matrix, coll *dna.Collection) []string {
+	tn := make(map[string]bool)
+	if m != nil {
+		for _, tx := range m.Taxa() {
+			tn[tx] = true
+		}
+	}
+	if coll != nil {
+		for _, tx := range coll.Taxa() {
+			tn[tx] = true
+		}
+	}
+
+	ls := make([]string, 0, len(tn))
+	for n := range tn {
+		ls = append(ls, n)
+	}
+	slices.Sort(ls)
+	return ls
+}
+
+func readTaxa(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var ls []string
+	for i := 1; ; i++ {
+		ln, err := r.ReadString('\n')
+		if errors.Is(err, io.EOF) && ln == "" {
+			break
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("on file %q: line %d: %v", name, i, err)
+		}
+
+		n := names.Taxon(strings.ReplaceAll(ln, "_", " "))
+		if n == "" {
+			continue
+		}
+		if n[0] == '#' {
+			continue
+		}
+		ls = append(ls, n)
+	}
+	return ls, nil
+}
@@ -0,0 +1,24 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package report is a metapackage for commands
+// that produce figures and reports
+// of the data of a project.
+package report
+
+import (
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/report/heatmap"
+	"github.com/js-arias/phydata/cmd/phydata/report/seqplot"
+)
+
+func init() {
+	Command.Add(heatmap.Command)
+	Command.Add(seqplot.Command)
+}
+
+var Command = &command.Command{
+	Usage: "report <command> [<argument>...]",
+	Short: "commands for figures and reports",
+}
@@ -0,0 +1,302 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package seqplot implements a command to draw
+// the distribution of the length
+// and the ambiguity content
+// of the DNA sequences
+// of a PhyData project.
+package seqplot
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/project"
+)
+
+var Command = &command.Command{
+	Usage: `seqplot [-o|--output <file>]
+	[--gene <gene>] [--bins <number>]
+	<project-file>`,
+	Short: "draw the length and ambiguity of DNA sequences",
+	Long: `
+Command seqplot reads the DNA sequences of a PhyData project, and draws an
+SVG image with the distribution of the length, and the ambiguity content, of
+the sequences of each gene, so truncated, or chimeric, sequences can be
+spotted before the alignment.
+
+The argument of the command is the name of the project file.
+
+For each gene, the image has two histograms. The first one is the
+distribution of the length of the sequences, counted as the number of
+nucleotides (i.e., without gaps or missing positions), with a dashed line at
+the median length. The second one is the distribution of the ambiguity
+content of the sequences, i.e., the fraction of the nucleotides that are
+ambiguous IUPAC codes (e.g., 'n', or 'r'). Sequences much shorter than the
+median, or with a high fraction of ambiguities, should be reviewed.
+
+By default, the histograms have 20 bins. Use the flag --bins to define a
+different number of bins.
+
+By default, all genes are drawn. Use the flag --gene to draw only the
+sequences of a given gene.
+
+By default, the image will be printed in the standard output. To define an
+output file use the flag --output, or -o to define the file name.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var output string
+var geneFlag string
+var bins int
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+	c.Flags().StringVar(&geneFlag, "gene", "", "")
+	c.Flags().IntVar(&bins, "bins", 20, "")
+}
+
+func run(c *command.Command, args []string) (err error) {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if bins < 1 {
+		return c.UsageError("flag --bins: expecting a positive number")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	if p.Path(project.DNA) == "" {
+		return fmt.Errorf("undefined DNA file")
+	}
+	coll := dna.New()
+	if _, err := dnafiles.Read(p, coll); err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+
+	genes := coll.Genes()
+	if geneFlag != "" {
+		g := strings.ToLower(strings.TrimSpace(geneFlag))
+		if !slices.Contains(genes, g) {
+			return fmt.Errorf("on project %q: gene %q not found", args[0], geneFlag)
+		}
+		genes = []string{g}
+	}
+	if len(genes) == 0 {
+		return fmt.Errorf("on project %q: no sequences", args[0])
+	}
+
+	var gs []geneSeqs
+	for _, g := range genes {
+		gs = append(gs, sequenceStats(coll, g))
+	}
+
+	out := c.Stdout()
+	if output != "" {
+		var f *os.File
+		f, err = os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			e := f.Close()
+			if e != nil && err == nil {
+				err = e
+			}
+		}()
+		out = f
+	}
+
+	bw := bufio.NewWriter(out)
+	writeSVG(bw, gs)
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("while writing plot: %v", err)
+	}
+	return nil
+}
+
+// GeneSeqs is the length
+// and the ambiguity content
+// of the sequences of a gene.
+type geneSeqs struct {
+	gene  string
+	lens  []float64
+	ambig []float64
+}
+
+// SequenceStats returns the length
+// and the ambiguity content
+// of the sequences of a gene.
+func sequenceStats(coll *dna.Collection, gene string) geneSeqs {
+	gs := geneSeqs{gene: gene}
+	for _, sp := range coll.Specimens() {
+		for _, acc := range coll.GeneAccession(sp, gene) {
+			ln, amb := seqContent(coll.Sequence(sp, gene, acc))
+			if ln == 0 {
+				continue
+			}
+			gs.lens = append(gs.lens, float64(ln))
+			gs.ambig = append(gs.ambig, float64(amb)/float64(ln))
+		}
+	}
+	return gs
+}
+
+// SeqContent returns the number of nucleotides
+// of a sequence
+// (without gaps or missing positions),
+// and the number of ambiguous nucleotides.
+func seqContent(seq string) (ln, ambig int) {
+	for _, r := range strings.ToLower(seq) {
+		switch r {
+		case '-', '?', ' ':
+			continue
+		case 'a', 'c', 'g', 't', 'u':
+		default:
+			ambig++
+		}
+		ln++
+	}
+	return ln, ambig
+}
+
+// A histogram is the number of values
+// in a set of equally sized bins.
+type histogram struct {
+	min, max float64
+	counts   []int
+}
+
+// NewHistogram returns the histogram of a set of integer values
+// (e.g., the length of the sequences).
+// The number of bins is not larger
+// than the number of distinct values.
+func newHistogram(vals []float64, bins int) histogram {
+	min := slices.Min(vals)
+	max := slices.Max(vals) + 1
+	if n := int(max - min); n < bins {
+		bins = n
+	}
+	return newHistogramRange(vals, bins, min, max)
+}
+
+// NewHistogramRange returns the histogram of a set of values
+// in a given range.
+func newHistogramRange(vals []float64, bins int, min, max float64) histogram {
+	if max <= min {
+		max = min + 1
+	}
+	h := histogram{
+		min:    min,
+		max:    max,
+		counts: make([]int, bins),
+	}
+	width := (h.max - h.min) / float64(bins)
+	for _, v := range vals {
+		b := int((v - h.min) / width)
+		if b >= bins {
+			b = bins - 1
+		}
+		h.counts[b]++
+	}
+	return h
+}
+
+// Median returns the median of a set of values.
+func median(vals []float64) float64 {
+	s := slices.Clone(vals)
+	slices.Sort(s)
+	if len(s)%2 == 1 {
+		return s[len(s)/2]
+	}
+	return (s[len(s)/2-1] + s[len(s)/2]) / 2
+}
+
+// Sizes of the elements of the image
+// (in pixels).
+const (
+	panelWidth  = 300
+	panelHeight = 120
+	axisSpace   = 40 // space for the labels of the axis
+	titleSpace  = 24 // space for the title of each gene
+	margin      = 10
+	fontSize    = 11
+)
+
+// WriteSVG writes the histograms of the length
+// and ambiguity content
+// of the sequences of each gene
+// as an SVG image.
+func writeSVG(w io.Writer, gs []geneSeqs) {
+	rowHeight := titleSpace + panelHeight + axisSpace
+	width := margin + 2*(axisSpace+panelWidth+margin)
+	height := 2*margin + len(gs)*rowHeight
+
+	fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"sans-serif\" font-size=\"%d\">\n", width, height, width, height, fontSize)
+	fmt.Fprintf(w, "<rect width=\"100%%\" height=\"100%%\" fill=\"white\"/>\n")
+
+	for i, g := range gs {
+		y := margin + i*rowHeight
+		fmt.Fprintf(w, "<text x=\"%d\" y=\"%d\" font-weight=\"bold\">%s (%d sequences)</text>\n", margin, y+fontSize+4, html.EscapeString(g.gene), len(g.lens))
+		if len(g.lens) == 0 {
+			continue
+		}
+		y += titleSpace
+
+		x := margin + axisSpace
+		lh := newHistogram(g.lens, bins)
+		writeHistogram(w, x, y, lh, "length (nucleotides)", "%.0f")
+		med := median(g.lens)
+		mx := x + int((med-lh.min)/(lh.max-lh.min)*panelWidth)
+		fmt.Fprintf(w, "<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"#d95f02\" stroke-dasharray=\"4 2\"><title>median: %.0f</title></line>\n", mx, y, mx, y+panelHeight, med)
+
+		x += panelWidth + margin + axisSpace
+		ah := newHistogramRange(g.ambig, bins, 0, slices.Max(g.ambig))
+		writeHistogram(w, x, y, ah, "ambiguous nucleotides (fraction)", "%.3f")
+	}
+	fmt.Fprintf(w, "</svg>\n")
+}
+
+// WriteHistogram writes the bars and the axis
+// of a histogram,
+// with its top-left corner at x, y.
+func writeHistogram(w io.Writer, x, y int, h histogram, label, format string) {
+	top := slices.Max(h.counts)
+	bw := float64(panelWidth) / float64(len(h.counts))
+	vw := (h.max - h.min) / float64(len(h.counts))
+	for i, n := range h.counts {
+		if n == 0 {
+			continue
+		}
+		bh := float64(n) / float64(top) * panelHeight
+		from := h.min + float64(i)*vw
+		to := from + vw
+		fmt.Fprintf(w, "<rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"#3182bd\" stroke=\"white\" stroke-width=\"0.5\"><title>%s-%s: %d</title></rect>\n", float64(x)+float64(i)*bw, float64(y)+panelHeight-bh, bw, bh, fmt.Sprintf(format, from), fmt.Sprintf(format, to), n)
+	}
+
+	bottom := y + panelHeight
+	fmt.Fprintf(w, "<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"black\"/>\n", x, bottom, x+panelWidth, bottom)
+	fmt.Fprintf(w, "<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"black\"/>\n", x, y, x, bottom)
+	fmt.Fprintf(w, "<text x=\"%d\" y=\"%d\" text-anchor=\"end\">%d</text>\n", x-4, y+fontSize, top)
+	fmt.Fprintf(w, "<text x=\"%d\" y=\"%d\" text-anchor=\"end\">0</text>\n", x-4, bottom)
+	fmt.Fprintf(w, "<text x=\"%d\" y=\"%d\">%s</text>\n", x, bottom+fontSize+4, fmt.Sprintf(format, h.min))
+	fmt.Fprintf(w, "<text x=\"%d\" y=\"%d\" text-anchor=\"end\">%s</text>\n", x+panelWidth, bottom+fontSize+4, fmt.Sprintf(format, h.max))
+	fmt.Fprintf(w, "<text x=\"%d\" y=\"%d\" text-anchor=\"middle\">%s</text>\n", x+panelWidth/2, bottom+2*fontSize+8, html.EscapeString(label))
+}
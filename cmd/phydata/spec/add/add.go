@@ -0,0 +1,176 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package add implements a command to add specimen records
+// to a PhyData project.
+package add
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/project"
+	"github.com/js-arias/phydata/specimen"
+)
+
+var Command = &command.Command{
+	Usage: `add [-f|--file <specimen-file>]
+	<project-file> <specimen-data-file>`,
+	Short: "add specimen records to a project",
+	Long: `
+Command add reads a file with specimen records, and add the records to a
+PhyData project.
+
+The first argument of the command is the name of the project file. If no
+project file exists, a new project will be created.
+
+The second argument is the name of the file that contains the specimen
+records. It must be a TSV file with the fields 'taxon' and 'specimen', and
+optionally, the fields 'voucher' (the catalog code of the specimen in a
+collection), 'latitude' and 'longitude' (the coordinates of the collection
+site, in decimal degrees), 'locality', 'collector', 'date' (the collection
+date), and 'comments'. If a specimen is already in the project, its record
+will be updated with the values in the file.
+
+By default, the specimen records will be stored in the specimen file
+currently defined for the project. If the project does not have a specimen
+file, a new one will be created with the name 'specimens.tab'. A different
+file name can be defined using the flag --file or -f. If this flag is given
+and there is a specimen file already defined, then a new file will be created
+and used as the specimen file for the project (previously defined records
+will be preserved).
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var specFile string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&specFile, "file", "", "")
+	c.Flags().StringVar(&specFile, "f", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if len(args) < 2 {
+		return c.UsageError("expecting specimen file")
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := openProject(pFile)
+	if err != nil {
+		return err
+	}
+
+	reg := specimen.New()
+	if sf := p.Path(project.Specimens); sf != "" {
+		if err := readSpecFile(sf, reg); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+
+	in := args[1]
+	nr := specimen.New()
+	if err := readSpecFile(in, nr); err != nil {
+		return err
+	}
+
+	fields := []specimen.Field{
+		specimen.Voucher,
+		specimen.Latitude,
+		specimen.Longitude,
+		specimen.Locality,
+		specimen.Collector,
+		specimen.Date,
+		specimen.Comments,
+	}
+	for _, spec := range nr.Specimens() {
+		reg.Add(nr.Taxon(spec), spec)
+		for _, f := range fields {
+			v := nr.Val(spec, f)
+			if v == "" {
+				continue
+			}
+			reg.Set(spec, v, f)
+		}
+	}
+
+	if specFile == "" {
+		specFile = p.Path(project.Specimens)
+		if specFile == "" {
+			specFile = "specimens.tab"
+		}
+	}
+	if p.ReadOnly(project.Specimens, specFile) {
+		return fmt.Errorf("on project %q: file %q is read-only", pFile, p.Source(project.Specimens, specFile))
+	}
+	if err := writeSpecimens(specFile, reg); err != nil {
+		return err
+	}
+
+	p.Add(project.Specimens, specFile)
+	if err := p.Write(pFile); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func openProject(name string) (*project.Project, error) {
+	p, err := project.Read(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return project.New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable ot open project %q: %v", name, err)
+	}
+	return p, nil
+}
+
+func readSpecFile(name string, r *specimen.Registry) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := r.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeSpecimens(name string, r *specimen.Registry) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: specimen records\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Specimens))
+	if err := r.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package idigbio implements a command to fill
+// the collection data of the specimens
+// of a PhyData project
+// using the vouchers records in iDigBio.
+package idigbio
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/fetch"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/cmd/phydata/progress"
+	"github.com/js-arias/phydata/project"
+	"github.com/js-arias/phydata/specimen"
+)
+
+var Command = &command.Command{
+	Usage: "idigbio [--overwrite] <project-file>",
+	Short: "fill specimen data from iDigBio",
+	Long: `
+Command idigbio reads the specimen records of a PhyData project, and search
+the voucher of each specimen in iDigBio (see <https://www.idigbio.org>) to
+fill the locality, the collector, the collection date, and the geographic
+coordinates of the specimen.
+
+The argument of the command is the name of the project file.
+
+The voucher is expected to be a catalog code in the form '<institution>
+<catalog-number>' (e.g., 'FMNH 2485'), or a Darwin Core triplet in the form
+'<institution>:<collection>:<catalog-number>' (e.g., 'FMNH:Mammals:2485').
+Specimens without a voucher are ignored. A voucher is resolved only if it
+matches a single iDigBio record.
+
+By default, only undefined fields are filled. Use the flag --overwrite to
+replace the values already defined with the values of iDigBio.
+
+The output is a TSV table with the specimen, the voucher, and the reason of
+each voucher that could not be resolved.
+
+The command requires an internet connection. The requests are paced to avoid
+overloading the servers, and failed requests are retried with an increasing
+wait time. Use the environment variable PHYDATA_WAIT to define a longer time
+between requests (e.g., '1s'), and PHYDATA_RETRIES to define the maximum
+number of retries of a request.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var overwrite bool
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&overwrite, "overwrite", false, "")
+}
+
+// searchURL is the URL of the iDigBio search API
+// for specimen records.
+const searchURL = "https://search.idigbio.org/v2/search/records"
+
+// wait is the time between requests,
+// to avoid overloading the iDigBio servers.
+const wait = 100 * time.Millisecond
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+
+	sf := p.Path(project.Specimens)
+	if sf == "" {
+		return fmt.Errorf("undefined specimens file")
+	}
+	reg := specimen.New()
+	if err := readSpecFile(sf, reg); err != nil {
+		return fmt.Errorf("on project %q: %v", pFile, err)
+	}
+
+	client := fetch.New(wait)
+
+	tab := csv.NewWriter(c.Stdout())
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	if err := tab.Write([]string{"specimen", "voucher", "reason"}); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+
+	changed := false
+	count := progress.NewCounter("searching specimens", len(reg.Specimens()))
+	for _, spec := range reg.Specimens() {
+		count.Add(1)
+		v := reg.Val(spec, specimen.Voucher)
+		if v == "" {
+			continue
+		}
+		inst, cat := parseVoucher(v)
+		rec, n, err := search(client, inst, cat)
+		if err != nil {
+			return fmt.Errorf("when searching %q: %v", v, err)
+		}
+		if n != 1 {
+			reason := "not found"
+			if n > 1 {
+				reason = fmt.Sprintf("ambiguous: %d records", n)
+			}
+			if err := tab.Write([]string{spec, v, reason}); err != nil {
+				return fmt.Errorf("while writing data: %v", err)
+			}
+			continue
+		}
+
+		for f, val := range rec.fields() {
+			if val == "" {
+				continue
+			}
+			if !overwrite && reg.Val(spec, f) != "" {
+				continue
+			}
+			reg.Set(spec, val, f)
+			changed = true
+		}
+	}
+	count.Done()
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+
+	if !changed {
+		return nil
+	}
+	if p.ReadOnly(project.Specimens, sf) {
+		return fmt.Errorf("on project %q: file %q is read-only", pFile, p.Source(project.Specimens, sf))
+	}
+	if err := writeSpecimens(sf, reg); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseVoucher returns the institution code
+// and the catalog number of a voucher.
+func parseVoucher(v string) (inst, cat string) {
+	if strings.Contains(v, ":") {
+		parts := strings.Split(v, ":")
+		inst = strings.TrimSpace(parts[0])
+		cat = strings.TrimSpace(parts[len(parts)-1])
+		return inst, cat
+	}
+
+	f := strings.Fields(v)
+	if len(f) == 1 {
+		return "", f[0]
+	}
+	return f[0], strings.Join(f[1:], " ")
+}
+
+// A searchResult is the response
+// of the iDigBio search API.
+type searchResult struct {
+	Count int      `json:"itemCount"`
+	Items []record `json:"items"`
+}
+
+type record struct {
+	IndexTerms struct {
+		Locality  string `json:"locality"`
+		Collector string `json:"collector"`
+		Date      string `json:"datecollected"`
+		GeoPoint  *struct {
+			Lat float64 `json:"lat"`
+			Lon float64 `json:"lon"`
+		} `json:"geopoint"`
+	} `json:"indexTerms"`
+	Data map[string]any `json:"data"`
+}
+
+// Fields returns the specimen fields
+// of an iDigBio record.
+func (r record) fields() map[specimen.Field]string {
+	f := map[specimen.Field]string{
+		specimen.Locality:  r.data("dwc:locality", r.IndexTerms.Locality),
+		specimen.Collector: r.data("dwc:recordedBy", r.IndexTerms.Collector),
+		specimen.Date:      r.data("dwc:eventDate", r.IndexTerms.Date),
+	}
+	if d, _, ok := strings.Cut(f[specimen.Date], "T"); ok {
+		f[specimen.Date] = d
+	}
+	if gp := r.IndexTerms.GeoPoint; gp != nil {
+		f[specimen.Latitude] = strconv.FormatFloat(gp.Lat, 'f', -1, 64)
+		f[specimen.Longitude] = strconv.FormatFloat(gp.Lon, 'f', -1, 64)
+	}
+	return f
+}
+
+// Data returns a value
+// from the original data of the record,
+// or def, if the value is not defined.
+func (r record) data(key, def string) string {
+	if v, ok := r.Data[key].(string); ok && strings.TrimSpace(v) != "" {
+		return v
+	}
+	return def
+}
+
+func search(client *fetch.Client, inst, cat string) (record, int, error) {
+	rq := map[string]string{
+		"catalognumber": strings.ToLower(cat),
+	}
+	if inst != "" {
+		rq["institutioncode"] = strings.ToLower(inst)
+	}
+	b, err := json.Marshal(rq)
+	if err != nil {
+		return record{}, 0, err
+	}
+	q := url.Values{}
+	q.Set("rq", string(b))
+	q.Set("limit", "1")
+
+	var sr searchResult
+	if err := client.JSON(http.MethodGet, searchURL+"?"+q.Encode(), nil, &sr); err != nil {
+		return record{}, 0, err
+	}
+	if sr.Count != 1 || len(sr.Items) == 0 {
+		return record{}, sr.Count, nil
+	}
+	return sr.Items[0], 1, nil
+}
+
+func readSpecFile(name string, r *specimen.Registry) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := r.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeSpecimens(name string, r *specimen.Registry) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: specimen records\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Specimens))
+	if err := r.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package spec is a metapackage for commands
+// that dealt with specimen records.
+package spec
+
+import (
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/spec/add"
+	"github.com/js-arias/phydata/cmd/phydata/spec/idigbio"
+)
+
+func init() {
+	Command.Add(add.Command)
+	Command.Add(idigbio.Command)
+}
+
+var Command = &command.Command{
+	Usage: "spec <command> [<argument>...]",
+	Short: "commands for specimen records",
+}
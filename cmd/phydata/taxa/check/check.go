@@ -0,0 +1,430 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package check implements a command to detect
+// likely misspelled taxon names
+// in a PhyData project.
+package check
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/matrix/protein"
+	"github.com/js-arias/phydata/project"
+	"github.com/js-arias/phydata/specimen"
+)
+
+var Command = &command.Command{
+	Usage: `check [--max-dist <number>] [--merge]
+	<project-file>`,
+	Short: "detect likely misspelled taxon names",
+	Long: `
+Command check reads a PhyData project, and search for taxon names that are
+very similar (for example "Bufonidae" and "Bufbnidae"), and then are likely
+misspellings, or duplicates, of the same taxon. Taxon names of all the
+datasets of the project (observations, DNA, proteins and specimens) are
+checked.
+
+The argument of the command is the name of the project file.
+
+Two names are similar if the edit distance (the number of insertions,
+deletions, or substitutions of letters, i.e., the Levenshtein distance)
+between them is equal or less than a threshold. By default, the maximum
+distance is 2. Use the flag --max-dist to change this value. Similar names
+are grouped in clusters.
+
+The output is a TSV table with the cluster, the taxon name, the number of
+specimens of the taxon (in all datasets), and the distance to the name with
+more specimens of the cluster.
+
+If the flag --merge is defined, for each name of a cluster, the command will
+ask to merge the name into the name with more specimens of the cluster. The
+answer must be 'y' to merge the names. If any name is merged, the datasets of
+the project will be updated.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var maxDist int
+var merge bool
+
+func setFlags(c *command.Command) {
+	c.Flags().IntVar(&maxDist, "max-dist", 2, "")
+	c.Flags().BoolVar(&merge, "merge", false, "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+	if maxDist < 1 {
+		return c.UsageError("invalid --max-dist value")
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+
+	var m *matrix.Matrix
+	if mf := p.Path(project.Observations); mf != "" {
+		m = matrix.New()
+		if err := readObsFile(mf, m); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+	var coll *dna.Collection
+	var files *dnafiles.Files
+	if p.Path(project.DNA) != "" {
+		coll = dna.New()
+		files, err = dnafiles.Read(p, coll)
+		if err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+	var prot *protein.Collection
+	if pf := p.Path(project.Proteins); pf != "" {
+		prot = protein.New()
+		if err := readProteinFile(pf, prot); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+	var reg *specimen.Registry
+	if sf := p.Path(project.Specimens); sf != "" {
+		reg = specimen.New()
+		if err := readSpecFile(sf, reg); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+	if m == nil && coll == nil && prot == nil && reg == nil {
+		return fmt.Errorf("on project %q: no datasets with taxon names", pFile)
+	}
+
+	count := make(map[string]int)
+	if m != nil {
+		for _, tx := range m.Taxa() {
+			count[tx] += len(m.TaxSpec(tx))
+		}
+	}
+	if coll != nil {
+		for _, tx := range coll.Taxa() {
+			count[tx] += len(coll.TaxSpec(tx))
+		}
+	}
+	if prot != nil {
+		for _, tx := range prot.Taxa() {
+			count[tx] += len(prot.TaxSpec(tx))
+		}
+	}
+	if reg != nil {
+		for _, tx := range reg.Taxa() {
+			count[tx] += len(reg.TaxSpec(tx))
+		}
+	}
+
+	clusters := makeClusters(count)
+	if err := writeClusters(c.Stdout(), clusters, count); err != nil {
+		return err
+	}
+	if !merge || len(clusters) == 0 {
+		return nil
+	}
+
+	in := bufio.NewReader(c.Stdin())
+	var merged bool
+	for _, cl := range clusters {
+		to := cl[0]
+		for _, tx := range cl[1:] {
+			fmt.Fprintf(c.Stderr(), "merge %q into %q? [y/N] ", tx, to)
+			ln, err := in.ReadString('\n')
+			if err != nil && ln == "" {
+				return nil
+			}
+			if strings.ToLower(strings.TrimSpace(ln)) != "y" {
+				continue
+			}
+			if m != nil {
+				m.RenameTaxon(tx, to)
+			}
+			if coll != nil {
+				coll.RenameTaxon(tx, to)
+			}
+			if prot != nil {
+				prot.RenameTaxon(tx, to)
+			}
+			if reg != nil {
+				reg.RenameTaxon(tx, to)
+			}
+			merged = true
+		}
+	}
+	if !merged {
+		return nil
+	}
+
+	for _, set := range []project.Dataset{project.Observations, project.Proteins, project.Specimens} {
+		if f := p.Path(set); f != "" && p.ReadOnly(set, f) {
+			return fmt.Errorf("on project %q: file %q is read-only", pFile, p.Source(set, f))
+		}
+	}
+	if m != nil {
+		if err := writeObs(p.Path(project.Observations), m); err != nil {
+			return err
+		}
+	}
+	if coll != nil {
+		if err := files.Write(coll); err != nil {
+			return err
+		}
+	}
+	if prot != nil {
+		if err := writeProteins(p.Path(project.Proteins), prot); err != nil {
+			return err
+		}
+	}
+	if reg != nil {
+		if err := writeSpecimens(p.Path(project.Specimens), reg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MakeClusters returns the clusters of similar names.
+// In each cluster,
+// the first name is the name with more specimens.
+func makeClusters(count map[string]int) [][]string {
+	names := make([]string, 0, len(count))
+	for tx := range count {
+		names = append(names, tx)
+	}
+	slices.Sort(names)
+
+	// single-linkage clusters
+	parent := make([]int, len(names))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i, a := range names {
+		la := strings.ToLower(a)
+		for j := i + 1; j < len(names); j++ {
+			lb := strings.ToLower(names[j])
+			if d := distance(la, lb); d > maxDist {
+				continue
+			}
+			pi, pj := find(i), find(j)
+			if pi != pj {
+				parent[pj] = pi
+			}
+		}
+	}
+
+	groups := make(map[int][]string)
+	for i, n := range names {
+		r := find(i)
+		groups[r] = append(groups[r], n)
+	}
+
+	var clusters [][]string
+	for _, g := range groups {
+		if len(g) < 2 {
+			continue
+		}
+		slices.SortStableFunc(g, func(a, b string) int {
+			return count[b] - count[a]
+		})
+		clusters = append(clusters, g)
+	}
+	slices.SortFunc(clusters, func(a, b []string) int {
+		return strings.Compare(a[0], b[0])
+	})
+	return clusters
+}
+
+func writeClusters(w io.Writer, clusters [][]string, count map[string]int) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	if err := tab.Write([]string{"cluster", "taxon", "specimens", "distance"}); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+	for i, cl := range clusters {
+		to := strings.ToLower(cl[0])
+		for _, tx := range cl {
+			row := []string{
+				strconv.Itoa(i + 1),
+				tx,
+				strconv.Itoa(count[tx]),
+				strconv.Itoa(distance(to, strings.ToLower(tx))),
+			}
+			if err := tab.Write(row); err != nil {
+				return fmt.Errorf("while writing data: %v", err)
+			}
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+// Distance returns the Levenshtein distance
+// between two strings.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func readObsFile(name string, m *matrix.Matrix) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := m.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	if w := m.Warnings(); len(w) > 0 {
+		// skipped rows would be lost when writing the file
+		return fmt.Errorf("while reading file %q: %v (see 'phydata obs validate')", name, w[0])
+	}
+	return nil
+}
+
+func readProteinFile(name string, c *protein.Collection) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := c.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func readSpecFile(name string, r *specimen.Registry) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := r.ReadTSV(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeObs(name string, m *matrix.Matrix) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: character observations\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Observations))
+	if err := m.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeProteins(name string, c *protein.Collection) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: amino acid sequences\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Proteins))
+	if err := c.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeSpecimens(name string, r *specimen.Registry) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: specimen records\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Specimens))
+	if err := r.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
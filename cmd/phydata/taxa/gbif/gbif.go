@@ -0,0 +1,326 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package gbif implements a command to verify
+// the taxon names of a PhyData project
+// with the GBIF backbone taxonomy.
+package gbif
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/ages"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/cmd/phydata/fetch"
+	"github.com/js-arias/phydata/cmd/phydata/progress"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/matrix/protein"
+	"github.com/js-arias/phydata/project"
+	"github.com/js-arias/phydata/specimen"
+)
+
+var Command = &command.Command{
+	Usage: `gbif [--kingdom <name>] [--rename <file>]
+	<project-file>`,
+	Short: "verify taxon names with GBIF",
+	Long: `
+Command gbif reads a PhyData project, and search each taxon name of the
+project in the GBIF backbone taxonomy (see <https://www.gbif.org>). Taxon
+names of all the datasets of the project are searched.
+
+The argument of the command is the name of the project file.
+
+The output is a TSV table with the taxon name, the status of the name in GBIF
+(ACCEPTED, SYNONYM, DOUBTFUL, or NONE if the name is not found), the kind of
+match (EXACT, FUZZY, HIGHERRANK, or NONE), the matched name, the accepted
+name, and the GBIF key of the matched name.
+
+To resolve homonyms across kingdoms, use the flag --kingdom to define the
+kingdom of the taxa (e.g., 'Animalia').
+
+If the flag --rename is defined with a file, a rename mapping will be written
+in that file. The mapping includes all the taxa of the project, and for the
+synonyms and the fuzzy matches, a second column with the accepted name. This
+file can be used as the taxa file of the matrix command, to export the matrix
+with the accepted names.
+
+The command requires an internet connection. The requests are paced to avoid
+overloading the servers, and failed requests are retried with an increasing
+wait time. Use the environment variable PHYDATA_WAIT to define a longer time
+between requests (e.g., '1s'), and PHYDATA_RETRIES to define the maximum
+number of retries of a request.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var kingdom string
+var renameFile string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&kingdom, "kingdom", "", "")
+	c.Flags().StringVar(&renameFile, "rename", "", "")
+}
+
+// apiURL is the URL of the GBIF species API.
+const apiURL = "https://api.gbif.org/v1/species"
+
+// wait is the time between requests,
+// to avoid overloading the GBIF servers.
+const wait = 100 * time.Millisecond
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	p, err := project.Read(args[0])
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", args[0], err)
+	}
+
+	taxa, err := projectTaxa(p)
+	if err != nil {
+		return fmt.Errorf("on project %q: %v", args[0], err)
+	}
+	if len(taxa) == 0 {
+		return fmt.Errorf("on project %q: no taxa", args[0])
+	}
+
+	client := fetch.New(wait)
+
+	tab := csv.NewWriter(c.Stdout())
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	if err := tab.Write([]string{"taxon", "status", "match", "name", "accepted", "key"}); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+
+	rename := make(map[string]string)
+	count := progress.NewCounter("searching taxa", len(taxa))
+	for _, tx := range taxa {
+		count.Add(1)
+		m, err := match(client, tx)
+		if err != nil {
+			return fmt.Errorf("when searching %q: %v", tx, err)
+		}
+
+		accepted := m.CanonicalName
+		if m.Synonym || m.Status == "SYNONYM" {
+			accepted, err = acceptedName(client, m)
+			if err != nil {
+				return fmt.Errorf("when searching %q: %v", tx, err)
+			}
+		}
+		if m.MatchType == "HIGHERRANK" {
+			accepted = ""
+		}
+		if accepted != "" && !strings.EqualFold(accepted, tx) {
+			rename[tx] = accepted
+		}
+
+		key := ""
+		if m.UsageKey != 0 {
+			key = strconv.Itoa(m.UsageKey)
+		}
+		status := m.Status
+		if status == "" {
+			status = "NONE"
+		}
+		row := []string{
+			tx,
+			status,
+			m.MatchType,
+			m.CanonicalName,
+			accepted,
+			key,
+		}
+		if err := tab.Write(row); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+		tab.Flush()
+	}
+	count.Done()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+
+	if renameFile != "" {
+		if err := writeRename(renameFile, taxa, rename); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A nameMatch is the response
+// of the GBIF species match API.
+type nameMatch struct {
+	UsageKey         int    `json:"usageKey"`
+	AcceptedUsageKey int    `json:"acceptedUsageKey"`
+	CanonicalName    string `json:"canonicalName"`
+	Rank             string `json:"rank"`
+	Status           string `json:"status"`
+	MatchType        string `json:"matchType"`
+	Synonym          bool   `json:"synonym"`
+	Species          string `json:"species"`
+}
+
+func match(client *fetch.Client, name string) (nameMatch, error) {
+	q := url.Values{}
+	q.Set("name", name)
+	if kingdom != "" {
+		q.Set("kingdom", kingdom)
+	}
+
+	var m nameMatch
+	if err := client.JSON(http.MethodGet, apiURL+"/match?"+q.Encode(), nil, &m); err != nil {
+		return nameMatch{}, err
+	}
+	return m, nil
+}
+
+// A usage is the response
+// of the GBIF species API
+// for a name usage.
+type usage struct {
+	Key           int    `json:"key"`
+	CanonicalName string `json:"canonicalName"`
+	AcceptedKey   int    `json:"acceptedKey"`
+}
+
+// AcceptedName returns the accepted name
+// of a synonym.
+func acceptedName(client *fetch.Client, m nameMatch) (string, error) {
+	key := m.AcceptedUsageKey
+	if key == 0 {
+		var u usage
+		if err := client.JSON(http.MethodGet, apiURL+"/"+strconv.Itoa(m.UsageKey), nil, &u); err != nil {
+			return "", err
+		}
+		key = u.AcceptedKey
+	}
+	if key == 0 {
+		if m.Rank == "SPECIES" {
+			return m.Species, nil
+		}
+		return "", nil
+	}
+
+	var u usage
+	if err := client.JSON(http.MethodGet, apiURL+"/"+strconv.Itoa(key), nil, &u); err != nil {
+		return "", err
+	}
+	return u.CanonicalName, nil
+}
+
+func writeRename(name string, taxa []string, rename map[string]string) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: GBIF rename mapping\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	for _, tx := range taxa {
+		if n, ok := rename[tx]; ok {
+			fmt.Fprintf(f, "%s\t%s\n", tx, n)
+			continue
+		}
+		fmt.Fprintf(f, "%s\n", tx)
+	}
+	return nil
+}
+
+// ProjectTaxa returns the taxa of all datasets
+// of a project.
+func projectTaxa(p *project.Project) ([]string, error) {
+	taxa := make(map[string]bool)
+	if mf := p.Path(project.Observations); mf != "" {
+		m := matrix.New()
+		if err := readData(mf, m.ReadTSV); err != nil {
+			return nil, err
+		}
+		if w := m.Warnings(); len(w) > 0 {
+			// the taxa of the skipped rows would be ignored
+			return nil, fmt.Errorf("while reading file %q: %v (see 'phydata obs validate')", mf, w[0])
+		}
+		for _, tx := range m.Taxa() {
+			taxa[tx] = true
+		}
+	}
+	if p.Path(project.DNA) != "" {
+		coll := dna.New()
+		if _, err := dnafiles.Read(p, coll); err != nil {
+			return nil, err
+		}
+		for _, tx := range coll.Taxa() {
+			taxa[tx] = true
+		}
+	}
+	if pf := p.Path(project.Proteins); pf != "" {
+		coll := protein.New()
+		if err := readData(pf, coll.ReadTSV); err != nil {
+			return nil, err
+		}
+		for _, tx := range coll.Taxa() {
+			taxa[tx] = true
+		}
+	}
+	if sf := p.Path(project.Specimens); sf != "" {
+		reg := specimen.New()
+		if err := readData(sf, reg.ReadTSV); err != nil {
+			return nil, err
+		}
+		for _, tx := range reg.Taxa() {
+			taxa[tx] = true
+		}
+	}
+	if af := p.Path(project.Ages); af != "" {
+		a := ages.New()
+		if err := readData(af, a.ReadTSV); err != nil {
+			return nil, err
+		}
+		for _, tx := range a.Taxa() {
+			taxa[tx] = true
+		}
+	}
+
+	ls := make([]string, 0, len(taxa))
+	for tx := range taxa {
+		ls = append(ls, tx)
+	}
+	slices.Sort(ls)
+	return ls, nil
+}
+
+func readData(name string, read func(io.Reader) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := read(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
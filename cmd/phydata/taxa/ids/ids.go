@@ -0,0 +1,455 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package ids implements a command to fill
+// the external identifiers of the taxa
+// of a PhyData project.
+package ids
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/ages"
+	"github.com/js-arias/phydata/cmd/phydata/dnafiles"
+	"github.com/js-arias/phydata/cmd/phydata/fetch"
+	"github.com/js-arias/phydata/cmd/phydata/lock"
+	"github.com/js-arias/phydata/matrix"
+	"github.com/js-arias/phydata/matrix/dna"
+	"github.com/js-arias/phydata/matrix/protein"
+	"github.com/js-arias/phydata/project"
+	"github.com/js-arias/phydata/specimen"
+	"github.com/js-arias/phydata/taxonomy"
+)
+
+var Command = &command.Command{
+	Usage: `ids [--source <list>] [--overwrite]
+	[-f|--file <taxonomy-file>]
+	<project-file>`,
+	Short: "fill external taxon identifiers",
+	Long: `
+Command ids reads a PhyData project, and search the taxon names of the
+project in external taxonomic databases, to fill the identifiers of each
+taxon in the taxonomy dataset of the project. Taxon names of all the
+datasets of the project are searched.
+
+The argument of the command is the name of the project file.
+
+The identifiers are:
+
+	ott   the taxon ID in the Open Tree of Life taxonomy
+	      (see <https://tree.opentreeoflife.org>)
+	ncbi  the taxon ID in the NCBI taxonomy
+	      (see <https://www.ncbi.nlm.nih.gov/taxonomy>)
+	gbif  the taxon key in the GBIF backbone taxonomy
+	      (see <https://www.gbif.org>)
+
+By default, all identifiers are searched. Use the flag --source with a
+comma-separated list of identifiers to search only some of them (e.g.,
+'--source ott,ncbi'). Only exact matches of the names are accepted.
+
+The NCBI asks the users of its E-utilities to identify themselves with an
+email, and accepts more requests per second from users with an API key. Both
+values can be defined with the environment variables NCBI_EMAIL and
+NCBI_API_KEY, or with the keys 'entrez-email' and 'entrez-api-key' of the
+configuration file (see 'phydata help config').
+
+The requests are paced to avoid overloading the servers, and failed requests
+are retried with an increasing wait time. Use the environment variable
+PHYDATA_WAIT to define a longer time between requests (e.g., '1s'), and
+PHYDATA_RETRIES to define the maximum number of retries of a request.
+
+By default, only undefined identifiers are searched. Use the flag --overwrite
+to search all identifiers, replacing the identifiers already defined. If a
+name is not found, the previous identifier is preserved.
+
+By default, the identifiers will be stored in the taxonomy file currently
+defined for the project. If the project does not have a taxonomy file, a new
+one will be created with the name 'taxonomy.tab'. A different file name can
+be defined using the flag --file or -f.
+
+The output is a TSV table with the taxon name and the identifiers of each
+taxon.
+
+The command requires an internet connection.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var sources string
+var overwrite bool
+var taxFile string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&sources, "source", "ott,ncbi,gbif", "")
+	c.Flags().BoolVar(&overwrite, "overwrite", false, "")
+	c.Flags().StringVar(&taxFile, "file", "", "")
+	c.Flags().StringVar(&taxFile, "f", "", "")
+}
+
+// API URLs of the taxonomic databases.
+const (
+	ottURL  = "https://api.opentreeoflife.org/v3/tnrs/match_names"
+	ncbiURL = "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/esearch.fcgi"
+	gbifURL = "https://api.gbif.org/v1/species/match"
+)
+
+// wait is the time between requests,
+// to avoid overloading the servers
+// (NCBI accepts up to three requests per second).
+const wait = 350 * time.Millisecond
+
+// keyWait is the time between requests
+// to the NCBI
+// when an API key is defined
+// (NCBI accepts up to ten requests per second with a key).
+const keyWait = 110 * time.Millisecond
+
+// ottBatch is the number of names
+// searched in a single request
+// to the Open Tree of Life.
+const ottBatch = 250
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting project file")
+	}
+
+	resolvers := map[taxonomy.Field]func(*fetch.Client, []string) (map[string]string, error){
+		taxonomy.OTT:  searchOTT,
+		taxonomy.NCBI: searchNCBI,
+		taxonomy.GBIF: searchGBIF,
+	}
+	var fields []taxonomy.Field
+	for _, s := range strings.Split(sources, ",") {
+		f := taxonomy.Field(strings.ToLower(strings.TrimSpace(s)))
+		if f == "" {
+			continue
+		}
+		if _, ok := resolvers[f]; !ok {
+			return c.UsageError(fmt.Sprintf("unknown source %q", s))
+		}
+		if slices.Contains(fields, f) {
+			continue
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return c.UsageError("expecting a source")
+	}
+
+	pFile := args[0]
+	unlock, err := lock.Project(pFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := project.Read(pFile)
+	if err != nil {
+		return fmt.Errorf("unable ot open project %q: %v", pFile, err)
+	}
+
+	tx := taxonomy.New()
+	if tf := p.Path(project.Taxonomy); tf != "" {
+		if err := readData(tf, tx.ReadTSV); err != nil {
+			return fmt.Errorf("on project %q: %v", pFile, err)
+		}
+	}
+
+	taxa, err := projectTaxa(p)
+	if err != nil {
+		return fmt.Errorf("on project %q: %v", pFile, err)
+	}
+	for _, name := range taxa {
+		tx.Add(name)
+	}
+	if len(tx.Taxa()) == 0 {
+		return fmt.Errorf("on project %q: no taxa", pFile)
+	}
+
+	for _, f := range fields {
+		var names []string
+		for _, name := range tx.Taxa() {
+			if !overwrite && tx.Val(name, f) != "" {
+				continue
+			}
+			names = append(names, name)
+		}
+		if len(names) == 0 {
+			continue
+		}
+
+		w := wait
+		if key, _ := fetch.Entrez(); f == taxonomy.NCBI && key != "" {
+			w = keyWait
+		}
+		ids, err := resolvers[f](fetch.New(w), names)
+		if err != nil {
+			return fmt.Errorf("when searching %s identifiers: %v", f, err)
+		}
+		for name, id := range ids {
+			tx.Set(name, id, f)
+		}
+	}
+
+	if taxFile == "" {
+		taxFile = p.Path(project.Taxonomy)
+		if taxFile == "" {
+			taxFile = "taxonomy.tab"
+		}
+	}
+	if p.ReadOnly(project.Taxonomy, taxFile) {
+		return fmt.Errorf("on project %q: file %q is read-only", pFile, p.Source(project.Taxonomy, taxFile))
+	}
+	if err := writeTaxonomy(taxFile, tx); err != nil {
+		return err
+	}
+	p.Add(project.Taxonomy, taxFile)
+	if err := p.Write(pFile); err != nil {
+		return err
+	}
+
+	return writeIDs(c.Stdout(), tx)
+}
+
+func writeIDs(w io.Writer, tx *taxonomy.Taxonomy) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	if err := tab.Write([]string{"taxon", "ott", "ncbi", "gbif"}); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+	for _, name := range tx.Taxa() {
+		row := []string{
+			name,
+			tx.Val(name, taxonomy.OTT),
+			tx.Val(name, taxonomy.NCBI),
+			tx.Val(name, taxonomy.GBIF),
+		}
+		if err := tab.Write(row); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+	}
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+// An ottMatch is the response
+// of the Open Tree of Life TNRS API.
+type ottMatch struct {
+	Results []struct {
+		Name    string `json:"name"`
+		Matches []struct {
+			Approximate bool `json:"is_approximate_match"`
+			Taxon       struct {
+				OTT  int    `json:"ott_id"`
+				Name string `json:"name"`
+			} `json:"taxon"`
+		} `json:"matches"`
+	} `json:"results"`
+}
+
+func searchOTT(client *fetch.Client, names []string) (map[string]string, error) {
+	ids := make(map[string]string)
+	for i := 0; i < len(names); i += ottBatch {
+		end := min(i+ottBatch, len(names))
+		q := map[string]any{
+			"names":                   names[i:end],
+			"do_approximate_matching": false,
+		}
+		body, err := json.Marshal(q)
+		if err != nil {
+			return nil, err
+		}
+
+		var m ottMatch
+		if err := client.JSON(http.MethodPost, ottURL, body, &m); err != nil {
+			return nil, err
+		}
+		for _, r := range m.Results {
+			for _, mt := range r.Matches {
+				if mt.Approximate || mt.Taxon.OTT == 0 {
+					continue
+				}
+				ids[r.Name] = strconv.Itoa(mt.Taxon.OTT)
+				break
+			}
+		}
+	}
+	return ids, nil
+}
+
+// An ncbiSearch is the response
+// of the NCBI E-utilities search API.
+type ncbiSearch struct {
+	Result struct {
+		IDs []string `json:"idlist"`
+	} `json:"esearchresult"`
+}
+
+func searchNCBI(client *fetch.Client, names []string) (map[string]string, error) {
+	key, email := fetch.Entrez()
+	ids := make(map[string]string)
+	for _, name := range names {
+		q := url.Values{}
+		q.Set("db", "taxonomy")
+		q.Set("term", "\""+name+"\"[Scientific Name]")
+		q.Set("retmode", "json")
+		q.Set("tool", "phydata")
+		if email != "" {
+			q.Set("email", email)
+		}
+		if key != "" {
+			q.Set("api_key", key)
+		}
+
+		var s ncbiSearch
+		if err := client.JSON(http.MethodGet, ncbiURL+"?"+q.Encode(), nil, &s); err != nil {
+			return nil, fmt.Errorf("when searching %q: %v", name, err)
+		}
+		if len(s.Result.IDs) != 1 {
+			// not found, or ambiguous
+			continue
+		}
+		ids[name] = s.Result.IDs[0]
+	}
+	return ids, nil
+}
+
+// A gbifMatch is the response
+// of the GBIF species match API.
+type gbifMatch struct {
+	UsageKey  int    `json:"usageKey"`
+	MatchType string `json:"matchType"`
+}
+
+func searchGBIF(client *fetch.Client, names []string) (map[string]string, error) {
+	ids := make(map[string]string)
+	for _, name := range names {
+		q := url.Values{}
+		q.Set("name", name)
+		q.Set("strict", "true")
+
+		var m gbifMatch
+		if err := client.JSON(http.MethodGet, gbifURL+"?"+q.Encode(), nil, &m); err != nil {
+			return nil, fmt.Errorf("when searching %q: %v", name, err)
+		}
+		if m.MatchType != "EXACT" || m.UsageKey == 0 {
+			continue
+		}
+		ids[name] = strconv.Itoa(m.UsageKey)
+	}
+	return ids, nil
+}
+
+// ProjectTaxa returns the taxa of all datasets
+// of a project.
+func projectTaxa(p *project.Project) ([]string, error) {
+	taxa := make(map[string]bool)
+	if mf := p.Path(project.Observations); mf != "" {
+		m := matrix.New()
+		if err := readData(mf, m.ReadTSV); err != nil {
+			return nil, err
+		}
+		if w := m.Warnings(); len(w) > 0 {
+			// the taxa of the skipped rows would be ignored
+			return nil, fmt.Errorf("while reading file %q: %v (see 'phydata obs validate')", mf, w[0])
+		}
+		for _, tx := range m.Taxa() {
+			taxa[tx] = true
+		}
+	}
+	if p.Path(project.DNA) != "" {
+		coll := dna.New()
+		if _, err := dnafiles.Read(p, coll); err != nil {
+			return nil, err
+		}
+		for _, tx := range coll.Taxa() {
+			taxa[tx] = true
+		}
+	}
+	if pf := p.Path(project.Proteins); pf != "" {
+		coll := protein.New()
+		if err := readData(pf, coll.ReadTSV); err != nil {
+			return nil, err
+		}
+		for _, tx := range coll.Taxa() {
+			taxa[tx] = true
+		}
+	}
+	if sf := p.Path(project.Specimens); sf != "" {
+		reg := specimen.New()
+		if err := readData(sf, reg.ReadTSV); err != nil {
+			return nil, err
+		}
+		for _, tx := range reg.Taxa() {
+			taxa[tx] = true
+		}
+	}
+	if af := p.Path(project.Ages); af != "" {
+		a := ages.New()
+		if err := readData(af, a.ReadTSV); err != nil {
+			return nil, err
+		}
+		for _, tx := range a.Taxa() {
+			taxa[tx] = true
+		}
+	}
+
+	ls := make([]string, 0, len(taxa))
+	for tx := range taxa {
+		ls = append(ls, tx)
+	}
+	slices.Sort(ls)
+	return ls, nil
+}
+
+func readData(name string, read func(io.Reader) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := read(f); err != nil {
+		return fmt.Errorf("while reading file %q: %v", name, err)
+	}
+	return nil
+}
+
+func writeTaxonomy(name string, tx *taxonomy.Taxonomy) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	fmt.Fprintf(f, "# phydata: taxonomy\n")
+	fmt.Fprintf(f, "# data saved on: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# %s\n", project.VersionComment(project.Taxonomy))
+	if err := tx.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package taxa is a metapackage for commands
+// that dealt with the taxon names
+// of all the datasets of a project.
+package taxa
+
+import (
+	"github.com/js-arias/command"
+	"github.com/js-arias/phydata/cmd/phydata/taxa/check"
+	"github.com/js-arias/phydata/cmd/phydata/taxa/gbif"
+	"github.com/js-arias/phydata/cmd/phydata/taxa/ids"
+)
+
+func init() {
+	Command.Add(check.Command)
+	Command.Add(gbif.Command)
+	Command.Add(ids.Command)
+}
+
+var Command = &command.Command{
+	Usage: "taxa <command> [<argument>...]",
+	Short: "commands for taxon names",
+}
@@ -0,0 +1,306 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package coverage implements measures of the taxon coverage
+// of the partitions of a supermatrix.
+package coverage
+
+import (
+	"math/rand"
+	"slices"
+)
+
+// A Coverage is a table of taxa and partitions
+// (e.g., genes, or morphology)
+// that indicates whether a taxon has data
+// for a partition.
+type Coverage struct {
+	taxa  map[string]bool
+	parts map[string]map[string]bool
+}
+
+// New creates a new empty coverage table.
+func New() *Coverage {
+	return &Coverage{
+		taxa:  make(map[string]bool),
+		parts: make(map[string]map[string]bool),
+	}
+}
+
+// Clone returns a copy of the table.
+func (c *Coverage) Clone() *Coverage {
+	nc := New()
+	for t := range c.taxa {
+		nc.taxa[t] = true
+	}
+	for p, tx := range c.parts {
+		np := make(map[string]bool, len(tx))
+		for t := range tx {
+			np[t] = true
+		}
+		nc.parts[p] = np
+	}
+	return nc
+}
+
+// AddTaxon adds a taxon to the table,
+// without data for any partition.
+func (c *Coverage) AddTaxon(taxon string) {
+	c.taxa[taxon] = true
+}
+
+// AddPartition adds a partition to the table,
+// without data for any taxon.
+func (c *Coverage) AddPartition(part string) {
+	if _, ok := c.parts[part]; ok {
+		return
+	}
+	c.parts[part] = make(map[string]bool)
+}
+
+// Add sets a taxon as having data
+// for a partition.
+func (c *Coverage) Add(part, taxon string) {
+	c.AddTaxon(taxon)
+	c.AddPartition(part)
+	c.parts[part][taxon] = true
+}
+
+// Has returns true if a taxon has data
+// for a partition.
+func (c *Coverage) Has(part, taxon string) bool {
+	return c.parts[part][taxon]
+}
+
+// DeletePartition removes a partition from the table.
+func (c *Coverage) DeletePartition(part string) {
+	delete(c.parts, part)
+}
+
+// DeleteTaxon removes a taxon from the table.
+func (c *Coverage) DeleteTaxon(taxon string) {
+	delete(c.taxa, taxon)
+	for _, p := range c.parts {
+		delete(p, taxon)
+	}
+}
+
+// Partitions returns the partitions of the table.
+func (c *Coverage) Partitions() []string {
+	parts := make([]string, 0, len(c.parts))
+	for p := range c.parts {
+		parts = append(parts, p)
+	}
+	slices.Sort(parts)
+	return parts
+}
+
+// Taxa returns the taxa of the table.
+func (c *Coverage) Taxa() []string {
+	taxa := make([]string, 0, len(c.taxa))
+	for t := range c.taxa {
+		taxa = append(taxa, t)
+	}
+	slices.Sort(taxa)
+	return taxa
+}
+
+// Occupancy returns the fraction of taxa
+// with data for a partition.
+func (c *Coverage) Occupancy(part string) float64 {
+	if len(c.taxa) == 0 {
+		return 0
+	}
+	return float64(len(c.parts[part])) / float64(len(c.taxa))
+}
+
+// TaxonOccupancy returns the fraction of partitions
+// with data for a taxon.
+func (c *Coverage) TaxonOccupancy(taxon string) float64 {
+	if len(c.parts) == 0 {
+		return 0
+	}
+	var n int
+	for _, p := range c.parts {
+		if p[taxon] {
+			n++
+		}
+	}
+	return float64(n) / float64(len(c.parts))
+}
+
+// Total returns the fraction of cells
+// (taxon-partition pairs)
+// with data.
+func (c *Coverage) Total() float64 {
+	if len(c.taxa) == 0 || len(c.parts) == 0 {
+		return 0
+	}
+	var n int
+	for _, p := range c.parts {
+		n += len(p)
+	}
+	return float64(n) / float64(len(c.taxa)*len(c.parts))
+}
+
+// Shared returns the number of taxa
+// with data in both partitions.
+func (c *Coverage) Shared(a, b string) int {
+	pa, pb := c.parts[a], c.parts[b]
+	var n int
+	for t := range pa {
+		if pb[t] {
+			n++
+		}
+	}
+	return n
+}
+
+// maxQuartets is the maximum number of quartets
+// evaluated exactly.
+const maxQuartets = 1_000_000
+
+// sampleSize is the number of quartets
+// sampled when the number of quartets is too large.
+const sampleSize = 100_000
+
+// Decisiveness returns the fraction of quartets of taxa
+// for which at least one partition has data
+// for the four taxa
+// (Sanderson et al. 2010, BMC Evol. Biol. 10: 155).
+// A matrix with a decisiveness of 1
+// is decisive for all possible trees.
+//
+// If the number of quartets is too large,
+// the value is estimated from a random sample of quartets
+// using the given seed.
+func (c *Coverage) Decisiveness(seed int64) float64 {
+	taxa := c.Taxa()
+	n := len(taxa)
+	if n < 4 {
+		return 1
+	}
+
+	decisive := c.decisive()
+
+	total := n * (n - 1) / 2 * (n - 2) / 3 * (n - 3) / 4
+	if total <= maxQuartets {
+		var d int
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				for k := j + 1; k < n; k++ {
+					for l := k + 1; l < n; l++ {
+						if decisive([4]string{taxa[i], taxa[j], taxa[k], taxa[l]}) {
+							d++
+						}
+					}
+				}
+			}
+		}
+		return float64(d) / float64(total)
+	}
+
+	rnd := rand.New(rand.NewSource(seed))
+	var d int
+	for i := 0; i < sampleSize; i++ {
+		var q [4]int
+		for j := 0; j < 4; {
+			v := rnd.Intn(n)
+			if slices.Contains(q[:j], v) {
+				continue
+			}
+			q[j] = v
+			j++
+		}
+		if decisive([4]string{taxa[q[0]], taxa[q[1]], taxa[q[2]], taxa[q[3]]}) {
+			d++
+		}
+	}
+	return float64(d) / float64(sampleSize)
+}
+
+// Gain returns the increase in decisiveness
+// if a taxon gets data for a partition,
+// i.e., the fraction of quartets of taxa
+// that will become decisive.
+//
+// If the number of evaluated quartets is too large,
+// the value is estimated from a random sample of quartets
+// using the given seed.
+func (c *Coverage) Gain(part, taxon string, seed int64) float64 {
+	p, ok := c.parts[part]
+	if !ok || !c.taxa[taxon] || p[taxon] {
+		return 0
+	}
+	n := len(c.taxa)
+	if n < 4 {
+		return 0
+	}
+	total := float64(n) * float64(n-1) / 2 * float64(n-2) / 3 * float64(n-3) / 4
+
+	taxa := make([]string, 0, len(p))
+	for t := range p {
+		taxa = append(taxa, t)
+	}
+	slices.Sort(taxa)
+	k := len(taxa)
+	if k < 3 {
+		return 0
+	}
+
+	// count the quartets with the taxon
+	// and three taxa of the partition
+	// that are not decisive
+	decisive := c.decisive()
+	triplets := k * (k - 1) / 2 * (k - 2) / 3
+	if triplets <= maxQuartets {
+		var d int
+		for i := 0; i < k; i++ {
+			for j := i + 1; j < k; j++ {
+				for l := j + 1; l < k; l++ {
+					if !decisive([4]string{taxon, taxa[i], taxa[j], taxa[l]}) {
+						d++
+					}
+				}
+			}
+		}
+		return float64(d) / total
+	}
+
+	rnd := rand.New(rand.NewSource(seed))
+	var d int
+	for i := 0; i < sampleSize; i++ {
+		var q [3]int
+		for j := 0; j < 3; {
+			v := rnd.Intn(k)
+			if slices.Contains(q[:j], v) {
+				continue
+			}
+			q[j] = v
+			j++
+		}
+		if !decisive([4]string{taxon, taxa[q[0]], taxa[q[1]], taxa[q[2]]}) {
+			d++
+		}
+	}
+	return float64(d) / float64(sampleSize) * float64(triplets) / total
+}
+
+// Decisive returns a function that returns true
+// if at least one partition has data
+// for the four taxa of a quartet.
+func (c *Coverage) decisive() func(q [4]string) bool {
+	parts := make([]map[string]bool, 0, len(c.parts))
+	for _, p := range c.Partitions() {
+		parts = append(parts, c.parts[p])
+	}
+	return func(q [4]string) bool {
+		for _, p := range parts {
+			if p[q[0]] && p[q[1]] && p[q[2]] && p[q[3]] {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package coverage_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/phydata/coverage"
+)
+
+func TestCoverage(t *testing.T) {
+	c := coverage.New()
+	for _, tx := range []string{"a", "b", "c", "d", "e"} {
+		c.Add("morphology", tx)
+	}
+	for _, tx := range []string{"a", "b", "c", "d"} {
+		c.Add("cytb", tx)
+	}
+	for _, tx := range []string{"d", "e"} {
+		c.Add("16s", tx)
+	}
+	c.AddTaxon("f")
+
+	if p := c.Partitions(); !reflect.DeepEqual(p, []string{"16s", "cytb", "morphology"}) {
+		t.Errorf("partitions: got %v", p)
+	}
+	if tx := c.Taxa(); !reflect.DeepEqual(tx, []string{"a", "b", "c", "d", "e", "f"}) {
+		t.Errorf("taxa: got %v", tx)
+	}
+
+	occ := map[string]float64{
+		"16s":        2.0 / 6,
+		"cytb":       4.0 / 6,
+		"morphology": 5.0 / 6,
+	}
+	for p, want := range occ {
+		if got := c.Occupancy(p); math.Abs(got-want) > 1e-6 {
+			t.Errorf("occupancy %q: got %.4f, want %.4f", p, got, want)
+		}
+	}
+	if got := c.TaxonOccupancy("d"); got != 1 {
+		t.Errorf("taxon occupancy %q: got %.4f, want %.4f", "d", got, 1.0)
+	}
+	if got, want := c.Total(), 11.0/18; math.Abs(got-want) > 1e-6 {
+		t.Errorf("total: got %.4f, want %.4f", got, want)
+	}
+	if got := c.Shared("cytb", "16s"); got != 1 {
+		t.Errorf("shared: got %d, want %d", got, 1)
+	}
+
+	// 15 quartets, 5 with all taxa of morphology
+	if got, want := c.Decisiveness(1), 5.0/15; math.Abs(got-want) > 1e-6 {
+		t.Errorf("decisiveness: got %.4f, want %.4f", got, want)
+	}
+
+	c.DeleteTaxon("f")
+	if got := c.Decisiveness(1); got != 1 {
+		t.Errorf("decisiveness: got %.4f, want %.4f", got, 1.0)
+	}
+}
+
+func TestGain(t *testing.T) {
+	c := coverage.New()
+	for _, tx := range []string{"a", "b", "c", "d", "e"} {
+		c.Add("morphology", tx)
+	}
+	for _, tx := range []string{"a", "b", "c", "d"} {
+		c.Add("cytb", tx)
+	}
+	for _, tx := range []string{"d", "e"} {
+		c.Add("16s", tx)
+	}
+	c.AddTaxon("f")
+
+	tests := map[string]struct {
+		part  string
+		taxon string
+		want  float64
+	}{
+		"few taxa":      {"16s", "a", 0},
+		"with data":     {"cytb", "a", 0},
+		"decisive":      {"cytb", "e", 0},
+		"to morphology": {"morphology", "f", 10.0 / 15},
+		"to cytb":       {"cytb", "f", 4.0 / 15},
+		"unknown taxon": {"cytb", "g", 0},
+	}
+	for name, test := range tests {
+		got := c.Gain(test.part, test.taxon, 1)
+		if math.Abs(got-test.want) > 1e-6 {
+			t.Errorf("%s: got %.4f, want %.4f", name, got, test.want)
+		}
+	}
+
+	nc := c.Clone()
+	nc.Add("cytb", "f")
+	if got, want := nc.Decisiveness(1), c.Decisiveness(1)+4.0/15; math.Abs(got-want) > 1e-6 {
+		t.Errorf("clone: got %.4f, want %.4f", got, want)
+	}
+	if c.Has("cytb", "f") {
+		t.Errorf("clone: original coverage modified")
+	}
+}
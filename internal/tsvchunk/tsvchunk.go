@@ -0,0 +1,213 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package tsvchunk implements the parallel decoding
+// of large TSV files.
+package tsvchunk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// ChunkSize is the size of the blocks of a TSV file
+// that are decoded in parallel.
+const chunkSize = 1 << 20
+
+// A chunk is a block of complete rows
+// of a TSV file.
+type chunk struct {
+	data []byte
+	line int // number of lines before the chunk
+
+	rows  [][]string
+	lines []int
+	err   error
+	done  chan struct{}
+}
+
+// Read reads the rows of a TSV file
+// (after the header)
+// in blocks that are decoded in parallel,
+// and calls fn for each row,
+// in the order of the file.
+// Line is the number of lines already read
+// (i.e., the header),
+// and fields is the number of fields of each row.
+// If prep is not nil,
+// it is called for each row
+// during the parallel decoding
+// (so it must not modify any shared data).
+// If fn returns an error,
+// the reading stops
+// and the error is returned.
+func Read(r *bufio.Reader, comma rune, line, fields int, prep func(row []string), fn func(row []string, ln int) error) error {
+	workers := runtime.GOMAXPROCS(0)
+	jobs := make(chan *chunk)
+	queue := make(chan *chunk, 2*workers)
+	quit := make(chan struct{})
+	defer close(quit)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for ch := range jobs {
+				ch.decode(comma, fields, prep)
+				close(ch.done)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		defer close(queue)
+		splitChunks(r, line, func(ch *chunk) bool {
+			select {
+			case queue <- ch:
+			case <-quit:
+				return false
+			}
+			if ch.err != nil {
+				close(ch.done)
+				return false
+			}
+			select {
+			case jobs <- ch:
+			case <-quit:
+				return false
+			}
+			return true
+		})
+	}()
+
+	for ch := range queue {
+		<-ch.done
+		for i, row := range ch.rows {
+			if err := fn(row, ch.lines[i]); err != nil {
+				return err
+			}
+		}
+		if ch.err != nil {
+			return ch.err
+		}
+	}
+	return nil
+}
+
+// SplitChunks reads a TSV file
+// and calls emit with each block of complete rows,
+// until the end of the file,
+// or emit returns false.
+// A read error is emitted as a chunk with the error.
+func splitChunks(r *bufio.Reader, line int, emit func(*chunk) bool) {
+	var rest []byte
+	for {
+		buf := make([]byte, len(rest)+chunkSize)
+		copy(buf, rest)
+		n, err := io.ReadFull(r, buf[len(rest):])
+		buf = buf[:len(rest)+n]
+		eof := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+		if err != nil && !eof {
+			emit(&chunk{err: err, done: make(chan struct{})})
+			return
+		}
+		if len(buf) == 0 {
+			return
+		}
+
+		end := len(buf)
+		if !eof {
+			end = lastRecord(buf)
+		}
+		if end == 0 {
+			// a row larger than the chunk size
+			rest = buf
+			continue
+		}
+
+		ch := &chunk{
+			data: buf[:end],
+			line: line,
+			done: make(chan struct{}),
+		}
+		line += bytes.Count(ch.data, []byte{'\n'})
+		rest = buf[end:]
+		if !emit(ch) || eof {
+			return
+		}
+	}
+}
+
+// LastRecord returns the position after the last new line
+// that is not inside a quoted field.
+// Comment lines are ignored.
+func lastRecord(b []byte) int {
+	last := 0
+	for i := 0; i < len(b); {
+		nl := bytes.IndexByte(b[i:], '\n')
+		if nl < 0 {
+			break
+		}
+		ln := b[i : i+nl+1]
+		if ln[0] == '#' || bytes.IndexByte(ln, '"') < 0 {
+			i += nl + 1
+			last = i
+			continue
+		}
+
+		// a row with quoted fields
+		quoted := false
+		j := i
+		for ; j < len(b); j++ {
+			if b[j] == '"' {
+				quoted = !quoted
+				continue
+			}
+			if b[j] == '\n' && !quoted {
+				break
+			}
+		}
+		if j == len(b) {
+			break
+		}
+		i = j + 1
+		last = i
+	}
+	return last
+}
+
+// Decode decodes the rows of a chunk.
+func (ch *chunk) decode(comma rune, fields int, prep func(row []string)) {
+	tab := csv.NewReader(bytes.NewReader(ch.data))
+	tab.Comma = comma
+	tab.Comment = '#'
+	tab.FieldsPerRecord = fields
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			ln := ch.line
+			var pe *csv.ParseError
+			if errors.As(err, &pe) {
+				pe.StartLine += ch.line
+				pe.Line += ch.line
+				ln = pe.StartLine
+			}
+			ch.err = fmt.Errorf("on row %d: %v", ln, err)
+			return
+		}
+		if prep != nil {
+			prep(row)
+		}
+		ln, _ := tab.FieldPos(0)
+		ch.rows = append(ch.rows, row)
+		ch.lines = append(ch.lines, ln+ch.line)
+	}
+}
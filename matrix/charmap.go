@@ -0,0 +1,230 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// A CharMap is a map of characters and states
+// from a source matrix
+// (e.g., a published matrix)
+// to the canonical characters and states
+// of a project.
+type CharMap struct {
+	chars  map[string]string
+	states map[[2]string][2]string
+}
+
+// NewCharMap creates a new empty character map.
+func NewCharMap() *CharMap {
+	return &CharMap{
+		chars:  make(map[string]string),
+		states: make(map[[2]string][2]string),
+	}
+}
+
+// Add adds a map from a source character and state
+// to a canonical character and state.
+// If the source state is empty,
+// the map is for the whole character,
+// and the state names are kept.
+// If the canonical character is empty,
+// the state is mapped
+// to a canonical state of the same character.
+// If the canonical state is empty,
+// the state name is kept.
+func (cm *CharMap) Add(char, state, toChar, toState string) error {
+	char = charName(char)
+	if char == "" {
+		return errors.New("empty character")
+	}
+	state = charName(state)
+	toChar = charName(toChar)
+	toState = charName(toState)
+
+	if state == "" {
+		if toState != "" {
+			return fmt.Errorf("character %q: canonical state %q without source state", char, toState)
+		}
+		if toChar == "" {
+			return fmt.Errorf("character %q: undefined canonical character", char)
+		}
+		cm.chars[char] = toChar
+		return nil
+	}
+
+	if state == NotApplicable || state == Unknown {
+		return fmt.Errorf("character %q: state %q can not be mapped", char, state)
+	}
+	if toChar == "" && toState == "" {
+		return fmt.Errorf("character %q: state %q: undefined canonical state", char, state)
+	}
+	if toState == "" {
+		toState = state
+	}
+	cm.states[[2]string{char, state}] = [2]string{toChar, toState}
+	return nil
+}
+
+// Map returns the canonical character and state
+// of a source character and state.
+// If the character or the state are not in the map,
+// they are returned unchanged.
+func (cm *CharMap) Map(char, state string) (string, string) {
+	char = charName(char)
+	state = charName(state)
+	if cm == nil {
+		return char, state
+	}
+
+	nc := char
+	if c, ok := cm.chars[char]; ok {
+		nc = c
+	}
+	if s, ok := cm.states[[2]string{char, state}]; ok {
+		if s[0] != "" {
+			nc = s[0]
+		}
+		return nc, s[1]
+	}
+	return nc, state
+}
+
+var charMapFields = []string{
+	"character",
+	"to-character",
+}
+
+// ReadCharMap reads a character map
+// from a TSV file.
+//
+// The TSV file must contains the following fields:
+//
+//   - character, the name of the character in the source matrix
+//   - to-character, the name of the canonical character
+//
+// Additional fields are:
+//
+//   - state, the name of the state in the source matrix
+//   - to-state, the name of the canonical state
+//
+// If the state is empty,
+// the whole character is mapped.
+// If the to-character field is empty,
+// the state is mapped to a canonical state
+// of the same character.
+//
+// Here is an example file:
+//
+//	# character map
+//	character	state	to-character	to-state
+//	char 2		tail muscle
+//	char 3	state 0	tail shape	round
+//	char 3	state 1	tail shape	pointed
+func ReadCharMap(r io.Reader) (*CharMap, error) {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return nil, fmt.Errorf("while reading header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(strings.TrimSpace(h))
+		fields[h] = i
+	}
+	for _, h := range charMapFields {
+		if _, ok := fields[h]; !ok {
+			return nil, fmt.Errorf("expecting field %q", h)
+		}
+	}
+
+	cm := NewCharMap()
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		char := row[fields["character"]]
+		if charName(char) == "" {
+			continue
+		}
+		toChar := row[fields["to-character"]]
+		var state, toState string
+		if i, ok := fields["state"]; ok {
+			state = row[i]
+		}
+		if i, ok := fields["to-state"]; ok {
+			toState = row[i]
+		}
+		if err := cm.Add(char, state, toChar, toState); err != nil {
+			return nil, fmt.Errorf("on row %d: %v", ln, err)
+		}
+	}
+	return cm, nil
+}
+
+// Merge adds the observations of another matrix
+// into the matrix,
+// using a character map
+// to translate the characters and states
+// of the other matrix.
+// If the character map is nil,
+// the characters and states are kept.
+//
+// The observations are added in the same way
+// as they are read from a file,
+// so merged characters with different states
+// will become polymorphisms,
+// and the additional fields of the observations
+// are preserved.
+func (m *Matrix) Merge(o *Matrix, cm *CharMap) error {
+	for _, c := range o.Chars() {
+		for s := range o.chars[c].states {
+			nc, ns := cm.Map(c, s)
+			m.addState(nc, ns)
+		}
+	}
+
+	for _, spec := range o.Specimens() {
+		sp := o.specs[spec]
+		chars := make([]string, 0, len(sp.obs))
+		for c := range sp.obs {
+			chars = append(chars, c)
+		}
+		slices.Sort(chars)
+		for _, c := range chars {
+			obs := sp.obs[c]
+			for _, s := range o.Obs(spec, c) {
+				nc, ns := cm.Map(c, s)
+				if err := m.Add(sp.taxon, spec, nc, ns); err != nil {
+					return err
+				}
+				no, ok := m.specs[spec].obs[nc][ns]
+				if !ok {
+					continue
+				}
+				*no = *obs[s]
+				no.name = ns
+				no.extra = maps.Clone(obs[s].extra)
+			}
+		}
+	}
+	return nil
+}
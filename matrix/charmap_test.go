@@ -0,0 +1,98 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix"
+)
+
+var charMapBlob = `# character map
+character	state	to-character	to-state
+char 2		tail muscle	
+char 3	state 0	tail shape	round
+char 3	state 1	tail shape	pointed
+char 4	state 1		present
+`
+
+func TestCharMap(t *testing.T) {
+	cm, err := matrix.ReadCharMap(strings.NewReader(charMapBlob))
+	if err != nil {
+		t.Fatalf("unable to read character map: %v", err)
+	}
+
+	tests := map[string]struct {
+		char, state string
+		toChar      string
+		toState     string
+	}{
+		"whole character": {"Char 2", "absent", "tail muscle", "absent"},
+		"state":           {"char 3", "State 0", "tail shape", "round"},
+		"same character":  {"char 4", "state 1", "char 4", "present"},
+		"unmapped state":  {"char 4", "state 0", "char 4", "state 0"},
+		"unmapped":        {"char 5", "state 0", "char 5", "state 0"},
+		"inapplicable":    {"char 2", matrix.NotApplicable, "tail muscle", matrix.NotApplicable},
+	}
+	for name, test := range tests {
+		c, s := cm.Map(test.char, test.state)
+		if c != test.toChar || s != test.toState {
+			t.Errorf("%s: got %q %q, want %q %q", name, c, s, test.toChar, test.toState)
+		}
+	}
+
+	errs := map[string]string{
+		"no canonical":  "character\tto-character\nchar 2\t\n",
+		"no state":      "character\tto-character\tto-state\nchar 2\ttail\tpresent\n",
+		"missing field": "character\tstate\nchar 2\tpresent\n",
+	}
+	for name, blob := range errs {
+		if _, err := matrix.ReadCharMap(strings.NewReader(blob)); err == nil {
+			t.Errorf("%s: expecting error", name)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	m := matrix.New()
+	m.Add("Ascaphus truei", "Ascaphus truei", "tail muscle", "present")
+	m.Add("Ascaphus truei", "Ascaphus truei", "tail shape", "round")
+
+	o := matrix.New()
+	o.Add("Ascaphus truei", "kluge1969:Ascaphus truei", "char 2", "present")
+	o.Add("Ascaphus truei", "kluge1969:Ascaphus truei", "char 3", "state 1")
+	o.Set("kluge1969:Ascaphus truei", "char 3", "state 1", "kluge1969", matrix.Reference)
+	o.Add("Bufonidae", "kluge1969:Bufonidae", "char 2", "absent")
+	o.Add("Bufonidae", "kluge1969:Bufonidae", "char 3", "state 0")
+
+	cm, err := matrix.ReadCharMap(strings.NewReader(charMapBlob))
+	if err != nil {
+		t.Fatalf("unable to read character map: %v", err)
+	}
+	if err := m.Merge(o, cm); err != nil {
+		t.Fatalf("merge: unexpected error: %v", err)
+	}
+
+	if got, want := m.Chars(), []string{"tail muscle", "tail shape"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("chars: got %v, want %v", got, want)
+	}
+	if got, want := m.States("tail shape"), []string{"pointed", "round"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("states: got %v, want %v", got, want)
+	}
+	if got, want := m.Obs("kluge1969:Ascaphus truei", "tail shape"), []string{"pointed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("obs: got %v, want %v", got, want)
+	}
+	if got := m.Val("kluge1969:Ascaphus truei", "tail shape", "pointed", matrix.Reference); got != "kluge1969" {
+		t.Errorf("reference: got %q, want %q", got, "kluge1969")
+	}
+	if got, want := m.Obs("kluge1969:Bufonidae", "tail muscle"), []string{"absent"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("obs: got %v, want %v", got, want)
+	}
+	if got, want := m.TaxSpec("Bufonidae"), []string{"kluge1969:bufonidae"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("specimens: got %v, want %v", got, want)
+	}
+}
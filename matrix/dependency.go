@@ -0,0 +1,103 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import "slices"
+
+// A Violation is a specimen
+// in which the coding of a character
+// contradicts its dependency
+// on another character.
+type Violation struct {
+	Spec   string
+	Char   string
+	States []string // states of the character
+
+	Parent       string
+	ParentStates []string // states of the controlling character
+}
+
+// applicable returns true
+// if a character is applicable
+// for a specimen,
+// and true if the state of the controlling character
+// is known.
+func (m *Matrix) applicable(spec, parent string, states []string) (applicable, known bool) {
+	obs := m.Obs(spec, parent)
+	if obs[0] == Unknown {
+		return false, false
+	}
+	for _, o := range obs {
+		if slices.Contains(states, o) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// CheckDependency returns the specimens
+// in which a character
+// that is only applicable
+// when the controlling character (parent)
+// has one of the given states
+// is coded with a state
+// while the parent is in a different state
+// (or it is inapplicable),
+// or is inapplicable
+// while the parent is in one of the given states.
+// Specimens with an unknown state for the parent
+// are ignored.
+func (m *Matrix) CheckDependency(char, parent string, states []string) []Violation {
+	var vs []Violation
+	for _, sp := range m.Specimens() {
+		app, known := m.applicable(sp, parent, states)
+		if !known {
+			continue
+		}
+		obs := m.Obs(sp, char)
+		if obs[0] == Unknown {
+			continue
+		}
+		if app == (obs[0] != NotApplicable) {
+			continue
+		}
+		vs = append(vs, Violation{
+			Spec:         sp,
+			Char:         char,
+			States:       obs,
+			Parent:       parent,
+			ParentStates: m.Obs(sp, parent),
+		})
+	}
+	return vs
+}
+
+// SetInapplicable sets as inapplicable
+// a character
+// that is only applicable
+// when the controlling character (parent)
+// has one of the given states,
+// in the specimens in which the character is unknown
+// and the parent is in a different state
+// (or it is inapplicable).
+// Coded observations are not changed.
+// It returns the number of modified specimens.
+func (m *Matrix) SetInapplicable(char, parent string, states []string) int {
+	var n int
+	for _, sp := range m.Specimens() {
+		app, known := m.applicable(sp, parent, states)
+		if !known || app {
+			continue
+		}
+		if m.Obs(sp, char)[0] != Unknown {
+			continue
+		}
+		if err := m.Add(m.specs[sp].taxon, sp, char, NotApplicable); err != nil {
+			continue
+		}
+		n++
+	}
+	return n
+}
@@ -0,0 +1,44 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix"
+)
+
+func TestDependency(t *testing.T) {
+	m := matrix.New()
+	m.Add("Ascaphus truei", "Ascaphus truei", "tail", "present")
+	m.Add("Ascaphus truei", "Ascaphus truei", "tail color", "green")
+	m.Add("Bufonidae", "Bufonidae", "tail", "absent")
+	m.Add("Bufonidae", "Bufonidae", "tail color", "brown")
+	m.Add("Discoglossidae", "Discoglossidae", "tail", "present")
+	m.Add("Discoglossidae", "Discoglossidae", "tail color", matrix.NotApplicable)
+	m.Add("Leiopelma", "Leiopelma", "tail", "absent")
+	m.Add("Pipidae", "Pipidae", "tail color", "green")
+
+	states := []string{"present"}
+	vs := m.CheckDependency("tail color", "tail", states)
+	var got []string
+	for _, v := range vs {
+		got = append(got, v.Spec)
+	}
+	if want := []string{"bufonidae", "discoglossidae"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("violations: got %v, want %v", got, want)
+	}
+
+	if n := m.SetInapplicable("tail color", "tail", states); n != 1 {
+		t.Errorf("set inapplicable: got %d, want %d", n, 1)
+	}
+	if obs := m.Obs("Leiopelma", "tail color"); obs[0] != matrix.NotApplicable {
+		t.Errorf("set inapplicable: got %v, want %q", obs, matrix.NotApplicable)
+	}
+	if obs := m.Obs("Bufonidae", "tail color"); obs[0] != "brown" {
+		t.Errorf("coded observation: got %v, want %q", obs, "brown")
+	}
+}
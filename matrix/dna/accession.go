@@ -0,0 +1,139 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A Policy defines how a collection
+// handles a GenBank accession
+// that is already assigned
+// to a different specimen or gene.
+type Policy int
+
+// Valid accession policies.
+const (
+	// Allow accepts the same accession
+	// in different specimens or genes.
+	// This is the default policy.
+	Allow Policy = iota
+
+	// Reject returns an error
+	// if the accession is already assigned
+	// to a different specimen or gene.
+	Reject
+
+	// Merge assigns the sequence
+	// to the specimen that already has the accession.
+	// As a single GenBank accession
+	// can include multiple genes
+	// (e.g., a mitochondrial genome),
+	// the same accession can be assigned
+	// to different genes.
+	Merge
+)
+
+// SetPolicy sets the policy used
+// when a GenBank accession is added
+// to a different specimen or gene.
+func (c *Collection) SetPolicy(p Policy) {
+	c.policy = p
+}
+
+// AccessionSpec returns the specimens
+// that have sequences
+// with a given GenBank accession.
+func (c *Collection) AccessionSpec(genBank string) []string {
+	uses := c.accs[strings.TrimSpace(genBank)]
+	specs := make(map[string]bool, len(uses))
+	for u := range uses {
+		specs[u[0]] = true
+	}
+
+	ls := make([]string, 0, len(specs))
+	for sp := range specs {
+		ls = append(ls, sp)
+	}
+	slices.Sort(ls)
+	return ls
+}
+
+// AccessionGene returns the genes
+// that have sequences
+// with a given GenBank accession.
+func (c *Collection) AccessionGene(genBank string) []string {
+	uses := c.accs[strings.TrimSpace(genBank)]
+	genes := make(map[string]bool, len(uses))
+	for u := range uses {
+		genes[u[1]] = true
+	}
+
+	ls := make([]string, 0, len(genes))
+	for g := range genes {
+		ls = append(ls, g)
+	}
+	slices.Sort(ls)
+	return ls
+}
+
+// CheckAccession applies the accession policy
+// to a new sequence,
+// and returns the specimen
+// in which the sequence will be stored.
+func (c *Collection) checkAccession(spec, gene, genBank string) (string, error) {
+	if c.policy == Allow || strings.HasPrefix(genBank, noGenBank) {
+		return spec, nil
+	}
+
+	specs := c.AccessionSpec(genBank)
+	if len(specs) == 0 {
+		return spec, nil
+	}
+	if c.policy == Merge {
+		if slices.Contains(specs, spec) {
+			return spec, nil
+		}
+		return specs[0], nil
+	}
+
+	// alignment variants are versions of the same gene
+	base, _ := SplitVariant(gene)
+	for u := range c.accs[genBank] {
+		if u[0] != spec {
+			return "", fmt.Errorf("accession %q already assigned to specimen %q", genBank, u[0])
+		}
+		if g, _ := SplitVariant(u[1]); g != base {
+			return "", fmt.Errorf("accession %q already assigned to gene %q", genBank, u[1])
+		}
+	}
+	return spec, nil
+}
+
+// AddAccession adds a sequence
+// to the accession index.
+func (c *Collection) addAccession(spec, gene, genBank string) {
+	uses, ok := c.accs[genBank]
+	if !ok {
+		uses = make(map[[2]string]bool)
+		c.accs[genBank] = uses
+	}
+	uses[[2]string{spec, gene}] = true
+}
+
+// DelAccession removes a sequence
+// from the accession index.
+func (c *Collection) delAccession(spec, gene, genBank string) {
+	uses, ok := c.accs[genBank]
+	if !ok {
+		return
+	}
+	delete(uses, [2]string{spec, gene})
+	if len(uses) == 0 {
+		delete(c.accs, genBank)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+func TestAccessionPolicy(t *testing.T) {
+	c := dna.New()
+	if err := c.Add("Loxodonta africana", "sp-01", "cytb", "MN148748", "ccatccaacatctcagca"); err != nil {
+		t.Fatalf("add: unexpected error: %v", err)
+	}
+	if err := c.Add("Orycteropus afer", "sp-02", "cytb", "MN148748", "ccatccaacatctcagca"); err != nil {
+		t.Fatalf("add: unexpected error: %v", err)
+	}
+	want := []string{"sp-01", "sp-02"}
+	if got := c.AccessionSpec("MN148748"); !reflect.DeepEqual(got, want) {
+		t.Errorf("allow: specimens: got %v, want %v", got, want)
+	}
+
+	c = dna.New()
+	c.SetPolicy(dna.Reject)
+	if err := c.Add("Loxodonta africana", "sp-01", "cytb", "MN148748", "ccatccaacatctcagca"); err != nil {
+		t.Fatalf("add: unexpected error: %v", err)
+	}
+	if err := c.Add("Loxodonta africana", "sp-01", "cytb", "MN148748", "ccatccaacatctcagca"); err != nil {
+		t.Errorf("reject: same sequence: unexpected error: %v", err)
+	}
+	if err := c.Add("Orycteropus afer", "sp-02", "cytb", "MN148748", "ccatccaacatctcagca"); err == nil {
+		t.Errorf("reject: expecting error on a different specimen")
+	}
+	if err := c.Add("Loxodonta africana", "sp-01", "coi", "MN148748", "ccatccaacatctcagca"); err == nil {
+		t.Errorf("reject: expecting error on a different gene")
+	}
+	c.Delete("sp-01", "cytb", "MN148748")
+	if err := c.Add("Orycteropus afer", "sp-02", "cytb", "MN148748", "ccatccaacatctcagca"); err != nil {
+		t.Errorf("reject: after delete: unexpected error: %v", err)
+	}
+
+	c = dna.New()
+	c.SetPolicy(dna.Merge)
+	if err := c.Add("Loxodonta africana", "sp-01", "cytb", "MN148748", "ccatccaacatctcagca"); err != nil {
+		t.Fatalf("add: unexpected error: %v", err)
+	}
+	if err := c.Add("Orycteropus afer", "sp-02", "coi", "MN148748", "atgaccccaatacgcaaa"); err != nil {
+		t.Fatalf("merge: unexpected error: %v", err)
+	}
+	if got := c.AccessionSpec("MN148748"); !reflect.DeepEqual(got, []string{"sp-01"}) {
+		t.Errorf("merge: specimens: got %v, want %v", got, []string{"sp-01"})
+	}
+	if got := c.AccessionGene("MN148748"); !reflect.DeepEqual(got, []string{"coi", "cytb"}) {
+		t.Errorf("merge: genes: got %v, want %v", got, []string{"coi", "cytb"})
+	}
+	if got := c.Specimens(); !reflect.DeepEqual(got, []string{"sp-01"}) {
+		t.Errorf("merge: collection specimens: got %v, want %v", got, []string{"sp-01"})
+	}
+}
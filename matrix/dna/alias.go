@@ -0,0 +1,183 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Aliases is a table of gene names
+// that maps the synonyms of a gene
+// to its canonical name.
+type Aliases map[string]string
+
+// DefaultAliases returns a table
+// with the common synonyms of the most used genes
+// in phylogenetic analysis.
+func DefaultAliases() Aliases {
+	a := make(Aliases)
+	for _, ls := range [][]string{
+		{"cox1", "coi", "coxi", "co1", "cox-1", "mt-co1"},
+		{"cox2", "coii", "coxii", "co2", "cox-2", "mt-co2"},
+		{"cox3", "coiii", "coxiii", "co3", "cox-3", "mt-co3"},
+		{"cytb", "cob", "cyt b", "cyt-b", "mt-cyb"},
+		{"nd1", "nad1", "nadh1", "mt-nd1"},
+		{"nd2", "nad2", "nadh2", "mt-nd2"},
+		{"nd3", "nad3", "nadh3", "mt-nd3"},
+		{"nd4", "nad4", "nadh4", "mt-nd4"},
+		{"nd4l", "nad4l", "nadh4l", "mt-nd4l"},
+		{"nd5", "nad5", "nadh5", "mt-nd5"},
+		{"nd6", "nad6", "nadh6", "mt-nd6"},
+		{"atp6", "atpase6", "atpase 6", "mt-atp6"},
+		{"atp8", "atpase8", "atpase 8", "mt-atp8"},
+		{"12s", "rrns", "12s rrna", "rrn12", "12s rdna", "mt-rnr1"},
+		{"16s", "rrnl", "16s rrna", "rrn16", "16s rdna", "mt-rnr2"},
+		{"18s", "18s rrna", "18s rdna", "ssu rrna"},
+		{"28s", "28s rrna", "28s rdna", "lsu rrna"},
+		{"rag1", "rag-1"},
+		{"rag2", "rag-2"},
+		{"h3", "histone h3"},
+	} {
+		a.add(ls[0], ls[1:]...)
+	}
+	return a
+}
+
+// ReadAliases reads a table of gene aliases
+// from a tab-delimited file.
+// Each line of the file is a gene,
+// the first field is the canonical name of the gene,
+// and the following fields are its synonyms.
+// Empty lines,
+// or lines starting with '#' are ignored.
+//
+// Here is an example file:
+//
+//	# gene aliases
+//	cox1	coi	coxi	co1
+//	16s	rrnl	16s rrna
+func ReadAliases(r io.Reader) (Aliases, error) {
+	a := make(Aliases)
+	br := bufio.NewReader(r)
+	for i := 1; ; i++ {
+		ln, err := br.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("on line %d: %v", i, err)
+		}
+		ln = strings.TrimSpace(ln)
+		if ln != "" && ln[0] != '#' {
+			fs := strings.Split(ln, "\t")
+			if aliasName(fs[0]) == "" {
+				return nil, fmt.Errorf("on line %d: empty gene name", i)
+			}
+			a.add(fs[0], fs[1:]...)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+	return a, nil
+}
+
+// Gene returns the canonical name of a gene.
+// If the gene is not in the table,
+// it returns the gene name.
+func (a Aliases) Gene(name string) string {
+	name = aliasName(name)
+	if n, ok := a[name]; ok {
+		return n
+	}
+	return name
+}
+
+// Merge adds the aliases of b
+// to the table.
+// If a name is in both tables,
+// the canonical name of b
+// will be used.
+func (a Aliases) Merge(b Aliases) {
+	for k, v := range b {
+		a[k] = v
+	}
+}
+
+func (a Aliases) add(gene string, synonyms ...string) {
+	gene = aliasName(gene)
+	a[gene] = gene
+	for _, s := range synonyms {
+		s = aliasName(s)
+		if s == "" {
+			continue
+		}
+		a[s] = gene
+	}
+}
+
+// CanonGene returns the name of a gene
+// as stored in a collection:
+// in lower case,
+// and if the table is not nil,
+// the canonical name of the gene
+// (keeping its alignment variant).
+func (a Aliases) canonGene(gene string) string {
+	gene = strings.ToLower(strings.TrimSpace(gene))
+	if a == nil || gene == "" {
+		return gene
+	}
+	g, v := SplitVariant(gene)
+	gene = a.Gene(g)
+	if v != "" {
+		gene += VariantSep + v
+	}
+	return gene
+}
+
+func aliasName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// SetAliases sets the table of gene aliases
+// used by the collection.
+// When a sequence is added,
+// the synonyms of a gene
+// will be replaced by the canonical name of the gene.
+func (c *Collection) SetAliases(a Aliases) {
+	c.aliases = a
+}
+
+// RenameGene changes the name of a gene.
+// If the new name is already in the collection,
+// the sequences of the old gene
+// will be merged into the new gene.
+func (c *Collection) RenameGene(old, name string) {
+	old = strings.TrimSpace(strings.ToLower(old))
+	name = strings.TrimSpace(strings.ToLower(name))
+	if name == "" || old == name {
+		return
+	}
+
+	c.sortedGenes = nil
+	for _, sp := range c.specs {
+		gb, ok := sp.genes[old]
+		if !ok {
+			continue
+		}
+		delete(sp.genes, old)
+		ng, ok := sp.genes[name]
+		if !ok {
+			ng = make(map[string]*genBankSequence, len(gb))
+			sp.genes[name] = ng
+		}
+		for acc, seq := range gb {
+			ng[acc] = seq
+			c.delAccession(sp.name, old, acc)
+			c.addAccession(sp.name, name, acc)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+func TestAliases(t *testing.T) {
+	a := dna.DefaultAliases()
+	tests := map[string]string{
+		"COI":      "cox1",
+		"coxI":     "cox1",
+		"cox1":     "cox1",
+		"16S rRNA": "16s",
+		"rrnL":     "16s",
+		"Cyt  b":   "cytb",
+		"eef1a1":   "eef1a1",
+	}
+	for g, want := range tests {
+		if got := a.Gene(g); got != want {
+			t.Errorf("gene %q: got %q, want %q", g, got, want)
+		}
+	}
+
+	b, err := dna.ReadAliases(strings.NewReader("# gene aliases\ncoi\tcox1\tcoxi\n\nEF1a\teef1a1\n"))
+	if err != nil {
+		t.Fatalf("unable to read aliases: %v", err)
+	}
+	a.Merge(b)
+	if got := a.Gene("cox1"); got != "coi" {
+		t.Errorf("merged: gene %q: got %q, want %q", "cox1", got, "coi")
+	}
+	if got := a.Gene("eef1a1"); got != "ef1a" {
+		t.Errorf("merged: gene %q: got %q, want %q", "eef1a1", got, "ef1a")
+	}
+}
+
+func TestAddWithAliases(t *testing.T) {
+	c := dna.New()
+	c.SetAliases(dna.DefaultAliases())
+	if err := c.Add("Loxodonta africana", "sp-01", "COI", "MN148748", "ccatccaacatctcagca"); err != nil {
+		t.Fatalf("add: unexpected error: %v", err)
+	}
+	if err := c.Add("Orycteropus afer", "sp-02", "cox1", "OR167429", "ccatccaacatctcagca"); err != nil {
+		t.Fatalf("add: unexpected error: %v", err)
+	}
+	if got := c.Genes(); !reflect.DeepEqual(got, []string{"cox1"}) {
+		t.Errorf("genes: got %v, want %v", got, []string{"cox1"})
+	}
+}
+
+func TestRenameGene(t *testing.T) {
+	c := dna.New()
+	if err := c.Add("Loxodonta africana", "sp-01", "coi", "MN148748", "ccatccaacatctcagca"); err != nil {
+		t.Fatalf("add: unexpected error: %v", err)
+	}
+	c.Set("sp-01", "coi", "MN148748", "mitochondrion", dna.Organelle)
+	if err := c.Add("Orycteropus afer", "sp-02", "cox1", "OR167429", "ccatccaacatctcagca"); err != nil {
+		t.Fatalf("add: unexpected error: %v", err)
+	}
+
+	c.RenameGene("coi", "cox1")
+	if got := c.Genes(); !reflect.DeepEqual(got, []string{"cox1"}) {
+		t.Errorf("genes: got %v, want %v", got, []string{"cox1"})
+	}
+	if got := c.Val("sp-01", "cox1", "MN148748", dna.Organelle); got != "mitochondrion" {
+		t.Errorf("organelle: got %q, want %q", got, "mitochondrion")
+	}
+	if got := c.AccessionGene("MN148748"); !reflect.DeepEqual(got, []string{"cox1"}) {
+		t.Errorf("accession genes: got %v, want %v", got, []string{"cox1"})
+	}
+}
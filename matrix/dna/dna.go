@@ -8,23 +8,99 @@ package dna
 
 import (
 	"fmt"
+	"maps"
 	"slices"
+	"strconv"
 	"strings"
-	"unicode"
-	"unicode/utf8"
+
+	"github.com/js-arias/phydata/names"
 )
 
 // A Collection is a collection of taxa
 // and their sequences.
+//
+// A Collection is not safe for concurrent use,
+// even for reading,
+// as the sorted lists returned by the getters
+// are cached.
 type Collection struct {
 	specs map[string]*specimen
+
+	// sorted specimens of each taxon
+	taxon map[string][]string
+
+	// sorted lists built on demand,
+	// and discarded when the collection is modified
+	sortedGenes []string
+	sortedSpecs []string
+	sortedTaxa  []string
+
+	// accession index
+	accs   map[string]map[[2]string]bool
+	policy Policy
+
+	aliases Aliases
+
+	// header synonyms
+	headers Headers
+
+	// replace uracil with thymine
+	normU bool
 }
 
+// noGenBank is the prefix of the accession
+// of sequences without a GenBank accession.
+const noGenBank = "no-gb:"
+
 // New creates a new empty collection.
 func New() *Collection {
 	return &Collection{
 		specs: make(map[string]*specimen),
+		taxon: make(map[string][]string),
+		accs:  make(map[string]map[[2]string]bool),
+	}
+}
+
+// Clone returns a deep copy of the collection,
+// including its accession policy,
+// aliases, header synonyms,
+// and uracil normalization,
+// so it can be modified
+// without changing the original collection.
+func (c *Collection) Clone() *Collection {
+	nc := &Collection{
+		specs:   make(map[string]*specimen, len(c.specs)),
+		taxon:   make(map[string][]string, len(c.taxon)),
+		accs:    make(map[string]map[[2]string]bool, len(c.accs)),
+		policy:  c.policy,
+		aliases: maps.Clone(c.aliases),
+		headers: maps.Clone(c.headers),
+		normU:   c.normU,
+	}
+	for id, sp := range c.specs {
+		nsp := &specimen{
+			taxon: sp.taxon,
+			name:  sp.name,
+			genes: make(map[string]map[string]*genBankSequence, len(sp.genes)),
+		}
+		for g, gb := range sp.genes {
+			ngb := make(map[string]*genBankSequence, len(gb))
+			for acc, seq := range gb {
+				ns := *seq
+				ns.extra = maps.Clone(seq.extra)
+				ngb[acc] = &ns
+			}
+			nsp.genes[g] = ngb
+		}
+		nc.specs[id] = nsp
 	}
+	for tx, specs := range c.taxon {
+		nc.taxon[tx] = slices.Clone(specs)
+	}
+	for acc, set := range c.accs {
+		nc.accs[acc] = maps.Clone(set)
+	}
+	return nc
 }
 
 // Add adds a new sequence to the collection
@@ -37,31 +113,57 @@ func New() *Collection {
 // in this case if no specimen is given,
 // it will return an error.
 // The sequence can be aligned or unaligned.
+// If the collection normalizes uracil
+// (see SetNormalizeU),
+// any 'u' in the sequence is replaced by a 't'.
+//
+// If the collection has a table of gene aliases,
+// the canonical name of the gene will be used
+// (see SetAliases).
+// The gene can be an alignment variant
+// (see SplitVariant).
+//
+// If the GenBank accession is already assigned
+// to a different specimen or gene,
+// the accession policy of the collection is applied
+// (see SetPolicy).
 func (c *Collection) Add(taxon, spec, gene, genBank, seq string) error {
-	taxon = canon(taxon)
+	return c.add(taxon, spec, gene, genBank, formatSequence(seq))
+}
+
+// Add adds a sequence
+// already formatted with formatSequence.
+func (c *Collection) add(taxon, spec, gene, genBank, seq string) error {
+	taxon = names.Taxon(taxon)
 	if taxon == "" {
 		return nil
 	}
 
 	genBank = strings.TrimSpace(genBank)
-	spec = specID(spec)
+	spec = names.Specimen(spec)
 	if spec == "" && genBank == "" {
 		return fmt.Errorf("sequence without identifier")
 	}
 	if spec == "" {
-		spec = specID("genbank:" + genBank)
+		spec = names.Specimen("genbank:" + genBank)
 	}
 	if genBank == "" {
-		genBank = "no-gb:" + spec
+		genBank = noGenBank + spec
 	}
 
-	seq = formatSequence(seq)
+	if c.normU {
+		seq = strings.ReplaceAll(seq, "u", "t")
+	}
 
-	gene = strings.TrimSpace(gene)
+	gene = c.aliases.canonGene(gene)
 	if gene == "" {
 		return fmt.Errorf("sequence %q without a defined gene-molecule identifier", genBank)
 	}
-	gene = strings.ToLower(gene)
+
+	spec, err := c.checkAccession(spec, gene, genBank)
+	if err != nil {
+		return err
+	}
 
 	sp, ok := c.specs[spec]
 	if !ok {
@@ -71,20 +173,54 @@ func (c *Collection) Add(taxon, spec, gene, genBank, seq string) error {
 			genes: make(map[string]map[string]*genBankSequence),
 		}
 		c.specs[spec] = sp
+		c.addTaxSpec(taxon, spec)
+		c.sortedSpecs = nil
 	}
 
 	gb, ok := sp.genes[gene]
 	if !ok {
 		gb = make(map[string]*genBankSequence)
 		sp.genes[gene] = gb
+		c.sortedGenes = nil
 	}
 	gb[genBank] = &genBankSequence{
 		seq: seq,
 	}
+	c.addAccession(spec, gene, genBank)
 
 	return nil
 }
 
+// Delete removes a sequence from the collection.
+// If the specimen has no more sequences,
+// it will be removed from the collection.
+func (c *Collection) Delete(specimen, gene, genBank string) {
+	specimen = names.Specimen(specimen)
+	sp, ok := c.specs[specimen]
+	if !ok {
+		return
+	}
+	gene = strings.TrimSpace(strings.ToLower(gene))
+	gb, ok := sp.genes[gene]
+	if !ok {
+		return
+	}
+	if _, ok := gb[genBank]; !ok {
+		return
+	}
+	delete(gb, genBank)
+	c.delAccession(specimen, gene, genBank)
+	if len(gb) == 0 {
+		delete(sp.genes, gene)
+		c.sortedGenes = nil
+	}
+	if len(sp.genes) == 0 {
+		delete(c.specs, specimen)
+		c.delTaxSpec(sp.taxon, specimen)
+		c.sortedSpecs = nil
+	}
+}
+
 // GenBank returns the GenBank accessions
 // for the sequences in a collection.
 func (c *Collection) GenBank() []string {
@@ -108,37 +244,34 @@ func (c *Collection) GenBank() []string {
 // Genes returns the genes-molecules with sequences
 // in the collection.
 func (c *Collection) Genes() []string {
-	genNames := make(map[string]bool)
-	for _, sp := range c.specs {
-		for g := range sp.genes {
-			genNames[g] = true
+	if c.sortedGenes == nil {
+		genNames := make(map[string]bool)
+		for _, sp := range c.specs {
+			for g := range sp.genes {
+				genNames[g] = true
+			}
 		}
-	}
 
-	genes := make([]string, 0, len(genNames))
-	for g := range genNames {
-		genes = append(genes, g)
+		genes := make([]string, 0, len(genNames))
+		for g := range genNames {
+			genes = append(genes, g)
+		}
+		slices.Sort(genes)
+		c.sortedGenes = genes
 	}
-	slices.Sort(genes)
-	return genes
+	return slices.Clone(c.sortedGenes)
 }
 
 // GeneAccession returns the accession
 // for a given gene
 // of a given specimen.
 func (c *Collection) GeneAccession(specimen, gene string) []string {
-	specimen = specID(specimen)
-	if specimen == "" {
-		return nil
-	}
-
-	sp, ok := c.specs[specimen]
-	if !ok {
+	sp := c.spec(specimen)
+	if sp == nil {
 		return nil
 	}
-	gene = strings.TrimSpace(strings.ToLower(gene))
-	gb, ok := sp.genes[gene]
-	if !ok {
+	gb := sp.gene(gene)
+	if gb == nil {
 		return nil
 	}
 
@@ -161,21 +294,48 @@ func (c *Collection) Sequence(specimen, gene, genBank string) string {
 	return seq.seq
 }
 
+// Placed returns a sequence for a given specimen,
+// gene,
+// and GenBank accession,
+// placed in the coordinates of the reference gene.
+// If the sequence is in the minus strand,
+// it is reverse complemented.
+// If the sequence has a start position,
+// the positions before the start
+// are filled with missing data ('?').
+func (c *Collection) Placed(specimen, gene, genBank string) string {
+	seq := c.sequence(specimen, gene, genBank)
+	if seq == nil {
+		return ""
+	}
+
+	s := seq.seq
+	if seq.strand == "-" {
+		s = ReverseComplement(s)
+	}
+	if off := seq.offset(); off > 0 {
+		s = strings.Repeat("?", off) + s
+	}
+	return s
+}
+
 // Specimens returns the specimens in the collection.
 func (c *Collection) Specimens() []string {
-	specs := make([]string, 0, len(c.specs))
-	for _, sp := range c.specs {
-		specs = append(specs, sp.name)
+	if c.sortedSpecs == nil {
+		specs := make([]string, 0, len(c.specs))
+		for _, sp := range c.specs {
+			specs = append(specs, sp.name)
+		}
+		slices.Sort(specs)
+		c.sortedSpecs = specs
 	}
-	slices.Sort(specs)
-	return specs
+	return slices.Clone(c.sortedSpecs)
 }
 
 // SpecGene return the genes defined for a given specimen.
 func (c *Collection) SpecGene(specimen string) []string {
-	specimen = specID(specimen)
-	sp, ok := c.specs[specimen]
-	if !ok {
+	sp := c.spec(specimen)
+	if sp == nil {
 		return nil
 	}
 
@@ -190,6 +350,9 @@ func (c *Collection) SpecGene(specimen string) []string {
 
 // MaxLen returns the maximum length
 // of a sequence for a given gene.
+// If the sequences have a defined start position,
+// the length includes the positions
+// before the start of the sequence.
 func (c *Collection) MaxLen(gene string) int {
 	gene = strings.ToLower(strings.TrimSpace(gene))
 	var max int
@@ -199,7 +362,7 @@ func (c *Collection) MaxLen(gene string) int {
 			continue
 		}
 		for _, s := range gb {
-			ln := len(s.seq)
+			ln := s.offset() + len(s.seq)
 			if ln > max {
 				max = ln
 			}
@@ -211,33 +374,80 @@ func (c *Collection) MaxLen(gene string) int {
 
 // Taxa returns the taxa defined in the matrix.
 func (c *Collection) Taxa() []string {
-	taxa := make(map[string]bool)
-	for _, sp := range c.specs {
-		taxa[sp.taxon] = true
+	if c.sortedTaxa == nil {
+		txLs := make([]string, 0, len(c.taxon))
+		for t := range c.taxon {
+			txLs = append(txLs, t)
+		}
+		slices.Sort(txLs)
+		c.sortedTaxa = txLs
 	}
+	return slices.Clone(c.sortedTaxa)
+}
 
-	txLs := make([]string, 0, len(taxa))
-	for t := range taxa {
-		txLs = append(txLs, t)
+// RenameTaxon changes the name of a taxon.
+// If the new name is already in the collection,
+// the specimens of the old taxon
+// will be merged into the new taxon.
+func (c *Collection) RenameTaxon(old, name string) {
+	old = names.Taxon(old)
+	name = names.Taxon(name)
+	if name == "" || old == name {
+		return
 	}
-	slices.Sort(txLs)
 
-	return txLs
+	specs, ok := c.taxon[old]
+	if !ok {
+		return
+	}
+	for _, spec := range specs {
+		c.specs[spec].taxon = name
+	}
+	specs = append(c.taxon[name], specs...)
+	slices.Sort(specs)
+	c.taxon[name] = specs
+	delete(c.taxon, old)
+	c.sortedTaxa = nil
 }
 
 // TaxSpec returns the specimens of a given taxon.
 func (c *Collection) TaxSpec(name string) []string {
-	name = canon(name)
-	var specs []string
-	for _, sp := range c.specs {
-		if sp.taxon != name {
-			continue
-		}
-		specs = append(specs, sp.name)
+	specs, ok := c.taxon[name]
+	if !ok {
+		specs = c.taxon[names.Taxon(name)]
 	}
-	slices.Sort(specs)
+	return slices.Clone(specs)
+}
 
-	return specs
+// AddTaxSpec adds a specimen
+// to the specimens of a taxon.
+func (c *Collection) addTaxSpec(taxon, spec string) {
+	specs := c.taxon[taxon]
+	i, ok := slices.BinarySearch(specs, spec)
+	if ok {
+		return
+	}
+	if len(specs) == 0 {
+		c.sortedTaxa = nil
+	}
+	c.taxon[taxon] = slices.Insert(specs, i, spec)
+}
+
+// DelTaxSpec removes a specimen
+// from the specimens of a taxon.
+func (c *Collection) delTaxSpec(taxon, spec string) {
+	specs := c.taxon[taxon]
+	i, ok := slices.BinarySearch(specs, spec)
+	if !ok {
+		return
+	}
+	specs = slices.Delete(specs, i, i+1)
+	if len(specs) == 0 {
+		delete(c.taxon, taxon)
+		c.sortedTaxa = nil
+		return
+	}
+	c.taxon[taxon] = specs
 }
 
 // Field is used to define additional information fields
@@ -251,10 +461,53 @@ const (
 	Organelle Field = "organelle"
 	Reference Field = "reference"
 	Comments  Field = "comments"
+
+	// Molecule is the kind of molecule
+	// of the sequence:
+	// "dna" (the default),
+	// "rna",
+	// or "rrna",
+	// for ribosomal RNA.
+	Molecule Field = "molecule"
+
+	// Structure is the secondary structure
+	// of an RNA sequence,
+	// in dot-bracket notation
+	// (e.g., "((..))").
+	Structure Field = "structure"
+
+	// Sequencing metadata
+	// of lab-generated sequences:
+	// a summary of the sequencing quality
+	// (e.g., the mean phred score),
+	// a link to the trace
+	// (chromatogram) file,
+	// the sequencing run,
+	// and the primers used
+	// (separated by commas).
+	Quality Field = "quality"
+	Trace   Field = "trace"
+	Run     Field = "run"
+	Primers Field = "primers"
+
+	// Start and End are the first and last positions
+	// (starting from 1)
+	// of the sequence
+	// in the reference gene.
+	Start Field = "start"
+	End   Field = "end"
+
+	// Strand is the strand of the sequence
+	// in relation to the reference gene:
+	// "+" (the default) or "-".
+	Strand Field = "strand"
 )
 
 // Set sets the value of an additional information
 // for a sequence.
+// Fields not defined by the collection
+// are stored as opaque values,
+// and an empty value removes them.
 func (c *Collection) Set(specimen, gene, genBank, val string, field Field) {
 	seq := c.sequence(specimen, gene, genBank)
 	if seq == nil {
@@ -276,10 +529,41 @@ func (c *Collection) Set(specimen, gene, genBank, val string, field Field) {
 		}
 	case Organelle:
 		seq.organelle = strings.ToLower(val)
+	case Molecule:
+		seq.molecule = parseMolecule(val)
+	case Structure:
+		seq.structure = strings.Join(strings.Fields(val), "")
+	case Quality:
+		seq.quality = val
+	case Trace:
+		seq.trace = val
+	case Run:
+		seq.run = val
+	case Primers:
+		seq.primers = val
 	case Reference:
 		seq.ref = val
 	case Comments:
 		seq.comment = val
+	case Start:
+		seq.start = parsePosition(val)
+	case End:
+		seq.end = parsePosition(val)
+	case Strand:
+		seq.strand = parseStrand(val)
+	default:
+		name := headerName(string(field))
+		if name == "" {
+			return
+		}
+		if val == "" {
+			delete(seq.extra, name)
+			return
+		}
+		if seq.extra == nil {
+			seq.extra = make(map[string]string)
+		}
+		seq.extra[name] = val
 	}
 }
 
@@ -302,28 +586,74 @@ func (c *Collection) Val(specimen, gene, genBank string, field Field) string {
 		return "false"
 	case Organelle:
 		return seq.organelle
+	case Molecule:
+		if seq.molecule == "" {
+			return "dna"
+		}
+		return seq.molecule
+	case Structure:
+		return seq.structure
+	case Quality:
+		return seq.quality
+	case Trace:
+		return seq.trace
+	case Run:
+		return seq.run
+	case Primers:
+		return seq.primers
 	case Reference:
 		return seq.ref
 	case Comments:
 		return seq.comment
+	case Start:
+		if seq.start == 0 {
+			return ""
+		}
+		return strconv.Itoa(seq.start)
+	case End:
+		if seq.end == 0 {
+			return ""
+		}
+		return strconv.Itoa(seq.end)
+	case Strand:
+		return seq.strand
 	}
 
-	return ""
+	return seq.extra[headerName(string(field))]
 }
 
-func (c *Collection) sequence(specimen, gene, genBank string) *genBankSequence {
-	specimen = specID(specimen)
-	if specimen == "" {
-		return nil
+// ExtraFields returns the names of the fields
+// that are not defined by the collection
+// (e.g., the additional columns of a TSV file
+// added by a collaborator)
+// and that have a value in at least one sequence.
+func (c *Collection) ExtraFields() []Field {
+	fields := make(map[string]bool)
+	for _, sp := range c.specs {
+		for _, g := range sp.genes {
+			for _, seq := range g {
+				for f := range seq.extra {
+					fields[f] = true
+				}
+			}
+		}
 	}
 
-	sp, ok := c.specs[specimen]
-	if !ok {
+	ls := make([]Field, 0, len(fields))
+	for f := range fields {
+		ls = append(ls, Field(f))
+	}
+	slices.Sort(ls)
+	return ls
+}
+
+func (c *Collection) sequence(specimen, gene, genBank string) *genBankSequence {
+	sp := c.spec(specimen)
+	if sp == nil {
 		return nil
 	}
-	gene = strings.TrimSpace(strings.ToLower(gene))
-	gb, ok := sp.genes[gene]
-	if !ok {
+	gb := sp.gene(gene)
+	if gb == nil {
 		return nil
 	}
 	seq, ok := gb[genBank]
@@ -334,6 +664,28 @@ func (c *Collection) sequence(specimen, gene, genBank string) *genBankSequence {
 	return seq
 }
 
+// Spec returns a specimen,
+// or nil if it is not in the collection.
+// As the collection stores normalized IDs,
+// the ID is normalized only if it is not found,
+// so the IDs returned by the collection
+// are not normalized again.
+func (c *Collection) spec(id string) *specimen {
+	if sp, ok := c.specs[id]; ok {
+		return sp
+	}
+	return c.specs[names.Specimen(id)]
+}
+
+// Gene returns the sequences of a gene,
+// or nil if the specimen has no sequences of the gene.
+func (sp *specimen) gene(name string) map[string]*genBankSequence {
+	if gb, ok := sp.genes[name]; ok {
+		return gb
+	}
+	return sp.genes[strings.TrimSpace(strings.ToLower(name))]
+}
+
 type specimen struct {
 	taxon string
 	name  string
@@ -347,26 +699,91 @@ type genBankSequence struct {
 	organelle string
 	ref       string
 	comment   string
+
+	molecule  string
+	structure string
+
+	// sequencing metadata
+	quality string
+	trace   string
+	run     string
+	primers string
+
+	start  int
+	end    int
+	strand string
+
+	// extra stores the values of fields
+	// unknown to the collection
+	extra map[string]string
 }
 
-// Canon returns a taxon name
-// in its canonical form.
-func canon(name string) string {
-	name = strings.Join(strings.Fields(name), " ")
-	if name == "" {
-		return ""
+// Offset returns the number of positions
+// of the reference gene
+// before the start of the sequence.
+func (s *genBankSequence) offset() int {
+	if s.start > 0 {
+		return s.start - 1
+	}
+	if s.end > 0 && s.end > len(s.seq) {
+		return s.end - len(s.seq)
 	}
-	name = strings.ToLower(name)
-	r, n := utf8.DecodeRuneInString(name)
-	return string(unicode.ToUpper(r)) + name[n:]
+	return 0
 }
 
-func specID(spec string) string {
-	spec = strings.Join(strings.Fields(spec), "_")
-	if spec == "" {
+func parsePosition(val string) int {
+	p, err := strconv.Atoi(val)
+	if err != nil || p < 0 {
+		return 0
+	}
+	return p
+}
+
+// ParseMolecule returns the kind of molecule
+// of a sequence.
+// DNA is stored as an empty string.
+func parseMolecule(val string) string {
+	switch m := strings.ToLower(val); m {
+	case "", "dna":
 		return ""
+	case "rrna", "ribosomal rna":
+		return "rrna"
+	default:
+		return m
+	}
+}
+
+func parseStrand(val string) string {
+	switch strings.ToLower(val) {
+	case "-", "minus", "reverse":
+		return "-"
+	case "+", "plus", "forward":
+		return "+"
+	}
+	return ""
+}
+
+var complement = map[rune]rune{
+	'a': 't', 'c': 'g', 'g': 'c', 't': 'a', 'u': 'a',
+	'r': 'y', 'y': 'r', 'k': 'm', 'm': 'k',
+	'b': 'v', 'v': 'b', 'd': 'h', 'h': 'd',
+}
+
+// ReverseComplement returns the reverse complement
+// of a sequence.
+func ReverseComplement(seq string) string {
+	rs := []rune(seq)
+	for i, j := 0, len(rs)-1; i <= j; i, j = i+1, j-1 {
+		a, b := rs[i], rs[j]
+		if c, ok := complement[b]; ok {
+			b = c
+		}
+		if c, ok := complement[a]; ok {
+			a = c
+		}
+		rs[i], rs[j] = b, a
 	}
-	return strings.ToLower(spec)
+	return string(rs)
 }
 
 func formatSequence(seq string) string {
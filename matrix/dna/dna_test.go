@@ -6,6 +6,7 @@ package dna_test
 
 import (
 	"reflect"
+	"slices"
 	"testing"
 
 	"github.com/js-arias/phydata/matrix/dna"
@@ -165,6 +166,9 @@ func newCollection() *dna.Collection {
 	c.Set("genbank:xm_003897809", "eef1a1", "XM_003897809", "true", dna.Aligned)
 	c.Set("genbank:xm_003897809", "eef1a1", "XM_003897809", "true", dna.Protein)
 	c.Set("genbank:xm_003897809", "eef1a1", "XM_003897809", "nucleus", dna.Organelle)
+	c.Set("genbank:xm_003897809", "eef1a1", "XM_003897809", "11", dna.Start)
+	c.Set("genbank:xm_003897809", "eef1a1", "XM_003897809", "40", dna.End)
+	c.Set("genbank:xm_003897809", "eef1a1", "XM_003897809", "minus", dna.Strand)
 	return c
 }
 
@@ -206,7 +210,7 @@ func cmpCollection(t testing.TB, got, want *dna.Collection) {
 					}
 
 					prt := want.Val(spec, gene, acc, dna.Protein)
-					protein := got.Val(spec, gene, acc, dna.Aligned)
+					protein := got.Val(spec, gene, acc, dna.Protein)
 					if protein != prt {
 						t.Errorf("sequence %q: specimen %q, gene %q, accession %q: protein: got %q, want %q", tax, spec, gene, acc, protein, prt)
 					}
@@ -216,6 +220,13 @@ func cmpCollection(t testing.TB, got, want *dna.Collection) {
 					if organelle != org {
 						t.Errorf("sequence %q: specimen %q, gene %q, accession %q: organelle: got %q, want %q", tax, spec, gene, acc, organelle, org)
 					}
+
+					for _, f := range []dna.Field{dna.Start, dna.End, dna.Strand} {
+						v := want.Val(spec, gene, acc, f)
+						if gv := got.Val(spec, gene, acc, f); gv != v {
+							t.Errorf("sequence %q: specimen %q, gene %q, accession %q: %s: got %q, want %q", tax, spec, gene, acc, f, gv, v)
+						}
+					}
 				}
 			}
 
@@ -223,3 +234,212 @@ func cmpCollection(t testing.TB, got, want *dna.Collection) {
 	}
 
 }
+
+func TestDelete(t *testing.T) {
+	c := newCollection()
+
+	c.Delete("sp-01", "cytb", "MN148748")
+	if s := c.Sequence("sp-01", "cytb", "MN148748"); s != "" {
+		t.Errorf("delete: sequence %q not deleted", "MN148748")
+	}
+	if g := c.SpecGene("sp-01"); !reflect.DeepEqual(g, []string{"eef1a1"}) {
+		t.Errorf("delete: specimen genes: got %v, want %v", g, []string{"eef1a1"})
+	}
+
+	c.Delete("sp-02", "cytb", "OR167429")
+	specs := []string{
+		"fmnh_un_2485",
+		"genbank:ku871221",
+		"genbank:xm_003897809",
+		"sp-01",
+	}
+	if sp := c.Specimens(); !reflect.DeepEqual(sp, specs) {
+		t.Errorf("delete: specimens: got %v, want %v", sp, specs)
+	}
+	taxa := []string{"Loxodonta africana", "Panthera tigris", "Papio anubis"}
+	if tx := c.Taxa(); !reflect.DeepEqual(tx, taxa) {
+		t.Errorf("delete: taxa: got %v, want %v", tx, taxa)
+	}
+	if sp := c.TaxSpec("Orycteropus afer"); len(sp) != 0 {
+		t.Errorf("delete: specimens of %q: got %v, want none", "Orycteropus afer", sp)
+	}
+}
+
+func TestTaxSpec(t *testing.T) {
+	c := newCollection()
+	c.Add("Papio anubis", "a-01", "cytb", "KU871222", "atgaccccaa")
+
+	specs := []string{"a-01", "genbank:ku871221", "genbank:xm_003897809"}
+	if sp := c.TaxSpec("papio  anubis"); !reflect.DeepEqual(sp, specs) {
+		t.Errorf("specimens of %q: got %v, want %v", "Papio anubis", sp, specs)
+	}
+
+	nc := c.Filter(func(e dna.Entry) bool {
+		return e.Gene == "cytb"
+	})
+	specs = []string{"a-01", "genbank:ku871221"}
+	if sp := nc.TaxSpec("Papio anubis"); !reflect.DeepEqual(sp, specs) {
+		t.Errorf("filter: specimens of %q: got %v, want %v", "Papio anubis", sp, specs)
+	}
+
+	nc = c.Clone()
+	nc.Delete("a-01", "cytb", "KU871222")
+	nc.RenameTaxon("Papio anubis", "Papio hamadryas")
+	specs = []string{"a-01", "genbank:ku871221", "genbank:xm_003897809"}
+	if sp := c.TaxSpec("Papio anubis"); !reflect.DeepEqual(sp, specs) {
+		t.Errorf("clone: original specimens of %q: got %v, want %v", "Papio anubis", sp, specs)
+	}
+	specs = []string{"genbank:ku871221", "genbank:xm_003897809"}
+	if sp := nc.TaxSpec("Papio hamadryas"); !reflect.DeepEqual(sp, specs) {
+		t.Errorf("clone: specimens of %q: got %v, want %v", "Papio hamadryas", sp, specs)
+	}
+}
+
+func TestSortedLists(t *testing.T) {
+	c := newCollection()
+
+	// modifying a returned list
+	// does not change the collection
+	genes := c.Genes()
+	genes[0] = "zzz"
+	if got := c.Genes(); got[0] == "zzz" {
+		t.Errorf("genes: list changed by the caller: %v", got)
+	}
+
+	// lists are updated after a change
+	c.Add("Rattus rattus", "r-01", "16s", "AB000001", "acgt")
+	if got, want := c.Genes(), []string{"16s", "cytb", "eef1a1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("genes: got %v, want %v", got, want)
+	}
+	if got := c.Specimens(); !slices.Contains(got, "r-01") {
+		t.Errorf("specimens: %q not in %v", "r-01", got)
+	}
+	if got := c.Taxa(); !slices.Contains(got, "Rattus rattus") {
+		t.Errorf("taxa: %q not in %v", "Rattus rattus", got)
+	}
+	c.RenameGene("16s", "rrna 16s")
+	if got, want := c.Genes(), []string{"cytb", "eef1a1", "rrna 16s"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("genes: got %v, want %v", got, want)
+	}
+	c.Delete("r-01", "rrna 16s", "AB000001")
+	if got, want := c.Genes(), []string{"cytb", "eef1a1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("genes: got %v, want %v", got, want)
+	}
+	if got := c.Specimens(); slices.Contains(got, "r-01") {
+		t.Errorf("specimens: deleted %q in %v", "r-01", got)
+	}
+	if got := c.Taxa(); slices.Contains(got, "Rattus rattus") {
+		t.Errorf("taxa: deleted %q in %v", "Rattus rattus", got)
+	}
+}
+
+func BenchmarkGenes(b *testing.B) {
+	_, c := largeDNAText(10_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Genes()
+	}
+}
+
+func BenchmarkTaxSpec(b *testing.B) {
+	_, c := largeDNAText(10_000)
+	taxa := c.Taxa()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, tx := range taxa {
+			c.TaxSpec(tx)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	c := newCollection()
+	c.SetPolicy(dna.Reject)
+	c.Set("sp-01", "cytb", "MN148748", "P-07", "plate")
+
+	nc := c.Clone()
+	cmpCollection(t, nc, c)
+
+	nc.Delete("sp-02", "cytb", "OR167429")
+	nc.Set("sp-01", "cytb", "MN148748", "nucleus", dna.Organelle)
+	nc.Set("sp-01", "cytb", "MN148748", "P-08", "plate")
+	nc.Add("Loxodonta africana", "sp-01", "cytb", "MN148749", "ccatccaaca")
+
+	if s := c.Sequence("sp-02", "cytb", "OR167429"); s == "" {
+		t.Errorf("original sequence %q deleted by the clone", "OR167429")
+	}
+	if v := c.Val("sp-01", "cytb", "MN148748", dna.Organelle); v != "mitochondrion" {
+		t.Errorf("original organelle: got %q, want %q", v, "mitochondrion")
+	}
+	if v := c.Val("sp-01", "cytb", "MN148748", "plate"); v != "P-07" {
+		t.Errorf("original extra field: got %q, want %q", v, "P-07")
+	}
+	if acc := c.GeneAccession("sp-01", "cytb"); !reflect.DeepEqual(acc, []string{"MN148748"}) {
+		t.Errorf("original accessions: got %v, want %v", acc, []string{"MN148748"})
+	}
+	if err := nc.Add("Orycteropus afer", "sp-02", "cytb", "MN148748", "ccatccaaca"); err == nil {
+		t.Errorf("clone policy: expecting error on a duplicated accession")
+	}
+}
+
+func TestRenameTaxon(t *testing.T) {
+	c := newCollection()
+
+	c.RenameTaxon("Papio anubis", "Loxodonta africana")
+	taxa := []string{"Loxodonta africana", "Orycteropus afer", "Panthera tigris"}
+	if tx := c.Taxa(); !reflect.DeepEqual(tx, taxa) {
+		t.Errorf("taxa: got %v, want %v", tx, taxa)
+	}
+	specs := []string{"genbank:ku871221", "genbank:xm_003897809", "sp-01"}
+	if sp := c.TaxSpec("Loxodonta africana"); !reflect.DeepEqual(sp, specs) {
+		t.Errorf("specimens of %q: got %v, want %v", "Loxodonta africana", sp, specs)
+	}
+}
+
+func TestPlaced(t *testing.T) {
+	c := newCollection()
+
+	want := "??????????gggtgcagtggcgcgatctcggctcactgc"
+	if s := c.Placed("genbank:xm_003897809", "eef1a1", "XM_003897809"); s != want {
+		t.Errorf("placed: got %q, want %q", s, want)
+	}
+	if s := c.Placed("sp-01", "cytb", "MN148748"); s != c.Sequence("sp-01", "cytb", "MN148748") {
+		t.Errorf("placed: got %q, want %q", s, c.Sequence("sp-01", "cytb", "MN148748"))
+	}
+	if ln := c.MaxLen("eef1a1"); ln != 40 {
+		t.Errorf("max len: got %d, want %d", ln, 40)
+	}
+	if st := c.Val("genbank:xm_003897809", "eef1a1", "XM_003897809", dna.Strand); st != "-" {
+		t.Errorf("strand: got %q, want %q", st, "-")
+	}
+}
+
+func BenchmarkGeneAccession(b *testing.B) {
+	_, c := largeDNAText(10_000)
+	specs := c.Specimens()
+	genes := c.Genes()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, sp := range specs {
+			for _, g := range genes {
+				c.GeneAccession(sp, g)
+			}
+		}
+	}
+}
+
+func BenchmarkPlaced(b *testing.B) {
+	_, c := largeDNAText(10_000)
+	specs := c.Specimens()
+	genes := c.Genes()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, sp := range specs {
+			for _, g := range genes {
+				for _, acc := range c.GeneAccession(sp, g) {
+					c.Placed(sp, g, acc)
+				}
+			}
+		}
+	}
+}
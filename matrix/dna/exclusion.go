@@ -0,0 +1,248 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A Region is a range of columns
+// of an alignment.
+// Positions are 1-based,
+// and the range includes both ends.
+type Region struct {
+	From    int
+	To      int
+	Comment string
+}
+
+// Exclusions is a set of exclusion masks
+// of the genes of a collection,
+// for example,
+// the ambiguously aligned regions of a gene.
+type Exclusions struct {
+	genes map[string][]Region
+}
+
+// NewExclusions creates a new empty set
+// of exclusion masks.
+func NewExclusions() *Exclusions {
+	return &Exclusions{
+		genes: make(map[string][]Region),
+	}
+}
+
+// Add adds an excluded region to a gene.
+// If the region is already defined,
+// its comment will be replaced.
+func (e *Exclusions) Add(gene string, from, to int, comment string) error {
+	gene = strings.ToLower(strings.TrimSpace(gene))
+	if gene == "" {
+		return fmt.Errorf("excluded region without a gene")
+	}
+	if from < 1 {
+		return fmt.Errorf("gene %q: invalid start position %d", gene, from)
+	}
+	if to < from {
+		return fmt.Errorf("gene %q: end position %d is lesser than start position %d", gene, to, from)
+	}
+	comment = strings.Join(strings.Fields(comment), " ")
+
+	for i, r := range e.genes[gene] {
+		if r.From == from && r.To == to {
+			e.genes[gene][i].Comment = comment
+			return nil
+		}
+	}
+	e.genes[gene] = append(e.genes[gene], Region{
+		From:    from,
+		To:      to,
+		Comment: comment,
+	})
+	slices.SortFunc(e.genes[gene], func(a, b Region) int {
+		if a.From != b.From {
+			return a.From - b.From
+		}
+		return a.To - b.To
+	})
+	return nil
+}
+
+// Delete removes an excluded region of a gene.
+// If from is 0,
+// all the regions of the gene will be removed.
+func (e *Exclusions) Delete(gene string, from, to int) {
+	gene = strings.ToLower(strings.TrimSpace(gene))
+	if from == 0 {
+		delete(e.genes, gene)
+		return
+	}
+
+	ls := slices.DeleteFunc(e.genes[gene], func(r Region) bool {
+		return r.From == from && r.To == to
+	})
+	if len(ls) == 0 {
+		delete(e.genes, gene)
+		return
+	}
+	e.genes[gene] = ls
+}
+
+// Genes returns the genes
+// with excluded regions.
+func (e *Exclusions) Genes() []string {
+	genes := make([]string, 0, len(e.genes))
+	for g := range e.genes {
+		genes = append(genes, g)
+	}
+	slices.Sort(genes)
+	return genes
+}
+
+// Regions returns the excluded regions of a gene,
+// sorted by its start position.
+func (e *Exclusions) Regions(gene string) []Region {
+	gene = strings.ToLower(strings.TrimSpace(gene))
+	return slices.Clone(e.genes[gene])
+}
+
+// Mask returns the mask of a gene
+// for an alignment of the given length.
+// Columns in an excluded region
+// are set as false,
+// and all other columns are set as true.
+func (e *Exclusions) Mask(gene string, length int) Mask {
+	m := make(Mask, length)
+	for i := range m {
+		m[i] = true
+	}
+	for _, r := range e.Regions(gene) {
+		for i := r.From - 1; i < r.To && i < length; i++ {
+			m[i] = false
+		}
+	}
+	return m
+}
+
+var exclusionFields = []string{
+	"gene",
+	"from",
+	"to",
+}
+
+// ReadTSV reads a set of exclusion masks
+// from a TSV file.
+//
+// The TSV file must contains the following fields:
+//
+//   - gene, the name of the gene
+//   - from, the first excluded column (1-based)
+//   - to, the last excluded column
+//
+// Additionally, it can contain a "comments" field.
+//
+// Here is an example file:
+//
+//	# exclusion masks
+//	gene	from	to	comments
+//	12s	210	245	ambiguously aligned loop
+//	16s	501	530
+func (e *Exclusions) ReadTSV(r io.Reader) error {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return fmt.Errorf("while reading header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(h)
+		fields[h] = i
+	}
+	for _, h := range exclusionFields {
+		if _, ok := fields[h]; !ok {
+			return fmt.Errorf("expecting field %q", h)
+		}
+	}
+
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		f := "gene"
+		gene := row[fields[f]]
+		if strings.TrimSpace(gene) == "" {
+			continue
+		}
+
+		f = "from"
+		from, err := strconv.Atoi(strings.TrimSpace(row[fields[f]]))
+		if err != nil {
+			return fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		f = "to"
+		to, err := strconv.Atoi(strings.TrimSpace(row[fields[f]]))
+		if err != nil {
+			return fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+
+		var comment string
+		f = "comments"
+		if i, ok := fields[f]; ok {
+			comment = row[i]
+		}
+		if err := e.Add(gene, from, to, comment); err != nil {
+			return fmt.Errorf("on row %d: %v", ln, err)
+		}
+	}
+	return nil
+}
+
+// TSV writes a set of exclusion masks
+// as a TSV file.
+func (e *Exclusions) TSV(w io.Writer) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	header := append(slices.Clone(exclusionFields), "comments")
+	if err := tab.Write(header); err != nil {
+		return fmt.Errorf("unable to write header: %v", err)
+	}
+
+	for _, g := range e.Genes() {
+		for _, r := range e.genes[g] {
+			row := []string{
+				g,
+				strconv.Itoa(r.From),
+				strconv.Itoa(r.To),
+				r.Comment,
+			}
+			if err := tab.Write(row); err != nil {
+				return fmt.Errorf("while writing data: %v", err)
+			}
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+func TestExclusions(t *testing.T) {
+	e := dna.NewExclusions()
+	if err := e.Add("16S", 8, 9, ""); err != nil {
+		t.Fatalf("unable to add region: %v", err)
+	}
+	if err := e.Add("16s", 2, 3, "ambiguous loop"); err != nil {
+		t.Fatalf("unable to add region: %v", err)
+	}
+	if err := e.Add("cytb", 1, 1, ""); err != nil {
+		t.Fatalf("unable to add region: %v", err)
+	}
+	if err := e.Add("cytb", 5, 2, ""); err == nil {
+		t.Errorf("invalid region: expecting error")
+	}
+
+	if g := e.Genes(); !reflect.DeepEqual(g, []string{"16s", "cytb"}) {
+		t.Errorf("genes: got %v, want %v", g, []string{"16s", "cytb"})
+	}
+	want := []dna.Region{
+		{From: 2, To: 3, Comment: "ambiguous loop"},
+		{From: 8, To: 9},
+	}
+	if r := e.Regions("16s"); !reflect.DeepEqual(r, want) {
+		t.Errorf("regions: got %v, want %v", r, want)
+	}
+
+	seq := "acgtacgtac"
+	if got := e.Mask("16s", len(seq)).Apply(seq); got != "atacgc" {
+		t.Errorf("mask: got %q, want %q", got, "atacgc")
+	}
+
+	var w bytes.Buffer
+	if err := e.TSV(&w); err != nil {
+		t.Fatalf("unable to write TSV data: %v", err)
+	}
+	t.Logf("output:\n%s\n", w.String())
+
+	got := dna.NewExclusions()
+	if err := got.ReadTSV(&w); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+	for _, g := range e.Genes() {
+		if r := got.Regions(g); !reflect.DeepEqual(r, e.Regions(g)) {
+			t.Errorf("gene %q: got %v, want %v", g, r, e.Regions(g))
+		}
+	}
+
+	e.Delete("16s", 2, 3)
+	if r := e.Regions("16s"); !reflect.DeepEqual(r, want[1:]) {
+		t.Errorf("delete: got %v, want %v", r, want[1:])
+	}
+	e.Delete("cytb", 0, 0)
+	if g := e.Genes(); !reflect.DeepEqual(g, []string{"16s"}) {
+		t.Errorf("delete gene: got %v, want %v", g, []string{"16s"})
+	}
+}
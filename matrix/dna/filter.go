@@ -0,0 +1,102 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+import "maps"
+
+// An Entry identifies a sequence
+// of a collection.
+type Entry struct {
+	Taxon    string
+	Specimen string
+	Gene     string
+	GenBank  string
+}
+
+// Filter returns a new collection
+// with the sequences for which keep returns true.
+// The additional fields of the kept sequences
+// are copied,
+// as well as the accession policy,
+// the aliases,
+// the header synonyms,
+// and the uracil normalization
+// of the collection.
+func (c *Collection) Filter(keep func(Entry) bool) *Collection {
+	nc := New()
+	nc.policy = c.policy
+	nc.aliases = maps.Clone(c.aliases)
+	nc.headers = maps.Clone(c.headers)
+	nc.normU = c.normU
+
+	for id, sp := range c.specs {
+		var nsp *specimen
+		for g, gb := range sp.genes {
+			for acc, seq := range gb {
+				e := Entry{
+					Taxon:    sp.taxon,
+					Specimen: sp.name,
+					Gene:     g,
+					GenBank:  acc,
+				}
+				if !keep(e) {
+					continue
+				}
+
+				if nsp == nil {
+					nsp = &specimen{
+						taxon: sp.taxon,
+						name:  sp.name,
+						genes: make(map[string]map[string]*genBankSequence),
+					}
+					nc.specs[id] = nsp
+					nc.addTaxSpec(sp.taxon, id)
+				}
+				ngb, ok := nsp.genes[g]
+				if !ok {
+					ngb = make(map[string]*genBankSequence)
+					nsp.genes[g] = ngb
+				}
+				ns := *seq
+				ns.extra = maps.Clone(seq.extra)
+				ngb[acc] = &ns
+				nc.addAccession(id, g, acc)
+			}
+		}
+	}
+	return nc
+}
+
+// Equal returns true
+// if a sequence is in both collections,
+// with the same taxon,
+// sequence,
+// and values of the additional fields.
+func (c *Collection) Equal(o *Collection, specimen, gene, genBank string) bool {
+	s1 := c.sequence(specimen, gene, genBank)
+	s2 := o.sequence(specimen, gene, genBank)
+	if s1 == nil || s2 == nil {
+		return false
+	}
+	if c.spec(specimen).taxon != o.spec(specimen).taxon {
+		return false
+	}
+	return s1.seq == s2.seq &&
+		s1.aligned == s2.aligned &&
+		s1.protein == s2.protein &&
+		s1.organelle == s2.organelle &&
+		s1.ref == s2.ref &&
+		s1.comment == s2.comment &&
+		s1.molecule == s2.molecule &&
+		s1.structure == s2.structure &&
+		s1.quality == s2.quality &&
+		s1.trace == s2.trace &&
+		s1.run == s2.run &&
+		s1.primers == s2.primers &&
+		s1.start == s2.start &&
+		s1.end == s2.end &&
+		s1.strand == s2.strand &&
+		maps.Equal(s1.extra, s2.extra)
+}
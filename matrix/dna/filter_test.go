@@ -0,0 +1,82 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+func TestFilter(t *testing.T) {
+	c := newCollection()
+
+	f := c.Filter(func(e dna.Entry) bool {
+		return e.Gene == "cytb" && e.Taxon != "Orycteropus afer"
+	})
+
+	if g := f.Genes(); !reflect.DeepEqual(g, []string{"cytb"}) {
+		t.Errorf("genes: got %v, want %v", g, []string{"cytb"})
+	}
+	taxa := []string{"Loxodonta africana", "Panthera tigris", "Papio anubis"}
+	if tx := f.Taxa(); !reflect.DeepEqual(tx, taxa) {
+		t.Errorf("taxa: got %v, want %v", tx, taxa)
+	}
+	specs := []string{"fmnh_un_2485", "genbank:ku871221", "sp-01"}
+	if sp := f.Specimens(); !reflect.DeepEqual(sp, specs) {
+		t.Errorf("specimens: got %v, want %v", sp, specs)
+	}
+	if v := f.Val("sp-01", "cytb", "MN148748", dna.Organelle); v != "mitochondrion" {
+		t.Errorf("organelle: got %q, want %q", v, "mitochondrion")
+	}
+	if sp := f.AccessionSpec("OR167429"); len(sp) != 0 {
+		t.Errorf("filtered accession: got %v, want no specimens", sp)
+	}
+	if sp := f.AccessionSpec("MN148748"); !reflect.DeepEqual(sp, []string{"sp-01"}) {
+		t.Errorf("accession: got %v, want %v", sp, []string{"sp-01"})
+	}
+
+	// the original collection is not modified
+	f.Delete("sp-01", "cytb", "MN148748")
+	if s := c.Sequence("sp-01", "cytb", "MN148748"); s == "" {
+		t.Errorf("original sequence %q deleted", "MN148748")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	c := newCollection()
+	o := c.Filter(func(e dna.Entry) bool {
+		return true
+	})
+
+	if !c.Equal(o, "sp-01", "cytb", "MN148748") {
+		t.Errorf("sequence %q: want equal", "MN148748")
+	}
+	if c.Equal(o, "sp-01", "cytb", "undefined") {
+		t.Errorf("sequence %q: undefined sequence: want not equal", "undefined")
+	}
+
+	o.Set("sp-01", "cytb", "MN148748", "a comment", dna.Comments)
+	if c.Equal(o, "sp-01", "cytb", "MN148748") {
+		t.Errorf("sequence %q: changed field: want not equal", "MN148748")
+	}
+	o.Set("sp-01", "cytb", "MN148748", "", dna.Comments)
+	o.Set("sp-01", "cytb", "MN148748", "value", dna.Field("collaborator"))
+	if c.Equal(o, "sp-01", "cytb", "MN148748") {
+		t.Errorf("sequence %q: changed extra field: want not equal", "MN148748")
+	}
+
+	o.RenameTaxon("Papio anubis", "Papio hamadryas")
+	for _, sp := range c.TaxSpec("Papio anubis") {
+		for _, g := range c.SpecGene(sp) {
+			for _, acc := range c.GeneAccession(sp, g) {
+				if c.Equal(o, sp, g, acc) {
+					t.Errorf("sequence %q: changed taxon: want not equal", acc)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+import (
+	"slices"
+	"strings"
+)
+
+// An Indel is a gap shared by one or more sequences
+// of an aligned gene.
+type Indel struct {
+	// Start is the first position of the gap
+	// (starting from 0).
+	Start int
+
+	// End is the position after the last position
+	// of the gap.
+	End int
+}
+
+// Len returns the number of positions in the indel.
+func (i Indel) Len() int {
+	return i.End - i.Start
+}
+
+// IndelCoding codes the gaps of a set of aligned sequences
+// as presence/absence characters
+// using the simple indel coding
+// of Simmons & Ochoterena (2000, Syst. Biol. 49: 369).
+//
+// The sequences are given as a map of a terminal name
+// to an aligned sequence.
+//
+// Each gap with unique start and end positions
+// is coded as a character.
+// A terminal with the indel is coded as '1',
+// a terminal without the indel is coded as '0',
+// and a terminal with a larger gap
+// that includes the indel,
+// or with missing data in the indel region,
+// is coded as '?'.
+// Leading and trailing gaps are considered as missing data
+// and are not coded.
+//
+// It returns the list of indels,
+// sorted by position,
+// and the coding of each terminal.
+func IndelCoding(seqs map[string]string) ([]Indel, map[string]string) {
+	gaps := make(map[string][]Indel, len(seqs))
+	var indels []Indel
+	for n, s := range seqs {
+		g := seqGaps(s)
+		gaps[n] = g
+		for _, i := range g {
+			if slices.Contains(indels, i) {
+				continue
+			}
+			indels = append(indels, i)
+		}
+	}
+	slices.SortFunc(indels, func(a, b Indel) int {
+		if a.Start != b.Start {
+			return a.Start - b.Start
+		}
+		return a.End - b.End
+	})
+
+	codes := make(map[string]string, len(seqs))
+	for n, s := range seqs {
+		var code strings.Builder
+		for _, i := range indels {
+			code.WriteByte(indelState(s, gaps[n], i))
+		}
+		codes[n] = code.String()
+	}
+
+	return indels, codes
+}
+
+func indelState(seq string, gaps []Indel, i Indel) byte {
+	if len(seq) < i.End {
+		return '?'
+	}
+
+	for _, g := range gaps {
+		if g == i {
+			return '1'
+		}
+		if g.Start <= i.Start && g.End >= i.End {
+			return '?'
+		}
+	}
+
+	for p := i.Start; p < i.End; p++ {
+		switch seq[p] {
+		case '?', 'n':
+			return '?'
+		case '-':
+			// outside the internal gaps
+			// so it is a terminal gap
+			if !inGaps(gaps, p) {
+				return '?'
+			}
+		}
+	}
+	return '0'
+}
+
+func inGaps(gaps []Indel, p int) bool {
+	for _, g := range gaps {
+		if p >= g.Start && p < g.End {
+			return true
+		}
+	}
+	return false
+}
+
+// SeqGaps returns the internal gaps of a sequence.
+func seqGaps(seq string) []Indel {
+	first := strings.IndexFunc(seq, isBase)
+	if first < 0 {
+		return nil
+	}
+	last := strings.LastIndexFunc(seq, isBase)
+
+	var gaps []Indel
+	start := -1
+	for p := first; p <= last; p++ {
+		if seq[p] == '-' {
+			if start < 0 {
+				start = p
+			}
+			continue
+		}
+		if start >= 0 {
+			gaps = append(gaps, Indel{Start: start, End: p})
+			start = -1
+		}
+	}
+	return gaps
+}
+
+// IsBase returns true if a position of a sequence
+// is not a gap,
+// or missing data
+// ('?', or 'n', any base).
+func isBase(r rune) bool {
+	switch r {
+	case '-', '?', 'n', 'N':
+		return false
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+func TestIndelCoding(t *testing.T) {
+	seqs := map[string]string{
+		"a": "acgt--acgtac",
+		"b": "acgt--ac--ac",
+		"c": "acg------tac",
+		"d": "--gtacacgtac",
+		"e": "acgtacac??ac",
+	}
+
+	indels, codes := dna.IndelCoding(seqs)
+	wantIndels := []dna.Indel{
+		{Start: 3, End: 9},
+		{Start: 4, End: 6},
+		{Start: 8, End: 10},
+	}
+	if !reflect.DeepEqual(indels, wantIndels) {
+		t.Errorf("indels: got %v, want %v", indels, wantIndels)
+	}
+
+	wantCodes := map[string]string{
+		"a": "010",
+		"b": "011",
+		"c": "1?0",
+		"d": "000",
+		"e": "?0?",
+	}
+	if !reflect.DeepEqual(codes, wantCodes) {
+		t.Errorf("codes: got %v, want %v", codes, wantCodes)
+	}
+}
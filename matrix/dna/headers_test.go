@@ -0,0 +1,58 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+func TestHeaders(t *testing.T) {
+	text := "species\tvoucher\tlocus\taccession\tsequence\n" +
+		"Orycteropus afer\tsp-02\tcytb\tOR167429\tgaccaacattcgtaaaacc\n"
+
+	if err := dna.New().ReadTSV(strings.NewReader(text)); err == nil {
+		t.Errorf("without synonyms: expecting error")
+	}
+
+	c := dna.New()
+	c.SetHeaders(dna.DefaultHeaders())
+	if err := c.ReadTSV(strings.NewReader(text)); err != nil {
+		t.Fatalf("unable to read data: %v", err)
+	}
+	want := "gaccaacattcgtaaaacc"
+	if seq := c.Sequence("sp-02", "cytb", "OR167429"); seq != want {
+		t.Errorf("sequence: got %q, want %q", seq, want)
+	}
+
+	// field names take precedence
+	text = "taxon\tspecies\tspecimen\tgene\tgenbank\tbases\n" +
+		"Orycteropus afer\tafer\tsp-02\tcytb\tOR167429\tgaccaacattcgtaaaacc\n"
+	c = dna.New()
+	c.SetHeaders(dna.DefaultHeaders())
+	if err := c.ReadTSV(strings.NewReader(text)); err != nil {
+		t.Fatalf("unable to read data: %v", err)
+	}
+	if tx := c.Taxa(); len(tx) != 1 || tx[0] != "Orycteropus afer" {
+		t.Errorf("taxa: got %v, want %v", tx, []string{"Orycteropus afer"})
+	}
+
+	h, err := dna.ReadHeaders(strings.NewReader("# synonyms\nbases\tnucleotides\n"))
+	if err != nil {
+		t.Fatalf("unable to read synonyms: %v", err)
+	}
+	text = "taxon\tspecimen\tgene\tgenbank\tnucleotides\n" +
+		"Orycteropus afer\tsp-02\tcytb\tOR167429\tgaccaacattcgtaaaacc\n"
+	c = dna.New()
+	c.SetHeaders(h)
+	if err := c.ReadTSV(strings.NewReader(text)); err != nil {
+		t.Fatalf("unable to read data: %v", err)
+	}
+	if seq := c.Sequence("sp-02", "cytb", "OR167429"); seq != want {
+		t.Errorf("sequence: got %q, want %q", seq, want)
+	}
+}
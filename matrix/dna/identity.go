@@ -0,0 +1,92 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+import "strings"
+
+// Identity returns the fraction of identical bases
+// between two sequences.
+//
+// Sequences are compared position by position,
+// so they should be aligned.
+// Positions with gaps or missing data
+// in any of the sequences are ignored.
+// If the sequences share less than MinOverlap
+// of the comparable positions of the shorter sequence
+// (e.g., partial sequences of different regions),
+// they are not comparable,
+// and it returns 0.
+func Identity(a, b string) float64 {
+	ln := len(a)
+	if len(b) < ln {
+		ln = len(b)
+	}
+
+	var n, eq int
+	for i := 0; i < ln; i++ {
+		if !isComparable(a[i]) || !isComparable(b[i]) {
+			continue
+		}
+		n++
+		if a[i] == b[i] {
+			eq++
+		}
+	}
+	short := min(countComparable(a), countComparable(b))
+	if n == 0 || float64(n) < MinOverlap*float64(short) {
+		return 0
+	}
+	return float64(eq) / float64(n)
+}
+
+// MinOverlap is the minimum fraction
+// of the comparable positions of the shorter sequence
+// that must be shared by two sequences
+// to calculate its identity.
+const MinOverlap = 0.5
+
+// Overlap returns the number of positions
+// in which both sequences have a base
+// (i.e., positions without gaps or missing data).
+func Overlap(a, b string) int {
+	ln := len(a)
+	if len(b) < ln {
+		ln = len(b)
+	}
+
+	var n int
+	for i := 0; i < ln; i++ {
+		if isComparable(a[i]) && isComparable(b[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+// Ungap returns a sequence without gaps.
+func Ungap(seq string) string {
+	return strings.ReplaceAll(seq, "-", "")
+}
+
+func isComparable(b byte) bool {
+	switch b {
+	case '-', '?', 'n':
+		return false
+	}
+	return true
+}
+
+// CountComparable returns the number of positions
+// of a sequence
+// without gaps or missing data.
+func countComparable(seq string) int {
+	var n int
+	for i := 0; i < len(seq); i++ {
+		if isComparable(seq[i]) {
+			n++
+		}
+	}
+	return n
+}
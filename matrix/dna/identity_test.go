@@ -0,0 +1,50 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+func TestIdentity(t *testing.T) {
+	tests := map[string]struct {
+		a, b string
+		want float64
+	}{
+		"identical":  {"acgtacgtac", "acgtacgtac", 1},
+		"one diff":   {"acgtacgtac", "acgtacgtaa", 0.9},
+		"with gaps":  {"acgt--gtac", "acgtacgtaa", 0.875},
+		"missing":    {"??gtacgtnc", "acgtacgtac", 1},
+		"no overlap": {"acgt------", "----acgtac", 0},
+		"fragments":  {"acgtacgtac--------", "--------acgtacgtac", 0},
+		"partial":    {"acgtacgtac", "----acgtaa", 5.0 / 6},
+	}
+
+	for name, test := range tests {
+		if got := dna.Identity(test.a, test.b); got != test.want {
+			t.Errorf("%s: got %.4f, want %.4f", name, got, test.want)
+		}
+	}
+}
+
+func TestOverlap(t *testing.T) {
+	tests := map[string]struct {
+		a, b string
+		want int
+	}{
+		"identical":  {"acgtacgtac", "acgtacgtac", 10},
+		"missing":    {"??gtacgtnc", "acgtacgtac", 7},
+		"fragments":  {"acgtacgtac--------", "--------acgtacgtac", 2},
+		"no overlap": {"acgt------", "----acgtac", 0},
+	}
+
+	for name, test := range tests {
+		if got := dna.Overlap(test.a, test.b); got != test.want {
+			t.Errorf("%s: got %d, want %d", name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+// A Profile is the set of k-mers
+// (words of length k)
+// of a sequence.
+type Profile map[string]bool
+
+// KmerProfile returns the k-mer profile of a sequence.
+// Gaps are ignored,
+// and k-mers with missing data
+// or ambiguous bases are not included.
+func KmerProfile(seq string, k int) Profile {
+	seq = Ungap(seq)
+	p := make(Profile)
+	for i := 0; i+k <= len(seq); i++ {
+		w := seq[i : i+k]
+		if !isNucleotideWord(w) {
+			continue
+		}
+		p[w] = true
+	}
+	return p
+}
+
+// Distance returns the k-mer distance between two profiles,
+// defined as the fraction of k-mers of the smaller profile
+// that are not shared with the other profile.
+// If any of the profiles is empty,
+// it returns 1.
+func (p Profile) Distance(q Profile) float64 {
+	a, b := p, q
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	if len(a) == 0 {
+		return 1
+	}
+
+	var shared int
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return 1 - float64(shared)/float64(len(a))
+}
+
+func isNucleotideWord(w string) bool {
+	for i := 0; i < len(w); i++ {
+		switch w[i] {
+		case 'a', 'c', 'g', 't', 'u':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+func TestKmerDistance(t *testing.T) {
+	tests := map[string]struct {
+		a, b string
+		want float64
+	}{
+		"identical": {"acgtacgt", "acgtacgt", 0},
+		"gapped":    {"acgt--acgt", "acgtacgt", 0},
+		"contained": {"acgtac", "ttacgtacgg", 0},
+		"different": {"aaaaaa", "cccccc", 1},
+		"half":      {"aaaacccc", "aaaagggg", 0.75},
+		"missing":   {"??????", "acgtac", 1},
+	}
+
+	for name, test := range tests {
+		a := dna.KmerProfile(test.a, 3)
+		b := dna.KmerProfile(test.b, 3)
+		if got := a.Distance(b); got != test.want {
+			t.Errorf("%s: got %.4f, want %.4f", name, got, test.want)
+		}
+	}
+}
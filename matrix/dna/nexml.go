@@ -0,0 +1,182 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/js-arias/phydata/names"
+)
+
+// ReadNeXML reads the DNA sequences
+// of all the DNA characters blocks
+// of a NeXML file
+// (see <http://nexml.org>).
+// It require an ID for a bibliographic reference,
+// that will be used as the prefix
+// for the specimen identifiers.
+//
+// The label of each block is used as the gene name.
+// If a block has no label,
+// gene will be used as the gene name.
+// Blocks with other kind of data are ignored.
+//
+// Sequences are assumed to be aligned,
+// and sequences without data
+// (only gaps or missing states)
+// are ignored.
+func (c *Collection) ReadNeXML(r io.Reader, ref, gene string) error {
+	var doc nexmlDoc
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return fmt.Errorf("while reading NeXML: %v", err)
+	}
+
+	otus := make(map[string]string)
+	for _, ls := range doc.OTUs {
+		for _, o := range ls.OTU {
+			l := o.Label
+			if l == "" {
+				l = o.ID
+			}
+			otus[o.ID] = l
+		}
+	}
+
+	for _, b := range doc.Characters {
+		dt := strings.ToLower(b.Type)
+		if i := strings.Index(dt, ":"); i >= 0 {
+			dt = dt[i+1:]
+		}
+		if !strings.HasPrefix(dt, "dna") && !strings.HasPrefix(dt, "rna") {
+			continue
+		}
+
+		name := strings.Join(strings.Fields(strings.ReplaceAll(b.Label, "_", " ")), " ")
+		if name == "" {
+			name = gene
+		}
+		if name == "" {
+			return fmt.Errorf("DNA block %q without a gene name", b.ID)
+		}
+
+		symbols := make(map[string]string)
+		for _, s := range b.Format.States {
+			for _, ls := range [][]nexmlState{s.State, s.Polymorph, s.Uncertain} {
+				for _, st := range ls {
+					symbols[st.ID] = st.Symbol
+				}
+			}
+		}
+		chars := make(map[string]int, len(b.Format.Chars))
+		for i, ch := range b.Format.Chars {
+			chars[ch.ID] = i
+		}
+
+		for _, row := range b.Matrix.Rows {
+			tax, ok := otus[row.OTU]
+			if !ok {
+				return fmt.Errorf("while reading NeXML: row %q: undefined OTU %q", row.ID, row.OTU)
+			}
+			tax = names.Taxon(strings.ReplaceAll(tax, "_", " "))
+			if tax == "" {
+				continue
+			}
+
+			seq := row.Seq
+			if len(row.Cells) > 0 {
+				cells := make([]string, len(b.Format.Chars))
+				for i := range cells {
+					cells[i] = "?"
+				}
+				for _, cell := range row.Cells {
+					i, ok := chars[cell.Char]
+					if !ok {
+						return fmt.Errorf("while reading NeXML: taxon %q: undefined character %q", tax, cell.Char)
+					}
+					if s, ok := symbols[cell.State]; ok {
+						cells[i] = s
+					}
+				}
+				seq = strings.Join(cells, "")
+			}
+			seq = formatSequence(seq)
+			if !hasData(seq) {
+				continue
+			}
+
+			spec := names.Specimen(ref + ":" + tax)
+			if err := c.Add(tax, spec, name, "", seq); err != nil {
+				return fmt.Errorf("block %q: taxon %q: %v", name, tax, err)
+			}
+			for _, acc := range c.GeneAccession(spec, name) {
+				c.Set(spec, name, acc, "true", Aligned)
+				c.Set(spec, name, acc, ref, Reference)
+			}
+		}
+	}
+	return nil
+}
+
+type nexmlDoc struct {
+	OTUs       []nexmlOTUs  `xml:"otus"`
+	Characters []nexmlChars `xml:"characters"`
+}
+
+type nexmlOTUs struct {
+	OTU []nexmlOTU `xml:"otu"`
+}
+
+type nexmlOTU struct {
+	ID    string `xml:"id,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type nexmlChars struct {
+	ID     string      `xml:"id,attr"`
+	Label  string      `xml:"label,attr"`
+	Type   string      `xml:"type,attr"`
+	Format nexmlFormat `xml:"format"`
+	Matrix nexmlMatrix `xml:"matrix"`
+}
+
+type nexmlFormat struct {
+	States []nexmlStates `xml:"states"`
+	Chars  []nexmlChar   `xml:"char"`
+}
+
+type nexmlStates struct {
+	ID        string       `xml:"id,attr"`
+	State     []nexmlState `xml:"state"`
+	Polymorph []nexmlState `xml:"polymorphic_state_set"`
+	Uncertain []nexmlState `xml:"uncertain_state_set"`
+}
+
+type nexmlState struct {
+	ID     string `xml:"id,attr"`
+	Symbol string `xml:"symbol,attr"`
+}
+
+type nexmlChar struct {
+	ID string `xml:"id,attr"`
+}
+
+type nexmlMatrix struct {
+	Rows []nexmlRow `xml:"row"`
+}
+
+type nexmlRow struct {
+	ID    string      `xml:"id,attr"`
+	OTU   string      `xml:"otu,attr"`
+	Cells []nexmlCell `xml:"cell"`
+	Seq   string      `xml:"seq"`
+}
+
+type nexmlCell struct {
+	Char  string `xml:"char,attr"`
+	State string `xml:"state,attr"`
+}
@@ -0,0 +1,472 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/js-arias/phydata/names"
+)
+
+// ReadNexus reads the DNA sequences
+// of all the DNA character (or data) blocks
+// of a NEXUS file.
+// It require an ID for a bibliographic reference,
+// that will be used as the prefix
+// for the specimen identifiers.
+//
+// The title of each block is used as the gene name.
+// If a block has no title,
+// gene will be used as the gene name.
+// Blocks with other kind of data are ignored.
+//
+// Sequences are assumed to be aligned,
+// and sequences without data
+// (only gaps or missing states)
+// are ignored.
+func (c *Collection) ReadNexus(r io.Reader, ref, gene string) error {
+	nxf := bufio.NewReader(r)
+	token := &strings.Builder{}
+
+	// header
+	if _, err := readToken(nxf, token); err != nil {
+		return fmt.Errorf("expecting '#nexus' header: %v", err)
+	}
+	if t := strings.ToLower(token.String()); t != "#nexus" {
+		return fmt.Errorf("got %q, expecting '#nexus' header", t)
+	}
+
+	for {
+		if _, err := readToken(nxf, token); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("expecting 'begin' token: %v", err)
+		}
+		if t := strings.ToLower(token.String()); t != "begin" {
+			return fmt.Errorf("got %q, expecting 'begin' block", t)
+		}
+
+		if _, err := readToken(nxf, token); err != nil {
+			return fmt.Errorf("expecting block name: %v", err)
+		}
+		block := strings.ToLower(token.String())
+		if block == "characters" || block == "data" {
+			if err := c.readNexusChars(nxf, token, ref, gene); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := skipBlock(nxf, token); err != nil {
+			return fmt.Errorf("incomplete block %q: %v", block, err)
+		}
+	}
+}
+
+func (c *Collection) readNexusChars(r *bufio.Reader, token *strings.Builder, ref, gene string) error {
+	var title string
+	var format map[string]string
+	var seqs *nexusSeqs
+	for {
+		if _, err := readToken(r, token); err != nil {
+			return fmt.Errorf("incomplete block 'characters': %v", err)
+		}
+		t := strings.ToLower(token.String())
+		if t == "end" || t == "endblock" {
+			break
+		}
+		if t == "title" {
+			delim, err := readToken(r, token)
+			if err != nil {
+				return fmt.Errorf("incomplete block 'characters', token %q: %v", t, err)
+			}
+			title = token.String()
+			if delim == ';' {
+				continue
+			}
+			if err := skipDefinition(r, token); err != nil {
+				return fmt.Errorf("incomplete block 'characters', token %q: %v", t, err)
+			}
+			continue
+		}
+		if t == "format" {
+			var err error
+			format, err = readNexusFormat(r, token)
+			if err != nil {
+				return err
+			}
+			switch format["datatype"] {
+			case "dna", "rna", "nucleotide":
+				continue
+			}
+			if err := skipBlock(r, token); err != nil {
+				return fmt.Errorf("incomplete block 'characters': %v", err)
+			}
+			return nil
+		}
+		if t == "matrix" {
+			var err error
+			seqs, err = readNexusMatrix(r, token)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err := skipDefinition(r, token); err != nil {
+			return fmt.Errorf("incomplete block 'characters', token %q: %v", t, err)
+		}
+	}
+	if seqs == nil || format == nil {
+		return nil
+	}
+
+	title = strings.Join(strings.Fields(strings.ReplaceAll(title, "_", " ")), " ")
+	if title == "" {
+		title = gene
+	}
+	if title == "" {
+		return fmt.Errorf("DNA block without a gene name")
+	}
+
+	if mc := format["matchchar"]; mc != "" && len(seqs.taxa) > 0 {
+		seqs.match(rune(mc[0]))
+	}
+
+	for _, tax := range seqs.taxa {
+		seq := seqs.seqs[tax].String()
+		if !hasData(seq) {
+			continue
+		}
+		tx := names.Taxon(strings.ReplaceAll(tax, "_", " "))
+		spec := names.Specimen(ref + ":" + tx)
+		if err := c.Add(tx, spec, title, "", seq); err != nil {
+			return fmt.Errorf("block %q: taxon %q: %v", title, tax, err)
+		}
+		gb := c.GeneAccession(spec, title)
+		for _, acc := range gb {
+			c.Set(spec, title, acc, "true", Aligned)
+			c.Set(spec, title, acc, ref, Reference)
+		}
+	}
+	return nil
+}
+
+// NexusSeqs store the sequences
+// of a NEXUS matrix,
+// in the order of the matrix.
+type nexusSeqs struct {
+	taxa []string
+	seqs map[string]*strings.Builder
+}
+
+// Match replaces the match character
+// with the state of the first sequence.
+func (ns *nexusSeqs) match(mc rune) {
+	first := []rune(ns.seqs[ns.taxa[0]].String())
+	for _, tax := range ns.taxa[1:] {
+		seq := []rune(ns.seqs[tax].String())
+		for i, r := range seq {
+			if unicode.ToLower(r) != unicode.ToLower(mc) || i >= len(first) {
+				continue
+			}
+			seq[i] = first[i]
+		}
+		b := &strings.Builder{}
+		b.WriteString(string(seq))
+		ns.seqs[tax] = b
+	}
+}
+
+func readNexusMatrix(r *bufio.Reader, token *strings.Builder) (*nexusSeqs, error) {
+	ns := &nexusSeqs{
+		seqs: make(map[string]*strings.Builder),
+	}
+	last := ""
+	for {
+		if err := skipSpaces(r); err != nil {
+			return nil, fmt.Errorf("while reading matrix: %v, last taxon read %q", err, last)
+		}
+		r1, _, err := r.ReadRune()
+		if err != nil {
+			return nil, fmt.Errorf("while reading matrix: %v, last taxon read %q", err, last)
+		}
+		if r1 == ';' {
+			break
+		}
+		r.UnreadRune()
+
+		// read taxon name
+		if _, err := readToken(r, token); err != nil {
+			return nil, fmt.Errorf("while reading matrix: %v, last taxon read %q", err, last)
+		}
+		tax := strings.Join(strings.Fields(token.String()), " ")
+		seq, ok := ns.seqs[tax]
+		if !ok {
+			seq = &strings.Builder{}
+			ns.seqs[tax] = seq
+			ns.taxa = append(ns.taxa, tax)
+		}
+
+		// read sequence
+		for {
+			r1, _, err := r.ReadRune()
+			if err != nil {
+				return nil, fmt.Errorf("while reading matrix: taxon %q: %v", tax, err)
+			}
+			if r1 == '\n' || r1 == '\r' {
+				break
+			}
+			if r1 == ';' {
+				r.UnreadRune()
+				break
+			}
+			if unicode.IsSpace(r1) {
+				continue
+			}
+			if r1 == '[' {
+				if err := skipComment(r); err != nil {
+					return nil, fmt.Errorf("while reading matrix: taxon %q: %v", tax, err)
+				}
+				continue
+			}
+			if r1 == '(' || r1 == '{' {
+				var set []rune
+				for {
+					r1, _, err := r.ReadRune()
+					if err != nil {
+						return nil, fmt.Errorf("while reading matrix: taxon %q: %v", tax, err)
+					}
+					if r1 == ')' || r1 == '}' {
+						break
+					}
+					if unicode.IsSpace(r1) || r1 == ',' {
+						continue
+					}
+					set = append(set, unicode.ToLower(r1))
+				}
+				seq.WriteRune(ambiguity(set))
+				continue
+			}
+			seq.WriteRune(unicode.ToLower(r1))
+		}
+		last = tax
+	}
+	return ns, nil
+}
+
+// Ambiguity returns the IUPAC ambiguity code
+// of a set of nucleotides.
+func ambiguity(set []rune) rune {
+	for i, r := range set {
+		if r == 'u' {
+			set[i] = 't'
+		}
+	}
+	slices.Sort(set)
+	set = slices.Compact(set)
+	switch string(set) {
+	case "a", "c", "g", "t":
+		return set[0]
+	case "ag":
+		return 'r'
+	case "ct":
+		return 'y'
+	case "cg":
+		return 's'
+	case "at":
+		return 'w'
+	case "gt":
+		return 'k'
+	case "ac":
+		return 'm'
+	case "cgt":
+		return 'b'
+	case "agt":
+		return 'd'
+	case "act":
+		return 'h'
+	case "acg":
+		return 'v'
+	}
+	return 'n'
+}
+
+// HasData returns true if a sequence
+// has at least a defined nucleotide.
+func hasData(seq string) bool {
+	for _, r := range seq {
+		switch r {
+		case '-', '?', 'n':
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// ReadNexusFormat reads the format definition
+// of a character block,
+// and returns the values of each format option.
+func readNexusFormat(r *bufio.Reader, token *strings.Builder) (map[string]string, error) {
+	format := make(map[string]string)
+	for {
+		delim, err := readToken(r, token)
+		if err != nil {
+			return nil, fmt.Errorf("while reading format: %v", err)
+		}
+		key := strings.ToLower(token.String())
+		if delim == '=' {
+			delim, err = readToken(r, token)
+			if err != nil {
+				return nil, fmt.Errorf("while reading format: %v", err)
+			}
+			format[key] = strings.ToLower(token.String())
+		} else if key != "" {
+			format[key] = ""
+		}
+		if delim == ';' {
+			return format, nil
+		}
+	}
+}
+
+func skipBlock(r *bufio.Reader, token *strings.Builder) error {
+	for {
+		_, err := readToken(r, token)
+		t := strings.ToLower(token.String())
+		if t == "end" || t == "endblock" {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func skipDefinition(r *bufio.Reader, token *strings.Builder) error {
+	for {
+		delim, err := readToken(r, token)
+		if delim == ';' {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func readToken(r *bufio.Reader, token *strings.Builder) (delim rune, err error) {
+	token.Reset()
+
+	if err := skipSpaces(r); err != nil {
+		return 0, err
+	}
+
+	r1, _, err := r.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if r1 == '\'' || r1 == '"' {
+		// quoted block
+		stop := r1
+		for {
+			r1, _, err := r.ReadRune()
+			if err != nil {
+				return 0, err
+			}
+			if r1 == stop {
+				nx, _, err := r.ReadRune()
+				if err != nil {
+					return 0, err
+				}
+				if nx != stop {
+					r.UnreadRune()
+					delim = ' '
+					break
+				}
+				if stop == '\'' {
+					continue
+				}
+			}
+			token.WriteRune(r1)
+		}
+	} else {
+		r.UnreadRune()
+		for {
+			r1, _, err := r.ReadRune()
+			if err != nil {
+				return 0, err
+			}
+			if unicode.IsSpace(r1) {
+				delim = ' '
+				break
+			}
+			if r1 == ';' || r1 == ',' || r1 == '/' || r1 == '=' {
+				delim = r1
+				break
+			}
+			token.WriteRune(r1)
+		}
+	}
+
+	if unicode.IsSpace(delim) {
+		if err := skipSpaces(r); err != nil {
+			return 0, err
+		}
+		r1, _, err := r.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if r1 == ';' || r1 == ',' || r1 == '/' || r1 == '=' {
+			delim = r1
+		} else {
+			r.UnreadRune()
+		}
+	}
+	return delim, nil
+}
+
+func skipSpaces(r *bufio.Reader) error {
+	for {
+		r1, _, err := r.ReadRune()
+		if err != nil {
+			return err
+		}
+
+		// a comment
+		if r1 == '[' {
+			if err := skipComment(r); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !unicode.IsSpace(r1) {
+			r.UnreadRune()
+			return nil
+		}
+	}
+}
+
+func skipComment(r *bufio.Reader) error {
+	for {
+		r1, _, err := r.ReadRune()
+		if err != nil {
+			return err
+		}
+
+		// a comment
+		if r1 == ']' {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+var nexusDNA = `#NEXUS
+
+BEGIN TAXA;
+	TITLE Taxa;
+	DIMENSIONS NTAX=3;
+	TAXLABELS 'Ascaphus truei' Bufo_bufo Pipa_pipa;
+END;
+
+BEGIN CHARACTERS;
+	TITLE 'Morphology';
+	DIMENSIONS NCHAR=2;
+	FORMAT DATATYPE = STANDARD GAP = - MISSING = ? SYMBOLS = "0 1";
+	MATRIX
+	'Ascaphus truei'	01
+	Bufo_bufo	10
+	Pipa_pipa	11
+	;
+END;
+
+BEGIN CHARACTERS;
+	TITLE rbcL;
+	DIMENSIONS NCHAR=12;
+	FORMAT DATATYPE=DNA MISSING=? GAP=- MATCHCHAR=. INTERLEAVE;
+	MATRIX
+	[first part]
+	'Ascaphus truei'	ACGT AC
+	Bufo_bufo	..{AG}. -C
+	Pipa_pipa	??????
+
+	'Ascaphus truei'	GTACGT
+	Bufo_bufo	G.....
+	Pipa_pipa	------
+	;
+END;
+
+BEGIN DATA;
+	DIMENSIONS NTAX=1 NCHAR=4;
+	FORMAT DATATYPE=DNA MISSING=? GAP=-;
+	MATRIX
+	Bufo_bufo	aatt
+	;
+END;
+
+BEGIN TREES;
+	TREE t1 = ('Ascaphus truei',(Bufo_bufo,Pipa_pipa));
+END;
+`
+
+func TestReadNexus(t *testing.T) {
+	c := dna.New()
+	if err := c.ReadNexus(strings.NewReader(nexusDNA), "TB2:S1", "coi"); err != nil {
+		t.Fatalf("unable to read NEXUS data: %v", err)
+	}
+
+	want := dna.New()
+	want.Add("Ascaphus truei", "TB2:S1:Ascaphus truei", "rbcL", "", "acgtacgtacgt")
+	want.Add("Bufo bufo", "TB2:S1:Bufo bufo", "rbcL", "", "acrt-cgtacgt")
+	want.Add("Bufo bufo", "TB2:S1:Bufo bufo", "coi", "", "aatt")
+	for _, spec := range want.Specimens() {
+		for _, gene := range want.SpecGene(spec) {
+			for _, acc := range want.GeneAccession(spec, gene) {
+				want.Set(spec, gene, acc, "true", dna.Aligned)
+				want.Set(spec, gene, acc, "TB2:S1", dna.Reference)
+			}
+		}
+	}
+	cmpCollection(t, c, want)
+
+	for _, spec := range c.Specimens() {
+		for _, gene := range c.SpecGene(spec) {
+			for _, acc := range c.GeneAccession(spec, gene) {
+				if ref := c.Val(spec, gene, acc, dna.Reference); ref != "TB2:S1" {
+					t.Errorf("specimen %q, gene %q: reference: got %q, want %q", spec, gene, ref, "TB2:S1")
+				}
+			}
+		}
+	}
+}
+
+var nexmlDNA = `<?xml version="1.0" encoding="UTF-8"?>
+<nex:nexml xmlns:nex="http://www.nexml.org/2009" xmlns="http://www.nexml.org/2009" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" version="0.9">
+	<otus id="Tls1">
+		<otu id="t1" label="Ascaphus truei"/>
+		<otu id="t2" label="Bufo_bufo"/>
+		<otu id="t3" label="Pipa pipa"/>
+	</otus>
+	<characters id="m1" otus="Tls1" xsi:type="nex:StandardCells" label="Morphology">
+		<format/>
+		<matrix/>
+	</characters>
+	<characters id="m2" otus="Tls1" xsi:type="nex:DnaSeqs" label="rbcL">
+		<format/>
+		<matrix>
+			<row id="r1" otu="t1"><seq>ACGTAC GTACGT</seq></row>
+			<row id="r2" otu="t2"><seq>ACRT-CGTACGT</seq></row>
+			<row id="r3" otu="t3"><seq>????????????</seq></row>
+		</matrix>
+	</characters>
+	<characters id="m3" otus="Tls1" xsi:type="nex:DnaCells">
+		<format>
+			<states id="s1">
+				<state id="a" symbol="A"/>
+				<state id="t" symbol="T"/>
+			</states>
+			<char id="c1" states="s1"/>
+			<char id="c2" states="s1"/>
+			<char id="c3" states="s1"/>
+			<char id="c4" states="s1"/>
+		</format>
+		<matrix>
+			<row id="r4" otu="t2">
+				<cell char="c1" state="a"/>
+				<cell char="c2" state="a"/>
+				<cell char="c4" state="t"/>
+			</row>
+		</matrix>
+	</characters>
+</nex:nexml>
+`
+
+func TestReadNeXML(t *testing.T) {
+	c := dna.New()
+	if err := c.ReadNeXML(strings.NewReader(nexmlDNA), "TB2:S1", "coi"); err != nil {
+		t.Fatalf("unable to read NeXML data: %v", err)
+	}
+
+	want := dna.New()
+	want.Add("Ascaphus truei", "TB2:S1:Ascaphus truei", "rbcL", "", "acgtacgtacgt")
+	want.Add("Bufo bufo", "TB2:S1:Bufo bufo", "rbcL", "", "acrt-cgtacgt")
+	want.Add("Bufo bufo", "TB2:S1:Bufo bufo", "coi", "", "aa?t")
+	for _, spec := range want.Specimens() {
+		for _, gene := range want.SpecGene(spec) {
+			for _, acc := range want.GeneAccession(spec, gene) {
+				want.Set(spec, gene, acc, "true", dna.Aligned)
+			}
+		}
+	}
+	cmpCollection(t, c, want)
+}
@@ -0,0 +1,240 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// A Primer is an oligonucleotide
+// used to amplify or sequence a gene.
+type Primer struct {
+	Name      string
+	Gene      string
+	Sequence  string
+	Direction string
+	Reference string
+}
+
+// Primer directions.
+const (
+	Forward = "forward"
+	Reverse = "reverse"
+)
+
+// A PrimerSet is a registry of primers
+// used to sequence the genes of a collection.
+type PrimerSet struct {
+	primers map[string]Primer
+}
+
+// NewPrimerSet creates a new empty registry of primers.
+func NewPrimerSet() *PrimerSet {
+	return &PrimerSet{
+		primers: make(map[string]Primer),
+	}
+}
+
+// Add adds a primer to the registry.
+// If a primer with the same name
+// (ignoring case)
+// is already defined,
+// it will be replaced.
+func (ps *PrimerSet) Add(p Primer) error {
+	p.Name = strings.Join(strings.Fields(p.Name), " ")
+	if p.Name == "" {
+		return fmt.Errorf("primer without a name")
+	}
+	p.Gene = strings.ToLower(strings.TrimSpace(p.Gene))
+	if p.Gene == "" {
+		return fmt.Errorf("primer %q: without a gene", p.Name)
+	}
+	p.Sequence = formatSequence(p.Sequence)
+	dir, err := parseDirection(p.Direction)
+	if err != nil {
+		return fmt.Errorf("primer %q: %v", p.Name, err)
+	}
+	p.Direction = dir
+	p.Reference = strings.Join(strings.Fields(p.Reference), " ")
+
+	ps.primers[strings.ToLower(p.Name)] = p
+	return nil
+}
+
+// Primer returns a primer by its name.
+func (ps *PrimerSet) Primer(name string) (Primer, bool) {
+	p, ok := ps.primers[strings.ToLower(strings.Join(strings.Fields(name), " "))]
+	return p, ok
+}
+
+// Names returns the names of the primers
+// in the registry.
+func (ps *PrimerSet) Names() []string {
+	names := make([]string, 0, len(ps.primers))
+	for _, p := range ps.primers {
+		names = append(names, p.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Gene returns the primers of a gene,
+// sorted by name.
+func (ps *PrimerSet) Gene(gene string) []Primer {
+	gene = strings.ToLower(strings.TrimSpace(gene))
+	var ls []Primer
+	for _, p := range ps.primers {
+		if p.Gene == gene {
+			ls = append(ls, p)
+		}
+	}
+	slices.SortFunc(ls, func(a, b Primer) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return ls
+}
+
+// SeqPrimers returns the names of the primers
+// used for a sequence
+// (as defined in the Primers field).
+func (c *Collection) SeqPrimers(specimen, gene, genBank string) []string {
+	var ls []string
+	for _, n := range strings.Split(c.Val(specimen, gene, genBank, Primers), ",") {
+		n = strings.Join(strings.Fields(n), " ")
+		if n == "" {
+			continue
+		}
+		ls = append(ls, n)
+	}
+	return ls
+}
+
+func parseDirection(dir string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(dir)) {
+	case "":
+		return "", nil
+	case "f", "fwd", "forward", "+":
+		return Forward, nil
+	case "r", "rev", "reverse", "-":
+		return Reverse, nil
+	}
+	return "", fmt.Errorf("invalid direction %q", dir)
+}
+
+var primerFields = []string{
+	"name",
+	"gene",
+}
+
+// ReadTSV reads a registry of primers
+// from a TSV file.
+//
+// The TSV file must contains the following fields:
+//
+//   - name, the name of the primer
+//   - gene, the gene amplified by the primer
+//
+// Additional fields are:
+//
+//   - sequence, the sequence of the primer
+//   - direction, either "forward" or "reverse"
+//   - reference, an ID of a bibliographic reference
+//
+// Here is an example file:
+//
+//	# primers
+//	name	gene	sequence	direction	reference
+//	L14724	cytb	cgaagcttgatatgaaaaaccatcgttg	forward	irwin1991
+//	H15915	cytb	aactgcagtcatctccggtttacaagac	reverse	irwin1991
+func (ps *PrimerSet) ReadTSV(r io.Reader) error {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return fmt.Errorf("while reading header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(strings.TrimSpace(h))
+		fields[h] = i
+	}
+	for _, h := range primerFields {
+		if _, ok := fields[h]; !ok {
+			return fmt.Errorf("expecting field %q", h)
+		}
+	}
+
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		p := Primer{
+			Name: row[fields["name"]],
+			Gene: row[fields["gene"]],
+		}
+		if strings.TrimSpace(p.Name) == "" {
+			continue
+		}
+		if i, ok := fields["sequence"]; ok {
+			p.Sequence = row[i]
+		}
+		if i, ok := fields["direction"]; ok {
+			p.Direction = row[i]
+		}
+		if i, ok := fields["reference"]; ok {
+			p.Reference = row[i]
+		}
+		if err := ps.Add(p); err != nil {
+			return fmt.Errorf("on row %d: %v", ln, err)
+		}
+	}
+	return nil
+}
+
+// TSV writes a registry of primers
+// as a TSV file.
+func (ps *PrimerSet) TSV(w io.Writer) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	header := append(slices.Clone(primerFields), "sequence", "direction", "reference")
+	if err := tab.Write(header); err != nil {
+		return fmt.Errorf("unable to write header: %v", err)
+	}
+
+	for _, n := range ps.Names() {
+		p, _ := ps.Primer(n)
+		row := []string{
+			p.Name,
+			p.Gene,
+			p.Sequence,
+			p.Direction,
+			p.Reference,
+		}
+		if err := tab.Write(row); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+func TestPrimerSet(t *testing.T) {
+	ps := dna.NewPrimerSet()
+	primers := []dna.Primer{
+		{Name: "L14724", Gene: "CytB", Sequence: "CGAAGCTTGATATGAAAAACCATCGTTG", Direction: "F", Reference: "irwin1991"},
+		{Name: "H15915", Gene: "cytb", Sequence: "AACTGCAGTCATCTCCGGTTTACAAGAC", Direction: "-", Reference: "irwin1991"},
+		{Name: "LCO1490", Gene: "coi", Sequence: "GGTCAACAAATCATAAAGATATTGG", Direction: "forward", Reference: "folmer1994"},
+	}
+	for _, p := range primers {
+		if err := ps.Add(p); err != nil {
+			t.Fatalf("add %q: unexpected error: %v", p.Name, err)
+		}
+	}
+
+	want := dna.Primer{
+		Name:      "L14724",
+		Gene:      "cytb",
+		Sequence:  "cgaagcttgatatgaaaaaccatcgttg",
+		Direction: dna.Forward,
+		Reference: "irwin1991",
+	}
+	got, ok := ps.Primer("l14724")
+	if !ok {
+		t.Fatalf("primer %q not found", "L14724")
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("primer: got %v, want %v", got, want)
+	}
+
+	var gp []string
+	for _, p := range ps.Gene("cytb") {
+		gp = append(gp, p.Name)
+	}
+	if w := []string{"H15915", "L14724"}; !reflect.DeepEqual(gp, w) {
+		t.Errorf("gene primers: got %v, want %v", gp, w)
+	}
+
+	var w bytes.Buffer
+	if err := ps.TSV(&w); err != nil {
+		t.Fatalf("unable to write TSV: %v", err)
+	}
+	np := dna.NewPrimerSet()
+	if err := np.ReadTSV(&w); err != nil {
+		t.Fatalf("unable to read TSV: %v", err)
+	}
+	for _, n := range ps.Names() {
+		p, _ := ps.Primer(n)
+		q, ok := np.Primer(n)
+		if !ok {
+			t.Errorf("TSV: primer %q not found", n)
+			continue
+		}
+		if !reflect.DeepEqual(p, q) {
+			t.Errorf("TSV: primer %q: got %v, want %v", n, q, p)
+		}
+	}
+}
+
+func TestPrimerSetErrors(t *testing.T) {
+	tests := map[string]dna.Primer{
+		"no name":   {Gene: "cytb"},
+		"no gene":   {Name: "L14724"},
+		"direction": {Name: "L14724", Gene: "cytb", Direction: "up"},
+	}
+
+	for name, p := range tests {
+		ps := dna.NewPrimerSet()
+		if err := ps.Add(p); err == nil {
+			t.Errorf("%s: expecting error", name)
+		}
+	}
+
+	ps := dna.NewPrimerSet()
+	if err := ps.ReadTSV(strings.NewReader("name\tsequence\nL14724\tacgt\n")); err == nil {
+		t.Errorf("read: expecting error on missing field")
+	}
+}
+
+func TestSeqPrimers(t *testing.T) {
+	c := dna.New()
+	c.Add("Loxodonta africana", "sp-01", "cytb", "X01", "acgt")
+	c.Set("sp-01", "cytb", "X01", "L14724, H15915,", dna.Primers)
+
+	got := c.SeqPrimers("sp-01", "cytb", "X01")
+	if w := []string{"L14724", "H15915"}; !reflect.DeepEqual(got, w) {
+		t.Errorf("primers: got %v, want %v", got, w)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetNormalizeU sets whether uracil ('u')
+// is replaced by thymine ('t')
+// when a sequence is added to the collection.
+// By default,
+// sequences are stored as given.
+func (c *Collection) SetNormalizeU(normalize bool) {
+	c.normU = normalize
+}
+
+// Molecule returns the kind of molecule
+// declared for the sequences of a gene:
+// "dna", "rna", or "rrna".
+// If some sequences are declared as RNA,
+// the gene is taken as RNA
+// (if there are different kinds of RNA,
+// the first in alphabetical order is returned).
+func (c *Collection) Molecule(gene string) string {
+	gene = strings.ToLower(strings.TrimSpace(gene))
+	mol := ""
+	for _, sp := range c.specs {
+		for _, seq := range sp.genes[gene] {
+			if seq.molecule == "" {
+				continue
+			}
+			if mol == "" || seq.molecule < mol {
+				mol = seq.molecule
+			}
+		}
+	}
+	if mol == "" {
+		return "dna"
+	}
+	return mol
+}
+
+// Pairs returns the paired positions
+// of a secondary structure
+// in dot-bracket notation.
+// For each position of the structure,
+// it returns the index of the paired position,
+// or -1 if the position is unpaired.
+// Besides parenthesis,
+// square brackets, curly brackets,
+// and angle brackets
+// can be used for pairs
+// (e.g., for pseudoknots).
+// Any other symbol
+// (e.g., '.', or '-')
+// is an unpaired position.
+func Pairs(structure string) ([]int, error) {
+	open := map[rune]rune{')': '(', ']': '[', '}': '{', '>': '<'}
+	stacks := make(map[rune][]int)
+	st := []rune(structure)
+	pairs := make([]int, len(st))
+	for i, r := range st {
+		pairs[i] = -1
+		switch r {
+		case '(', '[', '{', '<':
+			stacks[r] = append(stacks[r], i)
+		case ')', ']', '}', '>':
+			o := open[r]
+			s := stacks[o]
+			if len(s) == 0 {
+				return nil, fmt.Errorf("position %d: unmatched %q", i+1, r)
+			}
+			j := s[len(s)-1]
+			stacks[o] = s[:len(s)-1]
+			pairs[i] = j
+			pairs[j] = i
+		}
+	}
+	for _, r := range "([{<" {
+		if s := stacks[r]; len(s) > 0 {
+			return nil, fmt.Errorf("position %d: unmatched %q", s[len(s)-1]+1, r)
+		}
+	}
+	return pairs, nil
+}
+
+// Structure returns the secondary structure
+// of an aligned gene,
+// i.e.,
+// the structure defined for the aligned sequences of the gene,
+// in the coordinates of the alignment.
+// All the aligned sequences with a defined structure
+// must have the same structure,
+// with the length of the alignment.
+// If no aligned sequence has a structure,
+// it returns an empty string.
+func (c *Collection) Structure(gene string) (string, error) {
+	gene = strings.ToLower(strings.TrimSpace(gene))
+	ln := c.MaxLen(gene)
+
+	var st, first string
+	for _, spec := range c.Specimens() {
+		for _, acc := range c.GeneAccession(spec, gene) {
+			seq := c.sequence(spec, gene, acc)
+			if seq.structure == "" || !seq.aligned {
+				continue
+			}
+			if len([]rune(seq.structure)) != ln {
+				return "", fmt.Errorf("gene %q: sequence %q: structure length %d, alignment length %d", gene, acc, len([]rune(seq.structure)), ln)
+			}
+			if st == "" {
+				st = seq.structure
+				first = acc
+				continue
+			}
+			if seq.structure != st {
+				return "", fmt.Errorf("gene %q: sequence %q: structure different from sequence %q", gene, acc, first)
+			}
+		}
+	}
+	if st == "" {
+		return "", nil
+	}
+	if _, err := Pairs(st); err != nil {
+		return "", fmt.Errorf("gene %q: sequence %q: structure: %v", gene, first, err)
+	}
+	return st, nil
+}
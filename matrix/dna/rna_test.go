@@ -0,0 +1,135 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+func TestNormalizeU(t *testing.T) {
+	c := dna.New()
+	if err := c.Add("Loxodonta africana", "sp-01", "18s", "X01", "acgu-acgu"); err != nil {
+		t.Fatalf("add: unexpected error: %v", err)
+	}
+	if got := c.Sequence("sp-01", "18s", "X01"); got != "acgu-acgu" {
+		t.Errorf("default: got %q, want %q", got, "acgu-acgu")
+	}
+
+	c = dna.New()
+	c.SetNormalizeU(true)
+	if err := c.Add("Loxodonta africana", "sp-01", "18s", "X01", "ACGU-acgu"); err != nil {
+		t.Fatalf("add: unexpected error: %v", err)
+	}
+	if got := c.Sequence("sp-01", "18s", "X01"); got != "acgt-acgt" {
+		t.Errorf("normalize: got %q, want %q", got, "acgt-acgt")
+	}
+
+	nc := c.Clone()
+	nc.Add("Orycteropus afer", "sp-02", "18s", "X02", "uuuu")
+	if got := nc.Sequence("sp-02", "18s", "X02"); got != "tttt" {
+		t.Errorf("clone: got %q, want %q", got, "tttt")
+	}
+}
+
+func TestMolecule(t *testing.T) {
+	c := dna.New()
+	c.Add("Loxodonta africana", "sp-01", "18s", "X01", "acgu")
+	c.Add("Orycteropus afer", "sp-02", "18s", "X02", "acgu")
+	c.Add("Orycteropus afer", "sp-02", "cytb", "X03", "acgt")
+
+	if got := c.Molecule("18s"); got != "dna" {
+		t.Errorf("undeclared: got %q, want %q", got, "dna")
+	}
+	c.Set("sp-02", "18s", "X02", "rRNA", dna.Molecule)
+	c.Set("sp-02", "18s", "X02", "((..))", dna.Structure)
+	if got := c.Molecule("18s"); got != "rrna" {
+		t.Errorf("declared: got %q, want %q", got, "rrna")
+	}
+	if got := c.Molecule("cytb"); got != "dna" {
+		t.Errorf("DNA gene: got %q, want %q", got, "dna")
+	}
+
+	var w bytes.Buffer
+	if err := c.TSV(&w); err != nil {
+		t.Fatalf("unable to write TSV: %v", err)
+	}
+	nc := dna.New()
+	if err := nc.ReadTSV(&w); err != nil {
+		t.Fatalf("unable to read TSV: %v", err)
+	}
+	if got := nc.Val("sp-02", "18s", "X02", dna.Molecule); got != "rrna" {
+		t.Errorf("TSV: molecule: got %q, want %q", got, "rrna")
+	}
+	if got := nc.Val("sp-02", "18s", "X02", dna.Structure); got != "((..))" {
+		t.Errorf("TSV: structure: got %q, want %q", got, "((..))")
+	}
+	if got := nc.Val("sp-01", "18s", "X01", dna.Molecule); got != "dna" {
+		t.Errorf("TSV: molecule: got %q, want %q", got, "dna")
+	}
+}
+
+func TestPairs(t *testing.T) {
+	tests := map[string]struct {
+		st    string
+		pairs []int
+		err   bool
+	}{
+		"hairpin":     {st: "((..))", pairs: []int{5, 4, -1, -1, 1, 0}},
+		"gaps":        {st: "(-.)", pairs: []int{3, -1, -1, 0}},
+		"pseudoknot":  {st: "([)]", pairs: []int{2, 3, 0, 1}},
+		"unpaired":    {st: "....", pairs: []int{-1, -1, -1, -1}},
+		"open stem":   {st: "((.)", err: true},
+		"closed stem": {st: "(.))", err: true},
+	}
+
+	for name, test := range tests {
+		pairs, err := dna.Pairs(test.st)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expecting error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(pairs, test.pairs) {
+			t.Errorf("%s: got %v, want %v", name, pairs, test.pairs)
+		}
+	}
+}
+
+func TestStructure(t *testing.T) {
+	c := dna.New()
+	c.Add("Loxodonta africana", "sp-01", "16s", "X01", "acg-cgt")
+	c.Set("sp-01", "16s", "X01", "true", dna.Aligned)
+	c.Set("sp-01", "16s", "X01", "((.-.))", dna.Structure)
+	c.Add("Orycteropus afer", "sp-02", "16s", "X02", "acgacgt")
+	c.Set("sp-02", "16s", "X02", "true", dna.Aligned)
+	c.Add("Papio anubis", "sp-03", "16s", "X03", "acgacg")
+	c.Set("sp-03", "16s", "X03", "((..))", dna.Structure)
+
+	st, err := c.Structure("16s")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st != "((.-.))" {
+		t.Errorf("structure: got %q, want %q", st, "((.-.))")
+	}
+
+	c.Set("sp-02", "16s", "X02", "(.....)", dna.Structure)
+	if _, err := c.Structure("16s"); err == nil {
+		t.Errorf("expecting error on different structures")
+	}
+	c.Set("sp-02", "16s", "X02", "((..))", dna.Structure)
+	if _, err := c.Structure("16s"); err == nil {
+		t.Errorf("expecting error on structure length")
+	}
+}
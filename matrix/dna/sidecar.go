@@ -0,0 +1,261 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/js-arias/phydata/names"
+)
+
+// IndexSuffix is the suffix added to the name of a DNA file
+// to name its index sidecar file.
+const IndexSuffix = ".idx"
+
+const sidecarHead = "# phydata: dna index\r\n"
+
+// An index sidecar file
+// stores the index of a plain TSV file
+// (the same index of a store file)
+// in a different file,
+// so a DNA file can be read on demand
+// without changing its format.
+// The index includes the checksum of the TSV file,
+// so an index is discarded
+// if the TSV file was modified.
+// To avoid reading the whole TSV file
+// each time the index is opened,
+// the index also stores the size
+// and the modification time of the TSV file,
+// and the checksum is only checked
+// if any of them is different.
+
+// OpenIndexed opens a DNA file
+// and reads its index.
+// If the file is a store file,
+// it uses the index of the store.
+// Otherwise it uses the index sidecar file
+// (i.e., the file name with the IndexSuffix),
+// if it exists,
+// and the DNA file is the same
+// as the indexed file
+// (i.e., it has the same size and modification time,
+// or the same checksum).
+// If the index does not exist,
+// or it is invalid,
+// the DNA file is indexed,
+// and the index sidecar file is rewritten
+// (errors while writing the sidecar are ignored).
+func OpenIndexed(name string) (*Store, error) {
+	if IsStore(name) {
+		return OpenStore(name)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if s, err := readSidecar(f); err == nil {
+		return s, nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	s, err := buildIndex(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("on file %q: %v", name, err)
+	}
+	s.writeSidecar(name + IndexSuffix)
+	return s, nil
+}
+
+// WriteIndex reads a DNA file in TSV format
+// and writes its index sidecar file.
+func WriteIndex(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s, err := buildIndex(f)
+	if err != nil {
+		return fmt.Errorf("on file %q: %v", name, err)
+	}
+	return s.writeSidecar(name + IndexSuffix)
+}
+
+// ReadSidecar reads the index sidecar file
+// of a DNA file,
+// and returns an error if the index
+// does not match the checksum of the DNA file.
+// The checksum is only calculated
+// if the size or the modification time of the DNA file
+// are different from the ones stored in the index.
+func readSidecar(f *os.File) (*Store, error) {
+	idx, err := os.Open(f.Name() + IndexSuffix)
+	if err != nil {
+		return nil, err
+	}
+	defer idx.Close()
+
+	br := bufio.NewReader(idx)
+	head, err := br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if head != sidecarHead {
+		return nil, errors.New("not an index file")
+	}
+
+	s := &Store{
+		f:     f,
+		taxon: make(map[string]string),
+		accs:  make(map[string]map[string][]string),
+	}
+	if err := s.readIndex(br); err != nil {
+		return nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if s.size == fi.Size() && s.mtime == fi.ModTime().UnixNano() {
+		return s, nil
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != s.sum {
+		return nil, errors.New("invalid checksum")
+	}
+
+	// the file is the same,
+	// so only the size and time are updated
+	s.setStat(fi)
+	s.writeSidecar(f.Name() + IndexSuffix)
+	return s, nil
+}
+
+// SetStat sets the size and the modification time
+// of the indexed file.
+func (s *Store) setStat(fi os.FileInfo) {
+	s.size = fi.Size()
+	s.mtime = fi.ModTime().UnixNano()
+}
+
+// WriteSidecar writes the index of a store
+// into a sidecar file.
+func (s *Store) writeSidecar(name string) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	bw := bufio.NewWriter(f)
+	fmt.Fprintf(bw, "%s%s\t%s\r\n", sidecarHead, idxChecksum, s.sum)
+	fmt.Fprintf(bw, "%s\t%d\t%d\r\n", idxStat, s.size, s.mtime)
+	if err := s.writeIndex(bw); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
+
+// BuildIndex reads a DNA file in TSV format
+// and returns the position of each sequence in the file.
+// The sequences are not validated
+// (they are validated when they are loaded).
+func buildIndex(f *os.File) (*Store, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	br := bufio.NewReaderSize(io.TeeReader(f, h), 1<<16)
+	comma, err := sniffDelimiter(br)
+	if err != nil {
+		return nil, err
+	}
+
+	tab := csv.NewReader(br)
+	tab.Comma = comma
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return nil, fmt.Errorf("while reading header: %v", err)
+	}
+	fields := DefaultHeaders().fields(head)
+	for _, h := range headerFields {
+		if _, ok := fields[h]; !ok {
+			return nil, fmt.Errorf("expecting field %q", h)
+		}
+	}
+
+	s := &Store{
+		f:      f,
+		header: section{off: 0, n: tab.InputOffset()},
+		taxon:  make(map[string]string),
+		accs:   make(map[string]map[string][]string),
+	}
+	last := tab.InputOffset()
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			ln, _ := tab.FieldPos(0)
+			return nil, fmt.Errorf("on row %d: %v", ln, err)
+		}
+		sc := section{off: last, n: tab.InputOffset() - last}
+		last = tab.InputOffset()
+
+		tax := names.Taxon(row[fields["taxon"]])
+		acc := strings.TrimSpace(row[fields["genbank"]])
+		spec := names.Specimen(row[fields["specimen"]])
+		gene := Aliases(nil).canonGene(row[fields["gene"]])
+		if tax == "" || acc == "" || spec == "" || gene == "" || row[fields["bases"]] == "" {
+			continue
+		}
+
+		if _, ok := s.taxon[spec]; !ok {
+			s.taxon[spec] = tax
+		}
+		s.addSeq(storeSeq{spec: spec, gene: gene, acc: acc, section: sc})
+	}
+
+	// read any remaining bytes for the checksum
+	if _, err := io.Copy(io.Discard, br); err != nil {
+		return nil, err
+	}
+	s.sum = hex.EncodeToString(h.Sum(nil))
+	s.setStat(fi)
+	return s, nil
+}
@@ -0,0 +1,230 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+func TestSidecar(t *testing.T) {
+	c := newCollection()
+
+	name := "tmp-dna-sidecar-for-test.tab"
+	defer os.Remove(name)
+	defer os.Remove(name + dna.IndexSuffix)
+	writeTSVFile(t, name, c)
+
+	s, err := dna.OpenIndexed(name)
+	if err != nil {
+		t.Fatalf("unable to open indexed file: %v", err)
+	}
+	if _, err := os.Stat(name + dna.IndexSuffix); err != nil {
+		t.Errorf("index sidecar not written: %v", err)
+	}
+	testIndexed(t, s, c)
+	s.Close()
+
+	// open using the sidecar
+	s, err = dna.OpenIndexed(name)
+	if err != nil {
+		t.Fatalf("unable to open indexed file: %v", err)
+	}
+	testIndexed(t, s, c)
+	s.Close()
+
+	// a modified file invalidates the index
+	cytb := dna.New()
+	for _, tx := range c.Taxa() {
+		for _, sp := range c.TaxSpec(tx) {
+			for _, acc := range c.GeneAccession(sp, "cytb") {
+				cytb.Add(tx, sp, "cytb", acc, c.Sequence(sp, "cytb", acc))
+			}
+		}
+	}
+	writeTSVFile(t, name, cytb)
+	s, err = dna.OpenIndexed(name)
+	if err != nil {
+		t.Fatalf("unable to open indexed file: %v", err)
+	}
+	testIndexed(t, s, cytb)
+	s.Close()
+}
+
+func writeTSVFile(t testing.TB, name string, c *dna.Collection) {
+	t.Helper()
+
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatalf("unable to create file: %v", err)
+	}
+	if _, err := f.WriteString("# phydata: DNA sequences\n"); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+	if err := c.TSV(f); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("unable to close file: %v", err)
+	}
+}
+
+func testIndexed(t testing.TB, s *dna.Store, c *dna.Collection) {
+	t.Helper()
+
+	if got, want := s.Taxa(), c.Taxa(); !reflect.DeepEqual(got, want) {
+		t.Errorf("taxa: got %v, want %v", got, want)
+	}
+	if got, want := s.Specimens(), c.Specimens(); !reflect.DeepEqual(got, want) {
+		t.Errorf("specimens: got %v, want %v", got, want)
+	}
+	if got, want := s.NumSequences(), c.NumSequences(); got != want {
+		t.Errorf("sequences: got %d, want %d", got, want)
+	}
+	for _, sp := range c.Specimens() {
+		if got, want := s.SpecGene(sp), c.SpecGene(sp); !reflect.DeepEqual(got, want) {
+			t.Errorf("specimen %q: genes: got %v, want %v", sp, got, want)
+		}
+	}
+
+	all := dna.New()
+	if err := s.Load(all, nil); err != nil {
+		t.Fatalf("unable to load indexed file: %v", err)
+	}
+	cmpCollection(t, all, c)
+
+	tx := c.Taxa()[0]
+	specs := make(map[string]bool)
+	for _, sp := range c.TaxSpec(tx) {
+		specs[sp] = true
+	}
+	sub := dna.New()
+	keep := func(spec, gene string) bool {
+		return specs[spec]
+	}
+	if err := s.Load(sub, keep); err != nil {
+		t.Fatalf("unable to load indexed file: %v", err)
+	}
+	if got := sub.Taxa(); !reflect.DeepEqual(got, []string{tx}) {
+		t.Errorf("load %q: taxa: got %v, want %v", tx, got, []string{tx})
+	}
+}
+
+func TestSidecarStat(t *testing.T) {
+	c := newCollection()
+
+	name := "tmp-dna-sidecar-stat-for-test.tab"
+	defer os.Remove(name)
+	defer os.Remove(name + dna.IndexSuffix)
+	writeTSVFile(t, name, c)
+
+	s, err := dna.OpenIndexed(name)
+	if err != nil {
+		t.Fatalf("unable to open indexed file: %v", err)
+	}
+	s.Close()
+
+	// a touched file keeps the index
+	mod := time.Now().Add(time.Hour)
+	if err := os.Chtimes(name, mod, mod); err != nil {
+		t.Fatalf("unable to change file time: %v", err)
+	}
+	s, err = dna.OpenIndexed(name)
+	if err != nil {
+		t.Fatalf("unable to open indexed file: %v", err)
+	}
+	testIndexed(t, s, c)
+	s.Close()
+	idx, err := os.ReadFile(name + dna.IndexSuffix)
+	if err != nil {
+		t.Fatalf("unable to read index: %v", err)
+	}
+	if !strings.Contains(string(idx), fmt.Sprintf("\t%d\r\n", mod.UnixNano())) {
+		t.Errorf("index: expecting the new time %d", mod.UnixNano())
+	}
+
+	// with the same size and time,
+	// the checksum is not checked
+	// (so the index is used as is)
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("unable to read file: %v", err)
+	}
+	if err := os.WriteFile(name, []byte(strings.ReplaceAll(string(data), "Papio", "Papiu")), 0o644); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+	if err := os.Chtimes(name, mod, mod); err != nil {
+		t.Fatalf("unable to change file time: %v", err)
+	}
+	s, err = dna.OpenIndexed(name)
+	if err != nil {
+		t.Fatalf("unable to open indexed file: %v", err)
+	}
+	if got, want := s.Taxa(), c.Taxa(); !reflect.DeepEqual(got, want) {
+		t.Errorf("taxa: got %v, want %v", got, want)
+	}
+	s.Close()
+}
+
+func TestSidecarAliases(t *testing.T) {
+	c := dna.New()
+	c.Add("Loxodonta africana", "sp-01", "COI", "MN148748", "ccatccaacatctcagca")
+	c.Add("Orycteropus afer", "sp-02", "cox1", "OR167429", "ccatccaacatctcagca")
+	c.Add("Orycteropus afer", "sp-02", "coi:aligned", "OR167429", "ccatccaacatctcagca")
+
+	name := "tmp-dna-sidecar-aliases-for-test.tab"
+	defer os.Remove(name)
+	defer os.Remove(name + dna.IndexSuffix)
+	writeTSVFile(t, name, c)
+
+	want := dna.New()
+	want.SetAliases(dna.DefaultAliases())
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("unable to open file: %v", err)
+	}
+	err = want.ReadTSV(f)
+	f.Close()
+	if err != nil {
+		t.Fatalf("unable to read file: %v", err)
+	}
+
+	s, err := dna.OpenIndexed(name)
+	if err != nil {
+		t.Fatalf("unable to open indexed file: %v", err)
+	}
+	defer s.Close()
+	s.SetAliases(dna.DefaultAliases())
+
+	if got, w := s.Genes(), want.Genes(); !reflect.DeepEqual(got, w) {
+		t.Errorf("genes: got %v, want %v", got, w)
+	}
+	for _, sp := range want.Specimens() {
+		if got, w := s.SpecGene(sp), want.SpecGene(sp); !reflect.DeepEqual(got, w) {
+			t.Errorf("specimen %q: genes: got %v, want %v", sp, got, w)
+		}
+	}
+	if got, w := s.GeneAccession("sp-01", "COI"), []string{"MN148748"}; !reflect.DeepEqual(got, w) {
+		t.Errorf("accessions: got %v, want %v", got, w)
+	}
+
+	sub := dna.New()
+	sub.SetAliases(dna.DefaultAliases())
+	keep := func(spec, gene string) bool {
+		return gene == "cox1"
+	}
+	if err := s.Load(sub, keep); err != nil {
+		t.Fatalf("unable to load indexed file: %v", err)
+	}
+	if got, w := sub.NumSequences(), 2; got != w {
+		t.Errorf("load %q: got %d sequences, want %d", "cox1", got, w)
+	}
+}
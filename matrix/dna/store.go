@@ -0,0 +1,453 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/phydata/names"
+)
+
+// A store file is a TSV file
+// (as written by TSV)
+// followed by an index of the position
+// of each sequence in the file.
+// The index is written as comment lines,
+// so the store can be read as a regular TSV file.
+const (
+	storeHead    = "# phydata: dna store\r\n"
+	storeTrailer = "# phydata: dna store index "
+	storeOffset  = 20 // digits of the index offset
+)
+
+// trailerLen is the length of the last line of a store file.
+var trailerLen = len(storeTrailer) + storeOffset + 2
+
+// Keywords of the index lines.
+const (
+	idxHeader = "#@header"
+	idxTaxon  = "#@taxon"
+	idxSeq    = "#@seq"
+
+	// checksum,
+	// and size and modification time,
+	// of the indexed file
+	// (only in sidecar files)
+	idxChecksum = "#@checksum"
+	idxStat     = "#@stat"
+)
+
+// A Store is an on-disk collection of DNA sequences,
+// indexed by specimen, gene, and accession,
+// so the sequences can be loaded on demand,
+// without reading the whole file.
+//
+// A store file is also a valid TSV file
+// that can be read with ReadTSV.
+type Store struct {
+	f      *os.File
+	header section
+
+	// checksum of the indexed file
+	sum string
+
+	// size and modification time
+	// (in nanoseconds)
+	// of the indexed file
+	size, mtime int64
+
+	// taxon of each specimen
+	taxon map[string]string
+
+	// sequences in file order
+	seqs []storeSeq
+
+	// accessions of each specimen and gene
+	accs map[string]map[string][]string
+
+	aliases Aliases
+}
+
+// A section is a section of a file.
+type section struct {
+	off, n int64
+}
+
+// A storeSeq is the position of a sequence
+// in a store file.
+type storeSeq struct {
+	spec, gene, acc string
+	section
+}
+
+// WriteStore writes a DNA sequence collection
+// as a store file.
+// Each comment is written as a comment line
+// after the first line of the store.
+func (c *Collection) WriteStore(w io.Writer, comments ...string) error {
+	bw := bufio.NewWriter(w)
+	cw := &countWriter{w: bw}
+	if _, err := io.WriteString(cw, storeHead); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	for _, cm := range comments {
+		cm = strings.Join(strings.Fields(cm), " ")
+		if _, err := fmt.Fprintf(cw, "# %s\r\n", cm); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+	}
+
+	var header section
+	var seqs []storeSeq
+	last := cw.n
+	row := func(spec, gene, acc string) error {
+		s := section{off: last, n: cw.n - last}
+		last = cw.n
+		if spec == "" {
+			header = s
+			return nil
+		}
+		seqs = append(seqs, storeSeq{spec: spec, gene: gene, acc: acc, section: s})
+		return nil
+	}
+	if err := c.writeTSV(cw, row); err != nil {
+		return err
+	}
+
+	idx := cw.n
+	s := &Store{
+		header: header,
+		taxon:  make(map[string]string, len(c.specs)),
+		seqs:   seqs,
+	}
+	for sp, spec := range c.specs {
+		s.taxon[sp] = spec.taxon
+	}
+	if err := s.writeIndex(cw); err != nil {
+		return err
+	}
+	fmt.Fprintf(cw, "%s%0*d\r\n", storeTrailer, storeOffset, idx)
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("while writing index: %v", err)
+	}
+	return nil
+}
+
+// WriteIndex writes the index lines of a store.
+func (s *Store) writeIndex(w io.Writer) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	tab.Write([]string{idxHeader, strconv.FormatInt(s.header.off, 10), strconv.FormatInt(s.header.n, 10)})
+	for _, sp := range s.Specimens() {
+		tab.Write([]string{idxTaxon, sp, s.taxon[sp]})
+	}
+	for _, sq := range s.seqs {
+		tab.Write([]string{idxSeq, sq.spec, sq.gene, sq.acc, strconv.FormatInt(sq.off, 10), strconv.FormatInt(sq.n, 10)})
+	}
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing index: %v", err)
+	}
+	return nil
+}
+
+// A countWriter is a writer
+// that counts the written bytes.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// IsStore returns true
+// if a file is a store file.
+func IsStore(name string) bool {
+	f, err := os.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	_, err = storeIndex(f)
+	return err == nil
+}
+
+// StoreIndex returns the offset of the index
+// of a store file.
+func storeIndex(f *os.File) (int64, error) {
+	st, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if st.Size() < int64(len(storeHead)+trailerLen) {
+		return 0, errors.New("not a store file")
+	}
+
+	head := make([]byte, len(storeHead))
+	if _, err := f.ReadAt(head, 0); err != nil {
+		return 0, err
+	}
+	if string(head) != storeHead {
+		return 0, errors.New("not a store file")
+	}
+
+	b := make([]byte, trailerLen)
+	if _, err := f.ReadAt(b, st.Size()-int64(trailerLen)); err != nil {
+		return 0, err
+	}
+	t := string(b)
+	if !strings.HasPrefix(t, storeTrailer) || !strings.HasSuffix(t, "\r\n") {
+		return 0, errors.New("not a store file")
+	}
+	idx, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(t, storeTrailer), "\r\n"), 10, 64)
+	if err != nil || idx < 0 || idx > st.Size() {
+		return 0, errors.New("invalid store index")
+	}
+	return idx, nil
+}
+
+// OpenStore opens a store file
+// and reads its index.
+func OpenStore(name string) (*Store, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := storeIndex(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("on file %q: %v", name, err)
+	}
+
+	s := &Store{
+		f:     f,
+		taxon: make(map[string]string),
+		accs:  make(map[string]map[string][]string),
+	}
+	if err := s.readIndex(io.NewSectionReader(f, idx, 1<<62)); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("on file %q: %v", name, err)
+	}
+	return s, nil
+}
+
+func (s *Store) readIndex(r io.Reader) error {
+	tab := csv.NewReader(bufio.NewReader(r))
+	tab.Comma = '\t'
+	tab.FieldsPerRecord = -1
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("while reading index: %v", err)
+		}
+		ln, _ := tab.FieldPos(0)
+		switch row[0] {
+		case idxChecksum:
+			if len(row) < 2 {
+				return fmt.Errorf("index: line %d: expecting 2 fields", ln)
+			}
+			s.sum = row[1]
+		case idxStat:
+			if len(row) < 3 {
+				return fmt.Errorf("index: line %d: expecting 3 fields", ln)
+			}
+			size, err := strconv.ParseInt(row[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("index: line %d: invalid size %q", ln, row[1])
+			}
+			mtime, err := strconv.ParseInt(row[2], 10, 64)
+			if err != nil {
+				return fmt.Errorf("index: line %d: invalid time %q", ln, row[2])
+			}
+			s.size, s.mtime = size, mtime
+		case idxHeader:
+			if len(row) < 3 {
+				return fmt.Errorf("index: line %d: expecting 3 fields", ln)
+			}
+			sc, err := parseSection(row[1], row[2])
+			if err != nil {
+				return fmt.Errorf("index: line %d: %v", ln, err)
+			}
+			s.header = sc
+		case idxTaxon:
+			if len(row) < 3 {
+				return fmt.Errorf("index: line %d: expecting 3 fields", ln)
+			}
+			s.taxon[row[1]] = row[2]
+		case idxSeq:
+			if len(row) < 6 {
+				return fmt.Errorf("index: line %d: expecting 6 fields", ln)
+			}
+			sc, err := parseSection(row[4], row[5])
+			if err != nil {
+				return fmt.Errorf("index: line %d: %v", ln, err)
+			}
+			s.addSeq(storeSeq{spec: row[1], gene: row[2], acc: row[3], section: sc})
+		default:
+			if strings.HasPrefix(row[0], storeTrailer) {
+				return nil
+			}
+			return fmt.Errorf("index: line %d: unknown keyword %q", ln, row[0])
+		}
+	}
+	return nil
+}
+
+func parseSection(off, n string) (section, error) {
+	o, err := strconv.ParseInt(off, 10, 64)
+	if err != nil {
+		return section{}, fmt.Errorf("invalid offset %q", off)
+	}
+	l, err := strconv.ParseInt(n, 10, 64)
+	if err != nil {
+		return section{}, fmt.Errorf("invalid length %q", n)
+	}
+	return section{off: o, n: l}, nil
+}
+
+// AddSeq adds a sequence to the index of the store.
+func (s *Store) addSeq(sq storeSeq) {
+	s.seqs = append(s.seqs, sq)
+	gene := s.aliases.canonGene(sq.gene)
+	g, ok := s.accs[sq.spec]
+	if !ok {
+		g = make(map[string][]string)
+		s.accs[sq.spec] = g
+	}
+	g[gene] = append(g[gene], sq.acc)
+}
+
+// SetAliases sets the table of gene aliases
+// used by the store,
+// so the synonyms of a gene
+// will be reported with the canonical name of the gene,
+// as in a collection with the same aliases
+// (see Collection.SetAliases).
+func (s *Store) SetAliases(a Aliases) {
+	s.aliases = a
+	seqs := s.seqs
+	s.seqs = nil
+	s.accs = make(map[string]map[string][]string, len(s.accs))
+	for _, sq := range seqs {
+		s.addSeq(sq)
+	}
+}
+
+// Close closes the store file.
+func (s *Store) Close() error {
+	return s.f.Close()
+}
+
+// Genes returns the genes in the store.
+func (s *Store) Genes() []string {
+	var genes []string
+	for _, g := range s.accs {
+		for gn := range g {
+			genes = append(genes, gn)
+		}
+	}
+	slices.Sort(genes)
+	return slices.Compact(genes)
+}
+
+// GeneAccession returns the accessions
+// of a gene of a specimen.
+func (s *Store) GeneAccession(specimen, gene string) []string {
+	specimen = names.Specimen(specimen)
+	gene = s.aliases.canonGene(gene)
+	acc := slices.Clone(s.accs[specimen][gene])
+	slices.Sort(acc)
+	return acc
+}
+
+// NumSequences returns the number of sequences
+// in the store.
+func (s *Store) NumSequences() int {
+	return len(s.seqs)
+}
+
+// SpecGene returns the genes of a specimen.
+func (s *Store) SpecGene(specimen string) []string {
+	specimen = names.Specimen(specimen)
+	g := s.accs[specimen]
+	genes := make([]string, 0, len(g))
+	for gn := range g {
+		genes = append(genes, gn)
+	}
+	slices.Sort(genes)
+	return genes
+}
+
+// Specimens returns the specimens in the store.
+func (s *Store) Specimens() []string {
+	specs := make([]string, 0, len(s.taxon))
+	for sp := range s.taxon {
+		specs = append(specs, sp)
+	}
+	slices.Sort(specs)
+	return specs
+}
+
+// Taxa returns the taxa in the store.
+func (s *Store) Taxa() []string {
+	taxa := make([]string, 0, len(s.taxon))
+	for _, tx := range s.taxon {
+		taxa = append(taxa, tx)
+	}
+	slices.Sort(taxa)
+	return slices.Compact(taxa)
+}
+
+// TaxSpec returns the specimens of a taxon.
+func (s *Store) TaxSpec(name string) []string {
+	name = names.Taxon(name)
+	var specs []string
+	for sp, tx := range s.taxon {
+		if tx != name {
+			continue
+		}
+		specs = append(specs, sp)
+	}
+	slices.Sort(specs)
+	return specs
+}
+
+// Load reads the sequences of the store
+// into a collection.
+// If keep is not nil,
+// only the sequences for which keep returns true
+// are read.
+func (s *Store) Load(c *Collection, keep func(specimen, gene string) bool) error {
+	rs := []io.Reader{
+		io.NewSectionReader(s.f, s.header.off, s.header.n),
+	}
+	for _, sq := range s.seqs {
+		if keep != nil && !keep(sq.spec, s.aliases.canonGene(sq.gene)) {
+			continue
+		}
+		rs = append(rs, io.NewSectionReader(s.f, sq.off, sq.n))
+	}
+	if err := c.ReadTSV(io.MultiReader(rs...)); err != nil {
+		return fmt.Errorf("on file %q: %v", s.f.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+func TestStore(t *testing.T) {
+	c := newCollection()
+
+	name := "tmp-dna-store-for-test.tab"
+	defer os.Remove(name)
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatalf("unable to create file: %v", err)
+	}
+	if err := c.WriteStore(f, "format version:  1"); err != nil {
+		t.Fatalf("unable to write store: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("unable to close store: %v", err)
+	}
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("unable to read file: %v", err)
+	}
+	if want := "# phydata: dna store\r\n# format version: 1\r\n"; !strings.HasPrefix(string(b), want) {
+		t.Errorf("store header: got %q, want %q", b[:len(want)], want)
+	}
+
+	if !dna.IsStore(name) {
+		t.Fatalf("file %q: not detected as a store", name)
+	}
+
+	// a store is also a valid TSV file
+	f, err = os.Open(name)
+	if err != nil {
+		t.Fatalf("unable to open file: %v", err)
+	}
+	tsv := dna.New()
+	err = tsv.ReadTSV(f)
+	f.Close()
+	if err != nil {
+		t.Fatalf("unable to read store as TSV: %v", err)
+	}
+	cmpCollection(t, tsv, c)
+
+	s, err := dna.OpenStore(name)
+	if err != nil {
+		t.Fatalf("unable to open store: %v", err)
+	}
+	defer s.Close()
+
+	if got, want := s.Taxa(), c.Taxa(); !reflect.DeepEqual(got, want) {
+		t.Errorf("taxa: got %v, want %v", got, want)
+	}
+	if got, want := s.Specimens(), c.Specimens(); !reflect.DeepEqual(got, want) {
+		t.Errorf("specimens: got %v, want %v", got, want)
+	}
+	if got, want := s.Genes(), c.Genes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("genes: got %v, want %v", got, want)
+	}
+	if got, want := s.NumSequences(), c.NumSequences(); got != want {
+		t.Errorf("sequences: got %d, want %d", got, want)
+	}
+	for _, tx := range c.Taxa() {
+		if got, want := s.TaxSpec(tx), c.TaxSpec(tx); !reflect.DeepEqual(got, want) {
+			t.Errorf("taxon %q: specimens: got %v, want %v", tx, got, want)
+		}
+	}
+	for _, sp := range c.Specimens() {
+		if got, want := s.SpecGene(sp), c.SpecGene(sp); !reflect.DeepEqual(got, want) {
+			t.Errorf("specimen %q: genes: got %v, want %v", sp, got, want)
+		}
+		for _, g := range c.SpecGene(sp) {
+			if got, want := s.GeneAccession(sp, g), c.GeneAccession(sp, g); !reflect.DeepEqual(got, want) {
+				t.Errorf("specimen %q, gene %q: accessions: got %v, want %v", sp, g, got, want)
+			}
+		}
+	}
+
+	all := dna.New()
+	if err := s.Load(all, nil); err != nil {
+		t.Fatalf("unable to load store: %v", err)
+	}
+	cmpCollection(t, all, c)
+
+	cytb := dna.New()
+	keep := func(spec, gene string) bool {
+		return gene == "cytb"
+	}
+	if err := s.Load(cytb, keep); err != nil {
+		t.Fatalf("unable to load store: %v", err)
+	}
+	if got := cytb.Genes(); !reflect.DeepEqual(got, []string{"cytb"}) {
+		t.Errorf("load cytb: genes: got %v, want %v", got, []string{"cytb"})
+	}
+	for _, sp := range cytb.Specimens() {
+		for _, acc := range cytb.GeneAccession(sp, "cytb") {
+			if got, want := cytb.Sequence(sp, "cytb", acc), c.Sequence(sp, "cytb", acc); got != want {
+				t.Errorf("load cytb: specimen %q, accession %q: got %q, want %q", sp, acc, got, want)
+			}
+		}
+	}
+
+	if dna.IsStore("dna_test.go") {
+		t.Errorf("file %q: detected as a store", "dna_test.go")
+	}
+}
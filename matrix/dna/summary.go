@@ -0,0 +1,86 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+import "strings"
+
+// NumSequences returns the number of sequences
+// in the collection.
+func (c *Collection) NumSequences() int {
+	var n int
+	for _, sp := range c.specs {
+		for _, gb := range sp.genes {
+			n += len(gb)
+		}
+	}
+	return n
+}
+
+// NumSpecimens returns the number of specimens
+// in the collection.
+func (c *Collection) NumSpecimens() int {
+	return len(c.specs)
+}
+
+// LenStats is a summary of the sequences
+// of a gene.
+type LenStats struct {
+	Taxa      int // number of taxa with sequences
+	Sequences int // number of sequences
+
+	// Min, Max, and Mean are the statistics
+	// of the length of the sequences,
+	// counted as the number of nucleotides
+	// (i.e., without gaps or missing positions).
+	Min  int
+	Max  int
+	Mean float64
+}
+
+// LenStats returns a summary of the sequences
+// of a gene.
+func (c *Collection) LenStats(gene string) LenStats {
+	gene = strings.ToLower(strings.TrimSpace(gene))
+
+	var st LenStats
+	var sum int
+	taxa := make(map[string]bool)
+	for _, sp := range c.specs {
+		gb, ok := sp.genes[gene]
+		if !ok || len(gb) == 0 {
+			continue
+		}
+		taxa[sp.taxon] = true
+		for _, s := range gb {
+			ln := baseCount(s.seq)
+			if st.Sequences == 0 || ln < st.Min {
+				st.Min = ln
+			}
+			if ln > st.Max {
+				st.Max = ln
+			}
+			sum += ln
+			st.Sequences++
+		}
+	}
+	st.Taxa = len(taxa)
+	if st.Sequences > 0 {
+		st.Mean = float64(sum) / float64(st.Sequences)
+	}
+	return st
+}
+
+// BaseCount returns the number of nucleotides
+// of a sequence.
+func baseCount(seq string) int {
+	var n int
+	for _, r := range seq {
+		if r == '-' || r == '?' {
+			continue
+		}
+		n++
+	}
+	return n
+}
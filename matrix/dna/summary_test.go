@@ -0,0 +1,36 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+func TestSummary(t *testing.T) {
+	c := newCollection()
+
+	if n := c.NumSequences(); n != 6 {
+		t.Errorf("sequences: got %d, want %d", n, 6)
+	}
+	if n := c.NumSpecimens(); n != 5 {
+		t.Errorf("specimens: got %d, want %d", n, 5)
+	}
+
+	want := dna.LenStats{
+		Taxa:      4,
+		Sequences: 4,
+		Min:       27,
+		Max:       30,
+		Mean:      28.75,
+	}
+	if st := c.LenStats("CytB"); st != want {
+		t.Errorf("cytb: got %+v, want %+v", st, want)
+	}
+	if st := c.LenStats("rag1"); st != (dna.LenStats{}) {
+		t.Errorf("rag1: got %+v, want %+v", st, dna.LenStats{})
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A GeneticCode is a translation table
+// identified by its NCBI number.
+type GeneticCode int
+
+// Valid genetic codes.
+const (
+	Standard                  GeneticCode = 1
+	VertebrateMitochondrial   GeneticCode = 2
+	YeastMitochondrial        GeneticCode = 3
+	MoldMitochondrial         GeneticCode = 4
+	InvertebrateMitochondrial GeneticCode = 5
+)
+
+// Translation tables in NCBI order:
+// the first, second, and third bases of each codon
+// are iterated in the order "TCAG".
+var codeTables = map[GeneticCode]string{
+	Standard:                  "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+	VertebrateMitochondrial:   "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSS**VVVVAAAADDEEGGGG",
+	YeastMitochondrial:        "FFLLSSSSYY**CCWWTTTTPPPPHHQQRRRRIIMMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+	MoldMitochondrial:         "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+	InvertebrateMitochondrial: "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSSSVVVVAAAADDEEGGGG",
+}
+
+// ErrStopCodon is the error returned
+// when a translated sequence has an internal stop codon.
+var ErrStopCodon = errors.New("internal stop codon")
+
+// Valid returns true if the genetic code is defined.
+func (gc GeneticCode) Valid() bool {
+	_, ok := codeTables[gc]
+	return ok
+}
+
+// Translate translates a codon into an amino acid
+// using the indicated genetic code.
+// Stop codons are translated as '*',
+// a codon with gaps is translated as '-',
+// a codon with missing data is translated as '?',
+// and a codon with ambiguous or partial data
+// is translated as 'X'.
+func (gc GeneticCode) Translate(codon string) byte {
+	if codon == "---" {
+		return '-'
+	}
+	if codon == "???" {
+		return '?'
+	}
+	if len(codon) != 3 {
+		return 'X'
+	}
+	table, ok := codeTables[gc]
+	if !ok {
+		return 'X'
+	}
+
+	var idx int
+	for i := 0; i < 3; i++ {
+		var v int
+		switch codon[i] {
+		case 't', 'u':
+			v = 0
+		case 'c':
+			v = 1
+		case 'a':
+			v = 2
+		case 'g':
+			v = 3
+		default:
+			return 'X'
+		}
+		idx = idx*4 + v
+	}
+	return table[idx]
+}
+
+// Translate returns the amino acid sequence
+// of a sequence in the collection,
+// using the indicated reading frame
+// (1, 2, or 3)
+// and genetic code.
+// If the sequence is in the minus strand,
+// it is reverse complemented
+// before the translation.
+//
+// If the translated sequence has an internal stop codon,
+// the returned error wraps ErrStopCodon,
+// and the translation is returned
+// with the stop codons marked as '*'.
+func (c *Collection) Translate(specimen, gene, genBank string, frame int, code GeneticCode) (string, error) {
+	if frame < 1 || frame > 3 {
+		return "", fmt.Errorf("invalid reading frame %d", frame)
+	}
+	if !code.Valid() {
+		return "", fmt.Errorf("invalid genetic code %d", code)
+	}
+
+	seq := c.sequence(specimen, gene, genBank)
+	if seq == nil {
+		return "", fmt.Errorf("sequence %q not found", genBank)
+	}
+
+	s := seq.seq
+	if seq.strand == "-" {
+		s = ReverseComplement(s)
+	}
+	if frame <= len(s) {
+		s = s[frame-1:]
+	} else {
+		s = ""
+	}
+
+	var aa strings.Builder
+	for i := 0; i+3 <= len(s); i += 3 {
+		aa.WriteByte(code.Translate(s[i : i+3]))
+	}
+	prot := aa.String()
+
+	last := strings.TrimRight(prot, "-?")
+	if i := strings.IndexByte(last, '*'); i >= 0 && i < len(last)-1 {
+		return prot, fmt.Errorf("%w at codon %d", ErrStopCodon, i+1)
+	}
+	return prot, nil
+}
@@ -0,0 +1,81 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+func TestTranslate(t *testing.T) {
+	c := dna.New()
+	c.Add("Homo sapiens", "sp-01", "cytb", "AB001", "atgacc---ccaatacgctaa")
+	c.Add("Homo sapiens", "sp-01", "cox1", "AB002", "atgtgaacctaa")
+	c.Add("Homo sapiens", "sp-01", "eef1a1", "AB003", "catgggaaagrg")
+	c.Add("Homo sapiens", "sp-01", "nd2", "AB004", "ttaggtcat")
+	c.Set("sp-01", "nd2", "AB004", "-", dna.Strand)
+
+	tests := map[string]struct {
+		gene  string
+		acc   string
+		frame int
+		code  dna.GeneticCode
+		want  string
+		stop  bool
+	}{
+		"standard": {
+			gene:  "cytb",
+			acc:   "AB001",
+			frame: 1,
+			code:  dna.Standard,
+			want:  "MT-PIR*",
+		},
+		"internal stop": {
+			gene:  "cox1",
+			acc:   "AB002",
+			frame: 1,
+			code:  dna.Standard,
+			want:  "M*T*",
+			stop:  true,
+		},
+		"mitochondrial": {
+			gene:  "cox1",
+			acc:   "AB002",
+			frame: 1,
+			code:  dna.VertebrateMitochondrial,
+			want:  "MWT*",
+		},
+		"frame 2": {
+			gene:  "eef1a1",
+			acc:   "AB003",
+			frame: 2,
+			code:  dna.Standard,
+			want:  "MGK",
+		},
+		"minus strand": {
+			gene:  "nd2",
+			acc:   "AB004",
+			frame: 1,
+			code:  dna.Standard,
+			want:  "MT*",
+		},
+	}
+
+	for name, test := range tests {
+		got, err := c.Translate("sp-01", test.gene, test.acc, test.frame, test.code)
+		if test.stop {
+			if !errors.Is(err, dna.ErrStopCodon) {
+				t.Errorf("%s: expecting stop codon error, got %v", name, err)
+			}
+		} else if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+import "strings"
+
+// A Mask is a set of columns of an alignment.
+// A true value indicates that the column
+// is included.
+type Mask []bool
+
+// Apply returns a sequence
+// with only the columns included in the mask.
+// Positions outside the mask are ignored.
+func (m Mask) Apply(seq string) string {
+	var b strings.Builder
+	for i := 0; i < len(seq); i++ {
+		if i < len(m) && !m[i] {
+			continue
+		}
+		b.WriteByte(seq[i])
+	}
+	return b.String()
+}
+
+// Len returns the number of included columns
+// in the mask.
+func (m Mask) Len() int {
+	var n int
+	for _, v := range m {
+		if v {
+			n++
+		}
+	}
+	return n
+}
+
+// GapColumns returns a mask
+// that excludes the columns of an alignment
+// in which all sequences have a gap
+// (or missing data,
+// including 'n').
+func GapColumns(seqs []string) Mask {
+	m := make(Mask, alignLen(seqs))
+	for i := range m {
+		for _, s := range seqs {
+			if i < len(s) && isBase(rune(s[i])) {
+				m[i] = true
+				break
+			}
+		}
+	}
+	return m
+}
+
+// RaggedEnds returns a mask
+// that excludes the leading and trailing columns
+// of an alignment
+// in which the fraction of sequences with a base
+// is less than a given fraction.
+func RaggedEnds(seqs []string, frac float64) Mask {
+	ln := alignLen(seqs)
+	m := make(Mask, ln)
+
+	start := ln
+	for i := 0; i < ln; i++ {
+		if baseFraction(seqs, i) >= frac {
+			start = i
+			break
+		}
+	}
+	end := start
+	for i := ln - 1; i >= start; i-- {
+		if baseFraction(seqs, i) >= frac {
+			end = i + 1
+			break
+		}
+	}
+
+	for i := start; i < end; i++ {
+		m[i] = true
+	}
+	return m
+}
+
+// Conserved returns a mask
+// that keeps the conserved columns of an alignment,
+// in a way similar to Gblocks (Castresana 2000).
+//
+// A column is excluded
+// if the fraction of sequences with a gap
+// (or missing data)
+// is larger than maxGaps,
+// or if the frequency of the most common base
+// is lesser than minIdentity.
+// Blocks of included columns
+// shorter than minBlock are also excluded.
+func Conserved(seqs []string, maxGaps, minIdentity float64, minBlock int) Mask {
+	m := make(Mask, alignLen(seqs))
+	for i := range m {
+		if 1-baseFraction(seqs, i) > maxGaps {
+			continue
+		}
+		if identity(seqs, i) < minIdentity {
+			continue
+		}
+		m[i] = true
+	}
+
+	// remove short blocks
+	for i := 0; i < len(m); {
+		if !m[i] {
+			i++
+			continue
+		}
+		j := i
+		for j < len(m) && m[j] {
+			j++
+		}
+		if j-i < minBlock {
+			for k := i; k < j; k++ {
+				m[k] = false
+			}
+		}
+		i = j
+	}
+	return m
+}
+
+func alignLen(seqs []string) int {
+	var ln int
+	for _, s := range seqs {
+		if len(s) > ln {
+			ln = len(s)
+		}
+	}
+	return ln
+}
+
+func baseFraction(seqs []string, col int) float64 {
+	if len(seqs) == 0 {
+		return 0
+	}
+	var n int
+	for _, s := range seqs {
+		if col < len(s) && isBase(rune(s[col])) {
+			n++
+		}
+	}
+	return float64(n) / float64(len(seqs))
+}
+
+func identity(seqs []string, col int) float64 {
+	freq := make(map[byte]int)
+	var n int
+	for _, s := range seqs {
+		if col >= len(s) || !isBase(rune(s[col])) {
+			continue
+		}
+		freq[s[col]]++
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	var max int
+	for _, f := range freq {
+		if f > max {
+			max = f
+		}
+	}
+	return float64(max) / float64(n)
+}
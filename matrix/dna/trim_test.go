@@ -0,0 +1,98 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+func TestTrim(t *testing.T) {
+	seqs := []string{
+		"--acg-tacgtac--",
+		"--acg-tacgaac-?",
+		"??atg-tccgtacg-",
+		"---cg-tacgta---",
+	}
+
+	tests := map[string]struct {
+		mask dna.Mask
+		want []string
+	}{
+		"gaps": {
+			mask: dna.GapColumns(seqs),
+			want: []string{
+				"acgtacgtac-",
+				"acgtacgaac-",
+				"atgtccgtacg",
+				"-cgtacgta--",
+			},
+		},
+		"ends": {
+			mask: dna.RaggedEnds(seqs, 1),
+			want: []string{
+				"cg-tacgta",
+				"cg-tacgaa",
+				"tg-tccgta",
+				"cg-tacgta",
+			},
+		},
+		"conserved": {
+			mask: dna.Conserved(seqs, 0.25, 0.8, 2),
+			want: []string{
+				"cgac",
+				"cgac",
+				"cgac",
+				"cga-",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		for i, s := range seqs {
+			got := test.mask.Apply(s)
+			if got != test.want[i] {
+				t.Errorf("%s: sequence %d: got %q, want %q", name, i, got, test.want[i])
+			}
+		}
+	}
+}
+
+func TestTrimAmbiguous(t *testing.T) {
+	seqs := []string{
+		"nacgtn",
+		"?acgan",
+		"nacgt-",
+		"Nacgt?",
+	}
+
+	tests := map[string]struct {
+		mask dna.Mask
+		want []string
+	}{
+		"gaps": {
+			mask: dna.GapColumns(seqs),
+			want: []string{"acgt", "acga", "acgt", "acgt"},
+		},
+		"ends": {
+			mask: dna.RaggedEnds(seqs, 0.5),
+			want: []string{"acgt", "acga", "acgt", "acgt"},
+		},
+		"conserved": {
+			mask: dna.Conserved(seqs, 0.5, 0.5, 1),
+			want: []string{"acgt", "acga", "acgt", "acgt"},
+		},
+	}
+
+	for name, test := range tests {
+		for i, s := range seqs {
+			got := test.mask.Apply(s)
+			if got != test.want[i] {
+				t.Errorf("%s: sequence %d: got %q, want %q", name, i, got, test.want[i])
+			}
+		}
+	}
+}
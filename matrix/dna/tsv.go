@@ -5,6 +5,7 @@
 package dna
 
 import (
+	"bufio"
 	"encoding/csv"
 	"errors"
 	"fmt"
@@ -12,6 +13,8 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/js-arias/phydata/internal/tsvchunk"
 )
 
 var headerFields = []string{
@@ -25,9 +28,18 @@ var headerFields = []string{
 var valFields = []Field{
 	Protein,
 	Organelle,
+	Molecule,
 	Aligned,
+	Start,
+	End,
+	Strand,
+	Structure,
 	Reference,
 	Comments,
+	Quality,
+	Trace,
+	Run,
+	Primers,
 }
 
 // ReadTSV reads a set of DNA sequences
@@ -45,9 +57,28 @@ var valFields = []Field{
 //
 //   - protein, if "true" the molecule product is a protein
 //   - organelle, the celular organelle that contains the sequence
+//   - molecule, the kind of molecule: "dna" (the default), "rna", or
+//     "rrna" (ribosomal RNA)
 //   - aligned, if "true" the sequence has been previously aligned
+//   - start, the first position of the sequence in the reference gene
+//   - end, the last position of the sequence in the reference gene
+//   - strand, the strand of the sequence in relation to the reference
+//     gene, either "+" or "-"
+//   - structure, the secondary structure of an RNA sequence,
+//     in dot-bracket notation
 //   - reference, an ID of a bibliographic reference
 //   - comments, simple additional comments about the sequence
+//   - quality, a summary of the sequencing quality
+//     (e.g., the mean phred score)
+//   - trace, a link to the trace (chromatogram) file
+//   - run, the sequencing run
+//   - primers, the primers used to sequence the gene,
+//     separated by commas
+//
+// Any other column is kept as an extra field
+// of the sequences
+// (see ExtraFields)
+// and it will be written back by TSV.
 //
 // Here is an example file:
 //
@@ -59,66 +90,121 @@ var valFields = []Field{
 //	Panthera tigris	fmnh_un_2485	cytb	MH290773	true	mitochondrion	true			gactcagacaaa---ccattccacccatac
 //	Papio anubis	genbank:ku871221	cytb	KU871221	true	mitochondrion	true			atgaccccaatacgcaaatctaatcctatc
 //	Papio anubis	genbank:xm_003897809	eef1a1	XM_003897809	true	nucleus	true			gcagtgagccgagatcgcgccactgcaccc
+//
+// Rows with an empty taxon, specimen, gene, accession, or sequence
+// are ignored.
+//
+// Besides tabs,
+// the file can be delimited by commas or semicolons
+// (as exported by spreadsheets in different locales):
+// the delimiter is detected from the header.
+//
+// Large files are decoded in parallel
+// (using up to GOMAXPROCS blocks at the same time),
+// but the rows are added in the order of the file.
 func (c *Collection) ReadTSV(r io.Reader) error {
-	tab := csv.NewReader(r)
-	tab.Comma = '\t'
+	return c.readTSV(r, 0, false)
+}
+
+// ReadStrictTSV is like ReadTSV,
+// but it returns an error
+// if a row has an empty taxon, specimen, gene, accession, or sequence,
+// or if the sequence can not be added.
+func (c *Collection) ReadStrictTSV(r io.Reader) error {
+	return c.readTSV(r, 0, true)
+}
+
+// ReadDelimited is like ReadTSV,
+// but the fields are delimited
+// by the given delimiter
+// (e.g., ',' or ';').
+// If the delimiter is 0,
+// it will be detected from the header.
+// If strict is true,
+// it works like ReadStrictTSV.
+func (c *Collection) ReadDelimited(r io.Reader, comma rune, strict bool) error {
+	return c.readTSV(r, comma, strict)
+}
+
+func (c *Collection) readTSV(r io.Reader, comma rune, strict bool) error {
+	br := bufio.NewReaderSize(r, 1<<16)
+	if comma == 0 {
+		var err error
+		comma, err = sniffDelimiter(br)
+		if err != nil {
+			return err
+		}
+	}
+
+	tab := csv.NewReader(br)
+	tab.Comma = comma
 	tab.Comment = '#'
 
 	head, err := tab.Read()
 	if err != nil {
 		return fmt.Errorf("while reading header: %v", err)
 	}
-	fields := make(map[string]int, len(head))
-	for i, h := range head {
-		h = strings.ToLower(h)
-		fields[h] = i
-	}
+	fields := c.headers.fields(head)
 	for _, h := range headerFields {
 		if _, ok := fields[h]; !ok {
 			return fmt.Errorf("expecting field %q", h)
 		}
 	}
+	extra := extraColumns(head, fields)
+	line, _ := tab.FieldPos(len(head) - 1)
 
-	for {
-		row, err := tab.Read()
-		if errors.Is(err, io.EOF) {
-			break
-		}
-		ln, _ := tab.FieldPos(0)
-		if err != nil {
-			return fmt.Errorf("on row %d: %v", ln, err)
-		}
-
+	bases := fields["bases"]
+	prep := func(row []string) {
+		row[bases] = formatSequence(row[bases])
+	}
+	return tsvchunk.Read(br, comma, line, len(head), prep, func(row []string, ln int) error {
 		f := "taxon"
 		tax := row[fields[f]]
 		if tax == "" {
-			continue
+			if strict {
+				return fmt.Errorf("on row %d: empty field %q", ln, f)
+			}
+			return nil
 		}
 
 		f = "specimen"
 		spec := row[fields[f]]
 		if spec == "" {
-			continue
+			if strict {
+				return fmt.Errorf("on row %d: empty field %q", ln, f)
+			}
+			return nil
 		}
 
 		f = "gene"
 		gene := row[fields[f]]
 		if gene == "" {
-			continue
+			if strict {
+				return fmt.Errorf("on row %d: empty field %q", ln, f)
+			}
+			return nil
 		}
 
 		f = "genbank"
 		gb := row[fields[f]]
 		if gb == "" {
-			continue
+			if strict {
+				return fmt.Errorf("on row %d: empty field %q", ln, f)
+			}
+			return nil
 		}
 
 		f = "bases"
 		seq := row[fields[f]]
 		if seq == "" {
-			continue
+			if strict {
+				return fmt.Errorf("on row %d: empty field %q", ln, f)
+			}
+			return nil
+		}
+		if err := c.add(tax, spec, gene, gb, seq); err != nil && strict {
+			return fmt.Errorf("on row %d: %v", ln, err)
 		}
-		c.Add(tax, spec, gene, gb, seq)
 
 		// additional fields
 		for _, ff := range valFields {
@@ -131,40 +217,50 @@ func (c *Collection) ReadTSV(r io.Reader) error {
 			v := row[i]
 			c.Set(spec, gene, gb, v, ff)
 		}
-	}
-
-	return nil
+		for i, f := range extra {
+			c.Set(spec, gene, gb, row[i], f)
+		}
+		return nil
+	})
 }
 
 // TSV writes a DNA sequence collection as a TSV file.
 func (c *Collection) TSV(w io.Writer) error {
+	return c.writeTSV(w, nil)
+}
+
+// WriteTSV writes a DNA sequence collection as a TSV file.
+// If row is not nil,
+// the data is flushed after the header,
+// and after each sequence,
+// and row is called with the specimen,
+// gene,
+// and the accession of the sequence
+// (empty values for the header).
+func (c *Collection) writeTSV(w io.Writer, row func(spec, gene, acc string) error) error {
 	tab := csv.NewWriter(w)
 	tab.Comma = '\t'
 	tab.UseCRLF = true
 
 	//header
-	header := []string{"taxon", "specimen", "gene", "genbank", "protein", "organelle", "aligned", "reference", "comments", "bases"}
+	header := []string{"taxon", "specimen", "gene", "genbank", "protein", "organelle", "molecule", "aligned", "start", "end", "strand", "structure", "reference", "comments", "quality", "trace", "run", "primers"}
+	extra := c.ExtraFields()
+	for _, f := range extra {
+		header = append(header, string(f))
+	}
+	header = append(header, "bases")
 	if err := tab.Write(header); err != nil {
 		return fmt.Errorf("unable to write header: %v", err)
 	}
-
-	tax := make(map[string][]string)
-	var tn []string
-	for _, sp := range c.specs {
-		t, ok := tax[sp.taxon]
-		if !ok {
-			tn = append(tn, sp.taxon)
+	if row != nil {
+		if err := flushRow(tab, row, "", "", ""); err != nil {
+			return err
 		}
-		t = append(t, sp.name)
-		tax[sp.taxon] = t
 	}
-	slices.Sort(tn)
 
 	genes := c.Genes()
-	for _, tt := range tn {
-		t := tax[tt]
-		slices.Sort(t)
-		for _, spv := range t {
+	for _, tt := range c.Taxa() {
+		for _, spv := range c.taxon[tt] {
 			sp := c.specs[spv]
 
 			for _, gn := range genes {
@@ -180,21 +276,38 @@ func (c *Collection) TSV(w io.Writer) error {
 
 				for _, a := range acc {
 					seq := g[a]
-					row := []string{
+					rec := []string{
 						sp.taxon,
 						sp.name,
 						gn,
 						a,
 						strconv.FormatBool(seq.protein),
 						seq.organelle,
+						c.Val(sp.name, gn, a, Molecule),
 						strconv.FormatBool(seq.aligned),
+						c.Val(sp.name, gn, a, Start),
+						c.Val(sp.name, gn, a, End),
+						seq.strand,
+						seq.structure,
 						seq.ref,
 						seq.comment,
-						seq.seq,
+						seq.quality,
+						seq.trace,
+						seq.run,
+						seq.primers,
 					}
-					if err := tab.Write(row); err != nil {
+					for _, f := range extra {
+						rec = append(rec, seq.extra[string(f)])
+					}
+					rec = append(rec, seq.seq)
+					if err := tab.Write(rec); err != nil {
 						return fmt.Errorf("while writing data: %v", err)
 					}
+					if row != nil {
+						if err := flushRow(tab, row, sp.name, gn, a); err != nil {
+							return err
+						}
+					}
 				}
 			}
 		}
@@ -207,3 +320,74 @@ func (c *Collection) TSV(w io.Writer) error {
 
 	return nil
 }
+
+// FlushRow flushes a TSV writer
+// and calls the row function.
+func flushRow(tab *csv.Writer, row func(spec, gene, acc string) error, spec, gene, acc string) error {
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return row(spec, gene, acc)
+}
+
+// SniffDelimiter returns the delimiter
+// of the first non-comment line
+// of a delimited text file.
+func sniffDelimiter(r *bufio.Reader) (rune, error) {
+	b, err := r.Peek(r.Size())
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return 0, err
+	}
+	for _, ln := range strings.Split(string(b), "\n") {
+		ln = strings.TrimSpace(ln)
+		if ln == "" || ln[0] == '#' {
+			continue
+		}
+		if strings.Contains(ln, "\t") {
+			return '\t', nil
+		}
+		if strings.Contains(ln, ",") {
+			return ',', nil
+		}
+		if strings.Contains(ln, ";") {
+			return ';', nil
+		}
+		break
+	}
+	return '\t', nil
+}
+
+// ExtraColumns returns the columns of a header
+// that are not used by a known field,
+// indexed by its column.
+func extraColumns(head []string, fields map[string]int) map[int]Field {
+	used := make(map[int]bool, len(fields))
+	known := make(map[string]bool, len(headerFields)+len(valFields))
+	for _, f := range headerFields {
+		used[fields[f]] = true
+		known[f] = true
+	}
+	for _, f := range valFields {
+		if i, ok := fields[string(f)]; ok {
+			used[i] = true
+		}
+		known[string(f)] = true
+	}
+
+	extra := make(map[int]Field)
+	for i, c := range head {
+		if used[i] {
+			continue
+		}
+		if i == 0 {
+			c = strings.TrimPrefix(c, "\ufeff")
+		}
+		name := headerName(c)
+		if name == "" || known[name] {
+			continue
+		}
+		extra[i] = Field(name)
+	}
+	return extra
+}
@@ -6,6 +6,11 @@ package dna_test
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/js-arias/phydata/matrix/dna"
@@ -26,3 +31,203 @@ func TestTSV(t *testing.T) {
 
 	cmpCollection(t, got, c)
 }
+
+func TestReadTSVChunks(t *testing.T) {
+	text, want := largeDNAText(6_000)
+
+	c := dna.New()
+	if err := c.ReadTSV(strings.NewReader(text)); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+	cmpCollection(t, c, want)
+
+	// errors report the line in the file
+	ln := strings.Count(text, "\n") + 1
+	text += "Taxon 1\tspec:1\tcytb\n"
+	err := dna.New().ReadTSV(strings.NewReader(text))
+	if err == nil {
+		t.Fatalf("expecting error on row %d", ln)
+	}
+	if w := fmt.Sprintf("on row %d: record on line %d:", ln, ln); !strings.HasPrefix(err.Error(), w) {
+		t.Errorf("error: got %q, want prefix %q", err, w)
+	}
+}
+
+// LargeDNAText returns a TSV file with n sequences,
+// with comment lines,
+// and the collection with the sequences.
+func largeDNAText(n int) (string, *dna.Collection) {
+	c := dna.New()
+	seq := strings.Repeat("ACGTTGCA acgtaaccgg ", 16)
+	genes := []string{"cytb", "coi"}
+
+	var b strings.Builder
+	b.WriteString("taxon\tspecimen\tgene\tgenbank\tbases\n")
+	for i := 0; i < n; i++ {
+		if i%1000 == 0 {
+			fmt.Fprintf(&b, "# block %d: a \"quoted comment\n", i/1000)
+		}
+		tax := fmt.Sprintf("Taxon %d", i%300)
+		spec := fmt.Sprintf("spec:%d", i/2)
+		gene := genes[i%2]
+		acc := fmt.Sprintf("AC%06d", i)
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%s\n", tax, spec, gene, acc, seq)
+		c.Add(tax, spec, gene, acc, seq)
+	}
+	return b.String(), c
+}
+
+func BenchmarkReadTSV(b *testing.B) {
+	text, _ := largeDNAText(50_000)
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+
+	procs := []int{1}
+	if n := runtime.NumCPU(); n > 1 {
+		procs = append(procs, n)
+	}
+	for _, p := range procs {
+		b.Run(fmt.Sprintf("procs=%d", p), func(b *testing.B) {
+			runtime.GOMAXPROCS(p)
+			b.SetBytes(int64(len(text)))
+			for i := 0; i < b.N; i++ {
+				c := dna.New()
+				if err := c.ReadTSV(strings.NewReader(text)); err != nil {
+					b.Fatalf("unable to read TSV data: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestReadStrictTSV(t *testing.T) {
+	c := newCollection()
+	var w bytes.Buffer
+	if err := c.TSV(&w); err != nil {
+		t.Fatalf("unable to write TSV data: %v", err)
+	}
+
+	got := dna.New()
+	if err := got.ReadStrictTSV(&w); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+	cmpCollection(t, got, c)
+
+	text := "taxon\tspecimen\tgene\tgenbank\tbases\n" +
+		"Orycteropus afer\tsp-02\tcytb\tOR167429\tgaccaacattcgtaaaacc\n" +
+		"Orycteropus afer\tsp-02\t\tOR167430\tgaccaacattcgtaaaacc\n"
+	if err := dna.New().ReadTSV(strings.NewReader(text)); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+	if err := dna.New().ReadStrictTSV(strings.NewReader(text)); err == nil {
+		t.Errorf("strict: expecting error on empty gene")
+	}
+}
+
+func TestReadDelimited(t *testing.T) {
+	tests := map[string]struct {
+		comma rune
+		text  string
+	}{
+		"comma": {
+			text: "taxon,specimen,gene,genbank,bases\n" +
+				"Orycteropus afer,sp-02,cytb,OR167429,gaccaacattcgtaaaacc\n",
+		},
+		"semicolon": {
+			text: "\ufeffTaxon; Specimen; Gene; GenBank; Bases\n" +
+				"Orycteropus afer;sp-02;cytb;OR167429;gaccaacattcgtaaaacc\n",
+		},
+		"explicit": {
+			comma: ';',
+			text: "taxon;specimen;gene;genbank;bases\n" +
+				"Orycteropus afer;sp-02;cytb;OR167429;gaccaacattcgtaaaacc\n",
+		},
+	}
+	for name, test := range tests {
+		got := dna.New()
+		if err := got.ReadDelimited(strings.NewReader(test.text), test.comma, true); err != nil {
+			t.Errorf("%s: unable to read data: %v", name, err)
+			continue
+		}
+		want := "gaccaacattcgtaaaacc"
+		if seq := got.Sequence("sp-02", "cytb", "OR167429"); seq != want {
+			t.Errorf("%s: sequence: got %q, want %q", name, seq, want)
+		}
+	}
+}
+
+func TestExtraFields(t *testing.T) {
+	text := "taxon\tspecimen\tgene\tgenbank\tvoucher status\tbases\tplate\n" +
+		"Papio anubis\tgenbank:ku871221\tcytb\tKU871221\tlost\tatgaccccaatacg\t\n" +
+		"Panthera tigris\tfmnh_un_2485\tcytb\tMH290773\t\tgactcagacaaa\tP-07\n"
+	c := dna.New()
+	if err := c.ReadTSV(strings.NewReader(text)); err != nil {
+		t.Fatalf("unable to read data: %v", err)
+	}
+
+	want := []dna.Field{"plate", "voucher status"}
+	if got := c.ExtraFields(); !reflect.DeepEqual(got, want) {
+		t.Errorf("extra fields: got %v, want %v", got, want)
+	}
+
+	var w bytes.Buffer
+	if err := c.TSV(&w); err != nil {
+		t.Fatalf("unable to write TSV data: %v", err)
+	}
+	got := dna.New()
+	if err := got.ReadTSV(&w); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+	if v := got.Val("genbank:ku871221", "cytb", "KU871221", "voucher status"); v != "lost" {
+		t.Errorf("extra field after round trip: got %q, want %q", v, "lost")
+	}
+	if v := got.Val("fmnh_un_2485", "cytb", "MH290773", "plate"); v != "P-07" {
+		t.Errorf("extra field after round trip: got %q, want %q", v, "P-07")
+	}
+	if s := got.Sequence("fmnh_un_2485", "cytb", "MH290773"); s != "gactcagacaaa" {
+		t.Errorf("sequence after round trip: got %q, want %q", s, "gactcagacaaa")
+	}
+}
+
+func TestSequencingFields(t *testing.T) {
+	text := "taxon\tspecimen\tgene\tgenbank\tphred\tchromatogram\tsequencing run\tprimer\tbases\n" +
+		"Panthera tigris\tfmnh_un_2485\tcytb\tMH290773\t38.2\ttraces/fmnh_2485.ab1\trun-2024-03\tL14724,H15915\tgactcagacaaa\n"
+	c := dna.New()
+	c.SetHeaders(dna.DefaultHeaders())
+	if err := c.ReadTSV(strings.NewReader(text)); err != nil {
+		t.Fatalf("unable to read data: %v", err)
+	}
+	if ls := c.ExtraFields(); len(ls) > 0 {
+		t.Errorf("unexpected extra fields: %v", ls)
+	}
+
+	var w bytes.Buffer
+	if err := c.TSV(&w); err != nil {
+		t.Fatalf("unable to write TSV data: %v", err)
+	}
+	got := dna.New()
+	if err := got.ReadTSV(&w); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+
+	want := map[dna.Field]string{
+		dna.Quality: "38.2",
+		dna.Trace:   "traces/fmnh_2485.ab1",
+		dna.Run:     "run-2024-03",
+		dna.Primers: "L14724,H15915",
+	}
+	for f, v := range want {
+		if g := got.Val("fmnh_un_2485", "cytb", "MH290773", f); g != v {
+			t.Errorf("field %q: got %q, want %q", f, g, v)
+		}
+	}
+}
+
+func BenchmarkTSV(b *testing.B) {
+	_, c := largeDNAText(10_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.TSV(io.Discard); err != nil {
+			b.Fatalf("unable to write TSV data: %v", err)
+		}
+	}
+}
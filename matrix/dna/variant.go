@@ -0,0 +1,91 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// VariantSep is the separator
+// between the name of a gene
+// and the name of an alignment variant
+// (e.g., "cytb:mafft-linsi").
+const VariantSep = ":"
+
+// SplitVariant splits a gene name
+// into the name of the gene
+// and the name of the alignment variant.
+// If the name has no variant,
+// the variant is empty.
+func SplitVariant(name string) (gene, variant string) {
+	gene, variant, _ = strings.Cut(name, VariantSep)
+	return strings.TrimSpace(gene), strings.TrimSpace(variant)
+}
+
+// Variants returns the names of the genes
+// stored in the collection
+// that are versions of a given gene,
+// i.e.,
+// the gene itself
+// (usually the raw sequences)
+// and its alignment variants.
+func (c *Collection) Variants(gene string) []string {
+	gene, _ = SplitVariant(strings.ToLower(gene))
+	var ls []string
+	for _, g := range c.Genes() {
+		if b, _ := SplitVariant(g); b == gene {
+			ls = append(ls, g)
+		}
+	}
+	return ls
+}
+
+// SelectVariants returns a new collection
+// with a single version of each gene.
+// The selection is a map of a gene name
+// to the selected version
+// (either the gene or one of its alignment variants).
+// Genes without a selection
+// must have a single version.
+func (c *Collection) SelectVariants(sel map[string]string) (*Collection, error) {
+	versions := make(map[string]string, len(sel))
+	for g, v := range sel {
+		versions[strings.ToLower(strings.TrimSpace(g))] = strings.ToLower(strings.TrimSpace(v))
+	}
+
+	keep := make(map[string]bool)
+	done := make(map[string]bool)
+	for _, g := range c.Genes() {
+		gene, _ := SplitVariant(g)
+		if done[gene] {
+			continue
+		}
+		done[gene] = true
+
+		vs := c.Variants(gene)
+		v, ok := versions[gene]
+		if !ok {
+			if len(vs) > 1 {
+				return nil, fmt.Errorf("gene %q: multiple versions %v", gene, vs)
+			}
+			v = vs[0]
+		}
+		if !slices.Contains(vs, v) {
+			return nil, fmt.Errorf("gene %q: undefined version %q", gene, v)
+		}
+		keep[v] = true
+	}
+	for gene := range versions {
+		if !done[gene] {
+			return nil, fmt.Errorf("gene %q: not in the collection", gene)
+		}
+	}
+
+	return c.Filter(func(e Entry) bool {
+		return keep[e.Gene]
+	}), nil
+}
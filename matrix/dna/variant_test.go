@@ -0,0 +1,116 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dna_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/dna"
+)
+
+func TestSplitVariant(t *testing.T) {
+	tests := map[string]struct {
+		name    string
+		gene    string
+		variant string
+	}{
+		"gene":    {"cytb", "cytb", ""},
+		"variant": {"cytb:mafft-linsi", "cytb", "mafft-linsi"},
+		"spaces":  {" cytb : manual ", "cytb", "manual"},
+	}
+
+	for name, test := range tests {
+		g, v := dna.SplitVariant(test.name)
+		if g != test.gene || v != test.variant {
+			t.Errorf("%s: got %q %q, want %q %q", name, g, v, test.gene, test.variant)
+		}
+	}
+}
+
+func newVariants(t testing.TB) *dna.Collection {
+	t.Helper()
+
+	c := dna.New()
+	c.SetPolicy(dna.Reject)
+	seqs := []struct {
+		gene string
+		acc  string
+		seq  string
+	}{
+		{"cytb", "MN148748", "ccatccaacatctcagca"},
+		{"cytb:mafft-linsi", "MN148748", "ccatcc--aacatctcagca"},
+		{"cytb:manual", "MN148748", "ccatccaaca--tctcagca"},
+		{"coi", "MN148750", "atgaccccaatacgcaaa"},
+	}
+	for _, s := range seqs {
+		if err := c.Add("Loxodonta africana", "sp-01", s.gene, s.acc, s.seq); err != nil {
+			t.Fatalf("add %q: unexpected error: %v", s.gene, err)
+		}
+	}
+	return c
+}
+
+func TestVariants(t *testing.T) {
+	c := dna.New()
+	c.SetPolicy(dna.Reject)
+	if err := c.Add("Loxodonta africana", "sp-01", "cytb", "MN148748", "ccatccaacatctcagca"); err != nil {
+		t.Fatalf("add: unexpected error: %v", err)
+	}
+	if err := c.Add("Loxodonta africana", "sp-01", "cytb:manual", "MN148748", "ccatccaaca--tctcagca"); err != nil {
+		t.Errorf("reject: unexpected error on an alignment variant: %v", err)
+	}
+	if err := c.Add("Loxodonta africana", "sp-01", "coi:manual", "MN148748", "atgaccccaatacgcaaa"); err == nil {
+		t.Errorf("reject: expecting error on a different gene")
+	}
+
+	c = newVariants(t)
+	want := []string{"cytb", "cytb:mafft-linsi", "cytb:manual"}
+	if got := c.Variants("cytb"); !reflect.DeepEqual(got, want) {
+		t.Errorf("variants: got %v, want %v", got, want)
+	}
+	if got := c.Variants("coi"); !reflect.DeepEqual(got, []string{"coi"}) {
+		t.Errorf("variants: got %v, want %v", got, []string{"coi"})
+	}
+}
+
+func TestSelectVariants(t *testing.T) {
+	c := newVariants(t)
+
+	tests := map[string]struct {
+		sel   map[string]string
+		genes []string
+		err   bool
+	}{
+		"variant": {
+			sel:   map[string]string{"cytb": "cytb:manual"},
+			genes: []string{"coi", "cytb:manual"},
+		},
+		"raw": {
+			sel:   map[string]string{"CytB": "cytb", "coi": "coi"},
+			genes: []string{"coi", "cytb"},
+		},
+		"no selection":      {err: true},
+		"undefined variant": {sel: map[string]string{"cytb": "cytb:muscle"}, err: true},
+		"undefined gene":    {sel: map[string]string{"cytb": "cytb", "12s": "12s"}, err: true},
+	}
+
+	for name, test := range tests {
+		nc, err := c.SelectVariants(test.sel)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expecting error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if got := nc.Genes(); !reflect.DeepEqual(got, test.genes) {
+			t.Errorf("%s: genes: got %v, want %v", name, got, test.genes)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import "maps"
+
+// An Observation is a character state
+// observed in a specimen.
+type Observation struct {
+	Taxon     string
+	Specimen  string
+	Character string
+	State     string
+}
+
+// Filter returns a new matrix
+// with the observations for which keep returns true.
+// The additional fields of the kept observations
+// are copied.
+//
+// The states of the characters with kept observations
+// are the same as in the original matrix,
+// so the state codes are preserved
+// in the filtered matrix.
+func (m *Matrix) Filter(keep func(Observation) bool) *Matrix {
+	nm := New()
+	nm.headers = maps.Clone(m.headers)
+	nm.taxPolicy = m.taxPolicy
+	nm.symbols = m.symbols
+
+	for id, sp := range m.specs {
+		var nsp *specimen
+		for c, obs := range sp.obs {
+			var nobs map[string]*observation
+			for s, o := range obs {
+				ob := Observation{
+					Taxon:     sp.taxon,
+					Specimen:  sp.name,
+					Character: m.chars[c].name,
+					State:     o.name,
+				}
+				if !keep(ob) {
+					continue
+				}
+				if nobs == nil {
+					nobs = make(map[string]*observation)
+				}
+				no := *o
+				no.extra = maps.Clone(o.extra)
+				nobs[s] = &no
+			}
+			if nobs == nil {
+				continue
+			}
+
+			if nsp == nil {
+				nsp = &specimen{
+					taxon: sp.taxon,
+					name:  sp.name,
+					obs:   make(map[string]map[string]*observation),
+				}
+				nm.specs[id] = nsp
+				nm.addTaxSpec(sp.taxon, id)
+			}
+			nsp.obs[c] = nobs
+			if _, ok := nm.chars[c]; !ok {
+				nm.chars[c] = &character{
+					name:   m.chars[c].name,
+					states: maps.Clone(m.chars[c].states),
+				}
+			}
+		}
+	}
+	return nm
+}
@@ -0,0 +1,53 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix"
+)
+
+func TestFilter(t *testing.T) {
+	m := newMatrix()
+
+	f := m.Filter(func(o matrix.Observation) bool {
+		if o.Taxon == "Ascaphus truei" {
+			return false
+		}
+		return o.Character == "tail muscle" || o.Character == "ribs, fusion"
+	})
+
+	taxa := []string{"Bufonidae", "Discoglossidae", "Pipidae", "Ranidae", "Rhinophrynidae"}
+	if tx := f.Taxa(); !reflect.DeepEqual(tx, taxa) {
+		t.Errorf("taxa: got %v, want %v", tx, taxa)
+	}
+	chars := []string{"ribs, fusion", "tail muscle"}
+	if c := f.Chars(); !reflect.DeepEqual(c, chars) {
+		t.Errorf("characters: got %v, want %v", c, chars)
+	}
+	if st := f.States("tail muscle"); !reflect.DeepEqual(st, []string{"absent", "present"}) {
+		t.Errorf("states: got %v, want %v", st, []string{"absent", "present"})
+	}
+	if obs := f.Obs("kluge1969:rhinophrynidae", "ribs, fusion"); !reflect.DeepEqual(obs, []string{matrix.NotApplicable}) {
+		t.Errorf("observation: got %v, want %v", obs, []string{matrix.NotApplicable})
+	}
+	if obs := f.Obs("kluge1969:pipidae", "pectoral girdle"); !reflect.DeepEqual(obs, []string{matrix.Unknown}) {
+		t.Errorf("filtered observation: got %v, want %v", obs, []string{matrix.Unknown})
+	}
+	if v := f.Val("kluge1969:pipidae", "ribs, fusion", "fused in adults", matrix.Reference); v != "kluge1969" {
+		t.Errorf("reference: got %q, want %q", v, "kluge1969")
+	}
+
+	// the original matrix is not modified
+	f.Add("Pipidae", "kluge1969:pipidae", "tail muscle", "vestigial")
+	if obs := m.Obs("kluge1969:pipidae", "tail muscle"); !reflect.DeepEqual(obs, []string{"absent"}) {
+		t.Errorf("original observation: got %v, want %v", obs, []string{"absent"})
+	}
+	if tx := m.Taxa(); len(tx) != 6 {
+		t.Errorf("original taxa: got %d taxa, want %d", len(tx), 6)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Headers is a table of header synonyms
+// that maps the name of a column
+// to the name of a field of a TSV file.
+type Headers map[string]string
+
+// DefaultHeaders returns a table
+// with the common synonyms of the fields
+// used by other pipelines.
+func DefaultHeaders() Headers {
+	h := make(Headers)
+	for _, ls := range [][]string{
+		{"taxon", "species", "taxon name", "terminal"},
+		{"specimen", "voucher", "specimen id", "catalog number"},
+		{"character", "trait", "character name"},
+		{"state", "character state", "value"},
+	} {
+		h.add(ls[0], ls[1:]...)
+	}
+	return h
+}
+
+// ReadHeaders reads a table of header synonyms
+// from a tab-delimited file.
+// Each line of the file is a field,
+// the first value is the name of the field,
+// and the following values are its synonyms.
+// Empty lines,
+// or lines starting with '#' are ignored.
+//
+// Here is an example file:
+//
+//	# header synonyms
+//	taxon	species	terminal
+//	specimen	voucher
+func ReadHeaders(r io.Reader) (Headers, error) {
+	h := make(Headers)
+	br := bufio.NewReader(r)
+	for i := 1; ; i++ {
+		ln, err := br.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("on line %d: %v", i, err)
+		}
+		ln = strings.TrimSpace(ln)
+		if ln != "" && ln[0] != '#' {
+			fs := strings.Split(ln, "\t")
+			if headerName(fs[0]) == "" {
+				return nil, fmt.Errorf("on line %d: empty field name", i)
+			}
+			h.add(fs[0], fs[1:]...)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+	return h, nil
+}
+
+// Merge adds the synonyms of b
+// to the table.
+// If a synonym is in both tables,
+// the field of b
+// will be used.
+func (h Headers) Merge(b Headers) {
+	for k, v := range b {
+		h[k] = v
+	}
+}
+
+func (h Headers) add(field string, synonyms ...string) {
+	field = headerName(field)
+	for _, s := range synonyms {
+		s = headerName(s)
+		if s == "" || s == field {
+			continue
+		}
+		h[s] = field
+	}
+}
+
+// Fields returns the index of each field
+// of a header,
+// using the synonyms of the table
+// for the columns that are not a field name.
+// Columns with a field name
+// take precedence over the synonyms.
+func (h Headers) fields(head []string) map[string]int {
+	fields := make(map[string]int, len(head))
+	for i, c := range head {
+		if i == 0 {
+			c = strings.TrimPrefix(c, "\ufeff")
+		}
+		fields[strings.ToLower(strings.TrimSpace(c))] = i
+	}
+	for i, c := range head {
+		if i == 0 {
+			c = strings.TrimPrefix(c, "\ufeff")
+		}
+		f, ok := h[headerName(c)]
+		if !ok {
+			continue
+		}
+		if _, ok := fields[f]; ok {
+			continue
+		}
+		fields[f] = i
+	}
+	return fields
+}
+
+func headerName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// SetHeaders sets the table of header synonyms
+// used by the matrix
+// when reading a TSV file.
+func (m *Matrix) SetHeaders(h Headers) {
+	m.headers = h
+}
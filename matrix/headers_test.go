@@ -0,0 +1,47 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix"
+)
+
+func TestHeaders(t *testing.T) {
+	text := "Species\tVoucher\tcharacter\tstate\n" +
+		"Pipidae\tkluge1969:pipidae\ttail muscle\tabsent\n"
+
+	if err := matrix.New().ReadTSV(strings.NewReader(text)); err == nil {
+		t.Errorf("without synonyms: expecting error")
+	}
+
+	m := matrix.New()
+	m.SetHeaders(matrix.DefaultHeaders())
+	if err := m.ReadTSV(strings.NewReader(text)); err != nil {
+		t.Fatalf("unable to read data: %v", err)
+	}
+	if obs := m.Obs("kluge1969:pipidae", "tail muscle"); len(obs) != 1 || obs[0] != "absent" {
+		t.Errorf("observation: got %v, want %v", obs, []string{"absent"})
+	}
+
+	h, err := matrix.ReadHeaders(strings.NewReader("# synonyms\ncharacter\tfeature\n"))
+	if err != nil {
+		t.Fatalf("unable to read synonyms: %v", err)
+	}
+	h2 := matrix.DefaultHeaders()
+	h2.Merge(h)
+	text = "species\tspecimen\tfeature\tstate\n" +
+		"Pipidae\tkluge1969:pipidae\ttail muscle\tabsent\n"
+	m = matrix.New()
+	m.SetHeaders(h2)
+	if err := m.ReadTSV(strings.NewReader(text)); err != nil {
+		t.Fatalf("unable to read data: %v", err)
+	}
+	if obs := m.Obs("kluge1969:pipidae", "tail muscle"); len(obs) != 1 || obs[0] != "absent" {
+		t.Errorf("merged: got %v, want %v", obs, []string{"absent"})
+	}
+}
@@ -7,10 +7,12 @@
 package matrix
 
 import (
+	"fmt"
+	"maps"
 	"slices"
 	"strings"
-	"unicode"
-	"unicode/utf8"
+
+	"github.com/js-arias/phydata/names"
 )
 
 // Character states without data.
@@ -20,10 +22,33 @@ const Unknown = "<unknown>"
 // A Matrix is a phylogenetic data matrix,
 // a collection of taxa
 // and their character states.
+//
+// A Matrix is not safe for concurrent use,
+// even for reading,
+// as the sorted lists returned by the getters
+// are cached.
 type Matrix struct {
+	// sorted specimens of each taxon
 	taxon map[string][]string
 	chars map[string]*character
 	specs map[string]*specimen
+
+	// sorted lists built on demand,
+	// and discarded when the matrix is modified
+	sortedChars []string
+	sortedSpecs []string
+	sortedTaxa  []string
+
+	// header synonyms
+	headers Headers
+
+	taxPolicy TaxPolicy
+
+	// symbols for NEXUS output
+	symbols string
+
+	// problems found while reading
+	warnings []error
 }
 
 // New creates a new empty matrix.
@@ -35,39 +60,79 @@ func New() *Matrix {
 	}
 }
 
+// Clone returns a deep copy of the matrix,
+// so it can be modified
+// without changing the original matrix.
+func (m *Matrix) Clone() *Matrix {
+	nm := &Matrix{
+		taxon:     make(map[string][]string, len(m.taxon)),
+		chars:     make(map[string]*character, len(m.chars)),
+		specs:     make(map[string]*specimen, len(m.specs)),
+		headers:   maps.Clone(m.headers),
+		taxPolicy: m.taxPolicy,
+		symbols:   m.symbols,
+	}
+	for tx, specs := range m.taxon {
+		nm.taxon[tx] = slices.Clone(specs)
+	}
+	for id, c := range m.chars {
+		nm.chars[id] = &character{
+			name:   c.name,
+			states: maps.Clone(c.states),
+		}
+	}
+	for id, sp := range m.specs {
+		nsp := &specimen{
+			taxon: sp.taxon,
+			name:  sp.name,
+			obs:   make(map[string]map[string]*observation, len(sp.obs)),
+		}
+		for c, obs := range sp.obs {
+			nobs := make(map[string]*observation, len(obs))
+			for s, o := range obs {
+				no := *o
+				no.extra = maps.Clone(o.extra)
+				nobs[s] = &no
+			}
+			nsp.obs[c] = nobs
+		}
+		nm.specs[id] = nsp
+	}
+	return nm
+}
+
 // Add adds a new observation
 // (i.e., a character state) to the matrix
 // for a given taxon specimen,
 // and character.
-func (m *Matrix) Add(taxon, spec, char, state string) {
-	taxon = canon(taxon)
+//
+// It returns a *TaxonError if the specimen is already assigned
+// to a different taxon.
+func (m *Matrix) Add(taxon, spec, char, state string) error {
+	taxon = names.Taxon(taxon)
 	if taxon == "" {
-		return
+		return nil
 	}
 
-	spec = specID(spec)
+	spec = names.Specimen(spec)
 
 	char = strings.Join(strings.Fields(char), " ")
 	if char == "" {
-		return
+		return nil
 	}
 	char = strings.ToLower(char)
 
 	state = strings.Join(strings.Fields(state), " ")
 	if state == "" {
-		return
+		return nil
 	}
 	state = strings.ToLower(state)
 
-	c, ok := m.chars[char]
-	if !ok {
-		c = &character{
-			name:   char,
-			states: make(map[string]bool),
-		}
-		m.chars[char] = c
+	if sp, ok := m.specs[spec]; ok && sp.taxon != taxon {
+		return &TaxonError{Spec: spec, Taxon: taxon, Want: sp.taxon}
 	}
-	c.states[state] = true
+
+	m.addState(char, state)
 
 	sp, ok := m.specs[spec]
 	if !ok {
@@ -77,13 +142,9 @@ func (m *Matrix) Add(taxon, spec, char, state string) {
 			obs:   make(map[string]map[string]*observation),
 		}
 		m.specs[spec] = sp
-		txSp := m.taxon[taxon]
-		m.taxon[taxon] = append(txSp, spec)
-	}
-	if sp.taxon != taxon {
-		return
+		m.addTaxSpec(taxon, spec)
+		m.sortedSpecs = nil
 	}
-
 	obs, ok := sp.obs[char]
 	if !ok {
 		obs = make(map[string]*observation)
@@ -93,43 +154,82 @@ func (m *Matrix) Add(taxon, spec, char, state string) {
 		obs = make(map[string]*observation)
 	} else if state == Unknown {
 		delete(sp.obs, char)
-		return
+		return nil
 	} else if isNoObservation(obs) {
 		obs = make(map[string]*observation)
 	}
 
 	obs[state] = &observation{name: state}
 	sp.obs[char] = obs
+	return nil
+}
+
+// AddState adds a state to a character
+// without adding an observation.
+func (m *Matrix) addState(char, state string) {
+	if char == "" || state == "" {
+		return
+	}
+	c, ok := m.chars[char]
+	if !ok {
+		c = &character{
+			name:   char,
+			states: make(map[string]bool),
+		}
+		m.chars[char] = c
+		m.sortedChars = nil
+	}
+	if !c.states[state] {
+		c.states[state] = true
+		c.sorted = nil
+	}
+}
+
+// A TaxonError is the error returned
+// when a specimen is assigned
+// to a different taxon.
+type TaxonError struct {
+	Spec  string // the specimen
+	Taxon string // the new taxon
+	Want  string // the taxon of the specimen
+}
+
+func (e *TaxonError) Error() string {
+	return fmt.Sprintf("specimen %q: got taxon %q, want %q", e.Spec, e.Taxon, e.Want)
+}
+
+// Warnings returns the problems found
+// while reading the observations
+// from a TSV file
+// (e.g., a row with a specimen assigned
+// to a different taxon).
+// The rows with a problem are not added to the matrix.
+func (m *Matrix) Warnings() []error {
+	return slices.Clone(m.warnings)
 }
 
 // Chars returns the characters in the matrix.
 func (m *Matrix) Chars() []string {
-	chars := make([]string, 0, len(m.chars))
-	for _, c := range m.chars {
-		chars = append(chars, c.name)
+	if m.sortedChars == nil {
+		chars := make([]string, 0, len(m.chars))
+		for _, c := range m.chars {
+			chars = append(chars, c.name)
+		}
+		slices.Sort(chars)
+		m.sortedChars = chars
 	}
-	slices.Sort(chars)
-	return chars
+	return slices.Clone(m.sortedChars)
 }
 
 // Obs returns the states assigned for character
 // in a specimen.
 func (m *Matrix) Obs(spec, char string) []string {
-	spec = specID(spec)
-
-	sp, ok := m.specs[spec]
-	if !ok {
+	sp := m.spec(spec)
+	if sp == nil {
 		return []string{Unknown}
 	}
-
-	char = strings.Join(strings.Fields(char), " ")
-	if char == "" {
-		return []string{Unknown}
-	}
-	char = strings.ToLower(char)
-
-	obs, ok := sp.obs[char]
-	if !ok {
+	obs := sp.charObs(char)
+	if obs == nil {
 		return []string{Unknown}
 	}
 
@@ -143,60 +243,96 @@ func (m *Matrix) Obs(spec, char string) []string {
 
 // States returns the states of a character in the matrix.
 func (m *Matrix) States(char string) []string {
-	char = strings.Join(strings.Fields(char), " ")
-	if char == "" {
-		return nil
-	}
-	char = strings.ToLower(char)
 	c, ok := m.chars[char]
+	if !ok {
+		c, ok = m.chars[charName(char)]
+	}
 	if !ok {
 		return nil
 	}
 
-	states := make([]string, 0, len(c.states))
-	for s := range c.states {
-		if s == NotApplicable {
-			continue
+	if c.sorted == nil {
+		states := make([]string, 0, len(c.states))
+		for s := range c.states {
+			if s == NotApplicable {
+				continue
+			}
+			states = append(states, s)
 		}
-		states = append(states, s)
+		slices.Sort(states)
+		c.sorted = states
 	}
-	slices.Sort(states)
-	return states
+	return slices.Clone(c.sorted)
 }
 
 // Specimens returns the specimens in the matrix.
 func (m *Matrix) Specimens() []string {
-	specs := make([]string, 0, len(m.specs))
-	for _, t := range m.specs {
-		specs = append(specs, t.name)
+	if m.sortedSpecs == nil {
+		specs := make([]string, 0, len(m.specs))
+		for _, t := range m.specs {
+			specs = append(specs, t.name)
+		}
+		slices.Sort(specs)
+		m.sortedSpecs = specs
 	}
-	slices.Sort(specs)
-	return specs
+	return slices.Clone(m.sortedSpecs)
 }
 
 // Taxa returns the taxa defined in the matrix.
 func (m *Matrix) Taxa() []string {
-	taxa := make([]string, 0, len(m.taxon))
-	for n := range m.taxon {
-		taxa = append(taxa, n)
+	if m.sortedTaxa == nil {
+		taxa := make([]string, 0, len(m.taxon))
+		for n := range m.taxon {
+			taxa = append(taxa, n)
+		}
+		slices.Sort(taxa)
+		m.sortedTaxa = taxa
 	}
-	slices.Sort(taxa)
-	return taxa
+	return slices.Clone(m.sortedTaxa)
+}
+
+// RenameTaxon changes the name of a taxon.
+// If the new name is already in the matrix,
+// the specimens of the old taxon
+// will be merged into the new taxon.
+func (m *Matrix) RenameTaxon(old, name string) {
+	old = names.Taxon(old)
+	name = names.Taxon(name)
+	if name == "" || old == name {
+		return
+	}
+
+	specs, ok := m.taxon[old]
+	if !ok {
+		return
+	}
+	for _, spec := range specs {
+		m.specs[spec].taxon = name
+	}
+	specs = append(m.taxon[name], specs...)
+	slices.Sort(specs)
+	m.taxon[name] = specs
+	delete(m.taxon, old)
+	m.sortedTaxa = nil
 }
 
 // TaxSpec returns the specimens of a given taxon.
 func (m *Matrix) TaxSpec(name string) []string {
-	name = canon(name)
-	specs := m.taxon[name]
+	return slices.Clone(m.taxSpecs(name))
+}
+
+// AddTaxSpec adds a specimen
+// to the specimens of a taxon.
+func (m *Matrix) addTaxSpec(taxon, spec string) {
+	specs := m.taxon[taxon]
+	i, ok := slices.BinarySearch(specs, spec)
+	if ok {
+		return
+	}
 	if len(specs) == 0 {
-		return nil
+		m.sortedTaxa = nil
 	}
-
-	sp := make([]string, len(specs))
-	copy(sp, specs)
-	slices.Sort(sp)
-
-	return sp
+	m.taxon[taxon] = slices.Insert(specs, i, spec)
 }
 
 // Field is used to define additional information fields
@@ -208,37 +344,45 @@ const (
 	Reference Field = "reference"
 	ImageLink Field = "image"
 	Comments  Field = "comments"
+	Reviewer  Field = "reviewer"
+	AddedBy   Field = "added-by"
+	Timestamp Field = "timestamp"
+
+	// Uncertain is used for observations
+	// in which the assignment of the state
+	// is doubtful.
+	// Its value is either "true" or empty.
+	Uncertain Field = "uncertain"
+
+	// Source is the source of the coding
+	// of an observation
+	// (see Observed, Literature, and Inferred).
+	Source Field = "source"
 )
 
-// Set sets the value of an addition information
-// for an observation.
-func (m *Matrix) Set(spec, char, state, val string, field Field) {
-	spec = specID(spec)
-
-	sp, ok := m.specs[spec]
-	if !ok {
-		return
-	}
-
-	char = strings.Join(strings.Fields(char), " ")
-	if char == "" {
-		return
-	}
-	char = strings.ToLower(char)
+// Valid values for the Source field.
+const (
+	// Observed is used for first-hand observations.
+	Observed = "observed"
 
-	obsMap, ok := sp.obs[char]
-	if !ok {
-		return
-	}
+	// Literature is used for codings
+	// taken from the literature.
+	Literature = "literature"
 
-	state = strings.Join(strings.Fields(state), " ")
-	if state == "" {
-		return
-	}
-	state = strings.ToLower(state)
+	// Inferred is used for codings inferred
+	// from other specimens,
+	// or from the ontogeny.
+	Inferred = "inferred"
+)
 
-	obs, ok := obsMap[state]
-	if !ok {
+// Set sets the value of an addition information
+// for an observation.
+// Fields not defined by the matrix
+// are stored as opaque values,
+// and an empty value removes them.
+func (m *Matrix) Set(spec, char, state, val string, field Field) {
+	obs := m.observation(spec, char, state)
+	if obs == nil {
 		return
 	}
 
@@ -251,38 +395,37 @@ func (m *Matrix) Set(spec, char, state, val string, field Field) {
 		obs.img = val
 	case Comments:
 		obs.comment = val
+	case Reviewer:
+		obs.reviewer = val
+	case AddedBy:
+		obs.addedBy = val
+	case Timestamp:
+		obs.timestamp = val
+	case Uncertain:
+		obs.uncertain = strings.ToLower(val) == "true"
+	case Source:
+		obs.source = strings.ToLower(val)
+	default:
+		name := headerName(string(field))
+		if name == "" {
+			return
+		}
+		if val == "" {
+			delete(obs.extra, name)
+			return
+		}
+		if obs.extra == nil {
+			obs.extra = make(map[string]string)
+		}
+		obs.extra[name] = val
 	}
 }
 
 // Val returns the value of additional fields
 // for an observation.
 func (m *Matrix) Val(spec, char, state string, field Field) string {
-	spec = specID(spec)
-
-	sp, ok := m.specs[spec]
-	if !ok {
-		return ""
-	}
-
-	char = strings.Join(strings.Fields(char), " ")
-	if char == "" {
-		return ""
-	}
-	char = strings.ToLower(char)
-
-	obsMap, ok := sp.obs[char]
-	if !ok {
-		return ""
-	}
-
-	state = strings.Join(strings.Fields(state), " ")
-	if state == "" {
-		return ""
-	}
-	state = strings.ToLower(state)
-
-	obs, ok := obsMap[state]
-	if !ok {
+	obs := m.observation(spec, char, state)
+	if obs == nil {
 		return ""
 	}
 
@@ -293,13 +436,55 @@ func (m *Matrix) Val(spec, char, state string, field Field) string {
 		return obs.img
 	case Comments:
 		return obs.comment
+	case Reviewer:
+		return obs.reviewer
+	case AddedBy:
+		return obs.addedBy
+	case Timestamp:
+		return obs.timestamp
+	case Uncertain:
+		if obs.uncertain {
+			return "true"
+		}
+		return ""
+	case Source:
+		return obs.source
 	}
-	return ""
+	return obs.extra[headerName(string(field))]
+}
+
+// ExtraFields returns the names of the fields
+// that are not defined by the matrix
+// (e.g., the additional columns of a TSV file
+// added by a collaborator)
+// and that have a value in at least one observation.
+func (m *Matrix) ExtraFields() []Field {
+	fields := make(map[string]bool)
+	for _, sp := range m.specs {
+		for _, obs := range sp.obs {
+			for _, o := range obs {
+				for f := range o.extra {
+					fields[f] = true
+				}
+			}
+		}
+	}
+
+	ls := make([]Field, 0, len(fields))
+	for f := range fields {
+		ls = append(ls, Field(f))
+	}
+	slices.Sort(ls)
+	return ls
 }
 
 type character struct {
 	name   string
 	states map[string]bool
+
+	// sorted states (without NotApplicable),
+	// built on demand
+	sorted []string
 }
 
 type specimen struct {
@@ -309,38 +494,85 @@ type specimen struct {
 }
 
 type observation struct {
-	name    string
-	ref     string // bibliographic reference
-	img     string // a link to an image
-	comment string // a commentary of the observation
+	name      string
+	ref       string // bibliographic reference
+	img       string // a link to an image
+	comment   string // a commentary of the observation
+	reviewer  string // the reviewer of the observation
+	addedBy   string // the person that added the observation
+	timestamp string // the time in which the observation was added
+	uncertain bool   // the observation is doubtful
+	source    string // the source of the coding
+
+	// extra stores the values of fields
+	// unknown to the matrix
+	extra map[string]string
 }
 
-func isNoObservation(obs map[string]*observation) bool {
-	if _, ok := obs[NotApplicable]; ok {
-		return true
+// The following functions look up the elements of the matrix.
+// As the matrix stores normalized names,
+// a name is normalized only if it is not found,
+// so the names returned by the matrix
+// (the usual case in loops over the matrix)
+// are not normalized again.
+
+// Spec returns a specimen,
+// or nil if it is not in the matrix.
+func (m *Matrix) spec(id string) *specimen {
+	if sp, ok := m.specs[id]; ok {
+		return sp
 	}
-	if _, ok := obs[Unknown]; ok {
-		return true
+	return m.specs[names.Specimen(id)]
+}
+
+// TaxSpec returns the specimens of a taxon
+// (the returned slice must not be modified).
+func (m *Matrix) taxSpecs(taxon string) []string {
+	if specs, ok := m.taxon[taxon]; ok {
+		return specs
 	}
-	return false
+	return m.taxon[names.Taxon(taxon)]
 }
 
-// Canon returns a taxon name
-// in its canonical form.
-func canon(name string) string {
-	name = strings.Join(strings.Fields(name), " ")
-	if name == "" {
-		return ""
+// Observation returns an observation,
+// or nil if it is not in the matrix.
+func (m *Matrix) observation(spec, char, state string) *observation {
+	sp := m.spec(spec)
+	if sp == nil {
+		return nil
 	}
-	name = strings.ToLower(name)
-	r, n := utf8.DecodeRuneInString(name)
-	return string(unicode.ToUpper(r)) + name[n:]
+	obs := sp.charObs(char)
+	if obs == nil {
+		return nil
+	}
+	if o, ok := obs[state]; ok {
+		return o
+	}
+	return obs[charName(state)]
 }
 
-func specID(spec string) string {
-	spec = strings.Join(strings.Fields(spec), "_")
-	if spec == "" {
-		return ""
+// CharObs returns the observed states
+// of a character in a specimen,
+// or nil if the character is not observed.
+func (sp *specimen) charObs(char string) map[string]*observation {
+	if obs, ok := sp.obs[char]; ok {
+		return obs
+	}
+	return sp.obs[charName(char)]
+}
+
+// CharName normalizes the name of a character,
+// or a character state.
+func charName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func isNoObservation(obs map[string]*observation) bool {
+	if _, ok := obs[NotApplicable]; ok {
+		return true
 	}
-	return strings.ToLower(spec)
+	if _, ok := obs[Unknown]; ok {
+		return true
+	}
+	return false
 }
@@ -5,7 +5,9 @@
 package matrix_test
 
 import (
+	"fmt"
 	"reflect"
+	"slices"
 	"testing"
 
 	"github.com/js-arias/phydata/matrix"
@@ -116,6 +118,75 @@ func TestMatrix(t *testing.T) {
 	}
 }
 
+func TestRenameTaxon(t *testing.T) {
+	m := newMatrix()
+
+	m.RenameTaxon("Rhinophrynidae", "Pipidae")
+	taxa := []string{"Ascaphus truei", "Bufonidae", "Discoglossidae", "Pipidae", "Ranidae"}
+	if tx := m.Taxa(); !reflect.DeepEqual(tx, taxa) {
+		t.Errorf("taxa: got %v, want %v", tx, taxa)
+	}
+	specs := []string{"kluge1969:pipidae", "kluge1969:rhinophrynidae"}
+	if sp := m.TaxSpec("Pipidae"); !reflect.DeepEqual(sp, specs) {
+		t.Errorf("specimens of %q: got %v, want %v", "Pipidae", sp, specs)
+	}
+
+	m.RenameTaxon("ascaphus truei", "Ascaphus montanus")
+	if sp := m.TaxSpec("Ascaphus montanus"); !reflect.DeepEqual(sp, []string{"kluge1969:ascaphus_truei"}) {
+		t.Errorf("specimens of %q: got %v, want %v", "Ascaphus montanus", sp, []string{"kluge1969:ascaphus_truei"})
+	}
+	if obs := m.Obs("kluge1969:ascaphus_truei", "tail muscle"); !reflect.DeepEqual(obs, []string{"present"}) {
+		t.Errorf("renamed observation: got %v, want %v", obs, []string{"present"})
+	}
+}
+
+func TestAddTaxonMismatch(t *testing.T) {
+	m := newMatrix()
+
+	if err := m.Add("Ranidae", "kluge1969:Pipidae", "tail muscle", "vestigial"); err == nil {
+		t.Errorf("add: expecting error on specimen with a different taxon")
+	}
+	if obs := m.Obs("kluge1969:pipidae", "tail muscle"); !reflect.DeepEqual(obs, []string{"absent"}) {
+		t.Errorf("observation: got %v, want %v", obs, []string{"absent"})
+	}
+	if st := m.States("tail muscle"); !reflect.DeepEqual(st, []string{"absent", "present"}) {
+		t.Errorf("states: got %v, want %v", st, []string{"absent", "present"})
+	}
+
+	if err := m.Add("pipidae", "kluge1969:Pipidae", "tail muscle", "present"); err != nil {
+		t.Errorf("add: unexpected error: %v", err)
+	}
+}
+
+func TestClone(t *testing.T) {
+	m := newMatrixWithComments()
+	m.Set("kluge1969:pipidae", "tail muscle", "absent", "B-12", "drawer")
+
+	c := m.Clone()
+	cmpMatrix(t, c, m)
+
+	c.Add("Pipidae", "kluge1969:pipidae", "tail muscle", "vestigial")
+	c.Set("kluge1969:pipidae", "tail muscle", "absent", "clone", matrix.Comments)
+	c.Set("kluge1969:pipidae", "tail muscle", "absent", "A-01", "drawer")
+	c.RenameTaxon("Ranidae", "Bufonidae")
+
+	if obs := m.Obs("kluge1969:pipidae", "tail muscle"); !reflect.DeepEqual(obs, []string{"absent"}) {
+		t.Errorf("original observation: got %v, want %v", obs, []string{"absent"})
+	}
+	if st := m.States("tail muscle"); !reflect.DeepEqual(st, []string{"absent", "present"}) {
+		t.Errorf("original states: got %v, want %v", st, []string{"absent", "present"})
+	}
+	if v := m.Val("kluge1969:pipidae", "tail muscle", "absent", matrix.Comments); v == "clone" {
+		t.Errorf("original comment modified by the clone")
+	}
+	if v := m.Val("kluge1969:pipidae", "tail muscle", "absent", "drawer"); v != "B-12" {
+		t.Errorf("original extra field: got %q, want %q", v, "B-12")
+	}
+	if sp := m.TaxSpec("Ranidae"); len(sp) == 0 {
+		t.Errorf("original taxon %q renamed by the clone", "Ranidae")
+	}
+}
+
 func newMatrix() *matrix.Matrix {
 	m := matrix.New()
 
@@ -190,6 +261,10 @@ func newMatrixWithComments() *matrix.Matrix {
 	m := newMatrix()
 	m.Set("kluge1969:Ascaphus truei", "tail muscle", "present", "ascaphus-tail.png", matrix.ImageLink)
 	m.Set("kluge1969:Ascaphus truei", "tail muscle", "present", "it might be not homologous with tail muscles of salamanders", matrix.Comments)
+	m.Set("kluge1969:Ascaphus truei", "tail muscle", "present", "J. S. Arias", matrix.AddedBy)
+	m.Set("kluge1969:Ascaphus truei", "tail muscle", "present", "2024-03-15T10:30:00Z", matrix.Timestamp)
+	m.Set("kluge1969:Pipidae", "ribs, fusion", "fused in adults", "true", matrix.Uncertain)
+	m.Set("kluge1969:Pipidae", "ribs, fusion", "fused in adults", matrix.Inferred, matrix.Source)
 
 	return m
 }
@@ -217,7 +292,7 @@ func cmpMatrix(t testing.TB, got, want *matrix.Matrix) {
 		}
 	}
 
-	fields := []matrix.Field{matrix.Reference, matrix.ImageLink, matrix.Comments}
+	fields := []matrix.Field{matrix.Reference, matrix.ImageLink, matrix.Comments, matrix.Reviewer, matrix.AddedBy, matrix.Timestamp, matrix.Uncertain, matrix.Source}
 
 	for _, sn := range specs {
 		for _, cn := range chars {
@@ -251,3 +326,105 @@ func cmpMatrix(t testing.TB, got, want *matrix.Matrix) {
 		}
 	}
 }
+
+func TestSortedLists(t *testing.T) {
+	m := newMatrix()
+
+	// modifying a returned list
+	// does not change the matrix
+	chars := m.Chars()
+	chars[0] = "zzz"
+	if got := m.Chars(); got[0] == "zzz" {
+		t.Errorf("chars: list changed by the caller: %v", got)
+	}
+
+	// lists are updated after a change
+	m.Add("Rana pipiens", "spec:rana", "aaa new char", "present")
+	if got := m.Chars(); got[0] != "aaa new char" {
+		t.Errorf("chars: got %v, want %q as first character", got, "aaa new char")
+	}
+	m.Add("Rana pipiens", "spec:rana", "aaa new char", "absent")
+	if got, want := m.States("aaa new char"), []string{"absent", "present"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("states: got %v, want %v", got, want)
+	}
+	if got := m.Specimens(); !slices.Contains(got, "spec:rana") {
+		t.Errorf("specimens: %q not in %v", "spec:rana", got)
+	}
+	if got := m.Taxa(); !slices.Contains(got, "Rana pipiens") {
+		t.Errorf("taxa: %q not in %v", "Rana pipiens", got)
+	}
+	m.RenameTaxon("Rana pipiens", "Lithobates pipiens")
+	if got := m.Taxa(); slices.Contains(got, "Rana pipiens") || !slices.Contains(got, "Lithobates pipiens") {
+		t.Errorf("taxa: got %v, after renaming %q", got, "Rana pipiens")
+	}
+}
+
+// LargeMatrix returns a matrix with the given number of taxa
+// (with two specimens each),
+// and characters
+// (with three states each).
+func largeMatrix(taxa, chars int) *matrix.Matrix {
+	m := matrix.New()
+	for t := 0; t < taxa; t++ {
+		tax := fmt.Sprintf("Taxon %d", t)
+		for s := 0; s < 2; s++ {
+			spec := fmt.Sprintf("spec:%d-%d", t, s)
+			for c := 0; c < chars; c++ {
+				m.Add(tax, spec, fmt.Sprintf("char %d", c), fmt.Sprintf("state %d", (t+s+c)%3))
+			}
+		}
+	}
+	return m
+}
+
+func BenchmarkObs(b *testing.B) {
+	m := largeMatrix(100, 100)
+	specs := m.Specimens()
+	chars := m.Chars()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, sp := range specs {
+			for _, c := range chars {
+				m.Obs(sp, c)
+			}
+		}
+	}
+}
+
+func BenchmarkStates(b *testing.B) {
+	m := largeMatrix(100, 1000)
+	chars := m.Chars()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, c := range chars {
+			m.States(c)
+		}
+	}
+}
+
+func BenchmarkTaxSpec(b *testing.B) {
+	m := largeMatrix(1000, 10)
+	taxa := m.Taxa()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, tx := range taxa {
+			m.TaxSpec(tx)
+		}
+	}
+}
+
+func BenchmarkChars(b *testing.B) {
+	m := largeMatrix(100, 1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Chars()
+	}
+}
+
+func BenchmarkTaxa(b *testing.B) {
+	m := largeMatrix(1000, 10)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Taxa()
+	}
+}
@@ -0,0 +1,473 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/phydata/names"
+)
+
+// ReadNeXML reads a character matrix from a NeXML file
+// (see <http://nexml.org>).
+// It require an ID for a bibliographic reference,
+// that will be used as the prefix
+// for the specimen identifiers.
+//
+// Only the first characters block
+// with standard (morphological) characters
+// is read.
+// Blocks with molecular data are ignored.
+func (m *Matrix) ReadNeXML(r io.Reader, ref string) error {
+	var doc nexmlDoc
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return fmt.Errorf("while reading NeXML: %v", err)
+	}
+
+	otus := doc.otuLabels()
+	for _, b := range doc.Characters {
+		dt := strings.ToLower(b.Type)
+		if i := strings.Index(dt, ":"); i >= 0 {
+			dt = dt[i+1:]
+		}
+		if !strings.HasPrefix(dt, "standard") {
+			continue
+		}
+		return m.readNeXMLChars(b, otus, ref)
+	}
+	return nil
+}
+
+// An Annotator returns the IRIs
+// of the ontology terms
+// of characters and states.
+type Annotator interface {
+	// CharTerm returns the IRI of a character
+	// (e.g., an UBERON anatomical entity).
+	CharTerm(char string) string
+
+	// StateTerm returns the IRI of a character state
+	// (e.g., a PATO quality).
+	StateTerm(char, state string) string
+}
+
+// NeXML writes an observation matrix as a NeXML file
+// (see <http://nexml.org>),
+// with a StandardCells characters block.
+//
+// If an annotator is given,
+// the characters and states with ontology terms
+// will be annotated using the 'is about' relation
+// (IAO:0000136)
+// as in the files used by Phenoscape.
+//
+// Polymorphic observations are written
+// as polymorphic state sets,
+// and if a taxon has only uncertain observations,
+// as an uncertain state set
+// of all the states of the character.
+func (m *Matrix) NeXML(w io.Writer, ann Annotator) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(bw, "<nex:nexml version=\"0.9\" generator=\"phydata\"\n")
+	fmt.Fprintf(bw, "\txmlns=\"http://www.nexml.org/2009\"\n")
+	fmt.Fprintf(bw, "\txmlns:nex=\"http://www.nexml.org/2009\"\n")
+	fmt.Fprintf(bw, "\txmlns:obo=\"http://purl.obolibrary.org/obo/\"\n")
+	fmt.Fprintf(bw, "\txmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\">\n")
+
+	// otus
+	taxa := m.Taxa()
+	fmt.Fprintf(bw, "\t<otus id=\"otus1\">\n")
+	for i, tx := range taxa {
+		fmt.Fprintf(bw, "\t\t<otu id=\"otu%d\" label=\"%s\"/>\n", i+1, xmlAttr(tx))
+	}
+	fmt.Fprintf(bw, "\t</otus>\n")
+
+	// characters
+	chars := m.Chars()
+	fmt.Fprintf(bw, "\t<characters id=\"chars1\" otus=\"otus1\" xsi:type=\"nex:StandardCells\">\n")
+	fmt.Fprintf(bw, "\t\t<format>\n")
+	cells := make([]map[string]string, len(chars))
+	for i, c := range chars {
+		cells[i] = m.writeNeXMLStates(bw, ann, i+1, c, taxa)
+	}
+	for i, c := range chars {
+		fmt.Fprintf(bw, "\t\t\t<char id=\"c%d\" states=\"states%d\" label=\"%s\"", i+1, i+1, xmlAttr(c))
+		if ann == nil || ann.CharTerm(c) == "" {
+			fmt.Fprintf(bw, "/>\n")
+			continue
+		}
+		fmt.Fprintf(bw, ">\n")
+		writeNeXMLMeta(bw, "\t\t\t\t", ann.CharTerm(c))
+		fmt.Fprintf(bw, "\t\t\t</char>\n")
+	}
+	fmt.Fprintf(bw, "\t\t</format>\n")
+
+	// matrix
+	fmt.Fprintf(bw, "\t\t<matrix>\n")
+	for i, tx := range taxa {
+		fmt.Fprintf(bw, "\t\t\t<row id=\"row%d\" otu=\"otu%d\">\n", i+1, i+1)
+		for j := range chars {
+			st, ok := cells[j][tx]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(bw, "\t\t\t\t<cell char=\"c%d\" state=\"%s\"/>\n", j+1, st)
+		}
+		fmt.Fprintf(bw, "\t\t\t</row>\n")
+	}
+	fmt.Fprintf(bw, "\t\t</matrix>\n")
+	fmt.Fprintf(bw, "\t</characters>\n")
+	fmt.Fprintf(bw, "</nex:nexml>\n")
+
+	return bw.Flush()
+}
+
+// WriteNeXMLStates writes the state definitions
+// of a character,
+// and returns the ID of the state (or state set)
+// of each taxon.
+func (m *Matrix) writeNeXMLStates(w io.Writer, ann Annotator, num int, char string, taxa []string) map[string]string {
+	states := m.States(char)
+	ids := make(map[string]string, len(states))
+
+	fmt.Fprintf(w, "\t\t\t<states id=\"states%d\">\n", num)
+	for i, s := range states {
+		id := fmt.Sprintf("s%d.%d", num, i)
+		ids[s] = id
+		fmt.Fprintf(w, "\t\t\t\t<state id=\"%s\" symbol=\"%d\" label=\"%s\"", id, i, xmlAttr(s))
+		if ann == nil || ann.StateTerm(char, s) == "" {
+			fmt.Fprintf(w, "/>\n")
+			continue
+		}
+		fmt.Fprintf(w, ">\n")
+		writeNeXMLMeta(w, "\t\t\t\t\t", ann.StateTerm(char, s))
+		fmt.Fprintf(w, "\t\t\t\t</state>\n")
+	}
+
+	// state sets
+	sym := len(states)
+	cells := make(map[string]string, len(taxa))
+	sets := make(map[string]string)
+	setDefs := make(map[string][]string)
+	addSet := func(kind string, members []string) string {
+		key := kind + ":" + strings.Join(members, "\t")
+		if id, ok := sets[key]; ok {
+			return id
+		}
+		symbol := strconv.Itoa(sym)
+		id := fmt.Sprintf("s%d.%d", num, sym)
+		if len(members) == 0 {
+			// inapplicable characters
+			symbol = "-"
+			id = fmt.Sprintf("s%d.gap", num)
+		} else {
+			sym++
+		}
+		sets[key] = id
+
+		def := &strings.Builder{}
+		fmt.Fprintf(def, "\t\t\t\t<%s id=\"%s\" symbol=\"%s\">\n", kind, id, symbol)
+		for _, mb := range members {
+			fmt.Fprintf(def, "\t\t\t\t\t<member state=\"%s\"/>\n", ids[mb])
+		}
+		fmt.Fprintf(def, "\t\t\t\t</%s>\n", kind)
+		setDefs[kind] = append(setDefs[kind], def.String())
+		return id
+	}
+
+	for _, tx := range taxa {
+		na := false
+		unc := false
+		var obs []string
+		seen := make(map[string]bool)
+		for _, sp := range m.TaxSpec(tx) {
+			for _, o := range m.Obs(sp, char) {
+				if o == NotApplicable {
+					na = true
+					continue
+				}
+				if o == Unknown {
+					continue
+				}
+				if m.Val(sp, char, o, Uncertain) == "true" {
+					unc = true
+					continue
+				}
+				if seen[o] {
+					continue
+				}
+				seen[o] = true
+				obs = append(obs, o)
+			}
+		}
+		switch {
+		case len(obs) == 1:
+			cells[tx] = ids[obs[0]]
+		case len(obs) > 1:
+			var members []string
+			for _, s := range states {
+				if seen[s] {
+					members = append(members, s)
+				}
+			}
+			cells[tx] = addSet("polymorphic_state_set", members)
+		case unc && len(states) > 1:
+			cells[tx] = addSet("uncertain_state_set", states)
+		case unc:
+			cells[tx] = ids[states[0]]
+		case na:
+			cells[tx] = addSet("uncertain_state_set", nil)
+		}
+	}
+	// polymorphic sets must be defined before uncertain sets
+	for _, kind := range []string{"polymorphic_state_set", "uncertain_state_set"} {
+		for _, d := range setDefs[kind] {
+			fmt.Fprintf(w, "%s", d)
+		}
+	}
+	fmt.Fprintf(w, "\t\t\t</states>\n")
+	return cells
+}
+
+// WriteNeXMLMeta writes an ontology annotation
+// using the 'is about' relation.
+func writeNeXMLMeta(w io.Writer, indent, iri string) {
+	fmt.Fprintf(w, "%s<meta xsi:type=\"nex:ResourceMeta\" rel=\"obo:IAO_0000136\" href=\"%s\"/>\n", indent, xmlAttr(iri))
+}
+
+// XMLAttr escapes a string
+// to be used as an XML attribute.
+func xmlAttr(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func (m *Matrix) readNeXMLChars(b nexmlChars, otus map[string]string, ref string) error {
+	// character definitions
+	sets := make(map[string]nexmlStates, len(b.Format.States))
+	for _, s := range b.Format.States {
+		sets[s.ID] = s
+	}
+	chars := make(map[string]int, len(b.Format.Chars))
+	for i, c := range b.Format.Chars {
+		chars[c.ID] = i
+	}
+	charName := func(i int) string {
+		var n string
+		if i < len(b.Format.Chars) {
+			n = b.Format.Chars[i].Label
+		}
+		n = strings.Join(strings.Fields(strings.ReplaceAll(n, "_", " ")), " ")
+		if n == "" {
+			n = fmt.Sprintf("char %d", i+1)
+		}
+		return n
+	}
+
+	for _, row := range b.Matrix.Rows {
+		tax, ok := otus[row.OTU]
+		if !ok {
+			return fmt.Errorf("while reading NeXML: row %q: undefined OTU %q", row.ID, row.OTU)
+		}
+		tax = names.Taxon(strings.ReplaceAll(tax, "_", " "))
+		if tax == "" {
+			continue
+		}
+		spec := names.Specimen(ref + ":" + tax)
+
+		for _, cell := range row.Cells {
+			i, ok := chars[cell.Char]
+			if !ok {
+				return fmt.Errorf("while reading NeXML: taxon %q: undefined character %q", tax, cell.Char)
+			}
+			st := sets[b.Format.Chars[i].States]
+			if err := m.addNeXMLState(tax, spec, charName(i), ref, st, st.byID(cell.State)); err != nil {
+				return fmt.Errorf("while reading NeXML: taxon %q: %v", tax, err)
+			}
+		}
+
+		if row.Seq == "" {
+			continue
+		}
+		symbols := strings.Fields(row.Seq)
+		if len(symbols) == 1 && len(b.Format.Chars) != 1 {
+			symbols = strings.Split(symbols[0], "")
+		}
+		for i, sym := range symbols {
+			var st nexmlStates
+			if i < len(b.Format.Chars) {
+				st = sets[b.Format.Chars[i].States]
+			}
+			if err := m.addNeXMLState(tax, spec, charName(i), ref, st, st.bySymbol(sym)); err != nil {
+				return fmt.Errorf("while reading NeXML: taxon %q: %v", tax, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Matrix) addNeXMLState(tax, spec, char, ref string, set nexmlStates, st nexmlState) error {
+	switch st.Symbol {
+	case "?":
+		return m.Add(tax, spec, char, Unknown)
+	case "-":
+		if err := m.Add(tax, spec, char, NotApplicable); err != nil {
+			return err
+		}
+		m.Set(spec, char, NotApplicable, ref, Reference)
+		return nil
+	}
+
+	if len(st.Members) == 0 {
+		if st.ID == "" {
+			// undefined or missing state
+			return m.Add(tax, spec, char, Unknown)
+		}
+		sName := stateName(st)
+		if err := m.Add(tax, spec, char, sName); err != nil {
+			return err
+		}
+		m.Set(spec, char, sName, ref, Reference)
+		return nil
+	}
+
+	// polymorphic or uncertain states
+	for _, mb := range st.Members {
+		ms := set.byID(mb.State)
+		if ms.ID == "" {
+			continue
+		}
+		sName := stateName(ms)
+		if err := m.Add(tax, spec, char, sName); err != nil {
+			return err
+		}
+		m.Set(spec, char, sName, ref, Reference)
+	}
+	return nil
+}
+
+func stateName(st nexmlState) string {
+	n := strings.Join(strings.Fields(strings.ReplaceAll(st.Label, "_", " ")), " ")
+	if n != "" {
+		return n
+	}
+	return "state " + st.Symbol
+}
+
+type nexmlDoc struct {
+	OTUs       []nexmlOTUs  `xml:"otus"`
+	Characters []nexmlChars `xml:"characters"`
+}
+
+// OTULabels returns a map of OTU IDs
+// to OTU labels.
+func (d nexmlDoc) otuLabels() map[string]string {
+	otus := make(map[string]string)
+	for _, ls := range d.OTUs {
+		for _, o := range ls.OTU {
+			l := o.Label
+			if l == "" {
+				l = o.ID
+			}
+			otus[o.ID] = l
+		}
+	}
+	return otus
+}
+
+type nexmlOTUs struct {
+	OTU []nexmlOTU `xml:"otu"`
+}
+
+type nexmlOTU struct {
+	ID    string `xml:"id,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type nexmlChars struct {
+	ID     string      `xml:"id,attr"`
+	Label  string      `xml:"label,attr"`
+	Type   string      `xml:"type,attr"`
+	Format nexmlFormat `xml:"format"`
+	Matrix nexmlMatrix `xml:"matrix"`
+}
+
+type nexmlFormat struct {
+	States []nexmlStates `xml:"states"`
+	Chars  []nexmlChar   `xml:"char"`
+}
+
+type nexmlStates struct {
+	ID        string       `xml:"id,attr"`
+	State     []nexmlState `xml:"state"`
+	Polymorph []nexmlState `xml:"polymorphic_state_set"`
+	Uncertain []nexmlState `xml:"uncertain_state_set"`
+}
+
+func (s nexmlStates) byID(id string) nexmlState {
+	for _, ls := range [][]nexmlState{s.State, s.Polymorph, s.Uncertain} {
+		for _, st := range ls {
+			if st.ID == id {
+				return st
+			}
+		}
+	}
+	return nexmlState{}
+}
+
+func (s nexmlStates) bySymbol(sym string) nexmlState {
+	for _, ls := range [][]nexmlState{s.State, s.Polymorph, s.Uncertain} {
+		for _, st := range ls {
+			if st.Symbol == sym {
+				return st
+			}
+		}
+	}
+	if sym == "?" || sym == "-" {
+		return nexmlState{Symbol: sym}
+	}
+	return nexmlState{}
+}
+
+type nexmlState struct {
+	ID      string        `xml:"id,attr"`
+	Symbol  string        `xml:"symbol,attr"`
+	Label   string        `xml:"label,attr"`
+	Members []nexmlMember `xml:"member"`
+}
+
+type nexmlMember struct {
+	State string `xml:"state,attr"`
+}
+
+type nexmlChar struct {
+	ID     string `xml:"id,attr"`
+	Label  string `xml:"label,attr"`
+	States string `xml:"states,attr"`
+}
+
+type nexmlMatrix struct {
+	Rows []nexmlRow `xml:"row"`
+}
+
+type nexmlRow struct {
+	ID    string      `xml:"id,attr"`
+	OTU   string      `xml:"otu,attr"`
+	Cells []nexmlCell `xml:"cell"`
+	Seq   string      `xml:"seq"`
+}
+
+type nexmlCell struct {
+	Char  string `xml:"char,attr"`
+	State string `xml:"state,attr"`
+}
@@ -0,0 +1,184 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix"
+)
+
+var nexmlMatrix = `<?xml version="1.0" encoding="UTF-8"?>
+<nex:nexml xmlns:nex="http://www.nexml.org/2009" xmlns="http://www.nexml.org/2009" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" version="0.9">
+	<otus id="Tls1" label="Taxa">
+		<otu id="t1" label="Ascaphus truei"/>
+		<otu id="t2" label="Bufonidae"/>
+		<otu id="t3" label="Discoglossidae"/>
+		<otu id="t4" label="Pipidae"/>
+		<otu id="t5" label="Ranidae"/>
+		<otu id="t6" label="Rhinophrynidae"/>
+	</otus>
+	<characters id="m1" otus="Tls1" xsi:type="nex:DnaSeqs" label="rbcL">
+		<format/>
+		<matrix>
+			<row id="r1" otu="t1"><seq>ACGT</seq></row>
+		</matrix>
+	</characters>
+	<characters id="m2" otus="Tls1" xsi:type="nex:StandardSeqs" label="Phylogenetic data matrix">
+		<format>
+			<states id="s1">
+				<state id="s1_0" symbol="0" label="arciferal"/>
+				<state id="s1_1" symbol="1" label="finnisternal"/>
+				<polymorphic_state_set id="s1_4" symbol="4">
+					<member state="s1_0"/>
+					<member state="s1_1"/>
+				</polymorphic_state_set>
+			</states>
+			<states id="s2">
+				<state id="s2_0" symbol="0" label="free"/>
+				<state id="s2_1" symbol="1" label="fused"/>
+				<state id="s2_2" symbol="2" label="fused_in_adults"/>
+				<uncertain_state_set id="s2_gap" symbol="-"/>
+			</states>
+			<states id="s3">
+				<state id="s3_0" symbol="0" label="juxtapose"/>
+				<state id="s3_1" symbol="1" label="overlap"/>
+			</states>
+			<states id="s4">
+				<state id="s4_0" symbol="0" label="absent"/>
+				<state id="s4_1" symbol="1" label="present"/>
+			</states>
+			<states id="s5">
+				<state id="s5_0" symbol="0" label="ectochordal"/>
+				<state id="s5_1" symbol="1" label="holochordal"/>
+				<state id="s5_2" symbol="2" label="stegochordal"/>
+			</states>
+			<char id="c1" states="s1" label="pectoral_girdle"/>
+			<char id="c2" states="s2" label="ribs,_fusion"/>
+			<char id="c3" states="s3" label="scapula, relation to clavical"/>
+			<char id="c4" states="s4" label="tail_muscle"/>
+			<char id="c5" states="s5" label="vertebral_ossification"/>
+		</format>
+		<matrix>
+			<row id="r1" otu="t1"><seq>0 0 1 1 0</seq></row>
+			<row id="r2" otu="t2"><seq>0 1 0 0 1</seq></row>
+			<row id="r3" otu="t3"><seq>0 0 1 0 2</seq></row>
+			<row id="r4" otu="t4"><seq>4 2 1 0 2</seq></row>
+			<row id="r5" otu="t5"><seq>1 1 0 0 1</seq></row>
+			<row id="r6" otu="t6"><seq>0 - 1 0 0</seq></row>
+		</matrix>
+	</characters>
+</nex:nexml>
+`
+
+func TestReadNeXML(t *testing.T) {
+	m := matrix.New()
+	if err := m.ReadNeXML(strings.NewReader(nexmlMatrix), "kluge1969"); err != nil {
+		t.Fatalf("unable to read NeXML data: %v", err)
+	}
+
+	want := newMatrix()
+	cmpMatrix(t, m, want)
+}
+
+var nexmlCells = `<?xml version="1.0" encoding="UTF-8"?>
+<nexml xmlns="http://www.nexml.org/2009" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+	<otus id="Tls1">
+		<otu id="t1" label="Pipidae"/>
+		<otu id="t2" label="Rhinophrynidae"/>
+	</otus>
+	<characters id="m1" otus="Tls1" xsi:type="nex:StandardCells">
+		<format>
+			<states id="s1">
+				<state id="s1_0" symbol="0" label="absent"/>
+				<state id="s1_1" symbol="1" label="present"/>
+				<uncertain_state_set id="s1_unk" symbol="?">
+					<member state="s1_0"/>
+					<member state="s1_1"/>
+				</uncertain_state_set>
+			</states>
+			<char id="c1" states="s1" label="tail muscle"/>
+			<char id="c2" states="s1"/>
+		</format>
+		<matrix>
+			<row id="r1" otu="t1">
+				<cell char="c1" state="s1_0"/>
+				<cell char="c2" state="s1_1"/>
+			</row>
+			<row id="r2" otu="t2">
+				<cell char="c1" state="s1_unk"/>
+				<cell char="c2" state="s1_0"/>
+			</row>
+		</matrix>
+	</characters>
+</nexml>
+`
+
+func TestReadNeXMLCells(t *testing.T) {
+	m := matrix.New()
+	if err := m.ReadNeXML(strings.NewReader(nexmlCells), "tb:s1"); err != nil {
+		t.Fatalf("unable to read NeXML data: %v", err)
+	}
+
+	want := matrix.New()
+	want.Add("Pipidae", "tb:s1:Pipidae", "tail muscle", "absent")
+	want.Add("Pipidae", "tb:s1:Pipidae", "char 2", "present")
+	want.Add("Rhinophrynidae", "tb:s1:Rhinophrynidae", "tail muscle", matrix.Unknown)
+	want.Add("Rhinophrynidae", "tb:s1:Rhinophrynidae", "char 2", "absent")
+	want.Set("tb:s1:Pipidae", "tail muscle", "absent", "tb:s1", matrix.Reference)
+	want.Set("tb:s1:Pipidae", "char 2", "present", "tb:s1", matrix.Reference)
+	want.Set("tb:s1:Rhinophrynidae", "char 2", "absent", "tb:s1", matrix.Reference)
+	cmpMatrix(t, m, want)
+}
+
+type testAnnotator map[string]string
+
+func (ta testAnnotator) CharTerm(char string) string {
+	return ta[char]
+}
+
+func (ta testAnnotator) StateTerm(char, state string) string {
+	return ta[char+":"+state]
+}
+
+func TestWriteNeXML(t *testing.T) {
+	m := newMatrix()
+	ann := testAnnotator{
+		"tail muscle":        "http://purl.obolibrary.org/obo/UBERON_0001630",
+		"tail muscle:absent": "http://purl.obolibrary.org/obo/PATO_0000462",
+	}
+
+	var w bytes.Buffer
+	if err := m.NeXML(&w, ann); err != nil {
+		t.Fatalf("unable to write NeXML data: %v", err)
+	}
+	t.Logf("output:\n%s\n", w.String())
+
+	out := w.String()
+	for _, iri := range ann {
+		if !strings.Contains(out, `href="`+iri+`"`) {
+			t.Errorf("annotation %q not found", iri)
+		}
+	}
+
+	got := matrix.New()
+	if err := got.ReadNeXML(&w, "kluge1969"); err != nil {
+		t.Fatalf("unable to read NeXML data: %v", err)
+	}
+	cmpMatrix(t, got, m)
+}
+
+func BenchmarkNeXML(b *testing.B) {
+	m := largeMatrix(100, 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := m.NeXML(io.Discard, nil); err != nil {
+			b.Fatalf("unable to write NeXML data: %v", err)
+		}
+	}
+}
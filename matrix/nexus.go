@@ -6,17 +6,27 @@ package matrix
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
 	"time"
 	"unicode"
+
+	"github.com/js-arias/phydata/names"
 )
 
 // ReadNexus reads a character matrix from a NEXUS file.
 // It require an ID for the matrix,
 // and a ID for a bibliographic reference.
+//
+// Only the first character (or data) block
+// with standard (morphological) characters
+// is read.
+// Blocks with molecular data are ignored,
+// so if there is no block with standard characters,
+// no observation will be added.
 func (m *Matrix) ReadNexus(r io.Reader, ref string) error {
 	nxf := bufio.NewReader(r)
 	token := &strings.Builder{}
@@ -32,6 +42,10 @@ func (m *Matrix) ReadNexus(r io.Reader, ref string) error {
 	// ignore all blocks except character block
 	for {
 		if _, err := readToken(nxf, token); err != nil {
+			if errors.Is(err, io.EOF) {
+				// no standard characters
+				return nil
+			}
 			return fmt.Errorf("expecting 'begin' token: %v", err)
 		}
 		if t := strings.ToLower(token.String()); t != "begin" {
@@ -42,29 +56,56 @@ func (m *Matrix) ReadNexus(r io.Reader, ref string) error {
 			return fmt.Errorf("expecting block name: %v", err)
 		}
 		block := strings.ToLower(token.String())
-		if block == "characters" {
-			break
+		if block == "characters" || block == "data" {
+			ok, err := m.readNexusChars(nxf, token, ref)
+			if err != nil {
+				return err
+			}
+			if ok {
+				return nil
+			}
+			continue
 		}
 
 		if err := skipBlock(nxf, token); err != nil {
 			return fmt.Errorf("incomplete block %q: %v", block, err)
 		}
 	}
+}
 
+// ReadNexusChars reads a character block.
+// It returns false if the block
+// is not a block of standard characters.
+func (m *Matrix) readNexusChars(nxf *bufio.Reader, token *strings.Builder, ref string) (bool, error) {
 	var chars []nexusChar
+	var symbols map[rune]int
 	for {
 		if _, err := readToken(nxf, token); err != nil {
-			return fmt.Errorf("incomplete block 'characters': %v", err)
+			return false, fmt.Errorf("incomplete block 'characters': %v", err)
 		}
 		t := strings.ToLower(token.String())
 		if t == "end" || t == "endblock" {
 			break
 		}
+		if t == "format" {
+			format, err := readNexusFormat(nxf, token)
+			if err != nil {
+				return false, err
+			}
+			if dt, ok := format["datatype"]; ok && dt != "standard" {
+				if err := skipBlock(nxf, token); err != nil {
+					return false, fmt.Errorf("incomplete block 'characters': %v", err)
+				}
+				return false, nil
+			}
+			symbols = parseSymbols(format["symbols"])
+			continue
+		}
 		if t == "charstatelabels" {
 			var err error
 			chars, err = readNexusCharStateLabels(nxf, token)
 			if err != nil {
-				return err
+				return false, err
 			}
 			continue
 		}
@@ -72,31 +113,133 @@ func (m *Matrix) ReadNexus(r io.Reader, ref string) error {
 			var err error
 			chars, err = readNexusCharLabels(nxf, token)
 			if err != nil {
-				return err
+				return false, err
 			}
 			continue
 		}
 		if t == "statelabels" {
 			if err := readNexusStateLabels(nxf, token, chars); err != nil {
-				return err
+				return false, err
 			}
 			continue
 		}
 		if t == "matrix" {
-			if err := m.readNexusMatrix(nxf, token, ref, chars); err != nil {
-				return err
+			if err := m.readNexusMatrix(nxf, token, ref, chars, symbols); err != nil {
+				return false, err
 			}
 			continue
 		}
 		if err := skipDefinition(nxf, token); err != nil {
-			return fmt.Errorf("incomplete block 'characters', token %q: %v", t, err)
+			return false, fmt.Errorf("incomplete block 'characters', token %q: %v", t, err)
+		}
+	}
+
+	return true, nil
+}
+
+// ReadNexusFormat reads the format definition
+// of a character block,
+// and returns the values of each format option.
+func readNexusFormat(r *bufio.Reader, token *strings.Builder) (map[string]string, error) {
+	format := make(map[string]string)
+	for {
+		delim, err := readToken(r, token)
+		if err != nil {
+			return nil, fmt.Errorf("while reading format: %v", err)
+		}
+		key := strings.ToLower(token.String())
+		if delim == '=' {
+			delim, err = readToken(r, token)
+			if err != nil {
+				return nil, fmt.Errorf("while reading format: %v", err)
+			}
+			format[key] = strings.ToLower(token.String())
+		} else if key != "" {
+			format[key] = ""
+		}
+		if delim == ';' {
+			return format, nil
 		}
 	}
+}
 
+// DefaultSymbols are the symbols
+// used for the character states
+// when writing a NEXUS file.
+const DefaultSymbols = "0123456789ABCDEFGHIJKLMNOPQRSTUV"
+
+// SetSymbols sets the symbols
+// used for the character states
+// when writing a NEXUS file.
+// Each letter of the string is a symbol,
+// and spaces are ignored.
+// If symbols is empty,
+// DefaultSymbols will be used.
+//
+// It returns an error if a symbol is repeated,
+// or it is one of the reserved symbols of the NEXUS format.
+func (m *Matrix) SetSymbols(symbols string) error {
+	symbols = strings.Join(strings.Fields(symbols), "")
+	seen := make(map[rune]bool)
+	for _, r := range symbols {
+		if strings.ContainsRune(nexusReserved, r) {
+			return fmt.Errorf("invalid symbol %q", r)
+		}
+		r = unicode.ToLower(r)
+		if seen[r] {
+			return fmt.Errorf("repeated symbol %q", r)
+		}
+		seen[r] = true
+	}
+	m.symbols = symbols
 	return nil
 }
 
+// Symbols returns the symbols
+// used for the character states
+// when writing a NEXUS file.
+func (m *Matrix) Symbols() string {
+	if m.symbols == "" {
+		return DefaultSymbols
+	}
+	return m.symbols
+}
+
+// NexusReserved are the symbols
+// with an special meaning in a NEXUS matrix.
+const nexusReserved = "?-(){}[];,'\"="
+
+// NexusSymbols returns the symbols
+// used for the states of the indicated characters
+// in a NEXUS file.
+// The number of symbols is the maximum number of states
+// of the characters
+// (at least two).
+// It returns an error
+// if a character has more states than the available symbols.
+func (m *Matrix) NexusSymbols(chars []string) ([]rune, error) {
+	sym := []rune(m.Symbols())
+
+	max := 2
+	for _, c := range chars {
+		n := len(m.States(c))
+		if n > len(sym) {
+			return nil, fmt.Errorf("character %q: %d states, but only %d symbols available", c, n, len(sym))
+		}
+		if n > max {
+			max = n
+		}
+	}
+	if max > len(sym) {
+		max = len(sym)
+	}
+	return sym[:max], nil
+}
+
 // Nexus writes an observation matrix as a NEXUS file.
+// If a taxon has only uncertain observations for a character,
+// the character is written as an uncertainty
+// among all the states of the character.
 func (m *Matrix) Nexus(w io.Writer) error {
 	// header
 	fmt.Fprintf(w, "#NEXUS\n")
@@ -117,10 +260,18 @@ func (m *Matrix) Nexus(w io.Writer) error {
 
 	// character block
 	chars := m.Chars()
+	sym, err := m.NexusSymbols(chars)
+	if err != nil {
+		return err
+	}
+	symLs := make([]string, len(sym))
+	for i, r := range sym {
+		symLs[i] = string(r)
+	}
 	fmt.Fprintf(w, "BEGIN CHARACTERS;\n")
 	fmt.Fprintf(w, "\tTITLE 'Phylogenetic data matrix';\n")
 	fmt.Fprintf(w, "\tDIMENSIONS NCHAR=%d;\n", len(chars))
-	fmt.Fprintf(w, "\tFORMAT DATATYPE = STANDARD RESPECTCASE GAP = - MISSING = ? SYMBOLS = \"0 1 2 3 4 5 6 7 8 9 A B C D E F\";\n")
+	fmt.Fprintf(w, "\tFORMAT DATATYPE = STANDARD RESPECTCASE GAP = - MISSING = ? SYMBOLS = \"%s\";\n", strings.Join(symLs, " "))
 	fmt.Fprintf(w, "\tCHARSTATELABELS\n")
 	states := make(map[string][]string, len(chars))
 	for i, c := range chars {
@@ -147,6 +298,7 @@ func (m *Matrix) Nexus(w io.Writer) error {
 		for _, c := range chars {
 			val := "?"
 			chSt := make(map[string]bool)
+			unc := false
 			for _, spec := range sp {
 				obs := m.Obs(spec, c)
 				for _, o := range obs {
@@ -157,10 +309,24 @@ func (m *Matrix) Nexus(w io.Writer) error {
 					if o == Unknown {
 						continue
 					}
+					if m.Val(spec, c, o, Uncertain) == "true" {
+						unc = true
+						continue
+					}
 
 					chSt[o] = true
 				}
 			}
+			if len(chSt) == 0 && unc {
+				// only uncertain observations
+				val = ""
+				for i := range states[c] {
+					val += string(sym[i])
+				}
+				if len(val) > 1 {
+					val = "{" + val + "}"
+				}
+			}
 			if len(chSt) == 0 {
 				fmt.Fprintf(w, "%s", val)
 				continue
@@ -170,10 +336,10 @@ func (m *Matrix) Nexus(w io.Writer) error {
 				if !chSt[s] {
 					continue
 				}
-				val += strconv.FormatInt(int64(i), 16)
+				val += string(sym[i])
 			}
 			if len(val) > 1 {
-				val = "{" + val + "}"
+				val = "(" + val + ")"
 			}
 			fmt.Fprintf(w, "%s", val)
 		}
@@ -319,7 +485,7 @@ func readNexusStateLabels(r *bufio.Reader, token *strings.Builder, chars []nexus
 	return nil
 }
 
-func (m *Matrix) readNexusMatrix(r *bufio.Reader, token *strings.Builder, ref string, chars []nexusChar) error {
+func (m *Matrix) readNexusMatrix(r *bufio.Reader, token *strings.Builder, ref string, chars []nexusChar, symbols map[rune]int) error {
 	last := ""
 	for {
 		// read taxon name
@@ -328,8 +494,8 @@ func (m *Matrix) readNexusMatrix(r *bufio.Reader, token *strings.Builder, ref st
 		}
 		tax := strings.ReplaceAll(token.String(), "_", " ")
 		tax = strings.Join(strings.Fields(tax), " ")
-		tax = canon(tax)
-		spec := specID(ref + ":" + tax)
+		tax = names.Taxon(tax)
+		spec := names.Specimen(ref + ":" + tax)
 
 		// read characters
 		char := 0
@@ -354,12 +520,16 @@ func (m *Matrix) readNexusMatrix(r *bufio.Reader, token *strings.Builder, ref st
 			char++
 
 			if r1 == '-' {
-				m.Add(tax, spec, cName, NotApplicable)
+				if err := m.Add(tax, spec, cName, NotApplicable); err != nil {
+					return fmt.Errorf("while reading matrix: taxon %q: char: %d: %v", tax, char, err)
+				}
 				m.Set(spec, cName, NotApplicable, ref, Reference)
 				continue
 			}
 			if r1 == '?' {
-				m.Add(tax, spec, cName, Unknown)
+				if err := m.Add(tax, spec, cName, Unknown); err != nil {
+					return fmt.Errorf("while reading matrix: taxon %q: char: %d: %v", tax, char, err)
+				}
 				continue
 			}
 			if r1 == '(' || r1 == '{' {
@@ -377,15 +547,17 @@ func (m *Matrix) readNexusMatrix(r *bufio.Reader, token *strings.Builder, ref st
 						continue
 					}
 
-					s, err := strconv.ParseInt(string(r1), 16, 0)
+					s, err := stateSymbol(r1, symbols)
 					if err != nil {
 						return fmt.Errorf("while reading matrix: taxon %q: char: %d [%q]: %v", tax, char, string(r1), err)
 					}
 					sName := fmt.Sprintf("state %d", s)
-					if int(s) < len(c.states) {
-						sName = c.states[int(s)]
+					if s < len(c.states) {
+						sName = c.states[s]
+					}
+					if err := m.Add(tax, spec, cName, sName); err != nil {
+						return fmt.Errorf("while reading matrix: taxon %q: char: %d: %v", tax, char, err)
 					}
-					m.Add(tax, spec, cName, sName)
 					m.Set(spec, cName, sName, ref, Reference)
 					empty = false
 				}
@@ -394,15 +566,17 @@ func (m *Matrix) readNexusMatrix(r *bufio.Reader, token *strings.Builder, ref st
 				}
 				continue
 			}
-			s, err := strconv.ParseInt(string(r1), 16, 0)
+			s, err := stateSymbol(r1, symbols)
 			if err != nil {
 				return fmt.Errorf("while reading matrix: taxon %q: char: %d [%q]: %v", tax, char, string(r1), err)
 			}
 			sName := fmt.Sprintf("state %d", s)
-			if int(s) < len(c.states) {
-				sName = c.states[int(s)]
+			if s < len(c.states) {
+				sName = c.states[s]
+			}
+			if err := m.Add(tax, spec, cName, sName); err != nil {
+				return fmt.Errorf("while reading matrix: taxon %q: char: %d: %v", tax, char, err)
 			}
-			m.Add(tax, spec, cName, sName)
 			m.Set(spec, cName, sName, ref, Reference)
 		}
 		last = tax
@@ -423,6 +597,35 @@ func (m *Matrix) readNexusMatrix(r *bufio.Reader, token *strings.Builder, ref st
 	return nil
 }
 
+// ParseSymbols returns the index of each symbol
+// of the symbols definition
+// of a NEXUS format.
+func parseSymbols(symbols string) map[rune]int {
+	symbols = strings.Join(strings.Fields(symbols), "")
+	if symbols == "" {
+		return nil
+	}
+	sym := make(map[rune]int)
+	for i, r := range []rune(symbols) {
+		sym[unicode.ToLower(r)] = i
+	}
+	return sym
+}
+
+// StateSymbol returns the index of a state symbol.
+// Symbols not in the symbols definition
+// are searched in the default symbols.
+func stateSymbol(r rune, symbols map[rune]int) (int, error) {
+	r = unicode.ToLower(r)
+	if s, ok := symbols[r]; ok {
+		return s, nil
+	}
+	if i := strings.IndexRune(strings.ToLower(DefaultSymbols), r); i >= 0 {
+		return i, nil
+	}
+	return 0, fmt.Errorf("undefined symbol %q", r)
+}
+
 func skipBlock(r *bufio.Reader, token *strings.Builder) error {
 	for {
 		_, err := readToken(r, token)
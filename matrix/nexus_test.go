@@ -6,6 +6,8 @@ package matrix_test
 
 import (
 	"bytes"
+	"fmt"
+	"io"
 	"reflect"
 	"strings"
 	"testing"
@@ -42,7 +44,7 @@ BEGIN CHARACTERS;
 	Ascaphus_truei	00110
 	Bufonidae	01001
 	Discoglossidae	00102
-	Pipidae	{01}2102
+	Pipidae	(01)2102
 	Ranidae	11001
 	Rhinophrynidae	0-100
 	;
@@ -59,6 +61,30 @@ func TestReadNexus(t *testing.T) {
 	cmpMatrix(t, m, want)
 }
 
+func TestReadNexusSkipDNA(t *testing.T) {
+	dnaBlock := `#NEXUS
+
+BEGIN CHARACTERS;
+	TITLE rbcL;
+	DIMENSIONS NCHAR=4;
+	FORMAT DATATYPE=DNA MISSING=? GAP=-;
+	MATRIX
+	Ascaphus_truei	ACGT
+	;
+END;
+`
+	data := strings.Replace(nexusMatrix, "BEGIN CHARACTERS;", "BEGIN DATA;", 1)
+	data = dnaBlock + strings.TrimPrefix(data, "#NEXUS\n")
+
+	m := matrix.New()
+	if err := m.ReadNexus(strings.NewReader(data), "kluge1969"); err != nil {
+		t.Fatalf("unable to read NEXUS data: %v", err)
+	}
+
+	want := newMatrix()
+	cmpMatrix(t, m, want)
+}
+
 func TestWriteNexus(t *testing.T) {
 	m := newMatrix()
 	var w bytes.Buffer
@@ -104,7 +130,7 @@ BEGIN CHARACTERS;
 	Ascaphus_truei	00110
 	Bufonidae	01001
 	Discoglossidae	00102
-	Pipidae	{01}2102
+	Pipidae	(01)2102
 	Ranidae	11001
 	Rhinophrynidae	0-100
 	;
@@ -187,7 +213,7 @@ BEGIN CHARACTERS;
 	Ascaphus_truei	00110
 	Bufonidae	01001
 	Discoglossidae	00102
-	Pipidae	{01}2102
+	Pipidae	(01)2102
 	Ranidae	11001
 	Rhinophrynidae	0-100
 	;
@@ -203,3 +229,118 @@ func TestReadNexusOldSchool(t *testing.T) {
 	want := newMatrix()
 	cmpMatrix(t, m, want)
 }
+
+func TestWriteNexusUncertain(t *testing.T) {
+	m := matrix.New()
+	m.Add("Ascaphus truei", "ascaphus", "tail muscle", "present")
+	m.Add("Bufonidae", "bufonidae", "tail muscle", "absent")
+	m.Set("bufonidae", "tail muscle", "absent", "true", matrix.Uncertain)
+	m.Add("Pipidae", "pipidae:1", "tail muscle", "absent")
+	m.Add("Pipidae", "pipidae:2", "tail muscle", "present")
+	m.Set("pipidae:2", "tail muscle", "present", "true", matrix.Uncertain)
+	m.Add("Ranidae", "ranidae:1", "tail muscle", "absent")
+	m.Add("Ranidae", "ranidae:2", "tail muscle", "present")
+
+	var w bytes.Buffer
+	if err := m.Nexus(&w); err != nil {
+		t.Fatalf("unable to write NEXUS data: %v", err)
+	}
+
+	want := map[string]string{
+		"Ascaphus_truei": "1",
+		"Bufonidae":      "{01}",
+		"Pipidae":        "0",
+		"Ranidae":        "(01)",
+	}
+	for _, ln := range strings.Split(w.String(), "\n") {
+		f := strings.Fields(ln)
+		if len(f) != 2 {
+			continue
+		}
+		if v, ok := want[f[0]]; ok && f[1] != v {
+			t.Errorf("taxon %q: got %q, want %q", f[0], f[1], v)
+		}
+	}
+}
+
+func TestWriteNexusManyStates(t *testing.T) {
+	m := matrix.New()
+	for i := 0; i < 20; i++ {
+		tx := fmt.Sprintf("Taxon %02d", i)
+		m.Add(tx, strings.ToLower(tx), "color", fmt.Sprintf("color %02d", i))
+	}
+
+	var w bytes.Buffer
+	if err := m.Nexus(&w); err != nil {
+		t.Fatalf("unable to write NEXUS data: %v", err)
+	}
+	if !strings.Contains(w.String(), `SYMBOLS = "0 1 2 3 4 5 6 7 8 9 A B C D E F G H I J"`) {
+		t.Errorf("expecting 20 symbols in output:\n%s", w.String())
+	}
+
+	got := matrix.New()
+	if err := got.ReadNexus(&w, "test"); err != nil {
+		t.Fatalf("unable to read NEXUS data: %v", err)
+	}
+	if st := got.States("color"); !reflect.DeepEqual(st, m.States("color")) {
+		t.Errorf("states: got %v, want %v", st, m.States("color"))
+	}
+	for _, tx := range m.Taxa() {
+		sp := strings.ToLower(tx)
+		want := m.Obs(sp, "color")
+		obs := got.Obs("test:"+sp, "color")
+		if !reflect.DeepEqual(obs, want) {
+			t.Errorf("taxon %q: got %v, want %v", tx, obs, want)
+		}
+	}
+}
+
+func TestSetSymbols(t *testing.T) {
+	m := newMatrix()
+	if s := m.Symbols(); s != matrix.DefaultSymbols {
+		t.Errorf("default symbols: got %q, want %q", s, matrix.DefaultSymbols)
+	}
+	if err := m.SetSymbols("a b c"); err != nil {
+		t.Fatalf("unable to set symbols: %v", err)
+	}
+	if s := m.Symbols(); s != "abc" {
+		t.Errorf("symbols: got %q, want %q", s, "abc")
+	}
+
+	var w bytes.Buffer
+	if err := m.Nexus(&w); err != nil {
+		t.Fatalf("unable to write NEXUS data: %v", err)
+	}
+	if !strings.Contains(w.String(), `SYMBOLS = "a b c"`) {
+		t.Errorf("expecting custom symbols in output:\n%s", w.String())
+	}
+
+	got := matrix.New()
+	if err := got.ReadNexus(&w, "kluge1969"); err != nil {
+		t.Fatalf("unable to read NEXUS data: %v", err)
+	}
+	cmpMatrix(t, got, m)
+
+	if err := m.SetSymbols("ab"); err != nil {
+		t.Fatalf("unable to set symbols: %v", err)
+	}
+	if err := m.Nexus(&bytes.Buffer{}); err == nil {
+		t.Errorf("expecting error when states exceed symbols")
+	}
+
+	for _, s := range []string{"01?", "01-", "0(1", "aA", "011"} {
+		if err := m.SetSymbols(s); err == nil {
+			t.Errorf("symbols %q: expecting error", s)
+		}
+	}
+}
+
+func BenchmarkNexus(b *testing.B) {
+	m := largeMatrix(100, 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := m.Nexus(io.Discard); err != nil {
+			b.Fatalf("unable to write NEXUS data: %v", err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ReadNumbering reads a character numbering
+// from a TSV file,
+// and returns the characters
+// sorted by its number.
+//
+// The TSV file must contains the following fields:
+//
+//   - number, the number of the character,
+//     starting from 1
+//   - character, the name of the character
+//
+// Here is an example file:
+//
+//	# character numbering
+//	number	character
+//	1	tail muscle
+//	2	ribs, fusion
+//	3	pectoral girdle
+func ReadNumbering(r io.Reader) ([]string, error) {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return nil, fmt.Errorf("while reading header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(h)
+		fields[h] = i
+	}
+	for _, h := range []string{"number", "character"} {
+		if _, ok := fields[h]; !ok {
+			return nil, fmt.Errorf("expecting field %q", h)
+		}
+	}
+
+	nums := make(map[string]int)
+	used := make(map[int]string)
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		f := "character"
+		char := strings.ToLower(strings.Join(strings.Fields(row[fields[f]]), " "))
+		if char == "" {
+			continue
+		}
+
+		f = "number"
+		n, err := strconv.Atoi(strings.TrimSpace(row[fields[f]]))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("on row %d: field %q: invalid value %q", ln, f, row[fields[f]])
+		}
+		if c, ok := used[n]; ok && c != char {
+			return nil, fmt.Errorf("on row %d: characters %q and %q with number %d", ln, c, char, n)
+		}
+		if p, ok := nums[char]; ok && p != n {
+			return nil, fmt.Errorf("on row %d: character %q with numbers %d and %d", ln, char, p, n)
+		}
+		nums[char] = n
+		used[n] = char
+	}
+
+	chars := make([]string, 0, len(nums))
+	for c := range nums {
+		chars = append(chars, c)
+	}
+	slices.SortFunc(chars, func(a, b string) int {
+		return nums[a] - nums[b]
+	})
+	return chars, nil
+}
+
+// WriteNumbering writes a character numbering
+// as a TSV file,
+// using the order of the given characters.
+func WriteNumbering(w io.Writer, chars []string) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	if err := tab.Write([]string{"number", "character"}); err != nil {
+		return fmt.Errorf("unable to write header: %v", err)
+	}
+	for i, c := range chars {
+		if err := tab.Write([]string{strconv.Itoa(i + 1), c}); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+// Renumber returns a list of characters
+// that honors a previous numbering.
+// The characters in the previous numbering
+// keep its relative order,
+// and the new characters are added at the end,
+// sorted alphabetically.
+// Characters of the previous numbering
+// that are not in the list
+// are removed.
+func Renumber(prev, chars []string) []string {
+	in := make(map[string]bool, len(chars))
+	for _, c := range chars {
+		in[strings.ToLower(strings.Join(strings.Fields(c), " "))] = true
+	}
+
+	ls := make([]string, 0, len(chars))
+	seen := make(map[string]bool, len(chars))
+	for _, c := range prev {
+		c = strings.ToLower(strings.Join(strings.Fields(c), " "))
+		if !in[c] || seen[c] {
+			continue
+		}
+		ls = append(ls, c)
+		seen[c] = true
+	}
+
+	var added []string
+	for c := range in {
+		if seen[c] {
+			continue
+		}
+		added = append(added, c)
+	}
+	slices.Sort(added)
+	return append(ls, added...)
+}
@@ -0,0 +1,62 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix"
+)
+
+var numberingText = `# character numbering
+number	character
+3	pectoral girdle
+1	tail muscle
+2	Ribs,  fusion
+4	dorsal fin
+`
+
+func TestNumbering(t *testing.T) {
+	prev, err := matrix.ReadNumbering(strings.NewReader(numberingText))
+	if err != nil {
+		t.Fatalf("unable to read numbering: %v", err)
+	}
+	want := []string{"tail muscle", "ribs, fusion", "pectoral girdle", "dorsal fin"}
+	if !reflect.DeepEqual(prev, want) {
+		t.Errorf("numbering: got %v, want %v", prev, want)
+	}
+
+	chars := newMatrix().Chars()
+	got := matrix.Renumber(prev, chars)
+	want = []string{"tail muscle", "ribs, fusion", "pectoral girdle", "scapula, relation to clavical", "vertebral ossification"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("renumber: got %v, want %v", got, want)
+	}
+
+	var w bytes.Buffer
+	if err := matrix.WriteNumbering(&w, got); err != nil {
+		t.Fatalf("unable to write numbering: %v", err)
+	}
+	np, err := matrix.ReadNumbering(&w)
+	if err != nil {
+		t.Fatalf("unable to read numbering: %v", err)
+	}
+	if !reflect.DeepEqual(np, got) {
+		t.Errorf("numbering: got %v, want %v", np, got)
+	}
+}
+
+func TestNumberingDuplicated(t *testing.T) {
+	data := `number	character
+1	tail muscle
+1	ribs, fusion
+`
+	if _, err := matrix.ReadNumbering(strings.NewReader(data)); err == nil {
+		t.Errorf("expecting error on duplicated number")
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package protein stores amino acid sequences
+// for taxon specimens.
+package protein
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/js-arias/phydata/names"
+)
+
+// A Collection is a collection of taxa
+// and their amino acid sequences.
+type Collection struct {
+	specs map[string]*specimen
+}
+
+// New creates a new empty collection.
+func New() *Collection {
+	return &Collection{
+		specs: make(map[string]*specimen),
+	}
+}
+
+// Add adds a new amino acid sequence to the collection
+// for a given taxon specimen
+// and gene.
+// Accession is the ID of the sequence,
+// usually the GenBank accession
+// of the source DNA sequence.
+func (c *Collection) Add(taxon, spec, gene, accession, seq string) error {
+	taxon = names.Taxon(taxon)
+	if taxon == "" {
+		return nil
+	}
+
+	spec = names.Specimen(spec)
+	if spec == "" {
+		return fmt.Errorf("sequence %q without specimen", accession)
+	}
+	accession = strings.TrimSpace(accession)
+	if accession == "" {
+		return fmt.Errorf("sequence without identifier")
+	}
+
+	gene = strings.TrimSpace(gene)
+	if gene == "" {
+		return fmt.Errorf("sequence %q without a defined gene identifier", accession)
+	}
+	gene = strings.ToLower(gene)
+
+	sp, ok := c.specs[spec]
+	if !ok {
+		sp = &specimen{
+			taxon: taxon,
+			name:  spec,
+			genes: make(map[string]map[string]*aaSequence),
+		}
+		c.specs[spec] = sp
+	}
+
+	acc, ok := sp.genes[gene]
+	if !ok {
+		acc = make(map[string]*aaSequence)
+		sp.genes[gene] = acc
+	}
+	acc[accession] = &aaSequence{
+		seq: formatSequence(seq),
+	}
+	return nil
+}
+
+// Genes returns the genes with sequences
+// in the collection.
+func (c *Collection) Genes() []string {
+	genNames := make(map[string]bool)
+	for _, sp := range c.specs {
+		for g := range sp.genes {
+			genNames[g] = true
+		}
+	}
+
+	genes := make([]string, 0, len(genNames))
+	for g := range genNames {
+		genes = append(genes, g)
+	}
+	slices.Sort(genes)
+	return genes
+}
+
+// GeneAccession returns the accessions
+// for a given gene
+// of a given specimen.
+func (c *Collection) GeneAccession(specimen, gene string) []string {
+	sp, ok := c.specs[names.Specimen(specimen)]
+	if !ok {
+		return nil
+	}
+	gene = strings.TrimSpace(strings.ToLower(gene))
+	gb, ok := sp.genes[gene]
+	if !ok {
+		return nil
+	}
+
+	acc := make([]string, 0, len(gb))
+	for a := range gb {
+		acc = append(acc, a)
+	}
+	slices.Sort(acc)
+	return acc
+}
+
+// Sequence returns a sequence for a given specimen,
+// gene,
+// and accession.
+func (c *Collection) Sequence(specimen, gene, accession string) string {
+	seq := c.sequence(specimen, gene, accession)
+	if seq == nil {
+		return ""
+	}
+	return seq.seq
+}
+
+// Specimens returns the specimens in the collection.
+func (c *Collection) Specimens() []string {
+	specs := make([]string, 0, len(c.specs))
+	for _, sp := range c.specs {
+		specs = append(specs, sp.name)
+	}
+	slices.Sort(specs)
+	return specs
+}
+
+// SpecGene return the genes defined for a given specimen.
+func (c *Collection) SpecGene(specimen string) []string {
+	sp, ok := c.specs[names.Specimen(specimen)]
+	if !ok {
+		return nil
+	}
+
+	genes := make([]string, 0, len(sp.genes))
+	for g := range sp.genes {
+		genes = append(genes, g)
+	}
+	slices.Sort(genes)
+	return genes
+}
+
+// Taxa returns the taxa defined in the collection.
+func (c *Collection) Taxa() []string {
+	taxa := make(map[string]bool)
+	for _, sp := range c.specs {
+		taxa[sp.taxon] = true
+	}
+
+	txLs := make([]string, 0, len(taxa))
+	for t := range taxa {
+		txLs = append(txLs, t)
+	}
+	slices.Sort(txLs)
+	return txLs
+}
+
+// RenameTaxon changes the name of a taxon.
+// If the new name is already in the collection,
+// the specimens of the old taxon
+// will be merged into the new taxon.
+func (c *Collection) RenameTaxon(old, name string) {
+	old = names.Taxon(old)
+	name = names.Taxon(name)
+	if name == "" {
+		return
+	}
+
+	for _, sp := range c.specs {
+		if sp.taxon != old {
+			continue
+		}
+		sp.taxon = name
+	}
+}
+
+// TaxSpec returns the specimens of a given taxon.
+func (c *Collection) TaxSpec(name string) []string {
+	name = names.Taxon(name)
+	var specs []string
+	for _, sp := range c.specs {
+		if sp.taxon != name {
+			continue
+		}
+		specs = append(specs, sp.name)
+	}
+	slices.Sort(specs)
+	return specs
+}
+
+// Field is used to define additional information fields
+// of an amino acid sequence.
+type Field string
+
+// Additional sequence fields.
+const (
+	Reference Field = "reference"
+	Comments  Field = "comments"
+)
+
+// Set sets the value of an additional information
+// for a sequence.
+func (c *Collection) Set(specimen, gene, accession, val string, field Field) {
+	seq := c.sequence(specimen, gene, accession)
+	if seq == nil {
+		return
+	}
+
+	val = strings.Join(strings.Fields(val), " ")
+	switch field {
+	case Reference:
+		seq.ref = val
+	case Comments:
+		seq.comment = val
+	}
+}
+
+// Val returns the value of additional fields
+// for a sequence.
+func (c *Collection) Val(specimen, gene, accession string, field Field) string {
+	seq := c.sequence(specimen, gene, accession)
+	if seq == nil {
+		return ""
+	}
+
+	switch field {
+	case Reference:
+		return seq.ref
+	case Comments:
+		return seq.comment
+	}
+	return ""
+}
+
+func (c *Collection) sequence(specimen, gene, accession string) *aaSequence {
+	sp, ok := c.specs[names.Specimen(specimen)]
+	if !ok {
+		return nil
+	}
+	gene = strings.TrimSpace(strings.ToLower(gene))
+	acc, ok := sp.genes[gene]
+	if !ok {
+		return nil
+	}
+	return acc[accession]
+}
+
+type specimen struct {
+	taxon string
+	name  string
+	genes map[string]map[string]*aaSequence
+}
+
+type aaSequence struct {
+	seq     string
+	ref     string
+	comment string
+}
+
+// FormatSequence returns an amino acid sequence
+// using upper case letters.
+func formatSequence(seq string) string {
+	seq = strings.Join(strings.Fields(seq), "")
+	return strings.ToUpper(seq)
+}
@@ -0,0 +1,94 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package protein_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix/protein"
+)
+
+func TestCollection(t *testing.T) {
+	c := newCollection()
+
+	specs := []string{"sp-01", "sp-02"}
+	if sp := c.Specimens(); !reflect.DeepEqual(sp, specs) {
+		t.Errorf("specimens: got %v, want %v", sp, specs)
+	}
+
+	genes := []string{"cytb", "eef1a1"}
+	if g := c.Genes(); !reflect.DeepEqual(g, genes) {
+		t.Errorf("genes: got %v, want %v", g, genes)
+	}
+
+	taxa := []string{"Loxodonta africana", "Orycteropus afer"}
+	if tx := c.Taxa(); !reflect.DeepEqual(tx, taxa) {
+		t.Errorf("taxa: got %v, want %v", tx, taxa)
+	}
+
+	if s := c.Sequence("SP-01", "cytb", "MN148748"); s != "MTNIRKSHPL" {
+		t.Errorf("sequence: got %q, want %q", s, "MTNIRKSHPL")
+	}
+	if r := c.Val("sp-01", "cytb", "MN148748", protein.Reference); r != "smith2020" {
+		t.Errorf("reference: got %q, want %q", r, "smith2020")
+	}
+}
+
+func TestTSV(t *testing.T) {
+	c := newCollection()
+	var w bytes.Buffer
+	if err := c.TSV(&w); err != nil {
+		t.Fatalf("unable to write TSV data: %v", err)
+	}
+	t.Logf("output:\n%s\n", w.String())
+
+	got := protein.New()
+	if err := got.ReadTSV(&w); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+
+	cmpCollection(t, got, c)
+}
+
+func newCollection() *protein.Collection {
+	c := protein.New()
+	c.Add("Loxodonta africana", "sp-01", "cytb", "MN148748", "mtnirkshpl")
+	c.Add("Loxodonta africana", "sp-01", "eef1a1", "XM_064288029", "GKGSFKYAWV")
+	c.Add("Orycteropus afer", "sp-02", "cytb", "OR167429", "XXTNIRKTHP")
+
+	c.Set("sp-01", "cytb", "MN148748", "smith2020", protein.Reference)
+	c.Set("sp-02", "cytb", "OR167429", "translated from DNA", protein.Comments)
+	return c
+}
+
+func cmpCollection(t testing.TB, got, want *protein.Collection) {
+	t.Helper()
+
+	if sp := got.Specimens(); !reflect.DeepEqual(sp, want.Specimens()) {
+		t.Errorf("specimens: got %v, want %v", sp, want.Specimens())
+	}
+	if g := got.Genes(); !reflect.DeepEqual(g, want.Genes()) {
+		t.Errorf("genes: got %v, want %v", g, want.Genes())
+	}
+
+	for _, spec := range want.Specimens() {
+		for _, gene := range want.SpecGene(spec) {
+			for _, acc := range want.GeneAccession(spec, gene) {
+				seq := want.Sequence(spec, gene, acc)
+				if s := got.Sequence(spec, gene, acc); s != seq {
+					t.Errorf("sequence %q, gene %q, accession %q: got %q, want %q", spec, gene, acc, s, seq)
+				}
+				for _, f := range []protein.Field{protein.Reference, protein.Comments} {
+					v := want.Val(spec, gene, acc, f)
+					if g := got.Val(spec, gene, acc, f); g != v {
+						t.Errorf("sequence %q, gene %q, accession %q: field %q: got %q, want %q", spec, gene, acc, f, g, v)
+					}
+				}
+			}
+		}
+	}
+}
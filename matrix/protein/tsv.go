@@ -0,0 +1,179 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package protein
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+var headerFields = []string{
+	"taxon",
+	"specimen",
+	"gene",
+	"accession",
+	"residues",
+}
+
+var valFields = []Field{
+	Reference,
+	Comments,
+}
+
+// ReadTSV reads a set of amino acid sequences
+// from a TSV file.
+//
+// The TSV file must contains the following fields:
+//
+//   - taxon, the taxonomic name of the source taxon
+//   - specimen, the ID of the particular source specimen
+//   - gene, an identifier for the gene
+//   - accession, the ID of the sequence
+//   - residues, the amino acid sequence
+//
+// Additional fields are:
+//
+//   - reference, an ID of a bibliographic reference
+//   - comments, simple additional comments about the sequence
+//
+// Here is an example file:
+//
+//	# amino acid sequences
+//	taxon	specimen	gene	accession	reference	comments	residues
+//	Loxodonta africana	sp-01	cytb	MN148748		translated from DNA	MTNIRKSHPLMKIVNNAFIDLPAPSNISSW
+//	Orycteropus afer	sp-02	cytb	OR167429		translated from DNA	MTNIRKTHPLFKIINHSFIDLPAPSNISSW
+func (c *Collection) ReadTSV(r io.Reader) error {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return fmt.Errorf("while reading header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(h)
+		fields[h] = i
+	}
+	for _, h := range headerFields {
+		if _, ok := fields[h]; !ok {
+			return fmt.Errorf("expecting field %q", h)
+		}
+	}
+
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		f := "taxon"
+		tax := row[fields[f]]
+		if tax == "" {
+			continue
+		}
+
+		f = "specimen"
+		spec := row[fields[f]]
+		if spec == "" {
+			continue
+		}
+
+		f = "gene"
+		gene := row[fields[f]]
+		if gene == "" {
+			continue
+		}
+
+		f = "accession"
+		acc := row[fields[f]]
+		if acc == "" {
+			continue
+		}
+
+		f = "residues"
+		seq := row[fields[f]]
+		if seq == "" {
+			continue
+		}
+		if err := c.Add(tax, spec, gene, acc, seq); err != nil {
+			return fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		// additional fields
+		for _, ff := range valFields {
+			f = string(ff)
+			i, ok := fields[f]
+			if !ok {
+				continue
+			}
+
+			v := row[i]
+			c.Set(spec, gene, acc, v, ff)
+		}
+	}
+
+	return nil
+}
+
+// TSV writes an amino acid sequence collection as a TSV file.
+func (c *Collection) TSV(w io.Writer) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	// header
+	header := []string{"taxon", "specimen", "gene", "accession", "reference", "comments", "residues"}
+	if err := tab.Write(header); err != nil {
+		return fmt.Errorf("unable to write header: %v", err)
+	}
+
+	specs := make([]*specimen, 0, len(c.specs))
+	for _, sp := range c.specs {
+		specs = append(specs, sp)
+	}
+	slices.SortFunc(specs, func(a, b *specimen) int {
+		if c := strings.Compare(a.taxon, b.taxon); c != 0 {
+			return c
+		}
+		return strings.Compare(a.name, b.name)
+	})
+
+	genes := c.Genes()
+	for _, sp := range specs {
+		for _, gn := range genes {
+			for _, a := range c.GeneAccession(sp.name, gn) {
+				seq := sp.genes[gn][a]
+				row := []string{
+					sp.taxon,
+					sp.name,
+					gn,
+					a,
+					seq.ref,
+					seq.comment,
+					seq.seq,
+				}
+				if err := tab.Write(row); err != nil {
+					return fmt.Errorf("while writing data: %v", err)
+				}
+			}
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
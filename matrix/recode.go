@@ -0,0 +1,355 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Default states of the binary characters
+// of a recoding.
+const (
+	Present = "present"
+	Absent  = "absent"
+)
+
+// A Recoding is a correspondence
+// between a multistate character
+// and a set of binary
+// (presence/absence)
+// characters.
+type Recoding struct {
+	// Char is the name of the multistate character.
+	Char string
+
+	// Binary are the binary characters.
+	Binary []BinaryChar
+
+	// None is the state of the multistate character
+	// in which all the binary characters are absent.
+	None string
+}
+
+// A BinaryChar is a binary character
+// that is equivalent to a state
+// of a multistate character.
+type BinaryChar struct {
+	Name  string
+	State string // the state of the multistate character
+
+	Present string // the state that indicates presence
+	Absent  string // the state that indicates absence
+}
+
+// Binaries returns the names of the binary characters
+// of the recoding.
+func (rc *Recoding) Binaries() []string {
+	ls := make([]string, 0, len(rc.Binary))
+	for _, b := range rc.Binary {
+		ls = append(ls, b.Name)
+	}
+	return ls
+}
+
+// ToMultistate adds the observations
+// of the multistate character of a recoding
+// using the observations of the binary characters.
+// If more than one binary character is present,
+// the multistate character will be polymorphic.
+// If all binary characters are absent
+// (or inapplicable),
+// it uses the None state
+// (if defined).
+// If all the binary characters are inapplicable,
+// the multistate character will be inapplicable.
+// Specimens without present binary characters,
+// and with unknown binary characters,
+// are ignored.
+// The new states keep the additional fields
+// of the binary observations.
+// It returns the number of specimens
+// with new observations.
+//
+// It returns an error
+// if the multistate character already has observations
+// (the new states would be added as polymorphisms).
+func (m *Matrix) ToMultistate(rc *Recoding) (int, error) {
+	char := charName(rc.Char)
+	if m.Observed(char) > 0 {
+		return 0, fmt.Errorf("character %q: already has observations", char)
+	}
+
+	var n int
+	for _, sp := range m.Specimens() {
+		var states []string
+		var src []obsSource // the observation of each state
+		var absent obsSource
+		var unknown bool
+		var na int
+		for _, b := range rc.Binary {
+			obs := m.Obs(sp, b.Name)
+			if obs[0] == Unknown {
+				unknown = true
+				continue
+			}
+			if obs[0] == NotApplicable {
+				na++
+				continue
+			}
+			if slices.Contains(obs, b.Present) {
+				states = append(states, b.State)
+				src = append(src, obsSource{b.Name, b.Present})
+				continue
+			}
+			if absent.char == "" {
+				absent = obsSource{b.Name, obs[0]}
+			}
+		}
+		if len(states) == 0 {
+			if unknown {
+				continue
+			}
+			switch {
+			case na == len(rc.Binary):
+				states = []string{NotApplicable}
+				src = []obsSource{{rc.Binary[0].Name, NotApplicable}}
+			case rc.None != "":
+				states = []string{rc.None}
+				src = []obsSource{absent}
+			default:
+				continue
+			}
+		}
+
+		tax := m.specs[sp].taxon
+		for i, s := range states {
+			if err := m.Add(tax, sp, char, s); err != nil {
+				return n, err
+			}
+			m.copyVals(sp, src[i], char, s)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// ToBinary adds the observations
+// of the binary characters of a recoding
+// using the observations of the multistate character.
+// A binary character is present
+// if its state is observed
+// in the multistate character,
+// and absent otherwise.
+// If the multistate character is inapplicable,
+// all the binary characters will be inapplicable.
+// Specimens with the multistate character unknown
+// are ignored.
+// The new states keep the additional fields
+// of the multistate observations.
+// It returns the number of specimens
+// with new observations.
+//
+// It returns an error
+// if a binary character already has observations.
+func (m *Matrix) ToBinary(rc *Recoding) (int, error) {
+	for _, b := range rc.Binary {
+		if m.Observed(b.Name) > 0 {
+			return 0, fmt.Errorf("character %q: already has observations", charName(b.Name))
+		}
+	}
+
+	var n int
+	for _, sp := range m.Specimens() {
+		obs := m.Obs(sp, rc.Char)
+		if obs[0] == Unknown {
+			continue
+		}
+
+		tax := m.specs[sp].taxon
+		for _, b := range rc.Binary {
+			st := b.Absent
+			src := obsSource{rc.Char, obs[0]}
+			if obs[0] == NotApplicable {
+				st = NotApplicable
+			} else if slices.Contains(obs, b.State) {
+				st = b.Present
+				src.state = b.State
+			}
+			if err := m.Add(tax, sp, b.Name, st); err != nil {
+				return n, err
+			}
+			m.copyVals(sp, src, b.Name, st)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// An obsSource is the character and state
+// of the observation
+// used to recode a new observation.
+type obsSource struct {
+	char  string
+	state string
+}
+
+// CopyVals copies the additional fields
+// of the source observation of a specimen
+// into a recoded observation.
+func (m *Matrix) copyVals(spec string, src obsSource, char, state string) {
+	o := m.observation(spec, src.char, src.state)
+	if o == nil {
+		return
+	}
+	for _, f := range valFields {
+		m.Set(spec, char, state, m.Val(spec, src.char, src.state, f), f)
+	}
+	for f, v := range o.extra {
+		m.Set(spec, char, state, v, Field(f))
+	}
+}
+
+var recodeFields = []string{
+	"character",
+	"state",
+	"binary",
+}
+
+// ReadRecoding reads a set of recodings
+// from a TSV file.
+//
+// The TSV file must contains the following fields:
+//
+//   - character, the name of the multistate character
+//   - state, a state of the multistate character
+//   - binary, the name of the binary character
+//     equivalent to the state
+//
+// Additional fields are:
+//
+//   - present, the state of the binary character
+//     that indicates presence
+//     (by default "present")
+//   - absent, the state of the binary character
+//     that indicates absence
+//     (by default "absent")
+//
+// A row without a binary character
+// defines the state of the multistate character
+// in which all the binary characters are absent.
+//
+// Here is an example file:
+//
+//	# recoding
+//	character	state	binary
+//	dentition	incisors	incisors
+//	dentition	canines	canines
+//	dentition	toothless
+func ReadRecoding(r io.Reader) ([]*Recoding, error) {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+	tab.FieldsPerRecord = -1
+
+	head, err := tab.Read()
+	if err != nil {
+		return nil, fmt.Errorf("while reading header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(strings.TrimSpace(h))
+		fields[h] = i
+	}
+	for _, h := range recodeFields {
+		if _, ok := fields[h]; !ok {
+			return nil, fmt.Errorf("expecting field %q", h)
+		}
+	}
+	val := func(row []string, f string) string {
+		i, ok := fields[f]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return charName(row[i])
+	}
+
+	var ls []*Recoding
+	binary := make(map[string]string)
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		char := val(row, "character")
+		if char == "" {
+			continue
+		}
+		state := val(row, "state")
+		if state == "" {
+			return nil, fmt.Errorf("on row %d: character %q: expecting state", ln, char)
+		}
+
+		var rc *Recoding
+		for _, r := range ls {
+			if r.Char == char {
+				rc = r
+				break
+			}
+		}
+		if rc == nil {
+			rc = &Recoding{Char: char}
+			ls = append(ls, rc)
+		}
+
+		b := val(row, "binary")
+		if b == "" {
+			if rc.None != "" && rc.None != state {
+				return nil, fmt.Errorf("on row %d: character %q: state %q: absence state already defined as %q", ln, char, state, rc.None)
+			}
+			rc.None = state
+			continue
+		}
+		if b == char {
+			return nil, fmt.Errorf("on row %d: character %q: binary character with the same name", ln, char)
+		}
+		if prev, ok := binary[b]; ok {
+			return nil, fmt.Errorf("on row %d: binary character %q: already used by %q", ln, b, prev)
+		}
+		binary[b] = char
+
+		bc := BinaryChar{
+			Name:    b,
+			State:   state,
+			Present: val(row, "present"),
+			Absent:  val(row, "absent"),
+		}
+		if bc.Present == "" {
+			bc.Present = Present
+		}
+		if bc.Absent == "" {
+			bc.Absent = Absent
+		}
+		if bc.Present == bc.Absent {
+			return nil, fmt.Errorf("on row %d: binary character %q: same state for presence and absence", ln, b)
+		}
+		rc.Binary = append(rc.Binary, bc)
+	}
+	for _, rc := range ls {
+		if len(rc.Binary) == 0 {
+			return nil, fmt.Errorf("character %q: without binary characters", rc.Char)
+		}
+	}
+	return ls, nil
+}
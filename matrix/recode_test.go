@@ -0,0 +1,157 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix"
+)
+
+var recodeBlob = `# recoding
+character	state	binary	present	absent
+dentition	incisors	incisors		
+dentition	canines	Canines	1	0
+dentition	toothless
+`
+
+func TestReadRecoding(t *testing.T) {
+	ls, err := matrix.ReadRecoding(strings.NewReader(recodeBlob))
+	if err != nil {
+		t.Fatalf("unable to read recoding: %v", err)
+	}
+	want := []*matrix.Recoding{
+		{
+			Char: "dentition",
+			Binary: []matrix.BinaryChar{
+				{Name: "incisors", State: "incisors", Present: matrix.Present, Absent: matrix.Absent},
+				{Name: "canines", State: "canines", Present: "1", Absent: "0"},
+			},
+			None: "toothless",
+		},
+	}
+	if !reflect.DeepEqual(ls, want) {
+		t.Errorf("recoding: got %v, want %v", ls, want)
+	}
+
+	errs := map[string]string{
+		"no state":       "character\tstate\tbinary\ndentition\t\tincisors\n",
+		"repeated":       "character\tstate\tbinary\ndentition\tincisors\tincisors\nteeth\tincisors\tincisors\n",
+		"no binary":      "character\tstate\tbinary\ndentition\ttoothless\t\n",
+		"missing field":  "character\tstate\nteeth\tincisors\n",
+		"same state":     "character\tstate\tbinary\tpresent\tabsent\ndentition\tincisors\tincisors\tx\tx\n",
+		"same character": "character\tstate\tbinary\ndentition\tincisors\tdentition\n",
+	}
+	for name, blob := range errs {
+		if _, err := matrix.ReadRecoding(strings.NewReader(blob)); err == nil {
+			t.Errorf("%s: expecting error", name)
+		}
+	}
+}
+
+func TestRecoding(t *testing.T) {
+	ls, err := matrix.ReadRecoding(strings.NewReader(recodeBlob))
+	if err != nil {
+		t.Fatalf("unable to read recoding: %v", err)
+	}
+	rc := ls[0]
+
+	m := matrix.New()
+	m.Add("Homo sapiens", "Homo sapiens", "incisors", "present")
+	m.Add("Homo sapiens", "Homo sapiens", "canines", "1")
+	m.Add("Mus musculus", "Mus musculus", "incisors", "present")
+	m.Add("Mus musculus", "Mus musculus", "canines", "0")
+	m.Add("Bradypus", "Bradypus", "incisors", "absent")
+	m.Add("Bradypus", "Bradypus", "canines", "0")
+	m.Add("Manis", "Manis", "incisors", matrix.NotApplicable)
+	m.Add("Manis", "Manis", "canines", matrix.NotApplicable)
+	m.Add("Orycteropus", "Orycteropus", "incisors", "absent")
+	m.Set("Homo sapiens", "canines", "1", "linnaeus1758", matrix.Reference)
+	m.Set("Homo sapiens", "canines", "1", "sharp", matrix.Field("shape"))
+	m.Set("Bradypus", "incisors", "absent", "literature", matrix.Source)
+
+	n, err := m.ToMultistate(rc)
+	if err != nil {
+		t.Fatalf("multistate: unexpected error: %v", err)
+	}
+	if n != 4 {
+		t.Errorf("multistate: got %d specimens, want %d", n, 4)
+	}
+	tests := map[string][]string{
+		"homo sapiens": {"canines", "incisors"},
+		"mus musculus": {"incisors"},
+		"bradypus":     {"toothless"},
+		"manis":        {matrix.NotApplicable},
+		"orycteropus":  {matrix.Unknown},
+	}
+	for sp, want := range tests {
+		if got := m.Obs(sp, "dentition"); !reflect.DeepEqual(got, want) {
+			t.Errorf("multistate: %s: got %v, want %v", sp, got, want)
+		}
+	}
+	vals := []struct {
+		spec, state string
+		field       matrix.Field
+		want        string
+	}{
+		{"homo sapiens", "canines", matrix.Reference, "linnaeus1758"},
+		{"homo sapiens", "canines", matrix.Field("shape"), "sharp"},
+		{"homo sapiens", "incisors", matrix.Reference, ""},
+		{"bradypus", "toothless", matrix.Source, "literature"},
+	}
+	for _, v := range vals {
+		if got := m.Val(v.spec, "dentition", v.state, v.field); got != v.want {
+			t.Errorf("multistate: %s: %s: %s: got %q, want %q", v.spec, v.state, v.field, got, v.want)
+		}
+	}
+
+	b := matrix.New()
+	b.Add("Homo sapiens", "Homo sapiens", "dentition", "incisors")
+	b.Add("Homo sapiens", "Homo sapiens", "dentition", "canines")
+	b.Add("Bradypus", "Bradypus", "dentition", "toothless")
+	b.Add("Manis", "Manis", "dentition", matrix.NotApplicable)
+	b.Set("Homo sapiens", "dentition", "canines", "linnaeus1758", matrix.Reference)
+	b.Set("Bradypus", "dentition", "toothless", "true", matrix.Uncertain)
+	n, err = b.ToBinary(rc)
+	if err != nil {
+		t.Fatalf("binary: unexpected error: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("binary: got %d specimens, want %d", n, 3)
+	}
+	binary := map[string][2]string{
+		"homo sapiens": {"present", "1"},
+		"bradypus":     {"absent", "0"},
+		"manis":        {matrix.NotApplicable, matrix.NotApplicable},
+	}
+	for sp, want := range binary {
+		got := [2]string{b.Obs(sp, "incisors")[0], b.Obs(sp, "canines")[0]}
+		if got != want {
+			t.Errorf("binary: %s: got %v, want %v", sp, got, want)
+		}
+	}
+	if got := b.Val("homo sapiens", "canines", "1", matrix.Reference); got != "linnaeus1758" {
+		t.Errorf("binary: %s: got reference %q, want %q", "homo sapiens", got, "linnaeus1758")
+	}
+	if got := b.Val("homo sapiens", "incisors", "present", matrix.Reference); got != "" {
+		t.Errorf("binary: %s: got reference %q, want %q", "homo sapiens", got, "")
+	}
+	if got := b.Val("bradypus", "incisors", "absent", matrix.Uncertain); got != "true" {
+		t.Errorf("binary: %s: got uncertain %q, want %q", "bradypus", got, "true")
+	}
+
+	// characters with observations are not recoded
+	if _, err := m.ToMultistate(rc); err == nil {
+		t.Errorf("multistate: expecting error on character with observations")
+	}
+	if got := m.Obs("mus musculus", "dentition"); !reflect.DeepEqual(got, []string{"incisors"}) {
+		t.Errorf("multistate: %s: got %v, want %v", "mus musculus", got, []string{"incisors"})
+	}
+	if _, err := b.ToBinary(rc); err == nil {
+		t.Errorf("binary: expecting error on characters with observations")
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/js-arias/phydata/names"
+)
+
+// Review writes an observation matrix
+// as a review grid,
+// a wide-format TSV file
+// with a row for each specimen
+// and a column for each character.
+//
+// The first two columns are the taxon name
+// and the specimen ID.
+// The specimen ID and the character name
+// are the stable ID of each cell,
+// so the reviewed grid can be read with ReadReview
+// even if rows or columns are reordered.
+//
+// As in Wide,
+// cells use the state names,
+// with polymorphic observations separated by '/',
+// a question mark '?' for unknown states,
+// and a dash '-' for inapplicable characters.
+func (m *Matrix) Review(w io.Writer) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	chars := m.Chars()
+	header := append([]string{"taxon", "specimen"}, chars...)
+	if err := tab.Write(header); err != nil {
+		return fmt.Errorf("unable to write header: %v", err)
+	}
+
+	for _, tx := range m.Taxa() {
+		specs := m.TaxSpec(tx)
+		slices.Sort(specs)
+		for _, sp := range specs {
+			row := make([]string, 0, len(header))
+			row = append(row, tx, sp)
+			for _, c := range chars {
+				row = append(row, wideCell(m, []string{sp}, c))
+			}
+			if err := tab.Write(row); err != nil {
+				return fmt.Errorf("while writing data: %v", err)
+			}
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+// ReadReview reads a review grid
+// written with Review
+// (and probably edited in a spreadsheet),
+// and updates the observations
+// of the cells that were changed.
+// Besides tabs,
+// the grid can be delimited by commas or semicolons.
+//
+// Only the cells with a content
+// different from the observations stored in the matrix
+// are updated,
+// and the reviewer is recorded
+// in the Reviewer field of the new observations.
+// The additional fields of the states
+// already present in the cell are kept.
+// Empty cells are ignored.
+// Specimens not in the matrix are added.
+// If a specimen is assigned to a different taxon,
+// it returns a *TaxonError.
+//
+// It returns the number of updated cells.
+func (m *Matrix) ReadReview(r io.Reader, reviewer string) (int, error) {
+	br := bufio.NewReaderSize(r, 1<<16)
+	comma, err := sniffDelimiter(br)
+	if err != nil {
+		return 0, err
+	}
+
+	tab := csv.NewReader(br)
+	tab.Comma = comma
+	tab.Comment = '#'
+	tab.FieldsPerRecord = -1
+
+	head, err := tab.Read()
+	if err != nil {
+		return 0, fmt.Errorf("while reading header: %v", err)
+	}
+	if len(head) < 2 || !strings.EqualFold(strings.TrimSpace(head[0]), "taxon") || !strings.EqualFold(strings.TrimSpace(head[1]), "specimen") {
+		return 0, fmt.Errorf("expecting 'taxon' and 'specimen' as the first fields")
+	}
+	chars := make([]string, len(head))
+	for i := 2; i < len(head); i++ {
+		chars[i] = strings.Join(strings.Fields(head[i]), " ")
+	}
+
+	var changed int
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return changed, fmt.Errorf("on row %d: %v", ln, err)
+		}
+		if len(row) < 2 {
+			continue
+		}
+
+		tax := names.Taxon(row[0])
+		spec := names.Specimen(row[1])
+		if tax == "" || spec == "" {
+			continue
+		}
+		for j := 2; j < len(row) && j < len(head); j++ {
+			if chars[j] == "" {
+				continue
+			}
+			states, err := cellStates(row[j], nil)
+			if err != nil {
+				return changed, fmt.Errorf("on row %d: specimen %q: character %q: %v", ln, spec, chars[j], err)
+			}
+			if len(states) == 0 {
+				continue
+			}
+			ok, err := m.replaceObs(tax, spec, chars[j], states, reviewer)
+			if err != nil {
+				return changed, fmt.Errorf("on row %d: %w", ln, err)
+			}
+			if ok {
+				changed++
+			}
+		}
+	}
+	return changed, nil
+}
+
+// ReplaceObs replaces the observations
+// of a character in a specimen,
+// if they are different from the given states.
+func (m *Matrix) replaceObs(tax, spec, char string, states []string, reviewer string) (bool, error) {
+	for i, s := range states {
+		states[i] = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	}
+	slices.Sort(states)
+	states = slices.Compact(states)
+	if slices.Contains(states, Unknown) {
+		states = []string{Unknown}
+	}
+	if slices.Contains(states, NotApplicable) {
+		states = []string{NotApplicable}
+	}
+
+	prev := m.Obs(spec, char)
+	if slices.Equal(prev, states) {
+		return false, nil
+	}
+
+	fields := []Field{Reference, ImageLink, Comments, AddedBy, Timestamp, Uncertain, Source}
+	vals := make(map[string][]string, len(prev))
+	for _, s := range prev {
+		v := make([]string, len(fields))
+		for i, f := range fields {
+			v[i] = m.Val(spec, char, s, f)
+		}
+		vals[s] = v
+	}
+	extra := make(map[string]map[string]string, len(prev))
+	if sp, ok := m.specs[spec]; ok {
+		for s, o := range sp.obs[strings.ToLower(char)] {
+			extra[s] = maps.Clone(o.extra)
+		}
+	}
+
+	if _, ok := m.specs[spec]; ok {
+		if err := m.Add(tax, spec, char, Unknown); err != nil {
+			return false, err
+		}
+	}
+	for _, s := range states {
+		if err := m.Add(tax, spec, char, s); err != nil {
+			return false, err
+		}
+		if s == Unknown {
+			continue
+		}
+		for i, f := range fields {
+			if v, ok := vals[s]; ok {
+				m.Set(spec, char, s, v[i], f)
+			}
+		}
+		for f, v := range extra[s] {
+			m.Set(spec, char, s, v, Field(f))
+		}
+		m.Set(spec, char, s, reviewer, Reviewer)
+	}
+	return true, nil
+}
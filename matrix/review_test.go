@@ -0,0 +1,129 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix"
+)
+
+func TestReview(t *testing.T) {
+	m := newMatrixWithComments()
+
+	var w strings.Builder
+	if err := m.Review(&w); err != nil {
+		t.Fatalf("unable to write review grid: %v", err)
+	}
+	t.Logf("output:\n%s\n", w.String())
+
+	// unchanged grid
+	n, err := m.ReadReview(strings.NewReader(w.String()), "jsa")
+	if err != nil {
+		t.Fatalf("unable to read review grid: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("changed cells: got %d, want %d", n, 0)
+	}
+	cmpMatrix(t, m, newMatrixWithComments())
+
+	// reviewed grid
+	grid := strings.ReplaceAll(w.String(), "kluge1969:pipidae\tarciferal/finnisternal", "kluge1969:pipidae\tarciferal")
+	grid = strings.ReplaceAll(grid, "kluge1969:ranidae\tfinnisternal\tfused", "kluge1969:ranidae\tfinnisternal\t-")
+	grid = strings.ReplaceAll(grid, "\tpresent\t", "\tpresent/absent\t")
+	n, err = m.ReadReview(strings.NewReader(grid), "jsa")
+	if err != nil {
+		t.Fatalf("unable to read review grid: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("changed cells: got %d, want %d", n, 3)
+	}
+
+	tests := map[string]struct {
+		spec  string
+		char  string
+		obs   []string
+		field matrix.Field
+		state string
+		val   string
+	}{
+		"polymorphism removed": {
+			spec:  "kluge1969:pipidae",
+			char:  "pectoral girdle",
+			obs:   []string{"arciferal"},
+			field: matrix.Reviewer,
+			state: "arciferal",
+			val:   "jsa",
+		},
+		"kept reference": {
+			spec:  "kluge1969:pipidae",
+			char:  "pectoral girdle",
+			obs:   []string{"arciferal"},
+			field: matrix.Reference,
+			state: "arciferal",
+			val:   "kluge1969",
+		},
+		"inapplicable": {
+			spec:  "kluge1969:ranidae",
+			char:  "ribs, fusion",
+			obs:   []string{matrix.NotApplicable},
+			field: matrix.Reviewer,
+			state: matrix.NotApplicable,
+			val:   "jsa",
+		},
+		"kept comments": {
+			spec:  "kluge1969:ascaphus_truei",
+			char:  "tail muscle",
+			obs:   []string{"absent", "present"},
+			field: matrix.Comments,
+			state: "present",
+			val:   "it might be not homologous with tail muscles of salamanders",
+		},
+		"added state": {
+			spec:  "kluge1969:ascaphus_truei",
+			char:  "tail muscle",
+			obs:   []string{"absent", "present"},
+			field: matrix.Reference,
+			state: "absent",
+			val:   "",
+		},
+		"unchanged": {
+			spec:  "kluge1969:ranidae",
+			char:  "tail muscle",
+			obs:   []string{"absent"},
+			field: matrix.Reviewer,
+			state: "absent",
+			val:   "",
+		},
+	}
+
+	for name, test := range tests {
+		if obs := m.Obs(test.spec, test.char); !reflect.DeepEqual(obs, test.obs) {
+			t.Errorf("%s: observation: got %v, want %v", name, obs, test.obs)
+		}
+		if v := m.Val(test.spec, test.char, test.state, test.field); v != test.val {
+			t.Errorf("%s: field %q: got %q, want %q", name, test.field, v, test.val)
+		}
+	}
+}
+
+func TestReadReviewTaxonMismatch(t *testing.T) {
+	m := newMatrixWithComments()
+
+	grid := "taxon\tspecimen\ttail muscle\n" +
+		"Ranidae\tkluge1969:pipidae\tpresent\n"
+	_, err := m.ReadReview(strings.NewReader(grid), "jsa")
+	var te *matrix.TaxonError
+	if !errors.As(err, &te) {
+		t.Fatalf("expecting taxon error, got %v", err)
+	}
+	if te.Spec != "kluge1969:pipidae" || te.Taxon != "Ranidae" || te.Want != "Pipidae" {
+		t.Errorf("taxon error: got %+v", te)
+	}
+	cmpMatrix(t, m, newMatrixWithComments())
+}
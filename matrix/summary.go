@@ -0,0 +1,87 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import "strings"
+
+// NumObs returns the number of observations
+// (i.e., the character states observed in each specimen)
+// in the matrix.
+// Inapplicable characters are counted
+// as a single observation.
+func (m *Matrix) NumObs() int {
+	var n int
+	for _, sp := range m.specs {
+		for _, obs := range sp.obs {
+			n += len(obs)
+		}
+	}
+	return n
+}
+
+// NumChars returns the number of characters
+// in the matrix.
+func (m *Matrix) NumChars() int {
+	return len(m.chars)
+}
+
+// NumSpecimens returns the number of specimens
+// in the matrix.
+func (m *Matrix) NumSpecimens() int {
+	return len(m.specs)
+}
+
+// Missing returns the number of characters
+// without observations
+// in any of the specimens of a taxon.
+// Inapplicable characters are not missing.
+func (m *Matrix) Missing(taxon string) int {
+	obs := make(map[string]bool, len(m.chars))
+	for _, sp := range m.taxSpecs(taxon) {
+		for c := range m.specs[sp].obs {
+			obs[c] = true
+		}
+	}
+	return len(m.chars) - len(obs)
+}
+
+// Coded returns the number of characters
+// with an observed state
+// in any of the specimens of a taxon.
+// Inapplicable characters are not coded.
+func (m *Matrix) Coded(taxon string) int {
+	coded := make(map[string]bool, len(m.chars))
+	for _, sp := range m.taxSpecs(taxon) {
+		for c, obs := range m.specs[sp].obs {
+			if isNoObservation(obs) {
+				continue
+			}
+			coded[c] = true
+		}
+	}
+	return len(coded)
+}
+
+// Observed returns the number of taxa
+// with observations of a character.
+// Inapplicable characters are counted
+// as observed.
+func (m *Matrix) Observed(char string) int {
+	char = strings.ToLower(strings.Join(strings.Fields(char), " "))
+	if char == "" {
+		return 0
+	}
+
+	var n int
+	for _, specs := range m.taxon {
+		for _, sp := range specs {
+			if _, ok := m.specs[sp].obs[char]; ok {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
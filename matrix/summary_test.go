@@ -0,0 +1,38 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix_test
+
+import "testing"
+
+func TestSummary(t *testing.T) {
+	m := newMatrix()
+	m.Add("Pipidae", "ford1993:pipidae", "larval teeth", "absent")
+
+	if n := m.NumObs(); n != 32 {
+		t.Errorf("observations: got %d, want %d", n, 32)
+	}
+	if n := m.NumChars(); n != 6 {
+		t.Errorf("characters: got %d, want %d", n, 6)
+	}
+	if n := m.NumSpecimens(); n != 7 {
+		t.Errorf("specimens: got %d, want %d", n, 7)
+	}
+
+	if n := m.Missing("Pipidae"); n != 0 {
+		t.Errorf("missing %q: got %d, want %d", "Pipidae", n, 0)
+	}
+	if n := m.Missing("Rhinophrynidae"); n != 1 {
+		t.Errorf("missing %q: got %d, want %d", "Rhinophrynidae", n, 1)
+	}
+	if n := m.Coded("Rhinophrynidae"); n != 4 {
+		t.Errorf("coded %q: got %d, want %d", "Rhinophrynidae", n, 4)
+	}
+	if n := m.Observed("ribs, fusion"); n != 6 {
+		t.Errorf("observed %q: got %d, want %d", "ribs, fusion", n, 6)
+	}
+	if n := m.Observed("Larval Teeth"); n != 1 {
+		t.Errorf("observed %q: got %d, want %d", "larval teeth", n, 1)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import "slices"
+
+// A TaxPolicy defines how the observations
+// of the specimens of a taxon
+// are aggregated by TaxObs.
+// Policies can be combined
+// (e.g., IgnoreUncertain|IgnoreInferred).
+type TaxPolicy int
+
+// Valid taxon policies.
+const (
+	// Union uses the union of the states
+	// observed in the specimens of the taxon.
+	// Uncertain observations are only used
+	// if the taxon has no other observations.
+	// This is the default policy.
+	Union TaxPolicy = 0
+
+	// IgnoreUncertain ignores
+	// the uncertain observations.
+	IgnoreUncertain TaxPolicy = 1
+
+	// IgnoreInferred ignores
+	// the observations inferred
+	// from other specimens.
+	IgnoreInferred TaxPolicy = 2
+)
+
+// SetTaxPolicy sets the policy used
+// to aggregate the observations of a taxon.
+func (m *Matrix) SetTaxPolicy(p TaxPolicy) {
+	m.taxPolicy = p
+}
+
+// TaxObs returns the states of a character
+// for a taxon,
+// as the union of the states observed
+// in all the specimens of the taxon
+// (see TaxPolicy).
+//
+// If the taxon has only uncertain observations,
+// it returns all the states of the character
+// and true,
+// to indicate the uncertainty.
+// If no state is observed,
+// it returns NotApplicable,
+// if the character is inapplicable in any specimen,
+// or Unknown.
+func (m *Matrix) TaxObs(taxon, char string) (states []string, uncertain bool) {
+	var na, unc bool
+	set := make(map[string]bool)
+	for _, id := range m.taxSpecs(taxon) {
+		sp, ok := m.specs[id]
+		if !ok {
+			continue
+		}
+		obs := sp.charObs(char)
+		if len(obs) == 0 {
+			continue
+		}
+		switch firstState(obs) {
+		case Unknown:
+			continue
+		case NotApplicable:
+			na = true
+			continue
+		}
+		for _, o := range obs {
+			if m.taxPolicy&IgnoreInferred != 0 && o.source == Inferred {
+				continue
+			}
+			if o.uncertain {
+				unc = true
+				continue
+			}
+			set[o.name] = true
+		}
+	}
+
+	if len(set) == 0 {
+		if unc && m.taxPolicy&IgnoreUncertain == 0 {
+			return m.States(char), true
+		}
+		if na {
+			return []string{NotApplicable}, false
+		}
+		return []string{Unknown}, false
+	}
+
+	states = make([]string, 0, len(set))
+	for s := range set {
+		states = append(states, s)
+	}
+	slices.Sort(states)
+	return states, false
+}
+
+// FirstState returns the first state
+// (in lexicographic order)
+// of a set of observations.
+func firstState(obs map[string]*observation) string {
+	var first string
+	for _, o := range obs {
+		if first == "" || o.name < first {
+			first = o.name
+		}
+	}
+	return first
+}
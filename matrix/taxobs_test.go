@@ -0,0 +1,95 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix"
+)
+
+func TestTaxObs(t *testing.T) {
+	m := matrix.New()
+	m.Add("Pipidae", "kluge1969:pipidae", "tail muscle", "absent")
+	m.Add("Pipidae", "ford1993:pipidae", "tail muscle", "present")
+	m.Add("Pipidae", "kluge1969:pipidae", "ribs, fusion", "fused in adults")
+	m.Add("Pipidae", "ford1993:pipidae", "ribs, fusion", "free")
+	m.Set("ford1993:pipidae", "ribs, fusion", "free", "true", matrix.Uncertain)
+	m.Add("Pipidae", "kluge1969:pipidae", "pectoral girdle", "arciferal")
+	m.Set("kluge1969:pipidae", "pectoral girdle", "arciferal", "true", matrix.Uncertain)
+	m.Add("Pipidae", "ford1993:pipidae", "vertebral ossification", "stegochordal")
+	m.Set("ford1993:pipidae", "vertebral ossification", "stegochordal", matrix.Inferred, matrix.Source)
+	m.Add("Pipidae", "kluge1969:pipidae", "larval teeth", matrix.NotApplicable)
+	m.Add("Ranidae", "kluge1969:ranidae", "pectoral girdle", "firmisternal")
+
+	tests := map[string]struct {
+		policy matrix.TaxPolicy
+		char   string
+		states []string
+		unc    bool
+	}{
+		"union": {
+			char:   "tail muscle",
+			states: []string{"absent", "present"},
+		},
+		"uncertain ignored": {
+			char:   "ribs, fusion",
+			states: []string{"fused in adults"},
+		},
+		"only uncertain": {
+			char:   "pectoral girdle",
+			states: []string{"arciferal", "firmisternal"},
+			unc:    true,
+		},
+		"strict": {
+			policy: matrix.IgnoreUncertain,
+			char:   "pectoral girdle",
+			states: []string{matrix.Unknown},
+		},
+		"inferred": {
+			char:   "vertebral ossification",
+			states: []string{"stegochordal"},
+		},
+		"no inferred": {
+			policy: matrix.IgnoreUncertain | matrix.IgnoreInferred,
+			char:   "vertebral ossification",
+			states: []string{matrix.Unknown},
+		},
+		"inapplicable": {
+			char:   "larval teeth",
+			states: []string{matrix.NotApplicable},
+		},
+		"unknown": {
+			char:   "scapula",
+			states: []string{matrix.Unknown},
+		},
+	}
+
+	for name, test := range tests {
+		m.SetTaxPolicy(test.policy)
+		states, unc := m.TaxObs("pipidae", test.char)
+		if !reflect.DeepEqual(states, test.states) {
+			t.Errorf("%s: states: got %v, want %v", name, states, test.states)
+		}
+		if unc != test.unc {
+			t.Errorf("%s: uncertain: got %v, want %v", name, unc, test.unc)
+		}
+	}
+}
+
+func BenchmarkTaxObs(b *testing.B) {
+	m := largeMatrix(100, 100)
+	taxa := m.Taxa()
+	chars := m.Chars()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, tx := range taxa {
+			for _, c := range chars {
+				m.TaxObs(tx, c)
+			}
+		}
+	}
+}
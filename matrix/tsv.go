@@ -5,12 +5,14 @@
 package matrix
 
 import (
+	"bufio"
 	"encoding/csv"
-	"errors"
 	"fmt"
 	"io"
 	"slices"
 	"strings"
+
+	"github.com/js-arias/phydata/internal/tsvchunk"
 )
 
 var headerFields = []string{
@@ -24,6 +26,11 @@ var valFields = []Field{
 	Reference,
 	ImageLink,
 	Comments,
+	Reviewer,
+	AddedBy,
+	Timestamp,
+	Uncertain,
+	Source,
 }
 
 // ReadTSV reads a set of specimen observations
@@ -41,6 +48,18 @@ var valFields = []Field{
 //   - reference, an ID of a bibliographic reference
 //   - image, a path to an image of the observation
 //   - comments, simple comments about the observation
+//   - reviewer, the name of the last reviewer of the observation
+//   - added-by, the name of the person that added the observation
+//   - timestamp, the time in which the observation was added,
+//     in RFC 3339 format
+//   - uncertain, if "true", the assignment of the state is doubtful
+//   - source, the source of the coding,
+//     either "observed", "literature", or "inferred"
+//
+// Any other column is kept as an extra field
+// of the observations
+// (see ExtraFields)
+// and it will be written back by TSV.
 //
 // Here is an example file:
 //
@@ -52,61 +71,117 @@ var valFields = []Field{
 //	Discoglossidae	kluge1969:discoglossidae	ribs, fusion	free	kluge1969
 //	Pipidae	kluge1969:pipidae	tail muscle	absent	kluge1969
 //	Pipidae	kluge1969:pipidae	ribs, fusion	fused in adults	kluge1969
+//
+// Rows with an empty taxon, specimen, character, or state
+// are ignored.
+// Rows with a specimen assigned to a different taxon
+// are skipped,
+// and reported as warnings
+// (see Warnings).
+//
+// Besides tabs,
+// the file can be delimited by commas or semicolons
+// (as exported by spreadsheets in different locales):
+// the delimiter is detected from the header.
+//
+// Large files are decoded in parallel
+// (using up to GOMAXPROCS blocks at the same time),
+// but the rows are added in the order of the file.
 func (m *Matrix) ReadTSV(r io.Reader) error {
-	tab := csv.NewReader(r)
-	tab.Comma = '\t'
+	return m.readTSV(r, 0, false)
+}
+
+// ReadStrictTSV is like ReadTSV,
+// but it returns an error
+// if a row has an empty taxon, specimen, character, or state,
+// or a specimen assigned to a different taxon.
+func (m *Matrix) ReadStrictTSV(r io.Reader) error {
+	return m.readTSV(r, 0, true)
+}
+
+// ReadDelimited is like ReadTSV,
+// but the fields are delimited
+// by the given delimiter
+// (e.g., ',' or ';').
+// If the delimiter is 0,
+// it will be detected from the header.
+// If strict is true,
+// it works like ReadStrictTSV.
+func (m *Matrix) ReadDelimited(r io.Reader, comma rune, strict bool) error {
+	return m.readTSV(r, comma, strict)
+}
+
+func (m *Matrix) readTSV(r io.Reader, comma rune, strict bool) error {
+	br := bufio.NewReaderSize(r, 1<<16)
+	if comma == 0 {
+		var err error
+		comma, err = sniffDelimiter(br)
+		if err != nil {
+			return err
+		}
+	}
+
+	tab := csv.NewReader(br)
+	tab.Comma = comma
 	tab.Comment = '#'
 
 	head, err := tab.Read()
 	if err != nil {
 		return fmt.Errorf("while reading header: %v", err)
 	}
-	fields := make(map[string]int, len(head))
-	for i, h := range head {
-		h = strings.ToLower(h)
-		fields[h] = i
-	}
+	fields := m.headers.fields(head)
 	for _, h := range headerFields {
 		if _, ok := fields[h]; !ok {
 			return fmt.Errorf("expecting field %q", h)
 		}
 	}
+	extra := extraColumns(head, fields)
+	line, _ := tab.FieldPos(len(head) - 1)
 
-	for {
-		row, err := tab.Read()
-		if errors.Is(err, io.EOF) {
-			break
-		}
-		ln, _ := tab.FieldPos(0)
-		if err != nil {
-			return fmt.Errorf("on row %d: %v", ln, err)
-		}
-
+	return tsvchunk.Read(br, comma, line, len(head), nil, func(row []string, ln int) error {
 		f := "taxon"
 		tax := row[fields[f]]
 		if tax == "" {
-			continue
+			if strict {
+				return fmt.Errorf("on row %d: empty field %q", ln, f)
+			}
+			return nil
 		}
 
 		f = "specimen"
 		spec := row[fields[f]]
 		if spec == "" {
-			continue
+			if strict {
+				return fmt.Errorf("on row %d: empty field %q", ln, f)
+			}
+			return nil
 		}
 
 		f = "character"
 		char := row[fields[f]]
 		if char == "" {
-			continue
+			if strict {
+				return fmt.Errorf("on row %d: empty field %q", ln, f)
+			}
+			return nil
 		}
 
 		f = "state"
 		state := row[fields[f]]
 		if state == "" {
-			continue
+			if strict {
+				return fmt.Errorf("on row %d: empty field %q", ln, f)
+			}
+			return nil
 		}
 
-		m.Add(tax, spec, char, state)
+		if err := m.Add(tax, spec, char, state); err != nil {
+			if strict {
+				return fmt.Errorf("on row %d: %v", ln, err)
+			}
+			m.warnings = append(m.warnings, fmt.Errorf("on row %d: %w", ln, err))
+			return nil
+		}
 
 		for _, ff := range valFields {
 			f = string(ff)
@@ -118,9 +193,11 @@ func (m *Matrix) ReadTSV(r io.Reader) error {
 			v := row[i]
 			m.Set(spec, char, state, v, ff)
 		}
-	}
-
-	return nil
+		for i, f := range extra {
+			m.Set(spec, char, state, row[i], f)
+		}
+		return nil
+	})
 }
 
 // TSV writes an observation matrix as a TSV file.
@@ -130,29 +207,19 @@ func (m *Matrix) TSV(w io.Writer) error {
 	tab.UseCRLF = true
 
 	// header
-	header := []string{"taxon", "specimen", "character", "state", "reference", "image", "comments"}
+	header := []string{"taxon", "specimen", "character", "state", "reference", "image", "comments", "reviewer", "added-by", "timestamp", "uncertain", "source"}
+	extra := m.ExtraFields()
+	for _, f := range extra {
+		header = append(header, string(f))
+	}
 	if err := tab.Write(header); err != nil {
 		return fmt.Errorf("unable to write header: %v", err)
 	}
 
-	tax := make(map[string][]string)
-	var tn []string
-	for _, sp := range m.specs {
-		t, ok := tax[sp.taxon]
-		if !ok {
-			tn = append(tn, sp.taxon)
-		}
-		t = append(t, sp.name)
-		tax[sp.taxon] = t
-	}
-	slices.Sort(tn)
-
 	chars := m.Chars()
 
-	for _, tt := range tn {
-		t := tax[tt]
-		slices.Sort(t)
-		for _, spv := range t {
+	for _, tt := range m.Taxa() {
+		for _, spv := range m.taxon[tt] {
 			sp := m.specs[spv]
 
 			for _, c := range chars {
@@ -171,7 +238,13 @@ func (m *Matrix) TSV(w io.Writer) error {
 						o.ref,
 						o.img,
 						o.comment,
+						o.reviewer,
+						o.addedBy,
+						o.timestamp,
+						uncertainVal(o),
+						o.source,
 					}
+					row = appendExtra(row, o, extra)
 					if err := tab.Write(row); err != nil {
 						return fmt.Errorf("while writing data: %v", err)
 					}
@@ -194,7 +267,13 @@ func (m *Matrix) TSV(w io.Writer) error {
 						o.ref,
 						o.img,
 						o.comment,
+						o.reviewer,
+						o.addedBy,
+						o.timestamp,
+						uncertainVal(o),
+						o.source,
 					}
+					row = appendExtra(row, o, extra)
 					if err := tab.Write(row); err != nil {
 						return fmt.Errorf("while writing data: %v", err)
 					}
@@ -209,3 +288,56 @@ func (m *Matrix) TSV(w io.Writer) error {
 	}
 	return nil
 }
+
+// UncertainVal returns the value
+// of the uncertain field of an observation.
+func uncertainVal(o *observation) string {
+	if o.uncertain {
+		return "true"
+	}
+	return ""
+}
+
+// AppendExtra adds the values of the extra fields
+// of an observation
+// to a row.
+func appendExtra(row []string, o *observation, extra []Field) []string {
+	for _, f := range extra {
+		row = append(row, o.extra[string(f)])
+	}
+	return row
+}
+
+// ExtraColumns returns the columns of a header
+// that are not used by a known field,
+// indexed by its column.
+func extraColumns(head []string, fields map[string]int) map[int]Field {
+	used := make(map[int]bool, len(fields))
+	known := make(map[string]bool, len(headerFields)+len(valFields))
+	for _, f := range headerFields {
+		used[fields[f]] = true
+		known[f] = true
+	}
+	for _, f := range valFields {
+		if i, ok := fields[string(f)]; ok {
+			used[i] = true
+		}
+		known[string(f)] = true
+	}
+
+	extra := make(map[int]Field)
+	for i, c := range head {
+		if used[i] {
+			continue
+		}
+		if i == 0 {
+			c = strings.TrimPrefix(c, "\ufeff")
+		}
+		name := headerName(c)
+		if name == "" || known[name] {
+			continue
+		}
+		extra[i] = Field(name)
+	}
+	return extra
+}
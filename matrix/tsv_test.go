@@ -6,6 +6,12 @@ package matrix_test
 
 import (
 	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -13,38 +19,38 @@ import (
 )
 
 var obsText = `# character observations
-taxon	specimen	character	state	reference	image	comments
-Ascaphus truei	kluge1969:ascaphus_truei	tail muscle	present	kluge1969	ascaphus-tail.png	it might be not homologous with tail muscles of salamanders
-Ascaphus truei	kluge1969:ascaphus_truei	ribs, fusion	free	kluge1969		
-Ascaphus truei	kluge1969:ascaphus_truei	vertebral ossification	ectochordal	kluge1969		
-Ascaphus truei	kluge1969:ascaphus_truei	pectoral girdle	arciferal	kluge1969		
-Ascaphus truei	kluge1969:ascaphus_truei	scapula, relation to clavical	overlap	kluge1969		
-Discoglossidae	kluge1969:discoglossidae	tail muscle	absent	kluge1969		
-Discoglossidae	kluge1969:discoglossidae	ribs, fusion	free	kluge1969		
-Discoglossidae	kluge1969:discoglossidae	vertebral ossification	stegochordal	kluge1969		
-Discoglossidae	kluge1969:discoglossidae	pectoral girdle	arciferal	kluge1969		
-Discoglossidae	kluge1969:discoglossidae	scapula, relation to clavical	overlap	kluge1969		
-Pipidae	kluge1969:pipidae	tail muscle	absent	kluge1969		
-Pipidae	kluge1969:pipidae	ribs, fusion	fused in adults	kluge1969		
-Pipidae	kluge1969:pipidae	vertebral ossification	stegochordal	kluge1969		
-Pipidae	kluge1969:pipidae	pectoral girdle	arciferal	kluge1969		
-Pipidae	kluge1969:pipidae	pectoral girdle	finnisternal	kluge1969		
-Pipidae	kluge1969:pipidae	scapula, relation to clavical	overlap	kluge1969		
-Rhinophrynidae	kluge1969:rhinophrynidae	tail muscle	absent	kluge1969		
-Rhinophrynidae	kluge1969:rhinophrynidae	ribs, fusion	<na>	kluge1969		
-Rhinophrynidae	kluge1969:rhinophrynidae	vertebral ossification	ectochordal	kluge1969		
-Rhinophrynidae	kluge1969:rhinophrynidae	pectoral girdle	arciferal	kluge1969		
-Rhinophrynidae	kluge1969:rhinophrynidae	scapula, relation to clavical	overlap	kluge1969		
-Bufonidae	kluge1969:bufonidae	tail muscle	absent	kluge1969		
-Bufonidae	kluge1969:bufonidae	ribs, fusion	fused	kluge1969		
-Bufonidae	kluge1969:bufonidae	vertebral ossification	holochordal	kluge1969		
-Bufonidae	kluge1969:bufonidae	pectoral girdle	arciferal	kluge1969		
-Bufonidae	kluge1969:bufonidae	scapula, relation to clavical	juxtapose	kluge1969		
-Ranidae	kluge1969:ranidae	tail muscle	absent	kluge1969		
-Ranidae	kluge1969:ranidae	ribs, fusion	fused	kluge1969		
-Ranidae	kluge1969:ranidae	vertebral ossification	holochordal	kluge1969		
-Ranidae	kluge1969:ranidae	pectoral girdle	finnisternal	kluge1969		
-Ranidae	kluge1969:ranidae	scapula, relation to clavical	juxtapose	kluge1969		
+taxon	specimen	character	state	reference	image	comments	added-by	timestamp	uncertain	source
+Ascaphus truei	kluge1969:ascaphus_truei	tail muscle	present	kluge1969	ascaphus-tail.png	it might be not homologous with tail muscles of salamanders	J. S. Arias	2024-03-15T10:30:00Z		
+Ascaphus truei	kluge1969:ascaphus_truei	ribs, fusion	free	kluge1969						
+Ascaphus truei	kluge1969:ascaphus_truei	vertebral ossification	ectochordal	kluge1969						
+Ascaphus truei	kluge1969:ascaphus_truei	pectoral girdle	arciferal	kluge1969						
+Ascaphus truei	kluge1969:ascaphus_truei	scapula, relation to clavical	overlap	kluge1969						
+Discoglossidae	kluge1969:discoglossidae	tail muscle	absent	kluge1969						
+Discoglossidae	kluge1969:discoglossidae	ribs, fusion	free	kluge1969						
+Discoglossidae	kluge1969:discoglossidae	vertebral ossification	stegochordal	kluge1969						
+Discoglossidae	kluge1969:discoglossidae	pectoral girdle	arciferal	kluge1969						
+Discoglossidae	kluge1969:discoglossidae	scapula, relation to clavical	overlap	kluge1969						
+Pipidae	kluge1969:pipidae	tail muscle	absent	kluge1969						
+Pipidae	kluge1969:pipidae	ribs, fusion	fused in adults	kluge1969					true	inferred
+Pipidae	kluge1969:pipidae	vertebral ossification	stegochordal	kluge1969						
+Pipidae	kluge1969:pipidae	pectoral girdle	arciferal	kluge1969						
+Pipidae	kluge1969:pipidae	pectoral girdle	finnisternal	kluge1969						
+Pipidae	kluge1969:pipidae	scapula, relation to clavical	overlap	kluge1969						
+Rhinophrynidae	kluge1969:rhinophrynidae	tail muscle	absent	kluge1969						
+Rhinophrynidae	kluge1969:rhinophrynidae	ribs, fusion	<na>	kluge1969						
+Rhinophrynidae	kluge1969:rhinophrynidae	vertebral ossification	ectochordal	kluge1969						
+Rhinophrynidae	kluge1969:rhinophrynidae	pectoral girdle	arciferal	kluge1969						
+Rhinophrynidae	kluge1969:rhinophrynidae	scapula, relation to clavical	overlap	kluge1969						
+Bufonidae	kluge1969:bufonidae	tail muscle	absent	kluge1969						
+Bufonidae	kluge1969:bufonidae	ribs, fusion	fused	kluge1969						
+Bufonidae	kluge1969:bufonidae	vertebral ossification	holochordal	kluge1969						
+Bufonidae	kluge1969:bufonidae	pectoral girdle	arciferal	kluge1969						
+Bufonidae	kluge1969:bufonidae	scapula, relation to clavical	juxtapose	kluge1969						
+Ranidae	kluge1969:ranidae	tail muscle	absent	kluge1969						
+Ranidae	kluge1969:ranidae	ribs, fusion	fused	kluge1969						
+Ranidae	kluge1969:ranidae	vertebral ossification	holochordal	kluge1969						
+Ranidae	kluge1969:ranidae	pectoral girdle	finnisternal	kluge1969						
+Ranidae	kluge1969:ranidae	scapula, relation to clavical	juxtapose	kluge1969						
 `
 
 func TestReadTSV(t *testing.T) {
@@ -57,6 +63,150 @@ func TestReadTSV(t *testing.T) {
 	cmpMatrix(t, m, want)
 }
 
+func TestReadStrictTSV(t *testing.T) {
+	m := matrix.New()
+	if err := m.ReadStrictTSV(strings.NewReader(obsText)); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+	cmpMatrix(t, m, newMatrixWithComments())
+
+	text := "taxon\tspecimen\tcharacter\tstate\n" +
+		"Pipidae\tkluge1969:pipidae\ttail muscle\tabsent\n" +
+		"Ranidae\tkluge1969:ranidae\ttail muscle\t\n"
+	m = matrix.New()
+	if err := m.ReadTSV(strings.NewReader(text)); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+	if err := matrix.New().ReadStrictTSV(strings.NewReader(text)); err == nil {
+		t.Errorf("strict: expecting error on empty state")
+	}
+}
+
+func TestReadTSVTaxonMismatch(t *testing.T) {
+	text := "taxon\tspecimen\tcharacter\tstate\n" +
+		"Pipidae\tkluge1969:pipidae\ttail muscle\tabsent\n" +
+		"Ranidae\tkluge1969:pipidae\tribs, fusion\tfree\n" +
+		"Pipidae\tkluge1969:pipidae\tribs, fusion\tfused in adults\n"
+
+	m := matrix.New()
+	if err := m.ReadTSV(strings.NewReader(text)); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+	w := m.Warnings()
+	if len(w) != 1 {
+		t.Fatalf("warnings: got %d, want 1", len(w))
+	}
+	var te *matrix.TaxonError
+	if !errors.As(w[0], &te) {
+		t.Fatalf("warning: got %v, want a taxon error", w[0])
+	}
+	if te.Spec != "kluge1969:pipidae" || te.Taxon != "Ranidae" || te.Want != "Pipidae" {
+		t.Errorf("warning: got %+v", te)
+	}
+	if obs := m.Obs("kluge1969:pipidae", "ribs, fusion"); !reflect.DeepEqual(obs, []string{"fused in adults"}) {
+		t.Errorf("observation: got %v, want %v", obs, []string{"fused in adults"})
+	}
+	if tx := m.Taxa(); !reflect.DeepEqual(tx, []string{"Pipidae"}) {
+		t.Errorf("taxa: got %v, want %v", tx, []string{"Pipidae"})
+	}
+
+	if err := matrix.New().ReadStrictTSV(strings.NewReader(text)); err == nil {
+		t.Errorf("strict: expecting error on taxon mismatch")
+	}
+}
+
+func TestReadDelimited(t *testing.T) {
+	m := matrix.New()
+	text := strings.ReplaceAll(obsText, "\t", ";")
+	if err := m.ReadTSV(strings.NewReader(text)); err != nil {
+		t.Fatalf("unable to read semicolon delimited data: %v", err)
+	}
+	cmpMatrix(t, m, newMatrixWithComments())
+
+	text = "taxon,specimen,character,state\n" +
+		"Pipidae,kluge1969:pipidae,\"ribs, fusion\",fused in adults\n"
+	m = matrix.New()
+	if err := m.ReadDelimited(strings.NewReader(text), ',', true); err != nil {
+		t.Fatalf("unable to read comma delimited data: %v", err)
+	}
+	if obs := m.Obs("kluge1969:pipidae", "ribs, fusion"); len(obs) != 1 || obs[0] != "fused in adults" {
+		t.Errorf("comma delimited: got %v, want %v", obs, []string{"fused in adults"})
+	}
+}
+
+func TestReadTSVChunks(t *testing.T) {
+	text, want := largeObsText(40_000)
+
+	m := matrix.New()
+	if err := m.ReadTSV(strings.NewReader(text)); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+	cmpMatrix(t, m, want)
+
+	// errors report the line in the file
+	ln := strings.Count(text, "\n") + 1
+	text += "Pipidae\tkluge1969:pipidae\ttail muscle\n"
+	err := matrix.New().ReadTSV(strings.NewReader(text))
+	if err == nil {
+		t.Fatalf("expecting error on row %d", ln)
+	}
+	if w := fmt.Sprintf("on row %d: record on line %d:", ln, ln); !strings.HasPrefix(err.Error(), w) {
+		t.Errorf("error: got %q, want prefix %q", err, w)
+	}
+}
+
+// LargeObsText returns a TSV file with n observations,
+// with comment lines, and quoted fields with new lines,
+// and the matrix with the observations.
+func largeObsText(n int) (string, *matrix.Matrix) {
+	m := matrix.New()
+	var b strings.Builder
+	tab := csv.NewWriter(&b)
+	tab.Comma = '\t'
+	tab.Write([]string{"taxon", "specimen", "character", "state", "comments"})
+	for i := 0; i < n; i++ {
+		if i%1000 == 0 {
+			tab.Flush()
+			fmt.Fprintf(&b, "# block %d: a \"quoted comment\n", i/1000)
+		}
+		tax := fmt.Sprintf("Taxon %d", i%500)
+		spec := fmt.Sprintf("spec:%d", i%500)
+		char := fmt.Sprintf("char %d", i/500)
+		state := fmt.Sprintf("state %d", i%3)
+		var comment string
+		if i%7 == 0 {
+			comment = fmt.Sprintf("a comment with\na new line, and \"quotes\" %d", i)
+		}
+		tab.Write([]string{tax, spec, char, state, comment})
+		m.Add(tax, spec, char, state)
+		m.Set(spec, char, state, comment, matrix.Comments)
+	}
+	tab.Flush()
+	return b.String(), m
+}
+
+func BenchmarkReadTSV(b *testing.B) {
+	text, _ := largeObsText(100_000)
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+
+	procs := []int{1}
+	if n := runtime.NumCPU(); n > 1 {
+		procs = append(procs, n)
+	}
+	for _, p := range procs {
+		b.Run(fmt.Sprintf("procs=%d", p), func(b *testing.B) {
+			runtime.GOMAXPROCS(p)
+			b.SetBytes(int64(len(text)))
+			for i := 0; i < b.N; i++ {
+				m := matrix.New()
+				if err := m.ReadTSV(strings.NewReader(text)); err != nil {
+					b.Fatalf("unable to read TSV data: %v", err)
+				}
+			}
+		})
+	}
+}
+
 func TestWriteTSV(t *testing.T) {
 	m := newMatrixWithComments()
 	var w bytes.Buffer
@@ -72,3 +222,46 @@ func TestWriteTSV(t *testing.T) {
 
 	cmpMatrix(t, got, m)
 }
+
+func TestExtraFields(t *testing.T) {
+	text := "taxon\tspecimen\tcharacter\tstate\tMuseum Drawer\tlab\n" +
+		"Pipidae\tkluge1969:pipidae\ttail muscle\tabsent\tB-12\t\n" +
+		"Pipidae\tkluge1969:pipidae\tribs, fusion\tfused in adults\t\tlab 3\n"
+	m := matrix.New()
+	if err := m.ReadTSV(strings.NewReader(text)); err != nil {
+		t.Fatalf("unable to read data: %v", err)
+	}
+
+	want := []matrix.Field{"lab", "museum drawer"}
+	if got := m.ExtraFields(); !reflect.DeepEqual(got, want) {
+		t.Errorf("extra fields: got %v, want %v", got, want)
+	}
+	if v := m.Val("kluge1969:pipidae", "tail muscle", "absent", "museum drawer"); v != "B-12" {
+		t.Errorf("extra field: got %q, want %q", v, "B-12")
+	}
+
+	var w bytes.Buffer
+	if err := m.TSV(&w); err != nil {
+		t.Fatalf("unable to write TSV data: %v", err)
+	}
+	got := matrix.New()
+	if err := got.ReadTSV(&w); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+	if v := got.Val("kluge1969:pipidae", "ribs, fusion", "fused in adults", "lab"); v != "lab 3" {
+		t.Errorf("extra field after round trip: got %q, want %q", v, "lab 3")
+	}
+	if v := got.Val("kluge1969:pipidae", "tail muscle", "absent", "lab"); v != "" {
+		t.Errorf("empty extra field after round trip: got %q, want %q", v, "")
+	}
+}
+
+func BenchmarkTSV(b *testing.B) {
+	m := largeMatrix(100, 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := m.TSV(io.Discard); err != nil {
+			b.Fatalf("unable to write TSV data: %v", err)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/phydata/names"
+)
+
+// A Legend is a map of character names
+// to a map of state codes
+// to state names.
+type Legend map[string]map[string]string
+
+// ReadLegend reads a legend of state codes
+// from a TSV file.
+//
+// The TSV file must contains the following fields:
+//
+//   - character, the name of the character
+//   - code, the code of the state
+//   - state, the name of the state
+//
+// Here is an example file:
+//
+//	# legend
+//	character	code	state
+//	tail muscle	0	absent
+//	tail muscle	1	present
+func ReadLegend(r io.Reader) (Legend, error) {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return nil, fmt.Errorf("while reading header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(h)
+		fields[h] = i
+	}
+	for _, h := range []string{"character", "code", "state"} {
+		if _, ok := fields[h]; !ok {
+			return nil, fmt.Errorf("expecting field %q", h)
+		}
+	}
+
+	legend := make(Legend)
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		char := strings.Join(strings.Fields(strings.ReplaceAll(row[fields["character"]], "_", " ")), " ")
+		if char == "" {
+			continue
+		}
+		char = strings.ToLower(char)
+		code := strings.ToLower(strings.TrimSpace(row[fields["code"]]))
+		if code == "" {
+			continue
+		}
+		state := strings.Join(strings.Fields(strings.ReplaceAll(row[fields["state"]], "_", " ")), " ")
+		if state == "" {
+			return nil, fmt.Errorf("on row %d: character %q: code %q without state name", ln, char, code)
+		}
+
+		l, ok := legend[char]
+		if !ok {
+			l = make(map[string]string)
+			legend[char] = l
+		}
+		l[code] = state
+	}
+	return legend, nil
+}
+
+// ReadWide reads a wide-format matrix
+// from a delimited text file
+// (tab, comma, or semicolon delimited,
+// the delimiter is detected from the header).
+// It require an ID for a bibliographic reference,
+// that will be used as the prefix
+// for the specimen identifiers,
+// and an optional legend.
+// Lines starting with '#' are ignored.
+// See AddGrid for the description of the format.
+func (m *Matrix) ReadWide(r io.Reader, ref string, legend Legend) error {
+	br := bufio.NewReaderSize(r, 1<<16)
+	comma, err := sniffDelimiter(br)
+	if err != nil {
+		return err
+	}
+
+	tab := csv.NewReader(br)
+	tab.Comma = comma
+	tab.Comment = '#'
+	tab.FieldsPerRecord = -1
+
+	var grid [][]string
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			ln, _ := tab.FieldPos(0)
+			return fmt.Errorf("on row %d: %v", ln, err)
+		}
+		grid = append(grid, row)
+	}
+	return m.AddGrid(grid, ref, legend)
+}
+
+// SniffDelimiter returns the delimiter
+// of the first non-comment line
+// of a delimited text file.
+func sniffDelimiter(r *bufio.Reader) (rune, error) {
+	b, err := r.Peek(r.Size())
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return 0, err
+	}
+	for _, ln := range strings.Split(string(b), "\n") {
+		ln = strings.TrimSpace(ln)
+		if ln == "" || ln[0] == '#' {
+			continue
+		}
+		if strings.Contains(ln, "\t") {
+			return '\t', nil
+		}
+		if strings.Contains(ln, ",") {
+			return ',', nil
+		}
+		if strings.Contains(ln, ";") {
+			return ';', nil
+		}
+		break
+	}
+	return '\t', nil
+}
+
+// AddGrid adds the observations of a wide-format grid,
+// a table with taxa in rows
+// and characters in columns,
+// for example,
+// as coded in a spreadsheet.
+// It require an ID for a bibliographic reference,
+// that will be used as the prefix
+// for the specimen identifiers.
+//
+// The first row of the grid is the header,
+// the first cell is ignored,
+// and the other cells are the character names.
+// If the first cell of the second row is empty,
+// or it is "states" or "legend",
+// the row is read as a legend with the state names
+// of each character.
+// A legend is a list of state names
+// separated by semicolons,
+// ordered by its state code
+// (e.g., "absent; present");
+// codes can be explicitly defined
+// using the form "<code>=<name>"
+// (e.g., "0=absent; 1=present").
+//
+// The other rows are the taxa,
+// with the taxon name in the first column.
+// Each cell is a state code,
+// a state name,
+// or a polymorphism of states separated by '/'
+// (e.g., "0/1"),
+// or enclosed in brackets
+// (e.g., "{01}").
+// A question mark '?' is an unknown state,
+// and a dash '-' is an inapplicable character.
+// Empty cells are ignored.
+// State codes are digits,
+// or hexadecimal digits
+// (as in NEXUS files).
+// If a state code is not defined in the legend,
+// the state will be named as "state <code>".
+//
+// If a legend is given,
+// it will be used to define the state names
+// of the characters without a legend row.
+func (m *Matrix) AddGrid(grid [][]string, ref string, legend Legend) error {
+	if len(grid) == 0 {
+		return nil
+	}
+	head := grid[0]
+	if len(head) < 2 {
+		return fmt.Errorf("grid without characters")
+	}
+	chars := make([]string, len(head))
+	for i := 1; i < len(head); i++ {
+		c := strings.Join(strings.Fields(strings.ReplaceAll(head[i], "_", " ")), " ")
+		if c == "" {
+			continue
+		}
+		chars[i] = c
+	}
+
+	rows := grid[1:]
+	legends := make([]map[string]string, len(head))
+	for i, c := range chars {
+		if c == "" {
+			continue
+		}
+		legends[i] = legend[strings.ToLower(c)]
+	}
+	if len(rows) > 0 && isLegend(rows[0]) {
+		for i := 1; i < len(rows[0]) && i < len(head); i++ {
+			if l := parseLegend(rows[0][i]); len(l) > 0 {
+				legends[i] = l
+			}
+		}
+		rows = rows[1:]
+	}
+
+	for i, row := range rows {
+		if len(row) == 0 {
+			continue
+		}
+		tax := strings.Join(strings.Fields(strings.ReplaceAll(row[0], "_", " ")), " ")
+		if tax == "" {
+			continue
+		}
+		tax = names.Taxon(tax)
+		spec := names.Specimen(ref + ":" + tax)
+
+		for j := 1; j < len(row) && j < len(head); j++ {
+			if chars[j] == "" {
+				continue
+			}
+			states, err := cellStates(row[j], legends[j])
+			if err != nil {
+				return fmt.Errorf("row %d: taxon %q: character %q: %v", i+1, tax, chars[j], err)
+			}
+			for _, s := range states {
+				if err := m.Add(tax, spec, chars[j], s); err != nil {
+					return fmt.Errorf("row %d: %v", i+1, err)
+				}
+				if s == Unknown {
+					continue
+				}
+				m.Set(spec, chars[j], s, ref, Reference)
+			}
+		}
+	}
+	return nil
+}
+
+// IsLegend returns true
+// if a row is a legend row.
+func isLegend(row []string) bool {
+	if len(row) == 0 {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(row[0])) {
+	case "", "states", "legend":
+		return true
+	}
+	return false
+}
+
+// ParseLegend returns a map of state codes
+// to state names.
+func parseLegend(cell string) map[string]string {
+	legend := make(map[string]string)
+	var i int
+	for _, s := range strings.Split(cell, ";") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if code, name, ok := strings.Cut(s, "="); ok {
+			code = strings.ToLower(strings.TrimSpace(code))
+			name = strings.Join(strings.Fields(strings.ReplaceAll(name, "_", " ")), " ")
+			if code != "" && name != "" {
+				legend[code] = name
+			}
+			continue
+		}
+		name := strings.Join(strings.Fields(strings.ReplaceAll(s, "_", " ")), " ")
+		legend[strconv.FormatInt(int64(i), 16)] = name
+		legend[strconv.Itoa(i)] = name
+		i++
+	}
+	return legend
+}
+
+// CellStates returns the states
+// coded in a cell.
+func cellStates(cell string, legend map[string]string) ([]string, error) {
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		return nil, nil
+	}
+	switch cell {
+	case "?":
+		return []string{Unknown}, nil
+	case "-":
+		return []string{NotApplicable}, nil
+	}
+
+	var codes []string
+	if open := cell[0]; open == '{' || open == '(' || open == '[' {
+		closing := map[byte]byte{'{': '}', '(': ')', '[': ']'}[open]
+		if cell[len(cell)-1] != closing {
+			return nil, fmt.Errorf("invalid polymorphism %q", cell)
+		}
+		in := strings.TrimSpace(cell[1 : len(cell)-1])
+		if strings.ContainsAny(in, "/, &") {
+			codes = strings.FieldsFunc(in, func(r rune) bool {
+				return r == '/' || r == ',' || r == ' ' || r == '&'
+			})
+		} else {
+			codes = strings.Split(in, "")
+		}
+	} else {
+		codes = strings.Split(cell, "/")
+	}
+
+	var states []string
+	for _, c := range codes {
+		c = strings.Join(strings.Fields(c), " ")
+		if c == "" {
+			continue
+		}
+		if n, ok := legend[strings.ToLower(c)]; ok {
+			states = append(states, n)
+			continue
+		}
+		if v, err := strconv.ParseInt(c, 16, 0); err == nil && len(c) == 1 {
+			states = append(states, fmt.Sprintf("state %d", v))
+			continue
+		}
+		if v, err := strconv.Atoi(c); err == nil && v >= 0 {
+			states = append(states, fmt.Sprintf("state %d", v))
+			continue
+		}
+		states = append(states, strings.ReplaceAll(c, "_", " "))
+	}
+	if len(states) == 0 {
+		return nil, fmt.Errorf("invalid cell %q", cell)
+	}
+	return states, nil
+}
+
+// Wide writes an observation matrix
+// as a wide-format TSV file,
+// with taxa in rows
+// and characters in columns.
+//
+// The observations of all specimens of a taxon
+// are merged,
+// and cells use the state names,
+// with polymorphic observations
+// separated by '/'.
+// A question mark '?' is used for unknown states,
+// and a dash '-' for inapplicable characters.
+// The output can be read with ReadWide.
+func (m *Matrix) Wide(w io.Writer) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	chars := m.Chars()
+	header := append([]string{"taxon"}, chars...)
+	if err := tab.Write(header); err != nil {
+		return fmt.Errorf("unable to write header: %v", err)
+	}
+
+	for _, tx := range m.Taxa() {
+		specs := m.TaxSpec(tx)
+		row := make([]string, 0, len(header))
+		row = append(row, tx)
+		for _, c := range chars {
+			row = append(row, wideCell(m, specs, c))
+		}
+		if err := tab.Write(row); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+// WideCell returns the content of a cell
+// for a taxon in a wide-format matrix.
+func wideCell(m *Matrix, specs []string, char string) string {
+	val := "?"
+	set := make(map[string]bool)
+	var states []string
+	for _, sp := range specs {
+		for _, o := range m.Obs(sp, char) {
+			if o == NotApplicable {
+				val = "-"
+				continue
+			}
+			if o == Unknown || set[o] {
+				continue
+			}
+			set[o] = true
+			states = append(states, o)
+		}
+	}
+	if len(states) == 0 {
+		return val
+	}
+	slices.Sort(states)
+	return strings.Join(states, "/")
+}
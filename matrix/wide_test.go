@@ -0,0 +1,127 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package matrix_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/phydata/matrix"
+)
+
+func TestAddGrid(t *testing.T) {
+	grid := [][]string{
+		{"taxon", "pectoral_girdle", "ribs, fusion", "scapula, relation to clavical", "tail muscle", "vertebral ossification"},
+		{"legend", "arciferal; finnisternal", "0=free; 1=fused; 2=fused in adults", "juxtapose;overlap", "", "ectochordal; holochordal; stegochordal"},
+		{"Ascaphus truei", "0", "0", "1", "present", "0"},
+		{"Bufonidae", "0", "1", "0", "absent", "1"},
+		{"Discoglossidae", "0", "0", "1", "absent", "2"},
+		{"Pipidae", "0/1", "2", "1", "absent", "2"},
+		{"Ranidae", "1", "1", "0", "absent", "1"},
+		{"Rhinophrynidae", "{01}", "-", "1", "absent", "0"},
+		{"Rhinophrynidae", "?", "", "", "", ""},
+	}
+	m := matrix.New()
+	if err := m.AddGrid(grid, "kluge1969", nil); err != nil {
+		t.Fatalf("unable to read grid: %v", err)
+	}
+
+	want := newMatrix()
+	want.Add("Rhinophrynidae", "kluge1969:Rhinophrynidae", "pectoral girdle", matrix.Unknown)
+	cmpMatrix(t, m, want)
+}
+
+func TestAddGridCodes(t *testing.T) {
+	grid := [][]string{
+		{"taxon", "char 1", "char 2", "char 3"},
+		{"Bufonidae", "A", "12", "(0 1)"},
+	}
+	m := matrix.New()
+	if err := m.AddGrid(grid, "ref", nil); err != nil {
+		t.Fatalf("unable to read grid: %v", err)
+	}
+
+	want := matrix.New()
+	want.Add("Bufonidae", "ref:Bufonidae", "char 1", "state 10")
+	want.Add("Bufonidae", "ref:Bufonidae", "char 2", "state 12")
+	want.Add("Bufonidae", "ref:Bufonidae", "char 3", "state 0")
+	want.Add("Bufonidae", "ref:Bufonidae", "char 3", "state 1")
+	want.Set("ref:Bufonidae", "char 1", "state 10", "ref", matrix.Reference)
+	want.Set("ref:Bufonidae", "char 2", "state 12", "ref", matrix.Reference)
+	want.Set("ref:Bufonidae", "char 3", "state 0", "ref", matrix.Reference)
+	want.Set("ref:Bufonidae", "char 3", "state 1", "ref", matrix.Reference)
+	cmpMatrix(t, m, want)
+}
+
+var wideCSV = `# kluge 1969 matrix
+taxon,pectoral girdle,"ribs, fusion","scapula, relation to clavical",tail muscle,vertebral ossification
+Ascaphus truei,0,0,1,1,ectochordal
+Bufonidae,0,1,0,0,holochordal
+Discoglossidae,0,0,1,0,stegochordal
+Pipidae,0/1,2,1,0,stegochordal
+Ranidae,1,1,0,0,holochordal
+Rhinophrynidae,0,-,1,0,ectochordal
+`
+
+var wideLegend = `# legend
+character	code	state
+pectoral girdle	0	arciferal
+pectoral girdle	1	finnisternal
+ribs, fusion	0	free
+ribs, fusion	1	fused
+ribs, fusion	2	fused_in_adults
+scapula, relation to clavical	0	juxtapose
+scapula, relation to clavical	1	overlap
+tail_muscle	0	absent
+tail_muscle	1	present
+`
+
+func TestReadWide(t *testing.T) {
+	legend, err := matrix.ReadLegend(strings.NewReader(wideLegend))
+	if err != nil {
+		t.Fatalf("unable to read legend: %v", err)
+	}
+
+	m := matrix.New()
+	if err := m.ReadWide(strings.NewReader(wideCSV), "kluge1969", legend); err != nil {
+		t.Fatalf("unable to read wide matrix: %v", err)
+	}
+	cmpMatrix(t, m, newMatrix())
+
+	tsv := strings.ReplaceAll(wideCSV, ",", "\t")
+	tsv = strings.ReplaceAll(tsv, `"ribs	 fusion"`, "ribs, fusion")
+	tsv = strings.ReplaceAll(tsv, `"scapula	 relation to clavical"`, "scapula, relation to clavical")
+	m = matrix.New()
+	if err := m.ReadWide(strings.NewReader(tsv), "kluge1969", legend); err != nil {
+		t.Fatalf("unable to read wide matrix: %v", err)
+	}
+	cmpMatrix(t, m, newMatrix())
+}
+
+func TestWide(t *testing.T) {
+	m := newMatrix()
+
+	var w strings.Builder
+	if err := m.Wide(&w); err != nil {
+		t.Fatalf("unable to write wide matrix: %v", err)
+	}
+
+	np := matrix.New()
+	if err := np.ReadWide(strings.NewReader(w.String()), "kluge1969", nil); err != nil {
+		t.Fatalf("unable to read wide matrix: %v", err)
+	}
+	cmpMatrix(t, np, m)
+}
+
+func BenchmarkWide(b *testing.B) {
+	m := largeMatrix(100, 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := m.Wide(io.Discard); err != nil {
+			b.Fatalf("unable to write wide data: %v", err)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package names implements the normalization
+// of taxon names
+// and specimen identifiers
+// used by PhyData to store the data.
+//
+// A taxon name is normalized
+// by removing leading and trailing spaces,
+// replacing any sequence of spaces with a single space,
+// and using lower case letters,
+// except the first letter that is upper case
+// (e.g., "  homo   SAPIENS " is stored as "Homo sapiens").
+// The qualifiers of open nomenclature
+// are written as "cf." and "aff.",
+// the rank markers of infraspecific names
+// as "subsp.", "var.", and "f."
+// (e.g., "Poa annua ssp reptans" is stored as "Poa annua subsp. reptans"),
+// and hybrid markers as "×"
+// (e.g., "Mentha x piperita" is stored as "Mentha × piperita").
+//
+// A specimen identifier is normalized
+// by removing leading and trailing spaces,
+// replacing any sequence of spaces with a single underscore,
+// and using lower case letters
+// (e.g., "MACN 1234" is stored as "macn_1234").
+package names
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Markers is a table of the accepted spellings
+// of the qualifiers and rank markers
+// of a taxon name.
+var markers = map[string]string{
+	"cf":     "cf.",
+	"cf.":    "cf.",
+	"aff":    "aff.",
+	"aff.":   "aff.",
+	"ssp":    "subsp.",
+	"ssp.":   "subsp.",
+	"subsp":  "subsp.",
+	"subsp.": "subsp.",
+	"var":    "var.",
+	"var.":   "var.",
+	"forma":  "f.",
+	"fo.":    "f.",
+	"f.":     "f.",
+	"x":      hybrid,
+	hybrid:   hybrid,
+}
+
+// Hybrid is the hybrid marker.
+const hybrid = "×"
+
+// Taxon returns a taxon name
+// in its canonical form.
+func Taxon(name string) string {
+	words := strings.Fields(strings.ToLower(name))
+	if len(words) == 0 {
+		return ""
+	}
+
+	ls := make([]string, 0, len(words))
+	for i, w := range words {
+		if i > 0 {
+			if m, ok := markers[w]; ok {
+				ls = append(ls, m)
+				continue
+			}
+		}
+		// a hybrid marker attached to a name
+		if w != hybrid && strings.HasPrefix(w, hybrid) {
+			if i > 0 {
+				ls = append(ls, hybrid)
+			}
+			w = strings.TrimPrefix(w, hybrid)
+			if i == 0 {
+				ls = append(ls, hybrid+upperFirst(w))
+				continue
+			}
+		}
+		ls = append(ls, w)
+	}
+	return upperFirst(strings.Join(ls, " "))
+}
+
+// UpperFirst returns a string
+// with its first letter in upper case.
+func upperFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
+
+// Species returns the species of a taxon name,
+// i.e., the name without the infraspecific epithets
+// (e.g., "Panthera tigris altaica" is "Panthera tigris",
+// and "Poa annua var. reptans" is "Poa annua").
+// Qualifiers and hybrid markers
+// before the species epithet
+// are kept
+// (e.g., "Bufo cf. marinus").
+// Names with less than two words,
+// and hybrid formulas
+// (e.g., "Mentha aquatica × Mentha spicata")
+// are returned unchanged.
+func Species(name string) string {
+	name = Taxon(name)
+	words := strings.Split(name, " ")
+	for i := 1; i < len(words); i++ {
+		switch words[i] {
+		case hybrid, "cf.", "aff.":
+			continue
+		}
+		if slices.Contains(words[i+1:], hybrid) {
+			return name
+		}
+		return strings.Join(words[:i+1], " ")
+	}
+	return name
+}
+
+// Specimen returns a specimen identifier
+// in its canonical form.
+func Specimen(id string) string {
+	id = strings.Join(strings.Fields(id), "_")
+	if id == "" {
+		return ""
+	}
+	return strings.ToLower(id)
+}
+
+// Options are additional normalization rules
+// for taxon names.
+// The zero value is equivalent to Taxon.
+type Options struct {
+	// StripDiacritics replaces the letters with diacritics
+	// with its base letter
+	// (e.g., "é" is replaced by "e").
+	StripDiacritics bool
+
+	// StripAuthors removes the author string
+	// of a name,
+	// i.e., any word after the genus
+	// that starts with an upper case letter,
+	// a parenthesis,
+	// or a digit
+	// (e.g., "Felis catus Linnaeus, 1758" is "Felis catus").
+	StripAuthors bool
+
+	// Species collapses a name
+	// to the species
+	// (see Species).
+	Species bool
+}
+
+// Taxon returns a taxon name
+// in its canonical form
+// using the normalization options.
+func (o Options) Taxon(name string) string {
+	words := strings.Fields(name)
+	if o.StripAuthors {
+		words = stripAuthors(words)
+	}
+	name = strings.Join(words, " ")
+	if o.StripDiacritics {
+		name = stripDiacritics(name)
+	}
+	if o.Species {
+		return Species(name)
+	}
+	return Taxon(name)
+}
+
+// StripAuthors removes the words of an author string.
+func stripAuthors(words []string) []string {
+	for i, w := range words {
+		if i == 0 {
+			continue
+		}
+		if _, ok := markers[strings.ToLower(w)]; ok {
+			continue
+		}
+		r, _ := utf8.DecodeRuneInString(w)
+		if unicode.IsUpper(r) || unicode.IsDigit(r) || r == '(' {
+			return words[:i]
+		}
+	}
+	return words
+}
+
+// Diacritics is a table of letters with diacritics
+// and its replacement.
+var diacritics = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Ā': "A", 'Ă': "A", 'Ą': "A",
+	'æ': "ae", 'Æ': "Ae",
+	'ç': "c", 'ć': "c", 'č': "c", 'Ç': "C", 'Ć': "C", 'Č': "C",
+	'ď': "d", 'đ': "d", 'Ď': "D", 'Đ': "D",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ē': "E", 'Ė': "E", 'Ę': "E", 'Ě': "E",
+	'ğ': "g", 'Ğ': "G",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ı': "i",
+	'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I", 'Ī': "I", 'İ': "I",
+	'ł': "l", 'ľ': "l", 'Ł': "L", 'Ľ': "L",
+	'ñ': "n", 'ń': "n", 'ň': "n", 'Ñ': "N", 'Ń': "N", 'Ň': "N",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o",
+	'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O", 'Ō': "O", 'Ő': "O",
+	'œ': "oe", 'Œ': "Oe",
+	'ř': "r", 'Ř': "R",
+	'ś': "s", 'š': "s", 'ş': "s", 'Ś': "S", 'Š': "S", 'Ş': "S",
+	'ß': "ss",
+	'ť': "t", 'ţ': "t", 'Ť': "T", 'Ţ': "T",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u",
+	'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "U", 'Ū': "U", 'Ů': "U", 'Ű': "U",
+	'ý': "y", 'ÿ': "y", 'Ý': "Y", 'Ÿ': "Y",
+	'ź': "z", 'ż': "z", 'ž': "z", 'Ź': "Z", 'Ż': "Z", 'Ž': "Z",
+}
+
+// StripDiacritics replaces the letters with diacritics
+// of a string.
+func stripDiacritics(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if v, ok := diacritics[r]; ok {
+			b.WriteString(v)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,143 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package names_test
+
+import (
+	"testing"
+
+	"github.com/js-arias/phydata/names"
+)
+
+func TestTaxon(t *testing.T) {
+	tests := map[string]struct {
+		name string
+		opt  names.Options
+		want string
+	}{
+		"canon": {
+			name: "  homo   SAPIENS ",
+			want: "Homo sapiens",
+		},
+		"empty": {
+			name: "   ",
+			want: "",
+		},
+		"authors kept": {
+			name: "Felis catus Linnaeus, 1758",
+			want: "Felis catus linnaeus, 1758",
+		},
+		"authors": {
+			name: "Felis catus Linnaeus, 1758",
+			opt:  names.Options{StripAuthors: true},
+			want: "Felis catus",
+		},
+		"authors in parenthesis": {
+			name: "Panthera tigris (Linnaeus, 1758)",
+			opt:  names.Options{StripAuthors: true},
+			want: "Panthera tigris",
+		},
+		"species": {
+			name: "Panthera tigris altaica",
+			opt:  names.Options{Species: true},
+			want: "Panthera tigris",
+		},
+		"species and authors": {
+			name: "Panthera tigris altaica Temminck, 1844",
+			opt:  names.Options{Species: true, StripAuthors: true},
+			want: "Panthera tigris",
+		},
+		"diacritics": {
+			name: "Müllerornis betsilei",
+			opt:  names.Options{StripDiacritics: true},
+			want: "Mullerornis betsilei",
+		},
+		"qualifier": {
+			name: "Bufo CF marinus",
+			want: "Bufo cf. marinus",
+		},
+		"affinis": {
+			name: "Bufo aff marinus",
+			want: "Bufo aff. marinus",
+		},
+		"trinomial": {
+			name: "Panthera tigris altaica",
+			want: "Panthera tigris altaica",
+		},
+		"subspecies marker": {
+			name: "Poa annua ssp reptans",
+			want: "Poa annua subsp. reptans",
+		},
+		"variety": {
+			name: "Poa annua var reptans",
+			want: "Poa annua var. reptans",
+		},
+		"hybrid": {
+			name: "Mentha x piperita",
+			want: "Mentha × piperita",
+		},
+		"attached hybrid": {
+			name: "Mentha ×piperita",
+			want: "Mentha × piperita",
+		},
+		"hybrid genus": {
+			name: "×agropogon littoralis",
+			want: "×Agropogon littoralis",
+		},
+		"hybrid and authors": {
+			name: "Mentha X piperita L.",
+			opt:  names.Options{StripAuthors: true},
+			want: "Mentha × piperita",
+		},
+		"variety to species": {
+			name: "Poa annua var. reptans Hausskn.",
+			opt:  names.Options{Species: true, StripAuthors: true},
+			want: "Poa annua",
+		},
+		"diacritics kept": {
+			name: "Müllerornis betsilei",
+			want: "Müllerornis betsilei",
+		},
+	}
+
+	for name, test := range tests {
+		if got := test.opt.Taxon(test.name); got != test.want {
+			t.Errorf("%s: got %q, want %q", name, got, test.want)
+		}
+	}
+	if got := names.Taxon("  homo   SAPIENS "); got != "Homo sapiens" {
+		t.Errorf("taxon: got %q, want %q", got, "Homo sapiens")
+	}
+}
+
+func TestSpecimen(t *testing.T) {
+	tests := map[string]string{
+		"MACN 1234":                  "macn_1234",
+		"  kluge1969:Ascaphus truei": "kluge1969:ascaphus_truei",
+		" ":                          "",
+	}
+	for id, want := range tests {
+		if got := names.Specimen(id); got != want {
+			t.Errorf("specimen %q: got %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestSpecies(t *testing.T) {
+	tests := map[string]string{
+		"Panthera tigris altaica":          "Panthera tigris",
+		"Poa annua subsp. reptans":         "Poa annua",
+		"Bufo cf. marinus":                 "Bufo cf. marinus",
+		"Bufo aff. marinus marinus":        "Bufo aff. marinus",
+		"Mentha × piperita":                "Mentha × piperita",
+		"Mentha aquatica × Mentha spicata": "Mentha aquatica × mentha spicata",
+		"Pipidae":                          "Pipidae",
+		"Bufo cf.":                         "Bufo cf.",
+	}
+	for name, want := range tests {
+		if got := names.Species(name); got != want {
+			t.Errorf("species %q: got %q, want %q", name, got, want)
+		}
+	}
+}
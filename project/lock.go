@@ -0,0 +1,105 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrLocked is the error returned
+// when a project is locked by other process.
+var ErrLocked = errors.New("project locked")
+
+// LockSuffix is the suffix added to the name of a project file
+// to build the name of its lock file.
+const LockSuffix = ".lock"
+
+// A Lock is an advisory lock of a project file.
+//
+// The lock is a file with the name of the project file
+// and the suffix '.lock',
+// so it is honored by any process
+// (including processes in other machines
+// that use a shared drive),
+// that uses the lock.
+// The lock file stores the process ID,
+// the host name,
+// and the time in which the lock was acquired.
+//
+// Only the project file is locked,
+// not its dataset files,
+// so the lock only serializes the processes
+// that use the same project file.
+// A dataset file shared by different projects
+// is not protected by the lock.
+type Lock struct {
+	name string
+}
+
+// LockFile acquires the lock of a project file.
+// If the project is already locked,
+// it returns an error that wraps ErrLocked.
+func LockFile(name string) (*Lock, error) {
+	ln := name + LockSuffix
+	f, err := os.OpenFile(ln, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return nil, lockedError(name, ln)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("while locking %q: %v", name, err)
+	}
+
+	host, _ := os.Hostname()
+	_, err = fmt.Fprintf(f, "%d\t%s\t%s\n", os.Getpid(), host, time.Now().Format(time.RFC3339))
+	if e := f.Close(); e != nil && err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(ln)
+		return nil, fmt.Errorf("while locking %q: %v", name, err)
+	}
+	return &Lock{name: ln}, nil
+}
+
+// LockedError returns an error
+// with the content of a lock file.
+func lockedError(name, ln string) error {
+	b, err := os.ReadFile(ln)
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrLocked, name)
+	}
+	f := strings.Split(strings.TrimSpace(string(b)), "\t")
+	if len(f) < 3 {
+		return fmt.Errorf("%w: %q", ErrLocked, name)
+	}
+	return fmt.Errorf("%w: %q: by process %s on %q since %s", ErrLocked, name, f[0], f[1], f[2])
+}
+
+// Release releases the lock.
+func (l *Lock) Release() error {
+	if l == nil || l.name == "" {
+		return nil
+	}
+	err := os.Remove(l.name)
+	l.name = ""
+	return err
+}
+
+// BreakLock removes the lock of a project file,
+// regardless of the process that holds the lock.
+// It should be used only with stale locks
+// (e.g., when a process was killed
+// before releasing the lock).
+func BreakLock(name string) error {
+	err := os.Remove(name + LockSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package project_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/js-arias/phydata/project"
+)
+
+func TestLock(t *testing.T) {
+	name := "tmp-project-for-lock-test.tab"
+	defer os.Remove(name + project.LockSuffix)
+
+	l, err := project.LockFile(name)
+	if err != nil {
+		t.Fatalf("error when locking: %v", err)
+	}
+
+	if _, err := project.LockFile(name); !errors.Is(err, project.ErrLocked) {
+		t.Errorf("locked project: got error %v, want %v", err, project.ErrLocked)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatalf("error when releasing: %v", err)
+	}
+	if err := l.Release(); err != nil {
+		t.Errorf("released lock: unexpected error %v", err)
+	}
+
+	l, err = project.LockFile(name)
+	if err != nil {
+		t.Fatalf("error when locking a released project: %v", err)
+	}
+	defer l.Release()
+
+	// stale lock
+	if err := project.BreakLock(name); err != nil {
+		t.Fatalf("error when breaking lock: %v", err)
+	}
+	nl, err := project.LockFile(name)
+	if err != nil {
+		t.Fatalf("error when locking after breaking: %v", err)
+	}
+	if err := nl.Release(); err != nil {
+		t.Fatalf("error when releasing: %v", err)
+	}
+	if err := project.BreakLock(name); err != nil {
+		t.Errorf("break without lock: unexpected error %v", err)
+	}
+}
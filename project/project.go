@@ -22,26 +22,70 @@ type Dataset string
 
 // Valid dataset types
 const (
+	// File for age ranges of taxa and specimens.
+	Ages Dataset = "ages"
+
+	// File for character metadata.
+	Characters Dataset = "characters"
+
 	// File for DNA sequences.
 	DNA = "dna"
 
+	// File for exclusion masks
+	// of aligned genes.
+	Exclusions Dataset = "exclusions"
+
 	// File with an hierarchy of homologues.
 	Homologues Dataset = "homologues"
 
 	// File for specimen character observations.
 	Observations Dataset = "observations"
+
+	// File for the primers
+	// used to sequence the genes.
+	Primers Dataset = "primers"
+
+	// File for amino acid sequences.
+	Proteins Dataset = "proteins"
+
+	// File for specimen records.
+	Specimens Dataset = "specimens"
+
+	// File for taxon records,
+	// with external taxon identifiers.
+	Taxonomy Dataset = "taxonomy"
 )
 
 // A Project represents a collection of paths
 // for particular datasets.
+//
+// A dataset can be stored in several files
+// (e.g., the DNA sequences of each gene,
+// or of each sequencing batch).
+//
+// A file can be marked as read-only
+// (e.g., a published reference dataset),
+// so the changes to the dataset
+// are written into a working file
+// (i.e., a file of the dataset that is not read-only)
+// and the read-only file is never modified.
+//
+// A file can be a remote file
+// (see Remote),
+// that is used through a local copy
+// (see Local).
 type Project struct {
-	paths map[Dataset]string
+	paths     map[Dataset][]string
+	readOnly  map[Dataset]map[string]bool
+	checksums map[Dataset]map[string]string
 }
 
 // New creates a new empty project.
 func New() *Project {
 	return &Project{
-		paths: make(map[Dataset]string),
+		paths:     make(map[Dataset][]string),
+		readOnly:  make(map[Dataset]map[string]bool),
+		checksums: make(map[Dataset]map[string]string),
 	}
 }
 
@@ -57,12 +101,23 @@ var header = []string{
 //   - dataset, for the kind of file
 //   - path, for the path of the file
 //
+// Optionally,
+// it can contain the field "access".
+// If the value of this field is "read-only",
+// the file is marked as read-only.
+// It can also contain the field "checksum",
+// with the checksum of a remote file
+// (see SetChecksum).
+//
 // Here is an example file:
 //
 //	# phydata project files
 //	dataset	path
 //	homologues	homologues.tab
 //	observations	observations.tab
+//
+// A dataset with several files
+// has a row for each file.
 func Read(name string) (*Project, error) {
 	f, err := os.Open(name)
 	if err != nil {
@@ -104,29 +159,146 @@ func Read(name string) (*Project, error) {
 
 		f = "path"
 		path := row[fields[f]]
-		p.paths[s] = path
+		p.Append(s, path)
+
+		f = "access"
+		if i, ok := fields[f]; ok {
+			switch v := strings.ToLower(strings.TrimSpace(row[i])); v {
+			case "":
+			case accessReadOnly:
+				p.SetReadOnly(s, path, true)
+			default:
+				return nil, fmt.Errorf("on file %q: on row %d: field %q: invalid value %q", name, ln, f, row[i])
+			}
+		}
+
+		f = "checksum"
+		if i, ok := fields[f]; ok {
+			if v := strings.TrimSpace(row[i]); v != "" {
+				if !validChecksum(v) {
+					return nil, fmt.Errorf("on file %q: on row %d: field %q: invalid value %q", name, ln, f, row[i])
+				}
+				p.SetChecksum(s, path, v)
+			}
+		}
 	}
 
 	return p, nil
 }
 
-// Add adds a filepath to a dataset to a given project.
+// AccessReadOnly is the value of the access field
+// of a read-only file.
+const accessReadOnly = "read-only"
+
+// Add adds a filepath to a dataset to a given project,
+// replacing all the previous files of the dataset
+// that are not read-only.
 // It returns the previous value
+// (see Path)
 // for the dataset.
+// If path is empty,
+// the dataset is removed.
 func (p *Project) Add(set Dataset, path string) string {
-	prev := p.paths[set]
+	prev := p.Path(set)
 	if path == "" {
 		delete(p.paths, set)
+		delete(p.readOnly, set)
+		delete(p.checksums, set)
 		return prev
 	}
 
-	p.paths[set] = path
+	path = p.Source(set, path)
+	var ls []string
+	for _, f := range p.paths[set] {
+		if p.ReadOnly(set, f) {
+			ls = append(ls, f)
+		}
+	}
+	if !slices.Contains(ls, path) {
+		ls = append(ls, path)
+	}
+	p.paths[set] = ls
 	return prev
 }
 
+// Append adds a filepath to the files of a dataset,
+// keeping the previous files.
+// If the path is already in the dataset,
+// nothing is changed.
+func (p *Project) Append(set Dataset, path string) {
+	if path == "" {
+		return
+	}
+	path = p.Source(set, path)
+	if slices.Contains(p.paths[set], path) {
+		return
+	}
+	p.paths[set] = append(p.paths[set], path)
+}
+
 // Path returns the path of the given dataset.
+// If the dataset has several files,
+// it returns the first one
+// that is not read-only
+// (i.e., the working file of the dataset),
+// or the first file,
+// if all the files are read-only.
+// For a remote file,
+// it returns the path of its local copy.
 func (p *Project) Path(set Dataset) string {
-	return p.paths[set]
+	ls := p.paths[set]
+	if len(ls) == 0 {
+		return ""
+	}
+	for _, f := range ls {
+		if !p.ReadOnly(set, f) {
+			return p.Local(set, f)
+		}
+	}
+	return p.Local(set, ls[0])
+}
+
+// Paths returns the paths of all the files
+// of the given dataset.
+// For remote files,
+// it returns the path of their local copies.
+func (p *Project) Paths(set Dataset) []string {
+	ls := make([]string, 0, len(p.paths[set]))
+	for _, f := range p.paths[set] {
+		ls = append(ls, p.Local(set, f))
+	}
+	return ls
+}
+
+// ReadOnly returns true
+// if a file of a dataset is marked as read-only.
+// Remote files are always read-only.
+func (p *Project) ReadOnly(set Dataset, path string) bool {
+	path = p.Source(set, path)
+	if Remote(path) {
+		return true
+	}
+	return p.readOnly[set][path]
+}
+
+// SetReadOnly marks
+// (or unmarks)
+// a file of a dataset as read-only.
+// If the file is not in the dataset,
+// nothing is changed.
+func (p *Project) SetReadOnly(set Dataset, path string, readOnly bool) {
+	path = p.Source(set, path)
+	if !slices.Contains(p.paths[set], path) {
+		return
+	}
+	if !readOnly {
+		delete(p.readOnly[set], path)
+		return
+	}
+	if p.readOnly[set] == nil {
+		p.readOnly[set] = make(map[string]bool)
+	}
+	p.readOnly[set][path] = true
 }
 
 // Sets returns the datasets defined on a project.
@@ -160,18 +332,53 @@ func (p *Project) Write(name string) (err error) {
 	tsv.Comma = '\t'
 	tsv.UseCRLF = true
 
-	if err := tsv.Write(header); err != nil {
+	// the access field is only written
+	// if there are read-only files
+	var access bool
+	for _, ro := range p.readOnly {
+		if len(ro) > 0 {
+			access = true
+			break
+		}
+	}
+	var checksum bool
+	for _, cs := range p.checksums {
+		if len(cs) > 0 {
+			checksum = true
+			break
+		}
+	}
+	head := slices.Clone(header)
+	if access {
+		head = append(head, "access")
+	}
+	if checksum {
+		head = append(head, "checksum")
+	}
+	if err := tsv.Write(head); err != nil {
 		return fmt.Errorf("on file %q: while writing header: %v", name, err)
 	}
 
 	sets := p.Sets()
 	for _, s := range sets {
-		row := []string{
-			string(s),
-			p.paths[s],
-		}
-		if err := tsv.Write(row); err != nil {
-			return fmt.Errorf("on file %q: %v", name, err)
+		for _, path := range p.paths[s] {
+			row := []string{
+				string(s),
+				path,
+			}
+			if access {
+				var v string
+				if p.readOnly[s][path] {
+					v = accessReadOnly
+				}
+				row = append(row, v)
+			}
+			if checksum {
+				row = append(row, p.checksums[s][path])
+			}
+			if err := tsv.Write(row); err != nil {
+				return fmt.Errorf("on file %q: %v", name, err)
+			}
 		}
 	}
 
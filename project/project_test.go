@@ -63,3 +63,108 @@ func testProject(t testing.TB, p *project.Project, sets []setPath) {
 		t.Errorf("sets: got %v, want %v", ls, datasets)
 	}
 }
+
+func TestProjectPaths(t *testing.T) {
+	p := project.New()
+	p.Add(project.Observations, "observations.tab")
+	p.Append(project.DNA, "cytb.tab")
+	p.Append(project.DNA, "coi.tab")
+	p.Append(project.DNA, "cytb.tab")
+
+	want := []string{"cytb.tab", "coi.tab"}
+	if got := p.Paths(project.DNA); !reflect.DeepEqual(got, want) {
+		t.Errorf("paths: got %v, want %v", got, want)
+	}
+	if got := p.Path(project.DNA); got != want[0] {
+		t.Errorf("path: got %q, want %q", got, want[0])
+	}
+
+	name := "tmp-project-paths-for-test.tab"
+	defer os.Remove(name)
+	if err := p.Write(name); err != nil {
+		t.Fatalf("error when writing data: %v", err)
+	}
+	np, err := project.Read(name)
+	if err != nil {
+		t.Fatalf("error when reading data: %v", err)
+	}
+	if got := np.Paths(project.DNA); !reflect.DeepEqual(got, want) {
+		t.Errorf("read paths: got %v, want %v", got, want)
+	}
+	if got := np.Paths(project.Observations); !reflect.DeepEqual(got, []string{"observations.tab"}) {
+		t.Errorf("read paths: got %v, want %v", got, []string{"observations.tab"})
+	}
+
+	if prev := np.Add(project.DNA, "dna.tab"); prev != want[0] {
+		t.Errorf("add: previous: got %q, want %q", prev, want[0])
+	}
+	if got := np.Paths(project.DNA); !reflect.DeepEqual(got, []string{"dna.tab"}) {
+		t.Errorf("add: paths: got %v, want %v", got, []string{"dna.tab"})
+	}
+	np.Add(project.DNA, "")
+	if got := np.Paths(project.DNA); len(got) != 0 {
+		t.Errorf("remove: paths: got %v, want none", got)
+	}
+}
+
+func TestProjectReadOnly(t *testing.T) {
+	p := project.New()
+	p.Add(project.Observations, "published.tab")
+	p.Append(project.DNA, "reference.tab")
+	p.Append(project.DNA, "cytb.tab")
+	p.SetReadOnly(project.Observations, "published.tab", true)
+	p.SetReadOnly(project.DNA, "reference.tab", true)
+	p.SetReadOnly(project.DNA, "undefined.tab", true)
+
+	if got := p.Path(project.Observations); got != "published.tab" {
+		t.Errorf("path: got %q, want %q", got, "published.tab")
+	}
+	if got := p.Path(project.DNA); got != "cytb.tab" {
+		t.Errorf("path: got %q, want %q", got, "cytb.tab")
+	}
+
+	// the read-only file is kept
+	p.Add(project.Observations, "working.tab")
+	want := []string{"published.tab", "working.tab"}
+	if got := p.Paths(project.Observations); !reflect.DeepEqual(got, want) {
+		t.Errorf("add: paths: got %v, want %v", got, want)
+	}
+	if got := p.Path(project.Observations); got != "working.tab" {
+		t.Errorf("add: path: got %q, want %q", got, "working.tab")
+	}
+
+	name := "tmp-project-read-only-for-test.tab"
+	defer os.Remove(name)
+	if err := p.Write(name); err != nil {
+		t.Fatalf("error when writing data: %v", err)
+	}
+	np, err := project.Read(name)
+	if err != nil {
+		t.Fatalf("error when reading data: %v", err)
+	}
+
+	readOnly := []setPath{
+		{project.DNA, "reference.tab"},
+		{project.Observations, "published.tab"},
+	}
+	writable := []setPath{
+		{project.DNA, "cytb.tab"},
+		{project.DNA, "undefined.tab"},
+		{project.Observations, "working.tab"},
+	}
+	for _, s := range readOnly {
+		if !np.ReadOnly(s.set, s.path) {
+			t.Errorf("set %s: file %q: want read-only", s.set, s.path)
+		}
+	}
+	for _, s := range writable {
+		if np.ReadOnly(s.set, s.path) {
+			t.Errorf("set %s: file %q: not read-only", s.set, s.path)
+		}
+	}
+
+	np.SetReadOnly(project.DNA, "reference.tab", false)
+	if np.ReadOnly(project.DNA, "reference.tab") {
+		t.Errorf("set %s: file %q: not read-only", project.DNA, "reference.tab")
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// CacheEnv is the environment variable
+// that defines the directory
+// of the local copies of the remote files.
+const CacheEnv = "PHYDATA_CACHE"
+
+// RemotePrefix is the prefix of the path
+// of a remote file.
+const remotePrefix = "https://"
+
+// ChecksumPrefix is the prefix of a checksum,
+// that identifies the hash function.
+const checksumPrefix = "sha256:"
+
+// Remote returns true
+// if a path is the URL of a remote file
+// (i.e., it starts with "https://").
+func Remote(path string) bool {
+	return strings.HasPrefix(path, remotePrefix)
+}
+
+// CacheDir returns the directory
+// used to store the local copies of the remote files.
+// By default,
+// it is the directory 'phydata'
+// in the user cache directory,
+// and it can be defined with the environment variable
+// PHYDATA_CACHE.
+func CacheDir() string {
+	if dir := os.Getenv(CacheEnv); dir != "" {
+		return dir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "phydata-cache"
+	}
+	return filepath.Join(dir, "phydata")
+}
+
+// Sum returns the checksum of the content of a file,
+// in the form "sha256:<hex digest>".
+func Sum(data []byte) string {
+	h := sha256.Sum256(data)
+	return checksumPrefix + hex.EncodeToString(h[:])
+}
+
+func validChecksum(sum string) bool {
+	v, ok := strings.CutPrefix(sum, checksumPrefix)
+	if !ok || len(v) != 2*sha256.Size {
+		return false
+	}
+	_, err := hex.DecodeString(v)
+	return err == nil
+}
+
+// Checksum returns the checksum
+// of a file of a dataset.
+// The file can be given by its URL
+// or by the path of its local copy.
+func (p *Project) Checksum(set Dataset, path string) string {
+	return p.checksums[set][p.Source(set, path)]
+}
+
+// SetChecksum sets the checksum
+// of a remote file of a dataset
+// (see Sum),
+// so the content of the file is pinned:
+// the local copy of the file
+// is identified by its checksum.
+// If sum is empty,
+// the checksum is removed.
+// If the file is not in the dataset,
+// or it is not a remote file,
+// nothing is changed.
+func (p *Project) SetChecksum(set Dataset, path, sum string) {
+	path = p.Source(set, path)
+	if !Remote(path) {
+		return
+	}
+	if !slices.Contains(p.paths[set], path) {
+		return
+	}
+	if sum == "" {
+		delete(p.checksums[set], path)
+		return
+	}
+	if p.checksums[set] == nil {
+		p.checksums[set] = make(map[string]string)
+	}
+	p.checksums[set][path] = sum
+}
+
+// Local returns the path of the local copy
+// of a file of a dataset.
+// For a file that is not a remote file,
+// it returns the path of the file.
+//
+// The local copy of a remote file
+// is stored in the cache directory
+// (see CacheDir),
+// using its checksum as file name,
+// or a hash of its URL,
+// if the file does not have a checksum.
+func (p *Project) Local(set Dataset, path string) string {
+	path = p.Source(set, path)
+	if !Remote(path) {
+		return path
+	}
+	if sum, ok := strings.CutPrefix(p.checksums[set][path], checksumPrefix); ok {
+		return filepath.Join(CacheDir(), "sha256", sum)
+	}
+	h := sha256.Sum256([]byte(path))
+	return filepath.Join(CacheDir(), "url", hex.EncodeToString(h[:]))
+}
+
+// Source returns the path of a file of a dataset
+// as defined in the project
+// (i.e., the URL of a remote file)
+// from the path of its local copy.
+// Any other path is returned as is.
+func (p *Project) Source(set Dataset, path string) string {
+	ls := p.paths[set]
+	if slices.Contains(ls, path) {
+		return path
+	}
+	for _, f := range ls {
+		if Remote(f) && p.Local(set, f) == path {
+			return f
+		}
+	}
+	return path
+}
@@ -0,0 +1,86 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package project_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/phydata/project"
+)
+
+func TestRemote(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(project.CacheEnv, dir)
+
+	url := "https://example.org/data/observations.tab"
+	p := project.New()
+	p.Add(project.Observations, url)
+	p.Append(project.DNA, "dna.tab")
+
+	if !project.Remote(url) {
+		t.Errorf("remote %q: want true", url)
+	}
+	if project.Remote("dna.tab") {
+		t.Errorf("remote %q: want false", "dna.tab")
+	}
+	if !p.ReadOnly(project.Observations, url) {
+		t.Errorf("remote %q: want read-only", url)
+	}
+
+	// without a checksum
+	local := p.Path(project.Observations)
+	if filepath.Dir(filepath.Dir(local)) != dir {
+		t.Errorf("local %q: want a file in %q", local, dir)
+	}
+	if got := p.Local(project.DNA, "dna.tab"); got != "dna.tab" {
+		t.Errorf("local: got %q, want %q", got, "dna.tab")
+	}
+
+	sum := project.Sum([]byte("taxon\tspecimen\tcharacter\tstate\n"))
+	p.SetChecksum(project.Observations, local, sum)
+	p.SetChecksum(project.DNA, "dna.tab", sum)
+	if got := p.Checksum(project.Observations, url); got != sum {
+		t.Errorf("checksum: got %q, want %q", got, sum)
+	}
+	if got := p.Checksum(project.DNA, "dna.tab"); got != "" {
+		t.Errorf("checksum: local file: got %q, want none", got)
+	}
+	local = p.Path(project.Observations)
+	if want := filepath.Join(dir, "sha256", sum[len("sha256:"):]); local != want {
+		t.Errorf("local: got %q, want %q", local, want)
+	}
+	if !p.ReadOnly(project.Observations, local) {
+		t.Errorf("local %q: want read-only", local)
+	}
+
+	// the remote file is kept
+	p.Add(project.Observations, "working.tab")
+	if got := p.Path(project.Observations); got != "working.tab" {
+		t.Errorf("add: path: got %q, want %q", got, "working.tab")
+	}
+	want := []string{local, "working.tab"}
+	if got := p.Paths(project.Observations); !reflect.DeepEqual(got, want) {
+		t.Errorf("add: paths: got %v, want %v", got, want)
+	}
+
+	name := "tmp-project-remote-for-test.tab"
+	defer os.Remove(name)
+	if err := p.Write(name); err != nil {
+		t.Fatalf("error when writing data: %v", err)
+	}
+	np, err := project.Read(name)
+	if err != nil {
+		t.Fatalf("error when reading data: %v", err)
+	}
+	if got := np.Paths(project.Observations); !reflect.DeepEqual(got, want) {
+		t.Errorf("read paths: got %v, want %v", got, want)
+	}
+	if got := np.Checksum(project.Observations, url); got != sum {
+		t.Errorf("read checksum: got %q, want %q", got, sum)
+	}
+}
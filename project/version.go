@@ -0,0 +1,95 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// VersionKey is the keyword of the comment line
+// with the format version of a dataset file.
+const versionKey = "format version:"
+
+// FormatVersions are the current format versions
+// of each dataset.
+// When the columns of a dataset change
+// (e.g., when a new observation field is added),
+// its format version should be incremented.
+var formatVersions = map[Dataset]int{
+	Ages:         1,
+	Characters:   1,
+	DNA:          1,
+	Exclusions:   1,
+	Observations: 1,
+	Primers:      1,
+	Proteins:     1,
+	Specimens:    1,
+	Taxonomy:     1,
+}
+
+// FormatVersion returns the current format version
+// of a dataset.
+// It returns 0 for an unknown dataset.
+func FormatVersion(set Dataset) int {
+	return formatVersions[set]
+}
+
+// VersionComment returns the text of the comment line
+// (without the comment mark)
+// with the current format version of a dataset,
+// to be written in the header of a dataset file.
+func VersionComment(set Dataset) string {
+	return fmt.Sprintf("%s %d", versionKey, FormatVersion(set))
+}
+
+// FileVersion returns the format version
+// of a dataset file,
+// as defined in the comment lines
+// at the start of the file.
+// A file without a version comment
+// has version 0.
+func FileVersion(name string) (int, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	v, err := readVersion(f)
+	if err != nil {
+		return 0, fmt.Errorf("on file %q: %v", name, err)
+	}
+	return v, nil
+}
+
+func readVersion(r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+	for {
+		ln, err := br.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+		if !strings.HasPrefix(ln, "#") {
+			return 0, nil
+		}
+		ln = strings.TrimSpace(strings.TrimPrefix(ln, "#"))
+		if v, ok := strings.CutPrefix(ln, versionKey); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid format version %q", strings.TrimSpace(v))
+			}
+			return n, nil
+		}
+		if errors.Is(err, io.EOF) {
+			return 0, nil
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package project_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/js-arias/phydata/project"
+)
+
+func TestFileVersion(t *testing.T) {
+	tests := map[string]struct {
+		data string
+		want int
+		err  bool
+	}{
+		"current": {
+			data: fmt.Sprintf("# phydata: character observations\n# data saved on: 2024-01-01T00:00:00Z\n# %s\ntaxon\tspecimen\tcharacter\tstate\n", project.VersionComment(project.Observations)),
+			want: project.FormatVersion(project.Observations),
+		},
+		"no version": {
+			data: "# phydata: character observations\ntaxon\tspecimen\tcharacter\tstate\n",
+		},
+		"after header": {
+			data: "taxon\tspecimen\tcharacter\tstate\n# format version: 1\n",
+		},
+		"newer": {
+			data: "#format version: 7\n",
+			want: 7,
+		},
+		"invalid": {
+			data: "# format version: one\n",
+			err:  true,
+		},
+		"empty": {},
+	}
+
+	name := "tmp-version-for-test.tab"
+	defer os.Remove(name)
+	for n, test := range tests {
+		if err := os.WriteFile(name, []byte(test.data), 0644); err != nil {
+			t.Fatalf("%s: unable to write file: %v", n, err)
+		}
+		v, err := project.FileVersion(name)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expecting error", n)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", n, err)
+			continue
+		}
+		if v != test.want {
+			t.Errorf("%s: version: got %d, want %d", n, v, test.want)
+		}
+	}
+
+	if v := project.FormatVersion(project.Homologues); v != 0 {
+		t.Errorf("homologues: version: got %d, want 0", v)
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package specimen implements a registry of specimens
+// with its voucher data.
+package specimen
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/phydata/names"
+)
+
+// A Registry is a collection of specimens.
+type Registry struct {
+	specs map[string]*specimen
+}
+
+// New creates a new empty registry.
+func New() *Registry {
+	return &Registry{
+		specs: make(map[string]*specimen),
+	}
+}
+
+// Add adds a specimen of a given taxon
+// to the registry.
+// If the specimen is already in the registry,
+// it will update its taxon.
+func (r *Registry) Add(taxon, spec string) {
+	taxon = names.Taxon(taxon)
+	if taxon == "" {
+		return
+	}
+	spec = names.Specimen(spec)
+	if spec == "" {
+		return
+	}
+
+	sp, ok := r.specs[spec]
+	if !ok {
+		sp = &specimen{
+			name: spec,
+		}
+		r.specs[spec] = sp
+	}
+	sp.taxon = taxon
+}
+
+// Delete removes a specimen from the registry.
+func (r *Registry) Delete(spec string) {
+	delete(r.specs, names.Specimen(spec))
+}
+
+// Specimens returns the specimens in the registry.
+func (r *Registry) Specimens() []string {
+	specs := make([]string, 0, len(r.specs))
+	for _, sp := range r.specs {
+		specs = append(specs, sp.name)
+	}
+	slices.Sort(specs)
+	return specs
+}
+
+// Taxon returns the taxon of a specimen.
+func (r *Registry) Taxon(spec string) string {
+	sp, ok := r.specs[names.Specimen(spec)]
+	if !ok {
+		return ""
+	}
+	return sp.taxon
+}
+
+// Taxa returns the taxa defined in the registry.
+func (r *Registry) Taxa() []string {
+	taxa := make(map[string]bool)
+	for _, sp := range r.specs {
+		taxa[sp.taxon] = true
+	}
+
+	txLs := make([]string, 0, len(taxa))
+	for t := range taxa {
+		txLs = append(txLs, t)
+	}
+	slices.Sort(txLs)
+	return txLs
+}
+
+// RenameTaxon changes the name of a taxon.
+// If the new name is already in the registry,
+// the specimens of the old taxon
+// will be merged into the new taxon.
+func (r *Registry) RenameTaxon(old, name string) {
+	old = names.Taxon(old)
+	name = names.Taxon(name)
+	if name == "" {
+		return
+	}
+
+	for _, sp := range r.specs {
+		if sp.taxon != old {
+			continue
+		}
+		sp.taxon = name
+	}
+}
+
+// TaxSpec returns the specimens of a given taxon.
+func (r *Registry) TaxSpec(name string) []string {
+	name = names.Taxon(name)
+	var specs []string
+	for _, sp := range r.specs {
+		if sp.taxon != name {
+			continue
+		}
+		specs = append(specs, sp.name)
+	}
+	slices.Sort(specs)
+	return specs
+}
+
+// Field is used to define additional information fields
+// of a specimen.
+type Field string
+
+// Additional specimen fields.
+const (
+	// Voucher is the catalog code of the specimen
+	// in a collection.
+	Voucher  Field = "voucher"
+	Comments Field = "comments"
+
+	// Geographic location of the specimen,
+	// latitude and longitude are in decimal degrees.
+	Latitude  Field = "latitude"
+	Longitude Field = "longitude"
+	Locality  Field = "locality"
+
+	// Collector is the name of the collector
+	// of the specimen,
+	// and Date is the collection date.
+	Collector Field = "collector"
+	Date      Field = "date"
+)
+
+// Set sets the value of an additional information
+// for a specimen.
+func (r *Registry) Set(spec, val string, field Field) {
+	sp, ok := r.specs[names.Specimen(spec)]
+	if !ok {
+		return
+	}
+
+	val = strings.Join(strings.Fields(val), " ")
+	switch field {
+	case Voucher:
+		sp.voucher = val
+	case Comments:
+		sp.comment = val
+	case Latitude:
+		sp.lat = parseCoord(val, 90)
+	case Longitude:
+		sp.lon = parseCoord(val, 180)
+	case Locality:
+		sp.locality = val
+	case Collector:
+		sp.collector = val
+	case Date:
+		sp.date = val
+	}
+}
+
+// Val returns the value of additional fields
+// for a specimen.
+func (r *Registry) Val(spec string, field Field) string {
+	sp, ok := r.specs[names.Specimen(spec)]
+	if !ok {
+		return ""
+	}
+
+	switch field {
+	case Voucher:
+		return sp.voucher
+	case Comments:
+		return sp.comment
+	case Latitude:
+		return sp.lat
+	case Longitude:
+		return sp.lon
+	case Locality:
+		return sp.locality
+	case Collector:
+		return sp.collector
+	case Date:
+		return sp.date
+	}
+	return ""
+}
+
+// Geo returns the geographic coordinates
+// (in decimal degrees)
+// of a specimen.
+// It returns false if the specimen
+// does not have valid coordinates.
+func (r *Registry) Geo(spec string) (lat, lon float64, ok bool) {
+	sp, ok := r.specs[names.Specimen(spec)]
+	if !ok {
+		return 0, 0, false
+	}
+	if sp.lat == "" || sp.lon == "" {
+		return 0, 0, false
+	}
+	lat, _ = strconv.ParseFloat(sp.lat, 64)
+	lon, _ = strconv.ParseFloat(sp.lon, 64)
+	return lat, lon, true
+}
+
+type specimen struct {
+	taxon     string
+	name      string
+	voucher   string
+	comment   string
+	lat       string
+	lon       string
+	locality  string
+	collector string
+	date      string
+}
+
+// ParseCoord returns the normalized value
+// of a geographic coordinate,
+// or an empty string
+// if the value is not a valid coordinate.
+func parseCoord(val string, max float64) string {
+	v, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return ""
+	}
+	if v < -max || v > max {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,120 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package specimen_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/phydata/specimen"
+)
+
+func TestRegistry(t *testing.T) {
+	r := newRegistry()
+
+	specs := []string{"fmnh_un_2485", "sp-01", "sp-02"}
+	if sp := r.Specimens(); !reflect.DeepEqual(sp, specs) {
+		t.Errorf("specimens: got %v, want %v", sp, specs)
+	}
+
+	taxa := []string{"Loxodonta africana", "Panthera tigris"}
+	if tx := r.Taxa(); !reflect.DeepEqual(tx, taxa) {
+		t.Errorf("taxa: got %v, want %v", tx, taxa)
+	}
+
+	if sp := r.TaxSpec("loxodonta africana"); !reflect.DeepEqual(sp, []string{"sp-01", "sp-02"}) {
+		t.Errorf("taxon specimens: got %v, want %v", sp, []string{"sp-01", "sp-02"})
+	}
+	if tx := r.Taxon("FMNH UN 2485"); tx != "Panthera tigris" {
+		t.Errorf("taxon: got %q, want %q", tx, "Panthera tigris")
+	}
+	if v := r.Val("fmnh_un_2485", specimen.Voucher); v != "FMNH 2485" {
+		t.Errorf("voucher: got %q, want %q", v, "FMNH 2485")
+	}
+
+	if lat, lon, ok := r.Geo("sp-01"); lat != -2.33 || lon != 34.83 || !ok {
+		t.Errorf("geo: got %.2f %.2f (%v), want -2.33 34.83 (true)", lat, lon, ok)
+	}
+	if _, _, ok := r.Geo("sp-02"); ok {
+		t.Errorf("geo: specimen %q without valid coordinates", "sp-02")
+	}
+}
+
+func TestRenameTaxon(t *testing.T) {
+	r := newRegistry()
+
+	r.RenameTaxon("Loxodonta africana", "Loxodonta cyclotis")
+	taxa := []string{"Loxodonta cyclotis", "Panthera tigris"}
+	if tx := r.Taxa(); !reflect.DeepEqual(tx, taxa) {
+		t.Errorf("taxa: got %v, want %v", tx, taxa)
+	}
+	if tx := r.Taxon("sp-02"); tx != "Loxodonta cyclotis" {
+		t.Errorf("taxon: got %q, want %q", tx, "Loxodonta cyclotis")
+	}
+}
+
+func TestTSV(t *testing.T) {
+	r := newRegistry()
+	var w bytes.Buffer
+	if err := r.TSV(&w); err != nil {
+		t.Fatalf("unable to write TSV data: %v", err)
+	}
+	t.Logf("output:\n%s\n", w.String())
+
+	got := specimen.New()
+	if err := got.ReadTSV(&w); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+	cmpRegistry(t, got, r)
+}
+
+func newRegistry() *specimen.Registry {
+	r := specimen.New()
+	r.Add("Panthera tigris", "FMNH_UN_2485")
+	r.Add("Loxodonta africana", "sp-01")
+	r.Add("Loxodonta africana", "sp-02")
+
+	r.Set("fmnh_un_2485", "FMNH 2485", specimen.Voucher)
+	r.Set("sp-01", "AMNH M-12345", specimen.Voucher)
+	r.Set("sp-01", "juvenile", specimen.Comments)
+	r.Set("sp-01", "-2.33", specimen.Latitude)
+	r.Set("sp-01", "34.83", specimen.Longitude)
+	r.Set("sp-01", "Serengeti, Tanzania", specimen.Locality)
+	r.Set("sp-01", "J. Smith", specimen.Collector)
+	r.Set("sp-01", "1990-05-12", specimen.Date)
+	r.Set("sp-02", "95", specimen.Latitude)
+	r.Set("sp-02", "34.83", specimen.Longitude)
+	return r
+}
+
+func cmpRegistry(t testing.TB, got, want *specimen.Registry) {
+	t.Helper()
+
+	specs := want.Specimens()
+	if sp := got.Specimens(); !reflect.DeepEqual(sp, specs) {
+		t.Errorf("specimens: got %v, want %v", sp, specs)
+	}
+
+	fields := []specimen.Field{
+		specimen.Voucher,
+		specimen.Latitude,
+		specimen.Longitude,
+		specimen.Locality,
+		specimen.Collector,
+		specimen.Date,
+		specimen.Comments,
+	}
+	for _, sp := range specs {
+		if tx := got.Taxon(sp); tx != want.Taxon(sp) {
+			t.Errorf("specimen %q: taxon: got %q, want %q", sp, tx, want.Taxon(sp))
+		}
+		for _, f := range fields {
+			if v := got.Val(sp, f); v != want.Val(sp, f) {
+				t.Errorf("specimen %q: field %q: got %q, want %q", sp, f, v, want.Val(sp, f))
+			}
+		}
+	}
+}
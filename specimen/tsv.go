@@ -0,0 +1,147 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package specimen
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var headerFields = []string{
+	"taxon",
+	"specimen",
+}
+
+var valFields = []Field{
+	Voucher,
+	Latitude,
+	Longitude,
+	Locality,
+	Collector,
+	Date,
+	Comments,
+}
+
+// ReadTSV reads a set of specimens
+// from a TSV file.
+//
+// The TSV file must contains the following fields:
+//
+//   - taxon, the taxonomic name of the specimen
+//   - specimen, the ID of the specimen
+//
+// Additional fields are:
+//
+//   - voucher, the catalog code of the specimen in a collection
+//   - latitude, the latitude of the collection site,
+//     in decimal degrees
+//   - longitude, the longitude of the collection site,
+//     in decimal degrees
+//   - locality, the description of the collection site
+//   - collector, the name of the collector
+//   - date, the collection date
+//   - comments, simple comments about the specimen
+//
+// Here is an example file:
+//
+//	# specimens
+//	taxon	specimen	voucher	latitude	longitude	locality	collector	date	comments
+//	Panthera tigris	fmnh_un_2485	FMNH 2485						adult male
+//	Loxodonta africana	sp-01	AMNH M-12345	-2.33	34.83	Serengeti, Tanzania	J. Smith	1990-05-12	juvenile
+func (r *Registry) ReadTSV(rd io.Reader) error {
+	tab := csv.NewReader(rd)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return fmt.Errorf("while reading header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(h)
+		fields[h] = i
+	}
+	for _, h := range headerFields {
+		if _, ok := fields[h]; !ok {
+			return fmt.Errorf("expecting field %q", h)
+		}
+	}
+
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		f := "taxon"
+		tax := row[fields[f]]
+		if tax == "" {
+			continue
+		}
+
+		f = "specimen"
+		spec := row[fields[f]]
+		if spec == "" {
+			continue
+		}
+		r.Add(tax, spec)
+
+		for _, ff := range valFields {
+			f = string(ff)
+			i, ok := fields[f]
+			if !ok {
+				continue
+			}
+			r.Set(spec, row[i], ff)
+		}
+	}
+	return nil
+}
+
+// TSV writes a specimen registry as a TSV file.
+func (r *Registry) TSV(w io.Writer) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	header := []string{"taxon", "specimen", "voucher", "latitude", "longitude", "locality", "collector", "date", "comments"}
+	if err := tab.Write(header); err != nil {
+		return fmt.Errorf("unable to write header: %v", err)
+	}
+
+	for _, tax := range r.Taxa() {
+		for _, spec := range r.TaxSpec(tax) {
+			sp := r.specs[spec]
+			row := []string{
+				sp.taxon,
+				sp.name,
+				sp.voucher,
+				sp.lat,
+				sp.lon,
+				sp.locality,
+				sp.collector,
+				sp.date,
+				sp.comment,
+			}
+			if err := tab.Write(row); err != nil {
+				return fmt.Errorf("while writing data: %v", err)
+			}
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package taxonomy implements a collection of taxon records
+// with identifiers of external taxonomic databases.
+package taxonomy
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/phydata/names"
+)
+
+// A Taxonomy is a collection of taxon records.
+type Taxonomy struct {
+	taxa map[string]*taxon
+}
+
+// New creates a new empty taxonomy.
+func New() *Taxonomy {
+	return &Taxonomy{
+		taxa: make(map[string]*taxon),
+	}
+}
+
+// Add adds a taxon to the taxonomy.
+// If the taxon is already in the taxonomy,
+// it will do nothing.
+func (t *Taxonomy) Add(name string) {
+	name = names.Taxon(name)
+	if name == "" {
+		return
+	}
+	if _, ok := t.taxa[name]; ok {
+		return
+	}
+	t.taxa[name] = &taxon{
+		name: name,
+	}
+}
+
+// Delete removes a taxon from the taxonomy.
+func (t *Taxonomy) Delete(name string) {
+	delete(t.taxa, names.Taxon(name))
+}
+
+// Taxa returns the taxa defined in the taxonomy.
+func (t *Taxonomy) Taxa() []string {
+	txLs := make([]string, 0, len(t.taxa))
+	for _, tx := range t.taxa {
+		txLs = append(txLs, tx.name)
+	}
+	slices.Sort(txLs)
+	return txLs
+}
+
+// RenameTaxon changes the name of a taxon.
+// If the new name is already in the taxonomy,
+// the undefined identifiers of the new taxon
+// will be filled with the identifiers
+// of the old taxon.
+func (t *Taxonomy) RenameTaxon(old, name string) {
+	old = names.Taxon(old)
+	name = names.Taxon(name)
+	if name == "" || name == old {
+		return
+	}
+
+	tx, ok := t.taxa[old]
+	if !ok {
+		return
+	}
+	delete(t.taxa, old)
+
+	nt, ok := t.taxa[name]
+	if !ok {
+		tx.name = name
+		t.taxa[name] = tx
+		return
+	}
+	if nt.ott == "" {
+		nt.ott = tx.ott
+	}
+	if nt.ncbi == "" {
+		nt.ncbi = tx.ncbi
+	}
+	if nt.gbif == "" {
+		nt.gbif = tx.gbif
+	}
+	if nt.comment == "" {
+		nt.comment = tx.comment
+	}
+}
+
+// Field is used to define additional information fields
+// of a taxon.
+type Field string
+
+// Additional taxon fields.
+const (
+	// OTT is the taxon ID
+	// in the Open Tree of Life taxonomy.
+	OTT Field = "ott"
+
+	// NCBI is the taxon ID
+	// in the NCBI taxonomy.
+	NCBI Field = "ncbi"
+
+	// GBIF is the taxon key
+	// in the GBIF backbone taxonomy.
+	GBIF Field = "gbif"
+
+	Comments Field = "comments"
+)
+
+// Set sets the value of an additional information
+// for a taxon.
+// External identifiers must be positive integers,
+// otherwise the identifier will be removed.
+func (t *Taxonomy) Set(name, val string, field Field) {
+	tx, ok := t.taxa[names.Taxon(name)]
+	if !ok {
+		return
+	}
+
+	val = strings.Join(strings.Fields(val), " ")
+	switch field {
+	case OTT:
+		val = strings.TrimPrefix(strings.ToLower(val), "ott")
+		tx.ott = parseID(val)
+	case NCBI:
+		tx.ncbi = parseID(val)
+	case GBIF:
+		tx.gbif = parseID(val)
+	case Comments:
+		tx.comment = val
+	}
+}
+
+// Val returns the value of additional fields
+// for a taxon.
+func (t *Taxonomy) Val(name string, field Field) string {
+	tx, ok := t.taxa[names.Taxon(name)]
+	if !ok {
+		return ""
+	}
+
+	switch field {
+	case OTT:
+		return tx.ott
+	case NCBI:
+		return tx.ncbi
+	case GBIF:
+		return tx.gbif
+	case Comments:
+		return tx.comment
+	}
+	return ""
+}
+
+type taxon struct {
+	name    string
+	ott     string
+	ncbi    string
+	gbif    string
+	comment string
+}
+
+// ParseID returns the normalized value
+// of an external identifier,
+// or an empty string
+// if the value is not a valid identifier.
+func parseID(val string) string {
+	id, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || id <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,107 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package taxonomy_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/phydata/taxonomy"
+)
+
+func TestTaxonomy(t *testing.T) {
+	tx := newTaxonomy()
+
+	taxa := []string{"Ascaphus truei", "Bufo bufo", "Rhinophrynus dorsalis"}
+	if ls := tx.Taxa(); !reflect.DeepEqual(ls, taxa) {
+		t.Errorf("taxa: got %v, want %v", ls, taxa)
+	}
+
+	tests := map[string]struct {
+		name  string
+		field taxonomy.Field
+		want  string
+	}{
+		"ott":           {"ascaphus truei", taxonomy.OTT, "1010778"},
+		"ott prefix":    {"Bufo bufo", taxonomy.OTT, "1039923"},
+		"ncbi":          {"Ascaphus truei", taxonomy.NCBI, "8439"},
+		"gbif":          {"Rhinophrynus dorsalis", taxonomy.GBIF, "2422769"},
+		"invalid":       {"Bufo bufo", taxonomy.NCBI, ""},
+		"comments":      {"Rhinophrynus dorsalis", taxonomy.Comments, "Mexican burrowing toad"},
+		"undefined":     {"Rhinophrynus dorsalis", taxonomy.OTT, ""},
+		"unknown taxon": {"Pipa pipa", taxonomy.GBIF, ""},
+	}
+	for name, test := range tests {
+		if v := tx.Val(test.name, test.field); v != test.want {
+			t.Errorf("%s: got %q, want %q", name, v, test.want)
+		}
+	}
+}
+
+func TestRenameTaxon(t *testing.T) {
+	tx := newTaxonomy()
+	tx.Add("Rhinophrynus")
+	tx.Set("Rhinophrynus", "1010001", taxonomy.OTT)
+
+	tx.RenameTaxon("Rhinophrynus dorsalis", "Rhinophrynus")
+	taxa := []string{"Ascaphus truei", "Bufo bufo", "Rhinophrynus"}
+	if ls := tx.Taxa(); !reflect.DeepEqual(ls, taxa) {
+		t.Errorf("taxa: got %v, want %v", ls, taxa)
+	}
+	if v := tx.Val("Rhinophrynus", taxonomy.OTT); v != "1010001" {
+		t.Errorf("ott: got %q, want %q", v, "1010001")
+	}
+	if v := tx.Val("Rhinophrynus", taxonomy.GBIF); v != "2422769" {
+		t.Errorf("gbif: got %q, want %q", v, "2422769")
+	}
+
+	tx.RenameTaxon("Bufo bufo", "Bufo spinosus")
+	if v := tx.Val("Bufo spinosus", taxonomy.OTT); v != "1039923" {
+		t.Errorf("ott: got %q, want %q", v, "1039923")
+	}
+}
+
+func TestTSV(t *testing.T) {
+	tx := newTaxonomy()
+	var w bytes.Buffer
+	if err := tx.TSV(&w); err != nil {
+		t.Fatalf("unable to write TSV data: %v", err)
+	}
+	t.Logf("output:\n%s\n", w.String())
+
+	got := taxonomy.New()
+	if err := got.ReadTSV(&w); err != nil {
+		t.Fatalf("unable to read TSV data: %v", err)
+	}
+
+	if ls := got.Taxa(); !reflect.DeepEqual(ls, tx.Taxa()) {
+		t.Errorf("taxa: got %v, want %v", ls, tx.Taxa())
+	}
+	fields := []taxonomy.Field{taxonomy.OTT, taxonomy.NCBI, taxonomy.GBIF, taxonomy.Comments}
+	for _, name := range tx.Taxa() {
+		for _, f := range fields {
+			if v := got.Val(name, f); v != tx.Val(name, f) {
+				t.Errorf("taxon %q: %s: got %q, want %q", name, f, v, tx.Val(name, f))
+			}
+		}
+	}
+}
+
+func newTaxonomy() *taxonomy.Taxonomy {
+	tx := taxonomy.New()
+	tx.Add("Ascaphus truei")
+	tx.Add("Bufo bufo")
+	tx.Add("Rhinophrynus dorsalis")
+
+	tx.Set("Ascaphus truei", "1010778", taxonomy.OTT)
+	tx.Set("Ascaphus truei", "8439", taxonomy.NCBI)
+	tx.Set("Ascaphus truei", "2426861", taxonomy.GBIF)
+	tx.Set("Bufo bufo", "ott1039923", taxonomy.OTT)
+	tx.Set("Bufo bufo", "txid8384", taxonomy.NCBI)
+	tx.Set("Rhinophrynus dorsalis", "2422769", taxonomy.GBIF)
+	tx.Set("Rhinophrynus dorsalis", "Mexican burrowing toad", taxonomy.Comments)
+	return tx
+}
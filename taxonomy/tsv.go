@@ -0,0 +1,125 @@
+// Copyright © 2024 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package taxonomy
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var headerFields = []string{
+	"taxon",
+}
+
+var valFields = []Field{
+	OTT,
+	NCBI,
+	GBIF,
+	Comments,
+}
+
+// ReadTSV reads a taxonomy
+// from a TSV file.
+//
+// The TSV file must contains the following fields:
+//
+//   - taxon, the taxonomic name
+//
+// Additional fields are:
+//
+//   - ott, the ID of the taxon in the Open Tree of Life taxonomy
+//   - ncbi, the ID of the taxon in the NCBI taxonomy
+//   - gbif, the key of the taxon in the GBIF backbone taxonomy
+//   - comments, simple comments about the taxon
+//
+// Here is an example file:
+//
+//	# taxonomy
+//	taxon	ott	ncbi	gbif	comments
+//	Ascaphus truei	1010778	8439	2426861
+//	Rhinophrynus dorsalis		8361	2422769	Mexican burrowing toad
+func (t *Taxonomy) ReadTSV(r io.Reader) error {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return fmt.Errorf("while reading header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(h)
+		fields[h] = i
+	}
+	for _, h := range headerFields {
+		if _, ok := fields[h]; !ok {
+			return fmt.Errorf("expecting field %q", h)
+		}
+	}
+
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		f := "taxon"
+		tax := row[fields[f]]
+		if tax == "" {
+			continue
+		}
+		t.Add(tax)
+
+		for _, ff := range valFields {
+			f = string(ff)
+			i, ok := fields[f]
+			if !ok {
+				continue
+			}
+			t.Set(tax, row[i], ff)
+		}
+	}
+	return nil
+}
+
+// TSV writes a taxonomy as a TSV file.
+func (t *Taxonomy) TSV(w io.Writer) error {
+	tab := csv.NewWriter(w)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	header := []string{"taxon", "ott", "ncbi", "gbif", "comments"}
+	if err := tab.Write(header); err != nil {
+		return fmt.Errorf("unable to write header: %v", err)
+	}
+
+	for _, name := range t.Taxa() {
+		tx := t.taxa[name]
+		row := []string{
+			tx.name,
+			tx.ott,
+			tx.ncbi,
+			tx.gbif,
+			tx.comment,
+		}
+		if err := tab.Write(row); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}